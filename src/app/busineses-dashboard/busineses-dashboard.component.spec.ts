@@ -47,7 +47,7 @@ describe('BusinesesDashboardComponent', () => {
   });
 
   it('Completes GET request to fetch list of services from db', () => {
-    const req = httpTestController.expectOne("http://localhost:8080/services");
+    const req = httpTestController.expectOne("http://localhost:8080/api/v1/services");
     expect(req.request.method).toEqual("GET");
   });
 
@@ -0,0 +1,10 @@
+export class OutstandingBalance {
+    constructor(
+        public currency : string,
+        public amount : number,
+        public overdue_amount : number,
+        public invoice_count : number,
+        public display? : {locale : string, amount : string, overdue_amount : string}
+
+    ) {}
+}
@@ -2,6 +2,7 @@ import { ComponentFixture, TestBed } from '@angular/core/testing';
 
 import { Router } from '@angular/router';
 import { RouterTestingModule } from '@angular/router/testing';
+import { HttpClientTestingModule } from '@angular/common/http/testing';
 import { ProfileComponent } from './profile.component';
 import { NavbarComponent } from '../navbar/navbar.component';
 import { User } from '../user';
@@ -18,7 +19,8 @@ describe('ProfileComponent', () => {
       declarations: [ ProfileComponent, NavbarComponent ],
       
       imports: [
-        RouterTestingModule
+        RouterTestingModule,
+        HttpClientTestingModule
       ],
       
     })
@@ -67,6 +69,13 @@ describe('ProfileComponent', () => {
 
   });
 
+  it('should show that nothing is owed', () => {
+
+    const compiled = fixture.nativeElement as HTMLElement;
+    expect(compiled.querySelector('#no-balance')?.textContent).toContain('all paid up');
+
+  });
+
   it('should give the testUser\'s first name', () => {
 
     component.ngOnInit();
@@ -1,6 +1,8 @@
 import { Component } from '@angular/core';
 import { User } from '../user';
 import { Router, ActivatedRoute } from '@angular/router';
+import { UserService } from '../user.service';
+import { OutstandingBalance } from '../outstanding-balance';
 
 @Component({
   selector: 'app-profile',
@@ -11,8 +13,9 @@ export class ProfileComponent {
 
     userIdParameter : string = "";
     isBusiness = false;
+    balances : OutstandingBalance[] = [];
 
-    constructor(private router: Router, private route: ActivatedRoute){}
+    constructor(private router: Router, private route: ActivatedRoute, private userService: UserService){}
 
     ngOnInit()
     {
@@ -22,10 +25,17 @@ export class ProfileComponent {
           this.userIdParameter = history.state.user.first_name;
           if(history.state.user.account_type === "Business")
             this.isBusiness = true;
+          else if(history.state.user.ID)
+            this.getBalance(history.state.user.ID);
         }
         else
           this.userIdParameter = "ERROR: userIdParameter is null; no user was passed!";
     }
+    getBalance(userId: string) {
+      this.userService.getUserBalance(userId)
+        .then((result) => this.balances = result.balances)
+        .catch((reason) => console.log(reason));
+    }
     routeToHome() {
       this.router.navigate(['/']);
     }
@@ -74,7 +74,7 @@ describe('ServicePageComponent', () => {
     component.joinClass();
     expect(component.userJoined).toBeTruthy();
 
-    const req = httpTestController.expectOne("http://localhost:8080/appointment");
+    const req = httpTestController.expectOne("http://localhost:8080/api/v1/appointment");
     expect(req.request.method).toEqual("POST");
     
 
@@ -96,7 +96,7 @@ describe('ServicePageComponent', () => {
     component.joinClass();
     expect(component.userJoined).toBeTruthy();
 
-    const req = httpTestController.expectOne("http://localhost:8080/appointment");
+    const req = httpTestController.expectOne("http://localhost:8080/api/v1/appointment");
     expect(req.request.method).toEqual("POST");
 
     component.leaveClass();
@@ -112,7 +112,7 @@ describe('ServicePageComponent', () => {
 
     component.saveEdit();
 
-    const req = httpTestController.expectOne("http://localhost:8080/service/"+service.ID);
+    const req = httpTestController.expectOne("http://localhost:8080/api/v1/service/"+service.ID);
     expect(req.request.method).toEqual("PUT");
 
   });
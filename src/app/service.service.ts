@@ -4,14 +4,22 @@ import {Service} from './service';
 import {FormGroup} from "@angular/forms";
 import { User } from './user';
 
+// Page of the list of services returned by the API
+interface ServicePage {
+  data: Service[];
+  total_count: number;
+  limit: number;
+  next_cursor: string;
+}
+
 @Injectable({
   providedIn: 'root'
 })
 export class ServiceService {
   constructor(private http: HttpClient) { }
 
-  private apiUrl = 'http://localhost:8080/service';
-  private getAllServices = 'http://localhost:8080/services';
+  private apiUrl = 'http://localhost:8080/api/v1/service';
+  private getAllServices = 'http://localhost:8080/api/v1/services';
 
   // Adds service to DB with specified properties
   addService(service: FormGroup) : Promise<Service>{
@@ -24,9 +32,16 @@ export class ServiceService {
     return this.http.get<Service>(this.apiUrl+'/'+ID).toPromise().then();
   }
 
-  // Gets all services in db
-  getServices() : Promise<Service[]>{
-    return this.http.get<Service[]>(this.getAllServices).toPromise().then();
+  // Gets all services in db, following the cursor of each page of the list
+  async getServices() : Promise<Service[]>{
+    let services: Service[] = [];
+    let url = this.getAllServices;
+    while (url) {
+      const page = await this.http.get<ServicePage>(url).toPromise();
+      services = services.concat(page?.data ?? []);
+      url = page?.next_cursor ? this.getAllServices+'?cursor='+encodeURIComponent(page.next_cursor) : '';
+    }
+    return services;
   }
 
   //update a particular service id based on given information
@@ -3,6 +3,7 @@ import { HttpClient } from '@angular/common/http';
 import { Appointment } from './appointment';
 import {User} from './user';
 import { ServiceAppointment } from './service-appointment';
+import { OutstandingBalance } from './outstanding-balance';
 
 @Injectable({
   providedIn: 'root'
@@ -11,9 +12,9 @@ export class UserService {
 
   constructor(private http: HttpClient) { }
 
-  private apiUrl = 'http://localhost:8080/register';
-  private getUserURL = 'http://localhost:8080/user/';
-  private apptUrl = 'http://localhost:8080/appointment';
+  private apiUrl = 'http://localhost:8080/api/v1/register';
+  private getUserURL = 'http://localhost:8080/api/v1/user/';
+  private apptUrl = 'http://localhost:8080/api/v1/appointment';
 
   addUser(firstName: string, lastName: string, email: string, password: string, accountType: string) : Promise<any | User>{
     return this.http.post<User>(this.apiUrl, {
@@ -24,7 +25,7 @@ export class UserService {
   //to access user obj in other code (console.log example)
   // call login.then( (user) => { console.log(user); });
   login(email: string, password: string) : Promise<void | User> {
-    return this.http.post<User>('http://localhost:8080/login', {
+    return this.http.post<User>('http://localhost:8080/api/v1/login', {
       email, password
     }).toPromise().then();
   }
@@ -53,4 +54,9 @@ export class UserService {
     return this.http.get<ServiceAppointment[]>(this.getUserURL+user_id+'/service-appointments').toPromise().then();
   }
 
+  getUserBalance(user_id: string) : Promise<{user_id: number, balances: OutstandingBalance[]}>
+  {
+    return this.http.get<{user_id: number, balances: OutstandingBalance[]}>(this.getUserURL+user_id+'/balance').toPromise().then();
+  }
+
 }
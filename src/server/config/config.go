@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -22,22 +23,32 @@ var PWHashCost int = 8
 
 // struct to map env values
 type Configuration struct {
-	JWT_SIGNING_KEY   []byte `mapstructure:"JWT_SIGNING_KEY"`
-	APP_DB_NAME       string `mapstructure:"APP_DB_NAME"`
-	APP_TEST_DB_NAME  string `mapstructure:"APP_TEST_DB_NAME"`
-	APP_DB_USER       string `mapstructure:"APP_DB_USER"`
-	APP_DB_PASSWORD   string `mapstructure:"APP_DB_PASSWORD"`
-	APP_DB_HOST       string `mapstructure:"APP_DB_HOST"`
-	APP_DB_PORT       int    `mapstructure:"APP_DB_PORT"`
-	APP_CACHE_DB_HOST string `mapstructure:"APP_CACHE_DB_HOST"`
-	APP_CACHE_DB_PORT int    `mapstructure:"APP_CACHE_DB_PORT"`
-	API_SERVER_HOST   string `mapstructure:"API_SERVER_HOST"`
-	API_SERVER_PORT   int    `mapstructure:"API_SERVER_PORT"`
-	FRONTEND_HOST     string `mapstructure:"FRONTEND_HOST"`
-	FRONTEND_PORT     int    `mapstructure:"FRONTEND_PORT"`
-	DEBUG_MODE        bool   `mapstructure:"DEBUG_MODE"`
-	FORMAT_DB_ON_INIT bool   `mapstructure:"FORMAT_DB_ON_INIT"`
-	LOAD_TEST_RECORDS bool   `mapstructure:"LOAD_TEST_RECORDS"`
+	JWT_SIGNING_KEY                 []byte `mapstructure:"JWT_SIGNING_KEY"`
+	APP_DB_NAME                     string `mapstructure:"APP_DB_NAME"`
+	APP_TEST_DB_NAME                string `mapstructure:"APP_TEST_DB_NAME"`
+	APP_DB_USER                     string `mapstructure:"APP_DB_USER"`
+	APP_DB_PASSWORD                 string `mapstructure:"APP_DB_PASSWORD"`
+	APP_DB_HOST                     string `mapstructure:"APP_DB_HOST"`
+	APP_DB_PORT                     int    `mapstructure:"APP_DB_PORT"`
+	APP_CACHE_DB_HOST               string `mapstructure:"APP_CACHE_DB_HOST"`
+	APP_CACHE_DB_PORT               int    `mapstructure:"APP_CACHE_DB_PORT"`
+	API_SERVER_HOST                 string `mapstructure:"API_SERVER_HOST"`
+	API_SERVER_PORT                 int    `mapstructure:"API_SERVER_PORT"`
+	FRONTEND_HOST                   string `mapstructure:"FRONTEND_HOST"`
+	FRONTEND_PORT                   int    `mapstructure:"FRONTEND_PORT"`
+	DEBUG_MODE                      bool   `mapstructure:"DEBUG_MODE"`
+	FORMAT_DB_ON_INIT               bool   `mapstructure:"FORMAT_DB_ON_INIT"`
+	LOAD_TEST_RECORDS               bool   `mapstructure:"LOAD_TEST_RECORDS"`
+	PAYMENT_PROVIDER_API_URL        string `mapstructure:"PAYMENT_PROVIDER_API_URL"`
+	PAYMENT_PROVIDER_SECRET_KEY     string `mapstructure:"PAYMENT_PROVIDER_SECRET_KEY"`
+	PAYMENT_PROVIDER_WEBHOOK_SECRET string `mapstructure:"PAYMENT_PROVIDER_WEBHOOK_SECRET"`
+	NOTIFIER_SMTP_HOST              string `mapstructure:"NOTIFIER_SMTP_HOST"`
+	NOTIFIER_SMTP_PORT              int    `mapstructure:"NOTIFIER_SMTP_PORT"`
+	NOTIFIER_SMTP_USERNAME          string `mapstructure:"NOTIFIER_SMTP_USERNAME"`
+	NOTIFIER_SMTP_PASSWORD          string `mapstructure:"NOTIFIER_SMTP_PASSWORD"`
+	NOTIFIER_FROM_ADDRESS           string `mapstructure:"NOTIFIER_FROM_ADDRESS"`
+	GEOCODING_ZIP_CENTROIDS_FILE    string `mapstructure:"GEOCODING_ZIP_CENTROIDS_FILE"`
+	LEGACY_API_ROUTES_SUNSET        string `mapstructure:"LEGACY_API_ROUTES_SUNSET"`
 }
 
 // Initialize method creates and initializes new Configuration object
@@ -101,6 +112,15 @@ func (config *Configuration) GetFrontendNetworkAddress() string {
 		config.FRONTEND_PORT)
 }
 
+// GetLegacyAPIRoutesSunset returns the date (LEGACY_API_ROUTES_SUNSET, in "YYYY-MM-DD" format) after which the unversioned API routes may be removed, or the zero time if no date is set
+func (config *Configuration) GetLegacyAPIRoutesSunset() (time.Time, error) {
+	if config.LEGACY_API_ROUTES_SUNSET == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse("2006-01-02", config.LEGACY_API_ROUTES_SUNSET)
+}
+
 // getNetworkAddress takes in host address and port number and returns the network address (a.k.a. DSN) in "host:port" string format
 func getNetworkAddress(host string, port int) string {
 	var networkAddress string = fmt.Sprintf("%s:%d",
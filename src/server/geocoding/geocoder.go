@@ -0,0 +1,145 @@
+package geocoding
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Interface for services that find the coordinates of a postal address
+//
+// Geocoders only look up coordinates. Storing them (and deciding when an address needs to be geocoded again) is left to the caller.
+type Geocoder interface {
+	Name() string                                   // Name of the geocoder, for logging
+	Geocode(location Location) (Coordinates, error) // Finds the coordinates of a location
+}
+
+// A postal address to geocode. Geocoders may use only part of it (e.g. only the zip code)
+type Location struct {
+	Address1 string // Address line 1
+	Address2 string // Address line 2
+	City     string // City
+	State    string // State (2 letter abbreviation)
+	ZipCode  string // Zip code (5 digit or ZIP+4)
+}
+
+// A point on the Earth's surface, in degrees
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`  // Degrees north of the equator (negative for south)
+	Longitude float64 `json:"longitude"` // Degrees east of the prime meridian (negative for west)
+}
+
+// Errors returned by geocoders
+var (
+	ErrInvalidLocation  = errors.New("invalid location")
+	ErrLocationNotFound = errors.New("location could not be geocoded")
+)
+
+// Mean radius of the Earth (in miles)
+const EarthRadiusMiles float64 = 3958.8
+
+/*
+*Description*
+
+func Validate
+
+Confirms that the calling Coordinates are a point on the Earth's surface (latitude between -90 and 90 degrees and longitude between
+-180 and 180 degrees).
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidLocation' if the coordinates are out of range (nil otherwise)
+*/
+func (coordinates Coordinates) Validate() error {
+	if math.IsNaN(coordinates.Latitude) || coordinates.Latitude < -90 || coordinates.Latitude > 90 {
+		return fmt.Errorf("%w: latitude must be between -90 and 90 degrees", ErrInvalidLocation)
+	}
+
+	if math.IsNaN(coordinates.Longitude) || coordinates.Longitude < -180 || coordinates.Longitude > 180 {
+		return fmt.Errorf("%w: longitude must be between -180 and 180 degrees", ErrInvalidLocation)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func DistanceMiles
+
+Returns the great-circle distance between the calling Coordinates and another point, using the haversine formula.
+
+*Parameters*
+
+	other  <Coordinates>
+
+		The other point.
+
+*Returns*
+
+	_  <float64>
+
+		The distance between the points (in miles).
+*/
+func (coordinates Coordinates) DistanceMiles(other Coordinates) float64 {
+	toRadians := func(degrees float64) float64 {
+		return degrees * math.Pi / 180
+	}
+
+	latitudeDelta := toRadians(other.Latitude - coordinates.Latitude)
+	longitudeDelta := toRadians(other.Longitude - coordinates.Longitude)
+
+	a := math.Pow(math.Sin(latitudeDelta/2), 2) +
+		math.Cos(toRadians(coordinates.Latitude))*math.Cos(toRadians(other.Latitude))*math.Pow(math.Sin(longitudeDelta/2), 2)
+
+	return 2 * EarthRadiusMiles * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+/*
+*Description*
+
+func normalizeZipCode
+
+Returns the 5 digit zip code of a location's zip code (e.g. "32601" for "32601-1234").
+
+*Parameters*
+
+	zipCode  <string>
+
+		The zip code.
+
+*Returns*
+
+	_  <string>
+
+		The 5 digit zip code.
+
+	_  <error>
+
+		'ErrInvalidLocation' if the zip code doesn't start with 5 digits (nil otherwise)
+*/
+func normalizeZipCode(zipCode string) (string, error) {
+	zipCode = strings.TrimSpace(zipCode)
+	if len(zipCode) < 5 {
+		return "", fmt.Errorf("%w: '%s' is not a zip code", ErrInvalidLocation, zipCode)
+	}
+
+	for _, character := range zipCode[:5] {
+		if character < '0' || character > '9' {
+			return "", fmt.Errorf("%w: '%s' is not a zip code", ErrInvalidLocation, zipCode)
+		}
+	}
+
+	if len(zipCode) > 5 && zipCode[5] != '-' && zipCode[5] != ' ' {
+		return "", fmt.Errorf("%w: '%s' is not a zip code", ErrInvalidLocation, zipCode)
+	}
+
+	return zipCode[:5], nil
+}
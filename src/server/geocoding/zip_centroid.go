@@ -0,0 +1,252 @@
+package geocoding
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Zip code centroids bundled with the server. The bundled file only covers a sample of zip codes (see 'NewZipCentroidGeocoder')
+//
+//go:embed data/zip_centroids.csv
+var bundledZipCentroids []byte
+
+// Header names accepted for each column of a zip centroid file (compared in lower case). The "geoid", "intptlat" and "intptlong"
+// names are those of the US Census Bureau's ZCTA Gazetteer file, so it can be loaded as published
+var (
+	zipColumnNames       []string = []string{"zip", "zip_code", "zcta", "zcta5", "geoid"}
+	latitudeColumnNames  []string = []string{"latitude", "lat", "intptlat"}
+	longitudeColumnNames []string = []string{"longitude", "lng", "lon", "intptlong"}
+)
+
+// Geocoder that places an address at the centroid of its zip code, from a dataset loaded into memory
+//
+// It works offline and never fails on a network error, at the cost of precision: every address in a zip code gets the same coordinates.
+type ZipCentroidGeocoder struct {
+	centroids map[string]Coordinates // Centroid of each 5 digit zip code
+}
+
+/*
+*Description*
+
+func NewZipCentroidGeocoder
+
+Creates a ZipCentroidGeocoder from a zip centroid file. If no file is given, the centroids bundled with the server are used. These only
+cover a sample of zip codes (e.g. for development and tests), so production servers should be given a complete dataset such as the
+US Census Bureau's ZCTA Gazetteer file.
+
+*Parameters*
+
+	path  <string>
+
+		Path of a comma or tab separated file with a header row and zip code, latitude and longitude columns (empty for the bundled
+		centroids).
+
+*Returns*
+
+	_  <*ZipCentroidGeocoder>
+
+		The geocoder.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func NewZipCentroidGeocoder(path string) (*ZipCentroidGeocoder, error) {
+	if path == "" {
+		return LoadZipCentroids(bytes.NewReader(bundledZipCentroids))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return LoadZipCentroids(file)
+}
+
+/*
+*Description*
+
+func LoadZipCentroids
+
+Creates a ZipCentroidGeocoder from zip centroid data. The data is comma or tab separated (detected from the header row), and its columns
+are found by name, so extra columns are ignored. Rows whose zip code or coordinates can't be parsed are rejected.
+
+*Parameters*
+
+	reader  <io.Reader>
+
+		The zip centroid data.
+
+*Returns*
+
+	_  <*ZipCentroidGeocoder>
+
+		The geocoder.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func LoadZipCentroids(reader io.Reader) (*ZipCentroidGeocoder, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := strings.Cut(string(data), "\n")
+	if strings.Contains(firstLine, "\t") {
+		csvReader.Comma = '\t'
+	}
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("zip centroid data has no header row: %w", err)
+	}
+
+	findColumn := func(names []string) int {
+		for i, column := range header {
+			column = strings.ToLower(strings.TrimSpace(column))
+			for _, name := range names {
+				if column == name {
+					return i
+				}
+			}
+		}
+
+		return -1
+	}
+
+	zipColumn, latitudeColumn, longitudeColumn := findColumn(zipColumnNames), findColumn(latitudeColumnNames), findColumn(longitudeColumnNames)
+	if zipColumn < 0 || latitudeColumn < 0 || longitudeColumn < 0 {
+		return nil, fmt.Errorf("zip centroid data must have zip code, latitude and longitude columns (found %s)", strings.Join(header, ", "))
+	}
+
+	geocoder := &ZipCentroidGeocoder{centroids: map[string]Coordinates{}}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := csvReader.FieldPos(0)
+
+		zipCode, err := normalizeZipCode(record[zipColumn])
+		if err != nil {
+			return nil, fmt.Errorf("line %d of zip centroid data: %w", line, err)
+		}
+
+		var coordinates Coordinates
+		coordinates.Latitude, err = strconv.ParseFloat(strings.TrimSpace(record[latitudeColumn]), 64)
+		if err == nil {
+			coordinates.Longitude, err = strconv.ParseFloat(strings.TrimSpace(record[longitudeColumn]), 64)
+		}
+
+		if err == nil {
+			err = coordinates.Validate()
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d of zip centroid data: %w", line, err)
+		}
+
+		geocoder.centroids[zipCode] = coordinates
+	}
+
+	return geocoder, nil
+}
+
+/*
+*Description*
+
+func Name
+
+Returns the name of the geocoder.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		"Zip Centroid"
+*/
+func (geocoder *ZipCentroidGeocoder) Name() string {
+	return "Zip Centroid"
+}
+
+/*
+*Description*
+
+func Geocode
+
+Finds the coordinates of a location from its zip code (the rest of the address is ignored). ZIP+4 codes are looked up by their first
+5 digits.
+
+*Parameters*
+
+	location  <Location>
+
+		The location to geocode.
+
+*Returns*
+
+	_  <Coordinates>
+
+		The centroid of the location's zip code.
+
+	_  <error>
+
+		'ErrInvalidLocation' if the location has no valid zip code, or 'ErrLocationNotFound' if the zip code isn't in the dataset (nil
+		otherwise)
+*/
+func (geocoder *ZipCentroidGeocoder) Geocode(location Location) (Coordinates, error) {
+	zipCode, err := normalizeZipCode(location.ZipCode)
+	if err != nil {
+		return Coordinates{}, err
+	}
+
+	coordinates, found := geocoder.centroids[zipCode]
+	if !found {
+		return Coordinates{}, fmt.Errorf("%w: zip code %s is not in the zip centroid dataset", ErrLocationNotFound, zipCode)
+	}
+
+	return coordinates, nil
+}
+
+/*
+*Description*
+
+func Count
+
+Returns the number of zip codes that the geocoder has centroids for.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <int>
+
+		The number of zip codes.
+*/
+func (geocoder *ZipCentroidGeocoder) Count() int {
+	return len(geocoder.centroids)
+}
@@ -6,6 +6,7 @@ require (
 	github.com/go-redis/redis/v7 v7.4.1
 	github.com/gorilla/mux v1.8.0
 	github.com/gorilla/sessions v1.2.1
+	github.com/jackc/pgx/v5 v5.2.0
 	github.com/rs/cors v1.8.3
 	github.com/spf13/viper v1.15.0
 	github.com/stretchr/testify v1.8.1
@@ -24,7 +25,6 @@ require (
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
-	github.com/jackc/pgx/v5 v5.2.0 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/magiconair/properties v1.8.7 // indirect
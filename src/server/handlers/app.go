@@ -5,8 +5,12 @@ import (
 	"log"
 	"net/http"
 	"server/config"
+	"server/geocoding"
 	"server/middleware"
 	"server/models"
+	"server/notifications"
+	"server/payments"
+	"server/utils"
 
 	//"github.com/go-redis/redis/v7"
 	"github.com/gorilla/mux"
@@ -17,6 +21,15 @@ import (
 
 var App *Application = &Application{}
 
+// Path prefix of every API route, and of the routes of version 1 of the API
+const (
+	apiPrefix   string = "/api/"
+	APIV1Prefix string = "/api/v1"
+)
+
+// Name of the catch-all route that proxies the Angular frontend (every other route is part of the API)
+const FrontendRouteName string = "frontend"
+
 /*
 *Description*
 
@@ -29,7 +42,10 @@ type Application struct {
 	CookieStore *sessions.CookieStore // Gorilla Sessions CookieStore for storing session/cookie data
 	AppDB       *gorm.DB              // gorm.DB instance used as main application database
 	// CacheDB     *redis.Client         // redis.Client instance used for caching database
-	NGHandler *AngularHandler // AngularHandler that allows the frontend to connect to the backend API server
+	NGHandler       *AngularHandler          // AngularHandler that allows the frontend to connect to the backend API server
+	PaymentProvider payments.PaymentProvider // Online payment provider used to collect and refund invoice payments
+	Notifier        notifications.Notifier   // Delivers messages to users (e.g. invoice reminders)
+	Geocoder        geocoding.Geocoder       // Finds the coordinates of business addresses (for nearby business searches)
 }
 
 /*
@@ -68,7 +84,57 @@ func (app *Application) Initialize(appDBName string) {
 	var ngHttpAddress string = fmt.Sprintf("http://%s", config.AppConfig.GetFrontendNetworkAddress())
 	app.NGHandler = NewAngularHandler(ngHost, ngHttpAddress)
 
+	// Initialize payment provider
+	app.PaymentProvider = payments.NewStripeProvider(
+		config.AppConfig.PAYMENT_PROVIDER_API_URL,
+		config.AppConfig.PAYMENT_PROVIDER_SECRET_KEY,
+		config.AppConfig.PAYMENT_PROVIDER_WEBHOOK_SECRET)
+
+	// Initialize notifier (messages are only logged if no SMTP server is configured)
+	app.Notifier = notifications.NewLogNotifier()
+	if config.AppConfig.NOTIFIER_SMTP_HOST != "" {
+		smtpNotifier, err := notifications.NewSMTPNotifier(
+			config.AppConfig.NOTIFIER_SMTP_HOST,
+			config.AppConfig.NOTIFIER_SMTP_PORT,
+			config.AppConfig.NOTIFIER_SMTP_USERNAME,
+			config.AppConfig.NOTIFIER_SMTP_PASSWORD,
+			config.AppConfig.NOTIFIER_FROM_ADDRESS)
+		if err != nil {
+			log.Printf("ERROR:  SMTP notifier could not be configured, messages will only be logged.  [%s]", err)
+		} else {
+			app.Notifier = smtpNotifier
+		}
+	}
+
+	// Initialize geocoder (the bundled zip code centroids are used if no dataset is configured)
+	zipCentroidGeocoder, err := geocoding.NewZipCentroidGeocoder(config.AppConfig.GEOCODING_ZIP_CENTROIDS_FILE)
+	if err != nil {
+		log.Printf("ERROR:  Zip centroid dataset could not be loaded, the bundled centroids will be used.  [%s]", err)
+		zipCentroidGeocoder, _ = geocoding.NewZipCentroidGeocoder("")
+	}
+	app.Geocoder = zipCentroidGeocoder
+
 	// Initialize router and routes
+	app.InitializeRouter()
+}
+
+/*
+*Description*
+
+func InitializeRouter
+
+Creates the application's router and defines its routes. Handlers are only bound to the Application, not called, so the router can be
+inspected (e.g. by tests) before the database and other components are initialized. The AngularHandler must be set first.
+
+*Parameters*
+
+	None
+
+*Returns*
+
+	None
+*/
+func (app *Application) InitializeRouter() {
 	app.Router = mux.NewRouter()
 	app.Router.Use(middleware.RequestLoggingMiddleware)
 	app.initializeRoutes()
@@ -81,6 +147,12 @@ func initializeRoutes
 
 Defines the the API endpoints/routes for the application and their behavior using the application's Gorilla Mux Router.
 
+API routes are versioned under a path prefix (e.g. '/api/v1'), so they never collide with the routes of the Angular frontend, which is
+served for every other GET request. Unknown routes under '/api/' respond with 404 errors instead of being passed on to the frontend.
+
+The unversioned routes that the API was first served on (e.g. '/user/{id}') are kept as deprecated aliases of the v1 routes until
+LEGACY_API_ROUTES_SUNSET, and their responses carry 'Deprecation', 'Link' and 'Sunset' headers (see 'middleware.DeprecatedRouteMiddleware').
+
 *Parameters*
 
 	None
@@ -95,59 +167,236 @@ func (app *Application) initializeRoutes() {
 	app.Router.HandleFunc("/", serveTableOfContents)
 	app.Router.HandleFunc("/home", serveTableOfContents)
 	app.Router.HandleFunc("/index", serveTableOfContents)
+	app.Router.HandleFunc("/docs", serveAPIDocs)
+
+	// Versioned API routes
+	v1Router := app.Router.PathPrefix(APIV1Prefix).Subrouter()
+	v1Router.NotFoundHandler = http.HandlerFunc(apiRouteNotFound)
+	v1Router.MethodNotAllowedHandler = http.HandlerFunc(apiMethodNotAllowed)
+	app.initializeV1Routes(v1Router)
+	app.Router.PathPrefix(apiPrefix).HandlerFunc(apiRouteNotFound)
+
+	// Legacy (unversioned) API routes
+	sunset, err := config.AppConfig.GetLegacyAPIRoutesSunset()
+	if err != nil {
+		log.Printf("ERROR:  LEGACY_API_ROUTES_SUNSET must be a date (YYYY-MM-DD), legacy routes will have no sunset date.  [%s]", err)
+	}
+
+	legacyRouter := app.Router.NewRoute().Subrouter()
+	legacyRouter.Use(middleware.DeprecatedRouteMiddleware(APIV1Prefix, sunset))
+	app.initializeV1Routes(legacyRouter)
+
+	// Path prefix for API to work with Angular frontend
+	// WARNING: This MUST be the last route defined by the router.
+	app.Router.PathPrefix("/").Handler(app.NGHandler.ReverseProxy).Methods("GET").Name(FrontendRouteName)
+}
+
+/*
+*Description*
+
+func initializeV1Routes
+
+Defines the routes of version 1 of the API on a router, with paths relative to the version's prefix (e.g. "/user/{id}" for
+'/api/v1/user/{id}').
+
+A new version of the API gets its own function that defines its routes on a router for its own prefix. Routes that don't change between
+versions are bound to the same handlers, and models are shared by every version, so only the handlers of routes whose requests or
+responses change are written for the new version.
+
+*Parameters*
 
+	router  <*mux.Router>
+
+		The router that the routes are defined on.
+
+*Returns*
+
+	None
+*/
+func (app *Application) initializeV1Routes(router *mux.Router) {
 	// User routes
-	app.Router.HandleFunc("/register", app.CreateUser).Methods("POST")
-	app.Router.HandleFunc("/login", app.Authenticate).Methods("POST")
-	app.Router.HandleFunc("/user/{id}", app.GetUser).Methods("GET")
-	app.Router.HandleFunc("/user/{id}", app.UpdateUser).Methods("PUT")
-	app.Router.HandleFunc("/user/{id}", app.DeleteUser).Methods("DELETE")
-	app.Router.HandleFunc("/users", app.GetUsers).Methods("GET")
-	app.Router.HandleFunc("/user/{id}/service-appointments", app.GetUserServiceAppointments).Methods("GET")
+	router.HandleFunc("/register", app.CreateUser).Methods("POST")
+	router.HandleFunc("/login", app.Authenticate).Methods("POST")
+	router.HandleFunc("/user/{id}", app.GetUser).Methods("GET")
+	router.HandleFunc("/user/{id}", app.UpdateUser).Methods("PUT")
+	router.HandleFunc("/user/{id}", app.DeleteUser).Methods("DELETE")
+	router.HandleFunc("/users", app.GetUsers).Methods("GET")
+	router.HandleFunc("/user/{id}/service-appointments", app.GetUserServiceAppointments).Methods("GET")
+	router.HandleFunc("/user/{id}/class-pack-credits", app.GetUserClassPackCredits).Methods("GET")
+	router.HandleFunc("/user/{id}/subscriptions", app.GetUserSubscriptions).Methods("GET")
+	router.HandleFunc("/user/{id}/invoices", app.GetUserInvoices).Methods("GET")
+	router.HandleFunc("/user/{id}/balance", app.GetUserBalance).Methods("GET")
+	router.HandleFunc("/user/{id}/store-credit", app.AddStoreCredit).Methods("POST")
+	router.HandleFunc("/user/{id}/store-credit", app.GetUserStoreCredit).Methods("GET")
+	router.HandleFunc("/user/{id}/gift-cards", app.GetUserGiftCards).Methods("GET")
 
 	// Business routes
-	app.Router.HandleFunc("/business", app.CreateBusiness).Methods("POST")
-	app.Router.HandleFunc("/business/{id}", app.GetBusiness).Methods("GET")
-	app.Router.HandleFunc("/business/{id}", app.UpdateBusiness).Methods("PUT")
-	app.Router.HandleFunc("/business/{id}", app.DeleteBusiness).Methods("DELETE")
-	app.Router.HandleFunc("/businesses", app.GetBusinesses).Methods("GET")
-	app.Router.HandleFunc("/business/{id}/services", app.GetBusinessServices).Methods("GET")
-	app.Router.HandleFunc("/business/{id}/service-appointments", app.GetBusinessServiceAppointments).Methods("GET")
+	router.HandleFunc("/business", app.CreateBusiness).Methods("POST")
+	router.HandleFunc("/business/{id}", app.GetBusiness).Methods("GET")
+	router.HandleFunc("/business/{id}", app.UpdateBusiness).Methods("PUT")
+	router.HandleFunc("/business/{id}", app.DeleteBusiness).Methods("DELETE")
+	router.HandleFunc("/businesses", app.GetBusinesses).Methods("GET")
+	router.HandleFunc("/business/{id}/services", app.GetBusinessServices).Methods("GET")
+	router.HandleFunc("/business/{id}/address", app.GetBusinessAddress).Methods("GET")
+	router.HandleFunc("/business/{id}/address", app.SetBusinessAddress).Methods("PUT")
+	router.HandleFunc("/business/{id}/service-appointments", app.GetBusinessServiceAppointments).Methods("GET")
+	router.HandleFunc("/business/{id}/reports/receivables", app.GetBusinessReceivables).Methods("GET")
+	router.HandleFunc("/business/{id}/reports/tax", app.GetBusinessTaxReport).Methods("GET")
+	router.HandleFunc("/business/{id}/reports/accounting", app.GetBusinessAccountingExport).Methods("GET")
+	router.HandleFunc("/business/{id}/accounting-exports", app.GetBusinessAccountingExports).Methods("GET")
+	router.HandleFunc("/business/{id}/booking-rules", app.GetBusinessBookingRule).Methods("GET")
+	router.HandleFunc("/business/{id}/booking-rules", app.UpdateBusinessBookingRule).Methods("PUT")
+	router.HandleFunc("/business/{id}/class-packs", app.CreateClassPack).Methods("POST")
+	router.HandleFunc("/business/{id}/class-packs", app.GetBusinessClassPacks).Methods("GET")
+	router.HandleFunc("/business/{id}/membership-plans", app.CreateMembershipPlan).Methods("POST")
+	router.HandleFunc("/business/{id}/membership-plans", app.GetBusinessMembershipPlans).Methods("GET")
+	router.HandleFunc("/business/{id}/promo-codes", app.CreatePromoCode).Methods("POST")
+	router.HandleFunc("/business/{id}/promo-codes", app.GetBusinessPromoCodes).Methods("GET")
+	router.HandleFunc("/business/{id}/store-credit", app.GetBusinessStoreCredit).Methods("GET")
+	router.HandleFunc("/business/{id}/gift-cards", app.CreateGiftCard).Methods("POST")
+	router.HandleFunc("/business/{id}/gift-cards", app.GetBusinessGiftCards).Methods("GET")
 
 	// Service routes
-	app.Router.HandleFunc("/service", app.CreateService).Methods("POST")
-	app.Router.HandleFunc("/service/{id}", app.GetService).Methods("GET")
-	app.Router.HandleFunc("/service/{id}", app.UpdateService).Methods("PUT")
-	app.Router.HandleFunc("/service/{id}", app.DeleteService).Methods("DELETE")
-	app.Router.HandleFunc("/services", app.GetServices).Methods("GET")
-	app.Router.HandleFunc("/service/{service-id}/user/{user-id}", app.GetUserEnrolledStatus).Methods("GET")
-	app.Router.HandleFunc("/service/{id}/users", app.GetListOfEnrolledUsers).Methods("GET")
-	app.Router.HandleFunc("/service/{id}/user-count", app.GetEnrolledUsersCount).Methods("GET")
-	app.Router.HandleFunc("/service/{id}/appointments", app.GetActiveServiceAppointments).Methods("GET")
-	app.Router.HandleFunc("/service/{id}/appointments/active", app.GetActiveServiceAppointments).Methods("GET")
-	app.Router.HandleFunc("/service/{id}/appointments/all", app.GetServiceAppointments).Methods("GET")
-	// TODO: app.Router.HandleFunc("/service/{id}/user-appointments", app.GetUserAppointments).Methods("GET")
+	router.HandleFunc("/service", app.CreateService).Methods("POST")
+	router.HandleFunc("/service/{id}", app.GetService).Methods("GET")
+	router.HandleFunc("/service/{id}", app.UpdateService).Methods("PUT")
+	router.HandleFunc("/service/{id}", app.DeleteService).Methods("DELETE")
+	router.HandleFunc("/services", app.GetServices).Methods("GET")
+	router.HandleFunc("/service/{service-id}/user/{user-id}", app.GetUserEnrolledStatus).Methods("GET")
+	router.HandleFunc("/service/{id}/users", app.GetListOfEnrolledUsers).Methods("GET")
+	router.HandleFunc("/service/{id}/user-count", app.GetEnrolledUsersCount).Methods("GET")
+	router.HandleFunc("/service/{id}/appointments", app.GetActiveServiceAppointments).Methods("GET")
+	router.HandleFunc("/service/{id}/appointments/active", app.GetActiveServiceAppointments).Methods("GET")
+	router.HandleFunc("/service/{id}/appointments/all", app.GetServiceAppointments).Methods("GET")
+	router.HandleFunc("/service/{id}/booking-rules", app.GetServiceBookingRule).Methods("GET")
+	router.HandleFunc("/service/{id}/booking-rules", app.UpdateServiceBookingRule).Methods("PUT")
+	router.HandleFunc("/service/{id}/booking-rules", app.DeleteServiceBookingRule).Methods("DELETE")
+	// TODO: router.HandleFunc("/service/{id}/user-appointments", app.GetUserAppointments).Methods("GET")
+
+	// Search routes
+	router.HandleFunc("/search", app.Search).Methods("GET")
+	router.HandleFunc("/businesses/nearby", app.GetNearbyBusinesses).Methods("GET")
 
 	// Appointment routes
-	app.Router.HandleFunc("/appointment", app.CreateAppointment).Methods("POST")
-	app.Router.HandleFunc("/appointment/{id}", app.GetAppointment).Methods("GET")
-	app.Router.HandleFunc("/appointment/{id}", app.UpdateAppointment).Methods("PUT")
-	app.Router.HandleFunc("/appointment/{id}", app.DeleteAppointment).Methods("DELETE")
-	app.Router.HandleFunc("/appointments", app.GetActiveAppointments).Methods("GET")
-	app.Router.HandleFunc("/appointments/active", app.GetActiveAppointments).Methods("GET")
-	app.Router.HandleFunc("/appointments/all", app.GetAppointments).Methods("GET")
-	app.Router.HandleFunc("/appointment/{id}/cancel", app.CancelAppointment).Methods("POST")
+	router.HandleFunc("/appointment", app.CreateAppointment).Methods("POST")
+	router.HandleFunc("/appointment/{id}", app.GetAppointment).Methods("GET")
+	router.HandleFunc("/appointment/{id}", app.UpdateAppointment).Methods("PUT")
+	router.HandleFunc("/appointment/{id}", app.DeleteAppointment).Methods("DELETE")
+	router.HandleFunc("/appointments", app.GetActiveAppointments).Methods("GET")
+	router.HandleFunc("/appointments/active", app.GetActiveAppointments).Methods("GET")
+	router.HandleFunc("/appointments/all", app.GetAppointments).Methods("GET")
+	router.HandleFunc("/appointment/{id}/cancel", app.CancelAppointment).Methods("POST")
+	router.HandleFunc("/appointment/{id}/status", app.UpdateAppointmentStatus).Methods("POST")
+	router.HandleFunc("/appointment/{id}/status-history", app.GetAppointmentStatusHistory).Methods("GET")
+	router.HandleFunc("/appointment/{id}/guests", app.GetAppointmentGuests).Methods("GET")
+	router.HandleFunc("/appointment/{id}/guests/{guest-id}/cancel", app.CancelAppointmentGuest).Methods("POST")
+	router.HandleFunc("/appointment/{id}/invoices", app.GetAppointmentInvoices).Methods("GET")
+
+	// Class pack routes
+	router.HandleFunc("/class-pack/{id}", app.GetClassPack).Methods("GET")
+	router.HandleFunc("/class-pack/{id}", app.UpdateClassPack).Methods("PUT")
+	router.HandleFunc("/class-pack/{id}", app.DeleteClassPack).Methods("DELETE")
+	router.HandleFunc("/class-pack/{id}/purchase", app.PurchaseClassPack).Methods("POST")
+
+	// Membership routes
+	router.HandleFunc("/membership-plan/{id}", app.GetMembershipPlan).Methods("GET")
+	router.HandleFunc("/membership-plan/{id}", app.UpdateMembershipPlan).Methods("PUT")
+	router.HandleFunc("/membership-plan/{id}", app.DeleteMembershipPlan).Methods("DELETE")
+	router.HandleFunc("/membership-plan/{id}/subscribe", app.SubscribeToMembershipPlan).Methods("POST")
+	router.HandleFunc("/subscription/{id}", app.GetSubscription).Methods("GET")
+	router.HandleFunc("/subscription/{id}/pause", app.PauseSubscription).Methods("POST")
+	router.HandleFunc("/subscription/{id}/resume", app.ResumeSubscription).Methods("POST")
+	router.HandleFunc("/subscription/{id}/cancel", app.CancelSubscription).Methods("POST")
+	router.HandleFunc("/subscription/{id}/change-plan", app.ChangeSubscriptionPlan).Methods("POST")
+
+	// Promo code routes
+	router.HandleFunc("/promo-code/{id}", app.GetPromoCode).Methods("GET")
+	router.HandleFunc("/promo-code/{id}", app.UpdatePromoCode).Methods("PUT")
+	router.HandleFunc("/promo-code/{id}", app.DeletePromoCode).Methods("DELETE")
+	router.HandleFunc("/promo-code/{id}/redemptions", app.GetPromoCodeRedemptions).Methods("GET")
+
+	// Tax rate routes
+	router.HandleFunc("/tax-rate", app.CreateTaxRate).Methods("POST")
+	router.HandleFunc("/tax-rate/{id}", app.GetTaxRate).Methods("GET")
+	router.HandleFunc("/tax-rate/{id}", app.UpdateTaxRate).Methods("PUT")
+	router.HandleFunc("/tax-rate/{id}", app.DeleteTaxRate).Methods("DELETE")
+	router.HandleFunc("/tax-rates", app.GetTaxRates).Methods("GET")
+
+	// Gift card routes
+	router.HandleFunc("/gift-card/{id}", app.GetGiftCard).Methods("GET")
+	router.HandleFunc("/gift-card/{id}", app.UpdateGiftCard).Methods("PUT")
 
 	// Invoice routes
-	app.Router.HandleFunc("/invoice", app.CreateInvoice).Methods("POST")
-	app.Router.HandleFunc("/invoice/{id}", app.GetInvoice).Methods("GET")
-	app.Router.HandleFunc("/invoice/{id}", app.UpdateInvoice).Methods("PUT")
-	app.Router.HandleFunc("/invoice/{id}", app.DeleteInvoice).Methods("DELETE")
-	app.Router.HandleFunc("/invoices", app.GetInvoices).Methods("GET")
+	router.HandleFunc("/invoice", app.CreateInvoice).Methods("POST")
+	router.HandleFunc("/invoice/{id}", app.GetInvoice).Methods("GET")
+	router.HandleFunc("/invoice/{id}", app.UpdateInvoice).Methods("PUT")
+	router.HandleFunc("/invoice/{id}", app.DeleteInvoice).Methods("DELETE")
+	router.HandleFunc("/invoices", app.GetInvoices).Methods("GET")
+	router.HandleFunc("/invoice/{id}/line-items", app.GetInvoiceLineItems).Methods("GET")
+	router.HandleFunc("/invoice/{id}/line-items", app.SetInvoiceLineItems).Methods("PUT")
+	router.HandleFunc("/invoice/{id}/pdf", app.GetInvoicePDF).Methods("GET")
+	router.HandleFunc("/invoice/{id}/issue", app.IssueInvoice).Methods("POST")
+	router.HandleFunc("/invoice/{id}/credit-notes", app.CreateCreditNote).Methods("POST")
+	router.HandleFunc("/invoice/{id}/credit-notes", app.GetInvoiceCreditNotes).Methods("GET")
+	router.HandleFunc("/credit-note/{id}", app.GetCreditNote).Methods("GET")
+	router.HandleFunc("/invoice/{id}/payments", app.CreatePayment).Methods("POST")
+	router.HandleFunc("/invoice/{id}/payments", app.GetInvoicePayments).Methods("GET")
+	router.HandleFunc("/payment/{id}", app.GetPayment).Methods("GET")
+	router.HandleFunc("/payment/{id}/refund", app.RefundPayment).Methods("POST")
+	router.HandleFunc("/payment/{id}/receipt", app.GetPaymentReceipt).Methods("GET")
+	router.HandleFunc("/invoice/{id}/store-credit", app.CreditInvoiceOverpayment).Methods("POST")
+
+	// Payment provider routes
+	router.HandleFunc("/invoice/{id}/payment-intent", app.CreatePaymentIntent).Methods("POST")
+	router.HandleFunc("/payment-intent/{id}/capture", app.CapturePaymentIntent).Methods("POST")
+	router.HandleFunc("/webhooks/payments", app.HandlePaymentWebhook).Methods("POST")
+
+	// Documentation routes
+	router.HandleFunc("/openapi.json", app.GetOpenAPISpec).Methods("GET")
+}
 
-	// Path prefix for API to work with Angular frontend
-	// WARNING: This MUST be the last route defined by the router.
-	app.Router.PathPrefix("/").Handler(app.NGHandler.ReverseProxy).Methods("GET")
+/*
+*Description*
+
+func apiRouteNotFound
+
+Responds with a 404 error to requests for unknown API routes, so they aren't passed on to the Angular frontend.
+
+*Parameters*
+
+	None
+
+*Returns*
+
+	None
+*/
+func apiRouteNotFound(writer http.ResponseWriter, request *http.Request) {
+	utils.RespondWithError(
+		writer,
+		http.StatusNotFound,
+		fmt.Sprintf("%s is not an API route", request.URL.Path))
+}
+
+/*
+*Description*
+
+func apiMethodNotAllowed
+
+Responds with a 405 error to requests for API routes that don't accept the request's method.
+
+*Parameters*
+
+	None
+
+*Returns*
+
+	None
+*/
+func apiMethodNotAllowed(writer http.ResponseWriter, request *http.Request) {
+	utils.RespondWithError(
+		writer,
+		http.StatusMethodNotAllowed,
+		fmt.Sprintf("%s doesn't accept %s requests", request.URL.Path, request.Method))
 }
 
 /*
@@ -193,7 +442,7 @@ func (app *Application) Run(networkAddress string) {
 		AllowedOrigins:      []string{appHTTPAddress, networkAddress, app.NGHandler.HTTPAddress, app.NGHandler.Host},
 		AllowedMethods:      []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS"},
 		AllowedHeaders:      []string{"X-Requested-With", "Content-Type", "Authorization", "DNT", "Keep-Alive", "User-Agent", "X-Requested-With", "If-Modified-Since", "Cache-Control", "Content-Range", "Range"},
-		ExposedHeaders:      []string{"DNT", "Keep-Alive", "User-Agent", "X-Requested-With", "If-Modified-Since", "Cache-Control", "Content-Type", "Content-Range", "Range", "Content-Disposition"},
+		ExposedHeaders:      []string{"DNT", "Keep-Alive", "User-Agent", "X-Requested-With", "If-Modified-Since", "Cache-Control", "Content-Type", "Content-Range", "Range", "Content-Disposition", "Deprecation", "Link", "Sunset"},
 		MaxAge:              86400,
 		AllowCredentials:    true,
 		AllowPrivateNetwork: true,
@@ -2,20 +2,52 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"server/models"
 	"server/utils"
-	_ "time"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// Body of a request to book an appointment (see 'CreateAppointment')
+type createAppointmentRequest struct {
+	models.Appointment
+	Guests []models.AppointmentGuest `json:"guests"`
+}
+
+// Body of a request to change the status of an appointment (see 'UpdateAppointmentStatus')
+type appointmentStatusRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
 /*
 *Description*
 
 func CreateAppointment
 
-Creates a new appointment record in the database.
+Books a new appointment (creates a new appointment record in the database).
+
+The booking is checked against the booking rules that apply to the Service (the Service's own rule, or else the Business default rule),
+against the Service's remaining capacity, and against the User's other appointments. A User can't book the same Service twice, or book
+Services whose time slots overlap, unless the Business allows overlapping bookings.
+Rejected bookings return a machine-readable reason code in the "code" field of the error response.
+
+Group bookings reserve several seats in one appointment: one seat for the booking user plus one seat per guest. Guest names and contact
+details are optional, and each guest can be cancelled individually later (see 'CancelAppointmentGuest').
+
+If the User has a membership that includes the Service (and has visits left for the billing period), the booking user's own seat is covered
+by the membership. Any remaining seats are paid for with the User's eligible class pack credits, if they have any.
+
+If the Business invoices at booking (see the Business 'billing_policy'), the seats that are not covered are invoiced at the Service's price
+in the same transaction. The invoice can be retrieved with 'GetAppointmentInvoices'.
+
+If a promo code is specified, it must be one of the Business's promo codes that can be used for the Service at the time of booking (see
+'CreatePromoCode'). Its discount is shown on the appointment's invoice, and the redemption is listed in the code's redemption report.
 
 *Parameters*
 
@@ -52,13 +84,22 @@ Creates a new appointment record in the database.
 
 		Optional fields:
 
-			cancel_date_time  <time.Time>
+			status  <string>
+
+				Initial status of the appointment, 'Pending' or 'Confirmed' (defaults to 'Confirmed'). Any other status is ignored, and
+				so is a cancellation time ('cancel_date_time').
+
+			seats  <uint>
+
+				Number of seats to reserve, including the booking user's own seat (defaults to one more than the number of guests)
 
-				Date/time when appointment was cancelled (if cancelled, else null)
+			guests  <[]object>
 
-			active  <bool>
+				Names and contact details of the guests (at most seats - 1). Each guest may include "name", "email", and "phone_number".
 
-				Status flag (true for Active, false for Cancelled)
+			promo_code  <string>
+
+				Promo code of the Service's Business (not case sensitive). Its discount is taken off the appointment's invoice.
 
 *Example request(s)*
 
@@ -68,6 +109,24 @@ Creates a new appointment record in the database.
 		"user_id":123
 	}
 
+	POST /appointment
+	{
+		"service_id":123,
+		"user_id":123,
+		"promo_code":"SPRING10"
+	}
+
+	POST /appointment
+	{
+		"service_id":123,
+		"user_id":123,
+		"seats":3,
+		"guests":[
+			{"name":"Jane Doe","email":"jane.doe@example.com"},
+			{"name":"John Doe","phone_number":"+1 555-555-5555"}
+		]
+	}
+
 *Response format*
 
 	Success:
@@ -83,12 +142,13 @@ Creates a new appointment record in the database.
 			"service_id":123,
 			"user_id":123,
 			"cancel_date_time":null,
-			"active":true
+			"status":"Confirmed",
+			"seats":1
 		}
 
 	Failure:
 
-		-- Case = Bad request body
+		-- Case = Bad request body or more guests than seats
 		HTTP/1.1 400 Bad Request
 		Content-Type: application/json
 
@@ -96,6 +156,37 @@ Creates a new appointment record in the database.
 		"error":"ERROR MESSAGE TEXT HERE"
 		}
 
+		-- Case = Service ID does not exist in the database
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Booking rejected by a booking rule
+		HTTP/1.1 422 Unprocessable Entity
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE",
+		"code":"REASON CODE HERE"
+		}
+
+		Reason codes:
+			service_already_started  --  the Service has already started
+			booking_window_not_open  --  bookings for the Service have not opened yet
+			booking_window_closed  --  bookings for the Service closed before the Service's start (minimum lead time)
+			weekly_booking_limit_reached  --  the User already holds the maximum number of active bookings with the Business for that week
+			insufficient_capacity  --  the Service does not have enough open seats left for the requested number of seats
+			duplicate_booking  --  the User already has an appointment for the Service
+			overlapping_booking  --  the Service's time slot overlaps one of the User's active appointments
+			promo_code_not_found  --  the Service's Business has no such promo code
+			promo_code_not_active  --  the promo code is not valid at the time of booking (before it starts or after it ends)
+			promo_code_not_eligible  --  the promo code can't be used for the Service
+			promo_code_limit_reached  --  the promo code has been used the maximum number of times, in total or by the User
+			promo_code_first_time_only  --  the promo code is for first-time customers and the User has other appointments with the Business
+
 		-- Case = Database operation error
 		HTTP/1.1 500 Internal Server Error
 		Content-Type: application/json
@@ -105,10 +196,10 @@ Creates a new appointment record in the database.
 		}
 */
 func (app *Application) CreateAppointment(writer http.ResponseWriter, request *http.Request) {
-	appt := models.Appointment{}
+	var booking createAppointmentRequest
 
 	decoder := json.NewDecoder(request.Body)
-	if err := decoder.Decode(&appt); err != nil {
+	if err := decoder.Decode(&booking); err != nil {
 		utils.RespondWithError(
 			writer,
 			http.StatusBadRequest,
@@ -119,12 +210,23 @@ func (app *Application) CreateAppointment(writer http.ResponseWriter, request *h
 
 	defer request.Body.Close()
 
-	returnedRecords, err := appt.Create(app.AppDB)
+	appt := booking.Appointment
+	returnedRecords, err := appt.Book(app.AppDB, time.Now(), booking.Guests)
 	createdAppointment := returnedRecords["appointment"]
-	if err != nil {
+
+	var bookingErr *models.BookingRejectedError
+	if errors.As(err, &bookingErr) {
+		utils.RespondWithErrorCode(
+			writer,
+			http.StatusUnprocessableEntity,
+			bookingErr.Code,
+			bookingErr.Message)
+
+		return
+	} else if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			appointmentErrorStatusCode(err),
 			err.Error())
 
 		return
@@ -186,7 +288,8 @@ Get appointment record from the database by ID.
 			"service_id":123,
 			"user_id":123,
 			"cancel_date_time":null,
-			"active":true
+			"status":"Confirmed",
+			"seats":1
 		}
 
 	Failure:
@@ -249,6 +352,9 @@ This function behaves like a PATCH method, rather than a true PUT. Any fields th
 
 If a specified field's value should be deleted from the record, the appropriate null/blank should be specified for that key in the JSON request body (e.g. "address2": "").
 
+When the update cancels the appointment, what was paid for it is refunded according to the cancellation policy, as for 'CancelAppointment'.
+The response is still the updated appointment, so use the '/appointment/{id}/cancel' or '/appointment/{id}/status' routes to get a breakdown of the refund.
+
 *Parameters*
 
 	writer  <http.ResponseWriter>
@@ -278,27 +384,21 @@ If a specified field's value should be deleted from the record, the appropriate
 
 		Optional fields:
 
-			service_id  <uint>
-
-				ID of Service record Appointment is associated with
-
-			user_id  <uint>
-
-				ID of User that booked the appointment
-
-			cancel_date_time  <time.Time>
+			status  <string>
 
-				Date/time when appointment was cancelled (if cancelled, else null)
+				Lifecycle status of the appointment. Must be a permitted transition from the appointment's current status.
+				The cancellation time is set by the server when the appointment is cancelled ('service_id', 'user_id', 'cancel_date_time' and
+				'seats' cannot be updated).
 
-			active  <bool>
-
-				Status flag (true for Active, false for Cancelled)
+				Permitted transitions:
+					Pending  -->  Confirmed, Cancelled By Customer, Cancelled By Business
+					Confirmed  -->  Completed, No Show, Cancelled By Customer, Cancelled By Business
 
 *Example request(s)*
 
 	PUT /appointment/123456
 	{
-		"active":false
+		"status":"Cancelled By Customer"
 	}
 
 *Response format*
@@ -316,11 +416,12 @@ If a specified field's value should be deleted from the record, the appropriate
 			"service_id":123,
 			"user_id":123,
 			"cancel_date_time":"2020-01-31T04:20:12.6789012-05:00",
-			"active":false
+			"status":"Cancelled By Customer",
+			"seats":1
 		}
 
 	Failure:
-		-- Case = Bad request body or missing/misformatted ID in request URL
+		-- Case = Bad request body, invalid status, restricted field (service_id, user_id, seats, cancel_date_time), or missing/misformatted ID in request URL
 		HTTP/1.1 400 Bad Request
 		Content-Type: application/json
 
@@ -328,6 +429,14 @@ If a specified field's value should be deleted from the record, the appropriate
 		"error":"ERROR MESSAGE TEXT HERE"
 		}
 
+		-- Case = Status change is not a permitted transition from the appointment's current status
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
 		-- Case = Database operation error
 		HTTP/1.1 500 Internal Server Error
 		Content-Type: application/json
@@ -368,12 +477,18 @@ func (app *Application) UpdateAppointment(writer http.ResponseWriter, request *h
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			appointmentErrorStatusCode(err),
 			err.Error())
 
 		return
 	}
 
+	// Cancelling through an update refunds the appointment the same way as the cancel and status routes
+	_, statusUpdated := updates["status"]
+	if statusUpdated && updatedAppointment.(*models.Appointment).IsCancelled() {
+		app.refundCancellation(apptID)
+	}
+
 	utils.RespondWithJSON(
 		writer,
 		http.StatusOK,
@@ -432,7 +547,8 @@ Deleted appointment record is returned in the response body if the operation is
 			"service_id":123,
 			"user_id":123,
 			"cancel_date_time":null,
-			"active":true
+			"status":"Confirmed",
+			"seats":1
 		}
 
 	Failure:
@@ -488,7 +604,8 @@ func (app *Application) DeleteAppointment(writer http.ResponseWriter, request *h
 
 func GetAppointments
 
-Get a list of all Appointment records in the database.
+Get a page of the Appointment records in the database, sorted and filtered as requested. Pages are found with the cursor returned
+with the previous page, and report the total number of records that match the filters.
 
 *Parameters*
 
@@ -510,14 +627,33 @@ Get a list of all Appointment records in the database.
 
 	Route:	/appointments/all
 
-	Body:
+	Query parameters (all optional):
 
-		None
+		limit  <int>
+
+			Number of records in the page (defaults to 50, at most 200).
+
+		cursor  <string>
+
+			The 'next_cursor' of the previous page (omitted for the first page). Must be used with the same sort.
+
+		sort  <string>
+
+			Comma separated fields to sort by, each prefixed with '-' to sort in descending order. Records that sort equally are sorted
+			by ID. Sortable fields: id, created_at, updated_at, user_id, service_id, seats.
+
+		{field}, {field}[{operator}]  <string>
+
+			Filters by a field, with an optional operator: eq (the default), ne, gt, gte, lt, lte, or in (comma separated values). Dates
+			are YYYY-MM-DD or RFC 3339 date/times. Fields: id, created_at, updated_at, user_id, service_id, status, seats,
+			cancel_date_time.
 
 *Example request(s)*
 
 	GET /appointments/all
 
+	GET /appointments/all?service_id=11&status[in]=Pending,Confirmed
+
 *Response format*
 
 	Success:
@@ -525,32 +661,48 @@ Get a list of all Appointment records in the database.
 		HTTP/1.1 200 OK
 		Content-Type: application/json
 
-		[
-			{
-				"ID": 123,
-				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
-				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
-				"DeletedAt": null,
-				"service_id":11,
-				"user_id":22,
-				"cancel_date_time":null,
-				"active":true
-			},
-			{
-				"ID": 456,
-				"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
-				"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
-				"DeletedAt": null,
-				"service_id":42,
-				"user_id":99,
-				"cancel_date_time":null,
-				"active":true
-			},
-			...
-		]
+		{
+			"data":[
+				{
+					"ID": 123,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"service_id":11,
+					"user_id":22,
+					"cancel_date_time":null,
+					"status":"Confirmed",
+					"seats":1
+				},
+				{
+					"ID": 456,
+					"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
+					"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
+					"DeletedAt": null,
+					"service_id":42,
+					"user_id":99,
+					"cancel_date_time":null,
+					"status":"Confirmed",
+					"seats":1
+				},
+				...
+			],
+			"total_count":124,
+			"limit":50,
+			"next_cursor":"eyJzIjoiIiwidiI6WzQxMV19"
+		}
 
 	Failure:
 
+		-- Case = Invalid limit, cursor, sort or filter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
 		HTTP/1.1 500 InternalServerError
 		Content-Type: application/json
 
@@ -559,14 +711,22 @@ Get a list of all Appointment records in the database.
 		}
 */
 func (app *Application) GetAppointments(writer http.ResponseWriter, request *http.Request) {
-	appt := models.Appointment{}
-	var appts []models.Appointment
+	options, err := parseListOptions(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
 
-	appts, err := appt.GetAll(app.AppDB)
+		return
+	}
+
+	appt := models.Appointment{}
+	page, err := appt.List(app.AppDB, options)
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			listErrorStatusCode(err),
 			err.Error())
 
 		log.Printf("ERROR:  %s", err.Error())
@@ -577,7 +737,7 @@ func (app *Application) GetAppointments(writer http.ResponseWriter, request *htt
 	utils.RespondWithJSON(
 		writer,
 		http.StatusOK,
-		appts)
+		page)
 }
 
 /*
@@ -585,7 +745,7 @@ func (app *Application) GetAppointments(writer http.ResponseWriter, request *htt
 
 func GetActiveAppointments
 
-Get a list of all active Appointment records in the database.
+Get a list of all active Appointment records in the database (appointments with a 'Pending' or 'Confirmed' status).
 
 *Parameters*
 
@@ -632,7 +792,8 @@ Get a list of all active Appointment records in the database.
 				"service_id":11,
 				"user_id":22,
 				"cancel_date_time":null,
-				"active":true
+				"status":"Confirmed",
+				"seats":1
 			},
 			{
 				"ID": 456,
@@ -642,7 +803,8 @@ Get a list of all active Appointment records in the database.
 				"service_id":42,
 				"user_id":99,
 				"cancel_date_time":null,
-				"active":true
+				"status":"Confirmed",
+				"seats":1
 			},
 			...
 		]
@@ -674,7 +836,7 @@ func (app *Application) GetActiveAppointments(writer http.ResponseWriter, reques
 
 	var activeAppts []models.Appointment
 	for _, appt := range appts {
-		if appt.Active {
+		if appt.IsActive() {
 			activeAppts = append(activeAppts, appt)
 		}
 	}
@@ -690,7 +852,16 @@ func (app *Application) GetActiveAppointments(writer http.ResponseWriter, reques
 
 func CancelAppointment
 
-Cancels the specified Appointment record in the database.
+Cancels the specified Appointment record in the database on behalf of the customer that booked it (status is set to 'Cancelled By Customer').
+
+Appointments that are already cancelled, completed, or marked as a no show cannot be cancelled. Businesses cancel appointments
+through the '/appointment/{id}/status' route with a 'Cancelled By Business' status.
+
+What was paid for the appointment is refunded according to the cancellation policy (see 'models.Appointment.RefundCancellation'): in
+full if it was cancelled in time, or everything beyond the service's cancellation fee if it was cancelled late. Online payments are
+refunded through the payment provider. The response includes a breakdown of the refund. If the refund can't be completed, the
+appointment is still cancelled and the breakdown's 'error' explains what went wrong (the rest is refunded later by the cancellation
+refund job).
 
 *Parameters*
 
@@ -736,12 +907,50 @@ Cancels the specified Appointment record in the database.
 				"service_id":11,
 				"user_id":22,
 				"cancel_date_time":"2023-04-20T04:20:13.5057833-05:00",
-				"active":false
+				"status":"Cancelled By Customer",
+				"seats":1
+			},
+			"refund": {
+				"appointment_id":123,
+				"invoice_id":456,
+				"invoice_status":"Paid",
+				"remaining_balance":0,
+				"currency":"USD",
+				"timely":false,
+				"policy":"Partial",
+				"amount_paid":5000,
+				"cancel_fee":1500,
+				"amount_retained":1500,
+				"amount_refunded":3500,
+				"amount_pending":0,
+				"refunds":[
+					{
+						"ID":789,
+						"payment_id":321,
+						"invoice_id":456,
+						"amount":3500,
+						"reason":"Late cancellation: Yoga Class (cancellation fee retained)",
+						...
+					}
+				],
+				"display": {
+					"locale":"en-US",
+					"amount_paid":"$50.00",
+					...
+				}
 			}
 		}
 
 	Failure:
 
+		-- Case = Appointment can no longer be cancelled
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
 		HTTP/1.1 500 InternalServerError
 		Content-Type: application/json
 
@@ -777,6 +986,348 @@ func (app *Application) CancelAppointment(writer http.ResponseWriter, request *h
 	}
 
 	returnedRecords, err := appt.Cancel(app.AppDB, apptID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			appointmentErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"appointment": returnedRecords["appointment"],
+			"refund":      app.refundCancellation(apptID),
+		})
+}
+
+/*
+*Description*
+
+func UpdateAppointmentStatus
+
+Moves the specified Appointment record to a new lifecycle status.
+
+The status change is rejected if it is not a permitted transition from the appointment's current status:
+
+	Pending  -->  Confirmed, Cancelled By Customer, Cancelled By Business
+	Confirmed  -->  Completed, No Show, Cancelled By Customer, Cancelled By Business
+
+Each successful status change is recorded in the appointment's status history.
+
+When the appointment is cancelled, what was paid for it is refunded according to the cancellation policy (see 'CancelAppointment').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/appointment/{id}/status
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			status  <string>
+
+				The status the appointment is moving to
+
+		Optional fields:
+
+			reason  <string>
+
+				Reason for the status change (recorded in the status history)
+
+*Example request(s)*
+
+	POST /appointment/123/status
+	{
+		"status":"Cancelled By Business",
+		"reason":"Instructor is sick"
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"appointment": {
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"DeletedAt": null,
+				"service_id":11,
+				"user_id":22,
+				"cancel_date_time":"2023-04-20T04:20:13.5057833-05:00",
+				"status":"Cancelled By Business"
+			},
+			"refund": {
+				"appointment_id":123,
+				"invoice_id":456,
+				"invoice_status":"Refunded",
+				"timely":true,
+				"policy":"Full",
+				"amount_paid":5000,
+				"amount_refunded":5000,
+				...
+			}
+		}
+
+		(the refund breakdown is only included when the appointment is cancelled, see 'CancelAppointment')
+
+	Failure:
+
+		-- Case = Bad request body, invalid status, or missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Appointment ID does not exist in the database
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Status change is not a permitted transition from the appointment's current status
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 InternalServerError
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) UpdateAppointmentStatus(writer http.ResponseWriter, request *http.Request) {
+	appt := models.Appointment{}
+	apptID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var statusChange appointmentStatusRequest
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&statusChange); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	returnedRecords, err := appt.UpdateStatus(app.AppDB, apptID, statusChange.Status, statusChange.Reason)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			appointmentErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	if !returnedRecords["appointment"].(*models.Appointment).IsCancelled() {
+		utils.RespondWithJSON(
+			writer,
+			http.StatusOK,
+			returnedRecords)
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"appointment": returnedRecords["appointment"],
+			"refund":      app.refundCancellation(apptID),
+		})
+}
+
+/*
+*Description*
+
+func refundCancellation
+
+Refunds what was paid for a cancelled Appointment (see 'models.Appointment.RefundCancellation'). The appointment is already cancelled,
+so a refund that fails is logged and reported in the breakdown's 'Error' instead of failing the request. The cancellation refund job
+retries it until it succeeds (see 'RunCancellationRefundJob').
+
+*Parameters*
+
+	apptID  <uint>
+
+		The ID of the cancelled appointment.
+
+*Returns*
+
+	_  <*models.CancellationRefund>
+
+		Breakdown of the refund.
+*/
+func (app *Application) refundCancellation(apptID uint) *models.CancellationRefund {
+	appt := models.Appointment{}
+	breakdown, err := appt.RefundCancellation(app.AppDB, app.PaymentProvider, apptID)
+	if err != nil {
+		breakdown.Error = err.Error()
+		log.Printf("ERROR:  Refund for cancelled Appointment ID (%d) could not be completed.  [%s]", apptID, err)
+	}
+
+	return breakdown
+}
+
+/*
+*Description*
+
+func GetAppointmentStatusHistory
+
+Get the list of status changes for the specified Appointment record, ordered from oldest to newest.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/appointment/{id}/status-history
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /appointment/123/status-history
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 1,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":123,
+				"from_status":"",
+				"to_status":"Confirmed",
+				"reason":""
+			},
+			{
+				"ID": 2,
+				"CreatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"DeletedAt": null,
+				"appointment_id":123,
+				"from_status":"Confirmed",
+				"to_status":"Cancelled By Business",
+				"reason":"Instructor is sick"
+			}
+		]
+
+	Failure:
+
+		-- Case = ID missing from or incorrectly formatted in request url
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetAppointmentStatusHistory(writer http.ResponseWriter, request *http.Request) {
+	appt := models.Appointment{}
+	apptID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	_, err = appt.Get(app.AppDB, apptID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Appointment ID (%d) does not exist in the database.  [%s]", apptID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	history, err := appt.GetStatusHistory(app.AppDB, apptID)
 	if err != nil {
 		utils.RespondWithError(
 			writer,
@@ -789,5 +1340,41 @@ func (app *Application) CancelAppointment(writer http.ResponseWriter, request *h
 	utils.RespondWithJSON(
 		writer,
 		http.StatusOK,
-		returnedRecords)
+		history)
+}
+
+/*
+*Description*
+
+func appointmentErrorStatusCode
+
+Maps an error returned by an Appointment model method to the appropriate HTTP status code.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the Appointment model method.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error (500 if the error is not a known Appointment error).
+*/
+func appointmentErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidAppointmentStatus), errors.Is(err, models.ErrInvalidSeatCount), errors.Is(err, models.ErrRestrictedAppointmentField):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrGuestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrGuestAlreadyCancelled):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidAppointmentStatusTransition):
+		return http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
 }
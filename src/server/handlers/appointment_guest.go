@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"server/models"
+	"server/utils"
+)
+
+/*
+*Description*
+
+func GetAppointmentGuests
+
+Get the list of guests attending with the specified Appointment record (including cancelled guests).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/appointment/{id}/guests
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /appointment/123/guests
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 1,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":123,
+				"name":"Jane Doe",
+				"email":"jane.doe@example.com",
+				"phone_number":"",
+				"cancel_date_time":null
+			},
+			{
+				"ID": 2,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"DeletedAt": null,
+				"appointment_id":123,
+				"name":"John Doe",
+				"email":"",
+				"phone_number":"+1 555-555-5555",
+				"cancel_date_time":"2023-04-20T04:20:13.5057833-05:00"
+			}
+		]
+
+	Failure:
+
+		-- Case = Bad request (invalid ID)
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetAppointmentGuests(writer http.ResponseWriter, request *http.Request) {
+	appt := models.Appointment{}
+	apptID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	_, err = appt.Get(app.AppDB, apptID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Appointment ID (%d) does not exist in the database.  [%s]", apptID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	guest := models.AppointmentGuest{}
+	var apptIDJsonKey string = "appointment_id"
+	guests, err := guest.GetRecordsBySecondaryID(app.AppDB, apptIDJsonKey, apptID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		guests)
+}
+
+/*
+*Description*
+
+func CancelAppointmentGuest
+
+Cancels a single guest's seat on the specified Appointment without cancelling the rest of the booking.
+
+The Appointment's seat count is reduced by one and the seat is released for the Service.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/appointment/{id}/guests/{guest-id}/cancel
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	POST /appointment/123/guests/2/cancel
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"appointment":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"DeletedAt": null,
+				"service_id":123,
+				"user_id":123,
+				"cancel_date_time":null,
+				"status":"Confirmed",
+				"seats":2
+			},
+			"appointment_guest":{
+				"ID": 2,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"DeletedAt": null,
+				"appointment_id":123,
+				"name":"John Doe",
+				"email":"",
+				"phone_number":"+1 555-555-5555",
+				"cancel_date_time":"2023-04-20T04:20:13.5057833-05:00"
+			}
+		}
+
+	Failure:
+
+		-- Case = Bad request (invalid ID)
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Appointment ID not found in DB, or guest is not attending the Appointment
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Guest already cancelled, or Appointment is no longer active
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CancelAppointmentGuest(writer http.ResponseWriter, request *http.Request) {
+	apptID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	guestID, err := utils.ParseRequestIDField(request, "guest-id")
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	guest := models.AppointmentGuest{}
+	returnedRecords, err := guest.Cancel(app.AppDB, apptID, guestID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			appointmentErrorStatusCode(err),
+			err.Error())
+
+		log.Printf("ERROR:  %s", err)
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		returnedRecords)
+}
@@ -0,0 +1,703 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/models"
+	"server/utils"
+
+	"gorm.io/gorm"
+)
+
+/*
+*Description*
+
+func GetBusinessBookingRule
+
+Get the default booking rule for the specified Business (the rule that applies to every Service without its own rule).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/booking-rules
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /business/42/booking-rules
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 7,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"business_id":42,
+			"service_id":null,
+			"booking_window_days":14,
+			"min_lead_time_minutes":120,
+			"max_bookings_per_week":3,
+			"min_cancel_notice_minutes":1440
+		}
+
+	Failure:
+		-- Case = ID missing from or incorrectly formatted in request url
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business has no default booking rule
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessBookingRule(writer http.ResponseWriter, request *http.Request) {
+	rule := models.BookingRule{}
+	businessID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	businessRule, err := rule.GetBusinessRule(app.AppDB, businessID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Business ID (%d) does not have a booking rule in the database.  [%s]", businessID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		businessRule)
+}
+
+/*
+*Description*
+
+func UpdateBusinessBookingRule
+
+Creates or replaces the default booking rule for the specified Business.
+
+Any limit that is omitted from the request body is set to 0 (no limit).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/business/{id}/booking-rules
+
+	Body:
+		Format: JSON
+
+		Optional fields:
+
+			booking_window_days  <uint>
+
+				How many days before a Service starts that bookings open (0 for no limit)
+
+			min_lead_time_minutes  <uint>
+
+				How many minutes before a Service starts that bookings close (0 to allow bookings until the Service starts)
+
+			max_bookings_per_week  <uint>
+
+				Max number of active appointments a User can hold with the Business per week (0 for no limit)
+
+			min_cancel_notice_minutes  <uint>
+
+				How many minutes before a Service starts that cancellations stop being timely (0 for any time before the Service starts).
+				Credits used for appointments cancelled in time are refunded to the customer's class pack.
+
+*Example request(s)*
+
+	PUT /business/42/booking-rules
+	{
+		"booking_window_days":14,
+		"min_lead_time_minutes":120,
+		"max_bookings_per_week":3,
+		"min_cancel_notice_minutes":1440
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 7,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"business_id":42,
+			"service_id":null,
+			"booking_window_days":14,
+			"min_lead_time_minutes":120,
+			"max_bookings_per_week":3,
+			"min_cancel_notice_minutes":1440
+		}
+
+	Failure:
+		-- Case = Bad request body or missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business ID does not exist in the database
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) UpdateBusinessBookingRule(writer http.ResponseWriter, request *http.Request) {
+	business := models.Business{}
+	businessID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	businessIDExists, err := business.IDExists(app.AppDB, businessID)
+	if err != nil || !businessIDExists {
+		var errorMessage string = fmt.Sprintf("Business ID (%d) does not exist in the database.", businessID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		return
+	}
+
+	rule := models.BookingRule{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&rule); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	rule.BusinessID = businessID
+	rule.ServiceID = nil
+
+	returnedRecords, err := rule.Upsert(app.AppDB)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		returnedRecords["booking_rule"])
+}
+
+/*
+*Description*
+
+func GetServiceBookingRule
+
+Get the booking rule that applies to bookings for the specified Service.
+
+The Service's own rule is returned if one exists. Otherwise, the default rule for the Service's Business is returned
+(with a null "service_id"). If neither exists, a rule with no limits is returned.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/service/{id}/booking-rules
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /service/11/booking-rules
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 8,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"business_id":42,
+			"service_id":11,
+			"booking_window_days":7,
+			"min_lead_time_minutes":60,
+			"max_bookings_per_week":0,
+			"min_cancel_notice_minutes":0
+		}
+
+	Failure:
+		-- Case = ID missing from or incorrectly formatted in request url
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Service ID does not exist in the database
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetServiceBookingRule(writer http.ResponseWriter, request *http.Request) {
+	service := models.Service{}
+	serviceID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	_, err = service.Get(app.AppDB, serviceID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Service ID (%d) does not exist in the database.  [%s]", serviceID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	rule := models.BookingRule{}
+	effectiveRule, err := rule.GetEffectiveRule(app.AppDB, &service)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		effectiveRule)
+}
+
+/*
+*Description*
+
+func UpdateServiceBookingRule
+
+Creates or replaces the booking rule for the specified Service. The Service's rule replaces the Business default rule for bookings of that Service.
+
+Any limit that is omitted from the request body is set to 0 (no limit).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/service/{id}/booking-rules
+
+	Body:
+		Format: JSON
+
+		Optional fields:
+
+			booking_window_days  <uint>
+
+				How many days before the Service starts that bookings open (0 for no limit)
+
+			min_lead_time_minutes  <uint>
+
+				How many minutes before the Service starts that bookings close (0 to allow bookings until the Service starts)
+
+			max_bookings_per_week  <uint>
+
+				Max number of active appointments a User can hold with the Business per week (0 for no limit)
+
+			min_cancel_notice_minutes  <uint>
+
+				How many minutes before a Service starts that cancellations stop being timely (0 for any time before the Service starts).
+				Credits used for appointments cancelled in time are refunded to the customer's class pack.
+
+*Example request(s)*
+
+	PUT /service/11/booking-rules
+	{
+		"booking_window_days":7,
+		"min_lead_time_minutes":60
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 8,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"business_id":42,
+			"service_id":11,
+			"booking_window_days":7,
+			"min_lead_time_minutes":60,
+			"max_bookings_per_week":0,
+			"min_cancel_notice_minutes":0
+		}
+
+	Failure:
+		-- Case = Bad request body or missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Service ID does not exist in the database
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) UpdateServiceBookingRule(writer http.ResponseWriter, request *http.Request) {
+	service := models.Service{}
+	serviceID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	_, err = service.Get(app.AppDB, serviceID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Service ID (%d) does not exist in the database.  [%s]", serviceID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	rule := models.BookingRule{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&rule); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	rule.BusinessID = service.BusinessID
+	rule.ServiceID = &serviceID
+
+	returnedRecords, err := rule.Upsert(app.AppDB)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		returnedRecords["booking_rule"])
+}
+
+/*
+*Description*
+
+func DeleteServiceBookingRule
+
+Deletes the booking rule for the specified Service, so that bookings for the Service fall back to the Business default rule.
+
+Deleted booking rule record is returned in the response body if the operation is sucessful.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	DELETE
+
+	Route:	/service/{id}/booking-rules
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	DELETE /service/11/booking-rules
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 8,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": "2022-06-31T04:20:12.6789012-05:00",
+			"business_id":42,
+			"service_id":11,
+			"booking_window_days":7,
+			"min_lead_time_minutes":60,
+			"max_bookings_per_week":0,
+			"min_cancel_notice_minutes":0
+		}
+
+	Failure:
+		-- Case = ID missing from or incorrectly formatted in request url
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Service does not have its own booking rule
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) DeleteServiceBookingRule(writer http.ResponseWriter, request *http.Request) {
+	rule := models.BookingRule{}
+	serviceID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	serviceRule, err := rule.GetServiceRule(app.AppDB, serviceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var errorMessage string = fmt.Sprintf("Service ID (%d) does not have its own booking rule in the database.", serviceID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		return
+	} else if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	returnedRecords, err := rule.Delete(app.AppDB, serviceRule.ID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		returnedRecords["booking_rule"])
+}
@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"server/geocoding"
 	"server/models"
+	"server/money"
 	"server/utils"
+
+	"gorm.io/gorm"
 )
 
 /*
@@ -55,6 +60,65 @@ Creates a new business record in the database and returns them as JSON in the re
 
 				The industry / sector that the business serves and/or operates within (financial services, health/wellness, etc.)
 
+			allow_overlapping_bookings  <bool>
+
+				True if users may book this business's services at times that overlap their other appointments (defaults to false)
+
+			billing_policy  <string>
+
+				When appointments for the business's services are invoiced: "At Booking", "At Completion", or "None" (defaults to "At Booking")
+
+			currency  <string>
+
+				ISO 4217 currency of the business's prices and invoices (defaults to "USD")
+
+			invoice_prefix  <string>
+
+				Prefix of the business's invoice numbers: 1-12 letters, digits or separators (defaults to "INV-")
+
+			credit_note_prefix  <string>
+
+				Prefix of the business's credit note numbers: 1-12 letters, digits or separators (defaults to "CN-")
+
+			payment_terms_days  <uint>
+
+				Days after an invoice is issued that it is due, at most 365 (defaults to 30, set it to 0 with an update for due on receipt)
+
+			late_fees_enabled  <bool>
+
+				True if overdue invoices are charged a late fee automatically (defaults to false)
+
+			late_fee_amount  <int>
+
+				Flat part of the late fee (in cents)
+
+			late_fee_rate  <uint>
+
+				Part of the late fee charged on the overdue balance (in basis points, e.g. 150 for 1.5%)
+
+			late_fee_grace_days  <uint>
+
+				Days after the due date before the late fee is charged, at most 365 (defaults to 0)
+
+			state  <string>
+
+				State (2 letter abbreviation) that the business is located in. Its sales tax rate is the rate for its zip code, or else
+				the rate for its state (see 'CreateTaxRate').
+
+			zip  <string>
+
+				Zip code that the business is located in
+
+			tax_jurisdiction  <string>
+
+				Code of the sales tax jurisdiction that the business is taxed in, instead of its state and zip code (must have a tax rate)
+
+			receivables_account, revenue_account, sales_tax_account, deposit_account, customer_credit_account  <string>
+
+				Names of the ledger accounts that the business's invoices, payments and refunds are posted to in accounting exports, at
+				most 100 characters (default to "Accounts Receivable", "Sales", "Sales Tax Payable", "Undeposited Funds" and
+				"Customer Credits", see 'GetBusinessAccountingExport')
+
 *Example request(s)*
 
 	POST /business
@@ -84,7 +148,7 @@ Creates a new business record in the database and returns them as JSON in the re
 
 	Failure:
 
-		-- Case = Bad request body
+		-- Case = Bad request body, invalid billing policy, currency, number prefix, payment terms or account mapping
 		HTTP/1.1 400 Bad Request
 		Content-Type: application/json
 
@@ -119,7 +183,7 @@ func (app *Application) CreateBusiness(writer http.ResponseWriter, request *http
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			businessErrorStatusCode(err),
 			err.Error())
 
 		return
@@ -281,6 +345,63 @@ If a specified field's value should be deleted from the record, the appropriate
 
 				Name of the business
 
+			allow_overlapping_bookings  <bool>
+
+				True if users may book this business's services at times that overlap their other appointments
+
+			billing_policy  <string>
+
+				When appointments for the business's services are invoiced: "At Booking", "At Completion", or "None"
+
+			currency  <string>
+
+				ISO 4217 currency of the business's prices and invoices
+
+			invoice_prefix  <string>
+
+				Prefix of the business's invoice numbers. Invoices issued afterwards use the new prefix, and the sequence of numbers continues.
+
+			credit_note_prefix  <string>
+
+				Prefix of the business's credit note numbers. Credit notes issued afterwards use the new prefix.
+
+			payment_terms_days  <uint>
+
+				Days after an invoice is issued that it is due (0 for due on receipt). Invoices issued afterwards use the new terms.
+
+			late_fees_enabled  <bool>
+
+				True if overdue invoices are charged a late fee automatically (a late fee amount or rate is then required)
+
+			late_fee_amount  <int>
+
+				Flat part of the late fee (in cents)
+
+			late_fee_rate  <uint>
+
+				Part of the late fee charged on the overdue balance (in basis points)
+
+			late_fee_grace_days  <uint>
+
+				Days after the due date before the late fee is charged
+
+			state  <string>
+
+				State (2 letter abbreviation) that the business is located in. Invoices created afterwards are taxed at the new location's rate.
+
+			zip  <string>
+
+				Zip code that the business is located in
+
+			tax_jurisdiction  <string>
+
+				Code of the sales tax jurisdiction that the business is taxed in (must have a tax rate, or empty to be taxed by state and zip code)
+
+			receivables_account, revenue_account, sales_tax_account, deposit_account, customer_credit_account  <string>
+
+				Names of the ledger accounts that the business's transactions are posted to in accounting exports. Exports made afterwards
+				use the new accounts, so keep them stable once exports have been imported.
+
 *Example request(s)*
 
 	PUT /business/456
@@ -305,7 +426,7 @@ If a specified field's value should be deleted from the record, the appropriate
 		}
 
 	Failure:
-		-- Case = Bad request body or missing/misformatted ID in request URL
+		-- Case = Bad request body, missing/misformatted ID in request URL, or invalid billing policy, currency, number prefix, payment terms or account mapping, or an address_id update (see SetBusinessAddress)
 		HTTP/1.1 400 Bad Request
 		Content-Type: application/json
 
@@ -313,6 +434,14 @@ If a specified field's value should be deleted from the record, the appropriate
 		"error":"ERROR MESSAGE TEXT HERE"
 		}
 
+		-- Case = Business ID does not exist in the database
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
 		-- Case = Database operation error
 		HTTP/1.1 500 Internal Server Error
 		Content-Type: application/json
@@ -349,7 +478,7 @@ func (app *Application) UpdateBusiness(writer http.ResponseWriter, request *http
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			businessErrorStatusCode(err),
 			err.Error())
 
 		return
@@ -462,7 +591,8 @@ func (app *Application) DeleteBusiness(writer http.ResponseWriter, request *http
 
 func GetBusinesses
 
-Get a list of all Business records in the database.
+Get a page of the Business records in the database, sorted and filtered as requested. Pages are found with the cursor returned
+with the previous page, and report the total number of records that match the filters.
 
 *Parameters*
 
@@ -484,14 +614,33 @@ Get a list of all Business records in the database.
 
 	Route:	/businesses
 
-	Body:
+	Query parameters (all optional):
 
-		None
+		limit  <int>
+
+			Number of records in the page (defaults to 50, at most 200).
+
+		cursor  <string>
+
+			The 'next_cursor' of the previous page (omitted for the first page). Must be used with the same sort.
+
+		sort  <string>
+
+			Comma separated fields to sort by, each prefixed with '-' to sort in descending order. Records that sort equally are sorted
+			by ID. Sortable fields: id, created_at, updated_at, owner_id, name.
+
+		{field}, {field}[{operator}]  <string>
+
+			Filters by a field, with an optional operator: eq (the default), ne, gt, gte, lt, lte, or in (comma separated values). Dates
+			are YYYY-MM-DD or RFC 3339 date/times. Fields: id, created_at, updated_at, owner_id, name, currency, billing_policy, state,
+			zip, tax_jurisdiction.
 
 *Example request(s)*
 
 	GET /businesses
 
+	GET /businesses?state=FL&sort=name&limit=20
+
 *Response format*
 
 	Success:
@@ -499,28 +648,42 @@ Get a list of all Business records in the database.
 		HTTP/1.1 200 OK
 		Content-Type: application/json
 
-		[
-			{
-				"ID": 727,
-				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
-				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
-				"DeletedAt": null,
-				"owner_id": 123,
-				"name": "Later Gator LLC"
-			},
-			{
-				"ID": 813,
-				"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
-				"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
-				"DeletedAt": null,
-				"owner_id": 420,
-				"name": "Abraham's Resort & Spa"
-			},
-			...
-		]
+		{
+			"data":[
+				{
+					"ID": 727,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"owner_id": 123,
+					"name": "Later Gator LLC"
+				},
+				{
+					"ID": 813,
+					"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
+					"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
+					"DeletedAt": null,
+					"owner_id": 420,
+					"name": "Abraham's Resort & Spa"
+				},
+				...
+			],
+			"total_count":124,
+			"limit":50,
+			"next_cursor":"eyJzIjoiIiwidiI6WzQxMV19"
+		}
 
 	Failure:
 
+		-- Case = Invalid limit, cursor, sort or filter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
 		HTTP/1.1 500 InternalServerError
 		Content-Type: application/json
 
@@ -529,14 +692,22 @@ Get a list of all Business records in the database.
 		}
 */
 func (app *Application) GetBusinesses(writer http.ResponseWriter, request *http.Request) {
-	business := models.Business{}
-	var businesses []models.Business
+	options, err := parseListOptions(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
 
-	businesses, err := business.GetAll(app.AppDB)
+		return
+	}
+
+	business := models.Business{}
+	page, err := business.List(app.AppDB, options)
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			listErrorStatusCode(err),
 			err.Error())
 
 		log.Printf("ERROR:  %s", err.Error())
@@ -547,7 +718,7 @@ func (app *Application) GetBusinesses(writer http.ResponseWriter, request *http.
 	utils.RespondWithJSON(
 		writer,
 		http.StatusOK,
-		businesses)
+		page)
 }
 
 /*
@@ -674,8 +845,9 @@ func (app *Application) GetBusinessServices(writer http.ResponseWriter, request
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
 		services)
 }
@@ -732,7 +904,8 @@ Get a list of all the Appointments (and the associated Service for each Appointm
 					"service_id":11,
 					"user_id":42,
 					"cancel_date_time":null,
-					"active":true
+					"status":"Confirmed",
+					"seats":1
 				},
 				"service": {
 					"ID": 11,
@@ -760,7 +933,8 @@ Get a list of all the Appointments (and the associated Service for each Appointm
 					"service_id":83,
 					"user_id":42,
 					"cancel_date_time":null,
-					"active":true
+					"status":"Confirmed",
+					"seats":1
 				},
 				"service": {
 					"ID": 83,
@@ -830,3 +1004,306 @@ func (app *Application) GetBusinessServiceAppointments(writer http.ResponseWrite
 		http.StatusOK,
 		businessSvcAppts)
 }
+
+/*
+*Description*
+
+func SetBusinessAddress
+
+Set the address of a business, creating it or replacing the one that the business already has. Addresses sent without coordinates are
+geocoded from their zip code, so the business can be found by nearby business searches as soon as its address is set.
+
+The business's state and zip fields (which determine its sales tax rate) are not changed.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/business/{id}/address
+
+	Body:
+
+		Format: JSON
+
+		Required fields:
+
+			address1, city, state, zip  <string>
+
+				Street address, city, state (2 letter abbreviation) and zip code of the business
+
+		Optional fields:
+
+			address2  <string>
+
+				Address line 2 (e.g. suite number)
+
+			latitude, longitude  <float>
+
+				Coordinates of the business in degrees (both or neither). If omitted, the address is geocoded.
+
+*Example request(s)*
+
+	PUT /business/456/address
+	{
+		"address1":"1 Gator Way",
+		"city":"Gainesville",
+		"state":"FL",
+		"zip":"32601"
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 12,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"address1":"1 Gator Way",
+			"address2":"",
+			"city":"Gainesville",
+			"state":"FL",
+			"zip":"32601",
+			"latitude":29.6479,
+			"longitude":-82.3249
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, missing address fields or invalid coordinates or zip code
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business ID does not exist in the database
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The address has no coordinates and couldn't be geocoded
+		HTTP/1.1 422 Unprocessable Entity
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) SetBusinessAddress(writer http.ResponseWriter, request *http.Request) {
+	business := models.Business{}
+	businessID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	address := models.Address{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&address); err != nil {
+		utils.RespondWithError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer request.Body.Close()
+
+	returnRecords, err := business.SetAddress(app.AppDB, app.Geocoder, businessID, address)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			businessErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		returnRecords["address"])
+}
+
+/*
+*Description*
+
+func GetBusinessAddress
+
+Get the address of a business, with its coordinates.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/address
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /business/456/address
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 12,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"address1":"1 Gator Way",
+			"address2":"",
+			"city":"Gainesville",
+			"state":"FL",
+			"zip":"32601",
+			"latitude":29.6479,
+			"longitude":-82.3249
+		}
+
+	Failure:
+		-- Case = ID missing from or incorrectly formatted in request url
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business ID does not exist in the database, or the business has no address
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessAddress(writer http.ResponseWriter, request *http.Request) {
+	business := models.Business{}
+	businessID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	address := models.Address{}
+	err = business.GetAddress(app.AppDB, businessID, &address)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			businessErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		&address)
+}
+
+/*
+*Description*
+
+func businessErrorStatusCode
+
+Maps an error returned by a Business model method to the appropriate HTTP status code.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the model method.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error (500 if the error is not a known business error).
+*/
+func businessErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidBillingPolicy),
+		errors.Is(err, models.ErrInvalidNumberPrefix),
+		errors.Is(err, models.ErrInvalidAccountMapping),
+		errors.Is(err, models.ErrInvalidPaymentTerms),
+		errors.Is(err, models.ErrInvalidTaxRate),
+		errors.Is(err, models.ErrInvalidAddress),
+		errors.Is(err, geocoding.ErrInvalidLocation),
+		errors.Is(err, money.ErrInvalidCurrency):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, geocoding.ErrLocationNotFound):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,1113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/models"
+	"server/money"
+	"server/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Body of a request to create a class pack (see 'CreateClassPack')
+type classPackRequest struct {
+	models.ClassPack
+	EligibleServices []string `json:"eligible_services"`
+}
+
+// Body of a request to buy a class pack (see 'PurchaseClassPack')
+type classPackPurchaseRequest struct {
+	UserID uint `json:"user_id"`
+}
+
+/*
+*Description*
+
+func CreateClassPack
+
+Creates a new class pack (a package of prepaid session credits) that the specified Business sells.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/business/{id}/class-packs
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			credits  <uint>
+
+				Number of session credits included in the class pack (must be at least 1)
+
+		Optional fields:
+
+			name  <string>
+
+				Class pack name
+
+			expiry_days  <uint>
+
+				Number of days after purchase that unused credits expire (0 for no expiry)
+
+			price  <uint>
+
+				Price (in cents) of the class pack
+
+			eligible_services  <[]string>
+
+				Names of the Services that the credits can be used for (empty for every Service offered by the Business)
+
+*Example request(s)*
+
+	POST /business/42/class-packs
+	{
+		"name":"10-class pack",
+		"credits":10,
+		"expiry_days":90,
+		"price":15000,
+		"eligible_services":["Tai Chi","Yoga"]
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"class_pack":{
+				"ID": 3,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"name":"10-class pack",
+				"credits":10,
+				"expiry_days":90,
+				"price":15000
+			},
+			"eligible_services":["Tai Chi","Yoga"]
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or class pack without credits
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreateClassPack(writer http.ResponseWriter, request *http.Request) {
+	business := models.Business{}
+	businessID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	businessIDExists, err := business.IDExists(app.AppDB, businessID)
+	if err != nil || !businessIDExists {
+		var errorMessage string = fmt.Sprintf("Business ID (%d) does not exist in the database.", businessID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		return
+	}
+
+	var packRequest classPackRequest
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&packRequest); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	pack := packRequest.ClassPack
+	pack.BusinessID = businessID
+
+	err = app.AppDB.Transaction(func(tx *gorm.DB) error {
+		_, err := pack.Create(tx)
+		if err != nil {
+			return err
+		}
+
+		return pack.SetEligibleServices(tx, pack.ID, packRequest.EligibleServices)
+	})
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			classPackErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	app.respondWithClassPack(writer, request, http.StatusCreated, &pack)
+}
+
+/*
+*Description*
+
+func GetBusinessClassPacks
+
+Get the list of class packs that the specified Business sells.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/class-packs
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /business/42/class-packs
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"class_pack":{
+					"ID": 3,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"business_id":42,
+					"name":"10-class pack",
+					"credits":10,
+					"expiry_days":90,
+					"price":15000
+				},
+				"eligible_services":["Tai Chi","Yoga"]
+			}
+		]
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessClassPacks(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	pack := models.ClassPack{}
+	var businessIDJsonKey string = "business_id"
+	packs, err := pack.GetRecordsBySecondaryID(app.AppDB, businessIDJsonKey, businessID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	packList := []map[string]interface{}{}
+	for i := range packs {
+		eligibleServices, err := pack.GetEligibleServices(app.AppDB, packs[i].ID)
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusInternalServerError,
+				err.Error())
+
+			return
+		}
+
+		packList = append(packList, map[string]interface{}{
+			"class_pack":        &packs[i],
+			"eligible_services": eligibleServices,
+		})
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		packList)
+}
+
+/*
+*Description*
+
+func GetClassPack
+
+Get a class pack record (and the Services its credits can be used for) from the database by ID.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/class-pack/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /class-pack/3
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"class_pack":{
+				"ID": 3,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"name":"10-class pack",
+				"credits":10,
+				"expiry_days":90,
+				"price":15000
+			},
+			"eligible_services":["Tai Chi","Yoga"]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetClassPack(writer http.ResponseWriter, request *http.Request) {
+	packID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	pack := models.ClassPack{}
+	_, err = pack.Get(app.AppDB, packID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Class Pack ID (%d) does not exist in the database.  [%s]", packID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	app.respondWithClassPack(writer, request, http.StatusOK, &pack)
+}
+
+/*
+*Description*
+
+func UpdateClassPack
+
+Updates the specified class pack record in the database. Changes only apply to future purchases.
+
+This function behaves like a PATCH method, rather than a true PUT. Any fields that aren't specified in the request body for the PUT request will not be altered for the specified record.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/class-pack/{id}
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A  --  At least one field should be present in the request body, but no fields are specifically required to be present in the request body.
+
+		Optional fields:
+
+			name  <string>
+
+				Class pack name
+
+			credits  <uint>
+
+				Number of session credits included in the class pack (must be at least 1)
+
+			expiry_days  <uint>
+
+				Number of days after purchase that unused credits expire (0 for no expiry)
+
+			price  <uint>
+
+				Price (in cents) of the class pack
+
+			eligible_services  <[]string>
+
+				Replaces the names of the Services that the credits can be used for (empty for every Service offered by the Business)
+
+*Example request(s)*
+
+	PUT /class-pack/3
+	{
+		"price":14000,
+		"eligible_services":[]
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"class_pack":{
+				"ID": 3,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"name":"10-class pack",
+				"credits":10,
+				"expiry_days":90,
+				"price":14000
+			},
+			"eligible_services":[]
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or class pack without credits
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) UpdateClassPack(writer http.ResponseWriter, request *http.Request) {
+	packID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var updates map[string]interface{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&updates); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	// Eligible services are stored separately from the class pack record
+	var eligibleServices []string
+	eligibleServicesValue, eligibleServicesUpdated := updates["eligible_services"]
+	if eligibleServicesUpdated {
+		delete(updates, "eligible_services")
+
+		serviceNames, isList := eligibleServicesValue.([]interface{})
+		if !isList && eligibleServicesValue != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				"eligible_services must be a list of service names")
+
+			return
+		}
+
+		for _, serviceName := range serviceNames {
+			serviceNameString, isString := serviceName.(string)
+			if !isString {
+				utils.RespondWithError(
+					writer,
+					http.StatusBadRequest,
+					"eligible_services must be a list of service names")
+
+				return
+			}
+			eligibleServices = append(eligibleServices, serviceNameString)
+		}
+	}
+
+	pack := models.ClassPack{}
+	var updatedPack *models.ClassPack
+	err = app.AppDB.Transaction(func(tx *gorm.DB) error {
+		var returnedRecords map[string]models.Model
+		if len(updates) > 0 {
+			returnedRecords, err = pack.Update(tx, packID, updates)
+		} else {
+			returnedRecords, err = pack.Get(tx, packID)
+		}
+
+		updatedPack = returnedRecords["class_pack"].(*models.ClassPack)
+		if err != nil || !eligibleServicesUpdated {
+			return err
+		}
+
+		return pack.SetEligibleServices(tx, packID, eligibleServices)
+	})
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			classPackErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	app.respondWithClassPack(writer, request, http.StatusOK, updatedPack)
+}
+
+/*
+*Description*
+
+func DeleteClassPack
+
+Delete a class pack record from the database by ID, so that it can no longer be purchased. Credits that customers have already purchased can still be used.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	DELETE
+
+	Route:	/class-pack/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	DELETE /class-pack/3
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 3,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": "2023-04-20T04:20:13.5057833-05:00",
+			"business_id":42,
+			"name":"10-class pack",
+			"credits":10,
+			"expiry_days":90,
+			"price":15000
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) DeleteClassPack(writer http.ResponseWriter, request *http.Request) {
+	packID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	pack := models.ClassPack{}
+	returnedRecords, err := pack.Delete(app.AppDB, packID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			classPackErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		returnedRecords["class_pack"])
+}
+
+/*
+*Description*
+
+func PurchaseClassPack
+
+Sells the specified class pack to a User. An Invoice is created for the price of the class pack and the User is credited with the class pack's credits.
+
+Credits are used automatically when the User books an eligible Service, and refunded when the appointment is cancelled in time.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/class-pack/{id}/purchase
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			user_id  <uint>
+
+				ID of User purchasing the class pack
+
+*Example request(s)*
+
+	POST /class-pack/3/purchase
+	{
+		"user_id":123
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"class_pack_purchase":{
+				"ID": 8,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"class_pack_id":3,
+				"user_id":123,
+				"business_id":42,
+				"invoice_id":77,
+				"credits_purchased":10,
+				"credits_remaining":10,
+				"expires_at":"2020-03-31T01:23:45.6789012-05:00"
+			},
+			"invoice":{
+				"ID": 77,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":0,
+				"user_id":123,
+				"subtotal":15000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":15000,
+				"remaining_balance":15000,
+				"status":"Unpaid"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body or missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Class pack ID or User ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) PurchaseClassPack(writer http.ResponseWriter, request *http.Request) {
+	packID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var purchaseRequest classPackPurchaseRequest
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&purchaseRequest); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	user := models.User{}
+	userIDExists, err := user.IDExists(app.AppDB, purchaseRequest.UserID)
+	if err != nil || !userIDExists {
+		var errorMessage string = fmt.Sprintf("User ID (%d) does not exist in the database.", purchaseRequest.UserID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		return
+	}
+
+	purchase := models.ClassPackPurchase{}
+	returnedRecords, err := purchase.Purchase(app.AppDB, packID, purchaseRequest.UserID, time.Now())
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			classPackErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func GetUserClassPackCredits
+
+Get the specified User's class pack credit balance: the number of usable (unexpired) credits, the User's class pack purchases,
+and the history of credit usage (oldest to newest).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/user/{id}/class-pack-credits
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /user/123/class-pack-credits
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"credits_available":9,
+			"purchases":[
+				{
+					"ID": 8,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"class_pack_id":3,
+					"user_id":123,
+					"business_id":42,
+					"invoice_id":77,
+					"credits_purchased":10,
+					"credits_remaining":9,
+					"expires_at":"2020-03-31T01:23:45.6789012-05:00"
+				}
+			],
+			"history":[
+				{
+					"ID": 15,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"class_pack_purchase_id":8,
+					"appointment_id":null,
+					"type":"Purchased",
+					"credits":10,
+					"credits_remaining":10
+				},
+				{
+					"ID": 16,
+					"CreatedAt": "2020-01-02T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"class_pack_purchase_id":8,
+					"appointment_id":456,
+					"type":"Used",
+					"credits":-1,
+					"credits_remaining":9
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetUserClassPackCredits(writer http.ResponseWriter, request *http.Request) {
+	userID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	purchase := models.ClassPackPurchase{}
+	creditsAvailable, purchases, history, err := purchase.GetCreditBalance(app.AppDB, userID, time.Now())
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"credits_available": creditsAvailable,
+			"purchases":         purchases,
+			"history":           history,
+		})
+}
+
+/*
+*Description*
+
+func respondWithClassPack
+
+Responds with the specified class pack record and the names of the Services its credits can be used for.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request (its 'Accept-Language' header chooses the locale that prices are formatted for)
+
+	code  <int>
+
+		The HTTP status code for a successful response
+
+	pack  <*models.ClassPack>
+
+		The class pack record
+
+*Returns*
+
+	None
+*/
+func (app *Application) respondWithClassPack(writer http.ResponseWriter, request *http.Request, code int, pack *models.ClassPack) {
+	eligibleServices, err := pack.GetEligibleServices(app.AppDB, pack.ID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		code,
+		map[string]interface{}{
+			"class_pack":        pack,
+			"eligible_services": eligibleServices,
+		})
+}
+
+/*
+*Description*
+
+func classPackErrorStatusCode
+
+Maps an error returned by a ClassPack or ClassPackPurchase model method to the appropriate HTTP status code.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the model method.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error (500 if the error is not a known class pack error).
+*/
+func classPackErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidClassPack), errors.Is(err, money.ErrInvalidCurrency), errors.Is(err, money.ErrCurrencyMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"server/models"
+	"server/utils"
+)
+
+/*
+*Description*
+
+func CreateCreditNote
+
+Issues a credit note against the specified invoice. Issued invoices can't be changed or deleted, so anything they bill by mistake is
+credited instead. The credit note is numbered in its business's own sequence of credit note numbers (e.g. "CN-000007", see the
+business's 'credit_note_prefix'), and the amount credited is taken off the invoice's balance.
+
+Crediting an invoice in full before anything was paid voids it. If the invoice has already been paid, the credit leaves it overpaid,
+and the difference can be refunded (see 'RefundPayment').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/invoice/{id}/credit-notes
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			reason  <string>
+
+				Reason for the credit (shown on the invoice)
+
+		Optional fields:
+
+			amount  <int>
+
+				Amount to credit (in cents), including tax. Defaults to everything the invoice still bills.
+
+*Example request(s)*
+
+	POST /invoice/123/credit-notes
+	{
+		"amount":1000,
+		"reason":"Mat rental was not used"
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"credit_note":{
+				"ID": 7,
+				"CreatedAt": "2020-01-02T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"invoice_id":123,
+				"business_id":789,
+				"user_id":456,
+				"number":"CN-000007",
+				"sequence":7,
+				"amount":1000,
+				"tax":0,
+				"reason":"Mat rental was not used",
+				"issued_at":"2020-01-02T01:23:45.6789012-05:00",
+				"currency":"USD"
+			},
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":5000,
+				"credit_total":1000,
+				"remaining_balance":4000,
+				"status":"Unpaid"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, a missing reason, more than the invoice bills, or a draft or void invoice
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreateCreditNote(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	creditNote := models.CreditNote{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&creditNote); err != nil && !errors.Is(err, io.EOF) {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	creditNote.InvoiceID = invoiceID
+	returnedRecords, err := creditNote.Create(app.AppDB)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func GetInvoiceCreditNotes
+
+Get the credit notes issued against the specified invoice (oldest first), along with the invoice itself.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/invoice/{id}/credit-notes
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /invoice/123/credit-notes
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":5000,
+				"credit_total":1000,
+				"remaining_balance":4000,
+				"status":"Unpaid"
+			},
+			"credit_notes":[
+				{
+					"ID": 7,
+					"CreatedAt": "2020-01-02T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"invoice_id":123,
+					"business_id":789,
+					"user_id":456,
+					"number":"CN-000007",
+					"sequence":7,
+					"amount":1000,
+					"tax":0,
+					"reason":"Mat rental was not used",
+					"issued_at":"2020-01-02T01:23:45.6789012-05:00",
+					"currency":"USD"
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetInvoiceCreditNotes(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	_, err = invoice.Get(app.AppDB, invoiceID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Invoice ID (%d) does not exist in the database.  [%s]", invoiceID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	creditNote := models.CreditNote{}
+	var invoiceIDJsonKey string = "invoice_id"
+	creditNotes, err := creditNote.GetRecordsBySecondaryID(app.AppDB, invoiceIDJsonKey, invoiceID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"invoice":      &invoice,
+			"credit_notes": creditNotes,
+		})
+}
+
+/*
+*Description*
+
+func GetCreditNote
+
+Get a credit note record from the database by ID.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/credit-note/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /credit-note/7
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 7,
+			"CreatedAt": "2020-01-02T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"invoice_id":123,
+			"business_id":789,
+			"user_id":456,
+			"number":"CN-000007",
+			"sequence":7,
+			"amount":1000,
+			"tax":0,
+			"reason":"Mat rental was not used",
+			"issued_at":"2020-01-02T01:23:45.6789012-05:00",
+			"currency":"USD"
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Credit note not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetCreditNote(writer http.ResponseWriter, request *http.Request) {
+	creditNoteID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	creditNote := models.CreditNote{}
+	returnedRecords, err := creditNote.Get(app.AppDB, creditNoteID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Credit note ID (%d) does not exist in the database.  [%s]", creditNoteID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		returnedRecords["credit_note"])
+}
@@ -0,0 +1,728 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/models"
+	"server/utils"
+)
+
+/*
+*Description*
+
+func CreateGiftCard
+
+Issues a gift card for the specified business. A code is generated for the card, and its balance can be spent on the business's invoices
+with 'Gift Card' payments (see 'CreatePayment').
+
+If the gift card has a purchaser, the purchaser is billed for it with an invoice (without sales tax), and the card can't be spent until
+the invoice is paid. Otherwise the business is giving the card away.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/business/{id}/gift-cards
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			initial_amount  <int>
+
+				Amount (in cents) loaded onto the gift card
+
+		Optional fields:
+
+			currency  <string>
+
+				ISO 4217 currency of the gift card (defaults to the business's currency)
+
+			purchaser_id  <uint>
+
+				ID of the user buying the gift card (omit if the business is giving it away)
+
+			recipient_name  <string>
+
+				Name of the person the gift card is for
+
+			recipient_email  <string>
+
+				Email address of the person the gift card is for
+
+			message  <string>
+
+				Message from the purchaser to the recipient
+
+			expires_at  <time.Time>
+
+				Date/time after which the balance can no longer be spent (omit if the gift card never expires)
+
+*Example request(s)*
+
+	POST /business/789/gift-cards
+	{
+		"initial_amount":5000,
+		"purchaser_id":456,
+		"recipient_name":"Sam Lee",
+		"message":"Happy birthday!"
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"gift_card":{
+				"ID": 12,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":789,
+				"code":"7KQM-X2PA-9RTD-HW4C",
+				"initial_amount":5000,
+				"balance":5000,
+				"currency":"USD",
+				"purchaser_id":456,
+				"invoice_id":124,
+				"recipient_name":"Sam Lee",
+				"recipient_email":"",
+				"message":"Happy birthday!",
+				"expires_at":null
+			},
+			"invoice":{
+				"ID": 124,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":0,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000043",
+				"subtotal":5000,
+				"tax_total":0,
+				"original_balance":5000,
+				"remaining_balance":5000,
+				"status":"Unpaid"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or an invalid amount or currency
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business or purchaser not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreateGiftCard(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	card := models.GiftCard{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&card); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	card.ID = 0
+	card.BusinessID = businessID
+	returnedRecords, err := card.Create(app.AppDB)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func GetBusinessGiftCards
+
+Get the gift cards issued by the specified business (oldest first).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/gift-cards
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /business/789/gift-cards
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 12,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-04T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":789,
+				"code":"7KQM-X2PA-9RTD-HW4C",
+				"initial_amount":5000,
+				"balance":2000,
+				"currency":"USD",
+				"purchaser_id":456,
+				"invoice_id":124,
+				"recipient_name":"Sam Lee",
+				"recipient_email":"",
+				"message":"Happy birthday!",
+				"expires_at":null
+			}
+		]
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessGiftCards(writer http.ResponseWriter, request *http.Request) {
+	app.respondWithGiftCards(writer, request, "business_id")
+}
+
+/*
+*Description*
+
+func GetUserGiftCards
+
+Get the gift cards bought by the specified user (oldest first).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/user/{id}/gift-cards
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /user/456/gift-cards
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 12,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-04T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":789,
+				"code":"7KQM-X2PA-9RTD-HW4C",
+				"initial_amount":5000,
+				"balance":2000,
+				"currency":"USD",
+				"purchaser_id":456,
+				"invoice_id":124,
+				"recipient_name":"Sam Lee",
+				"recipient_email":"",
+				"message":"Happy birthday!",
+				"expires_at":null
+			}
+		]
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetUserGiftCards(writer http.ResponseWriter, request *http.Request) {
+	app.respondWithGiftCards(writer, request, "purchaser_id")
+}
+
+/*
+*Description*
+
+func GetGiftCard
+
+Get a gift card record from the database by ID, along with the history of its balance (oldest first).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/gift-card/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /gift-card/12
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"gift_card":{
+				"ID": 12,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-04T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":789,
+				"code":"7KQM-X2PA-9RTD-HW4C",
+				"initial_amount":5000,
+				"balance":2000,
+				"currency":"USD",
+				"purchaser_id":456,
+				"invoice_id":124,
+				"recipient_name":"Sam Lee",
+				"recipient_email":"",
+				"message":"Happy birthday!",
+				"expires_at":null
+			},
+			"transactions":[
+				{
+					"ID": 20,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"gift_card_id":12,
+					"type":"Issued",
+					"amount":5000,
+					"balance_after":5000,
+					"invoice_id":124,
+					"payment_id":0,
+					"refund_id":0,
+					"currency":"USD"
+				},
+				{
+					"ID": 23,
+					"CreatedAt": "2020-01-04T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-04T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"gift_card_id":12,
+					"type":"Redeemed",
+					"amount":-3000,
+					"balance_after":2000,
+					"invoice_id":130,
+					"payment_id":19,
+					"refund_id":0,
+					"currency":"USD"
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Gift card not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetGiftCard(writer http.ResponseWriter, request *http.Request) {
+	cardID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	card := models.GiftCard{}
+	_, err = card.Get(app.AppDB, cardID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			fmt.Sprintf("Gift Card ID (%d) does not exist in the database.  [%s]", cardID, err))
+
+		return
+	}
+
+	transactions, err := card.GetTransactions(app.AppDB, cardID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"gift_card":    &card,
+			"transactions": transactions,
+		})
+}
+
+/*
+*Description*
+
+func UpdateGiftCard
+
+Updates the recipient, message or expiry of a gift card. The code and amounts can't be changed once the gift card is issued.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/gift-card/{id}
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A
+
+		Optional fields:
+
+			recipient_name  <string>
+
+				Name of the person the gift card is for
+
+			recipient_email  <string>
+
+				Email address of the person the gift card is for
+
+			message  <string>
+
+				Message from the purchaser to the recipient
+
+			expires_at  <time.Time>
+
+				Date/time after which the balance can no longer be spent (null if the gift card never expires)
+
+*Example request(s)*
+
+	PUT /gift-card/12
+	{
+		"recipient_email":"sam@example.com"
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 12,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"business_id":789,
+			"code":"7KQM-X2PA-9RTD-HW4C",
+			"initial_amount":5000,
+			"balance":5000,
+			"currency":"USD",
+			"purchaser_id":456,
+			"invoice_id":124,
+			"recipient_name":"Sam Lee",
+			"recipient_email":"sam@example.com",
+			"message":"Happy birthday!",
+			"expires_at":null
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or a field that can't be changed
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Gift card not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) UpdateGiftCard(writer http.ResponseWriter, request *http.Request) {
+	cardID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var updates map[string]interface{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&updates); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	card := models.GiftCard{}
+	returnedRecords, err := card.Update(app.AppDB, cardID, updates)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		returnedRecords["gift_card"])
+}
+
+/*
+*Description*
+
+func respondWithGiftCards
+
+Responds with the gift cards associated with the ID in the request URL (oldest first).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key that the ID in the request URL is matched on ("business_id" or "purchaser_id").
+
+*Returns*
+
+	None
+*/
+func (app *Application) respondWithGiftCards(writer http.ResponseWriter, request *http.Request, secondaryIDJsonKey string) {
+	secondaryID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	card := models.GiftCard{}
+	cards, err := card.GetRecordsBySecondaryID(app.AppDB, secondaryIDJsonKey, secondaryID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		cards)
+}
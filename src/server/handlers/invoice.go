@@ -2,14 +2,29 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"server/models"
+	"server/money"
+	"server/payments"
+	"server/pdf"
 	"server/utils"
-	_ "time"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// Body of a request to create an invoice (see 'CreateInvoice')
+type createInvoiceRequest struct {
+	models.Invoice
+	LineItems []models.InvoiceLineItem `json:"line_items"`
+	Issue     bool                     `json:"issue"`
+}
+
 /*
 *Description*
 
@@ -17,6 +32,16 @@ func CreateInvoice
 
 Creates a new invoice record in the database.
 
+If the original balance is not specified, the invoice is priced from its Appointment: the Service price for each seat the
+Appointment reserves (see 'Appointment.GetPrice'). If the billed user is not specified, the invoice bills the User that booked the Appointment,
+and if the issuing business is not specified, the invoice is issued by the Business that offers the Appointment's Service.
+
+Every amount on an invoice is in the invoice's currency, which defaults to the currency of the Appointment's Service. Line items in
+another currency are rejected.
+
+Invoices are created as drafts, which can be changed freely and aren't numbered. A draft can't be paid until it is issued (see
+'IssueInvoice'), either in the same request or later.
+
 *Parameters*
 
 	writer  <http.ResponseWriter>
@@ -46,24 +71,44 @@ Creates a new invoice record in the database.
 
 				ID of Appointment record Invoice is associated with
 
+		Optional fields:
+
+			user_id  <uint>
+
+				ID of User record billed by the invoice (defaults to the User that booked the Appointment)
+
+			business_id  <uint>
+
+				ID of Business record that issues the invoice. Always the Business that offers the Appointment's Service when an
+				appointment is specified (a different business_id is ignored).
+
 			original_balance  <int>
 
-				Total original balance of the invoice (in cents)
+				Total original balance of the invoice (in cents). Defaults to the Service price multiplied by the Appointment's seat count.
 
-		Optional fields:
+			currency  <string>
+
+				ISO 4217 currency of the invoice (e.g. "CAD"). Always the currency of the Appointment's Service when an appointment is
+				specified.
+
+			line_items  <[]InvoiceLineItem>
+
+				Charges listed on the invoice, each with a description, quantity, unit_price (in cents), discount (in cents) and
+				tax_rate (in basis points, e.g. 825 for 8.25%). If line items are specified, the invoice's subtotal, tax and
+				original balance are calculated from them instead (see 'SetInvoiceLineItems').
 
-			remaining_balance  <int>
+			due_at  <string>
 
-				Remaining balance of the invoice (in cents)
+				Date/time when the invoice is due (RFC 3339). Defaults to the business's payment terms, counted from when the invoice is issued.
 
-			status  <string>
+			issue  <bool>
 
-				Status of the invoice.
+				If true, the invoice is issued as soon as it is created (see 'IssueInvoice'). Defaults to false.
 
-				Permitted values (automatically set based on remaining balance value):
-					Unpaid
-					Paid
-					Overpaid
+		The remaining balance starts out equal to the original balance and the status starts out as Unpaid. Both are then
+		derived from the payments and refunds recorded against the invoice (see 'CreatePayment' and 'RefundPayment').
+
+		An appointment can only be invoiced again once its previous invoice is void or refunded.
 
 *Example request(s)*
 
@@ -73,6 +118,16 @@ Creates a new invoice record in the database.
 		"original_balance":5000
 	}
 
+	POST /invoice
+	{
+		"appointment_id":123,
+		"line_items":[
+			{"description":"Yoga", "quantity":2, "unit_price":2500, "tax_rate":825},
+			{"description":"Mat rental", "quantity":1, "unit_price":500, "discount":500}
+		],
+		"issue":true
+	}
+
 *Response format*
 
 	Success:
@@ -86,6 +141,13 @@ Creates a new invoice record in the database.
 			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
 			"DeletedAt": null,
 			"appointment_id":123,
+			"user_id":456,
+			"business_id":789,
+			"state":"Issued",
+			"number":"INV-000042",
+			"subtotal":5000,
+			"discount_total":0,
+			"tax_total":0,
 			"original_balance":5000,
 			"remaining_balance":5000,
 			"status":"Unpaid"
@@ -101,6 +163,22 @@ Creates a new invoice record in the database.
 		"error":"ERROR MESSAGE TEXT HERE"
 		}
 
+		-- Case = Appointment ID not found in DB (when pricing the invoice from its Appointment)
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The appointment already has an invoice that is not void or refunded
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
 		-- Case = Database operation error
 		HTTP/1.1 500 Internal Server Error
 		Content-Type: application/json
@@ -110,10 +188,10 @@ Creates a new invoice record in the database.
 		}
 */
 func (app *Application) CreateInvoice(writer http.ResponseWriter, request *http.Request) {
-	invoice := models.Invoice{}
+	var invoiceRequest createInvoiceRequest
 
 	decoder := json.NewDecoder(request.Body)
-	if err := decoder.Decode(&invoice); err != nil {
+	if err := decoder.Decode(&invoiceRequest); err != nil {
 		utils.RespondWithError(
 			writer,
 			http.StatusBadRequest,
@@ -124,19 +202,82 @@ func (app *Application) CreateInvoice(writer http.ResponseWriter, request *http.
 
 	defer request.Body.Close()
 
-	returnedRecords, err := invoice.Create(app.AppDB)
+	invoice := invoiceRequest.Invoice
+	if invoice.AppointmentID != 0 {
+		appt := models.Appointment{}
+		_, err := appt.Get(app.AppDB, invoice.AppointmentID)
+		if err != nil {
+			var errorMessage string = fmt.Sprintf("Appointment ID (%d) does not exist in the database.  [%s]", invoice.AppointmentID, err)
+
+			utils.RespondWithError(
+				writer,
+				http.StatusNotFound,
+				errorMessage)
+
+			log.Printf("ERROR:  %s", errorMessage)
+
+			return
+		}
+
+		if invoice.UserID == 0 {
+			invoice.UserID = appt.UserID
+		}
+
+		// The appointment is always invoiced by the business that provides its service, in the service's currency
+		service := models.Service{}
+		_, err = service.Get(app.AppDB, appt.ServiceID)
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusInternalServerError,
+				err.Error())
+
+			return
+		}
+
+		invoice.BusinessID = service.BusinessID
+		invoice.Currency = service.Currency
+
+		if invoice.OriginalBalance == 0 && len(invoiceRequest.LineItems) == 0 {
+			price, err := appt.GetPrice(app.AppDB)
+			if err != nil {
+				utils.RespondWithError(
+					writer,
+					http.StatusInternalServerError,
+					err.Error())
+
+				return
+			}
+
+			invoice.OriginalBalance = price
+		}
+	}
+
+	var returnedRecords map[string]models.Model
+	var err error
+	if len(invoiceRequest.LineItems) > 0 {
+		returnedRecords, err = invoice.CreateWithLineItems(app.AppDB, invoiceRequest.LineItems)
+	} else {
+		returnedRecords, err = invoice.Create(app.AppDB)
+	}
+
+	if err == nil && invoiceRequest.Issue {
+		returnedRecords, err = invoice.Issue(app.AppDB, invoice.ID, time.Now())
+	}
+
 	createdInvoice := returnedRecords["invoice"]
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			invoiceErrorStatusCode(err),
 			err.Error())
 
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusCreated,
 		createdInvoice)
 }
@@ -189,6 +330,13 @@ Get invoice record from the database by ID.
 			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
 			"DeletedAt": null,
 			"appointment_id":123,
+			"user_id":456,
+			"business_id":789,
+			"state":"Issued",
+			"number":"INV-000042",
+			"subtotal":5000,
+			"discount_total":0,
+			"tax_total":0,
 			"original_balance":5000,
 			"remaining_balance":5000,
 			"status":"Unpaid"
@@ -237,8 +385,9 @@ func (app *Application) GetInvoice(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
 		returnedInvoice)
 }
@@ -289,21 +438,23 @@ If a specified field's value should be deleted from the record, the appropriate
 
 			original_balance  <int>
 
-				Total original balance of the invoice (in cents)
+				Total original balance of the invoice (in cents). What has already been paid is kept, and the remaining balance and status are derived again.
 
-			remaining_balance  <int>
+			due_at  <string>
 
-				Remaining balance of the invoice (in cents)
+				Date/time when the invoice is due (RFC 3339), or null to use the business's payment terms when the invoice is issued.
 
-			status  <string>
+		The remaining balance and status can't be updated directly. They are derived from the payments and refunds recorded against the
+		invoice (see 'CreatePayment' and 'RefundPayment'). The state, number and payment terms are set when the invoice is issued (see
+		'IssueInvoice').
 
-				Status of the invoice (Unpaid, Paid, Overpaid)
+		Only drafts can be updated. Issued invoices are corrected with a credit note instead (see 'CreateCreditNote').
 
 *Example request(s)*
 
 	PUT /invoice/123456
 	{
-		"remaining_balance":0
+		"original_balance":4000
 	}
 
 *Response format*
@@ -319,13 +470,20 @@ If a specified field's value should be deleted from the record, the appropriate
 			"UpdatedAt": "2020-02-13T04:20:12.6789012-05:00",
 			"DeletedAt": null,
 			"appointment_id":123,
-			"original_balance":5000,
-			"remaining_balance":0,
-			"status":"Paid"
+			"user_id":456,
+			"business_id":789,
+			"state":"Issued",
+			"number":"INV-000042",
+			"subtotal":4000,
+			"discount_total":0,
+			"tax_total":0,
+			"original_balance":4000,
+			"remaining_balance":2000,
+			"status":"Partially Paid"
 		}
 
 	Failure:
-		-- Case = Bad request body or missing/misformatted ID in request URL
+		-- Case = Bad request body, missing/misformatted ID in request URL, or an update to the remaining balance, status, state, number or payment terms
 		HTTP/1.1 400 Bad Request
 		Content-Type: application/json
 
@@ -333,6 +491,22 @@ If a specified field's value should be deleted from the record, the appropriate
 		"error":"ERROR MESSAGE TEXT HERE"
 		}
 
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The invoice has been issued
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
 		-- Case = Database operation error
 		HTTP/1.1 500 Internal Server Error
 		Content-Type: application/json
@@ -373,14 +547,15 @@ func (app *Application) UpdateInvoice(writer http.ResponseWriter, request *http.
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			invoiceErrorStatusCode(err),
 			err.Error())
 
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
 		updatedInvoice)
 }
@@ -394,6 +569,9 @@ Delete an invoice record from the database by invoice ID if the ID exists in the
 
 Deleted invoice record is returned in the response body if the operation is sucessful.
 
+Only drafts can be deleted, so that issued invoice numbers have no gaps. Issued invoices are credited in full instead (see
+'CreateCreditNote').
+
 *Parameters*
 
 	writer  <http.ResponseWriter>
@@ -435,9 +613,16 @@ Deleted invoice record is returned in the response body if the operation is suce
 			"UpdatedAt": "2020-02-13T04:20:12.6789012-05:00",
 			"DeletedAt": "2022-06-31T04:20:12.6789012-05:00",,
 			"appointment_id":123,
+			"user_id":456,
+			"business_id":789,
+			"state":"Draft",
+			"number":"",
+			"subtotal":5000,
+			"discount_total":0,
+			"tax_total":0,
 			"original_balance":5000,
-			"remaining_balance":0,
-			"status":"Paid"
+			"remaining_balance":5000,
+			"status":"Unpaid"
 		}
 
 	Failure:
@@ -449,6 +634,22 @@ Deleted invoice record is returned in the response body if the operation is suce
 		"error":"ERROR MESSAGE TEXT HERE"
 		}
 
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The invoice has been issued
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+		"error":"ERROR MESSAGE TEXT HERE"
+		}
+
 		-- Case = Database operation error
 		HTTP/1.1 500 Internal Server Error
 		Content-Type: application/json
@@ -475,14 +676,15 @@ func (app *Application) DeleteInvoice(writer http.ResponseWriter, request *http.
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			invoiceErrorStatusCode(err),
 			err.Error())
 
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
 		deletedInvoice)
 
@@ -493,7 +695,8 @@ func (app *Application) DeleteInvoice(writer http.ResponseWriter, request *http.
 
 func GetInvoices
 
-Get a list of all Invoice records in the database.
+Get a page of the Invoice records in the database, sorted and filtered as requested. Pages are found with the cursor returned
+with the previous page, and report the total number of records that match the filters.
 
 *Parameters*
 
@@ -515,13 +718,168 @@ Get a list of all Invoice records in the database.
 
 	Route:	/invoices
 
+	Query parameters (all optional):
+
+		limit  <int>
+
+			Number of records in the page (defaults to 50, at most 200).
+
+		cursor  <string>
+
+			The 'next_cursor' of the previous page (omitted for the first page). Must be used with the same sort.
+
+		sort  <string>
+
+			Comma separated fields to sort by, each prefixed with '-' to sort in descending order. Records that sort equally are sorted
+			by ID. Sortable fields: id, created_at, updated_at, user_id, business_id, original_balance, remaining_balance.
+
+		{field}, {field}[{operator}]  <string>
+
+			Filters by a field, with an optional operator: eq (the default), ne, gt, gte, lt, lte, or in (comma separated values). Dates
+			are YYYY-MM-DD or RFC 3339 date/times. Fields: id, created_at, updated_at, user_id, business_id, appointment_id, status,
+			state, number, currency, original_balance, remaining_balance, issued_at, due_at.
+
+*Example request(s)*
+
+	GET /invoices
+
+	GET /invoices?status[in]=Unpaid,Overdue&business_id=789&sort=-remaining_balance
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"data":[
+				{
+					"ID": 123,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"appointment_id":41,
+					"user_id":456,
+					"business_id":789,
+					"state":"Issued",
+					"number":"INV-000042",
+					"subtotal":5000,
+					"discount_total":0,
+					"tax_total":0,
+					"original_balance":5000,
+					"remaining_balance":5000,
+					"status":"Unpaid"
+				},
+				{
+					"ID": 98,
+					"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
+					"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
+					"DeletedAt": null,
+					"appointment_id":292,
+					"user_id":456,
+					"business_id":789,
+					"state":"Issued",
+					"number":"INV-000042",
+					"subtotal":2000,
+					"discount_total":0,
+					"tax_total":0,
+					"original_balance":2000,
+					"remaining_balance":0,
+					"status":"Paid"
+				},
+				...
+			],
+			"total_count":124,
+			"limit":50,
+			"next_cursor":"eyJzIjoiIiwidiI6WzQxMV19"
+		}
+
+	Failure:
+
+		-- Case = Invalid limit, cursor, sort or filter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 InternalServerError
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetInvoices(writer http.ResponseWriter, request *http.Request) {
+	options, err := parseListOptions(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	page, err := invoice.List(app.AppDB, options)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			listErrorStatusCode(err),
+			err.Error())
+
+		log.Printf("ERROR:  %s", err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		&page)
+}
+
+/*
+*Description*
+
+func GetAppointmentInvoices
+
+Get the list of Invoice records (including voided invoices) for the specified Appointment, oldest first.
+
+Appointments are invoiced automatically according to the billing policy of the Business (see 'models.Business.BillingPolicy').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/appointment/{id}/invoices
+
 	Body:
 
 		None
 
 *Example request(s)*
 
-	GET /invoices
+	GET /appointment/41/invoices
 
 *Response format*
 
@@ -537,50 +895,1196 @@ Get a list of all Invoice records in the database.
 				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
 				"DeletedAt": null,
 				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
 				"original_balance":5000,
 				"remaining_balance":5000,
 				"status":"Unpaid"
-			},
-			{
-				"ID": 98,
-				"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
-				"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
-				"DeletedAt": null,
-				"appointment_id":292,
-				"original_balance":2000,
-				"remaining_balance":0,
-				"status":"Paid"
-			},
-			...
+			}
 		]
 
 	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
 
-		HTTP/1.1 500 InternalServerError
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Appointment not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
 		Content-Type: application/json
 
 		{
 			"error":"ERROR MESSAGE TEXT HERE"
 		}
 */
-func (app *Application) GetInvoices(writer http.ResponseWriter, request *http.Request) {
-	invoice := models.Invoice{}
-	var invoices []models.Invoice
+func (app *Application) GetAppointmentInvoices(writer http.ResponseWriter, request *http.Request) {
+	apptID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	appt := models.Appointment{}
+	_, err = appt.Get(app.AppDB, apptID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Appointment ID (%d) does not exist in the database.  [%s]", apptID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
 
-	invoices, err := invoice.GetAll(app.AppDB)
+		return
+	}
+
+	invoice := models.Invoice{}
+	var apptIDJsonKey string = "appointment_id"
+	invoices, err := invoice.GetRecordsBySecondaryID(app.AppDB, apptIDJsonKey, apptID)
 	if err != nil {
 		utils.RespondWithError(
 			writer,
 			http.StatusInternalServerError,
 			err.Error())
 
-		log.Printf("ERROR:  %s", err.Error())
-
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
 		invoices)
 }
+
+/*
+*Description*
+
+func GetUserInvoices
+
+Get the list of Invoice records (including voided invoices) that bill the specified User, oldest first.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/user/{id}/invoices
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /user/456/invoices
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 98,
+				"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
+				"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
+				"DeletedAt": null,
+				"appointment_id":292,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":2000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":2000,
+				"remaining_balance":0,
+				"status":"Paid"
+			},
+			{
+				"ID": 123,
+				"CreatedAt": "2022-12-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2022-12-02T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":0,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":0,
+				"remaining_balance":0,
+				"status":"Void"
+			}
+		]
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetUserInvoices(writer http.ResponseWriter, request *http.Request) {
+	userID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	var userIDJsonKey string = "user_id"
+	invoices, err := invoice.GetRecordsBySecondaryID(app.AppDB, userIDJsonKey, userID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		invoices)
+}
+
+/*
+*Description*
+
+func GetUserBalance
+
+Get the total that the specified user still owes on their issued invoices, with one balance for each currency they are billed in. The
+part of each balance that is overdue is included, so it can be shown on the user's profile.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/user/{id}/balance
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /user/456/balance
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"user_id":456,
+			"balances":[
+				{
+					"currency":"USD",
+					"amount":6500,
+					"overdue_amount":4000,
+					"invoice_count":2,
+					"display":{
+						"locale":"en-US",
+						"amount":"$65.00",
+						"overdue_amount":"$40.00"
+					}
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = User not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetUserBalance(writer http.ResponseWriter, request *http.Request) {
+	userID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	user := models.User{}
+	userExists, err := user.IDExists(app.AppDB, userID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	if !userExists {
+		var errorMessage string = fmt.Sprintf("User ID (%d) does not exist in the database.", userID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	balances, err := user.GetOutstandingBalances(app.AppDB, userID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"user_id":  userID,
+			"balances": balances,
+		})
+}
+
+/*
+*Description*
+
+func invoiceErrorStatusCode
+
+Maps an error returned by an Invoice, CreditNote, Payment, Refund, StoreCreditAccount or GiftCard operation (including requests to the PaymentProvider) to the matching HTTP
+status code.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the operation.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error.
+*/
+func invoiceErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidInvoice),
+		errors.Is(err, models.ErrInvoiceBalanceReadOnly),
+		errors.Is(err, money.ErrInvalidCurrency),
+		errors.Is(err, money.ErrCurrencyMismatch),
+		errors.Is(err, models.ErrInvalidPayment),
+		errors.Is(err, models.ErrInvalidRefund),
+		errors.Is(err, models.ErrInvalidCreditNote),
+		errors.Is(err, models.ErrInvalidStoreCredit),
+		errors.Is(err, models.ErrInvalidGiftCard):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrInvoiceIssued), errors.Is(err, models.ErrDuplicateInvoice):
+		return http.StatusConflict
+	case errors.Is(err, payments.ErrProviderRequest):
+		return http.StatusBadGateway
+	case errors.Is(err, payments.ErrProviderNotConfigured):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+/*
+*Description*
+
+func GetInvoiceLineItems
+
+Get the line items listed on the specified invoice, along with the invoice itself.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/invoice/{id}/line-items
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /invoice/123/line-items
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":500,
+				"tax_total":413,
+				"original_balance":5413,
+				"remaining_balance":5413,
+				"status":"Unpaid"
+			},
+			"line_items":[
+				{
+					"ID": 7,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"invoice_id":123,
+					"description":"Yoga",
+					"quantity":2,
+					"unit_price":2500,
+					"discount":0,
+					"tax_rate":825,
+					"subtotal":5000,
+					"tax":413,
+					"total":5413
+				},
+				{
+					"ID": 8,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"invoice_id":123,
+					"description":"Mat rental",
+					"quantity":1,
+					"unit_price":500,
+					"discount":500,
+					"tax_rate":0,
+					"subtotal":0,
+					"tax":0,
+					"total":0
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetInvoiceLineItems(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	_, err = invoice.Get(app.AppDB, invoiceID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Invoice ID (%d) does not exist in the database.  [%s]", invoiceID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	lineItems, err := invoice.GetLineItems(app.AppDB, invoiceID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"invoice":    &invoice,
+			"line_items": lineItems,
+		})
+}
+
+/*
+*Description*
+
+func SetInvoiceLineItems
+
+Replaces the line items listed on the specified invoice and recalculates its totals. What has already been paid towards the invoice is kept.
+
+Each line's subtotal is its quantity times its unit price, less its discount. Tax is calculated for each line from its subtotal and
+rounded half up to the nearest cent. The invoice's subtotal, discount total and tax total are the sums of its lines, and its original
+balance is its subtotal plus its tax total. Removing every line item from an invoice that has not been paid voids it.
+
+Only the line items of drafts can be replaced. Issued invoices are corrected with a credit note instead (see 'CreateCreditNote').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/invoice/{id}/line-items
+
+	Body:
+		Format: JSON
+
+		A list of line items, each with the following fields:
+
+			description  <string>
+
+				Description of the charge
+
+			quantity  <uint>
+
+				Number of units charged (defaults to 1)
+
+			unit_price  <int>
+
+				Price (in cents) of each unit
+
+			discount  <int>
+
+				Amount (in cents) taken off the line before tax (optional)
+
+			tax_rate  <uint>
+
+				Tax rate in basis points, e.g. 825 for 8.25% (optional)
+
+*Example request(s)*
+
+	PUT /invoice/123/line-items
+	[
+		{"description":"Yoga", "quantity":2, "unit_price":2500, "tax_rate":825},
+		{"description":"Mat rental", "quantity":1, "unit_price":500, "discount":500}
+	]
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"invoice":{ ... },
+			"line_items":[ ... ]
+		}
+
+		(see 'GetInvoiceLineItems')
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or an invalid line item
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The invoice has been issued
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) SetInvoiceLineItems(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var lineItems []models.InvoiceLineItem
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&lineItems); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	invoice := models.Invoice{}
+	returnedRecords, err := invoice.SetLineItems(app.AppDB, invoiceID, lineItems)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"invoice":    returnedRecords["invoice"],
+			"line_items": lineItems,
+		})
+}
+
+/*
+*Description*
+
+func IssueInvoice
+
+Issues the specified draft invoice. The invoice is given the next number in its business's sequence of invoice numbers (e.g.
+"INV-000042", see the business's 'invoice_prefix'), and from then on it can be paid but can no longer be changed or deleted.
+Mistakes on an issued invoice are corrected with a credit note (see 'CreateCreditNote').
+
+The invoice is due on the due date set while it was a draft, or else after the number of days in its business's payment terms (see
+the business's 'payment_terms_days'). Invoices that are still owed after their due date become Overdue, and are followed up with payment
+reminders and late fees by the dunning job.
+
+Invoices that are created automatically (for appointments, class packs and subscriptions) are issued as soon as they are created.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/invoice/{id}/issue
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	POST /invoice/123456/issue
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"invoice":{
+				"ID": 123456,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":123,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"sequence":42,
+				"issued_at":"2020-01-02T01:23:45.6789012-05:00",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":5000,
+				"credit_total":0,
+				"remaining_balance":5000,
+				"status":"Unpaid",
+				"payment_terms_days":30,
+				"due_at":"2020-02-01T01:23:45.6789012-05:00",
+				"late_fee_for_id":0
+			}
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL, a void invoice, or a due date before today
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The invoice has already been issued
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) IssueInvoice(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	returnedRecords, err := invoice.Issue(app.AppDB, invoiceID, time.Now())
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func GetInvoicePDF
+
+Renders the specified invoice as a PDF document, showing the issuing business, the billed customer, the line items, any credit
+notes, the payments and refunds, and the balance due. The same invoice always renders to the same document. Drafts are titled
+"DRAFT INVOICE" and named by their ID (e.g. "invoice-DRAFT-123.pdf") until they are issued.
+
+Invoices that bill a single amount without line items show the amount as one line. Amounts are written in the invoice's currency the
+way they are read in the locale that the request's 'Accept-Language' header asks for (e.g. "1 234,56 $" in fr-CA).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/invoice/{id}/pdf
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /invoice/123/pdf
+	Accept-Language: fr-CA
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/pdf
+		Content-Disposition: inline; filename="invoice-INV-000042.pdf"
+
+		(PDF document)
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetInvoicePDF(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	_, err = invoice.Get(app.AppDB, invoiceID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Invoice ID (%d) does not exist in the database.  [%s]", invoiceID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	document, err := app.getInvoiceDocument(&invoice, money.ParseLocale(request.Header.Get("Accept-Language")))
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithFile(
+		writer,
+		http.StatusOK,
+		"application/pdf",
+		fmt.Sprintf("invoice-%s.pdf", invoiceNumber(&invoice)),
+		pdf.RenderInvoice(document))
+}
+
+/*
+*Description*
+
+func getInvoiceDocument
+
+Collects the contents of the PDF document for an invoice (see 'GetInvoicePDF').
+
+*Parameters*
+
+	invoice  <*models.Invoice>
+
+		The invoice being rendered.
+
+	locale  <string>
+
+		The locale that the document's amounts are formatted for (see 'money.ParseLocale').
+
+*Returns*
+
+	_  <*pdf.Invoice>
+
+		The contents of the invoice document.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (app *Application) getInvoiceDocument(invoice *models.Invoice, locale string) (*pdf.Invoice, error) {
+	businessName, customerName, customerEmail, err := app.getInvoiceParties(invoice)
+	if err != nil {
+		return nil, err
+	}
+
+	lineItems, err := invoice.GetLineItems(app.AppDB, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := models.Payment{}
+	var invoiceIDJsonKey string = "invoice_id"
+	payments, err := payment.GetRecordsBySecondaryID(app.AppDB, invoiceIDJsonKey, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := models.Refund{}
+	refunds, err := refund.GetRecordsBySecondaryID(app.AppDB, invoiceIDJsonKey, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	amountPaid, err := invoice.GetAmountPaid(app.AppDB)
+	if err != nil {
+		return nil, err
+	}
+
+	creditNote := models.CreditNote{}
+	creditNotes, err := creditNote.GetRecordsBySecondaryID(app.AppDB, invoiceIDJsonKey, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drafts are dated when they were created until they are issued
+	var issuedAt time.Time = invoice.CreatedAt
+	if invoice.IssuedAt != nil {
+		issuedAt = *invoice.IssuedAt
+	}
+
+	document := &pdf.Invoice{
+		Number:        invoiceNumber(invoice),
+		Draft:         invoice.State != models.InvoiceStateIssued,
+		BusinessName:  businessName,
+		CustomerName:  customerName,
+		CustomerEmail: customerEmail,
+		IssuedAt:      issuedAt,
+		DueAt:         invoice.DueAt,
+		Status:        invoice.Status,
+		LineItems:     []pdf.LineItem{},
+		Subtotal:      invoice.Subtotal + invoice.DiscountTotal,
+		DiscountTotal: invoice.DiscountTotal,
+		TaxTotal:      invoice.TaxTotal,
+		Total:         invoice.OriginalBalance,
+		Credits:       []pdf.LedgerEntry{},
+		CreditTotal:   invoice.CreditTotal,
+		Transactions:  []pdf.LedgerEntry{},
+		AmountPaid:    amountPaid,
+		BalanceDue:    invoice.RemainingBalance,
+		Currency:      invoice.Currency,
+		Locale:        locale,
+	}
+
+	for _, lineItem := range lineItems {
+		document.LineItems = append(document.LineItems, pdf.LineItem{
+			Description: lineItem.Description,
+			Quantity:    lineItem.Quantity,
+			UnitPrice:   lineItem.UnitPrice,
+			Discount:    lineItem.Discount,
+			Tax:         lineItem.Tax,
+			Total:       lineItem.Total,
+		})
+	}
+
+	// Invoices without line items bill their original balance as a single amount
+	if len(lineItems) == 0 {
+		document.LineItems = append(document.LineItems, pdf.LineItem{
+			Description: "Amount billed",
+			Quantity:    1,
+			UnitPrice:   invoice.OriginalBalance,
+			Total:       invoice.OriginalBalance,
+		})
+	}
+
+	for _, creditNote := range creditNotes {
+		var description string = fmt.Sprintf("Credit note %s: %s", creditNote.Number, creditNote.Reason)
+		document.Credits = append(document.Credits, pdf.LedgerEntry{Date: creditNote.IssuedAt, Description: description, Amount: -creditNote.Amount})
+	}
+
+	for _, payment := range payments {
+		var description string = fmt.Sprintf("%s payment", payment.Method)
+		if payment.Reference != "" {
+			description = fmt.Sprintf("%s (%s)", description, payment.Reference)
+		}
+
+		document.Transactions = append(document.Transactions, pdf.LedgerEntry{Date: payment.PaidAt, Description: description, Amount: payment.Amount})
+	}
+
+	for _, refund := range refunds {
+		var description string = fmt.Sprintf("Refund of payment %d", refund.PaymentID)
+		if refund.Reason != "" {
+			description = fmt.Sprintf("%s: %s", description, refund.Reason)
+		}
+
+		document.Transactions = append(document.Transactions, pdf.LedgerEntry{Date: refund.RefundedAt, Description: description, Amount: -refund.Amount})
+	}
+
+	sort.SliceStable(document.Transactions, func(i, j int) bool {
+		return document.Transactions[i].Date.Before(document.Transactions[j].Date)
+	})
+
+	return document, nil
+}
+
+/*
+*Description*
+
+func getInvoiceParties
+
+Returns the name of the Business that issued an invoice and the name and email address of the User it bills. Names are left blank
+if the records no longer exist.
+
+*Parameters*
+
+	invoice  <*models.Invoice>
+
+		The invoice.
+
+*Returns*
+
+	businessName  <string>
+
+		The name of the issuing Business.
+
+	customerName  <string>
+
+		The full name of the billed User.
+
+	customerEmail  <string>
+
+		The email address of the billed User.
+
+	err  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (app *Application) getInvoiceParties(invoice *models.Invoice) (businessName string, customerName string, customerEmail string, err error) {
+	business := models.Business{}
+	_, err = business.Get(app.AppDB, invoice.BusinessID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", "", err
+	}
+
+	user := models.User{}
+	_, err = user.Get(app.AppDB, invoice.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", "", err
+	}
+
+	return business.Name, strings.TrimSpace(user.FirstName + " " + user.LastName), user.Email, nil
+}
+
+/*
+*Description*
+
+func invoiceNumber
+
+Returns the number that identifies an invoice on its documents. Drafts aren't numbered until they are issued, so they are identified
+by their ID instead (e.g. "DRAFT-123").
+
+*Parameters*
+
+	invoice  <*models.Invoice>
+
+		The invoice.
+
+*Returns*
+
+	_  <string>
+
+		The invoice number.
+*/
+func invoiceNumber(invoice *models.Invoice) string {
+	if invoice.Number == "" {
+		return fmt.Sprintf("DRAFT-%d", invoice.ID)
+	}
+
+	return invoice.Number
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"log"
+	"server/models"
+	"time"
+)
+
+/*
+*Description*
+
+func RunSubscriptionBillingJob
+
+Runs the membership billing job until the application exits. Every subscription whose billing period has ended is invoiced for the next
+period once when the job starts and then once per interval (see 'Subscription.BillDueSubscriptions').
+
+Intended to be run in its own goroutine.
+
+*Parameters*
+
+	interval  <time.Duration>
+
+		The time between billing runs.
+
+*Returns*
+
+	None
+*/
+func (app *Application) RunSubscriptionBillingJob(interval time.Duration) {
+	sub := models.Subscription{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		invoices, err := sub.BillDueSubscriptions(app.AppDB, time.Now())
+		if err != nil {
+			log.Printf("ERROR:  Subscription billing run failed.  [%s]", err)
+		}
+
+		if len(invoices) > 0 {
+			log.Printf("Subscription billing run generated %d invoice(s).", len(invoices))
+		}
+
+		<-ticker.C
+	}
+}
+
+/*
+*Description*
+
+func RunDunningJob
+
+Runs the dunning job until the application exits. Once when the job starts and then once per interval, issued invoices that are past
+their due date are marked Overdue (see 'Invoice.MarkOverdueInvoices'), late fees are charged on overdue invoices of businesses that
+charge them (see 'Invoice.ApplyLateFees'), and the payment reminders that are due are sent through the application's notifier
+(see 'InvoiceReminder.SendDueReminders').
+
+Intended to be run in its own goroutine.
+
+*Parameters*
+
+	interval  <time.Duration>
+
+		The time between dunning runs.
+
+*Returns*
+
+	None
+*/
+func (app *Application) RunDunningJob(interval time.Duration) {
+	invoice := models.Invoice{}
+	reminder := models.InvoiceReminder{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var asOf time.Time = time.Now()
+
+		overdueInvoices, err := invoice.MarkOverdueInvoices(app.AppDB, asOf)
+		if err != nil {
+			log.Printf("ERROR:  Overdue invoices could not be marked.  [%s]", err)
+		}
+
+		feeInvoices, err := invoice.ApplyLateFees(app.AppDB, asOf)
+		if err != nil {
+			log.Printf("ERROR:  Late fee run failed.  [%s]", err)
+		}
+
+		reminders, err := reminder.SendDueReminders(app.AppDB, app.Notifier, asOf)
+		if err != nil {
+			log.Printf("ERROR:  Reminder run failed.  [%s]", err)
+		}
+
+		if len(overdueInvoices) > 0 || len(feeInvoices) > 0 || len(reminders) > 0 {
+			log.Printf("Dunning run marked %d invoice(s) overdue, charged %d late fee(s) and sent %d reminder(s).", len(overdueInvoices), len(feeInvoices), len(reminders))
+		}
+
+		<-ticker.C
+	}
+}
+
+/*
+*Description*
+
+func RunCancellationRefundJob
+
+Runs the cancellation refund job until the application exits. Once when the job starts and then once per interval, the refunds of
+cancelled appointments that could not be completed when they were cancelled are retried through the application's payment provider
+(see 'Appointment.RetryCancellationRefunds').
+
+Intended to be run in its own goroutine.
+
+*Parameters*
+
+	interval  <time.Duration>
+
+		The time between refund runs.
+
+*Returns*
+
+	None
+*/
+func (app *Application) RunCancellationRefundJob(interval time.Duration) {
+	appt := models.Appointment{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		breakdowns, err := appt.RetryCancellationRefunds(app.AppDB, app.PaymentProvider)
+		if err != nil {
+			log.Printf("ERROR:  Cancellation refund run failed.  [%s]", err)
+		}
+
+		var failed int = 0
+		for _, breakdown := range breakdowns {
+			if breakdown.Error != "" {
+				failed++
+				log.Printf("ERROR:  Refund for cancelled Appointment ID (%d) could not be completed.  [%s]", breakdown.AppointmentID, breakdown.Error)
+			}
+		}
+
+		if len(breakdowns) > 0 {
+			log.Printf("Cancellation refund run retried %d refund(s) (%d failed).", len(breakdowns), failed)
+		}
+
+		<-ticker.C
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"server/models"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+)
+
+// Query parameters of list requests that page and sort the list. Every other query parameter is a filter
+var listPagingParameters []string = []string{"limit", "cursor", "sort"}
+
+/*
+*Description*
+
+func parseListOptions
+
+Reads the paging, sorting and filtering of a list request from its query parameters (see 'models.ListOptions'):
+
+	limit   Number of records in the page (defaults to 50, at most 200)
+	cursor  'next_cursor' of the previous page (omitted for the first page)
+	sort    Comma separated fields to sort by, each prefixed with '-' to sort in descending order (e.g. "-price,name")
+
+Every other query parameter filters the list by a field, with an optional operator in brackets: eq (the default), ne, gt, gte, lt, lte,
+or in (comma separated values). For example, '?business_id=42&price[gte]=1000&is_full=false'.
+
+*Parameters*
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	_  <models.ListOptions>
+
+		The paging, sorting and filtering of the list.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func parseListOptions(request *http.Request) (models.ListOptions, error) {
+	query := request.URL.Query()
+	options := models.ListOptions{
+		Cursor:  query.Get("cursor"),
+		Sort:    query.Get("sort"),
+		Filters: map[string]string{},
+	}
+
+	if query.Get("limit") != "" {
+		limit, err := strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			return options, fmt.Errorf("%w: limit '%s' must be a whole number", models.ErrInvalidListOptions, query.Get("limit"))
+		}
+
+		options.Limit = limit
+	}
+
+	for parameter := range query {
+		if !slices.Contains(listPagingParameters, parameter) {
+			options.Filters[parameter] = query.Get(parameter)
+		}
+	}
+
+	return options, nil
+}
+
+/*
+*Description*
+
+func listErrorStatusCode
+
+Maps an error returned while listing records to the HTTP status code of the response.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the model method.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error (500 if the error is not a known list error).
+*/
+func listErrorStatusCode(err error) int {
+	if errors.Is(err, models.ErrInvalidListOptions) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"server/models"
+	"server/money"
+	"server/utils"
+)
+
+/*
+*Description*
+
+func respondWithLocalizedJSON
+
+Responds with JSON like 'utils.RespondWithJSON', after formatting the amounts of every record in the payload for the locale that the
+request asks for in its 'Accept-Language' header (see 'models.Localizable'). The chosen locale is returned in the 'Content-Language'
+header.
+
+Records are found in the payload itself, in maps, in slices and in struct fields, so handlers can pass the same payload they would otherwise respond with.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+	code  <int>
+
+		The HTTP status code of the response
+
+	payload  <interface{}>
+
+		The payload that is encoded as JSON
+
+*Returns*
+
+	None
+*/
+func respondWithLocalizedJSON(writer http.ResponseWriter, request *http.Request, code int, payload interface{}) {
+	locale := money.ParseLocale(request.Header.Get("Accept-Language"))
+	localize(reflect.ValueOf(payload), locale)
+
+	writer.Header().Set("Content-Language", locale)
+	utils.RespondWithJSON(writer, code, payload)
+}
+
+/*
+*Description*
+
+func localize
+
+Formats the amounts of every 'models.Localizable' record found in a value for a locale, looking through pointers, interfaces, maps,
+slices and the exported fields of structs.
+
+*Parameters*
+
+	value  <reflect.Value>
+
+		The value being localized.
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	None
+*/
+func localize(value reflect.Value, locale string) {
+	switch value.Kind() {
+	case reflect.Interface:
+		if !value.IsNil() {
+			localize(value.Elem(), locale)
+		}
+	case reflect.Pointer:
+		if value.IsNil() {
+			return
+		}
+
+		if record, ok := value.Interface().(models.Localizable); ok {
+			record.Localize(locale)
+		} else {
+			localize(value.Elem(), locale)
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			localize(iter.Value(), locale)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			element := value.Index(i)
+			if element.CanAddr() {
+				element = element.Addr()
+			}
+
+			localize(element, locale)
+		}
+	case reflect.Struct:
+		// Envelopes such as 'models.ListPage' hold records in their exported fields
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				localize(value.Field(i), locale)
+			}
+		}
+	}
+}
@@ -0,0 +1,778 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"server/models"
+	"server/utils"
+
+	"gorm.io/gorm"
+)
+
+// Body of a request to create a membership plan (see 'CreateMembershipPlan')
+type membershipPlanRequest struct {
+	models.MembershipPlan
+	IncludedServices []string `json:"included_services"`
+}
+
+/*
+*Description*
+
+func CreateMembershipPlan
+
+Creates a new membership plan (a recurring membership billed every period) that the specified Business sells.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/business/{id}/membership-plans
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A  --  No fields are specifically required to be present in the request body.
+
+		Optional fields:
+
+			name  <string>
+
+				Membership plan name
+
+			price  <uint>
+
+				Price (in cents) charged every billing period
+
+			billing_interval  <string>
+
+				Length of each billing period (Weekly, Monthly, Yearly). Defaults to Monthly.
+
+			visits_per_period  <uint>
+
+				Number of included visits per billing period (0 for unlimited access)
+
+			included_services  <[]string>
+
+				Names of the Services that the membership includes (empty for every Service offered by the Business)
+
+*Example request(s)*
+
+	POST /business/42/membership-plans
+	{
+		"name":"Unlimited yoga",
+		"price":9900,
+		"billing_interval":"Monthly",
+		"visits_per_period":0,
+		"included_services":["Yoga"]
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"membership_plan":{
+				"ID": 5,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"name":"Unlimited yoga",
+				"price":9900,
+				"billing_interval":"Monthly",
+				"visits_per_period":0
+			},
+			"included_services":["Yoga"]
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or invalid billing interval
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreateMembershipPlan(writer http.ResponseWriter, request *http.Request) {
+	business := models.Business{}
+	businessID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	businessIDExists, err := business.IDExists(app.AppDB, businessID)
+	if err != nil || !businessIDExists {
+		var errorMessage string = fmt.Sprintf("Business ID (%d) does not exist in the database.", businessID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		return
+	}
+
+	var planRequest membershipPlanRequest
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&planRequest); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	plan := planRequest.MembershipPlan
+	plan.BusinessID = businessID
+
+	err = app.AppDB.Transaction(func(tx *gorm.DB) error {
+		_, err := plan.Create(tx)
+		if err != nil {
+			return err
+		}
+
+		return plan.SetIncludedServices(tx, plan.ID, planRequest.IncludedServices)
+	})
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			subscriptionErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	app.respondWithMembershipPlan(writer, request, http.StatusCreated, &plan)
+}
+
+/*
+*Description*
+
+func GetBusinessMembershipPlans
+
+Get the list of membership plans that the specified Business sells.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/membership-plans
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /business/42/membership-plans
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"membership_plan":{
+					"ID": 5,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"business_id":42,
+					"name":"Unlimited yoga",
+					"price":9900,
+					"billing_interval":"Monthly",
+					"visits_per_period":0
+				},
+				"included_services":["Yoga"]
+			}
+		]
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessMembershipPlans(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	plan := models.MembershipPlan{}
+	var businessIDJsonKey string = "business_id"
+	plans, err := plan.GetRecordsBySecondaryID(app.AppDB, businessIDJsonKey, businessID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	planList := []map[string]interface{}{}
+	for i := range plans {
+		includedServices, err := plan.GetIncludedServices(app.AppDB, plans[i].ID)
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusInternalServerError,
+				err.Error())
+
+			return
+		}
+
+		planList = append(planList, map[string]interface{}{
+			"membership_plan":   &plans[i],
+			"included_services": includedServices,
+		})
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		planList)
+}
+
+/*
+*Description*
+
+func GetMembershipPlan
+
+Get a membership plan record (and the Services it includes) from the database by ID.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/membership-plan/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /membership-plan/5
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"membership_plan":{
+				"ID": 5,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"name":"Unlimited yoga",
+				"price":9900,
+				"billing_interval":"Monthly",
+				"visits_per_period":0
+			},
+			"included_services":["Yoga"]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetMembershipPlan(writer http.ResponseWriter, request *http.Request) {
+	planID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	plan := models.MembershipPlan{}
+	_, err = plan.Get(app.AppDB, planID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Membership Plan ID (%d) does not exist in the database.  [%s]", planID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	app.respondWithMembershipPlan(writer, request, http.StatusOK, &plan)
+}
+
+/*
+*Description*
+
+func UpdateMembershipPlan
+
+Updates the specified membership plan record in the database. Price changes apply to existing subscriptions from their next billing period.
+
+This function behaves like a PATCH method, rather than a true PUT. Any fields that aren't specified in the request body for the PUT request will not be altered for the specified record.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/membership-plan/{id}
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A  --  At least one field should be present in the request body, but no fields are specifically required to be present in the request body.
+
+		Optional fields:
+
+			name  <string>
+
+				Membership plan name
+
+			price  <uint>
+
+				Price (in cents) charged every billing period
+
+			billing_interval  <string>
+
+				Length of each billing period (Weekly, Monthly, Yearly)
+
+			visits_per_period  <uint>
+
+				Number of included visits per billing period (0 for unlimited access)
+
+			included_services  <[]string>
+
+				Replaces the names of the Services that the membership includes (empty for every Service offered by the Business)
+
+*Example request(s)*
+
+	PUT /membership-plan/5
+	{
+		"price":10900,
+		"included_services":[]
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"membership_plan":{
+				"ID": 5,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"name":"Unlimited yoga",
+				"price":10900,
+				"billing_interval":"Monthly",
+				"visits_per_period":0
+			},
+			"included_services":[]
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or invalid billing interval
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) UpdateMembershipPlan(writer http.ResponseWriter, request *http.Request) {
+	planID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var updates map[string]interface{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&updates); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	// Included services are stored separately from the membership plan record
+	var includedServices []string
+	includedServicesValue, includedServicesUpdated := updates["included_services"]
+	if includedServicesUpdated {
+		delete(updates, "included_services")
+
+		serviceNames, isList := includedServicesValue.([]interface{})
+		if !isList && includedServicesValue != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				"included_services must be a list of service names")
+
+			return
+		}
+
+		for _, serviceName := range serviceNames {
+			serviceNameString, isString := serviceName.(string)
+			if !isString {
+				utils.RespondWithError(
+					writer,
+					http.StatusBadRequest,
+					"included_services must be a list of service names")
+
+				return
+			}
+			includedServices = append(includedServices, serviceNameString)
+		}
+	}
+
+	plan := models.MembershipPlan{}
+	var updatedPlan *models.MembershipPlan
+	err = app.AppDB.Transaction(func(tx *gorm.DB) error {
+		var returnedRecords map[string]models.Model
+		if len(updates) > 0 {
+			returnedRecords, err = plan.Update(tx, planID, updates)
+		} else {
+			returnedRecords, err = plan.Get(tx, planID)
+		}
+
+		updatedPlan = returnedRecords["membership_plan"].(*models.MembershipPlan)
+		if err != nil || !includedServicesUpdated {
+			return err
+		}
+
+		return plan.SetIncludedServices(tx, planID, includedServices)
+	})
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			subscriptionErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	app.respondWithMembershipPlan(writer, request, http.StatusOK, updatedPlan)
+}
+
+/*
+*Description*
+
+func DeleteMembershipPlan
+
+Delete a membership plan record from the database by ID, so that no new subscriptions can be started for it. Existing subscriptions continue to be billed.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	DELETE
+
+	Route:	/membership-plan/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	DELETE /membership-plan/5
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 5,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": "2023-04-20T04:20:13.5057833-05:00",
+			"business_id":42,
+			"name":"Unlimited yoga",
+			"price":9900,
+			"billing_interval":"Monthly",
+			"visits_per_period":0
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) DeleteMembershipPlan(writer http.ResponseWriter, request *http.Request) {
+	planID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	plan := models.MembershipPlan{}
+	returnedRecords, err := plan.Delete(app.AppDB, planID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			subscriptionErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		returnedRecords["membership_plan"])
+}
+
+/*
+*Description*
+
+func respondWithMembershipPlan
+
+Responds with the specified membership plan record and the names of the Services it includes.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request (its 'Accept-Language' header chooses the locale that prices are formatted for)
+
+	code  <int>
+
+		The HTTP status code for a successful response
+
+	plan  <*models.MembershipPlan>
+
+		The membership plan record
+
+*Returns*
+
+	None
+*/
+func (app *Application) respondWithMembershipPlan(writer http.ResponseWriter, request *http.Request, code int, plan *models.MembershipPlan) {
+	includedServices, err := plan.GetIncludedServices(app.AppDB, plan.ID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		code,
+		map[string]interface{}{
+			"membership_plan":   plan,
+			"included_services": includedServices,
+		})
+}
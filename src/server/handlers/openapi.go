@@ -0,0 +1,995 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"server/models"
+	"server/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Version of the OpenAPI specification that the API document follows, and version of the API that it describes
+const (
+	openAPIVersion string = "3.0.3"
+	apiVersion     string = "1.0.0"
+)
+
+// OpenAPI document (see https://spec.openapis.org/oas/v3.0.3). Only the parts of the specification that the API document uses are defined
+type openAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       openAPIInfo                `json:"info"`
+	Servers    []openAPIServer            `json:"servers"`
+	Tags       []openAPITag               `json:"tags"`
+	Paths      map[string]openAPIPathItem `json:"paths"`
+	Components openAPIComponents          `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPITag struct {
+	Name string `json:"name"`
+}
+
+// Operations of a path, keyed by lower case HTTP method
+type openAPIPathItem map[string]*openAPIOperation
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Tags        []string                   `json:"tags"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Style       string         `json:"style,omitempty"`
+	Explode     bool           `json:"explode,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Description string                      `json:"description,omitempty"`
+	Required    bool                        `json:"required"`
+	Content     map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+/*
+*Description*
+
+type apiOperation
+
+Describes one route of the API for the OpenAPI document. Request and response bodies are given as example values (e.g. 'models.User{}'),
+and their schemas are derived from the values' types, so the document follows the models as they change. Values of interface fields
+(e.g. 'models.ListPage.Data') and of 'apiObject' entries give the schema of what the handler actually puts there.
+*/
+type apiOperation struct {
+	method   string             // HTTP method
+	path     string             // Route, as registered with the router (e.g. "/user/{id}")
+	handler  string             // Name of the handler (used as the operation's ID)
+	summary  string             // One line description of the operation
+	query    []openAPIParameter // Query parameters
+	request  interface{}        // Example request body (nil if the request has no body)
+	note     string             // Description of the request body (e.g. for partial updates)
+	optional bool               // True if the request body can be omitted
+	status   int                // HTTP status code of a successful response
+	response interface{}        // Example response body (an 'apiFile' for file downloads)
+	files    []string           // Content types of the files that the response can also be downloaded as (e.g. "text/csv")
+}
+
+// An example JSON object whose properties are the entries of the map, for handlers that respond with keyed records
+type apiObject map[string]interface{}
+
+// Content type of a file that a handler responds with (e.g. "application/pdf")
+type apiFile string
+
+// Body of error responses (see 'utils.RespondWithError' and 'utils.RespondWithErrorCode')
+type errorResponse struct {
+	Error string `json:"error"`          // Error message
+	Code  string `json:"code,omitempty"` // Machine-readable reason code (only set for some errors, e.g. rejected bookings)
+}
+
+// Description of the request body of update requests, which are decoded into a map of updates
+const partialUpdateNote string = "Fields to update. Only the fields that are sent are changed."
+
+// Tag of the operations under each first segment of a route
+var apiTags map[string]string = map[string]string{
+	"register":        "Users",
+	"login":           "Users",
+	"user":            "Users",
+	"users":           "Users",
+	"business":        "Businesses",
+	"businesses":      "Businesses",
+	"service":         "Services",
+	"services":        "Services",
+	"search":          "Search",
+	"appointment":     "Appointments",
+	"appointments":    "Appointments",
+	"class-pack":      "Class packs",
+	"membership-plan": "Memberships",
+	"subscription":    "Memberships",
+	"promo-code":      "Promo codes",
+	"tax-rate":        "Tax rates",
+	"tax-rates":       "Tax rates",
+	"gift-card":       "Gift cards",
+	"invoice":         "Invoices",
+	"invoices":        "Invoices",
+	"credit-note":     "Invoices",
+	"payment":         "Payments",
+	"payment-intent":  "Payments",
+	"webhooks":        "Payments",
+	"openapi.json":    "Documentation",
+}
+
+// Path parameters of a route (e.g. "{id}")
+var pathParameterPattern *regexp.Regexp = regexp.MustCompile(`{([^}]+)}`)
+
+// Response bodies shared by several operations
+var (
+	serviceAppointmentsResponse []apiObject = []apiObject{{"service": models.Service{}, "appointments": []models.Appointment{}}}
+	storeCreditResponse         apiObject   = apiObject{"store_credit_accounts": []models.StoreCreditAccount{}, "transactions": []models.StoreCreditTransaction{}}
+	classPackResponse           apiObject   = apiObject{"class_pack": models.ClassPack{}, "eligible_services": []string{}}
+	membershipPlanResponse      apiObject   = apiObject{"membership_plan": models.MembershipPlan{}, "included_services": []string{}}
+	promoCodeResponse           apiObject   = apiObject{"promo_code": models.PromoCode{}, "eligible_services": []string{}}
+	appointmentRefundResponse   apiObject   = apiObject{"appointment": models.Appointment{}, "refund": (*models.CancellationRefund)(nil)}
+	paymentIntentResponse       apiObject   = apiObject{"payment_intent": models.PaymentIntent{}, "invoice": models.Invoice{}}
+)
+
+// Every route of version 1 of the API, relative to its prefix (see 'initializeV1Routes'). Routes that are added to the router must be
+// added here too, or the OpenAPI test fails
+var apiOperations []apiOperation = []apiOperation{
+	// User routes
+	{method: "POST", path: "/register", handler: "CreateUser", summary: "Register a user (and the business of business accounts)",
+		request: models.User{}, status: http.StatusCreated, response: apiObject{"user": models.User{}, "business": (*models.Business)(nil)}},
+	{method: "POST", path: "/login", handler: "Authenticate", summary: "Log in with an email and password",
+		request: Credentials{}, status: http.StatusOK, response: models.User{}},
+	{method: "GET", path: "/user/{id}", handler: "GetUser", summary: "Get a user",
+		status: http.StatusOK, response: models.User{}},
+	{method: "PUT", path: "/user/{id}", handler: "UpdateUser", summary: "Update a user",
+		request: models.User{}, note: partialUpdateNote, status: http.StatusOK, response: models.User{}},
+	{method: "DELETE", path: "/user/{id}", handler: "DeleteUser", summary: "Delete a user",
+		status: http.StatusOK, response: models.User{}},
+	{method: "GET", path: "/users", handler: "GetUsers", summary: "List users",
+		query: listParameters(), status: http.StatusOK, response: models.ListPage{Data: []models.User{}}},
+	{method: "GET", path: "/user/{id}/service-appointments", handler: "GetUserServiceAppointments", summary: "List a user's active appointments by service",
+		status: http.StatusOK, response: serviceAppointmentsResponse},
+	{method: "GET", path: "/user/{id}/class-pack-credits", handler: "GetUserClassPackCredits", summary: "Get a user's class pack credits",
+		status: http.StatusOK, response: apiObject{"credits_available": uint(0), "purchases": []models.ClassPackPurchase{}, "history": []models.ClassPackCreditTransaction{}}},
+	{method: "GET", path: "/user/{id}/subscriptions", handler: "GetUserSubscriptions", summary: "List a user's subscriptions",
+		status: http.StatusOK, response: []models.Subscription{}},
+	{method: "GET", path: "/user/{id}/invoices", handler: "GetUserInvoices", summary: "List a user's invoices",
+		status: http.StatusOK, response: []models.Invoice{}},
+	{method: "GET", path: "/user/{id}/balance", handler: "GetUserBalance", summary: "Get a user's outstanding balance in each currency",
+		status: http.StatusOK, response: apiObject{"user_id": uint(0), "balances": []models.OutstandingBalance{}}},
+	{method: "POST", path: "/user/{id}/store-credit", handler: "AddStoreCredit", summary: "Add goodwill store credit to a user's account",
+		request: storeCreditRequest{}, status: http.StatusCreated, response: apiObject{"store_credit_account": models.StoreCreditAccount{}, "transaction": models.StoreCreditTransaction{}}},
+	{method: "GET", path: "/user/{id}/store-credit", handler: "GetUserStoreCredit", summary: "Get a user's store credit accounts and transactions",
+		query:  []openAPIParameter{queryParameter("business_id", "integer", "ID of the only business whose store credit is returned")},
+		status: http.StatusOK, response: storeCreditResponse},
+	{method: "GET", path: "/user/{id}/gift-cards", handler: "GetUserGiftCards", summary: "List the gift cards that a user bought",
+		status: http.StatusOK, response: []models.GiftCard{}},
+
+	// Business routes
+	{method: "POST", path: "/business", handler: "CreateBusiness", summary: "Create a business",
+		request: models.Business{}, status: http.StatusCreated, response: apiObject{"business": models.Business{}}},
+	{method: "GET", path: "/business/{id}", handler: "GetBusiness", summary: "Get a business",
+		status: http.StatusOK, response: models.Business{}},
+	{method: "PUT", path: "/business/{id}", handler: "UpdateBusiness", summary: "Update a business",
+		request: models.Business{}, note: partialUpdateNote, status: http.StatusOK, response: models.Business{}},
+	{method: "DELETE", path: "/business/{id}", handler: "DeleteBusiness", summary: "Delete a business",
+		status: http.StatusOK, response: models.Business{}},
+	{method: "GET", path: "/businesses", handler: "GetBusinesses", summary: "List businesses",
+		query: listParameters(), status: http.StatusOK, response: models.ListPage{Data: []models.Business{}}},
+	{method: "GET", path: "/business/{id}/services", handler: "GetBusinessServices", summary: "List a business's services",
+		status: http.StatusOK, response: []models.Service{}},
+	{method: "GET", path: "/business/{id}/address", handler: "GetBusinessAddress", summary: "Get a business's address",
+		status: http.StatusOK, response: models.Address{}},
+	{method: "PUT", path: "/business/{id}/address", handler: "SetBusinessAddress", summary: "Set (and geocode) a business's address",
+		request: models.Address{}, status: http.StatusOK, response: models.Address{}},
+	{method: "GET", path: "/business/{id}/service-appointments", handler: "GetBusinessServiceAppointments", summary: "List the active appointments of a business's services",
+		status: http.StatusOK, response: serviceAppointmentsResponse},
+	{method: "GET", path: "/business/{id}/reports/receivables", handler: "GetBusinessReceivables", summary: "Get a business's accounts receivable aging report",
+		query: []openAPIParameter{
+			queryParameter("as_of", "string", "Date (YYYY-MM-DD) or date/time (RFC 3339) that the invoices are aged to (defaults to now)"),
+			queryParameter("user_id", "integer", "ID of the only customer to include"),
+			queryParameter("detail", "boolean", "If true, each customer's outstanding invoices are listed"),
+			queryParameter("format", "string", `"json" (the default) or "csv"`),
+		},
+		status: http.StatusOK, response: models.ReceivablesReport{}, files: []string{"text/csv"}},
+	{method: "GET", path: "/business/{id}/reports/tax", handler: "GetBusinessTaxReport", summary: "Get a business's sales tax report",
+		query: []openAPIParameter{
+			queryParameter("from", "string", "Date (YYYY-MM-DD) or date/time (RFC 3339) that the period starts at"),
+			queryParameter("to", "string", "Date (YYYY-MM-DD) or date/time (RFC 3339) that the period ends at"),
+			queryParameter("format", "string", `"json" (the default) or "csv"`),
+		},
+		status: http.StatusOK, response: models.TaxReport{}, files: []string{"text/csv"}},
+	{method: "GET", path: "/business/{id}/reports/accounting", handler: "GetBusinessAccountingExport", summary: "Export a business's invoices, payments and refunds as an accounting journal",
+		query: []openAPIParameter{
+			queryParameter("from", "string", "Date (YYYY-MM-DD) or date/time (RFC 3339) that the period starts at"),
+			queryParameter("to", "string", "Date (YYYY-MM-DD) or date/time (RFC 3339) that the period ends at"),
+			queryParameter("incremental", "boolean", "If true, the period starts where the previous incremental export ended and ends a few minutes ago"),
+			queryParameter("format", "string", `"json" (the default), "csv" or "iif"`),
+		},
+		status: http.StatusOK, response: models.AccountingJournal{}, files: []string{"text/csv", "text/plain"}},
+	{method: "GET", path: "/business/{id}/accounting-exports", handler: "GetBusinessAccountingExports", summary: "List a business's accounting exports",
+		status: http.StatusOK, response: []models.AccountingExport{}},
+	{method: "GET", path: "/business/{id}/booking-rules", handler: "GetBusinessBookingRule", summary: "Get a business's default booking rule",
+		status: http.StatusOK, response: models.BookingRule{}},
+	{method: "PUT", path: "/business/{id}/booking-rules", handler: "UpdateBusinessBookingRule", summary: "Set a business's default booking rule",
+		request: models.BookingRule{}, status: http.StatusOK, response: models.BookingRule{}},
+	{method: "POST", path: "/business/{id}/class-packs", handler: "CreateClassPack", summary: "Create a class pack",
+		request: classPackRequest{}, status: http.StatusCreated, response: classPackResponse},
+	{method: "GET", path: "/business/{id}/class-packs", handler: "GetBusinessClassPacks", summary: "List a business's class packs",
+		status: http.StatusOK, response: []apiObject{classPackResponse}},
+	{method: "POST", path: "/business/{id}/membership-plans", handler: "CreateMembershipPlan", summary: "Create a membership plan",
+		request: membershipPlanRequest{}, status: http.StatusCreated, response: membershipPlanResponse},
+	{method: "GET", path: "/business/{id}/membership-plans", handler: "GetBusinessMembershipPlans", summary: "List a business's membership plans",
+		status: http.StatusOK, response: []apiObject{membershipPlanResponse}},
+	{method: "POST", path: "/business/{id}/promo-codes", handler: "CreatePromoCode", summary: "Create a promo code",
+		request: promoCodeRequest{}, status: http.StatusCreated, response: promoCodeResponse},
+	{method: "GET", path: "/business/{id}/promo-codes", handler: "GetBusinessPromoCodes", summary: "List a business's promo codes",
+		status: http.StatusOK, response: []apiObject{promoCodeResponse}},
+	{method: "GET", path: "/business/{id}/store-credit", handler: "GetBusinessStoreCredit", summary: "Get the store credit accounts that a business holds for its customers",
+		status: http.StatusOK, response: storeCreditResponse},
+	{method: "POST", path: "/business/{id}/gift-cards", handler: "CreateGiftCard", summary: "Sell a gift card",
+		request: models.GiftCard{}, status: http.StatusCreated, response: apiObject{"gift_card": models.GiftCard{}, "invoice": (*models.Invoice)(nil)}},
+	{method: "GET", path: "/business/{id}/gift-cards", handler: "GetBusinessGiftCards", summary: "List a business's gift cards",
+		status: http.StatusOK, response: []models.GiftCard{}},
+
+	// Service routes
+	{method: "POST", path: "/service", handler: "CreateService", summary: "Create a service",
+		request: models.Service{}, status: http.StatusCreated, response: models.Service{}},
+	{method: "GET", path: "/service/{id}", handler: "GetService", summary: "Get a service",
+		status: http.StatusOK, response: apiObject{"service": models.Service{}}},
+	{method: "PUT", path: "/service/{id}", handler: "UpdateService", summary: "Update a service",
+		request: models.Service{}, note: partialUpdateNote, status: http.StatusOK, response: models.Service{}},
+	{method: "DELETE", path: "/service/{id}", handler: "DeleteService", summary: "Delete a service",
+		status: http.StatusOK, response: models.Service{}},
+	{method: "GET", path: "/services", handler: "GetServices", summary: "List services",
+		query: listParameters(), status: http.StatusOK, response: models.ListPage{Data: []models.Service{}}},
+	{method: "GET", path: "/service/{service-id}/user/{user-id}", handler: "GetUserEnrolledStatus", summary: "Check whether a user has an appointment for a service",
+		status: http.StatusOK, response: false},
+	{method: "GET", path: "/service/{id}/users", handler: "GetListOfEnrolledUsers", summary: "List the users with an appointment for a service",
+		status: http.StatusOK, response: []models.User{}},
+	{method: "GET", path: "/service/{id}/user-count", handler: "GetEnrolledUsersCount", summary: "Count the users with an appointment for a service",
+		status: http.StatusOK, response: 0},
+	{method: "GET", path: "/service/{id}/appointments", handler: "GetActiveServiceAppointments", summary: "List a service's active appointments",
+		status: http.StatusOK, response: []models.Appointment{}},
+	{method: "GET", path: "/service/{id}/appointments/active", handler: "GetActiveServiceAppointments", summary: "List a service's active appointments",
+		status: http.StatusOK, response: []models.Appointment{}},
+	{method: "GET", path: "/service/{id}/appointments/all", handler: "GetServiceAppointments", summary: "List all of a service's appointments",
+		status: http.StatusOK, response: []models.Appointment{}},
+	{method: "GET", path: "/service/{id}/booking-rules", handler: "GetServiceBookingRule", summary: "Get the booking rule that applies to a service",
+		status: http.StatusOK, response: models.BookingRule{}},
+	{method: "PUT", path: "/service/{id}/booking-rules", handler: "UpdateServiceBookingRule", summary: "Set a service's own booking rule",
+		request: models.BookingRule{}, status: http.StatusOK, response: models.BookingRule{}},
+	{method: "DELETE", path: "/service/{id}/booking-rules", handler: "DeleteServiceBookingRule", summary: "Delete a service's own booking rule",
+		status: http.StatusOK, response: models.BookingRule{}},
+
+	// Search routes
+	{method: "GET", path: "/search", handler: "Search", summary: "Search upcoming services and businesses",
+		query: []openAPIParameter{
+			requiredQueryParameter("q", "string", "Words to search for"),
+			queryParameter("from", "string", "Date (YYYY-MM-DD) or date/time (RFC 3339) that the services found start at or after (defaults to now)"),
+			queryParameter("to", "string", "Date (YYYY-MM-DD) or date/time (RFC 3339) that the services found start before"),
+			queryParameter("price_min", "integer", "Lowest price of the services found (in cents)"),
+			queryParameter("price_max", "integer", "Highest price of the services found (in cents)"),
+			queryParameter("available", "boolean", "If true, only services that aren't full are found"),
+			queryParameter("limit", "integer", "Number of services and of businesses returned (defaults to 20, at most 100)"),
+		},
+		status: http.StatusOK, response: models.SearchResults{}},
+	{method: "GET", path: "/businesses/nearby", handler: "GetNearbyBusinesses", summary: "Find the businesses near a point or zip code",
+		query: []openAPIParameter{
+			queryParameter("lat", "number", "Latitude of the point to search around (required unless zip is set)"),
+			queryParameter("lng", "number", "Longitude of the point to search around (required unless zip is set)"),
+			queryParameter("zip", "string", "Zip code to search around, used if lat and lng aren't set"),
+			queryParameter("radius", "number", "Radius of the search in miles (defaults to 10, at most 250)"),
+			queryParameter("limit", "integer", "Number of businesses returned (defaults to 20, at most 100)"),
+		},
+		status: http.StatusOK, response: []models.NearbyBusiness{}},
+
+	// Appointment routes
+	{method: "POST", path: "/appointment", handler: "CreateAppointment", summary: "Book an appointment (with guests for group bookings)",
+		request: createAppointmentRequest{}, status: http.StatusCreated, response: models.Appointment{}},
+	{method: "GET", path: "/appointment/{id}", handler: "GetAppointment", summary: "Get an appointment",
+		status: http.StatusOK, response: apiObject{"appointment": models.Appointment{}}},
+	{method: "PUT", path: "/appointment/{id}", handler: "UpdateAppointment", summary: "Update an appointment",
+		request: models.Appointment{}, note: partialUpdateNote, status: http.StatusOK, response: models.Appointment{}},
+	{method: "DELETE", path: "/appointment/{id}", handler: "DeleteAppointment", summary: "Delete an appointment",
+		status: http.StatusOK, response: models.Appointment{}},
+	{method: "GET", path: "/appointments", handler: "GetActiveAppointments", summary: "List active appointments",
+		status: http.StatusOK, response: []models.Appointment{}},
+	{method: "GET", path: "/appointments/active", handler: "GetActiveAppointments", summary: "List active appointments",
+		status: http.StatusOK, response: []models.Appointment{}},
+	{method: "GET", path: "/appointments/all", handler: "GetAppointments", summary: "List appointments",
+		query: listParameters(), status: http.StatusOK, response: models.ListPage{Data: []models.Appointment{}}},
+	{method: "POST", path: "/appointment/{id}/cancel", handler: "CancelAppointment", summary: "Cancel an appointment (refunding its invoice if the cancellation policy allows)",
+		status: http.StatusOK, response: appointmentRefundResponse},
+	{method: "POST", path: "/appointment/{id}/status", handler: "UpdateAppointmentStatus", summary: "Change the status of an appointment",
+		request: appointmentStatusRequest{}, status: http.StatusOK, response: appointmentRefundResponse},
+	{method: "GET", path: "/appointment/{id}/status-history", handler: "GetAppointmentStatusHistory", summary: "List an appointment's status changes",
+		status: http.StatusOK, response: []models.AppointmentStatusHistory{}},
+	{method: "GET", path: "/appointment/{id}/guests", handler: "GetAppointmentGuests", summary: "List the guests of a group booking",
+		status: http.StatusOK, response: []models.AppointmentGuest{}},
+	{method: "POST", path: "/appointment/{id}/guests/{guest-id}/cancel", handler: "CancelAppointmentGuest", summary: "Cancel one guest of a group booking",
+		status: http.StatusOK, response: apiObject{"appointment_guest": models.AppointmentGuest{}, "appointment": models.Appointment{}}},
+	{method: "GET", path: "/appointment/{id}/invoices", handler: "GetAppointmentInvoices", summary: "List an appointment's invoices",
+		status: http.StatusOK, response: []models.Invoice{}},
+
+	// Class pack routes
+	{method: "GET", path: "/class-pack/{id}", handler: "GetClassPack", summary: "Get a class pack",
+		status: http.StatusOK, response: classPackResponse},
+	{method: "PUT", path: "/class-pack/{id}", handler: "UpdateClassPack", summary: "Update a class pack",
+		request: classPackRequest{}, note: partialUpdateNote, status: http.StatusOK, response: classPackResponse},
+	{method: "DELETE", path: "/class-pack/{id}", handler: "DeleteClassPack", summary: "Delete a class pack",
+		status: http.StatusOK, response: models.ClassPack{}},
+	{method: "POST", path: "/class-pack/{id}/purchase", handler: "PurchaseClassPack", summary: "Buy a class pack",
+		request: classPackPurchaseRequest{}, status: http.StatusCreated, response: apiObject{"class_pack_purchase": models.ClassPackPurchase{}, "invoice": models.Invoice{}}},
+
+	// Membership routes
+	{method: "GET", path: "/membership-plan/{id}", handler: "GetMembershipPlan", summary: "Get a membership plan",
+		status: http.StatusOK, response: membershipPlanResponse},
+	{method: "PUT", path: "/membership-plan/{id}", handler: "UpdateMembershipPlan", summary: "Update a membership plan",
+		request: membershipPlanRequest{}, note: partialUpdateNote, status: http.StatusOK, response: membershipPlanResponse},
+	{method: "DELETE", path: "/membership-plan/{id}", handler: "DeleteMembershipPlan", summary: "Delete a membership plan",
+		status: http.StatusOK, response: models.MembershipPlan{}},
+	{method: "POST", path: "/membership-plan/{id}/subscribe", handler: "SubscribeToMembershipPlan", summary: "Subscribe a user to a membership plan",
+		request: subscriptionRequest{}, status: http.StatusCreated, response: apiObject{"subscription": models.Subscription{}, "invoice": (*models.Invoice)(nil)}},
+	{method: "GET", path: "/subscription/{id}", handler: "GetSubscription", summary: "Get a subscription with its plan and invoices",
+		status: http.StatusOK, response: apiObject{"subscription": models.Subscription{}, "membership_plan": models.MembershipPlan{}, "invoices": []models.SubscriptionInvoice{}}},
+	{method: "POST", path: "/subscription/{id}/pause", handler: "PauseSubscription", summary: "Pause a subscription",
+		status: http.StatusOK, response: apiObject{"subscription": models.Subscription{}}},
+	{method: "POST", path: "/subscription/{id}/resume", handler: "ResumeSubscription", summary: "Resume a paused subscription",
+		status: http.StatusOK, response: apiObject{"subscription": models.Subscription{}}},
+	{method: "POST", path: "/subscription/{id}/cancel", handler: "CancelSubscription", summary: "Cancel a subscription",
+		status: http.StatusOK, response: apiObject{"subscription": models.Subscription{}}},
+	{method: "POST", path: "/subscription/{id}/change-plan", handler: "ChangeSubscriptionPlan", summary: "Change (or preview changing) the plan of a subscription",
+		request: subscriptionPlanChangeRequest{}, status: http.StatusOK,
+		response: apiObject{"proration": models.SubscriptionProration{}, "subscription": models.Subscription{}, "invoice": (*models.Invoice)(nil)}},
+
+	// Promo code routes
+	{method: "GET", path: "/promo-code/{id}", handler: "GetPromoCode", summary: "Get a promo code",
+		status: http.StatusOK, response: promoCodeResponse},
+	{method: "PUT", path: "/promo-code/{id}", handler: "UpdatePromoCode", summary: "Update a promo code",
+		request: promoCodeRequest{}, note: partialUpdateNote, status: http.StatusOK, response: promoCodeResponse},
+	{method: "DELETE", path: "/promo-code/{id}", handler: "DeletePromoCode", summary: "Delete a promo code",
+		status: http.StatusOK, response: models.PromoCode{}},
+	{method: "GET", path: "/promo-code/{id}/redemptions", handler: "GetPromoCodeRedemptions", summary: "Get a promo code's redemption report",
+		status: http.StatusOK, response: models.PromoCodeRedemptionReport{}},
+
+	// Tax rate routes
+	{method: "POST", path: "/tax-rate", handler: "CreateTaxRate", summary: "Create a tax rate",
+		request: models.TaxRate{}, status: http.StatusCreated, response: models.TaxRate{}},
+	{method: "GET", path: "/tax-rate/{id}", handler: "GetTaxRate", summary: "Get a tax rate",
+		status: http.StatusOK, response: models.TaxRate{}},
+	{method: "PUT", path: "/tax-rate/{id}", handler: "UpdateTaxRate", summary: "Update a tax rate",
+		request: models.TaxRate{}, note: partialUpdateNote, status: http.StatusOK, response: models.TaxRate{}},
+	{method: "DELETE", path: "/tax-rate/{id}", handler: "DeleteTaxRate", summary: "Delete a tax rate",
+		status: http.StatusOK, response: models.TaxRate{}},
+	{method: "GET", path: "/tax-rates", handler: "GetTaxRates", summary: "List tax rates",
+		status: http.StatusOK, response: []models.TaxRate{}},
+
+	// Gift card routes
+	{method: "GET", path: "/gift-card/{id}", handler: "GetGiftCard", summary: "Get a gift card with its transactions",
+		status: http.StatusOK, response: apiObject{"gift_card": models.GiftCard{}, "transactions": []models.GiftCardTransaction{}}},
+	{method: "PUT", path: "/gift-card/{id}", handler: "UpdateGiftCard", summary: "Update a gift card",
+		request: models.GiftCard{}, note: partialUpdateNote, status: http.StatusOK, response: models.GiftCard{}},
+
+	// Invoice routes
+	{method: "POST", path: "/invoice", handler: "CreateInvoice", summary: "Create an invoice (with line items, and issued if requested)",
+		request: createInvoiceRequest{}, status: http.StatusCreated, response: models.Invoice{}},
+	{method: "GET", path: "/invoice/{id}", handler: "GetInvoice", summary: "Get an invoice",
+		status: http.StatusOK, response: apiObject{"invoice": models.Invoice{}}},
+	{method: "PUT", path: "/invoice/{id}", handler: "UpdateInvoice", summary: "Update an invoice",
+		request: models.Invoice{}, note: partialUpdateNote, status: http.StatusOK, response: models.Invoice{}},
+	{method: "DELETE", path: "/invoice/{id}", handler: "DeleteInvoice", summary: "Delete a draft invoice",
+		status: http.StatusOK, response: models.Invoice{}},
+	{method: "GET", path: "/invoices", handler: "GetInvoices", summary: "List invoices",
+		query: listParameters(), status: http.StatusOK, response: models.ListPage{Data: []models.Invoice{}}},
+	{method: "GET", path: "/invoice/{id}/line-items", handler: "GetInvoiceLineItems", summary: "List an invoice's line items",
+		status: http.StatusOK, response: apiObject{"invoice": models.Invoice{}, "line_items": []models.InvoiceLineItem{}}},
+	{method: "PUT", path: "/invoice/{id}/line-items", handler: "SetInvoiceLineItems", summary: "Replace the line items of a draft invoice",
+		request: []models.InvoiceLineItem{}, status: http.StatusOK, response: apiObject{"invoice": models.Invoice{}, "line_items": []models.InvoiceLineItem{}}},
+	{method: "GET", path: "/invoice/{id}/pdf", handler: "GetInvoicePDF", summary: "Download an invoice as a PDF",
+		status: http.StatusOK, response: apiFile("application/pdf")},
+	{method: "POST", path: "/invoice/{id}/issue", handler: "IssueInvoice", summary: "Issue a draft invoice",
+		status: http.StatusOK, response: apiObject{"invoice": models.Invoice{}}},
+	{method: "POST", path: "/invoice/{id}/credit-notes", handler: "CreateCreditNote", summary: "Credit an issued invoice (fully if no amount is given)",
+		request: models.CreditNote{}, optional: true, status: http.StatusCreated, response: apiObject{"credit_note": models.CreditNote{}, "invoice": models.Invoice{}}},
+	{method: "GET", path: "/invoice/{id}/credit-notes", handler: "GetInvoiceCreditNotes", summary: "List an invoice's credit notes",
+		status: http.StatusOK, response: apiObject{"invoice": models.Invoice{}, "credit_notes": []models.CreditNote{}}},
+	{method: "GET", path: "/credit-note/{id}", handler: "GetCreditNote", summary: "Get a credit note",
+		status: http.StatusOK, response: models.CreditNote{}},
+	{method: "POST", path: "/invoice/{id}/payments", handler: "CreatePayment", summary: "Record a payment against an invoice",
+		request: models.Payment{}, status: http.StatusCreated, response: apiObject{"payment": models.Payment{}, "invoice": models.Invoice{}, "receipt_url": ""}},
+	{method: "GET", path: "/invoice/{id}/payments", handler: "GetInvoicePayments", summary: "List an invoice's payments and refunds",
+		status: http.StatusOK, response: apiObject{"invoice": models.Invoice{}, "payments": []models.Payment{}, "refunds": []models.Refund{}}},
+	{method: "GET", path: "/payment/{id}", handler: "GetPayment", summary: "Get a payment with its refunds",
+		status: http.StatusOK, response: apiObject{"payment": models.Payment{}, "refunds": []models.Refund{}}},
+	{method: "POST", path: "/payment/{id}/refund", handler: "RefundPayment", summary: "Refund a payment (fully if no amount is given)",
+		request: models.Refund{}, optional: true, status: http.StatusCreated, response: apiObject{"refund": models.Refund{}, "invoice": models.Invoice{}}},
+	{method: "GET", path: "/payment/{id}/receipt", handler: "GetPaymentReceipt", summary: "Download a payment receipt as a PDF",
+		status: http.StatusOK, response: apiFile("application/pdf")},
+	{method: "POST", path: "/invoice/{id}/store-credit", handler: "CreditInvoiceOverpayment", summary: "Move an invoice's overpayment to the customer's store credit",
+		status: http.StatusCreated, response: apiObject{"invoice": models.Invoice{}, "refunds": []models.Refund{}}},
+
+	// Payment provider routes
+	{method: "POST", path: "/invoice/{id}/payment-intent", handler: "CreatePaymentIntent", summary: "Start collecting an online payment for an invoice",
+		request: paymentIntentRequest{}, optional: true, status: http.StatusCreated, response: paymentIntentResponse},
+	{method: "POST", path: "/payment-intent/{id}/capture", handler: "CapturePaymentIntent", summary: "Capture an authorized online payment",
+		request: paymentIntentCaptureRequest{}, optional: true, status: http.StatusOK, response: paymentIntentResponse},
+	{method: "POST", path: "/webhooks/payments", handler: "HandlePaymentWebhook", summary: "Receive a signed event from the payment provider",
+		request: json.RawMessage{}, note: "Event in the payment provider's format, signed with the webhook secret",
+		status: http.StatusOK, response: apiObject{"received": true, "duplicate": false}},
+
+	// Documentation routes
+	{method: "GET", path: "/openapi.json", handler: "GetOpenAPISpec", summary: "Get this OpenAPI document",
+		status: http.StatusOK, response: apiObject{"openapi": "", "info": apiObject{}, "paths": apiObject{}, "components": apiObject{}}},
+}
+
+/*
+*Description*
+
+func GetOpenAPISpec
+
+Get the OpenAPI 3 document that describes every route of the API, with the schemas of their request and response bodies. The
+interactive documentation at /docs is generated from it.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/api/v1/openapi.json
+
+*Example request(s)*
+
+	GET /api/v1/openapi.json
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"openapi":"3.0.3",
+			"info":{
+				"title":"BizZen API",
+				"description":"...",
+				"version":"1.0.0"
+			},
+			"servers":[
+				{
+					"url":"/api/v1"
+				}
+			],
+			"tags":[...],
+			"paths":{
+				"/user/{id}":{
+					"get":{
+						"operationId":"GetUser",
+						"summary":"Get a user",
+						"tags":["Users"],
+						"parameters":[...],
+						"responses":{...}
+					},
+					...
+				},
+				...
+			},
+			"components":{
+				"schemas":{...}
+			}
+		}
+*/
+func (app *Application) GetOpenAPISpec(writer http.ResponseWriter, request *http.Request) {
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		buildOpenAPIDocument(apiOperations))
+}
+
+/*
+*Description*
+
+func serveAPIDocs
+
+Serves the static webpage './static/docs.html', which renders the OpenAPI document (see 'GetOpenAPISpec') as interactive documentation.
+
+*Parameters*
+
+	None
+
+*Returns*
+
+	None
+*/
+func serveAPIDocs(writer http.ResponseWriter, request *http.Request) {
+	http.ServeFile(writer, request, "./static/docs.html")
+}
+
+/*
+*Description*
+
+func buildOpenAPIDocument
+
+Builds the OpenAPI document of a list of operations. The schemas of request and response bodies are derived from the operations'
+example values (see 'openAPISchemaBuilder'), and named types are shared between operations as components.
+
+*Parameters*
+
+	operations  <[]apiOperation>
+
+		The operations of the API.
+
+*Returns*
+
+	_  <openAPIDocument>
+
+		The OpenAPI document.
+*/
+func buildOpenAPIDocument(operations []apiOperation) openAPIDocument {
+	builder := &openAPISchemaBuilder{schemas: map[string]*openAPISchema{}}
+	document := openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title: "BizZen API",
+			Description: "API of the BizZen appointment booking platform. Request and response schemas are derived from the server's " +
+				"models. Errors are returned as JSON objects with an \"error\" message. The unversioned paths that the API was first " +
+				"served on (e.g. /user/{id}) are deprecated aliases of these paths. Breaking change: an Invoice's appointment is " +
+				"appointment_id (it was previously sent as user_id, which is now the billed user).",
+			Version: apiVersion,
+		},
+		Servers: []openAPIServer{{URL: APIV1Prefix, Description: "Version 1 of the API"}},
+		Tags:    []openAPITag{},
+		Paths:   map[string]openAPIPathItem{},
+	}
+
+	errorSchema := builder.schemaOf(reflect.ValueOf(errorResponse{}))
+	operationIDs := map[string]int{}
+	tags := map[string]bool{}
+
+	for _, operation := range operations {
+		firstSegment, _, _ := strings.Cut(strings.TrimPrefix(operation.path, "/"), "/")
+		tag := apiTags[firstSegment]
+		if !tags[tag] {
+			tags[tag] = true
+			document.Tags = append(document.Tags, openAPITag{Name: tag})
+		}
+
+		// Routes that share a handler (e.g. "/appointments" and "/appointments/active") still need unique operation IDs
+		operationIDs[operation.handler]++
+		operationID := operation.handler
+		if operationIDs[operation.handler] > 1 {
+			operationID = fmt.Sprintf("%s%d", operation.handler, operationIDs[operation.handler])
+		}
+
+		openAPIOperation := &openAPIOperation{
+			OperationID: operationID,
+			Summary:     operation.summary,
+			Tags:        []string{tag},
+			Responses: map[string]openAPIResponse{
+				"default": {
+					Description: "Error",
+					Content:     map[string]openAPIMediaType{"application/json": {Schema: errorSchema}},
+				},
+			},
+		}
+
+		for _, match := range pathParameterPattern.FindAllStringSubmatch(operation.path, -1) {
+			openAPIOperation.Parameters = append(openAPIOperation.Parameters, openAPIParameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &openAPISchema{Type: "integer"},
+			})
+		}
+
+		openAPIOperation.Parameters = append(openAPIOperation.Parameters, operation.query...)
+
+		if operation.request != nil {
+			openAPIOperation.RequestBody = &openAPIRequestBody{
+				Description: operation.note,
+				Required:    !operation.optional,
+				Content:     map[string]openAPIMediaType{"application/json": {Schema: builder.schemaOf(reflect.ValueOf(operation.request))}},
+			}
+		}
+
+		response := openAPIResponse{Description: http.StatusText(operation.status), Content: map[string]openAPIMediaType{}}
+		if file, isFile := operation.response.(apiFile); isFile {
+			response.Content[string(file)] = openAPIMediaType{Schema: &openAPISchema{Type: "string", Format: "binary"}}
+		} else {
+			response.Content["application/json"] = openAPIMediaType{Schema: builder.schemaOf(reflect.ValueOf(operation.response))}
+		}
+
+		for _, contentType := range operation.files {
+			response.Content[contentType] = openAPIMediaType{Schema: &openAPISchema{Type: "string", Format: "binary"}}
+		}
+
+		openAPIOperation.Responses[fmt.Sprint(operation.status)] = response
+
+		if document.Paths[operation.path] == nil {
+			document.Paths[operation.path] = openAPIPathItem{}
+		}
+		document.Paths[operation.path][strings.ToLower(operation.method)] = openAPIOperation
+	}
+
+	document.Components.Schemas = builder.schemas
+	return document
+}
+
+/*
+*Description*
+
+func queryParameter
+
+Creates an optional query parameter.
+
+*Parameters*
+
+	name  <string>
+
+		The name of the parameter.
+
+	schemaType  <string>
+
+		The OpenAPI type of the parameter ("string", "integer", "number" or "boolean").
+
+	description  <string>
+
+		The description of the parameter.
+
+*Returns*
+
+	_  <openAPIParameter>
+
+		The query parameter.
+*/
+func queryParameter(name string, schemaType string, description string) openAPIParameter {
+	return openAPIParameter{Name: name, In: "query", Description: description, Schema: &openAPISchema{Type: schemaType}}
+}
+
+/*
+*Description*
+
+func requiredQueryParameter
+
+Creates a required query parameter (see 'queryParameter').
+
+*Parameters*
+
+	name  <string>
+
+		The name of the parameter.
+
+	schemaType  <string>
+
+		The OpenAPI type of the parameter.
+
+	description  <string>
+
+		The description of the parameter.
+
+*Returns*
+
+	_  <openAPIParameter>
+
+		The query parameter.
+*/
+func requiredQueryParameter(name string, schemaType string, description string) openAPIParameter {
+	parameter := queryParameter(name, schemaType, description)
+	parameter.Required = true
+	return parameter
+}
+
+/*
+*Description*
+
+func listParameters
+
+Returns the query parameters of list requests (see 'parseListOptions'). Filters are free-form, so they are described as one object
+parameter whose properties are exploded into query parameters.
+
+*Parameters*
+
+	None
+
+*Returns*
+
+	_  <[]openAPIParameter>
+
+		The query parameters.
+*/
+func listParameters() []openAPIParameter {
+	return []openAPIParameter{
+		queryParameter("limit", "integer", fmt.Sprintf("Number of records in the page (defaults to %d, at most %d)", models.DefaultListLimit, models.MaxListLimit)),
+		queryParameter("cursor", "string", "'next_cursor' of the previous page (omitted for the first page)"),
+		queryParameter("sort", "string", "Comma separated fields to sort by, each prefixed with '-' to sort in descending order (e.g. \"-price,name\")"),
+		{
+			Name:        "filters",
+			In:          "query",
+			Description: "Filters by field, with an optional operator in brackets: eq (the default), ne, gt, gte, lt, lte or in (e.g. price[gte]=1000)",
+			Style:       "form",
+			Explode:     true,
+			Schema:      &openAPISchema{Type: "object", AdditionalProperties: &openAPISchema{Type: "string"}},
+		},
+	}
+}
+
+// Types whose JSON encoding doesn't follow their Go kind
+var (
+	timeType       reflect.Type = reflect.TypeOf(time.Time{})
+	deletedAtType  reflect.Type = reflect.TypeOf(gorm.DeletedAt{})
+	rawMessageType reflect.Type = reflect.TypeOf(json.RawMessage{})
+)
+
+// Derives OpenAPI schemas from Go values, following the rules of 'encoding/json'. Named struct types become components
+type openAPISchemaBuilder struct {
+	schemas map[string]*openAPISchema // Component schemas, keyed by type name
+}
+
+/*
+*Description*
+
+func schemaOf
+
+Derives the schema of a value's JSON encoding. Nil pointers, empty slices and empty maps are described by their element type, and
+interface values by the value that they hold, so example values can give the schema of fields whose type doesn't (e.g. a
+'models.ListPage' whose 'Data' is a '[]models.User').
+
+*Parameters*
+
+	value  <reflect.Value>
+
+		The value.
+
+*Returns*
+
+	_  <*openAPISchema>
+
+		The value's schema (an empty schema, which allows any value, if the value is nil or can't be described).
+*/
+func (builder *openAPISchemaBuilder) schemaOf(value reflect.Value) *openAPISchema {
+	if !value.IsValid() {
+		return &openAPISchema{}
+	}
+
+	valueType := value.Type()
+	switch valueType {
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case deletedAtType:
+		return &openAPISchema{Type: "string", Format: "date-time", Nullable: true}
+	case rawMessageType:
+		return &openAPISchema{}
+	}
+
+	switch valueType.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			return &openAPISchema{}
+		}
+
+		return builder.schemaOf(value.Elem())
+	case reflect.Ptr:
+		if value.IsNil() {
+			value = reflect.New(valueType.Elem())
+		}
+
+		schema := builder.schemaOf(value.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+
+		return schema
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if valueType.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+
+		item := reflect.New(valueType.Elem()).Elem()
+		if value.Len() > 0 {
+			item = value.Index(0)
+		}
+
+		return &openAPISchema{Type: "array", Items: builder.schemaOf(item)}
+	case reflect.Map:
+		schema := &openAPISchema{Type: "object"}
+		if valueType.Key().Kind() == reflect.String && value.Len() > 0 {
+			schema.Properties = map[string]*openAPISchema{}
+			entries := value.MapRange()
+			for entries.Next() {
+				schema.Properties[entries.Key().String()] = builder.schemaOf(entries.Value())
+			}
+
+			return schema
+		}
+
+		schema.AdditionalProperties = builder.schemaOf(reflect.New(valueType.Elem()).Elem())
+		return schema
+	case reflect.Struct:
+		return builder.structSchema(value)
+	}
+
+	return &openAPISchema{}
+}
+
+/*
+*Description*
+
+func structSchema
+
+Derives the schema of a struct. Named structs are added to the document's components and referenced, except for envelopes with
+interface fields (e.g. 'models.ListPage'), whose schema depends on the value that they hold.
+
+*Parameters*
+
+	value  <reflect.Value>
+
+		The struct.
+
+*Returns*
+
+	_  <*openAPISchema>
+
+		The struct's schema, or a reference to its component.
+*/
+func (builder *openAPISchemaBuilder) structSchema(value reflect.Value) *openAPISchema {
+	valueType := value.Type()
+	if valueType.Name() == "" {
+		return builder.objectSchema(value)
+	}
+
+	for i := 0; i < valueType.NumField(); i++ {
+		if valueType.Field(i).Type.Kind() == reflect.Interface {
+			return builder.objectSchema(value)
+		}
+	}
+
+	// Request types are unexported, but component names are part of the API
+	name := strings.ToUpper(valueType.Name()[:1]) + valueType.Name()[1:]
+	if _, found := builder.schemas[name]; !found {
+		// The component is added before its fields are described, so types that refer to themselves are only described once
+		builder.schemas[name] = &openAPISchema{}
+		*builder.schemas[name] = *builder.objectSchema(reflect.New(valueType).Elem())
+	}
+
+	return &openAPISchema{Ref: "#/components/schemas/" + name}
+}
+
+/*
+*Description*
+
+func objectSchema
+
+Describes a struct as an object with a property per field that is encoded as JSON. Fields of embedded structs (e.g. 'gorm.Model') are
+promoted to the object, unless the outer struct has a field with the same name.
+
+*Parameters*
+
+	value  <reflect.Value>
+
+		The struct.
+
+*Returns*
+
+	_  <*openAPISchema>
+
+		The object schema.
+*/
+func (builder *openAPISchemaBuilder) objectSchema(value reflect.Value) *openAPISchema {
+	schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+	valueType := value.Type()
+
+	fieldName := func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		return name
+	}
+
+	isEmbeddedStruct := func(field reflect.StructField) bool {
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		return field.Anonymous && fieldName(field) == "" && fieldType.Kind() == reflect.Struct
+	}
+
+	for i := 0; i < valueType.NumField(); i++ {
+		if !isEmbeddedStruct(valueType.Field(i)) {
+			continue
+		}
+
+		embedded := value.Field(i)
+		if embedded.Kind() == reflect.Ptr {
+			embedded = reflect.New(embedded.Type().Elem()).Elem()
+		}
+
+		for name, property := range builder.objectSchema(embedded).Properties {
+			schema.Properties[name] = property
+		}
+	}
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		name := fieldName(field)
+		if !field.IsExported() || isEmbeddedStruct(field) || name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = builder.schemaOf(value.Field(i))
+	}
+
+	return schema
+}
@@ -0,0 +1,859 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"server/models"
+	"server/money"
+	"server/pdf"
+	"server/utils"
+)
+
+/*
+*Description*
+
+func CreatePayment
+
+Records a payment against the specified invoice. The invoice's remaining balance and status are derived again from its payments and refunds.
+
+A receipt is produced for every recorded payment, and the response includes the URL it can be downloaded from (see 'GetPaymentReceipt').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/invoice/{id}/payments
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			amount  <int>
+
+				Amount paid (in cents)
+
+			method  <string>
+
+				Payment method (Cash, Card, Bank Transfer, Check, Store Credit, Gift Card, Other). Store Credit is taken from the
+				user's store credit with the invoice's business, and Gift Card from the gift card with 'gift_card_code'. Neither
+				can pay more than the invoice's remaining balance.
+
+		Optional fields:
+
+			gift_card_code  <string>
+
+				Code of the gift card to pay with (required for Gift Card payments)
+
+			reference  <string>
+
+				External reference for the payment (e.g. receipt number or card transaction ID)
+
+			paid_at  <time.Time>
+
+				Date/time when the payment was made (defaults to the current time)
+
+*Example request(s)*
+
+	POST /invoice/123/payments
+	{
+		"amount":3000,
+		"method":"Card",
+		"reference":"ch_3MtwBwLkdIwHu7ix28a3tqPa"
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"payment":{
+				"ID": 17,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"invoice_id":123,
+				"amount":3000,
+				"method":"Card",
+				"reference":"ch_3MtwBwLkdIwHu7ix28a3tqPa",
+				"paid_at":"2020-01-01T01:23:45.6789012-05:00",
+				"balance_after":2000
+			},
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":5000,
+				"remaining_balance":2000,
+				"status":"Partially Paid"
+			},
+			"receipt_url":"/api/v1/payment/17/receipt"
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, invalid amount or method, a draft or void invoice, or not
+		   enough store credit or gift card balance
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreatePayment(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	payment := models.Payment{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&payment); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	payment.ID = 0
+	payment.InvoiceID = invoiceID
+	returnedRecords, err := payment.Create(app.AppDB)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		map[string]interface{}{
+			"payment":     returnedRecords["payment"],
+			"invoice":     returnedRecords["invoice"],
+			"receipt_url": fmt.Sprintf("%s/payment/%d/receipt", APIV1Prefix, payment.ID),
+		})
+}
+
+/*
+*Description*
+
+func GetInvoicePayments
+
+Get the payments and refunds recorded against the specified invoice (oldest first), along with the invoice itself.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/invoice/{id}/payments
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /invoice/123/payments
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":5000,
+				"remaining_balance":3000,
+				"status":"Partially Paid"
+			},
+			"payments":[
+				{
+					"ID": 17,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"invoice_id":123,
+					"amount":3000,
+					"method":"Card",
+					"reference":"ch_3MtwBwLkdIwHu7ix28a3tqPa",
+					"paid_at":"2020-01-01T01:23:45.6789012-05:00",
+					"balance_after":2000
+				}
+			],
+			"refunds":[
+				{
+					"ID": 4,
+					"CreatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"payment_id":17,
+					"invoice_id":123,
+					"amount":1000,
+					"reason":"Guest cancelled",
+					"reference":"",
+					"refunded_at":"2020-01-03T01:23:45.6789012-05:00"
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetInvoicePayments(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	_, err = invoice.Get(app.AppDB, invoiceID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Invoice ID (%d) does not exist in the database.  [%s]", invoiceID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	payment := models.Payment{}
+	var invoiceIDJsonKey string = "invoice_id"
+	payments, err := payment.GetRecordsBySecondaryID(app.AppDB, invoiceIDJsonKey, invoiceID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	refund := models.Refund{}
+	refunds, err := refund.GetRecordsBySecondaryID(app.AppDB, invoiceIDJsonKey, invoiceID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"invoice":  &invoice,
+			"payments": payments,
+			"refunds":  refunds,
+		})
+}
+
+/*
+*Description*
+
+func GetPayment
+
+Get a payment record from the database by ID, along with the refunds made from it.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/payment/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /payment/17
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"payment":{
+				"ID": 17,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"invoice_id":123,
+				"amount":3000,
+				"method":"Card",
+				"reference":"ch_3MtwBwLkdIwHu7ix28a3tqPa",
+				"paid_at":"2020-01-01T01:23:45.6789012-05:00",
+				"balance_after":2000
+			},
+			"refunds":[]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Payment not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetPayment(writer http.ResponseWriter, request *http.Request) {
+	paymentID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	payment := models.Payment{}
+	_, err = payment.Get(app.AppDB, paymentID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Payment ID (%d) does not exist in the database.  [%s]", paymentID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	refund := models.Refund{}
+	var paymentIDJsonKey string = "payment_id"
+	refunds, err := refund.GetRecordsBySecondaryID(app.AppDB, paymentIDJsonKey, paymentID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"payment": &payment,
+			"refunds": refunds,
+		})
+}
+
+/*
+*Description*
+
+func RefundPayment
+
+Refunds all or part of the specified payment. The remaining balance and status of the invoice the payment was applied to are derived again
+from its payments and refunds.
+
+Payments collected through the payment provider (see 'CreatePaymentIntent') are refunded through the provider. Their refunds are
+recorded once the provider reports that they succeeded, with the provider's refund ID as the reference; until then, the returned
+refund has an ID of 0 and the invoice is unchanged.
+
+Refunds of Store Credit and Gift Card payments go back to the store credit or gift card they were paid with. Other payments can be
+refunded as store credit with the invoice's business instead of money (see 'store_credit'), in which case they are never sent to the
+payment provider.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/payment/{id}/refund
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A
+
+		Optional fields:
+
+			amount  <int>
+
+				Amount to refund (in cents). Defaults to everything that has not already been refunded from the payment.
+
+			reason  <string>
+
+				Reason for the refund
+
+			reference  <string>
+
+				External reference for the refund
+
+			store_credit  <bool>
+
+				True to give the refund to the user as store credit instead of money (defaults to false)
+
+*Example request(s)*
+
+	POST /payment/17/refund
+	{
+		"amount":1000,
+		"reason":"Guest cancelled"
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"refund":{
+				"ID": 4,
+				"CreatedAt": "2020-01-03T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"payment_id":17,
+				"invoice_id":123,
+				"amount":1000,
+				"reason":"Guest cancelled",
+				"reference":"",
+				"refunded_at":"2020-01-03T01:23:45.6789012-05:00"
+			},
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":5000,
+				"remaining_balance":3000,
+				"status":"Partially Paid"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or more than the refundable amount requested
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Payment not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The payment provider could not process the refund
+		HTTP/1.1 502 Bad Gateway
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The payment provider is not configured
+		HTTP/1.1 503 Service Unavailable
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) RefundPayment(writer http.ResponseWriter, request *http.Request) {
+	paymentID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	refund := models.Refund{}
+
+	// The request body is optional (an empty body refunds everything that is refundable)
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&refund); err != nil && !errors.Is(err, io.EOF) {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	// Payments collected through the payment provider are refunded through the provider too (unless they are refunded as store credit),
+	// and are recorded once the provider reports that the refund succeeded
+	intent := models.PaymentIntent{}
+	collectedOnline, err := intent.GetByPaymentID(app.AppDB, paymentID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	var returnedRecords map[string]models.Model
+	if collectedOnline && !refund.StoreCredit {
+		returnedRecords, err = intent.Refund(app.AppDB, app.PaymentProvider, paymentID, refund.Amount, refund.Reason)
+	} else {
+		refund.ID = 0
+		refund.PaymentID = paymentID
+		returnedRecords, err = refund.Create(app.AppDB)
+	}
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func GetPaymentReceipt
+
+Renders the receipt for the specified payment as a PDF document, showing the issuing business, the billed customer, the payment
+and the invoice's remaining balance right after the payment was applied.
+
+A receipt is produced for every recorded payment (see 'CreatePayment'). Payments are never modified, so a payment's receipt
+always renders to the same document in a given locale. Amounts are written in the payment's currency the way they are read in the
+locale that the request's 'Accept-Language' header asks for.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/payment/{id}/receipt
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /payment/17/receipt
+	Accept-Language: en-CA
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/pdf
+		Content-Disposition: inline; filename="receipt-17.pdf"
+
+		(PDF document)
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Payment not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetPaymentReceipt(writer http.ResponseWriter, request *http.Request) {
+	paymentID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	payment := models.Payment{}
+	_, err = payment.Get(app.AppDB, paymentID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Payment ID (%d) does not exist in the database.  [%s]", paymentID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	_, err = invoice.Get(app.AppDB, payment.InvoiceID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	businessName, customerName, customerEmail, err := app.getInvoiceParties(&invoice)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	receipt := &pdf.Receipt{
+		Number:        fmt.Sprintf("%d", payment.ID),
+		BusinessName:  businessName,
+		CustomerName:  customerName,
+		CustomerEmail: customerEmail,
+		InvoiceNumber: invoiceNumber(&invoice),
+		PaidAt:        payment.PaidAt,
+		Method:        payment.Method,
+		Reference:     payment.Reference,
+		Amount:        payment.Amount,
+		BalanceAfter:  payment.BalanceAfter,
+		Currency:      payment.Currency,
+		Locale:        money.ParseLocale(request.Header.Get("Accept-Language")),
+	}
+
+	utils.RespondWithFile(
+		writer,
+		http.StatusOK,
+		"application/pdf",
+		fmt.Sprintf("receipt-%d.pdf", payment.ID),
+		pdf.RenderReceipt(receipt))
+}
@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"server/models"
+	"server/payments"
+	"server/utils"
+)
+
+// Body of a request to start a payment intent (see 'CreatePaymentIntent')
+type paymentIntentRequest struct {
+	Amount          int  `json:"amount"`
+	CaptureManually bool `json:"capture_manually"`
+}
+
+// Body of a request to capture a payment intent (see 'CapturePaymentIntent')
+type paymentIntentCaptureRequest struct {
+	Amount int `json:"amount"`
+}
+
+// Largest webhook payload that is accepted from the payment provider (1 MB)
+const maxWebhookPayloadBytes int64 = 1 << 20
+
+/*
+*Description*
+
+func CreatePaymentIntent
+
+Asks the payment provider for a payment intent that the customer pays the specified invoice with. The response includes the intent's
+client secret, which the customer's browser uses to complete the payment with the provider.
+
+The payment is recorded against the invoice when the provider reports that the intent succeeded (see 'HandlePaymentWebhook').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/invoice/{id}/payment-intent
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A
+
+		Optional fields:
+
+			amount  <int>
+
+				Amount to collect (in cents). Defaults to the invoice's remaining balance.
+
+			capture_manually  <bool>
+
+				If true, the payment is only authorized until it is captured (see 'CapturePaymentIntent'). Defaults to false.
+
+*Example request(s)*
+
+	POST /invoice/123/payment-intent
+	{
+		"amount":3000
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"payment_intent":{
+				"ID": 8,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"invoice_id":123,
+				"provider":"stripe",
+				"provider_id":"pi_3MtwBwLkdIwHu7ix28a3tqPa",
+				"amount":3000,
+				"amount_received":0,
+				"status":"Pending",
+				"payment_id":null,
+				"client_secret":"pi_3MtwBwLkdIwHu7ix28a3tqPa_secret_YrKJUKribcBjcG8HVhfZluoGH"
+			},
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":5000,
+				"remaining_balance":5000,
+				"status":"Unpaid"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, more than the remaining balance requested, or a draft or void invoice
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The payment provider could not create the intent
+		HTTP/1.1 502 Bad Gateway
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The payment provider is not configured
+		HTTP/1.1 503 Service Unavailable
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreatePaymentIntent(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var options paymentIntentRequest
+
+	// The request body is optional (an empty body requests the remaining balance)
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&options); err != nil && !errors.Is(err, io.EOF) {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	intent := models.PaymentIntent{}
+	returnedRecords, err := intent.Start(app.AppDB, app.PaymentProvider, invoiceID, options.Amount, options.CaptureManually)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func CapturePaymentIntent
+
+Captures a payment intent that was only authorized (see 'CreatePaymentIntent'). The payment is recorded against the invoice when the
+provider reports that the capture succeeded.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/payment-intent/{id}/capture
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A
+
+		Optional fields:
+
+			amount  <int>
+
+				Amount to capture (in cents). Defaults to everything that was authorized.
+
+*Example request(s)*
+
+	POST /payment-intent/8/capture
+	{
+		"amount":2500
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"payment_intent":{
+				"ID": 8,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"invoice_id":123,
+				"provider":"stripe",
+				"provider_id":"pi_3MtwBwLkdIwHu7ix28a3tqPa",
+				"amount":3000,
+				"amount_received":2500,
+				"status":"Succeeded",
+				"payment_id":17
+			},
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-02T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"subtotal":5000,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":5000,
+				"remaining_balance":2500,
+				"status":"Partially Paid"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or an intent that is not waiting to be captured
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Payment intent not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The payment provider could not capture the intent
+		HTTP/1.1 502 Bad Gateway
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The payment provider is not configured
+		HTTP/1.1 503 Service Unavailable
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CapturePaymentIntent(writer http.ResponseWriter, request *http.Request) {
+	intentID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var options paymentIntentCaptureRequest
+
+	// The request body is optional (an empty body captures everything that was authorized)
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&options); err != nil && !errors.Is(err, io.EOF) {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	intent := models.PaymentIntent{}
+	returnedRecords, err := intent.Capture(app.AppDB, app.PaymentProvider, intentID, options.Amount)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func HandlePaymentWebhook
+
+Receives a webhook event from the payment provider. The event's signature is verified before anything is applied, and the event is
+then applied to the invoice ledger (see 'PaymentEvent.Process').
+
+Providers deliver the same event again if a delivery is not acknowledged, so repeated deliveries are acknowledged without being
+applied again. Events that fail to apply are not acknowledged, so that the provider retries them.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/webhooks/payments
+
+	Headers:
+
+		Stripe-Signature  <string>
+
+			The provider's signature of the payload
+
+	Body:
+		Format: JSON
+
+		The provider's event payload
+
+*Example request(s)*
+
+	POST /webhooks/payments
+	Stripe-Signature: t=1577859825,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+	{
+		"id":"evt_1MtwBwLkdIwHu7ixJlsOvGOo",
+		"type":"payment_intent.succeeded",
+		"created":1577859825,
+		"data":{
+			"object":{
+				"id":"pi_3MtwBwLkdIwHu7ix28a3tqPa",
+				"object":"payment_intent",
+				"amount":3000,
+				"amount_received":3000,
+				"currency":"usd",
+				"status":"succeeded",
+				"metadata":{"invoice_id":"123"}
+			}
+		}
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"received":true,
+			"duplicate":false
+		}
+
+	Failure:
+		-- Case = Missing or invalid signature, or a payload that is too large or cannot be parsed
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The payment provider is not configured
+		HTTP/1.1 503 Service Unavailable
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) HandlePaymentWebhook(writer http.ResponseWriter, request *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxWebhookPayloadBytes))
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	event, err := app.PaymentProvider.VerifyWebhook(payload, request.Header)
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, payments.ErrProviderNotConfigured) {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		log.Printf("ERROR:  %s", err.Error())
+		utils.RespondWithError(
+			writer,
+			statusCode,
+			err.Error())
+
+		return
+	}
+
+	paymentEvent := models.PaymentEvent{}
+	applied, err := paymentEvent.Process(app.AppDB, app.PaymentProvider.Name(), event)
+	if err != nil {
+		log.Printf("ERROR:  %s", err.Error())
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		map[string]bool{"received": true, "duplicate": !applied})
+}
@@ -0,0 +1,1035 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/models"
+	"server/money"
+	"server/utils"
+
+	"gorm.io/gorm"
+)
+
+// Body of a request to create a promo code (see 'CreatePromoCode')
+type promoCodeRequest struct {
+	models.PromoCode
+	EligibleServices []string `json:"eligible_services"`
+}
+
+/*
+*Description*
+
+func CreatePromoCode
+
+Creates a new promo code that customers of the specified Business can enter when booking an appointment (see 'CreateAppointment').
+
+A promo code takes either a percentage or a fixed amount off the appointment's invoice. It can be limited to some of the Business's
+Services, to a validity window, to a number of uses in total and per customer, and to first-time customers.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/business/{id}/promo-codes
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			code  <string>
+
+				Code that customers enter when booking (not case sensitive, unique per Business)
+
+			percent_off  <uint>  OR  amount_off  <uint>
+
+				Percentage taken off in basis points (e.g. 2000 for 20%, at most 10000), or amount (in cents) taken off each appointment.
+				Exactly one of them must be set.
+
+		Optional fields:
+
+			description  <string>
+
+				Description of the promotion
+
+			currency  <string>
+
+				ISO 4217 currency of the fixed discount (defaults to the Business's currency). Fixed discounts can only be used for Services
+				priced in the same currency.
+
+			starts_at  <time.Time>
+
+				Date/time that the code can first be used (null if it can be used straight away)
+
+			ends_at  <time.Time>
+
+				Date/time that the code stops being usable (null if it never expires)
+
+			max_redemptions  <uint>
+
+				Max number of appointments the code can be used for in total (0 for no limit)
+
+			max_redemptions_per_user  <uint>
+
+				Max number of appointments each customer can use the code for (0 for no limit)
+
+			first_time_customers_only  <bool>
+
+				If true, the code can only be used by customers who have no other appointments with the Business
+
+			eligible_services  <[]string>
+
+				Names of the Services that the code can be used for (empty for every Service offered by the Business)
+
+*Example request(s)*
+
+	POST /business/42/promo-codes
+	{
+		"code":"spring10",
+		"description":"$10 off any class this spring",
+		"amount_off":1000,
+		"starts_at":"2023-03-20T00:00:00Z",
+		"ends_at":"2023-06-21T00:00:00Z",
+		"max_redemptions":100,
+		"max_redemptions_per_user":1
+	}
+
+	POST /business/42/promo-codes
+	{
+		"code":"FIRSTYOGA",
+		"description":"20% off your first class",
+		"percent_off":2000,
+		"first_time_customers_only":true,
+		"eligible_services":["Yoga"]
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"promo_code":{
+				"ID": 7,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"code":"SPRING10",
+				"description":"$10 off any class this spring",
+				"percent_off":0,
+				"amount_off":1000,
+				"currency":"USD",
+				"starts_at":"2023-03-20T00:00:00Z",
+				"ends_at":"2023-06-21T00:00:00Z",
+				"max_redemptions":100,
+				"max_redemptions_per_user":1,
+				"first_time_customers_only":false,
+				"display":{"locale":"en-US","amount_off":"$10.00"}
+			},
+			"eligible_services":[]
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, invalid promo code, or a code the Business already has
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreatePromoCode(writer http.ResponseWriter, request *http.Request) {
+	business := models.Business{}
+	businessID, err := utils.ParseRequestID(request)
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	businessIDExists, err := business.IDExists(app.AppDB, businessID)
+	if err != nil || !businessIDExists {
+		var errorMessage string = fmt.Sprintf("Business ID (%d) does not exist in the database.", businessID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		return
+	}
+
+	var promoRequest promoCodeRequest
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&promoRequest); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	promo := promoRequest.PromoCode
+	promo.BusinessID = businessID
+
+	err = app.AppDB.Transaction(func(tx *gorm.DB) error {
+		_, err := promo.Create(tx)
+		if err != nil {
+			return err
+		}
+
+		return promo.SetEligibleServices(tx, promo.ID, promoRequest.EligibleServices)
+	})
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			promoCodeErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	app.respondWithPromoCode(writer, request, http.StatusCreated, &promo)
+}
+
+/*
+*Description*
+
+func GetBusinessPromoCodes
+
+Get the list of promo codes that the specified Business offers.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/promo-codes
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /business/42/promo-codes
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"promo_code":{
+					"ID": 7,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"business_id":42,
+					"code":"FIRSTYOGA",
+					"description":"20% off your first class",
+					"percent_off":2000,
+					"amount_off":0,
+					"currency":"USD",
+					"starts_at":null,
+					"ends_at":null,
+					"max_redemptions":0,
+					"max_redemptions_per_user":0,
+					"first_time_customers_only":true,
+					"display":{"locale":"en-US","amount_off":""}
+				},
+				"eligible_services":["Yoga"]
+			}
+		]
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessPromoCodes(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	promo := models.PromoCode{}
+	var businessIDJsonKey string = "business_id"
+	promos, err := promo.GetRecordsBySecondaryID(app.AppDB, businessIDJsonKey, businessID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	promoList := []map[string]interface{}{}
+	for i := range promos {
+		eligibleServices, err := promo.GetEligibleServices(app.AppDB, promos[i].ID)
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusInternalServerError,
+				err.Error())
+
+			return
+		}
+
+		promoList = append(promoList, map[string]interface{}{
+			"promo_code":        &promos[i],
+			"eligible_services": eligibleServices,
+		})
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		promoList)
+}
+
+/*
+*Description*
+
+func GetPromoCode
+
+Get a promo code record (and the Services it can be used for) from the database by ID.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/promo-code/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /promo-code/7
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"promo_code":{
+				"ID": 7,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"code":"FIRSTYOGA",
+				"description":"20% off your first class",
+				"percent_off":2000,
+				"amount_off":0,
+				"currency":"USD",
+				"starts_at":null,
+				"ends_at":null,
+				"max_redemptions":0,
+				"max_redemptions_per_user":0,
+				"first_time_customers_only":true,
+				"display":{"locale":"en-US","amount_off":""}
+			},
+			"eligible_services":["Yoga"]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetPromoCode(writer http.ResponseWriter, request *http.Request) {
+	promoID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	promo := models.PromoCode{}
+	_, err = promo.Get(app.AppDB, promoID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Promo Code ID (%d) does not exist in the database.  [%s]", promoID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	app.respondWithPromoCode(writer, request, http.StatusOK, &promo)
+}
+
+/*
+*Description*
+
+func UpdatePromoCode
+
+Updates the specified promo code record in the database. Discounts already taken off invoices are not changed.
+
+This function behaves like a PATCH method, rather than a true PUT. Any fields that aren't specified in the request body for the PUT request will not be altered for the specified record.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/promo-code/{id}
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A  --  At least one field should be present in the request body, but no fields are specifically required to be present in the request body.
+
+		Optional fields:
+
+			Any of the fields accepted by 'CreatePromoCode'. The updated promo code must still take either a percentage or a fixed
+			amount off (set the other one to 0 when switching between them).
+
+			eligible_services  <[]string>
+
+				Replaces the names of the Services that the code can be used for (empty for every Service offered by the Business)
+
+*Example request(s)*
+
+	PUT /promo-code/7
+	{
+		"ends_at":"2023-05-01T00:00:00Z",
+		"eligible_services":[]
+	}
+
+	PUT /promo-code/7
+	{
+		"percent_off":0,
+		"amount_off":500
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"promo_code":{
+				"ID": 7,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"code":"FIRSTYOGA",
+				"description":"20% off your first class",
+				"percent_off":2000,
+				"amount_off":0,
+				"currency":"USD",
+				"starts_at":null,
+				"ends_at":"2023-05-01T00:00:00Z",
+				"max_redemptions":0,
+				"max_redemptions_per_user":0,
+				"first_time_customers_only":true,
+				"display":{"locale":"en-US","amount_off":""}
+			},
+			"eligible_services":[]
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, invalid promo code, or a code the Business already has
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) UpdatePromoCode(writer http.ResponseWriter, request *http.Request) {
+	promoID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var updates map[string]interface{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&updates); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	// Eligible services are stored separately from the promo code record
+	var eligibleServices []string
+	eligibleServicesValue, eligibleServicesUpdated := updates["eligible_services"]
+	if eligibleServicesUpdated {
+		delete(updates, "eligible_services")
+
+		serviceNames, isList := eligibleServicesValue.([]interface{})
+		if !isList && eligibleServicesValue != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				"eligible_services must be a list of service names")
+
+			return
+		}
+
+		for _, serviceName := range serviceNames {
+			serviceNameString, isString := serviceName.(string)
+			if !isString {
+				utils.RespondWithError(
+					writer,
+					http.StatusBadRequest,
+					"eligible_services must be a list of service names")
+
+				return
+			}
+			eligibleServices = append(eligibleServices, serviceNameString)
+		}
+	}
+
+	promo := models.PromoCode{}
+	var updatedPromo *models.PromoCode
+	err = app.AppDB.Transaction(func(tx *gorm.DB) error {
+		var returnedRecords map[string]models.Model
+		if len(updates) > 0 {
+			returnedRecords, err = promo.Update(tx, promoID, updates)
+		} else {
+			returnedRecords, err = promo.Get(tx, promoID)
+		}
+
+		updatedPromo = returnedRecords["promo_code"].(*models.PromoCode)
+		if err != nil || !eligibleServicesUpdated {
+			return err
+		}
+
+		return promo.SetEligibleServices(tx, promoID, eligibleServices)
+	})
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			promoCodeErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	app.respondWithPromoCode(writer, request, http.StatusOK, updatedPromo)
+}
+
+/*
+*Description*
+
+func DeletePromoCode
+
+Delete a promo code record from the database by ID, so that it can no longer be used. Discounts already taken off invoices are kept, and
+the code's redemption report is still available.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	DELETE
+
+	Route:	/promo-code/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	DELETE /promo-code/7
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 7,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": "2023-04-20T04:20:13.5057833-05:00",
+			"business_id":42,
+			"code":"FIRSTYOGA",
+			"description":"20% off your first class",
+			"percent_off":2000,
+			"amount_off":0,
+			"currency":"USD",
+			"starts_at":null,
+			"ends_at":null,
+			"max_redemptions":0,
+			"max_redemptions_per_user":0,
+			"first_time_customers_only":true,
+			"display":{"locale":"en-US","amount_off":""}
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) DeletePromoCode(writer http.ResponseWriter, request *http.Request) {
+	promoID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	promo := models.PromoCode{}
+	returnedRecords, err := promo.Delete(app.AppDB, promoID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			promoCodeErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		returnedRecords["promo_code"])
+}
+
+/*
+*Description*
+
+func GetPromoCodeRedemptions
+
+Get the redemption report of the specified promo code: every appointment that was booked with the code, with the customer, the
+appointment's status and the discount taken off its invoice, along with how many times the code has been used, by how many customers,
+how many more times it can be used, and the total discount given in each currency.
+
+Redemptions of appointments that were cancelled are listed (with "cancelled":true), but don't count towards the code's limits or the
+totals. Deleted promo codes can still be reported.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/promo-code/{id}/redemptions
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /promo-code/7/redemptions
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"promo_code":{
+				"ID": 7,
+				...
+				"code":"SPRING10",
+				"amount_off":1000,
+				"max_redemptions":100,
+				...
+			},
+			"redemption_count":1,
+			"cancelled_count":1,
+			"customer_count":1,
+			"remaining_redemptions":99,
+			"totals":[
+				{
+					"currency":"USD",
+					"redemption_count":1,
+					"discount":1000,
+					"display":{"locale":"en-US","discount":"$10.00"}
+				}
+			],
+			"redemptions":[
+				{
+					"redemption_id":12,
+					"redeemed_at":"2023-04-02T10:00:00Z",
+					"user_id":456,
+					"first_name":"Lee",
+					"last_name":"Booker",
+					"email":"lee.booker@example.com",
+					"appointment_id":901,
+					"appointment_status":"Confirmed",
+					"service_id":123,
+					"service_name":"Yoga",
+					"invoice_id":88,
+					"invoice_number":"INV-000088",
+					"discount":1000,
+					"currency":"USD",
+					"cancelled":false,
+					"display":{"locale":"en-US","discount":"$10.00"}
+				},
+				{
+					"redemption_id":11,
+					...
+					"appointment_status":"Cancelled By Customer",
+					...
+					"cancelled":true
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetPromoCodeRedemptions(writer http.ResponseWriter, request *http.Request) {
+	promoID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	promo := models.PromoCode{}
+	report, err := promo.GetRedemptionReport(app.AppDB, promoID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		var errorMessage string = fmt.Sprintf("Promo Code ID (%d) does not exist in the database.  [%s]", promoID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	} else if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		report)
+}
+
+/*
+*Description*
+
+func respondWithPromoCode
+
+Responds with the specified promo code record and the names of the Services it can be used for.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request (its 'Accept-Language' header chooses the locale that amounts are formatted for)
+
+	code  <int>
+
+		The HTTP status code for a successful response
+
+	promo  <*models.PromoCode>
+
+		The promo code record
+
+*Returns*
+
+	None
+*/
+func (app *Application) respondWithPromoCode(writer http.ResponseWriter, request *http.Request, code int, promo *models.PromoCode) {
+	eligibleServices, err := promo.GetEligibleServices(app.AppDB, promo.ID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		code,
+		map[string]interface{}{
+			"promo_code":        promo,
+			"eligible_services": eligibleServices,
+		})
+}
+
+/*
+*Description*
+
+func promoCodeErrorStatusCode
+
+Maps an error returned by a PromoCode model method to the appropriate HTTP status code.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the model method.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error (500 if the error is not a known promo code error).
+*/
+func promoCodeErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidPromoCode), errors.Is(err, money.ErrInvalidCurrency):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,1033 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/models"
+	"server/utils"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+/*
+*Description*
+
+func GetBusinessReceivables
+
+Get the accounts receivable aging report of the specified business: how much each customer still owes on the business's issued
+invoices, split into aging buckets by how long the invoices have been past due (current, 1-30, 31-60, 61-90 and more than 90 days),
+with a total for each currency.
+
+The report can drill down into the outstanding invoices behind each balance, either for every customer ('detail') or for a single
+customer ('user_id'), and can be downloaded as a CSV file ('format').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/reports/receivables
+
+	Query parameters (all optional):
+
+		as_of  <string>
+
+			Date (YYYY-MM-DD, aged to the end of that day in UTC) or date/time (RFC 3339) that the invoices are aged to. Defaults to now.
+
+		user_id  <uint>
+
+			ID of the only customer to include. Their outstanding invoices are always listed.
+
+		detail  <bool>
+
+			If true, each customer's outstanding invoices are listed. Defaults to false.
+
+		format  <string>
+
+			"json" (the default) or "csv". Summary CSV files have one row per customer and a total row per currency, and detailed CSV
+			files have one row per invoice. Amounts in CSV files are decimal numbers in each currency's major unit (e.g. 12.50).
+
+*Example request(s)*
+
+	GET /business/789/reports/receivables
+
+	GET /business/789/reports/receivables?user_id=456&as_of=2023-03-31
+
+	GET /business/789/reports/receivables?detail=true&format=csv
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"business_id":789,
+			"as_of":"2023-04-01T00:00:00Z",
+			"customers":[
+				{
+					"user_id":456,
+					"first_name":"Lee",
+					"last_name":"Payer",
+					"email":"lee.payer@example.com",
+					"currency":"USD",
+					"invoice_count":2,
+					"oldest_due_at":"2023-01-15T10:00:00Z",
+					"current":2500,
+					"days_1_30":0,
+					"days_31_60":0,
+					"days_61_90":4000,
+					"days_over_90":0,
+					"total":6500,
+					"invoices":[
+						{
+							"invoice_id":123,
+							"number":"INV-000042",
+							"user_id":456,
+							"currency":"USD",
+							"issued_at":"2022-12-16T10:00:00Z",
+							"due_at":"2023-01-15T10:00:00Z",
+							"remaining_balance":4000,
+							"days_past_due":76,
+							"bucket":"61-90"
+						},
+						...
+					]
+				}
+			],
+			"totals":[
+				{
+					"currency":"USD",
+					"customer_count":1,
+					"invoice_count":2,
+					"current":2500,
+					"days_1_30":0,
+					"days_31_60":0,
+					"days_61_90":4000,
+					"days_over_90":0,
+					"total":6500
+				}
+			],
+			"detailed":true
+		}
+
+		HTTP/1.1 200 OK
+		Content-Type: text/csv; charset=utf-8
+		Content-Disposition: inline; filename="receivables-789-2023-04-01.csv"
+
+		Customer ID,Customer,Email,Currency,Invoices,Current,1-30,31-60,61-90,90+,Total
+		456,Lee Payer,lee.payer@example.com,USD,2,25.00,0.00,0.00,40.00,0.00,65.00
+		,Total,,USD,2,25.00,0.00,0.00,40.00,0.00,65.00
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL, or an invalid query parameter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessReceivables(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	query := request.URL.Query()
+
+	asOf := time.Now()
+	if query.Get("as_of") != "" {
+		asOf, err = parseReportTime(query.Get("as_of"))
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				err.Error())
+
+			return
+		}
+	}
+
+	var userID uint64
+	if query.Get("user_id") != "" {
+		userID, err = strconv.ParseUint(query.Get("user_id"), 10, 0)
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				fmt.Sprintf("user_id '%s' must be a positive whole number", query.Get("user_id")))
+
+			return
+		}
+	}
+
+	var detailed bool = userID != 0
+	if query.Get("detail") != "" {
+		detail, err := strconv.ParseBool(query.Get("detail"))
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				fmt.Sprintf("detail '%s' must be true or false", query.Get("detail")))
+
+			return
+		}
+
+		detailed = detailed || detail
+	}
+
+	var format string = query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			fmt.Sprintf("format '%s' must be json or csv", format))
+
+		return
+	}
+
+	business := models.Business{}
+	businessExists, err := business.IDExists(app.AppDB, businessID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	if !businessExists {
+		var errorMessage string = fmt.Sprintf("Business ID (%d) does not exist in the database.", businessID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	report, err := business.GetReceivablesReport(app.AppDB, businessID, asOf, uint(userID), detailed)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	if format != "csv" {
+		utils.RespondWithJSON(
+			writer,
+			http.StatusOK,
+			report)
+
+		return
+	}
+
+	file, err := report.CSV()
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithFile(
+		writer,
+		http.StatusOK,
+		"text/csv; charset=utf-8",
+		fmt.Sprintf("receivables-%d-%s.csv", businessID, asOf.Format("2006-01-02")),
+		file)
+}
+
+/*
+*Description*
+
+func GetBusinessTaxReport
+
+Get the sales tax report of the specified business for a period: the taxable sales, tax exempt sales and tax collected in each
+jurisdiction that the business's invoices were taxed in, with a total for each currency. Sales are reported when their invoice is issued,
+and credit notes issued in the period take sales and tax back.
+
+The report can be downloaded as a CSV file ('format').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/reports/tax
+
+	Query parameters (all optional):
+
+		from  <string>
+
+			Date (YYYY-MM-DD, from the start of that day in UTC) or date/time (RFC 3339) that the period starts at. Defaults to the start
+			of the current month.
+
+		to  <string>
+
+			Date (YYYY-MM-DD, to the end of that day in UTC) or date/time (RFC 3339) that the period ends at. Defaults to now.
+
+		format  <string>
+
+			"json" (the default) or "csv". CSV files have one row per jurisdiction and a total row per currency. Amounts in CSV files are
+			decimal numbers in each currency's major unit (e.g. 12.50).
+
+*Example request(s)*
+
+	GET /business/789/reports/tax?from=2023-01-01&to=2023-03-31
+
+	GET /business/789/reports/tax?from=2023-01-01&to=2023-03-31&format=csv
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"business_id":789,
+			"from":"2023-01-01T00:00:00Z",
+			"to":"2023-04-01T00:00:00Z",
+			"jurisdictions":[
+				{
+					"jurisdiction":"TX-AUSTIN",
+					"name":"Austin sales tax",
+					"currency":"USD",
+					"invoice_count":12,
+					"credit_note_count":1,
+					"invoiced":{"taxable_sales":24000,"exempt_sales":3000,"tax":1980},
+					"credited":{"taxable_sales":2000,"exempt_sales":0,"tax":165},
+					"net":{"taxable_sales":22000,"exempt_sales":3000,"tax":1815}
+				}
+			],
+			"totals":[
+				{
+					"currency":"USD",
+					"invoice_count":12,
+					"credit_note_count":1,
+					"invoiced":{"taxable_sales":24000,"exempt_sales":3000,"tax":1980},
+					"credited":{"taxable_sales":2000,"exempt_sales":0,"tax":165},
+					"net":{"taxable_sales":22000,"exempt_sales":3000,"tax":1815}
+				}
+			]
+		}
+
+		HTTP/1.1 200 OK
+		Content-Type: text/csv; charset=utf-8
+		Content-Disposition: inline; filename="tax-789-2023-01-01-2023-03-31.csv"
+
+		Jurisdiction,Name,Currency,Invoices,Credit Notes,Taxable Sales,Exempt Sales,Tax,Credited Taxable Sales,Credited Exempt Sales,Credited Tax,Net Taxable Sales,Net Exempt Sales,Net Tax
+		TX-AUSTIN,Austin sales tax,USD,12,1,240.00,30.00,19.80,20.00,0.00,1.65,220.00,30.00,18.15
+		,Total,USD,12,1,240.00,30.00,19.80,20.00,0.00,1.65,220.00,30.00,18.15
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL, or an invalid query parameter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessTaxReport(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	query := request.URL.Query()
+
+	to := time.Now()
+	if query.Get("to") != "" {
+		to, err = parseReportTime(query.Get("to"))
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				err.Error())
+
+			return
+		}
+	}
+
+	from := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, to.Location())
+	if query.Get("from") != "" {
+		from, err = parseReportStartTime(query.Get("from"))
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				err.Error())
+
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			"from must be before to")
+
+		return
+	}
+
+	var format string = query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			fmt.Sprintf("format '%s' must be json or csv", format))
+
+		return
+	}
+
+	business := models.Business{}
+	businessExists, err := business.IDExists(app.AppDB, businessID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	if !businessExists {
+		var errorMessage string = fmt.Sprintf("Business ID (%d) does not exist in the database.", businessID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	report, err := business.GetTaxReport(app.AppDB, businessID, from, to)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	if format != "csv" {
+		utils.RespondWithJSON(
+			writer,
+			http.StatusOK,
+			report)
+
+		return
+	}
+
+	file, err := report.CSV()
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	// The period's end is exclusive, so the file is named after the last day it includes
+	utils.RespondWithFile(
+		writer,
+		http.StatusOK,
+		"text/csv; charset=utf-8",
+		fmt.Sprintf("tax-%d-%s-%s.csv", businessID, from.Format("2006-01-02"), to.Add(-time.Nanosecond).Format("2006-01-02")),
+		file)
+}
+
+/*
+*Description*
+
+func GetBusinessAccountingExport
+
+Export the specified business's issued invoices, credit notes, payments and refunds for a period as a double-entry journal that can be
+imported into accounting software. Each document is one balanced journal entry, posted to the ledger accounts set in the business's
+settings (see 'UpdateBusiness'). Every export with a period is recorded (see 'GetBusinessAccountingExports').
+
+Incremental exports ('incremental') continue from where the business's previous incremental export ended, so consecutive incremental
+exports don't overlap. They end a few minutes before they are made, so documents that are still being recorded are left to the next
+export. If nothing new can be exported yet, the journal is empty and the export isn't recorded.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/reports/accounting
+
+	Query parameters (all optional):
+
+		from  <string>
+
+			Date (YYYY-MM-DD, from the start of that day in UTC) or date/time (RFC 3339) that the period starts at. Defaults to the start
+			of the current month.
+
+		to  <string>
+
+			Date (YYYY-MM-DD, to the end of that day in UTC) or date/time (RFC 3339) that the period ends at. Defaults to now.
+
+		incremental  <bool>
+
+			If true, the period starts where the previous incremental export ended (or when the business was created) and ends a few
+			minutes ago ('models.AccountingExportLag', so documents that are still being recorded are left to the next export), and includes the documents recorded
+			in that time. 'from' and 'to' can't be set. Defaults to false.
+
+		format  <string>
+
+			"json" (the default) for the journal as JSON with amounts in cents, "csv" for a CSV file with one row per journal line, or
+			"iif" for a QuickBooks Desktop import file. Amounts in CSV and IIF files are decimal numbers in each currency's major unit
+			(e.g. 12.50).
+
+*Example request(s)*
+
+	GET /business/789/reports/accounting?from=2023-01-01&to=2023-03-31
+
+	GET /business/789/reports/accounting?incremental=true&format=iif
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"business_id":789,
+			"from":"2023-01-01T00:00:00Z",
+			"to":"2023-04-01T00:00:00Z",
+			"accounts":{
+				"receivables":"Accounts Receivable",
+				"revenue":"Sales",
+				"sales_tax":"Sales Tax Payable",
+				"deposits":"Undeposited Funds",
+				"customer_credit":"Customer Credits"
+			},
+			"entries":[
+				{
+					"date":"2023-01-05T10:00:00Z",
+					"type":"Invoice",
+					"document_id":42,
+					"reference":"INV-000042",
+					"invoice_id":42,
+					"invoice_number":"INV-000042",
+					"user_id":123,
+					"customer":"Jane Doe",
+					"currency":"USD",
+					"memo":"",
+					"lines":[
+						{"account":"Accounts Receivable","debit":10825,"credit":0},
+						{"account":"Sales","debit":0,"credit":10000},
+						{"account":"Sales Tax Payable","debit":0,"credit":825}
+					]
+				},
+				{
+					"date":"2023-01-09T15:30:00Z",
+					"type":"Payment",
+					"document_id":17,
+					"reference":"ch_1234",
+					"invoice_id":42,
+					"invoice_number":"INV-000042",
+					"user_id":123,
+					"customer":"Jane Doe",
+					"currency":"USD",
+					"memo":"Payment by Card",
+					"lines":[
+						{"account":"Undeposited Funds","debit":10825,"credit":0},
+						{"account":"Accounts Receivable","debit":0,"credit":10825}
+					]
+				}
+			]
+		}
+
+		HTTP/1.1 200 OK
+		Content-Type: text/csv; charset=utf-8
+		Content-Disposition: inline; filename="accounting-789-2023-01-01-2023-03-31.csv"
+
+		Date,Type,Document ID,Reference,Invoice,Customer,Account,Debit,Credit,Currency,Memo
+		2023-01-05,Invoice,42,INV-000042,INV-000042,Jane Doe,Accounts Receivable,108.25,,USD,
+		2023-01-05,Invoice,42,INV-000042,INV-000042,Jane Doe,Sales,,100.00,USD,
+		2023-01-05,Invoice,42,INV-000042,INV-000042,Jane Doe,Sales Tax Payable,,8.25,USD,
+		2023-01-09,Payment,17,ch_1234,INV-000042,Jane Doe,Undeposited Funds,108.25,,USD,Payment by Card
+		2023-01-09,Payment,17,ch_1234,INV-000042,Jane Doe,Accounts Receivable,,108.25,USD,Payment by Card
+
+		HTTP/1.1 200 OK
+		Content-Type: text/plain; charset=utf-8
+		Content-Disposition: inline; filename="accounting-789-2023-01-01-2023-03-31.iif"
+
+		!TRNS	TRNSTYPE	DATE	ACCNT	NAME	AMOUNT	DOCNUM	MEMO
+		!SPL	TRNSTYPE	DATE	ACCNT	NAME	AMOUNT	DOCNUM	MEMO
+		!ENDTRNS
+		TRNS	INVOICE	01/05/2023	Accounts Receivable	Jane Doe	108.25	INV-000042
+		SPL	INVOICE	01/05/2023	Sales	Jane Doe	-100.00	INV-000042
+		SPL	INVOICE	01/05/2023	Sales Tax Payable	Jane Doe	-8.25	INV-000042
+		ENDTRNS
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL, or an invalid query parameter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessAccountingExport(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	query := request.URL.Query()
+
+	var incremental bool
+	if query.Get("incremental") != "" {
+		incremental, err = strconv.ParseBool(query.Get("incremental"))
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				fmt.Sprintf("incremental '%s' must be true or false", query.Get("incremental")))
+
+			return
+		}
+	}
+
+	if incremental && (query.Get("from") != "" || query.Get("to") != "") {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			"from and to can't be set for incremental exports")
+
+		return
+	}
+
+	to := time.Now()
+	if query.Get("to") != "" {
+		to, err = parseReportTime(query.Get("to"))
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				err.Error())
+
+			return
+		}
+	}
+
+	from := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, to.Location())
+	if query.Get("from") != "" {
+		from, err = parseReportStartTime(query.Get("from"))
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				err.Error())
+
+			return
+		}
+	}
+
+	var format string = query.Get("format")
+	if format == "" {
+		format = models.AccountingFormatJSON
+	}
+
+	business := models.Business{}
+	journal, _, err := business.ExportAccounting(app.AppDB, businessID, format, from, to, incremental)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			var errorMessage string = fmt.Sprintf("Business ID (%d) does not exist in the database.", businessID)
+
+			utils.RespondWithError(
+				writer,
+				http.StatusNotFound,
+				errorMessage)
+
+			log.Printf("ERROR:  %s", errorMessage)
+
+			return
+		}
+
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, models.ErrInvalidAccountingExport) {
+			statusCode = http.StatusBadRequest
+		}
+
+		utils.RespondWithError(
+			writer,
+			statusCode,
+			err.Error())
+
+		return
+	}
+
+	// The period's end is exclusive, so the file is named after the last day it includes
+	var fileName string = fmt.Sprintf("accounting-%d-%s-%s", businessID, journal.From.Format("2006-01-02"), journal.To.Add(-time.Nanosecond).Format("2006-01-02"))
+
+	switch format {
+	case models.AccountingFormatCSV:
+		file, err := journal.CSV()
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusInternalServerError,
+				err.Error())
+
+			return
+		}
+
+		utils.RespondWithFile(
+			writer,
+			http.StatusOK,
+			"text/csv; charset=utf-8",
+			fileName+".csv",
+			file)
+	case models.AccountingFormatIIF:
+		utils.RespondWithFile(
+			writer,
+			http.StatusOK,
+			"text/plain; charset=utf-8",
+			fileName+".iif",
+			journal.IIF())
+	default:
+		utils.RespondWithJSON(
+			writer,
+			http.StatusOK,
+			journal)
+	}
+}
+
+/*
+*Description*
+
+func GetBusinessAccountingExports
+
+Get the history of the specified business's accounting exports, newest first. The newest incremental export shows where the next
+incremental export will start ('to').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/accounting-exports
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /business/789/accounting-exports
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 12,
+				"CreatedAt": "2023-04-01T09:00:00Z",
+				"UpdatedAt": "2023-04-01T09:00:00Z",
+				"DeletedAt": null,
+				"business_id": 789,
+				"format": "iif",
+				"from": "2023-03-01T09:00:00Z",
+				"to": "2023-04-01T09:00:00Z",
+				"incremental": true,
+				"entry_count": 57
+			}
+		]
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Business not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessAccountingExports(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	business := models.Business{}
+	businessExists, err := business.IDExists(app.AppDB, businessID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	if !businessExists {
+		var errorMessage string = fmt.Sprintf("Business ID (%d) does not exist in the database.", businessID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	export := models.AccountingExport{}
+	exports, err := export.GetRecordsBySecondaryID(app.AppDB, "business_id", businessID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		exports)
+}
+
+/*
+*Description*
+
+func parseReportTime
+
+Parses the time that a report is run as of. Dates (YYYY-MM-DD) are treated as the end of that day in UTC, so the whole day is included.
+
+*Parameters*
+
+	value  <string>
+
+		A date (YYYY-MM-DD) or date/time (RFC 3339).
+
+*Returns*
+
+	_  <time.Time>
+
+		The time.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func parseReportTime(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date.AddDate(0, 0, 1), nil
+	}
+
+	asOf, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return asOf, fmt.Errorf("'%s' must be a date (YYYY-MM-DD) or a date/time (RFC 3339)", value)
+	}
+
+	return asOf, nil
+}
+
+/*
+*Description*
+
+func parseReportStartTime
+
+Parses the time that a report's period starts at. Dates (YYYY-MM-DD) are treated as the start of that day in UTC, so the whole day is
+included.
+
+*Parameters*
+
+	value  <string>
+
+		A date (YYYY-MM-DD) or date/time (RFC 3339).
+
+*Returns*
+
+	_  <time.Time>
+
+		The time.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func parseReportStartTime(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+
+	from, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return from, fmt.Errorf("'%s' must be a date (YYYY-MM-DD) or a date/time (RFC 3339)", value)
+	}
+
+	return from, nil
+}
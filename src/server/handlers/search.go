@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/geocoding"
+	"server/models"
+	"server/utils"
+	"strconv"
+)
+
+/*
+*Description*
+
+func Search
+
+Search upcoming services and businesses by name and description, most relevant first. Each word of the query also matches the words
+that start with it, so the search can be run as the query is typed. Services are found by their own name and description or by their
+business's name, and can be filtered by date, price and availability.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/search
+
+	Query parameters:
+
+		q  <string>
+
+			Words to search for (required).
+
+		from  <string>
+
+			Date (YYYY-MM-DD, from the start of that day in UTC) or date/time (RFC 3339) that the services found start at or after.
+			Defaults to now, so only upcoming services are found.
+
+		to  <string>
+
+			Date (YYYY-MM-DD, to the end of that day in UTC) or date/time (RFC 3339) that the services found start before. Defaults to
+			no limit.
+
+		price_min, price_max  <uint>
+
+			Lowest and highest price of the services found (in cents).
+
+		available  <bool>
+
+			If true, only services that aren't full are found. Defaults to false.
+
+		limit  <int>
+
+			Number of services and of businesses returned (defaults to 20, at most 100).
+
+*Example request(s)*
+
+	GET /search?q=yog
+
+	GET /search?q=yoga+beginner&to=2023-06-30&price_max=2500&available=true
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"query":"yog",
+			"services":[
+				{
+					"ID": 11,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"business_id": 66,
+					"name":"Yoga class",
+					"desc":"30 minute beginner yoga class",
+					"start_date_time":"2023-05-31T14:30:00.0000000-05:00",
+					"length":30,
+					"capacity":20,
+					"price":2000,
+					"cancel_fee":0,
+					"appt_ct":0,
+					"is_full":false,
+					"business_name":"Downward Dog Studio",
+					"rank":0.75990885
+				}
+			],
+			"service_count":1,
+			"businesses":[
+				{
+					"ID": 66,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"owner_id": 123,
+					"name": "Yoga Collective",
+					"upcoming_services": 8,
+					"rank":0.0607927
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing query, or an invalid query parameter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) Search(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	options := models.SearchOptions{Query: query.Get("q")}
+
+	var err error
+	if query.Get("from") != "" {
+		options.From, err = parseReportStartTime(query.Get("from"))
+	}
+
+	if err == nil && query.Get("to") != "" {
+		options.To, err = parseReportTime(query.Get("to"))
+	}
+
+	parsePrice := func(parameter string) (*uint, error) {
+		if query.Get(parameter) == "" {
+			return nil, nil
+		}
+
+		price, err := strconv.ParseUint(query.Get(parameter), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s '%s' must be a whole number of cents", parameter, query.Get(parameter))
+		}
+
+		cents := uint(price)
+		return &cents, nil
+	}
+
+	if err == nil {
+		options.MinPrice, err = parsePrice("price_min")
+	}
+
+	if err == nil {
+		options.MaxPrice, err = parsePrice("price_max")
+	}
+
+	if err == nil && query.Get("available") != "" {
+		options.AvailableOnly, err = strconv.ParseBool(query.Get("available"))
+		if err != nil {
+			err = fmt.Errorf("available '%s' must be true or false", query.Get("available"))
+		}
+	}
+
+	if err == nil && query.Get("limit") != "" {
+		options.Limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			err = fmt.Errorf("limit '%s' must be a whole number", query.Get("limit"))
+		}
+	}
+
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	results, err := models.Search(app.AppDB, options)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, models.ErrInvalidSearch) {
+			statusCode = http.StatusBadRequest
+		} else {
+			log.Printf("ERROR:  %s", err.Error())
+		}
+
+		utils.RespondWithError(
+			writer,
+			statusCode,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		&results)
+}
+
+/*
+*Description*
+
+func GetNearbyBusinesses
+
+Find the businesses within a radius of a point, nearest first, with their addresses and their number of upcoming services. The point is
+given by its coordinates (e.g. from the customer's device), or by a zip code that is geocoded to its centroid.
+
+Only businesses whose address has been set (see SetBusinessAddress) can be found.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/businesses/nearby
+
+	Query parameters:
+
+		lat, lng  <float>
+
+			Latitude and longitude of the point to search around, in degrees (required unless zip is set).
+
+		zip  <string>
+
+			Zip code to search around, used if lat and lng aren't set.
+
+		radius  <float>
+
+			Radius of the search in miles (defaults to 10, at most 250).
+
+		limit  <int>
+
+			Number of businesses returned (defaults to 20, at most 100).
+
+*Example request(s)*
+
+	GET /businesses/nearby?lat=29.6516&lng=-82.3248&radius=5
+
+	GET /businesses/nearby?zip=32601
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 66,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"owner_id": 123,
+				"name": "Downward Dog Studio",
+				"address_id": 12,
+				"address": {
+					"ID": 12,
+					"address1":"1 Gator Way",
+					"city":"Gainesville",
+					"state":"FL",
+					"zip":"32601",
+					"latitude":29.6479,
+					"longitude":-82.3249
+				},
+				"distance_miles": 0.25,
+				"upcoming_services": 8
+			},
+			...
+		]
+
+	Failure:
+		-- Case = Missing or invalid coordinates, zip code, radius or limit
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = The zip code couldn't be geocoded
+		HTTP/1.1 422 Unprocessable Entity
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetNearbyBusinesses(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+
+	parseFloat := func(parameter string) (float64, error) {
+		if query.Get(parameter) == "" {
+			return 0, nil
+		}
+
+		value, err := strconv.ParseFloat(query.Get(parameter), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s '%s' must be a number", parameter, query.Get(parameter))
+		}
+
+		return value, nil
+	}
+
+	var origin geocoding.Coordinates
+	var radius float64
+	var limit int
+	var err error
+
+	switch {
+	case query.Get("lat") != "" || query.Get("lng") != "":
+		if query.Get("lat") == "" || query.Get("lng") == "" {
+			err = errors.New("lat and lng must be set together")
+		}
+
+		if err == nil {
+			origin.Latitude, err = parseFloat("lat")
+		}
+
+		if err == nil {
+			origin.Longitude, err = parseFloat("lng")
+		}
+	case query.Get("zip") != "":
+		origin, err = app.Geocoder.Geocode(geocoding.Location{ZipCode: query.Get("zip")})
+	default:
+		err = errors.New("lat and lng, or zip, are required")
+	}
+
+	if err == nil {
+		radius, err = parseFloat("radius")
+	}
+
+	if err == nil && query.Get("limit") != "" {
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			err = fmt.Errorf("limit '%s' must be a whole number", query.Get("limit"))
+		}
+	}
+
+	if err != nil {
+		statusCode := http.StatusBadRequest
+		if errors.Is(err, geocoding.ErrLocationNotFound) {
+			statusCode = http.StatusUnprocessableEntity
+		}
+
+		utils.RespondWithError(
+			writer,
+			statusCode,
+			err.Error())
+
+		return
+	}
+
+	businesses, err := models.NearbyBusinesses(app.AppDB, origin, radius, limit)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, models.ErrInvalidSearch) {
+			statusCode = http.StatusBadRequest
+		} else {
+			log.Printf("ERROR:  %s", err.Error())
+		}
+
+		utils.RespondWithError(
+			writer,
+			statusCode,
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		businesses)
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"server/models"
+	"server/money"
 	"server/utils"
 	_ "time"
 )
@@ -76,6 +78,10 @@ Creates a new service record in the database.
 
 				Fee (in cents) for cancelling appointment after minimum notice cutoff
 
+			tax_exempt <bool>
+
+				True if the service is exempt from sales tax (defaults to false)
+
 *Example request(s)*
 
 	POST /service
@@ -151,14 +157,15 @@ func (app *Application) CreateService(writer http.ResponseWriter, request *http.
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			serviceErrorStatusCode(err),
 			err.Error())
 
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusCreated,
 		createdService)
 }
@@ -267,8 +274,9 @@ func (app *Application) GetService(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
 		returnedService)
 }
@@ -345,6 +353,10 @@ If a specified field's value should be deleted from the record, the appropriate
 
 				Fee (in cents) for cancelling appointment after minimum notice cutoff
 
+			tax_exempt <bool>
+
+				True if the service is exempt from sales tax. Invoices created afterwards are taxed accordingly.
+
 *Example request(s)*
 
 	PUT /service/123456
@@ -426,14 +438,15 @@ func (app *Application) UpdateService(writer http.ResponseWriter, request *http.
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			serviceErrorStatusCode(err),
 			err.Error())
 
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
 		updatedService)
 }
@@ -540,8 +553,9 @@ func (app *Application) DeleteService(writer http.ResponseWriter, request *http.
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
 		deletedService)
 
@@ -552,7 +566,8 @@ func (app *Application) DeleteService(writer http.ResponseWriter, request *http.
 
 func GetServices
 
-Get a list of all Service records in the database.
+Get a page of the Service records in the database, sorted and filtered as requested. Pages are found with the cursor returned
+with the previous page, and report the total number of records that match the filters.
 
 *Parameters*
 
@@ -574,14 +589,33 @@ Get a list of all Service records in the database.
 
 	Route:	/services
 
-	Body:
+	Query parameters (all optional):
 
-		None
+		limit  <int>
+
+			Number of records in the page (defaults to 50, at most 200).
+
+		cursor  <string>
+
+			The 'next_cursor' of the previous page (omitted for the first page). Must be used with the same sort.
+
+		sort  <string>
+
+			Comma separated fields to sort by, each prefixed with '-' to sort in descending order. Records that sort equally are sorted
+			by ID. Sortable fields: id, created_at, updated_at, business_id, name, start_date_time, length, capacity, price.
+
+		{field}, {field}[{operator}]  <string>
+
+			Filters by a field, with an optional operator: eq (the default), ne, gt, gte, lt, lte, or in (comma separated values). Dates
+			are YYYY-MM-DD or RFC 3339 date/times. Fields: id, created_at, updated_at, business_id, name, start_date_time, length,
+			capacity, price, currency, is_full, tax_exempt.
 
 *Example request(s)*
 
 	GET /services
 
+	GET /services?business_id=42&start_date_time[gte]=2023-06-01&start_date_time[lt]=2023-07-01&price[lte]=5000&is_full=false&sort=-price
+
 *Response format*
 
 	Success:
@@ -589,44 +623,58 @@ Get a list of all Service records in the database.
 		HTTP/1.1 200 OK
 		Content-Type: application/json
 
-		[
-			{
-				"ID": 11,
-				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
-				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
-				"DeletedAt": null,
-				"business_id": 66,
-				"name":"Yoga class",
-				"desc":"30 minute beginner yoga class",
-				"start_date_time":"2023-05-31T14:30:00.0000000-05:00",
-				"length":30,
-				"capacity":20,
-				"price":2000,
-				"cancel_fee":0,
-				"appt_ct":0,
-				"is_full":false
-			},
-			{
-				"ID": 83,
-				"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
-				"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
-				"DeletedAt": null,
-				"business_id": 42,
-				"name":"Caligraphy lessons",
-				"desc":"60 minute instructor-led course on caligraphy.",
-				"start_date_time":"2023-05-31T14:30:00.0000000-05:00",
-				"length":60,
-				"capacity":10,
-				"price":10000,
-				"cancel_fee":2000,
-				"appt_ct":0,
-				"is_full":false
-			},
-			...
-		]
+		{
+			"data":[
+				{
+					"ID": 11,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"business_id": 66,
+					"name":"Yoga class",
+					"desc":"30 minute beginner yoga class",
+					"start_date_time":"2023-05-31T14:30:00.0000000-05:00",
+					"length":30,
+					"capacity":20,
+					"price":2000,
+					"cancel_fee":0,
+					"appt_ct":0,
+					"is_full":false
+				},
+				{
+					"ID": 83,
+					"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
+					"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
+					"DeletedAt": null,
+					"business_id": 42,
+					"name":"Caligraphy lessons",
+					"desc":"60 minute instructor-led course on caligraphy.",
+					"start_date_time":"2023-05-31T14:30:00.0000000-05:00",
+					"length":60,
+					"capacity":10,
+					"price":10000,
+					"cancel_fee":2000,
+					"appt_ct":0,
+					"is_full":false
+				},
+				...
+			],
+			"total_count":124,
+			"limit":50,
+			"next_cursor":"eyJzIjoiIiwidiI6WzQxMV19"
+		}
 
 	Failure:
 
+		-- Case = Invalid limit, cursor, sort or filter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
 		HTTP/1.1 500 InternalServerError
 		Content-Type: application/json
 
@@ -635,14 +683,22 @@ Get a list of all Service records in the database.
 		}
 */
 func (app *Application) GetServices(writer http.ResponseWriter, request *http.Request) {
-	service := models.Service{}
-	var services []models.Service
+	options, err := parseListOptions(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
 
-	services, err := service.GetAll(app.AppDB)
+		return
+	}
+
+	service := models.Service{}
+	page, err := service.List(app.AppDB, options)
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			listErrorStatusCode(err),
 			err.Error())
 
 		log.Printf("ERROR:  %s", err.Error())
@@ -650,10 +706,11 @@ func (app *Application) GetServices(writer http.ResponseWriter, request *http.Re
 		return
 	}
 
-	utils.RespondWithJSON(
+	respondWithLocalizedJSON(
 		writer,
+		request,
 		http.StatusOK,
-		services)
+		&page)
 }
 
 /*
@@ -708,7 +765,8 @@ Get a list of all the active Appointments associated with the specified Service.
 				"service_id":42,
 				"user_id":11,
 				"cancel_date_time":null,
-				"active":true
+				"status":"Confirmed",
+				"seats":1
 			},
 			{
 				"ID": 456,
@@ -718,7 +776,8 @@ Get a list of all the active Appointments associated with the specified Service.
 				"service_id":42,
 				"user_id":33,
 				"cancel_date_time":"2022-11-23T05:41:03.4507451-05:00",
-				"active":false
+				"status":"Cancelled By Customer",
+				"seats":1
 			},
 			...
 		]
@@ -824,7 +883,8 @@ Get a list of all the Appointments (both active/inactive) associated with the sp
 				"service_id":42,
 				"user_id":11,
 				"cancel_date_time":null,
-				"active":true
+				"status":"Confirmed",
+				"seats":1
 			},
 			{
 				"ID": 456,
@@ -834,7 +894,8 @@ Get a list of all the Appointments (both active/inactive) associated with the sp
 				"service_id":42,
 				"user_id":33,
 				"cancel_date_time":null,
-				"active":true
+				"status":"Confirmed",
+				"seats":1
 			},
 			...
 		]
@@ -885,7 +946,7 @@ func (app *Application) GetActiveServiceAppointments(writer http.ResponseWriter,
 
 	var activeAppts []models.Appointment
 	for _, appt := range appts {
-		if appt.Active {
+		if appt.IsActive() {
 			activeAppts = append(activeAppts, appt)
 		}
 	}
@@ -1108,3 +1169,31 @@ func (app *Application) GetEnrolledUsersCount(writer http.ResponseWriter, reques
 		http.StatusOK,
 		len(users))
 }
+
+/*
+*Description*
+
+func serviceErrorStatusCode
+
+Maps an error returned by a Service model method to the appropriate HTTP status code.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the model method.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error (500 if the error is not a known service error).
+*/
+func serviceErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, money.ErrInvalidCurrency):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,650 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/models"
+	"server/utils"
+	"strconv"
+)
+
+// Body of a request to credit a user's store credit account (see 'AddStoreCredit')
+type storeCreditRequest struct {
+	BusinessID uint   `json:"business_id"`
+	Amount     int    `json:"amount"`
+	Currency   string `json:"currency"`
+	Reason     string `json:"reason"`
+}
+
+/*
+*Description*
+
+func AddStoreCredit
+
+Gives the specified user goodwill store credit with a business (e.g. to make up for a poor experience). The credit can be spent on the
+business's invoices with 'Store Credit' payments (see 'CreatePayment').
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/user/{id}/store-credit
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			business_id  <uint>
+
+				ID of the business giving the credit
+
+			amount  <int>
+
+				Amount of credit (in cents)
+
+		Optional fields:
+
+			currency  <string>
+
+				ISO 4217 currency of the credit (defaults to the business's currency)
+
+			reason  <string>
+
+				Reason the credit was given
+
+*Example request(s)*
+
+	POST /user/456/store-credit
+	{
+		"business_id":789,
+		"amount":1500,
+		"reason":"Sorry for the late start"
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"store_credit_account":{
+				"ID": 3,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"user_id":456,
+				"business_id":789,
+				"currency":"USD",
+				"balance":1500
+			},
+			"transaction":{
+				"ID": 8,
+				"CreatedAt": "2020-01-03T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"account_id":3,
+				"user_id":456,
+				"business_id":789,
+				"type":"Goodwill",
+				"amount":1500,
+				"balance_after":1500,
+				"invoice_id":0,
+				"payment_id":0,
+				"refund_id":0,
+				"reason":"Sorry for the late start",
+				"currency":"USD"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or an invalid amount or currency
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = User or business not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) AddStoreCredit(writer http.ResponseWriter, request *http.Request) {
+	userID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var creditRequest storeCreditRequest
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&creditRequest); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	account := models.StoreCreditAccount{}
+	returnedRecords, transaction, err := account.AddGoodwillCredit(app.AppDB, userID, creditRequest.BusinessID, creditRequest.Amount, creditRequest.Currency, creditRequest.Reason)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		map[string]interface{}{
+			"store_credit_account": returnedRecords["store_credit_account"],
+			"transaction":          transaction,
+		})
+}
+
+/*
+*Description*
+
+func GetUserStoreCredit
+
+Get the specified user's store credit balances (one per business and currency that the user holds credit in), along with the history
+of every change to them (oldest first).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/user/{id}/store-credit
+
+	Query parameters:
+
+		business_id  <uint>
+
+			Only include credit with this business (optional)
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /user/456/store-credit?business_id=789
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"store_credit_accounts":[
+				{
+					"ID": 3,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-05T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"user_id":456,
+					"business_id":789,
+					"currency":"USD",
+					"balance":500
+				}
+			],
+			"transactions":[
+				{
+					"ID": 8,
+					"CreatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"account_id":3,
+					"user_id":456,
+					"business_id":789,
+					"type":"Goodwill",
+					"amount":1500,
+					"balance_after":1500,
+					"invoice_id":0,
+					"payment_id":0,
+					"refund_id":0,
+					"reason":"Sorry for the late start",
+					"currency":"USD"
+				},
+				{
+					"ID": 9,
+					"CreatedAt": "2020-01-05T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-05T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"account_id":3,
+					"user_id":456,
+					"business_id":789,
+					"type":"Redeemed",
+					"amount":-1000,
+					"balance_after":500,
+					"invoice_id":123,
+					"payment_id":18,
+					"refund_id":0,
+					"reason":"",
+					"currency":"USD"
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL or business_id
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetUserStoreCredit(writer http.ResponseWriter, request *http.Request) {
+	userID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var businessID uint64
+	if request.URL.Query().Get("business_id") != "" {
+		businessID, err = strconv.ParseUint(request.URL.Query().Get("business_id"), 10, 0)
+		if err != nil {
+			utils.RespondWithError(
+				writer,
+				http.StatusBadRequest,
+				fmt.Sprintf("business_id '%s' must be a positive whole number", request.URL.Query().Get("business_id")))
+
+			return
+		}
+	}
+
+	app.respondWithStoreCredit(writer, request, "user_id", userID, uint(businessID))
+}
+
+/*
+*Description*
+
+func GetBusinessStoreCredit
+
+Get the store credit balances that customers hold with the specified business, along with the history of every change to them (oldest
+first).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/business/{id}/store-credit
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /business/789/store-credit
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"store_credit_accounts":[
+				{
+					"ID": 3,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"user_id":456,
+					"business_id":789,
+					"currency":"USD",
+					"balance":1500
+				}
+			],
+			"transactions":[
+				{
+					"ID": 8,
+					"CreatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"account_id":3,
+					"user_id":456,
+					"business_id":789,
+					"type":"Goodwill",
+					"amount":1500,
+					"balance_after":1500,
+					"invoice_id":0,
+					"payment_id":0,
+					"refund_id":0,
+					"reason":"Sorry for the late start",
+					"currency":"USD"
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetBusinessStoreCredit(writer http.ResponseWriter, request *http.Request) {
+	businessID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	app.respondWithStoreCredit(writer, request, "business_id", businessID, 0)
+}
+
+/*
+*Description*
+
+func CreditInvoiceOverpayment
+
+Keeps the surplus paid on the specified overpaid invoice as the user's store credit with the invoice's business, instead of refunding
+it. The surplus is refunded from the invoice's payments (newest first) as store credit, so the invoice ends up Paid.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/invoice/{id}/store-credit
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	POST /invoice/123/store-credit
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"invoice":{
+				"ID": 123,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":41,
+				"user_id":456,
+				"business_id":789,
+				"state":"Issued",
+				"number":"INV-000042",
+				"original_balance":5000,
+				"remaining_balance":0,
+				"status":"Paid"
+			},
+			"refunds":[
+				{
+					"ID": 4,
+					"CreatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-03T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"payment_id":17,
+					"invoice_id":123,
+					"amount":1000,
+					"reason":"Overpayment kept as store credit",
+					"reference":"",
+					"refunded_at":"2020-01-03T01:23:45.6789012-05:00",
+					"store_credit":true
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL, or the invoice is not overpaid
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Invoice not found
+		HTTP/1.1 404 Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreditInvoiceOverpayment(writer http.ResponseWriter, request *http.Request) {
+	invoiceID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	invoice := models.Invoice{}
+	returnedRecords, refunds, err := invoice.CreditOverpayment(app.AppDB, invoiceID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			invoiceErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		map[string]interface{}{
+			"invoice": returnedRecords["invoice"],
+			"refunds": refunds,
+		})
+}
+
+/*
+*Description*
+
+func respondWithStoreCredit
+
+Responds with the store credit accounts, and the history of their balances, that belong to the specified owner (a user or a business).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+	ownerIDJsonKey  <string>
+
+		The JSON key of the owner's ID ("user_id" or "business_id").
+
+	ownerID  <uint>
+
+		The owner's ID.
+
+	businessID  <uint>
+
+		Only include credit with this business (0 for credit with every business).
+
+*Returns*
+
+	None
+*/
+func (app *Application) respondWithStoreCredit(writer http.ResponseWriter, request *http.Request, ownerIDJsonKey string, ownerID uint, businessID uint) {
+	account := models.StoreCreditAccount{}
+	ownerAccounts, err := account.GetRecordsBySecondaryID(app.AppDB, ownerIDJsonKey, ownerID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	transaction := models.StoreCreditTransaction{}
+	ownerTransactions, err := transaction.GetRecordsBySecondaryID(app.AppDB, ownerIDJsonKey, ownerID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	accounts := []models.StoreCreditAccount{}
+	for _, ownerAccount := range ownerAccounts {
+		if businessID == 0 || ownerAccount.BusinessID == businessID {
+			accounts = append(accounts, ownerAccount)
+		}
+	}
+
+	transactions := []models.StoreCreditTransaction{}
+	for _, ownerTransaction := range ownerTransactions {
+		if businessID == 0 || ownerTransaction.BusinessID == businessID {
+			transactions = append(transactions, ownerTransaction)
+		}
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"store_credit_accounts": accounts,
+			"transactions":          transactions,
+		})
+}
@@ -0,0 +1,1061 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"server/models"
+	"server/money"
+	"server/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Body of a request to subscribe to a membership plan (see 'SubscribeToMembershipPlan')
+type subscriptionRequest struct {
+	UserID    uint       `json:"user_id"`
+	StartDate *time.Time `json:"start_date"`
+}
+
+// Body of a request to change the plan of a subscription (see 'ChangeSubscriptionPlan')
+type subscriptionPlanChangeRequest struct {
+	MembershipPlanID uint `json:"membership_plan_id"`
+	Preview          bool `json:"preview"`
+}
+
+/*
+*Description*
+
+func SubscribeToMembershipPlan
+
+Subscribes a User to the specified membership plan. If the membership starts immediately, an Invoice is created for the first billing period.
+
+Memberships that start in the future are invoiced by the billing job once their start date is reached, and every following billing period is
+invoiced by the billing job when it starts.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/membership-plan/{id}/subscribe
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			user_id  <uint>
+
+				ID of User subscribing to the membership plan
+
+		Optional fields:
+
+			start_date  <time.Time>
+
+				Date/time when the membership starts (defaults to the current time)
+
+*Example request(s)*
+
+	POST /membership-plan/5/subscribe
+	{
+		"user_id":123
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"subscription":{
+				"ID": 9,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"user_id":123,
+				"membership_plan_id":5,
+				"business_id":42,
+				"status":"Active",
+				"start_date":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_start":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_end":"2020-02-01T01:23:45.6789012-05:00",
+				"paused_at":null,
+				"cancelled_at":null,
+				"proration_credit":0
+			},
+			"invoice":{
+				"ID": 78,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":0,
+				"user_id":123,
+				"subtotal":9900,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":9900,
+				"remaining_balance":9900,
+				"status":"Unpaid"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body or missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Membership plan ID or User ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) SubscribeToMembershipPlan(writer http.ResponseWriter, request *http.Request) {
+	planID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var subscribeRequest subscriptionRequest
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&subscribeRequest); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	user := models.User{}
+	userIDExists, err := user.IDExists(app.AppDB, subscribeRequest.UserID)
+	if err != nil || !userIDExists {
+		var errorMessage string = fmt.Sprintf("User ID (%d) does not exist in the database.", subscribeRequest.UserID)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		return
+	}
+
+	var now time.Time = time.Now()
+	var startDate time.Time = now
+	if subscribeRequest.StartDate != nil {
+		startDate = *subscribeRequest.StartDate
+	}
+
+	sub := models.Subscription{}
+	returnedRecords, err := sub.Start(app.AppDB, planID, subscribeRequest.UserID, startDate, now)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			subscriptionErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusCreated,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func GetSubscription
+
+Get a subscription record from the database by ID, along with its current membership plan and the invoices generated for it (oldest to newest).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/subscription/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /subscription/9
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"subscription":{
+				"ID": 9,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"user_id":123,
+				"membership_plan_id":5,
+				"business_id":42,
+				"status":"Active",
+				"start_date":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_start":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_end":"2020-02-01T01:23:45.6789012-05:00",
+				"paused_at":null,
+				"cancelled_at":null,
+				"proration_credit":0
+			},
+			"membership_plan":{
+				"ID": 5,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"business_id":42,
+				"name":"Unlimited yoga",
+				"price":9900,
+				"billing_interval":"Monthly",
+				"visits_per_period":0
+			},
+			"invoices":[
+				{
+					"ID": 14,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"subscription_id":9,
+					"invoice_id":78,
+					"membership_plan_id":5,
+					"type":"Period",
+					"period_start":"2020-01-01T01:23:45.6789012-05:00",
+					"period_end":"2020-02-01T01:23:45.6789012-05:00",
+					"amount":9900
+				}
+			]
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetSubscription(writer http.ResponseWriter, request *http.Request) {
+	subID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	sub := models.Subscription{}
+	_, err = sub.Get(app.AppDB, subID)
+	if err != nil {
+		var errorMessage string = fmt.Sprintf("Subscription ID (%d) does not exist in the database.  [%s]", subID, err)
+
+		utils.RespondWithError(
+			writer,
+			http.StatusNotFound,
+			errorMessage)
+
+		log.Printf("ERROR:  %s", errorMessage)
+
+		return
+	}
+
+	plan := models.MembershipPlan{}
+	err = app.AppDB.Unscoped().First(&plan, sub.MembershipPlanID).Error
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	subInvoices, err := sub.GetInvoices(app.AppDB, subID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		map[string]interface{}{
+			"subscription":    &sub,
+			"membership_plan": &plan,
+			"invoices":        subInvoices,
+		})
+}
+
+/*
+*Description*
+
+func GetUserSubscriptions
+
+Get the list of membership subscriptions (active, paused and cancelled) for the specified User.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/user/{id}/subscriptions
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /user/123/subscriptions
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 9,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"user_id":123,
+				"membership_plan_id":5,
+				"business_id":42,
+				"status":"Active",
+				"start_date":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_start":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_end":"2020-02-01T01:23:45.6789012-05:00",
+				"paused_at":null,
+				"cancelled_at":null,
+				"proration_credit":0
+			}
+		]
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetUserSubscriptions(writer http.ResponseWriter, request *http.Request) {
+	userID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	sub := models.Subscription{}
+	var userIDJsonKey string = "user_id"
+	subs, err := sub.GetRecordsBySecondaryID(app.AppDB, userIDJsonKey, userID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		return
+	}
+
+	if subs == nil {
+		subs = []models.Subscription{}
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		subs)
+}
+
+/*
+*Description*
+
+func PauseSubscription
+
+Pauses the specified active subscription. Paused subscriptions are not billed and do not cover bookings.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/subscription/{id}/pause
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	POST /subscription/9/pause
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"subscription":{
+				"ID": 9,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-10T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"user_id":123,
+				"membership_plan_id":5,
+				"business_id":42,
+				"status":"Paused",
+				"start_date":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_start":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_end":"2020-02-01T01:23:45.6789012-05:00",
+				"paused_at":"2020-01-10T01:23:45.6789012-05:00",
+				"cancelled_at":null,
+				"proration_credit":0
+			}
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Subscription is not active
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) PauseSubscription(writer http.ResponseWriter, request *http.Request) {
+	sub := models.Subscription{}
+	app.changeSubscriptionStatus(writer, request, sub.Pause)
+}
+
+/*
+*Description*
+
+func ResumeSubscription
+
+Resumes the specified paused subscription. Time spent paused during a paid billing period is added to the end of that period.
+
+If the billing period has ended, an Invoice is created for the next billing period.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/subscription/{id}/resume
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	POST /subscription/9/resume
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"subscription":{
+				"ID": 9,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-15T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"user_id":123,
+				"membership_plan_id":5,
+				"business_id":42,
+				"status":"Active",
+				"start_date":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_start":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_end":"2020-02-06T01:23:45.6789012-05:00",
+				"paused_at":null,
+				"cancelled_at":null,
+				"proration_credit":0
+			}
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Subscription is not paused
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) ResumeSubscription(writer http.ResponseWriter, request *http.Request) {
+	sub := models.Subscription{}
+	app.changeSubscriptionStatus(writer, request, sub.Resume)
+}
+
+/*
+*Description*
+
+func CancelSubscription
+
+Cancels the specified subscription. Cancelled subscriptions are no longer billed.
+
+An active subscription keeps covering bookings for Services that start before the end of the billing period that has already been paid for.
+A paused subscription stops covering bookings immediately.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/subscription/{id}/cancel
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	POST /subscription/9/cancel
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"subscription":{
+				"ID": 9,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-20T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"user_id":123,
+				"membership_plan_id":5,
+				"business_id":42,
+				"status":"Cancelled",
+				"start_date":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_start":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_end":"2020-02-01T01:23:45.6789012-05:00",
+				"paused_at":null,
+				"cancelled_at":"2020-01-20T01:23:45.6789012-05:00",
+				"proration_credit":0
+			}
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Subscription is already cancelled
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CancelSubscription(writer http.ResponseWriter, request *http.Request) {
+	sub := models.Subscription{}
+	app.changeSubscriptionStatus(writer, request, sub.Cancel)
+}
+
+/*
+*Description*
+
+func ChangeSubscriptionPlan
+
+Moves the specified active subscription to a different membership plan offered by the same Business and returns the proration.
+
+The User is credited for the unused part of the current billing period on the old plan and charged for the rest of the period on the new plan.
+If the plans have different billing intervals, a new billing period on the new plan starts immediately and the new plan's full price is charged
+instead. A positive net amount is invoiced immediately. A negative net amount is kept as proration credit and deducted from the next invoice(s).
+
+If 'preview' is true, the proration is calculated without changing the subscription.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	POST
+
+	Route:	/subscription/{id}/change-plan
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			membership_plan_id  <uint>
+
+				ID of the membership plan to move to
+
+		Optional fields:
+
+			preview  <bool>
+
+				'true' to only calculate the proration (defaults to 'false')
+
+*Example request(s)*
+
+	POST /subscription/9/change-plan
+	{
+		"membership_plan_id":6
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"proration":{
+				"from_plan_id":5,
+				"to_plan_id":6,
+				"change_time":"2020-01-16T13:23:45.6789012-05:00",
+				"period_start":"2020-01-01T01:23:45.6789012-05:00",
+				"period_end":"2020-02-01T01:23:45.6789012-05:00",
+				"unused_credit":4950,
+				"new_plan_charge":7450,
+				"amount":2500
+			},
+			"subscription":{
+				"ID": 9,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-16T13:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"user_id":123,
+				"membership_plan_id":6,
+				"business_id":42,
+				"status":"Active",
+				"start_date":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_start":"2020-01-01T01:23:45.6789012-05:00",
+				"current_period_end":"2020-02-01T01:23:45.6789012-05:00",
+				"paused_at":null,
+				"cancelled_at":null,
+				"proration_credit":0
+			},
+			"invoice":{
+				"ID": 81,
+				"CreatedAt": "2020-01-16T13:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-16T13:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"appointment_id":0,
+				"user_id":123,
+				"subtotal":2500,
+				"discount_total":0,
+				"tax_total":0,
+				"original_balance":2500,
+				"remaining_balance":2500,
+				"status":"Unpaid"
+			}
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, or plan from a different Business
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Subscription ID or membership plan ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Subscription is not active
+		HTTP/1.1 409 Conflict
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) ChangeSubscriptionPlan(writer http.ResponseWriter, request *http.Request) {
+	subID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var changeRequest subscriptionPlanChangeRequest
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&changeRequest); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	sub := models.Subscription{}
+	proration, returnedRecords, err := sub.ChangePlan(app.AppDB, subID, changeRequest.MembershipPlanID, time.Now(), changeRequest.Preview)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			subscriptionErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	response := map[string]interface{}{"proration": proration}
+	for key, record := range returnedRecords {
+		response[key] = record
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		response)
+}
+
+/*
+*Description*
+
+func changeSubscriptionStatus
+
+Applies the specified Subscription status change (pause, resume or cancel) to the subscription identified in the request URL and responds with the result.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+	change  <func(*gorm.DB, uint, time.Time) (map[string]models.Model, error)>
+
+		The Subscription method that applies the status change
+
+*Returns*
+
+	None
+*/
+func (app *Application) changeSubscriptionStatus(writer http.ResponseWriter, request *http.Request, change func(*gorm.DB, uint, time.Time) (map[string]models.Model, error)) {
+	subID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	returnedRecords, err := change(app.AppDB, subID, time.Now())
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			subscriptionErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	respondWithLocalizedJSON(
+		writer,
+		request,
+		http.StatusOK,
+		returnedRecords)
+}
+
+/*
+*Description*
+
+func subscriptionErrorStatusCode
+
+Maps an error returned by a MembershipPlan or Subscription model method to the appropriate HTTP status code.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the model method.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error (500 if the error is not a known membership error).
+*/
+func subscriptionErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidMembershipPlan), errors.Is(err, money.ErrInvalidCurrency), errors.Is(err, money.ErrCurrencyMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrInvalidSubscriptionStatusTransition):
+		return http.StatusConflict
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,623 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"server/models"
+	"server/utils"
+
+	"gorm.io/gorm"
+)
+
+/*
+*Description*
+
+func CreateTaxRate
+
+Creates a new sales tax rate for a jurisdiction. Businesses in the rate's state (and zip code, if it has one) are charged the rate on
+their invoices, unless they choose another jurisdiction explicitly.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:  POST
+
+	Route:	/tax-rate
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			jurisdiction  <string>
+
+				Code of the jurisdiction (not case sensitive, and unique)
+
+			rate  <uint>
+
+				Combined tax rate charged in the jurisdiction, in basis points (e.g. 825 for 8.25%, at most 10000)
+
+		Optional fields:
+
+			name  <string>
+
+				Name of the tax (e.g. "Austin sales tax")
+
+			state  <string>
+
+				State (2 letter abbreviation) that the rate applies to. Leave it empty for a rate that only applies to businesses that
+				choose the jurisdiction explicitly.
+
+			zip  <string>
+
+				Zip code within the state that the rate applies to (leave it empty for the whole state)
+
+*Example request(s)*
+
+	POST /tax-rate
+	{
+		"jurisdiction":"TX",
+		"name":"Texas sales tax",
+		"state":"TX",
+		"rate":625
+	}
+
+	POST /tax-rate
+	{
+		"jurisdiction":"TX-AUSTIN",
+		"name":"Austin sales tax",
+		"state":"TX",
+		"zip":"78701",
+		"rate":825
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 201 Created
+		Content-Type: application/json
+
+		{
+			"ID": 3,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"jurisdiction":"TX-AUSTIN",
+			"name":"Austin sales tax",
+			"state":"TX",
+			"zip":"78701",
+			"rate":825
+		}
+
+	Failure:
+		-- Case = Bad request body, invalid tax rate, or a jurisdiction/state and zip code that already has a rate
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) CreateTaxRate(writer http.ResponseWriter, request *http.Request) {
+	rate := models.TaxRate{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&rate); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	returnedRecords, err := rate.Create(app.AppDB)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			taxRateErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusCreated,
+		returnedRecords["tax_rate"])
+}
+
+/*
+*Description*
+
+func GetTaxRates
+
+Get a list of every sales tax rate, ordered by state, zip code and jurisdiction.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/tax-rates
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /tax-rates
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		[
+			{
+				"ID": 3,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"jurisdiction":"TX",
+				"name":"Texas sales tax",
+				"state":"TX",
+				"zip":"",
+				"rate":625
+			},
+			{
+				"ID": 4,
+				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+				"DeletedAt": null,
+				"jurisdiction":"TX-AUSTIN",
+				"name":"Austin sales tax",
+				"state":"TX",
+				"zip":"78701",
+				"rate":825
+			}
+		]
+
+	Failure:
+
+		HTTP/1.1 500 InternalServerError
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetTaxRates(writer http.ResponseWriter, request *http.Request) {
+	rate := models.TaxRate{}
+
+	rates, err := rate.GetAll(app.AppDB)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusInternalServerError,
+			err.Error())
+
+		log.Printf("ERROR:  %s", err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		rates)
+}
+
+/*
+*Description*
+
+func GetTaxRate
+
+Get a sales tax rate record from the database by ID.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	GET
+
+	Route:	/tax-rate/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	GET /tax-rate/3
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 3,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": null,
+			"jurisdiction":"TX",
+			"name":"Texas sales tax",
+			"state":"TX",
+			"zip":"",
+			"rate":625
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) GetTaxRate(writer http.ResponseWriter, request *http.Request) {
+	rateID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	rate := models.TaxRate{}
+	returnedRecords, err := rate.Get(app.AppDB, rateID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			taxRateErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		returnedRecords["tax_rate"])
+}
+
+/*
+*Description*
+
+func UpdateTaxRate
+
+Updates the specified sales tax rate record in the database. Invoices created afterwards are charged the new rate, and tax already
+charged on invoices is not changed.
+
+This function behaves like a PATCH method, rather than a true PUT. Any fields that aren't specified in the request body for the PUT request will not be altered for the specified record.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	PUT
+
+	Route:	/tax-rate/{id}
+
+	Body:
+		Format: JSON
+
+		Required fields:
+
+			N/A  --  At least one field should be present in the request body, but no fields are specifically required to be present in the request body.
+
+		Optional fields:
+
+			Any of the fields accepted by 'CreateTaxRate'.
+
+*Example request(s)*
+
+	PUT /tax-rate/4
+	{
+		"rate":850
+	}
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 4,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2023-04-20T04:20:13.5057833-05:00",
+			"DeletedAt": null,
+			"jurisdiction":"TX-AUSTIN",
+			"name":"Austin sales tax",
+			"state":"TX",
+			"zip":"78701",
+			"rate":850
+		}
+
+	Failure:
+		-- Case = Bad request body, missing/misformatted ID in request URL, invalid tax rate, or a jurisdiction/state and zip code that
+		already has a rate
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) UpdateTaxRate(writer http.ResponseWriter, request *http.Request) {
+	rateID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	var updates map[string]interface{}
+
+	decoder := json.NewDecoder(request.Body)
+	if err := decoder.Decode(&updates); err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	defer request.Body.Close()
+
+	rate := models.TaxRate{}
+	returnedRecords, err := rate.Update(app.AppDB, rateID, updates)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			taxRateErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		returnedRecords["tax_rate"])
+}
+
+/*
+*Description*
+
+func DeleteTaxRate
+
+Delete a sales tax rate record from the database by ID. Businesses in its state and zip code are no longer charged the rate, and
+businesses that chose its jurisdiction explicitly must choose another jurisdiction first. Tax already charged on invoices is kept.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+
+*Expected request format*
+
+	Type:	DELETE
+
+	Route:	/tax-rate/{id}
+
+	Body:
+
+		None
+
+*Example request(s)*
+
+	DELETE /tax-rate/4
+
+*Response format*
+
+	Success:
+
+		HTTP/1.1 200 OK
+		Content-Type: application/json
+
+		{
+			"ID": 4,
+			"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+			"DeletedAt": "2023-04-20T04:20:13.5057833-05:00",
+			"jurisdiction":"TX-AUSTIN",
+			"name":"Austin sales tax",
+			"state":"TX",
+			"zip":"78701",
+			"rate":825
+		}
+
+	Failure:
+		-- Case = Missing/misformatted ID in request URL, or businesses have chosen the rate's jurisdiction
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = ID not found in DB
+		HTTP/1.1 404 Resource Not Found
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
+		HTTP/1.1 500 Internal Server Error
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+*/
+func (app *Application) DeleteTaxRate(writer http.ResponseWriter, request *http.Request) {
+	rateID, err := utils.ParseRequestID(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
+
+	rate := models.TaxRate{}
+	returnedRecords, err := rate.Delete(app.AppDB, rateID)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			taxRateErrorStatusCode(err),
+			err.Error())
+
+		return
+	}
+
+	utils.RespondWithJSON(
+		writer,
+		http.StatusOK,
+		returnedRecords["tax_rate"])
+}
+
+/*
+*Description*
+
+func taxRateErrorStatusCode
+
+Maps an error returned by a TaxRate model method to the appropriate HTTP status code.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by the model method.
+
+*Returns*
+
+	_  <int>
+
+		The HTTP status code for the error (500 if the error is not a known tax rate error).
+*/
+func taxRateErrorStatusCode(err error) int {
+	switch {
+	case errors.Is(err, models.ErrInvalidTaxRate):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
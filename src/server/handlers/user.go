@@ -591,7 +591,8 @@ func (app *Application) DeleteUser(writer http.ResponseWriter, request *http.Req
 
 func GetUsers
 
-Get a list of all User records in the database.
+Get a page of the User records in the database, sorted and filtered as requested. Pages are found with the cursor returned
+with the previous page, and report the total number of records that match the filters.
 
 *Parameters*
 
@@ -613,14 +614,33 @@ Get a list of all User records in the database.
 
 	Route:	/users
 
-	Body:
+	Query parameters (all optional):
 
-		None
+		limit  <int>
+
+			Number of records in the page (defaults to 50, at most 200).
+
+		cursor  <string>
+
+			The 'next_cursor' of the previous page (omitted for the first page). Must be used with the same sort.
+
+		sort  <string>
+
+			Comma separated fields to sort by, each prefixed with '-' to sort in descending order. Records that sort equally are sorted
+			by ID. Sortable fields: id, created_at, updated_at, email, first_name, last_name.
+
+		{field}, {field}[{operator}]  <string>
+
+			Filters by a field, with an optional operator: eq (the default), ne, gt, gte, lt, lte, or in (comma separated values). Dates
+			are YYYY-MM-DD or RFC 3339 date/times. Fields: id, created_at, updated_at, first_name, last_name, email, account_type,
+			business_id.
 
 *Example request(s)*
 
 	GET /users
 
+	GET /users?account_type=Business&sort=last_name,first_name&limit=20
+
 *Response format*
 
 	Success:
@@ -628,36 +648,50 @@ Get a list of all User records in the database.
 		HTTP/1.1 200 OK
 		Content-Type: application/json
 
-		[
-			{
-				"ID": 72,
-				"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
-				"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
-				"DeletedAt": null,
-				"email": "curb-it@example.com",
-				"password": "$2a$14$ITcK9ZosVTZpx3OeJT8qu.I1Qfy31MinvsYvPbOCeIXj2fSxMCh8O",
-				"account_type": "User",
-				"first_name": "Larry",
-				"last_name": "David",
-				"business_id": null
-			},
-			{
-				"ID": 411,
-				"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
-				"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
-				"DeletedAt": null,
-				"email": "bubble.guppies.witch@hotmail.com",
-				"password": "qwerQEWR174$8O4$1Qfy31MinvsYvPbOCeIXj2fSxMCh8O4$IT",
-				"account_type": "Business",
-				"first_name": "Wanda",
-				"last_name": "Sykes",
-				"business_id": 31
-			},
-			...
-		]
+		{
+			"data":[
+				{
+					"ID": 72,
+					"CreatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"UpdatedAt": "2020-01-01T01:23:45.6789012-05:00",
+					"DeletedAt": null,
+					"email": "curb-it@example.com",
+					"password": "$2a$14$ITcK9ZosVTZpx3OeJT8qu.I1Qfy31MinvsYvPbOCeIXj2fSxMCh8O",
+					"account_type": "User",
+					"first_name": "Larry",
+					"last_name": "David",
+					"business_id": null
+				},
+				{
+					"ID": 411,
+					"CreatedAt": "2022-07-10T14:32:13.1589417-05:00",
+					"UpdatedAt": "2022-11-23T05:41:03.4507451-05:00",
+					"DeletedAt": null,
+					"email": "bubble.guppies.witch@hotmail.com",
+					"password": "qwerQEWR174$8O4$1Qfy31MinvsYvPbOCeIXj2fSxMCh8O4$IT",
+					"account_type": "Business",
+					"first_name": "Wanda",
+					"last_name": "Sykes",
+					"business_id": 31
+				},
+				...
+			],
+			"total_count":124,
+			"limit":50,
+			"next_cursor":"eyJzIjoiIiwidiI6WzQxMV19"
+		}
 
 	Failure:
 
+		-- Case = Invalid limit, cursor, sort or filter
+		HTTP/1.1 400 Bad Request
+		Content-Type: application/json
+
+		{
+			"error":"ERROR MESSAGE TEXT HERE"
+		}
+
+		-- Case = Database operation error
 		HTTP/1.1 500 InternalServerError
 		Content-Type: application/json
 
@@ -666,14 +700,22 @@ Get a list of all User records in the database.
 		}
 */
 func (app *Application) GetUsers(writer http.ResponseWriter, request *http.Request) {
-	user := models.User{}
-	var users []models.User
+	options, err := parseListOptions(request)
+	if err != nil {
+		utils.RespondWithError(
+			writer,
+			http.StatusBadRequest,
+			err.Error())
+
+		return
+	}
 
-	users, err := user.GetAll(app.AppDB)
+	user := models.User{}
+	page, err := user.List(app.AppDB, options)
 	if err != nil {
 		utils.RespondWithError(
 			writer,
-			http.StatusInternalServerError,
+			listErrorStatusCode(err),
 			err.Error())
 
 		log.Printf("ERROR:  %s", err.Error())
@@ -684,7 +726,7 @@ func (app *Application) GetUsers(writer http.ResponseWriter, request *http.Reque
 	utils.RespondWithJSON(
 		writer,
 		http.StatusOK,
-		users)
+		page)
 }
 
 /*
@@ -775,7 +817,7 @@ func (app *Application) GetUserEnrolledStatus(writer http.ResponseWriter, reques
 
 	var user *models.User
 	hasServiceAppointment, err := user.HasServiceAppointment(app.AppDB, userID, serviceID)
-	if userIDErr != nil {
+	if err != nil {
 		utils.RespondWithError(
 			writer,
 			http.StatusInternalServerError,
@@ -842,7 +884,8 @@ Get a list of all the Appointments (and the associated Service for each Appointm
 					"service_id":11,
 					"user_id":42,
 					"cancel_date_time":null,
-					"active":true
+					"status":"Confirmed",
+					"seats":1
 				},
 				"service": {
 					"ID": 11,
@@ -870,7 +913,8 @@ Get a list of all the Appointments (and the associated Service for each Appointm
 					"service_id":83,
 					"user_id":42,
 					"cancel_date_time":null,
-					"active":true
+					"status":"Confirmed",
+					"seats":1
 				},
 				"service": {
 					"ID": 83,
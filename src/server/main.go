@@ -6,8 +6,18 @@ import (
 	"server/handlers"
 	"server/models"
 	"server/sample_data"
+	"time"
 )
 
+// Time between runs of the membership billing job
+const subscriptionBillingInterval time.Duration = time.Hour
+
+// Time between runs of the dunning job (overdue invoices, late fees and payment reminders)
+const dunningInterval time.Duration = time.Hour
+
+// Time between runs of the cancellation refund job (refunds that could not be completed when the appointment was cancelled)
+const cancellationRefundInterval time.Duration = 15 * time.Minute
+
 func main() {
 	var prodDBName string = config.AppConfig.APP_DB_NAME
 
@@ -23,6 +33,15 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Start the membership billing job
+	go handlers.App.RunSubscriptionBillingJob(subscriptionBillingInterval)
+
+	// Start the dunning job
+	go handlers.App.RunDunningJob(dunningInterval)
+
+	// Start the cancellation refund job
+	go handlers.App.RunCancellationRefundJob(cancellationRefundInterval)
+
 	// Launch application instance
 	handlers.App.Run(config.AppConfig.GetAPIServerNetworkAddress())
 }
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// DeprecatedRouteMiddleware marks the responses of deprecated routes with a 'Deprecation' header, a 'Link' header to the route that
+// replaces them (the same path under successorPrefix) and, if a sunset date is set, a 'Sunset' header with the date that they may be removed.
+// The first request for each deprecated route is logged, so the routes that clients still use show up without flooding the log
+func DeprecatedRouteMiddleware(successorPrefix string, sunset time.Time) func(http.Handler) http.Handler {
+	var requestedRoutes sync.Map
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			// Routes are told apart by their path template, so paths with different IDs are logged once
+			var routePath string = request.URL.Path
+			if route := mux.CurrentRoute(request); route != nil {
+				if pathTemplate, err := route.GetPathTemplate(); err == nil {
+					routePath = pathTemplate
+				}
+			}
+
+			if _, logged := requestedRoutes.LoadOrStore(request.Method+" "+routePath, true); !logged {
+				log.Printf("INFO:  Deprecated route %s %s was requested (use %s%s instead)", request.Method, routePath, successorPrefix, routePath)
+			}
+
+			writer.Header().Set("Deprecation", "true")
+			writer.Header().Set("Link", "<"+successorPrefix+request.URL.Path+`>; rel="successor-version"`)
+			if !sunset.IsZero() {
+				writer.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
@@ -0,0 +1,764 @@
+package models
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"server/money"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all AccountingExport records in the database (one record per export of a Business's journal, see 'Business.ExportAccounting')
+type AccountingExport struct {
+	gorm.Model
+	BusinessID  uint      `gorm:"column:business_id;not null;index" json:"business_id"` // ID of Business whose invoices, payments and refunds were exported
+	Format      string    `gorm:"column:format;not null" json:"format"`                 // Format of the exported file (csv, iif, json)
+	From        time.Time `gorm:"column:from_time;not null" json:"from"`                // Start of the exported period (inclusive)
+	To          time.Time `gorm:"column:to_time;not null" json:"to"`                    // End of the exported period (exclusive). The next incremental export starts here
+	Incremental bool      `gorm:"column:incremental;default:false" json:"incremental"`  // True if the export continued from the end of the Business's previous incremental export
+	EntryCount  int       `gorm:"column:entry_count" json:"entry_count"`                // Number of journal entries exported
+}
+
+// Accounting export formats
+const (
+	AccountingFormatCSV  string = "csv"  // One row per journal line, with amounts in each currency's major unit
+	AccountingFormatIIF  string = "iif"  // QuickBooks Desktop import file (Intuit Interchange Format)
+	AccountingFormatJSON string = "json" // Double-entry journal as JSON, with amounts in cents
+)
+
+// How long before the time of an incremental export its period ends. Documents are dated when they are written, but only become visible
+// once their transaction commits, so the most recent documents are left to the next incremental export. A document whose transaction
+// stays open for longer than this can still be skipped
+const AccountingExportLag time.Duration = 5 * time.Minute
+
+// List of valid accounting export formats
+var accountingFormats []string = []string{
+	AccountingFormatCSV,
+	AccountingFormatIIF,
+	AccountingFormatJSON,
+}
+
+// Types of journal entries in an accounting export
+const (
+	JournalEntryInvoice    string = "Invoice"     // An issued invoice: the customer owes its total
+	JournalEntryCreditNote string = "Credit Note" // A credit note: part of an invoice (and its tax) is no longer owed
+	JournalEntryPayment    string = "Payment"     // A payment received against an invoice
+	JournalEntryRefund     string = "Refund"      // A payment returned to the customer, as money or store credit
+)
+
+// Order of journal entries of each type that are dated at the same time
+var journalEntryOrder map[string]int = map[string]int{
+	JournalEntryInvoice:    0,
+	JournalEntryCreditNote: 1,
+	JournalEntryPayment:    2,
+	JournalEntryRefund:     3,
+}
+
+// Default names of the ledger accounts that a Business's transactions are exported to
+const (
+	DefaultReceivablesAccount    string = "Accounts Receivable"
+	DefaultRevenueAccount        string = "Sales"
+	DefaultSalesTaxAccount       string = "Sales Tax Payable"
+	DefaultDepositAccount        string = "Undeposited Funds"
+	DefaultCustomerCreditAccount string = "Customer Credits"
+)
+
+// Longest ledger account name that a Business can map its transactions to
+const maxAccountNameLength int = 100
+
+// Errors returned when an accounting export or a Business's account mapping is invalid
+var (
+	ErrInvalidAccountMapping   = errors.New("invalid account mapping")
+	ErrInvalidAccountingExport = errors.New("invalid accounting export")
+)
+
+// Names of the ledger accounts that a Business's transactions are exported to (see 'Business.GetAccountMapping')
+type AccountMapping struct {
+	Receivables    string `json:"receivables"`     // Amounts owed by customers on issued invoices
+	Revenue        string `json:"revenue"`         // Sales, after discounts and before tax
+	SalesTax       string `json:"sales_tax"`       // Tax charged on sales, owed to the tax jurisdictions
+	Deposits       string `json:"deposits"`        // Money received from customers (and returned to them by refunds)
+	CustomerCredit string `json:"customer_credit"` // Store credit and gift card balances held by customers
+}
+
+// One side of a journal entry. Exactly one of Debit and Credit is set (in the smallest unit of the entry's currency, e.g. cents)
+type JournalLine struct {
+	Account string `json:"account"` // Name of the ledger account
+	Debit   int    `json:"debit"`   // Amount debited to the account
+	Credit  int    `json:"credit"`  // Amount credited to the account
+}
+
+// A balanced double-entry journal entry for one invoice, credit note, payment or refund (see 'Business.ExportAccounting')
+type JournalEntry struct {
+	Date          time.Time     `json:"date"`           // Date/time when the invoice or credit note was issued, or the payment or refund was made
+	Type          string        `json:"type"`           // Invoice, Credit Note, Payment or Refund
+	DocumentID    uint          `json:"document_id"`    // ID of the Invoice, CreditNote, Payment or Refund record
+	Reference     string        `json:"reference"`      // Invoice or credit note number, or the external reference of the payment or refund
+	InvoiceID     uint          `json:"invoice_id"`     // ID of the Invoice that the entry belongs to
+	InvoiceNumber string        `json:"invoice_number"` // Number of the Invoice that the entry belongs to
+	UserID        uint          `json:"user_id"`        // ID of the User billed by the invoice
+	Customer      string        `json:"customer"`       // Name of the User billed by the invoice
+	Currency      string        `json:"currency"`       // ISO 4217 currency of the amounts
+	Memo          string        `json:"memo"`           // Description of the entry (e.g. the payment method or the reason for a refund)
+	Lines         []JournalLine `json:"lines"`          // Debits and credits of the entry, which total the same amount
+}
+
+// Double-entry journal of a Business's invoices, credit notes, payments and refunds over a period (see 'Business.ExportAccounting')
+type AccountingJournal struct {
+	BusinessID uint           `json:"business_id"` // ID of the Business that the journal belongs to
+	From       time.Time      `json:"from"`        // Start of the period (inclusive)
+	To         time.Time      `json:"to"`          // End of the period (exclusive)
+	Accounts   AccountMapping `json:"accounts"`    // Ledger accounts that the entries are posted to
+	Entries    []JournalEntry `json:"entries"`     // Journal entries, ordered by date
+}
+
+// An invoice, credit note, payment or refund of a Business, as selected by the accounting journal queries
+type accountingDocumentRow struct {
+	ID            uint      `gorm:"column:id"`
+	InvoiceID     uint      `gorm:"column:invoice_id"`
+	InvoiceNumber string    `gorm:"column:invoice_number"`
+	UserID        uint      `gorm:"column:user_id"`
+	FirstName     string    `gorm:"column:first_name"`
+	LastName      string    `gorm:"column:last_name"`
+	Reference     string    `gorm:"column:reference"`
+	Memo          string    `gorm:"column:memo"`
+	Method        string    `gorm:"column:method"`
+	Date          time.Time `gorm:"column:date"`
+	Amount        int       `gorm:"column:amount"`
+	Tax           int       `gorm:"column:tax"`
+	Currency      string    `gorm:"column:currency"`
+	StoreCredit   bool      `gorm:"column:store_credit"`
+	GiftCardSale  bool      `gorm:"column:gift_card_sale"`
+}
+
+// Journal entry of an accounting document row (with its reference details set and no lines yet)
+func (row accountingDocumentRow) entry(entryType string) JournalEntry {
+	return JournalEntry{
+		Date:          row.Date,
+		Type:          entryType,
+		DocumentID:    row.ID,
+		Reference:     row.Reference,
+		InvoiceID:     row.InvoiceID,
+		InvoiceNumber: row.InvoiceNumber,
+		UserID:        row.UserID,
+		Customer:      strings.TrimSpace(row.FirstName + " " + row.LastName),
+		Currency:      row.Currency,
+		Memo:          row.Memo,
+	}
+}
+
+// Selects the invoices, credit notes, payments and refunds of a Business for the accounting journal. Each query's '%s' is replaced with the
+// column that the period is filtered on (see 'accountingFilterColumns')
+var accountingDocumentQueries map[string]string = map[string]string{
+	JournalEntryInvoice: `SELECT
+			invoices.id,
+			invoices.id AS invoice_id,
+			invoices.number AS invoice_number,
+			invoices.user_id,
+			COALESCE(users.first_name, '') AS first_name,
+			COALESCE(users.last_name, '') AS last_name,
+			invoices.number AS reference,
+			'' AS memo,
+			invoices.issued_at AS date,
+			invoices.original_balance AS amount,
+			invoices.tax_total AS tax,
+			invoices.currency,
+			EXISTS (SELECT 1 FROM gift_cards WHERE gift_cards.invoice_id = invoices.id AND gift_cards.deleted_at IS NULL) AS gift_card_sale
+		FROM invoices
+		LEFT JOIN users ON users.id = invoices.user_id
+		WHERE invoices.business_id = @business_id
+			AND invoices.state = @state
+			AND invoices.%[1]s >= @from
+			AND invoices.%[1]s < @to
+			AND invoices.deleted_at IS NULL`,
+
+	JournalEntryCreditNote: `SELECT
+			credit_notes.id,
+			credit_notes.invoice_id,
+			invoices.number AS invoice_number,
+			invoices.user_id,
+			COALESCE(users.first_name, '') AS first_name,
+			COALESCE(users.last_name, '') AS last_name,
+			credit_notes.number AS reference,
+			credit_notes.reason AS memo,
+			credit_notes.issued_at AS date,
+			credit_notes.amount,
+			credit_notes.tax,
+			credit_notes.currency,
+			EXISTS (SELECT 1 FROM gift_cards WHERE gift_cards.invoice_id = invoices.id AND gift_cards.deleted_at IS NULL) AS gift_card_sale
+		FROM credit_notes
+		JOIN invoices ON invoices.id = credit_notes.invoice_id
+		LEFT JOIN users ON users.id = invoices.user_id
+		WHERE credit_notes.business_id = @business_id
+			AND credit_notes.%[1]s >= @from
+			AND credit_notes.%[1]s < @to
+			AND credit_notes.deleted_at IS NULL`,
+
+	JournalEntryPayment: `SELECT
+			payments.id,
+			payments.invoice_id,
+			invoices.number AS invoice_number,
+			invoices.user_id,
+			COALESCE(users.first_name, '') AS first_name,
+			COALESCE(users.last_name, '') AS last_name,
+			payments.reference,
+			'Payment by ' || payments.method AS memo,
+			payments.method,
+			payments.paid_at AS date,
+			payments.amount,
+			payments.currency
+		FROM payments
+		JOIN invoices ON invoices.id = payments.invoice_id
+		LEFT JOIN users ON users.id = invoices.user_id
+		WHERE invoices.business_id = @business_id
+			AND payments.%[1]s >= @from
+			AND payments.%[1]s < @to
+			AND payments.deleted_at IS NULL`,
+
+	JournalEntryRefund: `SELECT
+			refunds.id,
+			refunds.invoice_id,
+			invoices.number AS invoice_number,
+			invoices.user_id,
+			COALESCE(users.first_name, '') AS first_name,
+			COALESCE(users.last_name, '') AS last_name,
+			refunds.reference,
+			refunds.reason AS memo,
+			payments.method,
+			refunds.refunded_at AS date,
+			refunds.amount,
+			refunds.currency,
+			refunds.store_credit
+		FROM refunds
+		JOIN payments ON payments.id = refunds.payment_id
+		JOIN invoices ON invoices.id = refunds.invoice_id
+		LEFT JOIN users ON users.id = invoices.user_id
+		WHERE invoices.business_id = @business_id
+			AND refunds.%[1]s >= @from
+			AND refunds.%[1]s < @to
+			AND refunds.deleted_at IS NULL`,
+}
+
+// Columns that the accounting journal queries filter on, by the type of export. Exports of a date range include the documents dated in
+// the range. Incremental exports include the documents recorded since the previous export, so payments and refunds that are recorded
+// with an earlier date are still exported (invoices are recorded when they are issued). Incremental exports end 'AccountingExportLag'
+// before they are made, so documents whose transactions were still committing when an export ran are left to the next one
+var accountingFilterColumns map[bool]map[string]string = map[bool]map[string]string{
+	false: {
+		JournalEntryInvoice:    "issued_at",
+		JournalEntryCreditNote: "issued_at",
+		JournalEntryPayment:    "paid_at",
+		JournalEntryRefund:     "refunded_at",
+	},
+	true: {
+		JournalEntryInvoice:    "issued_at",
+		JournalEntryCreditNote: "created_at",
+		JournalEntryPayment:    "created_at",
+		JournalEntryRefund:     "created_at",
+	},
+}
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from AccountingExport object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the accounting export object
+*/
+func (export *AccountingExport) GetID() uint {
+	return export.ID
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves the AccountingExport records that match the specified secondary ID (e.g. all of the exports of a Business), newest first.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key of the secondary ID to search by (e.g. "business_id").
+
+	secondaryID  <uint>
+
+		The secondary ID to search for.
+
+*Returns*
+
+	_  <[]AccountingExport>
+
+		The AccountingExport records that match the secondary ID.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (export *AccountingExport) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]AccountingExport, error) {
+	var exports []AccountingExport
+	err := db.Where(fmt.Sprintf("%s = ?", secondaryIDJsonKey), secondaryID).Order("id DESC").Find(&exports).Error
+	return exports, err
+}
+
+/*
+*Description*
+
+func GetAccountMapping
+
+Returns the ledger accounts that the calling Business's transactions are exported to. Accounts that are not set use their defaults
+(e.g. 'DefaultReceivablesAccount').
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <AccountMapping>
+
+		The business's account mapping.
+*/
+func (business *Business) GetAccountMapping() AccountMapping {
+	account := func(name string, defaultName string) string {
+		if name == "" {
+			return defaultName
+		}
+
+		return name
+	}
+
+	return AccountMapping{
+		Receivables:    account(business.ReceivablesAccount, DefaultReceivablesAccount),
+		Revenue:        account(business.RevenueAccount, DefaultRevenueAccount),
+		SalesTax:       account(business.SalesTaxAccount, DefaultSalesTaxAccount),
+		Deposits:       account(business.DepositAccount, DefaultDepositAccount),
+		CustomerCredit: account(business.CustomerCreditAccount, DefaultCustomerCreditAccount),
+	}
+}
+
+/*
+*Description*
+
+func validateAccountMappings
+
+Confirms that the ledger account names in a map of Business attributes (if they are set) are valid. Account names are required, at most
+'maxAccountNameLength' characters, and can't contain tabs or line breaks (which would break the exported files).
+
+Changing an account only changes the exports made afterwards, so a mapping should be kept stable once the Business's journal has been
+imported into its accounting software.
+
+*Parameters*
+
+	attributes  <map[string]interface{}>
+
+		JSON with the attributes as keys and their values as values.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func validateAccountMappings(attributes map[string]interface{}) error {
+	for _, attribute := range []string{"receivables_account", "revenue_account", "sales_tax_account", "deposit_account", "customer_credit_account"} {
+		value, accountSet := attributes[attribute]
+		if !accountSet {
+			continue
+		}
+
+		account, isString := value.(string)
+		if !isString || strings.TrimSpace(account) == "" {
+			return fmt.Errorf("%w: %s must be the name of a ledger account", ErrInvalidAccountMapping, attribute)
+		}
+
+		if len([]rune(account)) > maxAccountNameLength || strings.ContainsAny(account, "\t\r\n") {
+			return fmt.Errorf("%w: %s '%s' must be at most %d characters, without tabs or line breaks", ErrInvalidAccountMapping, attribute, account, maxAccountNameLength)
+		}
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func ExportAccounting
+
+Exports the double-entry journal of a Business's issued invoices, credit notes, payments and refunds, and records the export. Each
+document is one balanced journal entry, posted to the ledger accounts in the Business's account mapping (see 'GetAccountMapping'):
+
+	Invoice:      debit Receivables with the total; credit Revenue with the subtotal and Sales Tax with the tax
+	Credit note:  debit Revenue with the amount less tax and Sales Tax with the tax; credit Receivables with the amount
+	Payment:      debit Deposits with the amount; credit Receivables with the amount
+	Refund:       debit Receivables with the amount; credit Deposits with the amount
+
+Gift cards sold on an invoice are owed to the card holder until they are spent, so their sale (and any credit note for it) is posted to
+Customer Credit instead of Revenue. Payments made with store credit or a gift card, refunds of those payments, and refunds that are kept
+as store credit are posted to Customer Credit instead of Deposits.
+
+Exports of a date range include the documents dated within the range. Incremental exports continue from the end of the Business's previous
+incremental export (or from when the Business was created) up to 'AccountingExportLag' before the time of the export, and include the
+documents recorded in that time. Consecutive incremental exports don't overlap, so a document is not exported by two of them. The lag
+leaves documents whose transactions may not have committed yet to the next incremental export (a document written by a transaction that
+stays open for longer than the lag can be missed, and is still included in exports of a date range). If nothing new can be exported yet
+(the previous incremental export ended less than the lag ago), an empty journal is returned and no export is recorded.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be retrieved from and the export recorded.
+
+	businessID  <uint>
+
+		The ID of the Business whose journal is exported.
+
+	format  <string>
+
+		The format of the exported file (see 'accountingFormats'), recorded with the export.
+
+	from  <time.Time>
+
+		The start of the period (inclusive). Ignored for incremental exports.
+
+	to  <time.Time>
+
+		The end of the period (exclusive). For incremental exports, the time of the export (the period ends 'AccountingExportLag' before it).
+
+	incremental  <bool>
+
+		True to continue from the end of the Business's previous incremental export.
+
+*Returns*
+
+	_  <*AccountingJournal>
+
+		The exported journal.
+
+	_  <*AccountingExport>
+
+		The recorded export.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (business *Business) ExportAccounting(db *gorm.DB, businessID uint, format string, from time.Time, to time.Time, incremental bool) (*AccountingJournal, *AccountingExport, error) {
+	journal := &AccountingJournal{BusinessID: businessID, Entries: []JournalEntry{}}
+	export := &AccountingExport{BusinessID: businessID, Format: format, Incremental: incremental}
+
+	if !slices.Contains(accountingFormats, format) {
+		return journal, export, fmt.Errorf("%w: format '%s' must be one of %v", ErrInvalidAccountingExport, format, accountingFormats)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Locking the business keeps concurrent incremental exports from starting at the same point
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(business, businessID).Error
+		if err != nil {
+			return err
+		}
+
+		if incremental {
+			from = business.CreatedAt
+			to = to.Add(-AccountingExportLag)
+
+			previous := &AccountingExport{}
+			err = tx.Where("business_id = ? AND incremental = ?", businessID, true).Order("to_time DESC").Limit(1).Find(previous).Error
+			if err != nil {
+				return err
+			}
+
+			if previous.ID != 0 {
+				from = previous.To
+			}
+		}
+
+		// Nothing new can be exported yet, so the journal is empty and the next incremental export still starts where the last one ended
+		if incremental && !from.Before(to) {
+			journal.From = from
+			journal.To = from
+			journal.Accounts = business.GetAccountMapping()
+			export.From = from
+			export.To = from
+			return nil
+		} else if !from.Before(to) {
+			return fmt.Errorf("%w: the start of the period must be before its end", ErrInvalidAccountingExport)
+		}
+
+		journal.From = from
+		journal.To = to
+		journal.Accounts = business.GetAccountMapping()
+
+		err = journal.addEntries(tx, incremental)
+		if err != nil {
+			return err
+		}
+
+		export.From = from
+		export.To = to
+		export.EntryCount = len(journal.Entries)
+
+		return tx.Create(export).Error
+	})
+
+	return journal, export, err
+}
+
+/*
+*Description*
+
+func addEntries
+
+Adds a journal entry to the calling AccountingJournal for each invoice, credit note, payment and refund of its Business in its period,
+and orders the entries by date (see 'Business.ExportAccounting').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be retrieved from.
+
+	incremental  <bool>
+
+		True to select the documents recorded in the period, instead of the documents dated in the period.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (journal *AccountingJournal) addEntries(db *gorm.DB, incremental bool) error {
+	parameters := map[string]interface{}{
+		"business_id": journal.BusinessID,
+		"state":       InvoiceStateIssued,
+		"from":        journal.From,
+		"to":          journal.To,
+	}
+
+	accounts := journal.Accounts
+
+	for entryType, query := range accountingDocumentQueries {
+		var rows []accountingDocumentRow
+		err := db.Raw(fmt.Sprintf(query, accountingFilterColumns[incremental][entryType]), parameters).Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if row.Amount == 0 {
+				continue
+			}
+
+			sales := accounts.Revenue
+			if row.GiftCardSale {
+				sales = accounts.CustomerCredit
+			}
+
+			deposits := accounts.Deposits
+			if row.StoreCredit || row.Method == PaymentMethodStoreCredit || row.Method == PaymentMethodGiftCard {
+				deposits = accounts.CustomerCredit
+			}
+
+			entry := row.entry(entryType)
+			switch entryType {
+			case JournalEntryInvoice:
+				entry.Lines = []JournalLine{
+					{Account: accounts.Receivables, Debit: row.Amount},
+					{Account: sales, Credit: row.Amount - row.Tax},
+					{Account: accounts.SalesTax, Credit: row.Tax},
+				}
+			case JournalEntryCreditNote:
+				entry.Lines = []JournalLine{
+					{Account: accounts.Receivables, Credit: row.Amount},
+					{Account: sales, Debit: row.Amount - row.Tax},
+					{Account: accounts.SalesTax, Debit: row.Tax},
+				}
+			case JournalEntryPayment:
+				entry.Lines = []JournalLine{
+					{Account: deposits, Debit: row.Amount},
+					{Account: accounts.Receivables, Credit: row.Amount},
+				}
+			case JournalEntryRefund:
+				entry.Lines = []JournalLine{
+					{Account: deposits, Credit: row.Amount},
+					{Account: accounts.Receivables, Debit: row.Amount},
+				}
+			}
+
+			// Untaxed documents have no sales tax line
+			lines := entry.Lines[:0]
+			for _, line := range entry.Lines {
+				if line.Debit != 0 || line.Credit != 0 {
+					lines = append(lines, line)
+				}
+			}
+			entry.Lines = lines
+
+			journal.Entries = append(journal.Entries, entry)
+		}
+	}
+
+	sort.Slice(journal.Entries, func(i, j int) bool {
+		a, b := journal.Entries[i], journal.Entries[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+
+		if a.Type != b.Type {
+			return journalEntryOrder[a.Type] < journalEntryOrder[b.Type]
+		}
+
+		return a.DocumentID < b.DocumentID
+	})
+
+	return nil
+}
+
+/*
+*Description*
+
+func CSV
+
+Writes the calling AccountingJournal as a CSV file with one row per journal line. Rows of the same entry share its date, type and
+reference. Amounts are written as plain decimal numbers in each currency's major unit (see 'money.Money.Decimal').
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <[]byte>
+
+		The contents of the CSV file.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (journal *AccountingJournal) CSV() ([]byte, error) {
+	var file bytes.Buffer
+	writer := csv.NewWriter(&file)
+
+	amount := func(cents int, currency string) string {
+		if cents == 0 {
+			return ""
+		}
+
+		return money.Money{Amount: cents, Currency: currency}.Decimal()
+	}
+
+	rows := [][]string{{"Date", "Type", "Document ID", "Reference", "Invoice", "Customer", "Account", "Debit", "Credit", "Currency", "Memo"}}
+	for _, entry := range journal.Entries {
+		for _, line := range entry.Lines {
+			rows = append(rows, []string{
+				entry.Date.Format("2006-01-02"),
+				entry.Type,
+				fmt.Sprint(entry.DocumentID),
+				entry.Reference,
+				entry.InvoiceNumber,
+				entry.Customer,
+				line.Account,
+				amount(line.Debit, entry.Currency),
+				amount(line.Credit, entry.Currency),
+				entry.Currency,
+				entry.Memo,
+			})
+		}
+	}
+
+	err := writer.WriteAll(rows)
+	return file.Bytes(), err
+}
+
+/*
+*Description*
+
+func IIF
+
+Writes the calling AccountingJournal as a QuickBooks Desktop import file (IIF). Each entry is one transaction: its first line is the
+transaction's TRNS row and the others are its SPL rows. Debits are positive amounts and credits are negative, so every transaction
+totals zero. Invoices and credit notes are imported as INVOICE and CREDIT MEMO transactions, payments as PAYMENT transactions and
+refunds as GENERAL JOURNAL transactions.
+
+IIF files have no currency, so they should only be imported into a company file in the currency of the entries.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <[]byte>
+
+		The contents of the IIF file.
+*/
+func (journal *AccountingJournal) IIF() []byte {
+	var file bytes.Buffer
+
+	transactionTypes := map[string]string{
+		JournalEntryInvoice:    "INVOICE",
+		JournalEntryCreditNote: "CREDIT MEMO",
+		JournalEntryPayment:    "PAYMENT",
+		JournalEntryRefund:     "GENERAL JOURNAL",
+	}
+
+	// Fields are tab separated, so tabs, line breaks and quotes in names and memos are replaced
+	clean := strings.NewReplacer("\t", " ", "\r", " ", "\n", " ", `"`, "'").Replace
+
+	file.WriteString("!TRNS\tTRNSTYPE\tDATE\tACCNT\tNAME\tAMOUNT\tDOCNUM\tMEMO\n")
+	file.WriteString("!SPL\tTRNSTYPE\tDATE\tACCNT\tNAME\tAMOUNT\tDOCNUM\tMEMO\n")
+	file.WriteString("!ENDTRNS\n")
+
+	for _, entry := range journal.Entries {
+		docNumber := entry.Reference
+		if entry.Type == JournalEntryPayment || entry.Type == JournalEntryRefund {
+			docNumber = entry.InvoiceNumber
+		}
+
+		for i, line := range entry.Lines {
+			row := "SPL"
+			if i == 0 {
+				row = "TRNS"
+			}
+
+			fmt.Fprintf(&file, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				row,
+				transactionTypes[entry.Type],
+				entry.Date.Format("01/02/2006"),
+				clean(line.Account),
+				clean(entry.Customer),
+				money.Money{Amount: line.Debit - line.Credit, Currency: entry.Currency}.Decimal(),
+				clean(docNumber),
+				clean(entry.Memo))
+		}
+
+		file.WriteString("ENDTRNS\n")
+	}
+
+	return file.Bytes()
+}
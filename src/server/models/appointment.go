@@ -1,10 +1,13 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"server/config"
 	"time"
 
+	"golang.org/x/exp/slices"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -12,10 +15,124 @@ import (
 // GORM model for all Appointment records in the database
 type Appointment struct {
 	gorm.Model
-	UserID         uint       `gorm:"column:user_id" json:"user_id"`                                // ID of user that booked the appointment
+	UserID         uint       `gorm:"column:user_id;index" json:"user_id"`                          // ID of user that booked the appointment
 	ServiceID      uint       `gorm:"column:service_id" json:"service_id"`                          // ID of service that appointment is for
-	Active         bool       `gorm:"column:active;default:true" json:"active"`                     // 1 for Active, 0 for Cancelled
+	Status         string     `gorm:"column:status;not null;default:Confirmed" json:"status"`       // Lifecycle status of the appointment (Pending, Confirmed, Cancelled By Customer, Cancelled By Business, Completed, No Show)
 	CancelDateTime *time.Time `gorm:"column:cancel_date_time;default:null" json:"cancel_date_time"` // Date/time when appointment was cancelled (if cancelled, else null)
+	Seats          uint       `gorm:"column:seats;not null;default:1" json:"seats"`                 // Number of seats reserved by the appointment (the booking user plus any guests)
+	RefundPending  bool       `gorm:"column:refund_pending;default:false" json:"-"`                 // True from when the appointment is cancelled until what was paid for it has been refunded (see 'RefundCancellation')
+	PromoCode      string     `gorm:"-" json:"promo_code,omitempty"`                                // Promo code entered when booking (not stored, see 'PromoCodeRedemption')
+}
+
+// Fields that lists of Appointment records can be sorted and filtered by (see 'Appointment.List')
+var appointmentListFields map[string]listField = map[string]listField{
+	"id":               {column: "id", jsonKey: "ID", fieldType: listFieldInt, sortable: true},
+	"created_at":       {column: "created_at", jsonKey: "CreatedAt", fieldType: listFieldTime, sortable: true},
+	"updated_at":       {column: "updated_at", jsonKey: "UpdatedAt", fieldType: listFieldTime, sortable: true},
+	"user_id":          {column: "user_id", jsonKey: "user_id", fieldType: listFieldInt, sortable: true},
+	"service_id":       {column: "service_id", jsonKey: "service_id", fieldType: listFieldInt, sortable: true},
+	"status":           {column: "status", jsonKey: "status", fieldType: listFieldString},
+	"seats":            {column: "seats", jsonKey: "seats", fieldType: listFieldInt, sortable: true},
+	"cancel_date_time": {column: "cancel_date_time", jsonKey: "cancel_date_time", fieldType: listFieldTime},
+}
+
+// Appointment lifecycle statuses
+const (
+	AppointmentStatusPending             string = "Pending"               // Booked, awaiting confirmation from the business
+	AppointmentStatusConfirmed           string = "Confirmed"             // Booked and confirmed
+	AppointmentStatusCancelledByCustomer string = "Cancelled By Customer" // Cancelled by the user that booked the appointment
+	AppointmentStatusCancelledByBusiness string = "Cancelled By Business" // Cancelled by the business offering the service
+	AppointmentStatusCompleted           string = "Completed"             // Service was attended
+	AppointmentStatusNoShow              string = "No Show"               // User did not attend the service
+)
+
+// Permitted status transitions for Appointment records (current status --> list of statuses it may move to)
+var appointmentStatusTransitions map[string][]string = map[string][]string{
+	AppointmentStatusPending: {
+		AppointmentStatusConfirmed,
+		AppointmentStatusCancelledByCustomer,
+		AppointmentStatusCancelledByBusiness,
+	},
+	AppointmentStatusConfirmed: {
+		AppointmentStatusCompleted,
+		AppointmentStatusNoShow,
+		AppointmentStatusCancelledByCustomer,
+		AppointmentStatusCancelledByBusiness,
+	},
+	AppointmentStatusCancelledByCustomer: {},
+	AppointmentStatusCancelledByBusiness: {},
+	AppointmentStatusCompleted:           {},
+	AppointmentStatusNoShow:              {},
+}
+
+// Appointment statuses for upcoming appointments
+var activeAppointmentStatuses []string = []string{
+	AppointmentStatusPending,
+	AppointmentStatusConfirmed,
+}
+
+// Appointment statuses that occupy seats for the associated Service (counted towards the Service's capacity)
+var seatHoldingAppointmentStatuses []string = []string{
+	AppointmentStatusPending,
+	AppointmentStatusConfirmed,
+	AppointmentStatusCompleted,
+	AppointmentStatusNoShow,
+}
+
+// Errors returned when an Appointment status change is rejected
+var (
+	ErrInvalidAppointmentStatus           = errors.New("invalid appointment status")
+	ErrInvalidAppointmentStatusTransition = errors.New("invalid appointment status transition")
+)
+
+// Error returned when an Appointment's seat count or guest list is invalid
+var ErrInvalidSeatCount = errors.New("invalid seat count")
+
+// Error returned when an Appointment update includes a field that is only set by the server
+var ErrRestrictedAppointmentField = errors.New("appointment field cannot be updated directly")
+
+/*
+*Description*
+
+func BeforeCreate (GORM hook)
+
+Standardizes the 'Status' attribute of the calling Appointment record and confirms that it is a valid status before the record is created.
+
+If no status is specified, the Appointment record is created with a 'Confirmed' status. If no seat count is specified,
+the Appointment record reserves a single seat.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the operations will be performed.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) BeforeCreate(db *gorm.DB) error {
+	if appt.Status == "" {
+		appt.Status = AppointmentStatusConfirmed
+	}
+
+	appt.Status = StandardizeAppointmentStatus(appt.Status)
+	if !AppointmentStatusIsValid(appt.Status) {
+		return fmt.Errorf("%w: '%s' is not a valid status for a new Appointment record", ErrInvalidAppointmentStatus, appt.Status)
+	}
+
+	if appt.IsCancelled() && appt.CancelDateTime == nil {
+		cancelDateTime := time.Now()
+		appt.CancelDateTime = &cancelDateTime
+	}
+
+	if appt.Seats == 0 {
+		appt.Seats = 1
+	}
+
+	return nil
 }
 
 /*
@@ -23,8 +140,11 @@ type Appointment struct {
 
 func AfterCreate (GORM hook)
 
-Appropriately updates the 'AppointmentCt' and 'IsFull' attributes for the Service record that is associated
-with the calling Appointment record.
+Records the initial status of the calling Appointment record in the appointment status history and appropriately
+updates the 'AppointmentCt' and 'IsFull' attributes for the Service record that is associated with the calling Appointment record.
+
+Only appointments with a seat-holding status are counted towards the Service record's 'AppointmentCt', and each
+appointment counts once per seat it reserves.
 
 *Parameters*
 
@@ -43,6 +163,17 @@ func (appt *Appointment) AfterCreate(db *gorm.DB) error {
 		log.Println("AfterCreate hook executed [Appointment model].")
 	}
 
+	// Record the initial status of the appointment in its status history
+	history := AppointmentStatusHistory{
+		AppointmentID: appt.ID,
+		ToStatus:      appt.Status,
+	}
+
+	_, err := history.Create(db)
+	if err != nil {
+		return err
+	}
+
 	var serviceIDJsonKey string = "service_id"
 	appts, err := appt.GetRecordsBySecondaryID(db, serviceIDJsonKey, appt.ServiceID)
 	if err != nil {
@@ -61,13 +192,13 @@ func (appt *Appointment) AfterCreate(db *gorm.DB) error {
 
 	var active_appt_ct int = 0
 	for _, appt := range appts {
-		if appt.Active {
-			active_appt_ct++
+		if appt.HoldsSeat() {
+			active_appt_ct += int(appt.Seats)
 		}
 	}
 	updates := map[string]interface{}{
 		"appt_ct": active_appt_ct,
-		"is_full": active_appt_ct == int(service.Capacity),
+		"is_full": active_appt_ct >= int(service.Capacity),
 	}
 
 	updatedService, err := service.Update(db, appt.ServiceID, updates)
@@ -90,6 +221,9 @@ func AfterUpdate (GORM hook)
 Appropriately updates the 'AppointmentCt' and 'IsFull' attributes for the Service record that is associated
 with the calling Appointment record.
 
+Only appointments with a seat-holding status are counted towards the Service record's 'AppointmentCt', and each
+appointment counts once per seat it reserves.
+
 *Parameters*
 
 	db  <*gorm.DB>
@@ -104,7 +238,7 @@ with the calling Appointment record.
 */
 func (appt *Appointment) AfterUpdate(db *gorm.DB) error {
 	if config.Debug {
-		log.Println("AfterCreate hook executed [Appointment model].")
+		log.Println("AfterUpdate hook executed [Appointment model].")
 	}
 
 	var serviceIDJsonKey string = "service_id"
@@ -126,13 +260,13 @@ func (appt *Appointment) AfterUpdate(db *gorm.DB) error {
 
 	var active_appt_ct int = 0
 	for _, appt := range appts {
-		if appt.Active {
-			active_appt_ct++
+		if appt.HoldsSeat() {
+			active_appt_ct += int(appt.Seats)
 		}
 	}
 	updates := map[string]interface{}{
 		"appt_ct": active_appt_ct,
-		"is_full": active_appt_ct == int(service.Capacity),
+		"is_full": active_appt_ct >= int(service.Capacity),
 	}
 
 	updatedService, err := service.Update(db, appt.ServiceID, updates)
@@ -246,236 +380,1537 @@ func (appt *Appointment) GetServiceID() uint {
 /*
 *Description*
 
-func Create
+func IsActive
 
-Creates a new Appointment record in the database and returns the created record along with any errors that are thrown.
+Returns whether the calling Appointment object is still upcoming (i.e. has a 'Pending' or 'Confirmed' status).
 
 *Parameters*
 
-	db  <*gorm.DB>
-
-		A pointer to the database instance where the record will be created.
+	N/A (None)
 
 *Returns*
 
-	_  <*Appointment>
-
-		The created Appointment record.
-
-	_  <error>
+	_  <bool>
 
-		Encountered error (nil if no errors are encountered).
+		'true' if the appointment is active, else 'false'.
 */
-func (appt *Appointment) Create(db *gorm.DB) (map[string]Model, error) {
-	err := db.Create(&appt).Error
-	returnRecords := map[string]Model{"appointment": appt}
-	return returnRecords, err
+func (appt *Appointment) IsActive() bool {
+	return slices.Contains(activeAppointmentStatuses, appt.Status)
 }
 
 /*
 *Description*
 
-func Get
+func HoldsSeat
 
-Retrieves a Appointment record in the database by ID if it exists and returns that record along with any errors that are thrown.
+Returns whether the calling Appointment object occupies a seat for its Service (i.e. the appointment has not been cancelled).
 
 *Parameters*
 
-	db  <*gorm.DB>
+	N/A (None)
 
-		A pointer to the database instance that will be used to retrieve the specified record.
+*Returns*
 
-	apptID  <uint>
+	_  <bool>
 
-		The ID of the appointment record being requested.
+		'true' if the appointment counts towards the Service record's capacity, else 'false'.
+*/
+func (appt *Appointment) HoldsSeat() bool {
+	return slices.Contains(seatHoldingAppointmentStatuses, appt.Status)
+}
 
-*Returns*
+/*
+*Description*
 
-	_  <*Appointment>
+func IsCancelled
 
-		The Appointment record that is retrieved from the database.
+Returns whether the calling Appointment object has been cancelled (by either the customer or the business).
 
-	_  <error>
+*Parameters*
 
-		Encountered error (nil if no errors are encountered)
+	N/A (None)
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the appointment has been cancelled, else 'false'.
 */
-func (appt *Appointment) Get(db *gorm.DB, apptID uint) (map[string]Model, error) {
-	err := db.First(&appt, apptID).Error
-	returnRecords := map[string]Model{"appointment": appt}
-	return returnRecords, err
+func (appt *Appointment) IsCancelled() bool {
+	return AppointmentStatusIsCancelled(appt.Status)
 }
 
 /*
 *Description*
 
-func GetAll
+func Create
 
-Retrieves all Appointment records from the database.
+Creates a new Appointment record in the database and returns the created record along with any errors that are thrown.
 
 *Parameters*
 
 	db  <*gorm.DB>
 
-		A pointer to the database instance that the records will be retrieved from.
+		A pointer to the database instance where the record will be created.
 
 *Returns*
 
-	_  <[]Appointment>
+	_  <*Appointment>
 
-		The list of Appointment records that are retrieved from the database.
+		The created Appointment record.
 
 	_  <error>
 
-		Encountered error (nil if no errors are encountered)
+		Encountered error (nil if no errors are encountered).
 */
-func (appt *Appointment) GetAll(db *gorm.DB) ([]Appointment, error) {
-	var appts []Appointment
-	err := db.Find(&appts).Error
-
-	return appts, err
+func (appt *Appointment) Create(db *gorm.DB) (map[string]Model, error) {
+	err := db.Create(&appt).Error
+	returnRecords := map[string]Model{"appointment": appt}
+	return returnRecords, err
 }
 
 /*
 *Description*
 
-func GetRecordsBySecondaryID
+func Book
 
-Retrieves a list of Appointment records from the database that are associated with the specified secondary key.
+Books the calling Appointment for its User and Service, enforcing the BookingRule that applies to the Service and the Service's capacity.
+
+The booking is rejected with a *BookingRejectedError (which carries a machine-readable reason code) if the Service has already
+started, if the booking window is not open yet or has closed, if the User has reached their weekly booking limit with the Business,
+if the Service does not have enough open seats left for the appointment's seat count, if the User already has an appointment for
+the Service, or if the Service's time slot overlaps one of the User's active appointments (unless the Business allows overlapping bookings).
+
+If the User has a membership that is entitled to the Service, the booking user's own seat is covered by the membership (see
+'Subscription.UseEntitlement'). If the User has eligible class pack credits, the remaining seats are paid for with one credit per seat
+(see 'ClassPackPurchase.UseCredits'). If the Business invoices at booking, the seats that are not covered are invoiced at the Service's
+price in the same transaction (see 'Business.BillingPolicy').
+
+If the appointment has a promo code, the code is redeemed for the appointment (see 'PromoCode.Redeem'), and its discount is taken off
+the appointment's invoice. The booking is rejected with a *BookingRejectedError if the code can't be used for the booking.
+
+The appointment is booked as 'Pending' if that status is specified, and as 'Confirmed' otherwise (any other status and cancellation
+time are ignored). An appointment reserves one seat for the booking user plus one seat per guest. If the seat count is not specified, it is set from the
+number of guests. Every seat beyond the booking user's own seat gets an AppointmentGuest record, using the specified guest details
+where given. The Service record is locked while the booking is made so that concurrent bookings cannot overfill the Service.
+
+Bookings made through the API should use this method rather than 'Create', which does not enforce booking rules or capacity.
 
 *Parameters*
 
 	db  <*gorm.DB>
 
-		A pointer to the database instance that the records will be retrieved from.
+		A pointer to the database instance where the record will be created.
 
-	secondaryIDJsonKey  <string>
+	bookingTime  <time.Time>
 
-		The JSON key for the secondary ID attribute.
+		The time the booking is being made (normally the current time).
 
-	secondaryID  <uint>
+	guests  <[]AppointmentGuest>
 
-		The secondary ID value.
+		Optional names and contact details for the guests attending with the booking user (at most one per additional seat).
 
 *Returns*
 
-	_  <[]Appointment>
+	_  <map[string]Model>
 
-		The list of Appointment records that are retrieved from the database.
+		A JSON style map object with a key-value pair that contains the created Appointment object.
 
 	_  <error>
 
-		Encountered error (nil if no errors are encountered)
+		Encountered error (nil if no errors are encountered).
 */
-func (appt *Appointment) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]Appointment, error) {
-	var appts []Appointment
+func (appt *Appointment) Book(db *gorm.DB, bookingTime time.Time, guests []AppointmentGuest) (map[string]Model, error) {
+	returnRecords := map[string]Model{"appointment": appt}
 
-	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Find(&appts).Error
-	return appts, err
-}
+	// New bookings start out Pending or Confirmed, so later statuses are only reached through permitted status changes
+	if StandardizeAppointmentStatus(appt.Status) != AppointmentStatusPending {
+		appt.Status = AppointmentStatusConfirmed
+	}
+	appt.CancelDateTime = nil
 
-/*
-*Description*
+	if appt.Seats == 0 {
+		appt.Seats = uint(len(guests)) + 1
+	}
 
-func Update
+	if len(guests) > int(appt.Seats)-1 {
+		return returnRecords, fmt.Errorf("%w: %d guest(s) specified for an appointment with %d seat(s)", ErrInvalidSeatCount, len(guests), appt.Seats)
+	}
 
-Updates the specified Appointment record in the database with the specified changes if the record exists.
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Lock the Service record until the transaction completes so that seats are counted and reserved atomically
+		service := &Service{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(service, appt.ServiceID).Error
+		if err != nil {
+			return fmt.Errorf("Service ID (%d) does not exist in the database.  [%w]", appt.ServiceID, err)
+		}
 
-Returns the updated record along with any errors that are thrown.
+		// Serialize bookings made by the same User so that concurrent bookings cannot overlap each other or exceed the User's
+		// booking limits (the lock is taken before the booking rules count the User's appointments)
+		err = advisoryLock(tx, bookingLockNamespace, appt.UserID)
+		if err != nil {
+			return err
+		}
 
-This function behaves like a PATCH method, rather than a true PUT. Any fields that aren't specified in the request body for the PUT request will not be altered for the specified record.
+		rule := BookingRule{}
+		effectiveRule, err := rule.GetEffectiveRule(tx, service)
+		if err != nil {
+			return err
+		}
 
-If a specified field's value should be deleted from the record, the appropriate null/blank should be specified for that key in the JSON request body (e.g. "type": "").
+		err = effectiveRule.CheckBooking(tx, service, appt.UserID, bookingTime)
+		if err != nil {
+			return err
+		}
 
-*Parameters*
+		err = appt.checkConflicts(tx, service)
+		if err != nil {
+			return err
+		}
 
-	db  <*gorm.DB>
+		seatsHeld, err := appt.GetSeatsHeld(tx, service.ID)
+		if err != nil {
+			return err
+		}
 
-		A pointer to the database instance that will be used to retrieve and update the specified record.
+		var openSeats int = int(service.Capacity) - seatsHeld
+		if int(appt.Seats) > openSeats {
+			if openSeats < 0 {
+				openSeats = 0
+			}
 
-	apptID  <uint>
+			return &BookingRejectedError{
+				Code:    BookingRejectedInsufficientCapacity,
+				Message: fmt.Sprintf("Service ID (%d) has %d open seat(s) left, but %d were requested.", service.ID, openSeats, appt.Seats),
+			}
+		}
 
-		The ID of the appointment record being updated.
+		returnRecords, err = appt.Create(tx)
+		if isUniqueViolation(err) {
+			return &BookingRejectedError{
+				Code:    BookingRejectedDuplicateBooking,
+				Message: fmt.Sprintf("User ID (%d) already has an appointment for Service ID (%d).", appt.UserID, service.ID),
+			}
+		} else if err != nil {
+			return err
+		}
 
-	updates  <map[string]interface{}>
+		// Every seat beyond the booking user's own seat is held by a guest (unnamed guests are allowed)
+		for i := 0; i < int(appt.Seats)-1; i++ {
+			guest := AppointmentGuest{}
+			if i < len(guests) {
+				guest = guests[i]
+			}
 
-		JSON with the fields that will be updated as keys and the updated values as values.
+			guest.ID = 0
+			guest.AppointmentID = appt.ID
+			guest.CancelDateTime = nil
 
-		Ex:
-			{
-				"name": "New name",
-				"address": "New address"
+			_, err = guest.Create(tx)
+			if err != nil {
+				return err
 			}
+		}
 
-*Returns*
+		var paidSeats uint = appt.Seats
+		subscription := Subscription{}
+		entitledSubscription, err := subscription.UseEntitlement(tx, appt, service)
+		if err != nil {
+			return err
+		} else if entitledSubscription != nil {
+			paidSeats--
+		}
 
-	_  <*Appointment>
+		purchase := ClassPackPurchase{}
+		_, err = purchase.UseCredits(tx, appt, service, paidSeats)
+		if err != nil {
+			return err
+		}
 
-		The Appointment record that is updated in the database.
+		if appt.PromoCode != "" {
+			promo := PromoCode{}
+			_, err = promo.Redeem(tx, appt.PromoCode, appt, service, bookingTime)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, billingPolicy, err := appt.getBillingPolicy(tx)
+		if err != nil || billingPolicy != BillingPolicyAtBooking {
+			return err
+		}
+
+		invoice, err := appt.bill(tx, service)
+		if invoice != nil {
+			returnRecords["invoice"] = invoice
+		}
+
+		return err
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func checkConflicts
+
+Confirms that booking the specified Service does not conflict with the calling Appointment's User's other appointments.
+
+Returns a *BookingRejectedError if the User already has an appointment for the Service, or if the Service's time slot overlaps one
+of the User's active appointments. Overlapping appointments are permitted if the Service's Business allows overlapping bookings.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	service  <*Service>
+
+		The Service being booked.
+
+*Returns*
 
 	_  <error>
 
-		Encountered error (nil if no errors are encountered)
+		Encountered error (nil if the booking does not conflict with any of the User's appointments).
 */
-func (appt *Appointment) Update(db *gorm.DB, apptID uint, updates map[string]interface{}) (map[string]Model, error) {
-	// Confirm apptID exists in the database and get current object
-	returnRecords, err := appt.Get(db, apptID)
-	updateAppointment := returnRecords["appointment"]
+func (appt *Appointment) checkConflicts(db *gorm.DB, service *Service) error {
+	user := User{}
+	hasServiceAppointment, err := user.HasServiceAppointment(db, appt.UserID, service.ID)
+	if err != nil {
+		return err
+	}
+
+	if hasServiceAppointment {
+		return &BookingRejectedError{
+			Code:    BookingRejectedDuplicateBooking,
+			Message: fmt.Sprintf("User ID (%d) already has an appointment for Service ID (%d).", appt.UserID, service.ID),
+		}
+	}
 
+	business := Business{}
+	err = db.Where("id = ?", service.BusinessID).Limit(1).Find(&business).Error
 	if err != nil {
-		return returnRecords, err
+		return err
 	}
 
-	err = db.Model(&updateAppointment).Clauses(clause.Returning{}).Where("id = ?", apptID).Updates(updates).Error
-	returnRecords = map[string]Model{"appointment": updateAppointment}
+	if business.AllowOverlappingBookings {
+		return nil
+	}
 
-	return returnRecords, err
+	overlappingAppts, err := appt.GetOverlappingAppointments(db, appt.UserID, service)
+	if err != nil {
+		return err
+	}
+
+	if len(overlappingAppts) > 0 {
+		return &BookingRejectedError{
+			Code: BookingRejectedOverlappingBooking,
+			Message: fmt.Sprintf("Service ID (%d) overlaps User ID (%d)'s Appointment ID (%d).",
+				service.ID, appt.UserID, overlappingAppts[0].ID),
+		}
+	}
+
+	return nil
 }
 
 /*
 *Description*
 
-func Cancel
-
-Cancels the specified Appointment record.
+func GetOverlappingAppointments
 
-The 'Active' attribute is set to 'false' and the 'CancelDateTime' attribute is set to the current time
-for the specified Appointment record.
+Retrieves the specified User's active Appointment records whose Service time slot overlaps the time slot of the specified Service.
 
-The Appointment record that is cancelled is returned with the updated attribute values.
+Time slots run from a Service's 'StartDateTime' for 'Length' minutes. Slots that only touch (one ends exactly when the other starts)
+do not overlap. Appointments for the specified Service itself are not included.
 
 *Parameters*
 
 	db  <*gorm.DB>
 
-		A pointer to the database instance where the record will be created.
+		A pointer to the database instance that will be queried.
 
-	apptID  <uint>
+	userID  <uint>
 
-		The ID of the appointment record being cancelled.
+		The ID of the User whose appointments are checked.
 
-*Returns*
+	service  <*Service>
 
-	_  <map[string]Model>
+		The Service whose time slot is checked.
 
-		A JSON style map object with a key-value pair that contains the cancelled Appointment object.
+*Returns*
 
-		Ex:
-			{
-				"appointment": <Appointment object - appointment that was cancelled>
-			}
+	_  <[]Appointment>
+
+		The list of overlapping Appointment records (ordered by the start of their Service).
 
 	_  <error>
 
 		Encountered error (nil if no errors are encountered).
 */
-func (appt *Appointment) Cancel(db *gorm.DB, apptID uint) (map[string]Model, error) {
-	var updates map[string]interface{} = map[string]interface{}{
-		"active":           false,
-		"cancel_date_time": time.Now(),
+func (appt *Appointment) GetOverlappingAppointments(db *gorm.DB, userID uint, service *Service) ([]Appointment, error) {
+	var overlappingAppts []Appointment
+
+	serviceEnd := service.StartDateTime.Add(time.Duration(service.Length) * time.Minute)
+	err := db.Model(&Appointment{}).
+		Joins("JOIN services ON services.id = appointments.service_id AND services.deleted_at IS NULL").
+		Where("appointments.user_id = ?", userID).
+		Where("appointments.status IN ?", activeAppointmentStatuses).
+		Where("appointments.service_id <> ?", service.ID).
+		Where("services.start_date_time < ?", serviceEnd).
+		Where("services.start_date_time + services.length * INTERVAL '1 minute' > ?", service.StartDateTime).
+		Order("services.start_date_time").
+		Find(&overlappingAppts).Error
+
+	return overlappingAppts, err
+}
+
+/*
+*Description*
+
+func GetSeatsHeld
+
+Returns the total number of seats held for the specified Service by Appointment records with a seat-holding status.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	serviceID  <uint>
+
+		The ID of the Service whose held seats are counted.
+
+*Returns*
+
+	_  <int>
+
+		The number of seats held for the Service.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) GetSeatsHeld(db *gorm.DB, serviceID uint) (int, error) {
+	var seatsHeld int
+	err := db.Model(&Appointment{}).
+		Select("COALESCE(SUM(seats), 0)").
+		Where("service_id = ? AND status IN ?", serviceID, seatHoldingAppointmentStatuses).
+		Scan(&seatsHeld).Error
+
+	return seatsHeld, err
+}
+
+/*
+*Description*
+
+func GetPrice
+
+Returns the price (in cents) of the calling Appointment, which is the price of its Service for each seat the appointment reserves.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the Service record will be retrieved from.
+
+*Returns*
+
+	_  <int>
+
+		The price of the appointment (in cents).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) GetPrice(db *gorm.DB) (int, error) {
+	service := &Service{}
+	err := db.First(service, appt.ServiceID).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return int(service.Price) * int(appt.Seats), nil
+}
+
+/*
+*Description*
+
+func GetBillableSeats
+
+Returns the number of seats on the calling Appointment that are not covered by a membership or by class pack credits, which are the seats that the User is invoiced for.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+*Returns*
+
+	_  <uint>
+
+		The number of billable seats.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) GetBillableSeats(db *gorm.DB) (uint, error) {
+	var coveredSeats int64
+	err := db.Model(&SubscriptionVisit{}).Where("appointment_id = ?", appt.ID).Count(&coveredSeats).Error
+	if err != nil {
+		return 0, err
+	}
+
+	// Net credits used by the appointment (usage minus refunds)
+	var creditSeats int64
+	err = db.Model(&ClassPackCreditTransaction{}).Select("COALESCE(-SUM(credits), 0)").Where("appointment_id = ?", appt.ID).Scan(&creditSeats).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var billableSeats int64 = int64(appt.Seats) - coveredSeats - creditSeats
+	if billableSeats < 0 {
+		billableSeats = 0
+	}
+
+	return uint(billableSeats), nil
+}
+
+/*
+*Description*
+
+func CancellationIsTimely
+
+Returns whether cancelling the calling Appointment at the specified time is timely, in which case the User is not charged for it.
+
+Cancellations by the Business are always timely. Otherwise, the cancellation must be made before the cutoff of the BookingRule that
+applies to the Service (see 'BookingRule.CancellationIsTimely').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	cancelTime  <time.Time>
+
+		The time the appointment (or one of its seats) was cancelled.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the cancellation is timely, else 'false'.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) CancellationIsTimely(db *gorm.DB, cancelTime time.Time) (bool, error) {
+	if appt.Status == AppointmentStatusCancelledByBusiness {
+		return true, nil
+	}
+
+	service := &Service{}
+	err := db.First(service, appt.ServiceID).Error
+	if err != nil {
+		return false, err
+	}
+
+	rule := BookingRule{}
+	effectiveRule, err := rule.GetEffectiveRule(db, service)
+	if err != nil {
+		return false, err
+	}
+
+	return effectiveRule.CancellationIsTimely(service, cancelTime), nil
+}
+
+/*
+*Description*
+
+func cancellationWasTimely
+
+Returns whether the calling Appointment's recorded cancellation was timely (see 'CancellationIsTimely').
+
+Appointments without a recorded cancellation time (e.g. rows migrated from the old 'active' flag) are treated as timely
+only if they were cancelled by the Business, since the customer's cancellation time is unknown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the service and booking rules.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the cancellation was timely, else 'false'.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) cancellationWasTimely(db *gorm.DB) (bool, error) {
+	if appt.CancelDateTime == nil {
+		return appt.Status == AppointmentStatusCancelledByBusiness, nil
+	}
+
+	return appt.CancellationIsTimely(db, *appt.CancelDateTime)
+}
+
+/*
+*Description*
+
+func GetInvoice
+
+Returns the calling Appointment's current invoice (the most recent Invoice for the appointment that has not been voided or refunded),
+or nil if the appointment has not been invoiced. The Invoice record is locked until the transaction completes.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) that will be queried.
+
+*Returns*
+
+	_  <*Invoice>
+
+		The appointment's current invoice (nil if there is none).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) GetInvoice(db *gorm.DB) (*Invoice, error) {
+	var invoices []Invoice
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("appointment_id = ? AND status NOT IN ?", appt.ID, []string{InvoiceStatusVoid, InvoiceStatusRefunded}).
+		Order("id DESC").
+		Limit(1).
+		Find(&invoices).Error
+	if err != nil || len(invoices) == 0 {
+		return nil, err
+	}
+
+	return &invoices[0], nil
+}
+
+/*
+*Description*
+
+func getBillingPolicy
+
+Returns the Service of the calling Appointment along with the billing policy of the Business offering it.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+*Returns*
+
+	_  <*Service>
+
+		The appointment's Service.
+
+	_  <string>
+
+		The billing policy of the Business (see 'Business.GetBillingPolicy').
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) getBillingPolicy(db *gorm.DB) (*Service, string, error) {
+	service := &Service{}
+	err := db.First(service, appt.ServiceID).Error
+	if err != nil {
+		return service, "", err
+	}
+
+	business := &Business{}
+	err = db.Limit(1).Find(business, service.BusinessID).Error
+	return service, business.GetBillingPolicy(), err
+}
+
+/*
+*Description*
+
+func getLineItems
+
+Returns the invoice line items that charge for the specified number of the calling Appointment's seats at the price of its Service
+(no line items if no seats are charged for).
+
+*Parameters*
+
+	service  <*Service>
+
+		The appointment's Service.
+
+	seats  <uint>
+
+		The number of seats to charge for.
+
+*Returns*
+
+	_  <[]InvoiceLineItem>
+
+		The invoice line items.
+*/
+func (appt *Appointment) getLineItems(service *Service, seats uint) []InvoiceLineItem {
+	if seats == 0 || service.Price == 0 {
+		return []InvoiceLineItem{}
+	}
+
+	return []InvoiceLineItem{{Description: service.Name, Quantity: seats, UnitPrice: int(service.Price), Currency: service.Currency}}
+}
+
+/*
+*Description*
+
+func applyPromoCode
+
+Takes the discount of the promo code that the calling Appointment was booked with (if any) off the specified line items, and notes the
+code in their descriptions. Percentage discounts are taken off each line, and fixed discounts are taken off once per appointment.
+
+The discount is added to the returned redemption's 'Discount' attribute, but the redemption record is not updated.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) that will be queried.
+
+	lineItems  <[]InvoiceLineItem>
+
+		The line items that charge for the appointment's seats (see 'getLineItems').
+
+*Returns*
+
+	_  <*PromoCodeRedemption>
+
+		The appointment's redemption with the discount taken off the line items (nil if the appointment was not booked with a promo code).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) applyPromoCode(db *gorm.DB, lineItems []InvoiceLineItem) (*PromoCodeRedemption, error) {
+	var redemptions []PromoCodeRedemption
+	err := db.Where("appointment_id = ?", appt.ID).Limit(1).Find(&redemptions).Error
+	if err != nil || len(redemptions) == 0 {
+		return nil, err
+	}
+	redemption := &redemptions[0]
+	redemption.Discount = 0
+
+	// Discounts already granted are honoured even if the promo code has since been deleted
+	promo := &PromoCode{}
+	err = db.Unscoped().First(promo, redemption.PromoCodeID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var amountOffLeft int = int(promo.AmountOff)
+	for i := range lineItems {
+		var linePrice int = int(lineItems[i].Quantity)*lineItems[i].UnitPrice - lineItems[i].Discount
+		discount := promo.GetDiscount(linePrice)
+		if promo.AmountOff > 0 {
+			if discount > amountOffLeft {
+				discount = amountOffLeft
+			}
+			amountOffLeft -= discount
+		}
+
+		if discount <= 0 {
+			continue
+		}
+
+		lineItems[i].Discount += discount
+		lineItems[i].Description = fmt.Sprintf("%s (promo code %s)", lineItems[i].Description, promo.Code)
+		redemption.Discount += discount
+	}
+
+	return redemption, nil
+}
+
+/*
+*Description*
+
+func getCancelFeeLineItems
+
+Returns the invoice line items that charge the specified cancellation fee for the calling Appointment (no line items if the fee is 0).
+
+*Parameters*
+
+	service  <*Service>
+
+		The appointment's Service.
+
+	cancelFee  <int>
+
+		The cancellation fee (in cents).
+
+*Returns*
+
+	_  <[]InvoiceLineItem>
+
+		The invoice line items.
+*/
+func (appt *Appointment) getCancelFeeLineItems(service *Service, cancelFee int) []InvoiceLineItem {
+	if cancelFee <= 0 {
+		return []InvoiceLineItem{}
+	}
+
+	return []InvoiceLineItem{{Description: fmt.Sprintf("Late cancellation fee: %s", service.Name), Quantity: 1, UnitPrice: cancelFee, Currency: service.Currency}}
+}
+
+/*
+*Description*
+
+func applyTax
+
+Charges the sales tax of the Business that offers the calling Appointment's Service on the specified line items (see 'applySalesTax').
+Lines for tax exempt Services are recorded in the Business's jurisdiction, but aren't charged tax.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) that will be queried.
+
+	service  <*Service>
+
+		The appointment's Service.
+
+	lineItems  <[]InvoiceLineItem>
+
+		The line items that charge for the appointment (see 'getLineItems' and 'getCancelFeeLineItems').
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) applyTax(db *gorm.DB, service *Service, lineItems []InvoiceLineItem) error {
+	return applySalesTax(db, service.BusinessID, service.TaxExempt, lineItems)
+}
+
+/*
+*Description*
+
+func invoice
+
+Creates and issues an Invoice that bills the calling Appointment's User for the specified line items, unless there are none.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the record will be created.
+
+	service  <*Service>
+
+		The appointment's Service (the invoice is issued by the service's Business).
+
+	lineItems  <[]InvoiceLineItem>
+
+		The charges to invoice.
+
+*Returns*
+
+	_  <*Invoice>
+
+		The created Invoice (nil if nothing was invoiced).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) invoice(db *gorm.DB, service *Service, lineItems []InvoiceLineItem) (*Invoice, error) {
+	if len(lineItems) == 0 {
+		return nil, nil
+	}
+
+	invoice := &Invoice{
+		AppointmentID: appt.ID,
+		UserID:        appt.UserID,
+		BusinessID:    service.BusinessID,
+		Currency:      service.Currency,
+	}
+
+	err := invoice.createIssued(db, lineItems)
+	return invoice, err
+}
+
+/*
+*Description*
+
+func bill
+
+Invoices the calling Appointment's billable seats at the price of its Service (see 'GetBillableSeats'), less the discount of the promo
+code it was booked with (see 'applyPromoCode'), plus sales tax (see 'applyTax'), unless the appointment already has an invoice.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the record will be created.
+
+	service  <*Service>
+
+		The appointment's Service.
+
+*Returns*
+
+	_  <*Invoice>
+
+		The created Invoice (nil if nothing was invoiced).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) bill(db *gorm.DB, service *Service) (*Invoice, error) {
+	invoice, err := appt.GetInvoice(db)
+	if err != nil || invoice != nil {
+		return nil, err
+	}
+
+	billableSeats, err := appt.GetBillableSeats(db)
+	if err != nil {
+		return nil, err
+	}
+
+	lineItems := appt.getLineItems(service, billableSeats)
+	redemption, err := appt.applyPromoCode(db, lineItems)
+	if err != nil {
+		return nil, err
+	}
+
+	err = appt.applyTax(db, service, lineItems)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err = appt.invoice(db, service, lineItems)
+	if err != nil || redemption == nil || invoice == nil {
+		return invoice, err
+	}
+
+	err = db.Model(redemption).Updates(map[string]interface{}{"invoice_id": invoice.ID, "discount": redemption.Discount}).Error
+	return invoice, err
+}
+
+/*
+*Description*
+
+func billStatusChange
+
+Updates the invoicing for the calling Appointment after it moves to a new status, according to the billing policy of the Business.
+
+	Completed or No Show (At Completion policy)  -->  The billable seats are invoiced
+	Timely cancellation  -->  The current invoice is credited in full, which voids it (or leaves a credit if it has already been paid)
+	Late cancellation  -->  The current invoice is credited down to the Service's cancellation fee (if it is lower). Under the At Completion policy the cancellation fee is invoiced.
+
+Issued invoices can't be changed, so the changes are made with credit notes (see 'Invoice.revise').
+
+Businesses with the None billing policy are not invoiced automatically.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) billStatusChange(db *gorm.DB) error {
+	service, billingPolicy, err := appt.getBillingPolicy(db)
+	if err != nil || billingPolicy == BillingPolicyNone {
+		return err
+	}
+
+	if !appt.IsCancelled() {
+		if billingPolicy == BillingPolicyAtCompletion && !appt.IsActive() {
+			_, err = appt.bill(db, service)
+		}
+
+		return err
+	}
+
+	invoice, err := appt.GetInvoice(db)
+	if err != nil {
+		return err
+	}
+
+	timely, err := appt.cancellationWasTimely(db)
+	if err != nil {
+		return err
+	}
+
+	var cancelFee int = int(service.CancelFee)
+	if timely {
+		cancelFee = 0
+	}
+
+	if invoice != nil {
+		if cancelFee < invoice.GetAmountBilled() {
+			var reason string = fmt.Sprintf("Cancellation: %s", service.Name)
+			if cancelFee > 0 {
+				reason = fmt.Sprintf("Late cancellation: %s (cancellation fee still owed)", service.Name)
+			}
+
+			lineItems := appt.getCancelFeeLineItems(service, cancelFee)
+			err = appt.applyTax(db, service, lineItems)
+			if err != nil {
+				return err
+			}
+
+			err = invoice.revise(db, lineItems, reason)
+		}
+	} else if billingPolicy == BillingPolicyAtCompletion && cancelFee > 0 {
+		var billableSeats uint
+		billableSeats, err = appt.GetBillableSeats(db)
+		if err != nil {
+			return err
+		}
+
+		var amountOwed int = int(billableSeats) * int(service.Price)
+		if amountOwed < cancelFee {
+			cancelFee = amountOwed
+		}
+
+		lineItems := appt.getCancelFeeLineItems(service, cancelFee)
+		err = appt.applyTax(db, service, lineItems)
+		if err != nil {
+			return err
+		}
+
+		_, err = appt.invoice(db, service, lineItems)
+	}
+
+	return err
+}
+
+/*
+*Description*
+
+func rebill
+
+Brings the calling Appointment's current invoice in line with its billable seats at the price of its Service (less the discount of
+the promo code it was booked with, plus sales tax), after its seat count changes (see 'Invoice.revise').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) rebill(db *gorm.DB) error {
+	service, billingPolicy, err := appt.getBillingPolicy(db)
+	if err != nil || billingPolicy == BillingPolicyNone {
+		return err
+	}
+
+	invoice, err := appt.GetInvoice(db)
+	if err != nil || invoice == nil {
+		return err
+	}
+
+	billableSeats, err := appt.GetBillableSeats(db)
+	if err != nil {
+		return err
+	}
+
+	lineItems := appt.getLineItems(service, billableSeats)
+	redemption, err := appt.applyPromoCode(db, lineItems)
+	if err != nil {
+		return err
+	}
+
+	err = appt.applyTax(db, service, lineItems)
+	if err != nil {
+		return err
+	}
+
+	err = invoice.revise(db, lineItems, fmt.Sprintf("Guest cancellation: %s", service.Name))
+	if err != nil || redemption == nil {
+		return err
+	}
+
+	return db.Model(redemption).Update("discount", redemption.Discount).Error
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a Appointment record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	apptID  <uint>
+
+		The ID of the appointment record being requested.
+
+*Returns*
+
+	_  <*Appointment>
+
+		The Appointment record that is retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) Get(db *gorm.DB, apptID uint) (map[string]Model, error) {
+	err := db.First(&appt, apptID).Error
+	returnRecords := map[string]Model{"appointment": appt}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetAll
+
+Retrieves all Appointment records from the database.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+*Returns*
+
+	_  <[]Appointment>
+
+		The list of Appointment records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) GetAll(db *gorm.DB) ([]Appointment, error) {
+	var appts []Appointment
+	err := db.Find(&appts).Error
+
+	return appts, err
+}
+
+/*
+*Description*
+
+func List
+
+Retrieves a page of Appointment records from the database, sorted and filtered as requested (see 'appointmentListFields').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	options  <ListOptions>
+
+		The limit, cursor, sort and filters of the page.
+
+*Returns*
+
+	_  <ListPage>
+
+		The page of Appointment records ([]Appointment), with the total number of records that match the filters and the cursor of the next page.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) List(db *gorm.DB, options ListOptions) (ListPage, error) {
+	var appts []Appointment
+	return listRecords(db, &appts, appointmentListFields, options)
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of Appointment records from the database that are associated with the specified secondary key.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]Appointment>
+
+		The list of Appointment records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]Appointment, error) {
+	var appts []Appointment
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Find(&appts).Error
+	return appts, err
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the specified Appointment record in the database with the specified changes if the record exists.
+
+Returns the updated record along with any errors that are thrown.
+
+This function behaves like a PATCH method, rather than a true PUT. Any fields that aren't specified in the request body for the PUT request will not be altered for the specified record.
+
+If a specified field's value should be deleted from the record, the appropriate null/blank should be specified for that key in the JSON request body (e.g. "type": "").
+
+If the updates include a 'status' key, the status change must be a permitted transition from the appointment's current status (see 'UpdateStatus').
+
+The 'seats' attribute cannot be updated with this method, since seats are only reserved by booking ('Book') and released by
+cancelling guests ('AppointmentGuest.Cancel') or the whole appointment. The 'cancel_date_time' attribute cannot be updated either,
+since it is always set by the server when the appointment is cancelled. The 'service_id' and 'user_id' attributes cannot be updated,
+since an appointment can only be made for a Service through booking (cancel the appointment and book a new one instead).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	apptID  <uint>
+
+		The ID of the appointment record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+		Ex:
+			{
+				"status": "Completed"
+			}
+
+*Returns*
+
+	_  <*Appointment>
+
+		The Appointment record that is updated in the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) Update(db *gorm.DB, apptID uint, updates map[string]interface{}) (map[string]Model, error) {
+	if _, seatsUpdated := updates["seats"]; seatsUpdated {
+		return map[string]Model{"appointment": &Appointment{}}, fmt.Errorf("%w: seats can only be changed by booking or by cancelling guests", ErrInvalidSeatCount)
+	}
+
+	if _, cancelDateTimeUpdated := updates["cancel_date_time"]; cancelDateTimeUpdated {
+		return map[string]Model{"appointment": &Appointment{}}, fmt.Errorf("%w: cancel_date_time is set when the appointment is cancelled", ErrRestrictedAppointmentField)
+	}
+
+	if _, refundPendingUpdated := updates["refund_pending"]; refundPendingUpdated {
+		return map[string]Model{"appointment": &Appointment{}}, fmt.Errorf("%w: refund_pending is set when the appointment is cancelled and refunded", ErrRestrictedAppointmentField)
+	}
+
+	// Moving an appointment to another User or Service would skip the booking checks and leave the Services' seat counts stale
+	for _, bookingKey := range []string{"service_id", "user_id"} {
+		if _, bookingUpdated := updates[bookingKey]; bookingUpdated {
+			return map[string]Model{"appointment": &Appointment{}}, fmt.Errorf("%w: %s can't be changed (cancel the appointment and book a new one)", ErrRestrictedAppointmentField, bookingKey)
+		}
+	}
+
+	return appt.update(db, apptID, updates, "")
+}
+
+/*
+*Description*
+
+func update
+
+Performs the update of the specified Appointment record for the 'Update' and 'UpdateStatus' methods.
+
+If the updates include a 'status' key, the status change is validated against the permitted status transitions and recorded
+in the appointment status history (along with the specified reason) in the same transaction as the update. The 'cancel_date_time'
+attribute is always set to the current time when the appointment is cancelled, and the appointment is marked as waiting for its
+refund (see 'RefundCancellation').
+The appointment's invoice is created, voided or adjusted for the new status according to the Business billing policy (see 'billStatusChange').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	apptID  <uint>
+
+		The ID of the appointment record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+	reason  <string>
+
+		The reason for the status change (only recorded if the updates include a 'status' key).
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated Appointment object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) update(db *gorm.DB, apptID uint, updates map[string]interface{}, reason string) (map[string]Model, error) {
+	var returnRecords map[string]Model
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Confirm apptID exists in the database and get current object (row is locked until the transaction completes)
+		updateAppointment := &Appointment{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(updateAppointment, apptID).Error
+		returnRecords = map[string]Model{"appointment": updateAppointment}
+		if err != nil {
+			return err
+		}
+
+		var originalStatus string = updateAppointment.Status
+		newStatus, statusUpdated := updates["status"]
+		if statusUpdated {
+			newStatusString, isString := newStatus.(string)
+			if !isString {
+				return fmt.Errorf("%w: status must be a string value", ErrInvalidAppointmentStatus)
+			}
+
+			newStatusString = StandardizeAppointmentStatus(newStatusString)
+			if !AppointmentStatusIsValid(newStatusString) {
+				return fmt.Errorf("%w: '%s'", ErrInvalidAppointmentStatus, newStatusString)
+			}
+
+			if !AppointmentStatusTransitionIsValid(originalStatus, newStatusString) {
+				return fmt.Errorf("%w: Appointment ID (%d) cannot move from '%s' to '%s'", ErrInvalidAppointmentStatusTransition, apptID, originalStatus, newStatusString)
+			}
+
+			updates["status"] = newStatusString
+
+			// Record when the appointment was cancelled (always the server's clock, so cancellations can't be backdated), and that
+			// its refund is still to be made, in the same transaction as the cancellation
+			if AppointmentStatusIsCancelled(newStatusString) {
+				updates["cancel_date_time"] = time.Now()
+				updates["refund_pending"] = true
+			}
+		}
+
+		err = tx.Model(updateAppointment).Clauses(clause.Returning{}).Where("id = ?", apptID).Updates(updates).Error
+		if err != nil {
+			return err
+		}
+
+		if statusUpdated {
+			history := AppointmentStatusHistory{
+				AppointmentID: apptID,
+				FromStatus:    originalStatus,
+				ToStatus:      updateAppointment.Status,
+				Reason:        reason,
+			}
+
+			_, err = history.Create(tx)
+			if err != nil {
+				return err
+			}
+
+			// Timely cancellations return any class pack credits that paid for the appointment
+			if updateAppointment.IsCancelled() && updateAppointment.CancelDateTime != nil {
+				purchase := ClassPackPurchase{}
+				_, err = purchase.RefundCredits(tx, updateAppointment, updateAppointment.Seats, *updateAppointment.CancelDateTime)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = updateAppointment.billStatusChange(tx)
+		}
+
+		return err
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func UpdateStatus
+
+Moves the specified Appointment record to a new lifecycle status.
+
+The status change is rejected with an 'ErrInvalidAppointmentStatusTransition' error if the appointment's current status
+does not permit a transition to the new status. When an appointment is cancelled, its 'CancelDateTime' attribute is set to the current time.
+
+Each successful status change is recorded in the appointment status history.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	apptID  <uint>
+
+		The ID of the appointment record being updated.
+
+	status  <string>
+
+		The status the appointment is moving to.
+
+	reason  <string>
+
+		The reason for the status change (optional, recorded in the status history).
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated Appointment object.
+
+		Ex:
+			{
+				"appointment": <Appointment object - appointment that was updated>
+			}
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) UpdateStatus(db *gorm.DB, apptID uint, status string, reason string) (map[string]Model, error) {
+	var updates map[string]interface{} = map[string]interface{}{
+		"status": status,
 	}
-	returnedRecords, err := appt.Update(db, apptID, updates)
-	log.Println(time.Now())
-	return returnedRecords, err
+
+	return appt.update(db, apptID, updates, reason)
+}
+
+/*
+*Description*
+
+func GetStatusHistory
+
+Retrieves the status history of the specified Appointment record, ordered from oldest to newest status change.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	apptID  <uint>
+
+		The ID of the appointment record.
+
+*Returns*
+
+	_  <[]AppointmentStatusHistory>
+
+		The list of status changes for the specified Appointment record.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) GetStatusHistory(db *gorm.DB, apptID uint) ([]AppointmentStatusHistory, error) {
+	history := AppointmentStatusHistory{}
+	var apptIDJsonKey string = "appointment_id"
+
+	return history.GetRecordsBySecondaryID(db, apptIDJsonKey, apptID)
+}
+
+/*
+*Description*
+
+func Cancel
+
+Cancels the specified Appointment record on behalf of the customer that booked it.
+
+The 'Status' attribute is set to 'Cancelled By Customer' and the 'CancelDateTime' attribute is set to the current time
+for the specified Appointment record. Appointments that are already cancelled, completed, or marked as a no show cannot be cancelled.
+
+The Appointment record that is cancelled is returned with the updated attribute values.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+	apptID  <uint>
+
+		The ID of the appointment record being cancelled.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the cancelled Appointment object.
+
+		Ex:
+			{
+				"appointment": <Appointment object - appointment that was cancelled>
+			}
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (appt *Appointment) Cancel(db *gorm.DB, apptID uint) (map[string]Model, error) {
+	return appt.UpdateStatus(db, apptID, AppointmentStatusCancelledByCustomer, "")
 }
 
 /*
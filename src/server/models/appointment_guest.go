@@ -0,0 +1,346 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all AppointmentGuest records in the database (one record per seat reserved by an Appointment beyond the booking user's own seat)
+type AppointmentGuest struct {
+	gorm.Model
+	AppointmentID  uint       `gorm:"column:appointment_id;index" json:"appointment_id"`            // ID of Appointment that the guest is attending with
+	Name           string     `gorm:"column:name" json:"name"`                                      // Guest's name (optional)
+	Email          string     `gorm:"column:email" json:"email"`                                    // Guest's email address (optional)
+	PhoneNumber    string     `gorm:"column:phone_number" json:"phone_number"`                      // Guest's phone number (optional)
+	CancelDateTime *time.Time `gorm:"column:cancel_date_time;default:null" json:"cancel_date_time"` // Date/time when the guest's seat was cancelled (if cancelled, else null)
+}
+
+// Errors returned when an AppointmentGuest cancellation is rejected
+var (
+	ErrGuestNotFound         = errors.New("guest not found")
+	ErrGuestAlreadyCancelled = errors.New("guest already cancelled")
+)
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from AppointmentGuest object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the appointment guest object
+*/
+func (guest *AppointmentGuest) GetID() uint {
+	return guest.ID
+}
+
+/*
+*Description*
+
+func IsCancelled
+
+Returns whether the calling AppointmentGuest's seat has been cancelled.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the guest's seat has been cancelled, else 'false'.
+*/
+func (guest *AppointmentGuest) IsCancelled() bool {
+	return guest.CancelDateTime != nil
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new AppointmentGuest record in the database and returns the created record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created AppointmentGuest object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (guest *AppointmentGuest) Create(db *gorm.DB) (map[string]Model, error) {
+	err := db.Create(&guest).Error
+	returnRecords := map[string]Model{"appointment_guest": guest}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves an AppointmentGuest record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	guestID  <uint>
+
+		The ID of the appointment guest record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved AppointmentGuest object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (guest *AppointmentGuest) Get(db *gorm.DB, guestID uint) (map[string]Model, error) {
+	err := db.First(&guest, guestID).Error
+	returnRecords := map[string]Model{"appointment_guest": guest}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the specified AppointmentGuest record in the database with the specified changes if the record exists.
+
+Returns the updated record along with any errors that are thrown.
+
+Guest seats should be cancelled with the 'Cancel' method, which also releases the seat held by the Appointment.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	guestID  <uint>
+
+		The ID of the appointment guest record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated AppointmentGuest object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (guest *AppointmentGuest) Update(db *gorm.DB, guestID uint, updates map[string]interface{}) (map[string]Model, error) {
+	updateGuest := &AppointmentGuest{}
+	returnRecords := map[string]Model{"appointment_guest": updateGuest}
+
+	err := db.First(updateGuest, guestID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Model(updateGuest).Clauses(clause.Returning{}).Where("id = ?", guestID).Updates(updates).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified AppointmentGuest record from the database if it exists.
+
+Deleted records are returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted from.
+
+	guestID  <uint>
+
+		The ID of the appointment guest record being deleted.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the deleted AppointmentGuest object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (guest *AppointmentGuest) Delete(db *gorm.DB, guestID uint) (map[string]Model, error) {
+	deleteGuest := &AppointmentGuest{}
+	returnRecords := map[string]Model{"appointment_guest": deleteGuest}
+
+	err := db.Clauses(clause.Returning{}).Delete(deleteGuest, guestID).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of AppointmentGuest records from the database that are associated with the specified secondary key.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]AppointmentGuest>
+
+		The list of AppointmentGuest records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (guest *AppointmentGuest) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]AppointmentGuest, error) {
+	var guests []AppointmentGuest
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&guests).Error
+	return guests, err
+}
+
+/*
+*Description*
+
+func Cancel
+
+Cancels a single guest's seat on the specified Appointment without cancelling the rest of the booking.
+
+The guest is marked as cancelled and the Appointment's seat count is reduced by one, which releases the seat for the Service.
+If the cancellation is timely, the guest's class pack credit is refunded (if the booking was paid for with credits) and the
+appointment's invoice is reduced by the guest's seat.
+All changes are made in the same transaction. To cancel the booking user's own seat, the whole Appointment should be cancelled instead.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be updated.
+
+	apptID  <uint>
+
+		The ID of the Appointment that the guest is attending with.
+
+	guestID  <uint>
+
+		The ID of the AppointmentGuest record being cancelled.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the cancelled AppointmentGuest object and the updated Appointment object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (guest *AppointmentGuest) Cancel(db *gorm.DB, apptID uint, guestID uint) (map[string]Model, error) {
+	cancelGuest := &AppointmentGuest{}
+	returnRecords := map[string]Model{"appointment_guest": cancelGuest}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		appt := &Appointment{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(appt, apptID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("appointment_id = ?", apptID).First(cancelGuest, guestID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: Guest ID (%d) is not attending Appointment ID (%d)", ErrGuestNotFound, guestID, apptID)
+		} else if err != nil {
+			return err
+		}
+
+		if cancelGuest.IsCancelled() {
+			return fmt.Errorf("%w: Guest ID (%d) was cancelled at %s", ErrGuestAlreadyCancelled, guestID, cancelGuest.CancelDateTime)
+		}
+
+		if !appt.IsActive() {
+			return fmt.Errorf("%w: Appointment ID (%d) has status '%s'", ErrInvalidAppointmentStatusTransition, apptID, appt.Status)
+		}
+
+		err = tx.Model(cancelGuest).Clauses(clause.Returning{}).Where("id = ?", guestID).Update("cancel_date_time", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		updatedRecords, err := appt.update(tx, apptID, map[string]interface{}{"seats": appt.Seats - 1}, "")
+		returnRecords["appointment"] = updatedRecords["appointment"]
+		if err != nil {
+			return err
+		}
+
+		// A timely guest cancellation returns the guest's class pack credit (if the booking was paid for with credits)
+		purchase := ClassPackPurchase{}
+		_, err = purchase.RefundCredits(tx, appt, 1, *cancelGuest.CancelDateTime)
+		if err != nil {
+			return err
+		}
+
+		// A timely guest cancellation also takes the guest's seat off the appointment's invoice
+		timely, err := appt.CancellationIsTimely(tx, *cancelGuest.CancelDateTime)
+		if err != nil || !timely {
+			return err
+		}
+
+		updatedAppointment := updatedRecords["appointment"].(*Appointment)
+		return updatedAppointment.rebill(tx)
+	})
+
+	return returnRecords, err
+}
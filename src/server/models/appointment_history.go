@@ -0,0 +1,206 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// GORM model for all AppointmentStatusHistory records in the database (one record per Appointment status change)
+type AppointmentStatusHistory struct {
+	gorm.Model
+	AppointmentID uint   `gorm:"column:appointment_id;index" json:"appointment_id"` // ID of Appointment whose status changed
+	FromStatus    string `gorm:"column:from_status" json:"from_status"`             // Status before the change (blank for the Appointment's initial status)
+	ToStatus      string `gorm:"column:to_status;not null" json:"to_status"`        // Status after the change
+	Reason        string `gorm:"column:reason" json:"reason"`                       // Optional reason given for the status change
+}
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from AppointmentStatusHistory object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the appointment status history object
+*/
+func (history *AppointmentStatusHistory) GetID() uint {
+	return history.ID
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new AppointmentStatusHistory record in the database and returns the created record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created AppointmentStatusHistory object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (history *AppointmentStatusHistory) Create(db *gorm.DB) (map[string]Model, error) {
+	err := db.Create(&history).Error
+	returnRecords := map[string]Model{"appointment_status_history": history}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves an AppointmentStatusHistory record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	historyID  <uint>
+
+		The ID of the appointment status history record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved AppointmentStatusHistory object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (history *AppointmentStatusHistory) Get(db *gorm.DB, historyID uint) (map[string]Model, error) {
+	err := db.First(&history, historyID).Error
+	returnRecords := map[string]Model{"appointment_status_history": history}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Update
+
+AppointmentStatusHistory records are an append-only audit trail, so this method always returns an error.
+
+Status changes should be made with the 'Appointment.UpdateStatus' method, which records a new history entry.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		Unused.
+
+	historyID  <uint>
+
+		Unused.
+
+	updates  <map[string]interface{}>
+
+		Unused.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Error stating that history records cannot be modified.
+*/
+func (history *AppointmentStatusHistory) Update(db *gorm.DB, historyID uint, updates map[string]interface{}) (map[string]Model, error) {
+	return map[string]Model{}, errors.New("appointment status history records cannot be modified")
+}
+
+/*
+*Description*
+
+func Delete
+
+AppointmentStatusHistory records are an append-only audit trail, so this method always returns an error.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		Unused.
+
+	historyID  <uint>
+
+		Unused.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Error stating that history records cannot be deleted.
+*/
+func (history *AppointmentStatusHistory) Delete(db *gorm.DB, historyID uint) (map[string]Model, error) {
+	return map[string]Model{}, errors.New("appointment status history records cannot be deleted")
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of AppointmentStatusHistory records from the database that are associated with the specified secondary key,
+ordered from oldest to newest status change.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]AppointmentStatusHistory>
+
+		The list of AppointmentStatusHistory records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (history *AppointmentStatusHistory) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]AppointmentStatusHistory, error) {
+	var historyRecords []AppointmentStatusHistory
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("created_at, id").Find(&historyRecords).Error
+	return historyRecords, err
+}
@@ -0,0 +1,558 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"server/config"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all BookingRule records in the database
+//
+// A BookingRule with a null ServiceID is the default rule for every Service offered by the Business.
+// A BookingRule with a ServiceID replaces the Business default rule for that Service.
+type BookingRule struct {
+	gorm.Model
+	BusinessID             uint  `gorm:"column:business_id;not null;index" json:"business_id"`              // ID of Business that the rule belongs to
+	ServiceID              *uint `gorm:"column:service_id;default:null;index" json:"service_id"`            // ID of Service the rule applies to (null for the Business default rule)
+	BookingWindowDays      uint  `gorm:"column:booking_window_days" json:"booking_window_days"`             // How many days before a Service starts that bookings open (0 for no limit)
+	MinLeadTimeMinutes     uint  `gorm:"column:min_lead_time_minutes" json:"min_lead_time_minutes"`         // How many minutes before a Service starts that bookings close (0 to allow bookings until the Service starts)
+	MaxBookingsPerWeek     uint  `gorm:"column:max_bookings_per_week" json:"max_bookings_per_week"`         // Max number of active appointments a User can hold with the Business per week (0 for no limit)
+	MinCancelNoticeMinutes uint  `gorm:"column:min_cancel_notice_minutes" json:"min_cancel_notice_minutes"` // How many minutes before a Service starts that cancellations stop being timely (0 for any time before the Service starts)
+}
+
+// Machine-readable reason codes returned when a booking is rejected
+const (
+	BookingRejectedServiceStarted       string = "service_already_started"
+	BookingRejectedWindowNotOpen        string = "booking_window_not_open"
+	BookingRejectedWindowClosed         string = "booking_window_closed"
+	BookingRejectedWeeklyLimitReached   string = "weekly_booking_limit_reached"
+	BookingRejectedInsufficientCapacity string = "insufficient_capacity"
+	BookingRejectedDuplicateBooking     string = "duplicate_booking"
+	BookingRejectedOverlappingBooking   string = "overlapping_booking"
+	BookingRejectedPromoCodeNotFound    string = "promo_code_not_found"
+	BookingRejectedPromoCodeNotActive   string = "promo_code_not_active"
+	BookingRejectedPromoCodeNotEligible string = "promo_code_not_eligible"
+	BookingRejectedPromoCodeLimit       string = "promo_code_limit_reached"
+	BookingRejectedPromoCodeFirstTime   string = "promo_code_first_time_only"
+)
+
+/*
+*Description*
+
+type BookingRejectedError
+
+Error returned when a booking request violates a booking rule.
+
+The 'Code' attribute is a machine-readable reason code (see the 'BookingRejected*' constants) and the 'Message' attribute
+is a human-readable description of why the booking was rejected.
+*/
+type BookingRejectedError struct {
+	Code    string
+	Message string
+}
+
+// Error returns the human-readable description of why the booking was rejected
+func (err *BookingRejectedError) Error() string {
+	return err.Message
+}
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from BookingRule object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the booking rule object
+*/
+func (rule *BookingRule) GetID() uint {
+	return rule.ID
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new BookingRule record in the database and returns the created record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created BookingRule object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (rule *BookingRule) Create(db *gorm.DB) (map[string]Model, error) {
+	err := db.Create(&rule).Error
+	returnRecords := map[string]Model{"booking_rule": rule}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a BookingRule record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	ruleID  <uint>
+
+		The ID of the booking rule record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved BookingRule object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (rule *BookingRule) Get(db *gorm.DB, ruleID uint) (map[string]Model, error) {
+	err := db.First(&rule, ruleID).Error
+	returnRecords := map[string]Model{"booking_rule": rule}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetBusinessRule
+
+Retrieves the default BookingRule record for the specified Business (the rule that applies to every Service without its own rule).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the record.
+
+	businessID  <uint>
+
+		The ID of the Business.
+
+*Returns*
+
+	_  <*BookingRule>
+
+		The Business default BookingRule record.
+
+	_  <error>
+
+		Encountered error (gorm.ErrRecordNotFound if the Business has no default rule).
+*/
+func (rule *BookingRule) GetBusinessRule(db *gorm.DB, businessID uint) (*BookingRule, error) {
+	businessRule := &BookingRule{}
+	err := db.Where("business_id = ? AND service_id IS NULL", businessID).First(businessRule).Error
+	return businessRule, err
+}
+
+/*
+*Description*
+
+func GetServiceRule
+
+Retrieves the BookingRule record that was defined specifically for the specified Service.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the record.
+
+	serviceID  <uint>
+
+		The ID of the Service.
+
+*Returns*
+
+	_  <*BookingRule>
+
+		The Service specific BookingRule record.
+
+	_  <error>
+
+		Encountered error (gorm.ErrRecordNotFound if the Service has no rule of its own).
+*/
+func (rule *BookingRule) GetServiceRule(db *gorm.DB, serviceID uint) (*BookingRule, error) {
+	serviceRule := &BookingRule{}
+	err := db.Where("service_id = ?", serviceID).First(serviceRule).Error
+	return serviceRule, err
+}
+
+/*
+*Description*
+
+func GetEffectiveRule
+
+Retrieves the BookingRule that applies to bookings for the specified Service.
+
+The Service's own rule is used if one exists. Otherwise, the default rule for the Service's Business is used.
+If neither exists, a rule with no limits is returned (bookings are still rejected once the Service has started).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the record.
+
+	service  <*Service>
+
+		The Service being booked.
+
+*Returns*
+
+	_  <*BookingRule>
+
+		The BookingRule that applies to the Service.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (rule *BookingRule) GetEffectiveRule(db *gorm.DB, service *Service) (*BookingRule, error) {
+	effectiveRule, err := rule.GetServiceRule(db, service.ID)
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return effectiveRule, err
+	}
+
+	effectiveRule, err = rule.GetBusinessRule(db, service.BusinessID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &BookingRule{BusinessID: service.BusinessID}, nil
+	}
+
+	return effectiveRule, err
+}
+
+/*
+*Description*
+
+func Upsert
+
+Creates or replaces the BookingRule record for the calling rule's Business/Service pair.
+
+If a rule already exists for the same 'BusinessID' and 'ServiceID', its limits are overwritten with the calling rule's limits.
+Otherwise, a new rule is created.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created/updated.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the saved BookingRule object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (rule *BookingRule) Upsert(db *gorm.DB) (map[string]Model, error) {
+	var existingRule *BookingRule
+	var err error
+
+	if rule.ServiceID != nil {
+		existingRule, err = rule.GetServiceRule(db, *rule.ServiceID)
+	} else {
+		existingRule, err = rule.GetBusinessRule(db, rule.BusinessID)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		rule.ID = 0
+		return rule.Create(db)
+	} else if err != nil {
+		return map[string]Model{"booking_rule": rule}, err
+	}
+
+	updates := map[string]interface{}{
+		"booking_window_days":       rule.BookingWindowDays,
+		"min_lead_time_minutes":     rule.MinLeadTimeMinutes,
+		"max_bookings_per_week":     rule.MaxBookingsPerWeek,
+		"min_cancel_notice_minutes": rule.MinCancelNoticeMinutes,
+	}
+
+	return rule.Update(db, existingRule.ID, updates)
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the specified BookingRule record in the database with the specified changes if the record exists.
+
+Returns the updated record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	ruleID  <uint>
+
+		The ID of the booking rule record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated BookingRule object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (rule *BookingRule) Update(db *gorm.DB, ruleID uint, updates map[string]interface{}) (map[string]Model, error) {
+	// Confirm ruleID exists in the database and get current object
+	returnRecords, err := rule.Get(db, ruleID)
+	updateRule := returnRecords["booking_rule"]
+
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Model(&updateRule).Clauses(clause.Returning{}).Where("id = ?", ruleID).Updates(updates).Error
+	returnRecords = map[string]Model{"booking_rule": updateRule}
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified BookingRule record from the database if it exists.
+
+Deleted record is returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted.
+
+	ruleID  <uint>
+
+		The ID of the booking rule record being deleted.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the deleted BookingRule object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (rule *BookingRule) Delete(db *gorm.DB, ruleID uint) (map[string]Model, error) {
+	// Confirm ruleID exists in the database and get current object
+	returnRecords, err := rule.Get(db, ruleID)
+	deleteRule := returnRecords["booking_rule"]
+
+	if err != nil {
+		return returnRecords, err
+	}
+
+	if config.Debug {
+		log.Printf("\n\nBookingRule object targeted for deletion:\n\n%+v\n\n", deleteRule)
+	}
+
+	err = db.Delete(deleteRule).Error
+	returnRecords = map[string]Model{"booking_rule": deleteRule}
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func CheckBooking
+
+Confirms that the specified User is permitted to book the specified Service at the specified time under the calling BookingRule.
+
+Checks performed (in order):
+
+  - The Service has not already started
+  - The booking window for the Service is open ('BookingWindowDays' before the Service starts)
+  - The booking window for the Service has not closed ('MinLeadTimeMinutes' before the Service starts)
+  - The User holds fewer than 'MaxBookingsPerWeek' active appointments with the Business in the week (Monday to Sunday) the Service starts
+
+The weekly limit is only enforced atomically if the caller holds the User's booking lock for the rest of its transaction (see 'Appointment.Book').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to count the User's existing appointments.
+
+	service  <*Service>
+
+		The Service being booked.
+
+	userID  <uint>
+
+		The ID of the User making the booking.
+
+	bookingTime  <time.Time>
+
+		The time the booking is being made.
+
+*Returns*
+
+	_  <error>
+
+		A *BookingRejectedError with the reason code if the booking violates the rule, any other encountered error, or nil if the booking is permitted.
+*/
+func (rule *BookingRule) CheckBooking(db *gorm.DB, service *Service, userID uint, bookingTime time.Time) error {
+	if !bookingTime.Before(service.StartDateTime) {
+		return &BookingRejectedError{
+			Code:    BookingRejectedServiceStarted,
+			Message: fmt.Sprintf("Service ID (%d) started at %s and can no longer be booked.", service.ID, service.StartDateTime.Format(time.RFC3339)),
+		}
+	}
+
+	if rule.BookingWindowDays > 0 {
+		bookingOpens := service.StartDateTime.AddDate(0, 0, -int(rule.BookingWindowDays))
+		if bookingTime.Before(bookingOpens) {
+			return &BookingRejectedError{
+				Code:    BookingRejectedWindowNotOpen,
+				Message: fmt.Sprintf("Bookings for Service ID (%d) open at %s (%d day(s) before the service starts).", service.ID, bookingOpens.Format(time.RFC3339), rule.BookingWindowDays),
+			}
+		}
+	}
+
+	if rule.MinLeadTimeMinutes > 0 {
+		bookingCloses := service.StartDateTime.Add(-time.Duration(rule.MinLeadTimeMinutes) * time.Minute)
+		if bookingTime.After(bookingCloses) {
+			return &BookingRejectedError{
+				Code:    BookingRejectedWindowClosed,
+				Message: fmt.Sprintf("Bookings for Service ID (%d) closed at %s (%d minute(s) before the service starts).", service.ID, bookingCloses.Format(time.RFC3339), rule.MinLeadTimeMinutes),
+			}
+		}
+	}
+
+	if rule.MaxBookingsPerWeek > 0 {
+		weekStart, weekEnd := bookingWeek(service.StartDateTime)
+
+		var weeklyBookingCt int64
+		err := db.Model(&Appointment{}).
+			Joins("JOIN services ON services.id = appointments.service_id AND services.deleted_at IS NULL").
+			Where("appointments.user_id = ?", userID).
+			Where("appointments.status IN ?", activeAppointmentStatuses).
+			Where("services.business_id = ?", service.BusinessID).
+			Where("services.start_date_time >= ? AND services.start_date_time < ?", weekStart, weekEnd).
+			Count(&weeklyBookingCt).Error
+		if err != nil {
+			return err
+		}
+
+		if weeklyBookingCt >= int64(rule.MaxBookingsPerWeek) {
+			return &BookingRejectedError{
+				Code:    BookingRejectedWeeklyLimitReached,
+				Message: fmt.Sprintf("User ID (%d) already has %d active booking(s) with Business ID (%d) for the week of %s (limit is %d).", userID, weeklyBookingCt, service.BusinessID, weekStart.Format("2006-01-02"), rule.MaxBookingsPerWeek),
+			}
+		}
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func bookingWeek
+
+Returns the start (Monday 00:00) and end (the following Monday 00:00) of the week containing the specified time, in the time's location.
+
+*Parameters*
+
+	t  <time.Time>
+
+		The time whose week is being calculated.
+
+*Returns*
+
+	weekStart  <time.Time>
+
+		The start of the week (inclusive).
+
+	weekEnd  <time.Time>
+
+		The end of the week (exclusive).
+*/
+func bookingWeek(t time.Time) (weekStart time.Time, weekEnd time.Time) {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	weekStart = time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	weekEnd = weekStart.AddDate(0, 0, 7)
+	return weekStart, weekEnd
+}
+
+/*
+*Description*
+
+func CancellationIsTimely
+
+Returns whether cancelling an appointment for the specified Service at the specified time gives the Business enough notice.
+
+A cancellation is timely if it is made before the Service starts and at least 'MinCancelNoticeMinutes' minutes before the Service starts.
+
+*Parameters*
+
+	service  <*Service>
+
+		The Service that the cancelled appointment is for.
+
+	cancelTime  <time.Time>
+
+		The time the appointment is cancelled.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the cancellation is timely, else 'false'.
+*/
+func (rule *BookingRule) CancellationIsTimely(service *Service, cancelTime time.Time) bool {
+	cancelCutoff := service.StartDateTime.Add(-time.Duration(rule.MinCancelNoticeMinutes) * time.Minute)
+	return cancelTime.Before(cancelCutoff)
+}
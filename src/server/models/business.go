@@ -1,12 +1,17 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"regexp"
+	"strings"
 
 	"server/config"
+	"server/money"
 
+	"golang.org/x/exp/slices"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -14,10 +19,73 @@ import (
 // GORM model for all Business records in the database
 type Business struct {
 	gorm.Model
-	OwnerID uint   `gorm:"column:owner_id" json:"owner_id"` // ID of User account that owns the business record
-	Name    string `gorm:"column:name" json:"name"`         // Business name
+	OwnerID                  uint   `gorm:"column:owner_id" json:"owner_id"`                                                                 // ID of User account that owns the business record
+	Name                     string `gorm:"column:name" json:"name"`                                                                         // Business name
+	AllowOverlappingBookings bool   `gorm:"column:allow_overlapping_bookings;default:false" json:"allow_overlapping_bookings"`               // True if users may book this business's services at times that overlap their other appointments
+	BillingPolicy            string `gorm:"column:billing_policy;not null;default:At Booking" json:"billing_policy"`                         // When appointments for this business's services are invoiced (At Booking, At Completion, None)
+	Currency                 string `gorm:"column:currency;not null;default:USD" json:"currency"`                                            // Default ISO 4217 currency of the business's prices and invoices (e.g. USD, CAD, EUR)
+	InvoicePrefix            string `gorm:"column:invoice_prefix;not null;default:INV-" json:"invoice_prefix"`                               // Prefix of the business's invoice numbers (e.g. "INV-" for INV-000042)
+	CreditNotePrefix         string `gorm:"column:credit_note_prefix;not null;default:CN-" json:"credit_note_prefix"`                        // Prefix of the business's credit note numbers (e.g. "CN-" for CN-000007)
+	PaymentTermsDays         uint   `gorm:"column:payment_terms_days;not null;default:30" json:"payment_terms_days"`                         // Days after an invoice is issued that it is due (0 for due on receipt)
+	LateFeesEnabled          bool   `gorm:"column:late_fees_enabled;default:false" json:"late_fees_enabled"`                                 // True if overdue invoices are charged a late fee automatically
+	LateFeeAmount            int    `gorm:"column:late_fee_amount;not null;default:0" json:"late_fee_amount"`                                // Flat part of the late fee (in cents)
+	LateFeeRate              uint   `gorm:"column:late_fee_rate;not null;default:0" json:"late_fee_rate"`                                    // Part of the late fee charged on the overdue balance (in basis points, e.g. 150 for 1.5%)
+	LateFeeGraceDays         uint   `gorm:"column:late_fee_grace_days;not null;default:0" json:"late_fee_grace_days"`                        // Days after the due date before the late fee is charged
+	AddressID                *uint  `gorm:"column:address_id;index" json:"address_id"`                                                       // ID of the Address record where the business is located (see 'Business.SetAddress')
+	State                    string `gorm:"column:state" json:"state"`                                                                       // State (2 letter abbreviation) that the business is located in (determines its sales tax rate)
+	ZipCode                  string `gorm:"column:zip" json:"zip"`                                                                           // Zip code that the business is located in (determines its sales tax rate)
+	TaxJurisdiction          string `gorm:"column:tax_jurisdiction" json:"tax_jurisdiction"`                                                 // Sales tax jurisdiction code that overrides the business's state and zip code (see 'TaxRate')
+	ReceivablesAccount       string `gorm:"column:receivables_account;not null;default:Accounts Receivable" json:"receivables_account"`      // Ledger account of amounts owed on issued invoices in accounting exports (see 'AccountMapping')
+	RevenueAccount           string `gorm:"column:revenue_account;not null;default:Sales" json:"revenue_account"`                            // Ledger account of sales in accounting exports
+	SalesTaxAccount          string `gorm:"column:sales_tax_account;not null;default:Sales Tax Payable" json:"sales_tax_account"`            // Ledger account of sales tax charged in accounting exports
+	DepositAccount           string `gorm:"column:deposit_account;not null;default:Undeposited Funds" json:"deposit_account"`                // Ledger account of payments received and refunded in accounting exports
+	CustomerCreditAccount    string `gorm:"column:customer_credit_account;not null;default:Customer Credits" json:"customer_credit_account"` // Ledger account of store credit and gift card balances in accounting exports
 }
 
+// Fields that lists of Business records can be sorted and filtered by (see 'Business.List')
+var businessListFields map[string]listField = map[string]listField{
+	"id":               {column: "id", jsonKey: "ID", fieldType: listFieldInt, sortable: true},
+	"created_at":       {column: "created_at", jsonKey: "CreatedAt", fieldType: listFieldTime, sortable: true},
+	"updated_at":       {column: "updated_at", jsonKey: "UpdatedAt", fieldType: listFieldTime, sortable: true},
+	"owner_id":         {column: "owner_id", jsonKey: "owner_id", fieldType: listFieldInt, sortable: true},
+	"name":             {column: "name", jsonKey: "name", fieldType: listFieldString, sortable: true},
+	"currency":         {column: "currency", jsonKey: "currency", fieldType: listFieldString},
+	"billing_policy":   {column: "billing_policy", jsonKey: "billing_policy", fieldType: listFieldString},
+	"state":            {column: "state", jsonKey: "state", fieldType: listFieldString},
+	"zip":              {column: "zip", jsonKey: "zip", fieldType: listFieldString},
+	"tax_jurisdiction": {column: "tax_jurisdiction", jsonKey: "tax_jurisdiction", fieldType: listFieldString},
+}
+
+// Business billing policies (when appointments are automatically invoiced)
+const (
+	BillingPolicyAtBooking    string = "At Booking"    // Appointments are invoiced when they are booked
+	BillingPolicyAtCompletion string = "At Completion" // Appointments are invoiced when they are marked as completed
+	BillingPolicyNone         string = "None"          // Appointments are not invoiced automatically
+)
+
+// List of valid business billing policies
+var billingPolicies []string = []string{
+	BillingPolicyAtBooking,
+	BillingPolicyAtCompletion,
+	BillingPolicyNone,
+}
+
+// Errors returned when a Business's settings are invalid
+var (
+	ErrInvalidBillingPolicy = errors.New("invalid billing policy")
+	ErrInvalidNumberPrefix  = errors.New("invalid document number prefix")
+	ErrInvalidPaymentTerms  = errors.New("invalid payment terms") // Payment terms or late fee settings are out of range
+)
+
+// Payment terms of invoices for new businesses and invoices without a Business (in days)
+const DefaultPaymentTermsDays uint = 30
+
+// Longest payment terms and late fee grace period that a Business can set (in days)
+const maxPaymentTermsDays uint = 365
+
+// Document number prefixes are 1-12 letters, digits and separators, starting with a letter or digit (e.g. "INV-" or "2024/")
+var numberPrefixPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9/_.-]{0,11}$`)
+
 /*
 *Description*
 
@@ -107,6 +175,311 @@ func (business *Business) GetID() uint {
 /*
 *Description*
 
+func GetBillingPolicy
+
+Returns the calling Business's billing policy (defaults to invoicing at booking if the policy is not set).
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The business's billing policy (see the 'BillingPolicy*' constants).
+*/
+func (business *Business) GetBillingPolicy() string {
+	if business.BillingPolicy == "" {
+		return BillingPolicyAtBooking
+	}
+
+	return business.BillingPolicy
+}
+
+/*
+*Description*
+
+func GetCurrency
+
+Returns the calling Business's default currency (defaults to 'money.DefaultCurrency' if the currency is not set).
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The business's ISO 4217 currency code.
+*/
+func (business *Business) GetCurrency() string {
+	if business.Currency == "" {
+		return money.DefaultCurrency
+	}
+
+	return business.Currency
+}
+
+/*
+*Description*
+
+func resolveCurrency
+
+Returns the currency that a Business's price or invoice is in. A currency that is specified is validated, and an unspecified
+currency defaults to the Business's currency (or 'money.DefaultCurrency' if there is no such Business).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	businessID  <uint>
+
+		The ID of the business that the price or invoice belongs to.
+
+	currency  <string>
+
+		The specified ISO 4217 currency code ("" if none was specified).
+
+*Returns*
+
+	_  <string>
+
+		The upper case ISO 4217 currency code.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func resolveCurrency(db *gorm.DB, businessID uint, currency string) (string, error) {
+	if currency != "" {
+		return money.NormalizeCurrency(currency)
+	}
+
+	business := &Business{}
+	err := db.Select("currency").First(business, businessID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return money.DefaultCurrency, nil
+	}
+
+	return business.GetCurrency(), err
+}
+
+/*
+*Description*
+
+func GetInvoicePrefix
+
+Returns the prefix of the calling Business's invoice numbers (defaults to 'DefaultInvoicePrefix' if the prefix is not set).
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The business's invoice number prefix.
+*/
+func (business *Business) GetInvoicePrefix() string {
+	if business.InvoicePrefix == "" {
+		return DefaultInvoicePrefix
+	}
+
+	return business.InvoicePrefix
+}
+
+/*
+*Description*
+
+func GetCreditNotePrefix
+
+Returns the prefix of the calling Business's credit note numbers (defaults to 'DefaultCreditNotePrefix' if the prefix is not set).
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The business's credit note number prefix.
+*/
+func (business *Business) GetCreditNotePrefix() string {
+	if business.CreditNotePrefix == "" {
+		return DefaultCreditNotePrefix
+	}
+
+	return business.CreditNotePrefix
+}
+
+/*
+*Description*
+
+func GetPaymentTermsDays
+
+Returns the number of days after an invoice is issued that the calling Business's invoices are due. Invoices without a Business (i.e.
+when the calling Business record was not found) use 'DefaultPaymentTermsDays'.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The business's payment terms (in days).
+*/
+func (business *Business) GetPaymentTermsDays() uint {
+	if business.ID == 0 {
+		return DefaultPaymentTermsDays
+	}
+
+	return business.PaymentTermsDays
+}
+
+/*
+*Description*
+
+func validateNumberPrefixes
+
+Confirms that the document number prefixes in a map of Business attributes (if they are set) are valid (see 'numberPrefixPattern').
+
+Changing a prefix only changes the numbers of documents issued afterwards. The sequence of numbers continues where it left off.
+
+*Parameters*
+
+	attributes  <map[string]interface{}>
+
+		JSON with the attributes as keys and their values as values.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func validateNumberPrefixes(attributes map[string]interface{}) error {
+	for _, attribute := range []string{"invoice_prefix", "credit_note_prefix"} {
+		prefix, prefixSet := attributes[attribute]
+		if prefixSet && !numberPrefixPattern.MatchString(fmt.Sprint(prefix)) {
+			return fmt.Errorf("%w: '%v' must be 1-12 letters, digits or separators (/ _ . -), starting with a letter or digit", ErrInvalidNumberPrefix, prefix)
+		}
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func validatePaymentTerms
+
+Confirms that the calling Business's payment terms and late fee settings are in range. Businesses that charge late fees must charge
+a flat fee, a percentage of the overdue balance, or both.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidPaymentTerms' if a setting is out of range (nil otherwise)
+*/
+func (business *Business) validatePaymentTerms() error {
+	if business.PaymentTermsDays > maxPaymentTermsDays || business.LateFeeGraceDays > maxPaymentTermsDays {
+		return fmt.Errorf("%w: payment terms and late fee grace periods can be at most %d days", ErrInvalidPaymentTerms, maxPaymentTermsDays)
+	}
+
+	if business.LateFeeAmount < 0 {
+		return fmt.Errorf("%w: the late fee amount can't be negative", ErrInvalidPaymentTerms)
+	}
+
+	if business.LateFeeRate > 10000 {
+		return fmt.Errorf("%w: the late fee rate can be at most 10000 basis points (100%%)", ErrInvalidPaymentTerms)
+	}
+
+	if business.LateFeesEnabled && business.LateFeeAmount == 0 && business.LateFeeRate == 0 {
+		return fmt.Errorf("%w: late fees need an amount, a rate or both", ErrInvalidPaymentTerms)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func GetLateFee
+
+Returns the late fee that the calling Business charges on an overdue balance: the flat late fee plus the late fee rate applied to the
+balance (rounded half up to the nearest cent). Businesses that don't charge late fees charge nothing.
+
+*Parameters*
+
+	overdueBalance  <int>
+
+		The overdue balance (in cents).
+
+*Returns*
+
+	_  <int>
+
+		The late fee (in cents).
+*/
+func (business *Business) GetLateFee(overdueBalance int) int {
+	if !business.LateFeesEnabled || overdueBalance <= 0 {
+		return 0
+	}
+
+	var numerator int64 = int64(overdueBalance) * int64(business.LateFeeRate)
+	return business.LateFeeAmount + int((2*numerator+10000)/20000)
+}
+
+/*
+*Description*
+
+func normalizeCurrencyUpdate
+
+Validates the currency in a map of updates (if it is being updated) and replaces it with its upper case ISO 4217 code.
+
+*Parameters*
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func normalizeCurrencyUpdate(updates map[string]interface{}) error {
+	currency, currencyUpdated := updates["currency"]
+	if !currencyUpdated {
+		return nil
+	}
+
+	normalized, err := money.NormalizeCurrency(fmt.Sprint(currency))
+	if err != nil {
+		return err
+	}
+
+	updates["currency"] = normalized
+	return nil
+}
+
+/*
+*Description*
+
 func Create
 
 Creates a new Business record in the database and returns the created record along with any errors that are thrown.
@@ -132,7 +505,59 @@ Creates a new Business record in the database and returns the created record alo
 		Encountered error (nil if no errors are encountered).
 */
 func (business *Business) Create(db *gorm.DB) (map[string]Model, error) {
-	err := db.Create(&business).Error
+	if business.BillingPolicy != "" && !slices.Contains(billingPolicies, business.BillingPolicy) {
+		return map[string]Model{"business": business}, fmt.Errorf("%w: billing policy '%s' must be one of %v", ErrInvalidBillingPolicy, business.BillingPolicy, billingPolicies)
+	}
+
+	currency, err := money.NormalizeCurrency(business.GetCurrency())
+	if err != nil {
+		return map[string]Model{"business": business}, err
+	}
+	business.Currency = currency
+
+	business.InvoicePrefix = business.GetInvoicePrefix()
+	business.CreditNotePrefix = business.GetCreditNotePrefix()
+	err = validateNumberPrefixes(map[string]interface{}{"invoice_prefix": business.InvoicePrefix, "credit_note_prefix": business.CreditNotePrefix})
+	if err != nil {
+		return map[string]Model{"business": business}, err
+	}
+
+	// Payment terms of 0 can't be told apart from unset terms here, so they default to 30 days (set them to 0 with an update)
+	if business.PaymentTermsDays == 0 {
+		business.PaymentTermsDays = DefaultPaymentTermsDays
+	}
+
+	err = business.validatePaymentTerms()
+	if err != nil {
+		return map[string]Model{"business": business}, err
+	}
+
+	accounts := business.GetAccountMapping()
+	business.ReceivablesAccount = accounts.Receivables
+	business.RevenueAccount = accounts.Revenue
+	business.SalesTaxAccount = accounts.SalesTax
+	business.DepositAccount = accounts.Deposits
+	business.CustomerCreditAccount = accounts.CustomerCredit
+	err = validateAccountMappings(map[string]interface{}{
+		"receivables_account":     business.ReceivablesAccount,
+		"revenue_account":         business.RevenueAccount,
+		"sales_tax_account":       business.SalesTaxAccount,
+		"deposit_account":         business.DepositAccount,
+		"customer_credit_account": business.CustomerCreditAccount,
+	})
+	if err != nil {
+		return map[string]Model{"business": business}, err
+	}
+
+	business.State = normalizeTaxLocation(business.State)
+	business.ZipCode = strings.TrimSpace(business.ZipCode)
+	business.TaxJurisdiction = normalizeTaxLocation(business.TaxJurisdiction)
+	err = validateTaxJurisdiction(db, business.TaxJurisdiction)
+	if err != nil {
+		return map[string]Model{"business": business}, err
+	}
+
+	err = db.Create(&business).Error
 	if err != nil {
 		returnRecords := map[string]Model{"business": business}
 		return returnRecords, err
@@ -209,6 +634,38 @@ func (business *Business) GetAll(db *gorm.DB) ([]Business, error) {
 /*
 *Description*
 
+func List
+
+Retrieves a page of Business records from the database, sorted and filtered as requested (see 'businessListFields').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	options  <ListOptions>
+
+		The limit, cursor, sort and filters of the page.
+
+*Returns*
+
+	_  <ListPage>
+
+		The page of Business records ([]Business), with the total number of records that match the filters and the cursor of the next page.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (business *Business) List(db *gorm.DB, options ListOptions) (ListPage, error) {
+	var businesses []Business
+	return listRecords(db, &businesses, businessListFields, options)
+}
+
+/*
+*Description*
+
 func GetServiceAppointments
 
 Retrieves the list of all Appointments (and the Service each Appointment is for) that are associated with the specified User.
@@ -257,7 +714,8 @@ Retrieves the list of all Appointments (and the Service each Appointment is for)
 							"service_id":22,
 							"user_id":33,
 							"cancel_date_time":null,
-							"active":true
+							"status":"Confirmed",
+							"seats":1
 						},
 						{
 							"ID":51,
@@ -267,7 +725,8 @@ Retrieves the list of all Appointments (and the Service each Appointment is for)
 							"service_id":22,
 							"user_id":26,
 							"cancel_date_time":null,
-							"active":true
+							"status":"Confirmed",
+							"seats":1
 						},
 						...
 					]
@@ -298,7 +757,8 @@ Retrieves the list of all Appointments (and the Service each Appointment is for)
 							"service_id":55,
 							"user_id":66,
 							"cancel_date_time":null,
-							"active":true
+							"status":"Confirmed",
+							"seats":1
 						},
 						{
 							"ID":69,
@@ -308,7 +768,8 @@ Retrieves the list of all Appointments (and the Service each Appointment is for)
 							"service_id":55,
 							"user_id":85,
 							"cancel_date_time":null,
-							"active":true
+							"status":"Confirmed",
+							"seats":1
 						},
 						...
 					]
@@ -347,7 +808,7 @@ func (business *Business) GetServiceAppointments(db *gorm.DB, businessID uint, a
 		var finalApptList []Appointment
 		if activeOnly {
 			for _, appt := range appts {
-				if appt.Active {
+				if appt.IsActive() {
 					finalApptList = append(finalApptList, appt)
 				}
 			}
@@ -407,6 +868,33 @@ If a specified field's value should be deleted from the record, the appropriate
 		Encountered error (nil if no errors are encountered)
 */
 func (business *Business) Update(db *gorm.DB, businessID uint, updates map[string]interface{}) (map[string]Model, error) {
+	if billingPolicy, policyUpdated := updates["billing_policy"]; policyUpdated && !slices.Contains(billingPolicies, fmt.Sprint(billingPolicy)) {
+		return map[string]Model{"business": &Business{}}, fmt.Errorf("%w: billing policy '%v' must be one of %v", ErrInvalidBillingPolicy, billingPolicy, billingPolicies)
+	}
+
+	if err := normalizeCurrencyUpdate(updates); err != nil {
+		return map[string]Model{"business": &Business{}}, err
+	}
+
+	if err := validateNumberPrefixes(updates); err != nil {
+		return map[string]Model{"business": &Business{}}, err
+	}
+
+	if err := validateAccountMappings(updates); err != nil {
+		return map[string]Model{"business": &Business{}}, err
+	}
+
+	if _, addressUpdated := updates["address_id"]; addressUpdated {
+		return map[string]Model{"business": &Business{}}, fmt.Errorf("%w: a business's address is set with its own request", ErrInvalidAddress)
+	}
+
+	normalizeTaxLocationUpdate(updates)
+	if jurisdiction, jurisdictionUpdated := updates["tax_jurisdiction"]; jurisdictionUpdated && jurisdiction != nil {
+		if err := validateTaxJurisdiction(db, fmt.Sprint(jurisdiction)); err != nil {
+			return map[string]Model{"business": &Business{}}, err
+		}
+	}
+
 	// Confirm businessID exists in the database and get current object
 	returnRecords, err := business.Get(db, businessID)
 	updateBusiness := returnRecords["business"]
@@ -415,6 +903,28 @@ func (business *Business) Update(db *gorm.DB, businessID uint, updates map[strin
 		return returnRecords, err
 	}
 
+	// Payment terms and late fee settings are checked together, so apply their updates to a copy of the business first
+	termsUpdates := map[string]interface{}{}
+	for _, attribute := range []string{"payment_terms_days", "late_fees_enabled", "late_fee_amount", "late_fee_rate", "late_fee_grace_days"} {
+		if value, attributeUpdated := updates[attribute]; attributeUpdated {
+			termsUpdates[attribute] = value
+		}
+	}
+
+	updatedTerms := *business
+	encodedUpdates, err := json.Marshal(termsUpdates)
+	if err == nil {
+		err = json.Unmarshal(encodedUpdates, &updatedTerms)
+	}
+
+	if err != nil {
+		return returnRecords, fmt.Errorf("%w: %s", ErrInvalidPaymentTerms, err)
+	}
+
+	if err = updatedTerms.validatePaymentTerms(); err != nil {
+		return returnRecords, err
+	}
+
 	err = db.Model(&updateBusiness).Clauses(clause.Returning{}).Where("id = ?", businessID).Updates(updates).Error
 	returnRecords = map[string]Model{"business": updateBusiness}
 
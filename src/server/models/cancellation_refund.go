@@ -0,0 +1,336 @@
+package models
+
+import (
+	"fmt"
+	"server/payments"
+
+	"gorm.io/gorm"
+)
+
+// How much of what was paid for a cancelled Appointment is refunded
+const (
+	RefundPolicyFull    string = "Full"    // Everything paid is refunded (timely cancellations and cancellations by the Business)
+	RefundPolicyPartial string = "Partial" // Everything paid except the Service's cancellation fee is refunded
+	RefundPolicyNone    string = "None"    // Nothing is refunded (the cancellation fee is at least what was paid)
+)
+
+// Breakdown of the refund for a cancelled Appointment (see 'Appointment.RefundCancellation'). Amounts are in the smallest unit of the
+// currency (e.g. cents).
+type CancellationRefund struct {
+	AppointmentID    uint                       `json:"appointment_id"`    // ID of the cancelled Appointment
+	InvoiceID        uint                       `json:"invoice_id"`        // ID of the appointment's Invoice (0 if it was not invoiced)
+	InvoiceStatus    string                     `json:"invoice_status"`    // Status of the invoice after the refund
+	RemainingBalance int                        `json:"remaining_balance"` // Remaining balance of the invoice after the refund (the cancellation fee still owed, if any)
+	Currency         string                     `json:"currency"`          // ISO 4217 currency of the amounts
+	Timely           bool                       `json:"timely"`            // True if the cancellation was made in time (or by the Business)
+	Policy           string                     `json:"policy"`            // How much of what was paid is refunded (Full, Partial, None)
+	AmountPaid       int                        `json:"amount_paid"`       // Amount paid for the appointment before the refund (net of earlier refunds)
+	CancelFee        int                        `json:"cancel_fee"`        // Cancellation fee charged for the appointment (0 if it was cancelled in time)
+	AmountRetained   int                        `json:"amount_retained"`   // Part of the amount paid that the Business keeps
+	AmountRefunded   int                        `json:"amount_refunded"`   // Amount refunded and recorded against the invoice's payments
+	AmountPending    int                        `json:"amount_pending"`    // Amount sent to the PaymentProvider that is recorded once the provider reports the refund succeeded
+	Refunds          []Refund                   `json:"refunds"`           // Recorded refunds, one per refunded payment
+	Error            string                     `json:"error,omitempty"`   // Reason the refund could not be completed (what is left is still owed to the customer)
+	Display          *CancellationRefundDisplay `json:"display,omitempty"` // Amounts formatted for the requester's locale (only set in API responses)
+}
+
+/*
+*Description*
+
+func RefundCancellation
+
+Refunds what was paid for the specified cancelled Appointment according to its cancellation policy, after the cancellation has credited
+the appointment's invoice (see 'billStatusChange').
+
+	Timely cancellation or cancelled by the Business  -->  Full refund
+	Late cancellation  -->  Everything paid beyond the Service's cancellation fee is refunded (a partial refund, or none if the fee is at
+	least what was paid). The fee is retained.
+
+The amount refunded is whatever was paid beyond what the credited invoice still bills, so the invoice ends up Paid when the fee is
+retained, or Refunded when everything was refunded. The most recent payments are refunded first. Payments collected through a
+PaymentProvider are refunded through the provider (and are recorded once it reports that the refund succeeded), and other payments
+are refunded by recording a Refund against them.
+
+The refund is made in a transaction that locks the appointment's invoice, so concurrent refunds of the same cancellation (e.g. by the
+cancel route and the cancellation refund job) can't refund what was paid twice. If a refund fails, the breakdown of what was refunded
+before it failed is returned with the error. What is left is still owed to the
+customer, and the appointment stays marked as waiting for its refund until the cancellation refund job completes it (see
+'RetryCancellationRefunds').
+
+Invoices of Businesses with the None billing policy are not credited when an appointment is cancelled (see 'billStatusChange'), so
+nothing is refunded automatically.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	provider  <payments.PaymentProvider>
+
+		The provider that collects online payments (nil if none is configured).
+
+	apptID  <uint>
+
+		The ID of the cancelled appointment.
+
+*Returns*
+
+	_  <*CancellationRefund>
+
+		Breakdown of the refund.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) RefundCancellation(db *gorm.DB, provider payments.PaymentProvider, apptID uint) (*CancellationRefund, error) {
+	breakdown := &CancellationRefund{AppointmentID: apptID, Refunds: []Refund{}}
+
+	var refundErr error
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.First(appt, apptID).Error
+		if err != nil {
+			return err
+		}
+
+		if !appt.IsCancelled() {
+			return fmt.Errorf("%w: Appointment ID (%d) has not been cancelled", ErrInvalidRefund, apptID)
+		}
+
+		service := &Service{}
+		err = tx.First(service, appt.ServiceID).Error
+		if err != nil {
+			return err
+		}
+		breakdown.Currency = service.Currency
+
+		breakdown.Timely, err = appt.cancellationWasTimely(tx)
+		if err != nil {
+			return err
+		}
+
+		breakdown.Policy = RefundPolicyFull
+		if !breakdown.Timely && service.CancelFee > 0 {
+			breakdown.Policy = RefundPolicyNone
+		}
+
+		// The invoice is locked until the transaction completes, so concurrent refunds of the same cancellation wait for each other and
+		// see what the other refunded (the amounts are only read once the lock is held)
+		invoice, err := appt.GetInvoice(tx)
+		if err != nil {
+			return err
+		} else if invoice == nil {
+			return tx.Model(appt).UpdateColumn("refund_pending", false).Error
+		}
+
+		breakdown.InvoiceID = invoice.ID
+		breakdown.Currency = invoice.Currency
+		breakdown.AmountPaid, err = invoice.GetAmountPaid(tx)
+		if err != nil {
+			return err
+		}
+
+		if !breakdown.Timely && invoice.GetAmountBilled() > 0 {
+			breakdown.CancelFee = invoice.GetAmountBilled()
+		}
+
+		var reason string = fmt.Sprintf("Cancellation: %s", service.Name)
+		if breakdown.CancelFee > 0 {
+			reason = fmt.Sprintf("Late cancellation: %s (cancellation fee retained)", service.Name)
+		}
+
+		refundErr = breakdown.refundPayments(tx, provider, invoice, breakdown.AmountPaid-invoice.GetAmountBilled(), reason)
+
+		breakdown.AmountRetained = breakdown.AmountPaid - breakdown.AmountRefunded - breakdown.AmountPending
+		if breakdown.AmountPaid > 0 {
+			switch {
+			case breakdown.AmountRetained <= 0:
+				breakdown.Policy = RefundPolicyFull
+			case breakdown.AmountRetained < breakdown.AmountPaid:
+				breakdown.Policy = RefundPolicyPartial
+			default:
+				breakdown.Policy = RefundPolicyNone
+			}
+		}
+
+		// The invoice is reported as it is after the refund, and what was refunded before a failure is kept
+		err = tx.First(invoice, invoice.ID).Error
+		breakdown.InvoiceStatus = invoice.Status
+		breakdown.RemainingBalance = invoice.RemainingBalance
+		if err != nil || refundErr != nil {
+			return err
+		}
+
+		// The cancellation has been refunded, so the cancellation refund job no longer retries it
+		return tx.Model(appt).UpdateColumn("refund_pending", false).Error
+	})
+	if refundErr != nil {
+		err = refundErr
+	}
+
+	return breakdown, err
+}
+
+/*
+*Description*
+
+func RetryCancellationRefunds
+
+Retries the refunds of cancelled Appointments that are still waiting for them. An appointment is marked as waiting for its refund in
+the transaction that cancels it (see 'update'), and the mark is cleared once 'RefundCancellation' completes. A cancellation refund
+runs after the cancellation is committed, so a refund that fails (e.g. the PaymentProvider is unavailable) keeps the mark, and is made
+again here. Overpaid invoices that no cancellation refund was started for (e.g. a surplus the Business keeps as store credit) are left
+alone. Refunds sent to the PaymentProvider use the same idempotency key as the original attempt, so a refund the provider already
+accepted is not sent twice.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	provider  <payments.PaymentProvider>
+
+		The provider that collects online payments (nil if none is configured).
+
+*Returns*
+
+	_  <[]*CancellationRefund>
+
+		Breakdowns of the retried refunds (including the ones that failed again, with their 'Error' set).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (appt *Appointment) RetryCancellationRefunds(db *gorm.DB, provider payments.PaymentProvider) ([]*CancellationRefund, error) {
+	breakdowns := []*CancellationRefund{}
+
+	var apptIDs []uint
+	err := db.Model(&Appointment{}).
+		Where("refund_pending = ?", true).
+		Where("status IN ?", []string{AppointmentStatusCancelledByCustomer, AppointmentStatusCancelledByBusiness}).
+		Order("id").
+		Pluck("id", &apptIDs).Error
+	if err != nil {
+		return breakdowns, err
+	}
+
+	for _, apptID := range apptIDs {
+		breakdown, err := (&Appointment{}).RefundCancellation(db, provider, apptID)
+		if err != nil {
+			breakdown.Error = err.Error()
+		}
+
+		breakdowns = append(breakdowns, breakdown)
+	}
+
+	return breakdowns, nil
+}
+
+/*
+*Description*
+
+func refundPayments
+
+Refunds the specified amount from the payments of an Invoice, most recent payment first, and adds the refunds to the calling
+CancellationRefund (see 'Appointment.RefundCancellation').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	provider  <payments.PaymentProvider>
+
+		The provider that collects online payments (nil if none is configured).
+
+	invoice  <*Invoice>
+
+		The invoice whose payments are refunded.
+
+	amount  <int>
+
+		The amount to refund (nothing is refunded if it isn't positive).
+
+	reason  <string>
+
+		The reason recorded with each refund.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (breakdown *CancellationRefund) refundPayments(db *gorm.DB, provider payments.PaymentProvider, invoice *Invoice, amount int, reason string) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	var invoicePayments []Payment
+	err := db.Where("invoice_id = ?", invoice.ID).Order("paid_at DESC, id DESC").Find(&invoicePayments).Error
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range invoicePayments {
+		if amount <= 0 {
+			break
+		}
+
+		refundedAmount, err := payment.GetRefundedAmount(db, payment.ID)
+		if err != nil {
+			return err
+		}
+
+		var paymentRefund int = payment.Amount - refundedAmount
+		if paymentRefund > amount {
+			paymentRefund = amount
+		}
+
+		if paymentRefund <= 0 {
+			continue
+		}
+
+		intent := &PaymentIntent{}
+		collectedOnline, err := intent.GetByPaymentID(db, payment.ID)
+		if err != nil {
+			return err
+		}
+
+		refund := &Refund{PaymentID: payment.ID, Amount: paymentRefund, Reason: reason}
+		if collectedOnline {
+			if provider == nil || provider.Name() != intent.Provider {
+				return fmt.Errorf("%w: Payment ID (%d) was collected through %s, which is not configured", ErrInvalidRefund, payment.ID, intent.Provider)
+			}
+
+			// The key makes a retried cancellation refund send the refund to the provider only once
+			var idempotencyKey string = fmt.Sprintf("appointment-%d-cancellation-payment-%d", breakdown.AppointmentID, payment.ID)
+			returnRecords, err := intent.refund(db, provider, payment.ID, paymentRefund, reason, idempotencyKey)
+			if err != nil {
+				return err
+			}
+			refund = returnRecords["refund"].(*Refund)
+		} else {
+			_, err = refund.Create(db)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Provider refunds that are still pending are recorded by the webhook event that reports them succeeding
+		if refund.ID == 0 {
+			breakdown.AmountPending += paymentRefund
+		} else {
+			breakdown.AmountRefunded += refund.Amount
+			breakdown.Refunds = append(breakdown.Refunds, *refund)
+		}
+
+		amount -= paymentRefund
+	}
+
+	return nil
+}
@@ -0,0 +1,377 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all ClassPack records in the database (packages of prepaid session credits that a Business sells)
+//
+// Each credit pays for one seat at an eligible Service. A ClassPack with no ClassPackService records is eligible for every
+// Service offered by the Business.
+type ClassPack struct {
+	gorm.Model
+	BusinessID uint          `gorm:"column:business_id;not null;index" json:"business_id"` // ID of Business that sells the class pack
+	Name       string        `gorm:"column:name" json:"name"`                              // Class pack name (e.g. "10-class pack")
+	Credits    uint          `gorm:"column:credits;not null" json:"credits"`               // Number of session credits included in the class pack
+	ExpiryDays uint          `gorm:"column:expiry_days" json:"expiry_days"`                // Number of days after purchase that unused credits expire (0 for no expiry)
+	Price      uint          `gorm:"column:price" json:"price"`                            // Price (in cents) of the class pack
+	Currency   string        `gorm:"column:currency;not null;default:USD" json:"currency"` // ISO 4217 currency of the price (defaults to the Business's currency)
+	Display    *PriceDisplay `gorm:"-" json:"display,omitempty"`                           // Price formatted for the requester's locale (only set in API responses)
+}
+
+// GORM model for all ClassPackService records in the database (one record per Service name that a ClassPack's credits can be used for)
+//
+// Services are matched by name, since each scheduled session of a class is a separate Service record.
+type ClassPackService struct {
+	gorm.Model
+	ClassPackID uint   `gorm:"column:class_pack_id;not null;index" json:"class_pack_id"` // ID of ClassPack that the eligible service belongs to
+	ServiceName string `gorm:"column:service_name;not null" json:"service_name"`         // Name of the eligible Service(s)
+}
+
+// Error returned when a ClassPack definition is invalid
+var ErrInvalidClassPack = errors.New("invalid class pack")
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from ClassPack object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the class pack object
+*/
+func (pack *ClassPack) GetID() uint {
+	return pack.ID
+}
+
+/*
+*Description*
+
+func GetExpiry
+
+Returns the time that credits from the calling ClassPack expire if the class pack is purchased at the specified time.
+
+*Parameters*
+
+	purchaseTime  <time.Time>
+
+		The time the class pack is purchased.
+
+*Returns*
+
+	_  <*time.Time>
+
+		The time the purchased credits expire (nil if the credits never expire).
+*/
+func (pack *ClassPack) GetExpiry(purchaseTime time.Time) *time.Time {
+	if pack.ExpiryDays == 0 {
+		return nil
+	}
+
+	expiresAt := purchaseTime.AddDate(0, 0, int(pack.ExpiryDays))
+	return &expiresAt
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new ClassPack record in the database and returns the created record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created ClassPack object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (pack *ClassPack) Create(db *gorm.DB) (map[string]Model, error) {
+	if pack.Credits == 0 {
+		return map[string]Model{"class_pack": pack}, fmt.Errorf("%w: a class pack must include at least one credit", ErrInvalidClassPack)
+	}
+
+	currency, err := resolveCurrency(db, pack.BusinessID, pack.Currency)
+	if err != nil {
+		return map[string]Model{"class_pack": pack}, err
+	}
+	pack.Currency = currency
+
+	err = db.Create(&pack).Error
+	returnRecords := map[string]Model{"class_pack": pack}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a ClassPack record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	packID  <uint>
+
+		The ID of the class pack record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved ClassPack object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (pack *ClassPack) Get(db *gorm.DB, packID uint) (map[string]Model, error) {
+	err := db.First(&pack, packID).Error
+	returnRecords := map[string]Model{"class_pack": pack}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of ClassPack records from the database that are associated with the specified secondary key.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]ClassPack>
+
+		The list of ClassPack records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (pack *ClassPack) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]ClassPack, error) {
+	var packs []ClassPack
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&packs).Error
+	return packs, err
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the specified ClassPack record in the database with the specified changes if the record exists.
+
+Returns the updated record along with any errors that are thrown.
+
+Changes only apply to future purchases. Credits that customers have already purchased keep the terms they were purchased with.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	packID  <uint>
+
+		The ID of the class pack record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated ClassPack object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (pack *ClassPack) Update(db *gorm.DB, packID uint, updates map[string]interface{}) (map[string]Model, error) {
+	updatePack := &ClassPack{}
+	returnRecords := map[string]Model{"class_pack": updatePack}
+
+	if credits, creditsUpdated := updates["credits"]; creditsUpdated && fmt.Sprint(credits) == "0" {
+		return returnRecords, fmt.Errorf("%w: a class pack must include at least one credit", ErrInvalidClassPack)
+	}
+
+	if err := normalizeCurrencyUpdate(updates); err != nil {
+		return returnRecords, err
+	}
+
+	err := db.First(updatePack, packID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Model(updatePack).Clauses(clause.Returning{}).Where("id = ?", packID).Updates(updates).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified ClassPack record from the database if it exists, so that it can no longer be purchased.
+
+Credits that customers have already purchased can still be used. Deleted records are returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted from.
+
+	packID  <uint>
+
+		The ID of the class pack record being deleted.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the deleted ClassPack object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (pack *ClassPack) Delete(db *gorm.DB, packID uint) (map[string]Model, error) {
+	deletePack := &ClassPack{}
+	returnRecords := map[string]Model{"class_pack": deletePack}
+
+	err := db.First(deletePack, packID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Delete(deletePack).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetEligibleServices
+
+Returns the names of the Services that the specified ClassPack's credits can be used for.
+
+An empty list means that the credits can be used for every Service offered by the Business.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	packID  <uint>
+
+		The ID of the class pack.
+
+*Returns*
+
+	_  <[]string>
+
+		The list of eligible Service names.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (pack *ClassPack) GetEligibleServices(db *gorm.DB, packID uint) ([]string, error) {
+	serviceNames := []string{}
+
+	err := db.Model(&ClassPackService{}).Where("class_pack_id = ?", packID).Order("service_name").Pluck("service_name", &serviceNames).Error
+	return serviceNames, err
+}
+
+/*
+*Description*
+
+func SetEligibleServices
+
+Replaces the list of Services that the specified ClassPack's credits can be used for.
+
+An empty list makes the credits eligible for every Service offered by the Business.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be replaced.
+
+	packID  <uint>
+
+		The ID of the class pack.
+
+	serviceNames  <[]string>
+
+		The names of the eligible Services.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (pack *ClassPack) SetEligibleServices(db *gorm.DB, packID uint, serviceNames []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("class_pack_id = ?", packID).Delete(&ClassPackService{}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, serviceName := range serviceNames {
+			err = tx.Create(&ClassPackService{ClassPackID: packID, ServiceName: serviceName}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,611 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all ClassPackPurchase records in the database (one record per ClassPack bought by a User)
+//
+// The number of credits and their expiry are fixed when the class pack is purchased.
+type ClassPackPurchase struct {
+	gorm.Model
+	ClassPackID      uint       `gorm:"column:class_pack_id;not null;index" json:"class_pack_id"` // ID of ClassPack that was purchased
+	UserID           uint       `gorm:"column:user_id;not null;index" json:"user_id"`             // ID of User that purchased the class pack
+	BusinessID       uint       `gorm:"column:business_id;not null" json:"business_id"`           // ID of Business that sold the class pack
+	InvoiceID        uint       `gorm:"column:invoice_id" json:"invoice_id"`                      // ID of Invoice that bills the User for the class pack
+	CreditsPurchased uint       `gorm:"column:credits_purchased" json:"credits_purchased"`        // Number of credits included when the class pack was purchased
+	CreditsRemaining uint       `gorm:"column:credits_remaining" json:"credits_remaining"`        // Number of credits that have not been used
+	ExpiresAt        *time.Time `gorm:"column:expires_at;default:null" json:"expires_at"`         // Date/time when unused credits expire (null if the credits never expire)
+}
+
+// GORM model for all ClassPackCreditTransaction records in the database (one record per change to a ClassPackPurchase's credit balance)
+type ClassPackCreditTransaction struct {
+	gorm.Model
+	ClassPackPurchaseID uint   `gorm:"column:class_pack_purchase_id;not null;index" json:"class_pack_purchase_id"` // ID of ClassPackPurchase whose credits changed
+	AppointmentID       *uint  `gorm:"column:appointment_id;default:null;index" json:"appointment_id"`             // ID of Appointment that used or released the credits (null for purchases)
+	Type                string `gorm:"column:type;not null" json:"type"`                                           // Type of credit change (Purchased, Used, Refunded)
+	Credits             int    `gorm:"column:credits" json:"credits"`                                              // Change in credits (positive for purchases and refunds, negative for usage)
+	CreditsRemaining    uint   `gorm:"column:credits_remaining" json:"credits_remaining"`                          // Credits remaining on the purchase after the change
+}
+
+// Class pack credit transaction types
+const (
+	ClassPackCreditPurchased string = "Purchased" // Credits added by purchasing a class pack
+	ClassPackCreditUsed      string = "Used"      // Credits used to book an appointment
+	ClassPackCreditRefunded  string = "Refunded"  // Credits returned by a timely cancellation
+)
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from ClassPackPurchase object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the class pack purchase object
+*/
+func (purchase *ClassPackPurchase) GetID() uint {
+	return purchase.ID
+}
+
+/*
+*Description*
+
+func IsExpired
+
+Returns whether the calling ClassPackPurchase's credits have expired at the specified time.
+
+*Parameters*
+
+	asOf  <time.Time>
+
+		The time to check.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the credits have expired, else 'false'.
+*/
+func (purchase *ClassPackPurchase) IsExpired(asOf time.Time) bool {
+	return purchase.ExpiresAt != nil && !asOf.Before(*purchase.ExpiresAt)
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new ClassPackPurchase record in the database and returns the created record along with any errors that are thrown.
+
+Class packs sold through the API should be purchased with the 'Purchase' method, which also bills the User and records the credits.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created ClassPackPurchase object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (purchase *ClassPackPurchase) Create(db *gorm.DB) (map[string]Model, error) {
+	err := db.Create(&purchase).Error
+	returnRecords := map[string]Model{"class_pack_purchase": purchase}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a ClassPackPurchase record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	purchaseID  <uint>
+
+		The ID of the class pack purchase record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved ClassPackPurchase object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (purchase *ClassPackPurchase) Get(db *gorm.DB, purchaseID uint) (map[string]Model, error) {
+	err := db.First(&purchase, purchaseID).Error
+	returnRecords := map[string]Model{"class_pack_purchase": purchase}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Update
+
+Credit balances can only change through bookings and cancellations (which are recorded as credit transactions), so this method always returns an error.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		Unused.
+
+	purchaseID  <uint>
+
+		Unused.
+
+	updates  <map[string]interface{}>
+
+		Unused.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Error stating that class pack purchases cannot be modified.
+*/
+func (purchase *ClassPackPurchase) Update(db *gorm.DB, purchaseID uint, updates map[string]interface{}) (map[string]Model, error) {
+	return map[string]Model{}, errors.New("class pack purchases cannot be modified")
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified ClassPackPurchase record from the database if it exists.
+
+Deleted records are returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted from.
+
+	purchaseID  <uint>
+
+		The ID of the class pack purchase record being deleted.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the deleted ClassPackPurchase object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (purchase *ClassPackPurchase) Delete(db *gorm.DB, purchaseID uint) (map[string]Model, error) {
+	deletePurchase := &ClassPackPurchase{}
+	returnRecords := map[string]Model{"class_pack_purchase": deletePurchase}
+
+	err := db.First(deletePurchase, purchaseID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Delete(deletePurchase).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Purchase
+
+Sells the specified ClassPack to the specified User.
+
+An Invoice is created for the price of the class pack (plus the Business's sales tax, see 'applySalesTax'), the User is credited with
+the class pack's credits, and the purchase is recorded in the credit history. All records are created in the same transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	packID  <uint>
+
+		The ID of the ClassPack being purchased.
+
+	userID  <uint>
+
+		The ID of the User purchasing the class pack.
+
+	purchaseTime  <time.Time>
+
+		The time of the purchase (normally the current time), which the credits' expiry is based on.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the created ClassPackPurchase and Invoice objects.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (purchase *ClassPackPurchase) Purchase(db *gorm.DB, packID uint, userID uint, purchaseTime time.Time) (map[string]Model, error) {
+	returnRecords := map[string]Model{"class_pack_purchase": purchase}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		pack := &ClassPack{}
+		err := tx.First(pack, packID).Error
+		if err != nil {
+			return fmt.Errorf("Class Pack ID (%d) does not exist in the database.  [%w]", packID, err)
+		}
+
+		invoice := &Invoice{UserID: userID, BusinessID: pack.BusinessID, Currency: pack.Currency}
+		lineItems := []InvoiceLineItem{{Description: fmt.Sprintf("%s (%d credits)", pack.Name, pack.Credits), Quantity: 1, UnitPrice: int(pack.Price), Currency: pack.Currency}}
+
+		err = applySalesTax(tx, pack.BusinessID, false, lineItems)
+		if err != nil {
+			return err
+		}
+
+		err = invoice.createIssued(tx, lineItems)
+		if err != nil {
+			return err
+		}
+		returnRecords["invoice"] = invoice
+
+		*purchase = ClassPackPurchase{
+			ClassPackID:      pack.ID,
+			UserID:           userID,
+			BusinessID:       pack.BusinessID,
+			InvoiceID:        invoice.ID,
+			CreditsPurchased: pack.Credits,
+			CreditsRemaining: pack.Credits,
+			ExpiresAt:        pack.GetExpiry(purchaseTime),
+		}
+
+		_, err = purchase.Create(tx)
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&ClassPackCreditTransaction{
+			ClassPackPurchaseID: purchase.ID,
+			Type:                ClassPackCreditPurchased,
+			Credits:             int(pack.Credits),
+			CreditsRemaining:    purchase.CreditsRemaining,
+		}).Error
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetEligiblePurchase
+
+Finds the specified User's ClassPackPurchase that should pay for an appointment at the specified Service.
+
+A purchase is eligible if it was sold by the Service's Business, has enough credits left for the appointment's seats, has not expired
+by the time the Service starts, and its ClassPack is eligible for the Service. If several purchases are eligible, the one that expires
+first is used. The purchase record is locked until the transaction completes.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) that will be queried.
+
+	userID  <uint>
+
+		The ID of the User booking the appointment.
+
+	service  <*Service>
+
+		The Service being booked.
+
+	seats  <uint>
+
+		The number of seats (credits) needed.
+
+*Returns*
+
+	_  <*ClassPackPurchase>
+
+		The eligible ClassPackPurchase (nil if the User has no eligible credits).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (purchase *ClassPackPurchase) GetEligiblePurchase(db *gorm.DB, userID uint, service *Service, seats uint) (*ClassPackPurchase, error) {
+	var eligiblePurchases []ClassPackPurchase
+
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND business_id = ? AND credits_remaining >= ?", userID, service.BusinessID, seats).
+		Where("(expires_at IS NULL OR expires_at > ?)", service.StartDateTime).
+		Where(`(NOT EXISTS (SELECT 1 FROM class_pack_services WHERE class_pack_services.class_pack_id = class_pack_purchases.class_pack_id)
+			OR EXISTS (SELECT 1 FROM class_pack_services WHERE class_pack_services.class_pack_id = class_pack_purchases.class_pack_id AND class_pack_services.service_name = ?))`, service.Name).
+		Order("expires_at ASC NULLS LAST, id").
+		Limit(1).
+		Find(&eligiblePurchases).Error
+
+	if err != nil || len(eligiblePurchases) == 0 {
+		return nil, err
+	}
+
+	return &eligiblePurchases[0], nil
+}
+
+/*
+*Description*
+
+func UseCredits
+
+Pays for the specified number of seats on the specified Appointment with credits from the User's eligible ClassPackPurchase
+(one credit per seat), if the User has one.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+	appt  <*Appointment>
+
+		The Appointment being booked.
+
+	service  <*Service>
+
+		The Service that the Appointment is for.
+
+	seats  <uint>
+
+		The number of seats to pay for.
+
+*Returns*
+
+	_  <*ClassPackPurchase>
+
+		The ClassPackPurchase that paid for the Appointment (nil if the User has no eligible credits).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (purchase *ClassPackPurchase) UseCredits(db *gorm.DB, appt *Appointment, service *Service, seats uint) (*ClassPackPurchase, error) {
+	if seats == 0 {
+		return nil, nil
+	}
+
+	eligiblePurchase, err := purchase.GetEligiblePurchase(db, appt.UserID, service, seats)
+	if err != nil || eligiblePurchase == nil {
+		return nil, err
+	}
+
+	err = eligiblePurchase.changeCredits(db, appt.ID, ClassPackCreditUsed, -int(seats))
+	return eligiblePurchase, err
+}
+
+/*
+*Description*
+
+func RefundCredits
+
+Returns up to the specified number of credits that the specified Appointment used, if the cancellation is timely.
+
+Cancellations by the Business are always refunded. Cancellations by the customer are refunded if they are made with at least the
+notice required by the BookingRule that applies to the Service (see 'BookingRule.CancellationIsTimely'). Appointments that were not
+paid for with class pack credits are ignored.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+	appt  <*Appointment>
+
+		The cancelled Appointment (or the Appointment whose guest was cancelled).
+
+	seats  <uint>
+
+		The number of seats that were cancelled.
+
+	cancelTime  <time.Time>
+
+		The time of the cancellation.
+
+*Returns*
+
+	_  <int>
+
+		The number of credits refunded.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (purchase *ClassPackPurchase) RefundCredits(db *gorm.DB, appt *Appointment, seats uint, cancelTime time.Time) (int, error) {
+	var usage struct {
+		ClassPackPurchaseID uint
+		Credits             int
+	}
+
+	// Net credits used by the appointment (usage minus earlier refunds)
+	err := db.Model(&ClassPackCreditTransaction{}).
+		Select("class_pack_purchase_id, -SUM(credits) AS credits").
+		Where("appointment_id = ?", appt.ID).
+		Group("class_pack_purchase_id").
+		Scan(&usage).Error
+	if err != nil || usage.Credits <= 0 {
+		return 0, err
+	}
+
+	timely, err := appt.CancellationIsTimely(db, cancelTime)
+	if err != nil || !timely {
+		return 0, err
+	}
+
+	var refundCredits int = usage.Credits
+	if int(seats) < refundCredits {
+		refundCredits = int(seats)
+	}
+
+	refundPurchase := &ClassPackPurchase{}
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).First(refundPurchase, usage.ClassPackPurchaseID).Error
+	if err != nil {
+		return 0, err
+	}
+
+	err = refundPurchase.changeCredits(db, appt.ID, ClassPackCreditRefunded, refundCredits)
+	return refundCredits, err
+}
+
+/*
+*Description*
+
+func changeCredits
+
+Adds the specified number of credits to the calling ClassPackPurchase (negative to use credits) and records the change in the credit history.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+	apptID  <uint>
+
+		The ID of the Appointment that caused the change.
+
+	transactionType  <string>
+
+		The type of credit change (see the 'ClassPackCredit*' constants).
+
+	credits  <int>
+
+		The change in credits.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (purchase *ClassPackPurchase) changeCredits(db *gorm.DB, apptID uint, transactionType string, credits int) error {
+	purchase.CreditsRemaining = uint(int(purchase.CreditsRemaining) + credits)
+
+	err := db.Model(purchase).Update("credits_remaining", purchase.CreditsRemaining).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Create(&ClassPackCreditTransaction{
+		ClassPackPurchaseID: purchase.ID,
+		AppointmentID:       &apptID,
+		Type:                transactionType,
+		Credits:             credits,
+		CreditsRemaining:    purchase.CreditsRemaining,
+	}).Error
+}
+
+/*
+*Description*
+
+func GetCreditBalance
+
+Returns the specified User's class pack credit balance: the number of usable credits, the User's class pack purchases, and the
+history of credit changes across all purchases (oldest to newest).
+
+Credits on expired purchases are not counted as usable.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	userID  <uint>
+
+		The ID of the User.
+
+	asOf  <time.Time>
+
+		The time the balance is calculated for (normally the current time).
+
+*Returns*
+
+	_  <uint>
+
+		The number of usable credits.
+
+	_  <[]ClassPackPurchase>
+
+		The User's class pack purchases.
+
+	_  <[]ClassPackCreditTransaction>
+
+		The history of credit changes.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (purchase *ClassPackPurchase) GetCreditBalance(db *gorm.DB, userID uint, asOf time.Time) (uint, []ClassPackPurchase, []ClassPackCreditTransaction, error) {
+	purchases := []ClassPackPurchase{}
+	history := []ClassPackCreditTransaction{}
+
+	err := db.Where("user_id = ?", userID).Order("id").Find(&purchases).Error
+	if err != nil {
+		return 0, purchases, history, err
+	}
+
+	var creditsAvailable uint = 0
+	for _, userPurchase := range purchases {
+		if !userPurchase.IsExpired(asOf) {
+			creditsAvailable += userPurchase.CreditsRemaining
+		}
+	}
+
+	err = db.Joins("JOIN class_pack_purchases ON class_pack_purchases.id = class_pack_credit_transactions.class_pack_purchase_id").
+		Where("class_pack_purchases.user_id = ?", userID).
+		Order("class_pack_credit_transactions.created_at, class_pack_credit_transactions.id").
+		Find(&history).Error
+
+	return creditsAvailable, purchases, history, err
+}
@@ -1,8 +1,12 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"server/config"
+	"server/geocoding"
+	"strings"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -21,13 +25,18 @@ type ContactInfo struct {
 // GORM model for all Address records in the database
 type Address struct {
 	gorm.Model
-	Address1 string `gorm:"not null;column:address1" json:"address1"` // Address line 1
-	Address2 string `gorm:"column:address2" json:"address2"`          // Address line 2
-	City     string `gorm:"not null;column:city" json:"city"`         // City
-	State    string `gorm:"not null;column:state" json:"state"`       // State (2 letter abbreviation)
-	ZipCode  string `gorm:"not null;column:zip" json:"zip"`           // Zip code
+	Address1  string   `gorm:"not null;column:address1" json:"address1"`                       // Address line 1
+	Address2  string   `gorm:"column:address2" json:"address2"`                                // Address line 2
+	City      string   `gorm:"not null;column:city" json:"city"`                               // City
+	State     string   `gorm:"not null;column:state" json:"state"`                             // State (2 letter abbreviation)
+	ZipCode   string   `gorm:"not null;column:zip" json:"zip"`                                 // Zip code
+	Latitude  *float64 `gorm:"column:latitude;index:idx_addresses_location" json:"latitude"`   // Latitude in degrees (nil if the address hasn't been geocoded)
+	Longitude *float64 `gorm:"column:longitude;index:idx_addresses_location" json:"longitude"` // Longitude in degrees (nil if the address hasn't been geocoded)
 }
 
+// Error returned when an Address is missing required fields or has invalid coordinates
+var ErrInvalidAddress = errors.New("invalid address")
+
 /*
 *Description*
 
@@ -136,6 +145,76 @@ func (address *Address) GetID() uint {
 /*
 *Description*
 
+func validate
+
+Confirms that the calling Address has every required field, and that its coordinates are either both set and in range or both unset.
+Surrounding whitespace is trimmed from its fields and its state is upper cased.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidAddress' if the address is invalid (nil otherwise)
+*/
+func (address *Address) validate() error {
+	address.Address1 = strings.TrimSpace(address.Address1)
+	address.Address2 = strings.TrimSpace(address.Address2)
+	address.City = strings.TrimSpace(address.City)
+	address.State = strings.ToUpper(strings.TrimSpace(address.State))
+	address.ZipCode = strings.TrimSpace(address.ZipCode)
+
+	if address.Address1 == "" || address.City == "" || address.State == "" || address.ZipCode == "" {
+		return fmt.Errorf("%w: address1, city, state and zip are required", ErrInvalidAddress)
+	}
+
+	if (address.Latitude == nil) != (address.Longitude == nil) {
+		return fmt.Errorf("%w: latitude and longitude must be set together", ErrInvalidAddress)
+	}
+
+	if address.Latitude != nil {
+		coordinates := geocoding.Coordinates{Latitude: *address.Latitude, Longitude: *address.Longitude}
+		if err := coordinates.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAddress, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func Location
+
+Returns the calling Address as a location that can be geocoded.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <geocoding.Location>
+
+		The address's location.
+*/
+func (address *Address) Location() geocoding.Location {
+	return geocoding.Location{
+		Address1: address.Address1,
+		Address2: address.Address2,
+		City:     address.City,
+		State:    address.State,
+		ZipCode:  address.ZipCode,
+	}
+}
+
+/*
+*Description*
+
 func Create
 
 Creates a new Address record in the database and returns the created record along with any errors that are thrown.
@@ -0,0 +1,321 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all CreditNote records in the database (one record per correction to an issued Invoice)
+//
+// Issued invoices can't be changed or deleted, so anything they bill by mistake (or no longer bill, e.g. after a cancellation) is
+// credited with a credit note. Credit notes are numbered in their own gapless sequence for each Business, and are never modified once
+// they are issued. The amount credited is taken off the invoice's balance (see 'Invoice.GetAmountBilled').
+type CreditNote struct {
+	gorm.Model
+	InvoiceID  uint               `gorm:"column:invoice_id;not null;index" json:"invoice_id"`                                 // ID of Invoice that the credit note corrects
+	BusinessID uint               `gorm:"column:business_id;uniqueIndex:idx_credit_notes_business_number" json:"business_id"` // ID of Business that issued the invoice and the credit note
+	UserID     uint               `gorm:"column:user_id;index" json:"user_id"`                                                // ID of User billed by the invoice
+	Number     string             `gorm:"column:number;not null;uniqueIndex:idx_credit_notes_business_number" json:"number"`  // Credit note number, unique and gapless for each Business (e.g. CN-000007)
+	Sequence   uint               `gorm:"column:sequence;not null" json:"sequence"`                                           // Position of the credit note in its Business's sequence of credit note numbers
+	Amount     int                `gorm:"column:amount;not null" json:"amount"`                                               // Amount credited (in cents), including tax
+	Tax        int                `gorm:"column:tax" json:"tax"`                                                              // Part of the amount that credits tax charged on the invoice (in cents)
+	Reason     string             `gorm:"column:reason;not null" json:"reason"`                                               // Reason for the credit (shown on the invoice)
+	IssuedAt   time.Time          `gorm:"column:issued_at;not null" json:"issued_at"`                                         // Date/time when the credit note was issued
+	Currency   string             `gorm:"column:currency;not null;default:USD" json:"currency"`                               // ISO 4217 currency of the credit note (the Invoice's currency)
+	Display    *CreditNoteDisplay `gorm:"-" json:"display,omitempty"`                                                         // Amounts formatted for the requester's locale (only set in API responses)
+}
+
+// Error returned when a CreditNote cannot be issued for an Invoice
+var ErrInvalidCreditNote = errors.New("invalid credit note")
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from CreditNote object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the credit note object
+*/
+func (creditNote *CreditNote) GetID() uint {
+	return creditNote.ID
+}
+
+/*
+*Description*
+
+func Create
+
+Issues a credit note that takes the specified amount off an issued Invoice's balance, and returns the created CreditNote along with
+the updated Invoice.
+
+The amount defaults to everything the invoice still bills, and can't be more than that. Crediting an invoice in full before anything
+was paid voids it. If the invoice has already been paid, the credit leaves it overpaid, and the difference can be refunded (see
+'Refund.Create').
+
+The Invoice record is locked while the credit note is issued. Draft invoices can be changed directly, so they can't be credited.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the created CreditNote object and the updated Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (creditNote *CreditNote) Create(db *gorm.DB) (map[string]Model, error) {
+	invoice := &Invoice{}
+	returnRecords := map[string]Model{"credit_note": creditNote, "invoice": invoice}
+
+	if creditNote.Amount < 0 {
+		return returnRecords, fmt.Errorf("%w: amount must be greater than 0", ErrInvalidCreditNote)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(invoice, creditNote.InvoiceID).Error
+		if err != nil {
+			return err
+		}
+
+		return creditNote.issue(tx, invoice)
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func issue
+
+Issues the calling CreditNote against the specified Invoice and derives the invoice's balance again (see 'Create').
+
+The Invoice record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be created and updated.
+
+	invoice  <*Invoice>
+
+		The invoice being credited.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (creditNote *CreditNote) issue(db *gorm.DB, invoice *Invoice) error {
+	if invoice.State != InvoiceStateIssued {
+		return fmt.Errorf("%w: Invoice ID (%d) is a draft and can be changed directly", ErrInvalidCreditNote, invoice.ID)
+	}
+
+	if invoice.Status == InvoiceStatusVoid || invoice.Status == InvoiceStatusRefunded {
+		return fmt.Errorf("%w: Invoice %s is %s", ErrInvalidCreditNote, invoice.Number, strings.ToLower(invoice.Status))
+	}
+
+	creditNote.Reason = strings.TrimSpace(creditNote.Reason)
+	if creditNote.Reason == "" {
+		return fmt.Errorf("%w: a reason is required", ErrInvalidCreditNote)
+	}
+
+	var creditableAmount int = invoice.GetAmountBilled()
+	if creditNote.Amount == 0 {
+		creditNote.Amount = creditableAmount
+	}
+
+	if creditNote.Amount <= 0 || creditNote.Amount > creditableAmount {
+		return fmt.Errorf("%w: %d of Invoice %s can be credited, but %d was requested", ErrInvalidCreditNote, creditableAmount, invoice.Number, creditNote.Amount)
+	}
+
+	// Tax is credited in proportion to the share of the invoice that is credited (rounded half up to the nearest cent)
+	creditNote.Tax = 0
+	if invoice.OriginalBalance > 0 {
+		var numerator int64 = int64(creditNote.Amount) * int64(invoice.TaxTotal)
+		creditNote.Tax = int((2*numerator + int64(invoice.OriginalBalance)) / (2 * int64(invoice.OriginalBalance)))
+	}
+
+	sequence, number, err := nextDocumentNumber(db, invoice.BusinessID, DocumentCreditNote)
+	if err != nil {
+		return err
+	}
+
+	creditNote.ID = 0
+	creditNote.InvoiceID = invoice.ID
+	creditNote.BusinessID = invoice.BusinessID
+	creditNote.UserID = invoice.UserID
+	creditNote.Number = number
+	creditNote.Sequence = sequence
+	creditNote.Currency = invoice.Currency
+	if creditNote.IssuedAt.IsZero() {
+		creditNote.IssuedAt = time.Now()
+	}
+
+	err = db.Create(creditNote).Error
+	if err != nil {
+		return err
+	}
+
+	return invoice.applyLedger(db)
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a CreditNote record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	creditNoteID  <uint>
+
+		The ID of the credit note record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved CreditNote object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (creditNote *CreditNote) Get(db *gorm.DB, creditNoteID uint) (map[string]Model, error) {
+	err := db.First(&creditNote, creditNoteID).Error
+	returnRecords := map[string]Model{"credit_note": creditNote}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of CreditNote records from the database that are associated with the specified secondary key (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]CreditNote>
+
+		The list of CreditNote records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (creditNote *CreditNote) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]CreditNote, error) {
+	creditNotes := []CreditNote{}
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&creditNotes).Error
+	return creditNotes, err
+}
+
+/*
+*Description*
+
+func Update
+
+Credit notes cannot be modified once they are issued (issue another credit note to correct the invoice further).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	creditNoteID  <uint>
+
+		N/A
+
+	updates  <map[string]interface{}>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (creditNote *CreditNote) Update(db *gorm.DB, creditNoteID uint, updates map[string]interface{}) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: credit notes cannot be modified once they are issued", ErrInvalidCreditNote)
+}
+
+/*
+*Description*
+
+func Delete
+
+Credit notes cannot be deleted once they are issued, so that their numbers have no gaps.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	creditNoteID  <uint>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (creditNote *CreditNote) Delete(db *gorm.DB, creditNoteID uint) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: credit notes cannot be deleted once they are issued", ErrInvalidCreditNote)
+}
@@ -4,13 +4,23 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"server/money"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v7"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// Namespaces of the transaction-level advisory locks taken by the models. Advisory locks are taken with the two-key form
+// (namespace, ID), so locks on the IDs of different kinds of records never contend with each other (see 'advisoryLock')
+const (
+	bookingLockNamespace int32 = 1 // Bookings made by a User (keyed by the User's ID, see 'Appointment.Book')
+)
+
 /*
 *Description*
 
@@ -131,13 +141,650 @@ Executes gorm AutoMigrate function for all DB models.
 	N/A (None)
 */
 func setupTables(db *gorm.DB) {
+	err := migrateInvoiceAppointmentIDColumn(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
 	db.AutoMigrate(
 		&User{},
+		&Address{},
 		&Business{},
 		&Service{},
 		&Appointment{},
+		&AppointmentStatusHistory{},
+		&AppointmentGuest{},
+		&BookingRule{},
+		&ClassPack{},
+		&ClassPackService{},
+		&ClassPackPurchase{},
+		&ClassPackCreditTransaction{},
+		&MembershipPlan{},
+		&MembershipPlanService{},
+		&Subscription{},
+		&SubscriptionInvoice{},
+		&SubscriptionVisit{},
 		&Invoice{},
+		&InvoiceLineItem{},
+		&CreditNote{},
+		&DocumentSequence{},
+		&InvoiceReminder{},
+		&Payment{},
+		&Refund{},
+		&PaymentIntent{},
+		&PaymentEvent{},
+		&PromoCode{},
+		&PromoCodeService{},
+		&PromoCodeRedemption{},
+		&TaxRate{},
+		&StoreCreditAccount{},
+		&StoreCreditTransaction{},
+		&GiftCard{},
+		&GiftCardTransaction{},
+		&AccountingExport{},
 	)
+
+	err = migrateAppointmentActiveToStatus(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
+	// Bookings rely on the unique index to prevent duplicate appointments, so the server can't run without it
+	err = migrateDuplicateHeldAppointments(db)
+	if err != nil {
+		log.Fatalf("ERROR:  Duplicate appointments could not be resolved.  [%s]", err)
+	}
+
+	err = createAppointmentIndexes(db)
+	if err != nil {
+		log.Fatalf("ERROR:  Appointment indexes could not be created.  [%s]", err)
+	}
+
+	err = migrateInvoiceBusinessIDs(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
+	err = migrateCurrencies(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
+	err = migrateInvoiceNumbers(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
+	err = migrateInvoiceDueDates(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
+	err = createInvoiceIndexes(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
+	err = createPromoCodeIndexes(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
+	err = createTaxRateIndexes(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+
+	err = createSearchIndexes(db)
+	if err != nil {
+		log.Printf("ERROR:  %s", err)
+	}
+}
+
+/*
+*Description*
+
+func createAppointmentIndexes
+
+Creates the indexes for the appointments table that can't be declared with gorm struct tags.
+
+A partial unique index on (user_id, service_id) ensures that a User can hold at most one Appointment that has not been cancelled
+for each Service. Cancelled appointments are excluded, so a User can re-book a Service after cancelling. Existing duplicates must be
+resolved before the index is created (see 'migrateDuplicateHeldAppointments').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the indexes will be created.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func createAppointmentIndexes(db *gorm.DB) error {
+	// Index predicates can't use bind parameters, so the (constant) statuses are quoted directly
+	quotedStatuses := make([]string, len(seatHoldingAppointmentStatuses))
+	for i, status := range seatHoldingAppointmentStatuses {
+		quotedStatuses[i] = fmt.Sprintf("'%s'", status)
+	}
+
+	return db.Exec(fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS idx_appointments_user_service_held
+		ON appointments (user_id, service_id)
+		WHERE deleted_at IS NULL AND status IN (%s)`, strings.Join(quotedStatuses, ", "))).Error
+}
+
+/*
+*Description*
+
+func createInvoiceIndexes
+
+Creates the indexes for the invoices table that can't be declared with gorm struct tags.
+
+A partial unique index on (business_id, number) ensures that no two issued invoices of a Business share a number. Drafts have no number
+yet, so they are excluded. A partial unique index on late_fee_for_id ensures that an overdue invoice is charged one late fee at most (see
+'Invoice.ApplyLateFees'). A partial unique index on appointment_id ensures that an appointment has one invoice that is not void or
+refunded at most (see 'Invoice.Create'). A partial index on the invoices that are still owed keeps the receivables report fast however many invoices
+have been paid (see 'Business.GetReceivablesReport').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the indexes will be created.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func createInvoiceIndexes(db *gorm.DB) error {
+	err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_business_number
+		ON invoices (business_id, number)
+		WHERE number IS NOT NULL AND number <> ''`).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_late_fee_for
+		ON invoices (late_fee_for_id)
+		WHERE late_fee_for_id <> 0 AND deleted_at IS NULL`).Error
+	if err != nil {
+		return err
+	}
+
+	// Index predicates can't use bind parameters, so the (constant) statuses are quoted directly
+	err = db.Exec(fmt.Sprintf(`CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_appointment_live
+		ON invoices (appointment_id)
+		WHERE appointment_id <> 0 AND status NOT IN ('%s', '%s') AND deleted_at IS NULL`, InvoiceStatusVoid, InvoiceStatusRefunded)).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_invoices_receivables
+		ON invoices (business_id, user_id, currency)
+		INCLUDE (status, due_at, remaining_balance)
+		WHERE state = 'Issued' AND remaining_balance > 0 AND deleted_at IS NULL`).Error
+}
+
+/*
+*Description*
+
+func createPromoCodeIndexes
+
+Creates the indexes for the promo_codes table that can't be declared with gorm struct tags.
+
+A partial unique index on (business_id, code) ensures that a Business can't have two promo codes with the same code. Deleted promo codes
+are excluded, so a Business can reuse the code of a promotion it has ended.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the indexes will be created.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func createPromoCodeIndexes(db *gorm.DB) error {
+	return db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_codes_business_code
+		ON promo_codes (business_id, code)
+		WHERE deleted_at IS NULL`).Error
+}
+
+/*
+*Description*
+
+func createTaxRateIndexes
+
+Creates the indexes for the tax_rates table that can't be declared with gorm struct tags.
+
+A partial unique index on jurisdiction ensures that each jurisdiction code has one rate, and a partial unique index on (state, zip)
+ensures that a Business's state and zip code can't match two rates (see 'Business.GetTaxRate'). Rates that only apply to Businesses that
+choose their jurisdiction have no state, so they are excluded from the second index. Deleted rates are excluded from both.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the indexes will be created.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func createTaxRateIndexes(db *gorm.DB) error {
+	err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rates_jurisdiction
+		ON tax_rates (jurisdiction)
+		WHERE deleted_at IS NULL`).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rates_state_zip
+		ON tax_rates (state, zip)
+		WHERE state <> '' AND deleted_at IS NULL`).Error
+}
+
+/*
+*Description*
+
+func createSearchIndexes
+
+Creates the full-text search index of the services and businesses tables (see 'Search').
+
+Each table gets a 'search_vector' column that Postgres generates from the searched text whenever a record is created or changed, so the
+index can't fall out of sync with the records. A Service's name is weighted above its description. GIN indexes on the columns keep
+searches fast.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the indexes will be created.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func createSearchIndexes(db *gorm.DB) error {
+	// Generated columns can't use bind parameters, so the (constant) configuration is quoted directly
+	err := db.Exec(fmt.Sprintf(`ALTER TABLE services ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (
+			setweight(to_tsvector('%[1]s', COALESCE(name, '')), 'A') ||
+			setweight(to_tsvector('%[1]s', COALESCE("desc", '')), 'B')
+		) STORED`, searchConfiguration)).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(fmt.Sprintf(`ALTER TABLE businesses ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('%s', COALESCE(name, ''))) STORED`, searchConfiguration)).Error
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_services_search ON services USING GIN (search_vector)`).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`CREATE INDEX IF NOT EXISTS idx_businesses_search ON businesses USING GIN (search_vector)`).Error
+}
+
+/*
+*Description*
+
+func isUniqueViolation
+
+Returns whether the specified error was caused by a unique constraint/index violation in the database.
+
+*Parameters*
+
+	err  <error>
+
+		The error returned by a database operation.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the error is a unique violation, else 'false'.
+*/
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+/*
+*Description*
+
+func advisoryLock
+
+Takes a transaction-level advisory lock on the specified ID within the specified namespace. The lock is held until the transaction
+completes. IDs are folded into the lock's 32-bit key, so IDs beyond 2^31 may share a lock with a smaller ID (which only makes them
+wait for each other).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) that takes the lock.
+
+	namespace  <int32>
+
+		The namespace of the lock (see 'bookingLockNamespace').
+
+	id  <uint>
+
+		The ID of the record being locked.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func advisoryLock(db *gorm.DB, namespace int32, id uint) error {
+	return db.Exec("SELECT pg_advisory_xact_lock(CAST(? AS integer), CAST(? AS integer))", namespace, int32(id%(1<<31))).Error
+}
+
+/*
+*Description*
+
+func migrateInvoiceAppointmentIDColumn
+
+Renames the legacy 'user_id' column of the invoices table to 'appointment_id'. The column has always held the ID of the
+Appointment record that an Invoice is associated with, so existing values are kept as they are.
+
+This changes the Invoice API: the appointment is now sent and returned as 'appointment_id', and 'user_id' is the User billed by the
+invoice (see the breaking change note in '_documentation/api-endpoints.md').
+
+The migration is skipped if the invoices table does not exist yet or already has an 'appointment_id' column.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the invoices table will be migrated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func migrateInvoiceAppointmentIDColumn(db *gorm.DB) error {
+	var legacyColumnName string = "user_id"
+	var columnName string = "appointment_id"
+
+	migrator := db.Migrator()
+	if !migrator.HasTable(&Invoice{}) || migrator.HasColumn(&Invoice{}, columnName) || !migrator.HasColumn(&Invoice{}, legacyColumnName) {
+		return nil
+	}
+
+	return migrator.RenameColumn(&Invoice{}, legacyColumnName, columnName)
+}
+
+/*
+*Description*
+
+func migrateAppointmentActiveToStatus
+
+Migrates existing Appointment records from the legacy 'active' boolean column to the 'status' column.
+
+Active appointments keep the default 'Confirmed' status and inactive appointments are moved to 'Cancelled By Customer'.
+Each migrated appointment gets an initial status history record. The legacy 'active' column is dropped once the migration completes.
+
+The migration is skipped if the 'active' column no longer exists in the appointments table.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the appointments table will be migrated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func migrateAppointmentActiveToStatus(db *gorm.DB) error {
+	var legacyColumnName string = "active"
+	if !db.Migrator().HasColumn(&Appointment{}, legacyColumnName) {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("UPDATE appointments SET status = CASE WHEN active THEN ? ELSE ? END",
+			AppointmentStatusConfirmed,
+			AppointmentStatusCancelledByCustomer).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO appointment_status_histories (created_at, updated_at, appointment_id, from_status, to_status, reason)
+			SELECT NOW(), NOW(), id, '', status, ? FROM appointments`, "Migrated from legacy 'active' flag").Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropColumn(&Appointment{}, legacyColumnName)
+	})
+}
+
+/*
+*Description*
+
+func migrateDuplicateHeldAppointments
+
+Resolves Appointment records that were booked before a User was limited to one held appointment per Service, so that the unique index
+on (user_id, service_id) can be created (see 'createAppointmentIndexes').
+
+For each User and Service with more than one appointment that holds seats, the earliest appointment is kept and the others are moved
+to 'Cancelled By Business' (with a status history record explaining why). The seat counts of the affected services are then recounted.
+
+The migration only changes duplicate appointments, so it can safely run every time the tables are set up.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the appointments table will be migrated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func migrateDuplicateHeldAppointments(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var duplicates []Appointment
+		err := tx.Raw(`SELECT * FROM (
+				SELECT appointments.*, ROW_NUMBER() OVER (PARTITION BY user_id, service_id ORDER BY created_at, id) AS held_rank
+				FROM appointments
+				WHERE deleted_at IS NULL AND status IN ?
+			) held
+			WHERE held_rank > 1`, seatHoldingAppointmentStatuses).Scan(&duplicates).Error
+		if err != nil || len(duplicates) == 0 {
+			return err
+		}
+
+		var cancelTime time.Time = time.Now()
+		serviceIDs := map[uint]bool{}
+		for _, duplicate := range duplicates {
+			err = tx.Model(&Appointment{}).Where("id = ?", duplicate.ID).Updates(map[string]interface{}{
+				"status":           AppointmentStatusCancelledByBusiness,
+				"cancel_date_time": cancelTime,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			history := AppointmentStatusHistory{
+				AppointmentID: duplicate.ID,
+				FromStatus:    duplicate.Status,
+				ToStatus:      AppointmentStatusCancelledByBusiness,
+				Reason:        "Duplicate booking of the same service",
+			}
+
+			_, err = history.Create(tx)
+			if err != nil {
+				return err
+			}
+
+			serviceIDs[duplicate.ServiceID] = true
+		}
+
+		for serviceID := range serviceIDs {
+			err = tx.Exec(`UPDATE services SET
+					appt_ct = held.seats,
+					is_full = held.seats >= services.capacity
+				FROM (
+					SELECT COALESCE(SUM(seats), 0) AS seats FROM appointments
+					WHERE service_id = ? AND deleted_at IS NULL AND status IN ?
+				) held
+				WHERE services.id = ?`, serviceID, seatHoldingAppointmentStatuses, serviceID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		log.Printf("Cancelled %d duplicate appointment(s) before creating the appointment indexes.", len(duplicates))
+		return nil
+	})
+}
+
+/*
+*Description*
+
+func migrateCurrencies
+
+Marks the currency of amounts that were recorded before currencies were tracked. Prices, invoices, payments and refunds were all
+recorded in 'money.DefaultCurrency' until then, so existing amounts (in cents) are kept as they are and any record without a
+currency is given the default currency.
+
+The migration only changes records without a currency, so it can safely run every time the tables are set up.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the tables will be migrated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func migrateCurrencies(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&Business{}, &Service{}, &ClassPack{}, &MembershipPlan{}, &Invoice{}, &InvoiceLineItem{}, &Payment{}, &Refund{}} {
+			err := tx.Session(&gorm.Session{SkipHooks: true}).Model(model).Unscoped().Where("currency IS NULL OR currency = ''").Update("currency", money.DefaultCurrency).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+/*
+*Description*
+
+func migrateInvoiceNumbers
+
+Issues the Invoice records that were created before invoices had draft and issued states. Those invoices were already sent to (and
+often paid by) their users, so they are treated as issued on the date they were created, and are numbered in the order they were
+created in each Business's sequence of invoice numbers (see 'Invoice.Issue').
+
+Only invoices without a state are migrated, so the migration is safe to run on every start.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the invoices table will be migrated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func migrateInvoiceNumbers(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var invoices []Invoice
+		err := tx.Where("state IS NULL OR state = ''").Order("id").Find(&invoices).Error
+		if err != nil {
+			return err
+		}
+
+		for _, invoice := range invoices {
+			sequence, number, err := nextDocumentNumber(tx, invoice.BusinessID, DocumentInvoice)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Session(&gorm.Session{SkipHooks: true}).Model(&Invoice{}).Where("id = ?", invoice.ID).Updates(map[string]interface{}{
+				"state":     InvoiceStateIssued,
+				"number":    number,
+				"sequence":  sequence,
+				"issued_at": invoice.CreatedAt,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+/*
+*Description*
+
+func migrateInvoiceDueDates
+
+Sets the due date of the issued Invoice records that were issued before invoices had due dates. They are due according to their
+Business's payment terms at the time of the migration (see 'Business.GetPaymentTermsDays'), counted from the date they were issued.
+
+Only issued invoices without a due date are migrated, so the migration is safe to run on every start.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the invoices table will be migrated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func migrateInvoiceDueDates(db *gorm.DB) error {
+	return db.Exec(`UPDATE invoices
+		SET payment_terms_days = COALESCE(businesses.payment_terms_days, ?),
+			due_at = invoices.issued_at + COALESCE(businesses.payment_terms_days, ?) * INTERVAL '1 day'
+		FROM invoices AS issued
+		LEFT JOIN businesses ON businesses.id = issued.business_id
+		WHERE issued.id = invoices.id AND invoices.state = ? AND invoices.issued_at IS NOT NULL AND invoices.due_at IS NULL`,
+		DefaultPaymentTermsDays, DefaultPaymentTermsDays, InvoiceStateIssued).Error
 }
 
 /*
@@ -213,3 +860,48 @@ func getListOfDBTables(db *gorm.DB) (tableNames []string, err error) {
 		return tableNames, nil
 	}
 }
+
+/*
+*Description*
+
+func migrateInvoiceBusinessIDs
+
+Sets the 'business_id' column of Invoice records that were created before invoices recorded the Business that issued them. The
+Business is found through the invoice's Appointment (and its Service), ClassPackPurchase, or Subscription.
+
+Invoices that already have a Business are left as they are, so the migration is safe to run on every start.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		The database instance where the invoices table will be migrated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func migrateInvoiceBusinessIDs(db *gorm.DB) error {
+	migrations := []string{
+		`UPDATE invoices SET business_id = services.business_id
+			FROM appointments, services
+			WHERE COALESCE(invoices.business_id, 0) = 0 AND invoices.appointment_id = appointments.id AND appointments.service_id = services.id`,
+		`UPDATE invoices SET business_id = class_pack_purchases.business_id
+			FROM class_pack_purchases
+			WHERE COALESCE(invoices.business_id, 0) = 0 AND class_pack_purchases.invoice_id = invoices.id`,
+		`UPDATE invoices SET business_id = subscriptions.business_id
+			FROM subscription_invoices, subscriptions
+			WHERE COALESCE(invoices.business_id, 0) = 0 AND subscription_invoices.invoice_id = invoices.id AND subscription_invoices.subscription_id = subscriptions.id`,
+	}
+
+	for _, migration := range migrations {
+		err := db.Exec(migration).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all DocumentSequence records in the database (one record per Business and type of numbered document)
+//
+// Numbers are taken from the sequence in the same transaction that issues the document. The sequence's row stays locked until the
+// transaction completes, so a document that fails to be issued gives its number back and every Business's numbers have no gaps.
+type DocumentSequence struct {
+	BusinessID uint   `gorm:"column:business_id;primaryKey;autoIncrement:false" json:"business_id"` // ID of Business that issues the documents (0 for documents without a Business)
+	Document   string `gorm:"column:document;primaryKey" json:"document"`                           // Type of document that is numbered (Invoice, Credit Note)
+	LastNumber uint   `gorm:"column:last_number;not null;default:0" json:"last_number"`             // Sequence number of the most recently issued document (0 if none has been issued)
+}
+
+// Types of numbered documents
+const (
+	DocumentInvoice    string = "Invoice"
+	DocumentCreditNote string = "Credit Note"
+)
+
+// Default prefixes of document numbers (see 'Business.InvoicePrefix' and 'Business.CreditNotePrefix')
+const (
+	DefaultInvoicePrefix    string = "INV-"
+	DefaultCreditNotePrefix string = "CN-"
+)
+
+/*
+*Description*
+
+func nextDocumentNumber
+
+Takes the next number in a Business's sequence of numbered documents and formats it with the Business's prefix for that type of
+document (e.g. "INV-000042").
+
+The sequence is locked until the calling transaction completes, so documents are numbered one at a time and in the order they are
+issued. If the transaction is rolled back, the number is given back.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the sequence will be updated.
+
+	businessID  <uint>
+
+		The ID of the business that issues the document.
+
+	document  <string>
+
+		The type of document (see the 'Document*' constants).
+
+*Returns*
+
+	_  <uint>
+
+		The document's sequence number.
+
+	_  <string>
+
+		The document's formatted number.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func nextDocumentNumber(db *gorm.DB, businessID uint, document string) (uint, string, error) {
+	sequence := &DocumentSequence{BusinessID: businessID, Document: document}
+
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(sequence).Error
+	if err != nil {
+		return 0, "", err
+	}
+
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("business_id = ? AND document = ?", businessID, document).First(sequence).Error
+	if err != nil {
+		return 0, "", err
+	}
+
+	sequence.LastNumber++
+	err = db.Model(&DocumentSequence{}).Where("business_id = ? AND document = ?", businessID, document).Update("last_number", sequence.LastNumber).Error
+	if err != nil {
+		return 0, "", err
+	}
+
+	business := &Business{}
+	err = db.Limit(1).Find(business, businessID).Error
+	if err != nil {
+		return 0, "", err
+	}
+
+	prefix := business.GetInvoicePrefix()
+	if document == DocumentCreditNote {
+		prefix = business.GetCreditNotePrefix()
+	}
+
+	return sequence.LastNumber, fmt.Sprintf("%s%06d", prefix, sequence.LastNumber), nil
+}
@@ -0,0 +1,582 @@
+package models
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all GiftCard records in the database (one record per gift card issued by a Business)
+//
+// A gift card is a code with a balance that can be spent with the Business that issued it, as a 'Gift Card' Payment. Gift cards that are
+// sold to a User are billed with an Invoice (without sales tax, which is charged on what the card is spent on), and can't be spent until
+// that invoice has been paid. The balance is never changed directly; every change is recorded as a GiftCardTransaction.
+type GiftCard struct {
+	gorm.Model
+	BusinessID     uint             `gorm:"column:business_id;not null;index" json:"business_id"` // ID of Business that issued the gift card
+	Code           string           `gorm:"column:code;not null;uniqueIndex" json:"code"`         // Code that is entered to spend the gift card (generated when the card is issued, e.g. "7KQM-X2PA-9RTD-HW4C")
+	InitialAmount  int              `gorm:"column:initial_amount;not null" json:"initial_amount"` // Amount (in cents) loaded onto the gift card when it was issued
+	Balance        int              `gorm:"column:balance;not null" json:"balance"`               // Amount (in cents) that can still be spent
+	Currency       string           `gorm:"column:currency;not null;default:USD" json:"currency"` // ISO 4217 currency of the balance (defaults to the Business's currency)
+	PurchaserID    uint             `gorm:"column:purchaser_id;index" json:"purchaser_id"`        // ID of User that bought the gift card (0 if the Business gave it away)
+	InvoiceID      uint             `gorm:"column:invoice_id" json:"invoice_id"`                  // ID of Invoice that bills the purchaser for the gift card (0 if the Business gave it away)
+	RecipientName  string           `gorm:"column:recipient_name" json:"recipient_name"`          // Name of the person the gift card is for
+	RecipientEmail string           `gorm:"column:recipient_email" json:"recipient_email"`        // Email address of the person the gift card is for
+	Message        string           `gorm:"column:message" json:"message"`                        // Message from the purchaser to the recipient
+	ExpiresAt      *time.Time       `gorm:"column:expires_at;default:null" json:"expires_at"`     // Date/time after which the balance can no longer be spent (null if the gift card never expires)
+	Display        *GiftCardDisplay `gorm:"-" json:"display,omitempty"`                           // Amounts formatted for the requester's locale (only set in API responses)
+}
+
+// GORM model for all GiftCardTransaction records in the database (one record per change to a GiftCard's balance)
+type GiftCardTransaction struct {
+	gorm.Model
+	GiftCardID   uint                      `gorm:"column:gift_card_id;not null;index" json:"gift_card_id"` // ID of GiftCard whose balance changed
+	Type         string                    `gorm:"column:type;not null" json:"type"`                       // Type of balance change (Issued, Redeemed, Restored)
+	Amount       int                       `gorm:"column:amount;not null" json:"amount"`                   // Change in balance (in cents, positive when the card is issued or restored and negative for redemptions)
+	BalanceAfter int                       `gorm:"column:balance_after" json:"balance_after"`              // Balance of the gift card (in cents) right after the change
+	InvoiceID    uint                      `gorm:"column:invoice_id" json:"invoice_id"`                    // ID of Invoice that the card was sold on or spent on (0 if the Business gave the card away)
+	PaymentID    uint                      `gorm:"column:payment_id" json:"payment_id"`                    // ID of 'Gift Card' Payment that spent the balance (0 when the card is issued)
+	RefundID     uint                      `gorm:"column:refund_id" json:"refund_id"`                      // ID of Refund that restored the balance (0 if the change is not a refund)
+	Currency     string                    `gorm:"column:currency;not null;default:USD" json:"currency"`   // ISO 4217 currency of the amount
+	Display      *CreditTransactionDisplay `gorm:"-" json:"display,omitempty"`                             // Amount and balance after formatted for the requester's locale (only set in API responses)
+}
+
+// Gift card transaction types
+const (
+	GiftCardIssued   string = "Issued"   // Balance loaded when the gift card was issued
+	GiftCardRedeemed string = "Redeemed" // Balance spent on an invoice with a 'Gift Card' payment
+	GiftCardRestored string = "Restored" // Balance given back when a 'Gift Card' payment is refunded
+)
+
+// Characters that gift card codes are made of (letters and digits that are easily told apart, e.g. no O and 0)
+const giftCardCodeAlphabet string = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Number of characters in a gift card code, written in groups of giftCardCodeGroupLength separated by dashes
+const (
+	giftCardCodeLength      int = 16
+	giftCardCodeGroupLength int = 4
+)
+
+// Error returned when a gift card can't be issued or spent
+var ErrInvalidGiftCard = errors.New("invalid gift card")
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from GiftCard object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the gift card object
+*/
+func (card *GiftCard) GetID() uint {
+	return card.ID
+}
+
+/*
+*Description*
+
+func generateGiftCardCode
+
+Generates a random gift card code from a cryptographically secure source, so that codes can't be guessed.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The code, in groups of characters separated by dashes (e.g. "7KQM-X2PA-9RTD-HW4C").
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func generateGiftCardCode() (string, error) {
+	var code strings.Builder
+	alphabetSize := big.NewInt(int64(len(giftCardCodeAlphabet)))
+
+	for i := 0; i < giftCardCodeLength; i++ {
+		if i > 0 && i%giftCardCodeGroupLength == 0 {
+			code.WriteByte('-')
+		}
+
+		index, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(giftCardCodeAlphabet[index.Int64()])
+	}
+
+	return code.String(), nil
+}
+
+/*
+*Description*
+
+func normalizeGiftCardCode
+
+Returns the form that a gift card code is stored and looked up in, so that codes can be entered in any case and with or without dashes
+or spaces (e.g. "7kqm x2pa 9rtd hw4c" becomes "7KQM-X2PA-9RTD-HW4C").
+
+*Parameters*
+
+	code  <string>
+
+		The code as entered.
+
+*Returns*
+
+	_  <string>
+
+		The normalized code.
+*/
+func normalizeGiftCardCode(code string) string {
+	characters := strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(code)))
+
+	var normalized strings.Builder
+	for i, character := range characters {
+		if i > 0 && i%giftCardCodeGroupLength == 0 {
+			normalized.WriteByte('-')
+		}
+		normalized.WriteRune(character)
+	}
+
+	return normalized.String()
+}
+
+/*
+*Description*
+
+func IsExpired
+
+Returns whether the calling GiftCard's balance has expired at the specified time.
+
+*Parameters*
+
+	asOf  <time.Time>
+
+		The time to check.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the gift card has expired, else 'false'.
+*/
+func (card *GiftCard) IsExpired(asOf time.Time) bool {
+	return card.ExpiresAt != nil && !asOf.Before(*card.ExpiresAt)
+}
+
+/*
+*Description*
+
+func checkRedeemable
+
+Confirms that the calling GiftCard's balance can be spent at the specified time: it must not have expired, and if it was sold, its
+Invoice must have been paid.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	asOf  <time.Time>
+
+		The time that the balance is spent.
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidGiftCard' if the gift card can't be spent, else any encountered error (nil if no errors are encountered)
+*/
+func (card *GiftCard) checkRedeemable(db *gorm.DB, asOf time.Time) error {
+	if card.IsExpired(asOf) {
+		return fmt.Errorf("%w: gift card %s expired on %s", ErrInvalidGiftCard, card.Code, card.ExpiresAt.Format(time.RFC3339))
+	}
+
+	if card.InvoiceID == 0 {
+		return nil
+	}
+
+	invoice := &Invoice{}
+	err := db.First(invoice, card.InvoiceID).Error
+	if err != nil {
+		return err
+	}
+
+	if invoice.Status != InvoiceStatusPaid && invoice.Status != InvoiceStatusOverpaid {
+		return fmt.Errorf("%w: gift card %s can't be used until Invoice ID (%d) for it is paid", ErrInvalidGiftCard, card.Code, invoice.ID)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func changeBalance
+
+Applies the specified GiftCardTransaction to the calling GiftCard and records it in the card's history. The balance can't go below 0,
+or above the amount the card was issued with.
+
+The GiftCard record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+	transaction  <*GiftCardTransaction>
+
+		The balance change. Its gift card, balance after and currency are set from the card.
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidGiftCard' if the gift card doesn't have enough balance, else any encountered error (nil if no errors are encountered)
+*/
+func (card *GiftCard) changeBalance(db *gorm.DB, transaction *GiftCardTransaction) error {
+	if card.Balance+transaction.Amount < 0 {
+		return fmt.Errorf("%w: gift card %s has a balance of %d, but %d was requested", ErrInvalidGiftCard, card.Code, card.Balance, -transaction.Amount)
+	}
+
+	if card.Balance+transaction.Amount > card.InitialAmount {
+		return fmt.Errorf("%w: gift card %s can't hold more than the %d it was issued with", ErrInvalidGiftCard, card.Code, card.InitialAmount)
+	}
+
+	card.Balance += transaction.Amount
+	err := db.Model(card).Update("balance", card.Balance).Error
+	if err != nil {
+		return err
+	}
+
+	transaction.GiftCardID = card.ID
+	transaction.BalanceAfter = card.Balance
+	transaction.Currency = card.Currency
+
+	return db.Create(transaction).Error
+}
+
+/*
+*Description*
+
+func Create
+
+Issues the calling GiftCard: a code is generated for it, and its balance is loaded with its initial amount.
+
+If the gift card has a purchaser, an Invoice is issued to the purchaser for the initial amount (without sales tax), and the card can't be
+spent until the invoice is paid. Otherwise the Business is giving the card away. All records are created in the same transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the created GiftCard object and the purchaser's Invoice (if the card was sold).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (card *GiftCard) Create(db *gorm.DB) (map[string]Model, error) {
+	returnRecords := map[string]Model{"gift_card": card}
+
+	if card.InitialAmount <= 0 {
+		return returnRecords, fmt.Errorf("%w: initial_amount must be greater than 0", ErrInvalidGiftCard)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.First(&Business{}, card.BusinessID).Error
+		if err != nil {
+			return fmt.Errorf("Business ID (%d) does not exist in the database.  [%w]", card.BusinessID, err)
+		}
+
+		currency, err := resolveCurrency(tx, card.BusinessID, card.Currency)
+		if err != nil {
+			return err
+		}
+
+		card.Currency = currency
+		card.Balance = 0
+		card.InvoiceID = 0
+		card.Code, err = generateGiftCardCode()
+		if err != nil {
+			return err
+		}
+
+		if card.PurchaserID != 0 {
+			err = tx.First(&User{}, card.PurchaserID).Error
+			if err != nil {
+				return fmt.Errorf("User ID (%d) does not exist in the database.  [%w]", card.PurchaserID, err)
+			}
+
+			invoice := &Invoice{UserID: card.PurchaserID, BusinessID: card.BusinessID, Currency: card.Currency}
+			lineItems := []InvoiceLineItem{{Description: fmt.Sprintf("Gift card %s", card.Code), Quantity: 1, UnitPrice: card.InitialAmount, Currency: card.Currency}}
+
+			err = invoice.createIssued(tx, lineItems)
+			if err != nil {
+				return err
+			}
+
+			card.InvoiceID = invoice.ID
+			returnRecords["invoice"] = invoice
+		}
+
+		err = tx.Create(card).Error
+		if err != nil {
+			return err
+		}
+
+		return card.changeBalance(tx, &GiftCardTransaction{Type: GiftCardIssued, Amount: card.InitialAmount, InvoiceID: card.InvoiceID})
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a GiftCard record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	cardID  <uint>
+
+		The ID of the gift card record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved GiftCard object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (card *GiftCard) Get(db *gorm.DB, cardID uint) (map[string]Model, error) {
+	err := db.First(&card, cardID).Error
+	returnRecords := map[string]Model{"gift_card": card}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetByCode
+
+Retrieves a GiftCard record in the database by its code (in any case, with or without dashes).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	code  <string>
+
+		The gift card's code.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved GiftCard object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (card *GiftCard) GetByCode(db *gorm.DB, code string) (map[string]Model, error) {
+	err := db.Where("code = ?", normalizeGiftCardCode(code)).First(card).Error
+	returnRecords := map[string]Model{"gift_card": card}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of GiftCard records from the database that are associated with the specified secondary key (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute (e.g. "business_id" or "purchaser_id").
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]GiftCard>
+
+		The list of GiftCard records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (card *GiftCard) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]GiftCard, error) {
+	cards := []GiftCard{}
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&cards).Error
+	return cards, err
+}
+
+/*
+*Description*
+
+func GetTransactions
+
+Retrieves the history of the specified GiftCard's balance (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	cardID  <uint>
+
+		The ID of the gift card.
+
+*Returns*
+
+	_  <[]GiftCardTransaction>
+
+		The gift card's GiftCardTransaction records.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (card *GiftCard) GetTransactions(db *gorm.DB, cardID uint) ([]GiftCardTransaction, error) {
+	transactions := []GiftCardTransaction{}
+
+	err := db.Where("gift_card_id = ?", cardID).Order("id").Find(&transactions).Error
+	return transactions, err
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the recipient, message or expiry of the specified GiftCard record in the database if the record exists.
+
+Returns the updated record along with any errors that are thrown. The code and amounts can't be changed, since the balance can only
+change through redemptions and refunds (which are recorded as GiftCardTransaction records).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	cardID  <uint>
+
+		The ID of the gift card record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated GiftCard object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (card *GiftCard) Update(db *gorm.DB, cardID uint, updates map[string]interface{}) (map[string]Model, error) {
+	updateCard := &GiftCard{}
+	returnRecords := map[string]Model{"gift_card": updateCard}
+
+	for field := range updates {
+		if field != "recipient_name" && field != "recipient_email" && field != "message" && field != "expires_at" {
+			return returnRecords, fmt.Errorf("%w: '%s' can't be changed once the gift card is issued", ErrInvalidGiftCard, field)
+		}
+	}
+
+	err := db.First(updateCard, cardID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Model(updateCard).Clauses(clause.Returning{}).Where("id = ?", cardID).Updates(updates).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Delete
+
+Gift cards can't be deleted once they are issued, since their balance is owed to whoever holds the code (see 'Update' to make one expire).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	cardID  <uint>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (card *GiftCard) Delete(db *gorm.DB, cardID uint) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: gift cards cannot be deleted once they are issued", ErrInvalidGiftCard)
+}
@@ -1,22 +1,96 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"server/config"
+	"server/money"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 // GORM model for all Invoice records in the database
+//
+// An Invoice either lists its charges as InvoiceLineItem records, in which case its totals are calculated from the line items, or
+// bills a single amount (its original balance) with no tax.
+//
+// Invoices start out as drafts, which can be changed or deleted but not paid. Issuing an invoice gives it the next number in its
+// Business's sequence of invoice numbers, and from then on it can't be changed or deleted. Mistakes on an issued invoice are
+// corrected with a CreditNote.
+//
+// An issued invoice is due a number of days after it is issued (see 'Business.PaymentTermsDays'). Invoices that are still owed after
+// their due date are Overdue, and are followed up by the dunning job (see 'InvoiceReminder' and 'ApplyLateFees').
 type Invoice struct {
 	gorm.Model
-	AppointmentID    uint   `gorm:"column:user_id" json:"user_id"`                     // ID of invoice that invoice is associated with
-	OriginalBalance  int    `gorm:"column:original_balance" json:"original_balance"`   // Total original balance of the invoice (in cents)
-	RemainingBalance int    `gorm:"column:remaining_balance" json:"remaining_balance"` // Remaining balance of the invoice (in cents)
-	Status           string `gorm:"column:status" json:"status"`                       // Enforced list of statuses based on remaining balance (Unpaid, Paid, Overpaid)
+	AppointmentID    uint            `gorm:"column:appointment_id" json:"appointment_id"`          // ID of appointment that invoice is associated with (0 if the invoice is not for an appointment)
+	UserID           uint            `gorm:"column:user_id;index" json:"user_id"`                  // ID of user that is billed by the invoice
+	BusinessID       uint            `gorm:"column:business_id;index" json:"business_id"`          // ID of business that issued the invoice
+	Subtotal         int             `gorm:"column:subtotal" json:"subtotal"`                      // Total of the invoice's line items after discounts, before tax (in cents)
+	DiscountTotal    int             `gorm:"column:discount_total" json:"discount_total"`          // Total discount taken off the invoice's line items (in cents)
+	TaxTotal         int             `gorm:"column:tax_total" json:"tax_total"`                    // Total tax on the invoice's line items (in cents)
+	OriginalBalance  int             `gorm:"column:original_balance" json:"original_balance"`      // Total original balance of the invoice (in cents): Subtotal + TaxTotal
+	RemainingBalance int             `gorm:"column:remaining_balance" json:"remaining_balance"`    // Remaining balance of the invoice (in cents), derived from its credit notes, payments and refunds
+	Status           string          `gorm:"column:status" json:"status"`                          // Enforced list of statuses based on remaining balance and due date (Unpaid, Partially Paid, Overdue, Paid, Overpaid), or Void/Refunded
+	State            string          `gorm:"column:state" json:"state"`                            // Draft (can still be changed) or Issued (numbered, and can no longer be changed)
+	Number           string          `gorm:"column:number" json:"number"`                          // Invoice number, unique and gapless for each Business (e.g. INV-000042). Assigned when the invoice is issued
+	Sequence         uint            `gorm:"column:sequence" json:"sequence"`                      // Position of the invoice in its Business's sequence of invoice numbers (0 for drafts)
+	IssuedAt         *time.Time      `gorm:"column:issued_at" json:"issued_at"`                    // Date/time when the invoice was issued (null for drafts)
+	CreditTotal      int             `gorm:"column:credit_total" json:"credit_total"`              // Total credited by the invoice's credit notes (in cents)
+	PaymentTermsDays uint            `gorm:"column:payment_terms_days" json:"payment_terms_days"`  // Days after the invoice was issued that it is due (set when the invoice is issued)
+	DueAt            *time.Time      `gorm:"column:due_at;index" json:"due_at"`                    // Date/time when the invoice is due (drafts may set it, otherwise set from the payment terms when the invoice is issued)
+	LateFeeForID     uint            `gorm:"column:late_fee_for_id;index" json:"late_fee_for_id"`  // ID of the overdue Invoice that this invoice charges a late fee for (0 if it is not a late fee)
+	Currency         string          `gorm:"column:currency;not null;default:USD" json:"currency"` // ISO 4217 currency of every amount on the invoice (defaults to the Business's currency)
+	Display          *InvoiceDisplay `gorm:"-" json:"display,omitempty"`                           // Totals and balances formatted for the requester's locale (only set in API responses)
 }
 
+// Fields that lists of Invoice records can be sorted and filtered by (see 'Invoice.List')
+var invoiceListFields map[string]listField = map[string]listField{
+	"id":                {column: "id", jsonKey: "ID", fieldType: listFieldInt, sortable: true},
+	"created_at":        {column: "created_at", jsonKey: "CreatedAt", fieldType: listFieldTime, sortable: true},
+	"updated_at":        {column: "updated_at", jsonKey: "UpdatedAt", fieldType: listFieldTime, sortable: true},
+	"user_id":           {column: "user_id", jsonKey: "user_id", fieldType: listFieldInt, sortable: true},
+	"business_id":       {column: "business_id", jsonKey: "business_id", fieldType: listFieldInt, sortable: true},
+	"appointment_id":    {column: "appointment_id", jsonKey: "appointment_id", fieldType: listFieldInt},
+	"status":            {column: "status", jsonKey: "status", fieldType: listFieldString},
+	"state":             {column: "state", jsonKey: "state", fieldType: listFieldString},
+	"number":            {column: "number", jsonKey: "number", fieldType: listFieldString},
+	"currency":          {column: "currency", jsonKey: "currency", fieldType: listFieldString},
+	"original_balance":  {column: "original_balance", jsonKey: "original_balance", fieldType: listFieldInt, sortable: true},
+	"remaining_balance": {column: "remaining_balance", jsonKey: "remaining_balance", fieldType: listFieldInt, sortable: true},
+	"issued_at":         {column: "issued_at", jsonKey: "issued_at", fieldType: listFieldTime},
+	"due_at":            {column: "due_at", jsonKey: "due_at", fieldType: listFieldTime},
+}
+
+// Invoice statuses
+const (
+	InvoiceStatusUnpaid        string = "Unpaid"         // Nothing has been paid
+	InvoiceStatusPartiallyPaid string = "Partially Paid" // Part of the balance has been paid
+	InvoiceStatusOverdue       string = "Overdue"        // Some of the balance is still owed after the due date
+	InvoiceStatusPaid          string = "Paid"           // The balance has been paid in full
+	InvoiceStatusOverpaid      string = "Overpaid"       // More than the balance has been paid
+	InvoiceStatusVoid          string = "Void"           // Cancelled before anything was paid (no longer owed)
+	InvoiceStatusRefunded      string = "Refunded"       // Cancelled, and everything that was paid has been refunded (no longer owed)
+)
+
+// Invoice states
+const (
+	InvoiceStateDraft  string = "Draft"  // The invoice can still be changed or deleted, and can't be paid yet
+	InvoiceStateIssued string = "Issued" // The invoice has been numbered and can no longer be changed (see 'CreditNote')
+)
+
+// Errors returned when an Invoice update is rejected
+var (
+	ErrInvalidInvoice         = errors.New("invalid invoice")
+	ErrInvoiceBalanceReadOnly = errors.New("invoice balance is read-only")          // The attribute is derived from the invoice's payments and refunds
+	ErrInvoiceIssued          = errors.New("invoice has been issued")               // Issued invoices can't be changed or deleted (see 'CreditNote')
+	ErrDuplicateInvoice       = errors.New("appointment has already been invoiced") // An appointment has one invoice that is not void or refunded at most
+)
+
 /*
 *Description*
 
@@ -29,6 +103,7 @@ Appropriately updates the 'Status' attribute for the calling Invoice record base
 	RemainingBalance < OriginalBalance && RemainingBalance > 0  -->  Status = 'Partially Paid'
 	RemainingBalance == 0  -->  Status = 'Paid'
 	RemainingBalance < 0  -->  Status = 'Overpaid'
+	RemainingBalance > 0 && past the due date  -->  Status = 'Overdue'
 
 *Parameters*
 
@@ -48,18 +123,7 @@ func (invoice *Invoice) BeforeCreate(db *gorm.DB) error {
 		log.Printf("Original Invoice:\n\n%v\n\n", invoice)
 	}
 
-	if invoice.RemainingBalance > invoice.OriginalBalance {
-		invoice.RemainingBalance = invoice.OriginalBalance
-		invoice.Status = "Unpaid"
-	} else if invoice.RemainingBalance == invoice.OriginalBalance {
-		invoice.Status = "Unpaid"
-	} else if invoice.RemainingBalance < invoice.OriginalBalance && invoice.RemainingBalance > 0 {
-		invoice.Status = "Partially Paid"
-	} else if invoice.RemainingBalance == 0 {
-		invoice.Status = "Paid"
-	} else if invoice.RemainingBalance < 0 {
-		invoice.Status = "Overpaid"
-	}
+	invoice.setStatus()
 
 	if config.Debug {
 		log.Printf("Created Invoice:\n\n%v\n\n", invoice)
@@ -80,6 +144,7 @@ Appropriately updates the 'Status' attribute for the calling Invoice record base
 	RemainingBalance < OriginalBalance && RemainingBalance > 0  -->  Status = 'Partially Paid'
 	RemainingBalance == 0  -->  Status = 'Paid'
 	RemainingBalance < 0  -->  Status = 'Overpaid'
+	RemainingBalance > 0 && past the due date  -->  Status = 'Overdue'
 
 *Parameters*
 
@@ -99,21 +164,152 @@ func (invoice *Invoice) AfterUpdate(db *gorm.DB) error {
 		log.Printf("Original Invoice:\n\n%v\n\n", invoice)
 	}
 
-	if invoice.RemainingBalance > invoice.OriginalBalance {
-		invoice.RemainingBalance = invoice.OriginalBalance
-		invoice.Status = "Unpaid"
-	} else if invoice.RemainingBalance == invoice.OriginalBalance {
-		invoice.Status = "Unpaid"
-	} else if invoice.RemainingBalance < invoice.OriginalBalance && invoice.RemainingBalance > 0 {
-		invoice.Status = "Partially Paid"
+	invoice.setStatus()
+
+	if config.Debug {
+		log.Printf("Updated Invoice:\n\n%v\n\n", invoice)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func setStatus
+
+Sets the 'Status' attribute for the calling Invoice based on the value of the 'RemainingBalance' attribute (see 'BeforeCreate'). The
+remaining balance is compared with the amount billed, which is the original balance less anything credited (see 'GetAmountBilled').
+
+Void invoices keep their status, and invoices with nothing left to pay (including invoices for 0) are Paid. Invoices that are still owed
+after their due date are Overdue.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	N/A (None)
+*/
+func (invoice *Invoice) setStatus() {
+	if invoice.Status == InvoiceStatusVoid || invoice.Status == InvoiceStatusRefunded {
+		return
+	}
+
+	var amountBilled int = invoice.GetAmountBilled()
+	if invoice.RemainingBalance > amountBilled {
+		invoice.RemainingBalance = amountBilled
+		invoice.Status = InvoiceStatusUnpaid
 	} else if invoice.RemainingBalance == 0 {
-		invoice.Status = "Paid"
+		invoice.Status = InvoiceStatusPaid
+	} else if invoice.RemainingBalance == amountBilled {
+		invoice.Status = InvoiceStatusUnpaid
+	} else if invoice.RemainingBalance < amountBilled && invoice.RemainingBalance > 0 {
+		invoice.Status = InvoiceStatusPartiallyPaid
 	} else if invoice.RemainingBalance < 0 {
-		invoice.Status = "Overpaid"
+		invoice.Status = InvoiceStatusOverpaid
 	}
 
-	if config.Debug {
-		log.Printf("Updated Invoice:\n\n%v\n\n", invoice)
+	if invoice.RemainingBalance > 0 && invoice.IsPastDue(time.Now()) {
+		invoice.Status = InvoiceStatusOverdue
+	}
+}
+
+/*
+*Description*
+
+func IsPastDue
+
+Returns true if the calling Invoice was due before the specified time. Drafts aren't due yet.
+
+*Parameters*
+
+	asOf  <time.Time>
+
+		The time to compare the due date with.
+
+*Returns*
+
+	_  <bool>
+
+		True if the invoice's due date has passed.
+*/
+func (invoice *Invoice) IsPastDue(asOf time.Time) bool {
+	return invoice.State == InvoiceStateIssued && invoice.DueAt != nil && invoice.DueAt.Before(asOf)
+}
+
+/*
+*Description*
+
+func GetAmountBilled
+
+Returns the amount (in cents) that the calling Invoice bills once its credit notes are taken off: its original balance minus the total
+of its credit notes.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <int>
+
+		The amount billed (in cents).
+*/
+func (invoice *Invoice) GetAmountBilled() int {
+	return invoice.OriginalBalance - invoice.CreditTotal
+}
+
+/*
+*Description*
+
+func checkDraft
+
+Confirms that the calling Invoice is still a draft, and can therefore be changed or deleted.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvoiceIssued' if the invoice has been issued (nil otherwise)
+*/
+func (invoice *Invoice) checkDraft() error {
+	if invoice.State == InvoiceStateIssued {
+		return fmt.Errorf("%w: Invoice %s can no longer be changed (issue a credit note to correct it)", ErrInvoiceIssued, invoice.Number)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func checkPayable
+
+Confirms that payments can be applied to the calling Invoice: it must have been issued, and must not be void.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidPayment' if the invoice can't be paid (nil otherwise)
+*/
+func (invoice *Invoice) checkPayable() error {
+	if invoice.State != InvoiceStateIssued {
+		return fmt.Errorf("%w: Invoice ID (%d) is a draft and must be issued before it is paid", ErrInvalidPayment, invoice.ID)
+	}
+
+	if invoice.Status == InvoiceStatusVoid || invoice.Status == InvoiceStatusRefunded {
+		return fmt.Errorf("%w: Invoice ID (%d) is %s", ErrInvalidPayment, invoice.ID, strings.ToLower(invoice.Status))
 	}
 
 	return nil
@@ -180,6 +376,11 @@ func Create
 
 Creates a new Invoice record in the database and returns the created record along with any errors that are thrown.
 
+The new invoice bills its original balance as a single amount with no tax (see 'CreateWithLineItems' to list its charges instead), and its
+remaining balance is always its original balance. The invoice is created as a draft, which is numbered when it is issued (see 'Issue').
+Payments are applied with 'Payment.Create' once it is issued. A due date can be set on the draft, otherwise the invoice is due according
+to its Business's payment terms.
+
 *Parameters*
 
 	db  <*gorm.DB>
@@ -197,7 +398,51 @@ Creates a new Invoice record in the database and returns the created record alon
 		Encountered error (nil if no errors are encountered).
 */
 func (invoice *Invoice) Create(db *gorm.DB) (map[string]Model, error) {
-	err := db.Create(&invoice).Error
+	// A new invoice bills a single amount until line items are added, and nothing has been paid towards it
+	invoice.Subtotal = invoice.OriginalBalance
+	invoice.DiscountTotal = 0
+	invoice.TaxTotal = 0
+	invoice.RemainingBalance = invoice.OriginalBalance
+
+	// The status follows from the balance (see 'setStatus'), and invoices are only voided or refunded once they exist
+	invoice.Status = ""
+
+	// Invoices are numbered when they are issued, so that issued invoice numbers have no gaps
+	invoice.State = InvoiceStateDraft
+	invoice.Number = ""
+	invoice.Sequence = 0
+	invoice.IssuedAt = nil
+	invoice.CreditTotal = 0
+	invoice.PaymentTermsDays = 0
+	invoice.LateFeeForID = 0
+
+	currency, err := resolveCurrency(db, invoice.BusinessID, invoice.Currency)
+	if err != nil {
+		return map[string]Model{"invoice": invoice}, err
+	}
+	invoice.Currency = currency
+
+	// An appointment is invoiced once (until its invoice is voided or refunded), so that cancelling it credits and refunds everything
+	// that was billed for it (the unique index on live appointment invoices catches concurrent invoices, see 'createInvoiceIndexes')
+	if invoice.AppointmentID != 0 {
+		var liveInvoiceCt int64
+		err = db.Model(&Invoice{}).
+			Where("appointment_id = ? AND status NOT IN ?", invoice.AppointmentID, []string{InvoiceStatusVoid, InvoiceStatusRefunded}).
+			Count(&liveInvoiceCt).Error
+		if err != nil {
+			return map[string]Model{"invoice": invoice}, err
+		}
+
+		if liveInvoiceCt > 0 {
+			return map[string]Model{"invoice": invoice}, fmt.Errorf("%w: Appointment ID (%d) already has an invoice (void it before invoicing the appointment again)", ErrDuplicateInvoice, invoice.AppointmentID)
+		}
+	}
+
+	err = db.Create(&invoice).Error
+	if invoice.AppointmentID != 0 && isUniqueViolation(err) {
+		err = fmt.Errorf("%w: Appointment ID (%d) already has an invoice (void it before invoicing the appointment again)", ErrDuplicateInvoice, invoice.AppointmentID)
+	}
+
 	returnRecords := map[string]Model{"invoice": invoice}
 	return returnRecords, err
 }
@@ -268,89 +513,905 @@ func (invoice *Invoice) GetAll(db *gorm.DB) ([]Invoice, error) {
 /*
 *Description*
 
-func Update
+func List
 
-Updates the specified Invoice record in the database with the specified changes if the record exists.
+Retrieves a page of Invoice records from the database, sorted and filtered as requested (see 'invoiceListFields').
 
-Returns the updated record along with any errors that are thrown.
+*Parameters*
 
-This function behaves like a PATCH method, rather than a true PUT. Any fields that aren't specified in the request body for the PUT request will not be altered for the specified record.
+	db  <*gorm.DB>
 
-If a specified field's value should be deleted from the record, the appropriate null/blank should be specified for that key in the JSON request body (e.g. "type": "").
+		A pointer to the database instance that the records will be retrieved from.
+
+	options  <ListOptions>
+
+		The limit, cursor, sort and filters of the page.
+
+*Returns*
+
+	_  <ListPage>
+
+		The page of Invoice records ([]Invoice), with the total number of records that match the filters and the cursor of the next page.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) List(db *gorm.DB, options ListOptions) (ListPage, error) {
+	var invoices []Invoice
+	return listRecords(db, &invoices, invoiceListFields, options)
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of Invoice records from the database that are associated with the specified secondary key (oldest to newest).
 
 *Parameters*
 
 	db  <*gorm.DB>
 
-		A pointer to the database instance that will be used to retrieve and update the specified record.
+		A pointer to the database instance that the records will be retrieved from.
 
-	invoiceID  <uint>
+	secondaryIDJsonKey  <string>
 
-		The ID of the invoice record being updated.
+		The JSON key for the secondary ID attribute.
 
-	updates  <map[string]interface{}>
+	secondaryID  <uint>
 
-		JSON with the fields that will be updated as keys and the updated values as values.
+		The secondary ID value.
 
-		Ex:
-			{
-				"name": "New name",
-				"address": "New address"
-			}
+*Returns*
+
+	_  <[]Invoice>
+
+		The list of Invoice records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]Invoice, error) {
+	invoices := []Invoice{}
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&invoices).Error
+	return invoices, err
+}
+
+/*
+*Description*
+
+func GetAmountPaid
+
+Returns the net amount (in cents) paid towards the calling Invoice: the total of its payments minus the total of its refunds.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
 
 *Returns*
 
-	_  <*Invoice>
+	_  <int>
 
-		The Invoice record that is updated in the database.
+		The net amount paid (in cents).
 
 	_  <error>
 
 		Encountered error (nil if no errors are encountered)
 */
-func (invoice *Invoice) Update(db *gorm.DB, invoiceID uint, updates map[string]interface{}) (map[string]Model, error) {
-	// Confirm invoiceID exists in the database and get current object
-	returnRecords, err := invoice.Get(db, invoiceID)
-	updateInvoice := returnRecords["invoice"]
+func (invoice *Invoice) GetAmountPaid(db *gorm.DB) (int, error) {
+	var amountPaid int
+
+	err := db.Raw(`SELECT
+		(SELECT COALESCE(SUM(amount), 0) FROM payments WHERE invoice_id = ? AND deleted_at IS NULL) -
+		(SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE invoice_id = ? AND deleted_at IS NULL)`,
+		invoice.ID, invoice.ID).Scan(&amountPaid).Error
+	return amountPaid, err
+}
+
+/*
+*Description*
+
+func GetAmountRefunded
+
+Returns the total amount (in cents) refunded from the calling Invoice's payments.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+*Returns*
+
+	_  <int>
+
+		The total amount refunded (in cents).
 
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) GetAmountRefunded(db *gorm.DB) (int, error) {
+	var amountRefunded int
+
+	err := db.Raw("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE invoice_id = ? AND deleted_at IS NULL", invoice.ID).Scan(&amountRefunded).Error
+	return amountRefunded, err
+}
+
+/*
+*Description*
+
+func GetAmountCredited
+
+Returns the total amount (in cents) credited to the calling Invoice by its credit notes.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+*Returns*
+
+	_  <int>
+
+		The total amount credited (in cents).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) GetAmountCredited(db *gorm.DB) (int, error) {
+	var amountCredited int
+
+	err := db.Raw("SELECT COALESCE(SUM(amount), 0) FROM credit_notes WHERE invoice_id = ? AND deleted_at IS NULL", invoice.ID).Scan(&amountCredited).Error
+	return amountCredited, err
+}
+
+/*
+*Description*
+
+func applyLedger
+
+Derives the calling Invoice's credit total, remaining balance and status from its credit notes, payments and refunds and saves them.
+
+An issued invoice that is credited in full before anything was paid is no longer owed, so it is voided. One that is credited in full
+after it was paid is Refunded once everything paid has been refunded.
+
+The Invoice record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the record will be updated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) applyLedger(db *gorm.DB) error {
+	amountPaid, err := invoice.GetAmountPaid(db)
 	if err != nil {
-		return returnRecords, err
+		return err
 	}
 
-	err = db.Model(&updateInvoice).Clauses(clause.Returning{}).Where("id = ?", invoiceID).Updates(updates).Error
-	returnRecords = map[string]Model{"invoice": updateInvoice}
+	amountCredited, err := invoice.GetAmountCredited(db)
+	if err != nil {
+		return err
+	}
 
-	return returnRecords, err
+	invoice.CreditTotal = amountCredited
+	invoice.RemainingBalance = invoice.OriginalBalance - amountCredited - amountPaid
+	if amountCredited > 0 && invoice.GetAmountBilled() <= 0 && amountPaid == 0 {
+		amountRefunded, err := invoice.GetAmountRefunded(db)
+		if err != nil {
+			return err
+		}
+
+		invoice.Status = InvoiceStatusVoid
+		if amountRefunded > 0 {
+			invoice.Status = InvoiceStatusRefunded
+		}
+	}
+	invoice.setStatus()
+
+	return db.Model(invoice).Updates(map[string]interface{}{
+		"subtotal":          invoice.Subtotal,
+		"discount_total":    invoice.DiscountTotal,
+		"tax_total":         invoice.TaxTotal,
+		"original_balance":  invoice.OriginalBalance,
+		"credit_total":      invoice.CreditTotal,
+		"remaining_balance": invoice.RemainingBalance,
+		"status":            invoice.Status,
+	}).Error
 }
 
 /*
 *Description*
 
-func Delete
+func setTotals
 
-Deletes the specified Invoice record from the database if it exists.
+Sets the calling Invoice's totals (in cents) and derives its original balance, remaining balance and status from them.
 
-Deleted record is returned along with any errors that are thrown.
+An invoice whose total drops to 0 before anything has been paid is voided. The Invoice record should be locked by the calling transaction.
 
 *Parameters*
 
 	db  <*gorm.DB>
 
-		A pointer to the database instance where the record will be deleted.
+		A pointer to the database instance (transaction) where the record will be updated.
 
-	invoiceID  <uint>
+	subtotal  <int>
 
-		The ID of the invoice record being deleted.
+		The total of the invoice's charges after discounts, before tax.
 
-*Returns*
+	discountTotal  <int>
 
-	_  <*Invoice>
+		The total discount taken off the invoice's charges.
 
-		The deleted Invoice record.
+	taxTotal  <int>
+
+		The total tax on the invoice's charges.
+
+*Returns*
 
 	_  <error>
 
-		Encountered error (nil if no errors are encountered).
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) setTotals(db *gorm.DB, subtotal int, discountTotal int, taxTotal int) error {
+	amountPaid, err := invoice.GetAmountPaid(db)
+	if err != nil {
+		return err
+	}
+
+	var wasBilled bool = invoice.OriginalBalance != 0
+
+	invoice.Subtotal = subtotal
+	invoice.DiscountTotal = discountTotal
+	invoice.TaxTotal = taxTotal
+	invoice.OriginalBalance = subtotal + taxTotal
+	invoice.Status = ""
+	if wasBilled && invoice.OriginalBalance == 0 && amountPaid == 0 {
+		invoice.Status = InvoiceStatusVoid
+	}
+
+	return invoice.applyLedger(db)
+}
+
+/*
+*Description*
+
+func Adjust
+
+Changes the original balance of the calling Invoice, which bills a single amount, to the specified amount while keeping what has already
+been paid (see 'setTotals'). The balance of an invoice with line items is derived from its line items and can't be adjusted directly,
+and issued invoices can't be adjusted at all.
+
+The Invoice record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the record will be updated.
+
+	originalBalance  <int>
+
+		The new original balance (in cents).
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) Adjust(db *gorm.DB, originalBalance int) error {
+	err := invoice.checkDraft()
+	if err != nil {
+		return err
+	}
+
+	var lineItemCount int64
+	err = db.Model(&InvoiceLineItem{}).Where("invoice_id = ?", invoice.ID).Count(&lineItemCount).Error
+	if err != nil {
+		return err
+	}
+
+	if lineItemCount > 0 {
+		return fmt.Errorf("%w: the balance of Invoice ID (%d) is derived from its line items", ErrInvoiceBalanceReadOnly, invoice.ID)
+	}
+
+	return invoice.setTotals(db, originalBalance, 0, 0)
+}
+
+/*
+*Description*
+
+func Issue
+
+Issues the specified draft Invoice: it is given the next number in its Business's sequence of invoice numbers (see 'Business.InvoicePrefix'),
+and from then on it can be paid but can no longer be changed or deleted. Mistakes on an issued invoice are corrected with a CreditNote.
+
+The invoice is due on the date set while it was a draft, or else after the number of days in its Business's payment terms
+(see 'Business.PaymentTermsDays').
+
+The Invoice record is locked while it is issued. Invoice numbers are taken in the same transaction, so they have no gaps.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be updated.
+
+	invoiceID  <uint>
+
+		The ID of the invoice.
+
+	issuedAt  <time.Time>
+
+		The date/time when the invoice is issued.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the issued Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) Issue(db *gorm.DB, invoiceID uint, issuedAt time.Time) (map[string]Model, error) {
+	issueInvoice := &Invoice{}
+	returnRecords := map[string]Model{"invoice": issueInvoice}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(issueInvoice, invoiceID).Error
+		if err != nil {
+			return err
+		}
+
+		return issueInvoice.issue(tx, issuedAt)
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func issue
+
+Issues the calling draft Invoice (see 'Issue').
+
+The Invoice record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the record will be updated.
+
+	issuedAt  <time.Time>
+
+		The date/time when the invoice is issued.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) issue(db *gorm.DB, issuedAt time.Time) error {
+	if invoice.State == InvoiceStateIssued {
+		return fmt.Errorf("%w: Invoice ID (%d) was already issued as %s", ErrInvoiceIssued, invoice.ID, invoice.Number)
+	}
+
+	if invoice.Status == InvoiceStatusVoid {
+		return fmt.Errorf("%w: Invoice ID (%d) is void and can't be issued", ErrInvalidInvoice, invoice.ID)
+	}
+
+	if invoice.DueAt != nil && invoice.DueAt.Before(issuedAt) {
+		return fmt.Errorf("%w: Invoice ID (%d) can't be due before it is issued", ErrInvalidInvoice, invoice.ID)
+	}
+
+	business := &Business{}
+	err := db.Limit(1).Find(business, invoice.BusinessID).Error
+	if err != nil {
+		return err
+	}
+
+	sequence, number, err := nextDocumentNumber(db, invoice.BusinessID, DocumentInvoice)
+	if err != nil {
+		return err
+	}
+
+	invoice.State = InvoiceStateIssued
+	invoice.Number = number
+	invoice.Sequence = sequence
+	invoice.IssuedAt = &issuedAt
+	invoice.PaymentTermsDays = business.GetPaymentTermsDays()
+
+	if invoice.DueAt == nil {
+		dueAt := issuedAt.AddDate(0, 0, int(invoice.PaymentTermsDays))
+		invoice.DueAt = &dueAt
+	} else {
+		invoice.PaymentTermsDays = uint(invoice.DueAt.Sub(issuedAt).Hours() / 24)
+	}
+
+	return db.Model(invoice).Updates(map[string]interface{}{
+		"state":              invoice.State,
+		"number":             invoice.Number,
+		"sequence":           invoice.Sequence,
+		"issued_at":          invoice.IssuedAt,
+		"payment_terms_days": invoice.PaymentTermsDays,
+		"due_at":             invoice.DueAt,
+	}).Error
+}
+
+/*
+*Description*
+
+func createIssued
+
+Creates a new Invoice record that lists the specified line items (see 'CreateWithLineItems') and issues it straight away. Used for
+invoices that are generated automatically (appointments, class packs and memberships).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be created.
+
+	lineItems  <[]InvoiceLineItem>
+
+		The charges listed on the invoice.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) createIssued(db *gorm.DB, lineItems []InvoiceLineItem) error {
+	_, err := invoice.CreateWithLineItems(db, lineItems)
+	if err != nil {
+		return err
+	}
+
+	return invoice.issue(db, time.Now())
+}
+
+/*
+*Description*
+
+func revise
+
+Brings the calling Invoice in line with the specified line items after the charges it was created for change (e.g. when an
+appointment is cancelled).
+
+A draft invoice's line items are replaced (see 'setLineItems'). An issued invoice can't be changed, so the difference is credited with a
+CreditNote instead. Issued invoices are only ever reduced: if the revised charges come to more than is billed, nothing is changed.
+
+The Invoice record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+	lineItems  <[]InvoiceLineItem>
+
+		The charges that the invoice should bill.
+
+	reason  <string>
+
+		The reason for the change (shown on the credit note).
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) revise(db *gorm.DB, lineItems []InvoiceLineItem, reason string) error {
+	if invoice.State != InvoiceStateIssued {
+		return invoice.setLineItems(db, lineItems)
+	}
+
+	var revisedTotal int
+	for i := range lineItems {
+		err := lineItems[i].Calculate()
+		if err != nil {
+			return err
+		}
+
+		revisedTotal += lineItems[i].Total
+	}
+
+	var amountCredited int = invoice.GetAmountBilled() - revisedTotal
+	if amountCredited <= 0 {
+		return nil
+	}
+
+	creditNote := &CreditNote{Amount: amountCredited, Reason: reason}
+	return creditNote.issue(db, invoice)
+}
+
+/*
+*Description*
+
+func CreateWithLineItems
+
+Creates a new draft Invoice record that lists the specified line items, and calculates its totals from them (see 'InvoiceLineItem.Calculate').
+
+Returns the created records along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	lineItems  <[]InvoiceLineItem>
+
+		The charges listed on the invoice.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (invoice *Invoice) CreateWithLineItems(db *gorm.DB, lineItems []InvoiceLineItem) (map[string]Model, error) {
+	returnRecords := map[string]Model{"invoice": invoice}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		invoice.OriginalBalance = 0
+		_, err := invoice.Create(tx)
+		if err != nil {
+			return err
+		}
+
+		return invoice.setLineItems(tx, lineItems)
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetLineItems
+
+Returns the line items listed on the specified Invoice, in the order they were added.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	invoiceID  <uint>
+
+		The ID of the invoice.
+
+*Returns*
+
+	_  <[]InvoiceLineItem>
+
+		The invoice's line items.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) GetLineItems(db *gorm.DB, invoiceID uint) ([]InvoiceLineItem, error) {
+	lineItems := []InvoiceLineItem{}
+
+	err := db.Where("invoice_id = ?", invoiceID).Order("id").Find(&lineItems).Error
+	return lineItems, err
+}
+
+/*
+*Description*
+
+func SetLineItems
+
+Replaces the line items listed on the specified draft Invoice and recalculates its totals. The line items of an issued invoice can't be
+changed (see 'CreditNote').
+
+The Invoice record is locked while its line items are replaced. Removing every line item from a draft voids it.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be replaced.
+
+	invoiceID  <uint>
+
+		The ID of the invoice.
+
+	lineItems  <[]InvoiceLineItem>
+
+		The charges listed on the invoice.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) SetLineItems(db *gorm.DB, invoiceID uint, lineItems []InvoiceLineItem) (map[string]Model, error) {
+	updateInvoice := &Invoice{}
+	returnRecords := map[string]Model{"invoice": updateInvoice}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(updateInvoice, invoiceID).Error
+		if err != nil {
+			return err
+		}
+
+		if err = updateInvoice.checkDraft(); err != nil {
+			return err
+		}
+
+		return updateInvoice.setLineItems(tx, lineItems)
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func setLineItems
+
+Replaces the line items listed on the calling Invoice and recalculates its totals (see 'SetLineItems').
+
+The Invoice record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be replaced.
+
+	lineItems  <[]InvoiceLineItem>
+
+		The charges listed on the invoice.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) setLineItems(db *gorm.DB, lineItems []InvoiceLineItem) error {
+	var subtotal, discountTotal, taxTotal int
+	for i := range lineItems {
+		if lineItems[i].Currency == "" {
+			lineItems[i].Currency = invoice.Currency
+		}
+
+		lineItemPrice, err := money.New(lineItems[i].UnitPrice, lineItems[i].Currency)
+		if err != nil {
+			return err
+		}
+
+		if err = lineItemPrice.SameCurrency(invoice.Currency); err != nil {
+			return fmt.Errorf("line item '%s' can't be listed on Invoice ID (%d): %w", lineItems[i].Description, invoice.ID, err)
+		}
+		lineItems[i].Currency = lineItemPrice.Currency
+
+		err = lineItems[i].Calculate()
+		if err != nil {
+			return err
+		}
+
+		subtotal += lineItems[i].Subtotal
+		discountTotal += lineItems[i].Discount
+		taxTotal += lineItems[i].Tax
+	}
+
+	err := db.Unscoped().Where("invoice_id = ?", invoice.ID).Delete(&InvoiceLineItem{}).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range lineItems {
+		lineItems[i].ID = 0
+		lineItems[i].InvoiceID = invoice.ID
+
+		err = db.Create(&lineItems[i]).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return invoice.setTotals(db, subtotal, discountTotal, taxTotal)
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the specified draft Invoice record in the database with the specified changes if the record exists. Issued invoices can't be
+changed, so updates to them are rejected with ErrInvoiceIssued (see 'CreditNote').
+
+Returns the updated record along with any errors that are thrown.
+
+The remaining balance and status are derived from the invoice's payments and refunds, so updates that include them are rejected with
+ErrInvoiceBalanceReadOnly. The state, number and payment terms are set when the invoice is issued (see 'Issue'), although a draft's due
+date can be changed. Changing the original balance keeps what has already been paid (see 'Adjust'). The original balance of an
+invoice with line items can't be changed (see 'SetLineItems').
+
+This function behaves like a PATCH method, rather than a true PUT. Any fields that aren't specified in the request body for the PUT request will not be altered for the specified record.
+
+If a specified field's value should be deleted from the record, the appropriate null/blank should be specified for that key in the JSON request body (e.g. "type": "").
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	invoiceID  <uint>
+
+		The ID of the invoice record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+		Ex:
+			{
+				"name": "New name",
+				"address": "New address"
+			}
+
+*Returns*
+
+	_  <*Invoice>
+
+		The Invoice record that is updated in the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) Update(db *gorm.DB, invoiceID uint, updates map[string]interface{}) (map[string]Model, error) {
+	updateInvoice := &Invoice{}
+	returnRecords := map[string]Model{"invoice": updateInvoice}
+
+	for _, attribute := range []string{"remaining_balance", "status"} {
+		if _, attributeUpdated := updates[attribute]; attributeUpdated {
+			return returnRecords, fmt.Errorf("%w: '%s' is derived from the invoice's payments and refunds", ErrInvoiceBalanceReadOnly, attribute)
+		}
+	}
+
+	for _, attribute := range []string{"subtotal", "discount_total", "tax_total"} {
+		if _, attributeUpdated := updates[attribute]; attributeUpdated {
+			return returnRecords, fmt.Errorf("%w: '%s' is derived from the invoice's line items", ErrInvoiceBalanceReadOnly, attribute)
+		}
+	}
+
+	if _, attributeUpdated := updates["credit_total"]; attributeUpdated {
+		return returnRecords, fmt.Errorf("%w: 'credit_total' is derived from the invoice's credit notes", ErrInvoiceBalanceReadOnly)
+	}
+
+	for _, attribute := range []string{"state", "number", "sequence", "issued_at", "payment_terms_days"} {
+		if _, attributeUpdated := updates[attribute]; attributeUpdated {
+			return returnRecords, fmt.Errorf("%w: '%s' is set when the invoice is issued", ErrInvalidInvoice, attribute)
+		}
+	}
+
+	if _, attributeUpdated := updates["late_fee_for_id"]; attributeUpdated {
+		return returnRecords, fmt.Errorf("%w: 'late_fee_for_id' is set when a late fee is charged", ErrInvalidInvoice)
+	}
+
+	otherUpdates := map[string]interface{}{}
+	if err := normalizeCurrencyUpdate(updates); err != nil {
+		return returnRecords, err
+	}
+
+	for attribute, value := range updates {
+		if attribute != "original_balance" {
+			otherUpdates[attribute] = value
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Confirm invoiceID exists in the database and get current object (row is locked until the transaction completes)
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(updateInvoice, invoiceID).Error
+		if err != nil {
+			return err
+		}
+
+		if err = updateInvoice.checkDraft(); err != nil {
+			return err
+		}
+
+		if currency, currencyUpdated := updates["currency"]; currencyUpdated && currency != updateInvoice.Currency {
+			return fmt.Errorf("%w: Invoice ID (%d) is in %s, and the currency of an invoice can't be changed", money.ErrCurrencyMismatch, invoiceID, updateInvoice.Currency)
+		}
+
+		if len(otherUpdates) > 0 {
+			err = tx.Model(updateInvoice).Clauses(clause.Returning{}).Where("id = ?", invoiceID).Updates(otherUpdates).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		originalBalance, balanceUpdated := updates["original_balance"]
+		if !balanceUpdated {
+			return nil
+		}
+
+		switch balance := originalBalance.(type) {
+		case int:
+			return updateInvoice.Adjust(tx, balance)
+		case float64:
+			if balance == math.Trunc(balance) {
+				return updateInvoice.Adjust(tx, int(balance))
+			}
+		}
+
+		return fmt.Errorf("%w: original_balance must be a whole number of cents", ErrInvalidInvoice)
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified draft Invoice record from the database if it exists. Issued invoices can't be deleted, so that their numbers
+have no gaps (see 'CreditNote' to cancel what an issued invoice bills).
+
+Deleted record is returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted.
+
+	invoiceID  <uint>
+
+		The ID of the invoice record being deleted.
+
+*Returns*
+
+	_  <*Invoice>
+
+		The deleted Invoice record.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
 */
 func (invoice *Invoice) Delete(db *gorm.DB, invoiceID uint) (map[string]Model, error) {
 	// Confirm invoiceID exists in the database and get current object
@@ -361,6 +1422,10 @@ func (invoice *Invoice) Delete(db *gorm.DB, invoiceID uint) (map[string]Model, e
 		return returnRecords, err
 	}
 
+	if err = invoice.checkDraft(); err != nil {
+		return returnRecords, err
+	}
+
 	if config.Debug {
 		log.Printf("\n\nInvoice object targeted for deletion:\n\n%+v\n\n", deleteInvoice)
 	}
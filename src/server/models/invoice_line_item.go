@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// GORM model for all InvoiceLineItem records in the database (one record per charge listed on an Invoice)
+//
+// All amounts are in cents. The Subtotal, Tax and Total attributes are calculated from the other attributes (see 'Calculate').
+type InvoiceLineItem struct {
+	gorm.Model
+	InvoiceID       uint                    `gorm:"column:invoice_id;not null;index" json:"invoice_id"`   // ID of Invoice that the line item is listed on
+	Description     string                  `gorm:"column:description" json:"description"`                // Description of the charge (e.g. "Yoga (2 seats)")
+	Quantity        uint                    `gorm:"column:quantity;not null" json:"quantity"`             // Number of units charged (defaults to 1)
+	UnitPrice       int                     `gorm:"column:unit_price;not null" json:"unit_price"`         // Price (in cents) of each unit
+	Discount        int                     `gorm:"column:discount" json:"discount"`                      // Amount (in cents) taken off the line before tax
+	TaxRate         uint                    `gorm:"column:tax_rate" json:"tax_rate"`                      // Tax rate in basis points (hundredths of a percent, e.g. 825 for 8.25%)
+	Subtotal        int                     `gorm:"column:subtotal" json:"subtotal"`                      // Quantity * UnitPrice - Discount (the taxable amount)
+	Tax             int                     `gorm:"column:tax" json:"tax"`                                // Subtotal * TaxRate, rounded half up to the nearest cent
+	Total           int                     `gorm:"column:total" json:"total"`                            // Subtotal + Tax
+	Currency        string                  `gorm:"column:currency;not null;default:USD" json:"currency"` // ISO 4217 currency of the line's amounts (must match the Invoice's currency)
+	TaxJurisdiction string                  `gorm:"column:tax_jurisdiction" json:"tax_jurisdiction"`      // Code of the sales tax jurisdiction that the line was taxed in (empty if sales tax doesn't apply, see 'TaxRate')
+	Display         *InvoiceLineItemDisplay `gorm:"-" json:"display,omitempty"`                           // Amounts formatted for the requester's locale (only set in API responses)
+}
+
+// Number of basis points in 100% (the largest permitted tax rate)
+const basisPointsPerUnit int64 = 10000
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from InvoiceLineItem object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the invoice line item object
+*/
+func (lineItem *InvoiceLineItem) GetID() uint {
+	return lineItem.ID
+}
+
+/*
+*Description*
+
+func Calculate
+
+Validates the calling InvoiceLineItem and calculates its subtotal, tax and total (in cents).
+
+Tax is calculated separately for each line and rounded half up to the nearest cent, so the tax on an Invoice is always the sum of
+the tax shown on its lines:
+
+	Subtotal = Quantity * UnitPrice - Discount
+	Tax = round(Subtotal * TaxRate / 10000)
+	Total = Subtotal + Tax
+
+A quantity of 0 is treated as 1. Unit prices and discounts can't be negative, a discount can't be larger than the line's
+price, and the tax rate can't be more than 100% (10000 basis points).
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (lineItem *InvoiceLineItem) Calculate() error {
+	if lineItem.Quantity == 0 {
+		lineItem.Quantity = 1
+	}
+
+	if lineItem.UnitPrice < 0 || lineItem.Discount < 0 {
+		return fmt.Errorf("%w: line item '%s' can't have a negative unit price or discount", ErrInvalidInvoice, lineItem.Description)
+	}
+
+	var price int64 = int64(lineItem.Quantity) * int64(lineItem.UnitPrice)
+	if int64(lineItem.Discount) > price {
+		return fmt.Errorf("%w: line item '%s' has a discount (%d) larger than its price (%d)", ErrInvalidInvoice, lineItem.Description, lineItem.Discount, price)
+	}
+
+	if int64(lineItem.TaxRate) > basisPointsPerUnit {
+		return fmt.Errorf("%w: line item '%s' has a tax rate (%d basis points) over 100%%", ErrInvalidInvoice, lineItem.Description, lineItem.TaxRate)
+	}
+
+	var subtotal int64 = price - int64(lineItem.Discount)
+	lineItem.Subtotal = int(subtotal)
+	lineItem.Tax = int(roundHalfUp(subtotal*int64(lineItem.TaxRate), basisPointsPerUnit))
+	lineItem.Total = lineItem.Subtotal + lineItem.Tax
+
+	return nil
+}
+
+/*
+*Description*
+
+func roundHalfUp
+
+Divides a non-negative numerator by a positive denominator, rounding halves up (e.g. 12.5 cents rounds to 13 cents).
+
+*Parameters*
+
+	numerator  <int64>
+
+		The non-negative value being divided.
+
+	denominator  <int64>
+
+		The positive value to divide by.
+
+*Returns*
+
+	_  <int64>
+
+		The rounded quotient.
+*/
+func roundHalfUp(numerator int64, denominator int64) int64 {
+	return (2*numerator + denominator) / (2 * denominator)
+}
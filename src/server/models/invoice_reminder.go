@@ -0,0 +1,568 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"server/money"
+	"server/notifications"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all InvoiceReminder records in the database (one record per payment reminder sent for an Invoice)
+//
+// Reminders escalate as an invoice goes further past its due date (see 'dunningSchedule'). Each stage of the schedule is sent at most
+// once per invoice, and a stage is never sent after a later stage has been sent.
+type InvoiceReminder struct {
+	gorm.Model
+	InvoiceID uint      `gorm:"column:invoice_id;not null;uniqueIndex:idx_invoice_reminders_invoice_level" json:"invoice_id"` // ID of Invoice that the reminder is for
+	UserID    uint      `gorm:"column:user_id;index" json:"user_id"`                                                          // ID of User billed by the invoice (the recipient)
+	Level     uint      `gorm:"column:level;not null;uniqueIndex:idx_invoice_reminders_invoice_level" json:"level"`           // Position of the reminder's stage in the dunning schedule (1 for the first reminder)
+	Stage     string    `gorm:"column:stage;not null" json:"stage"`                                                           // Name of the reminder's stage (Upcoming, Overdue, Second Notice, Final Notice)
+	Recipient string    `gorm:"column:recipient;not null" json:"recipient"`                                                   // Address that the reminder was sent to
+	Notifier  string    `gorm:"column:notifier;not null" json:"notifier"`                                                     // Name of the notifier that sent the reminder (e.g. smtp)
+	SentAt    time.Time `gorm:"column:sent_at;not null" json:"sent_at"`                                                       // Date/time when the reminder was sent
+}
+
+// A stage of the dunning schedule
+type dunningStage struct {
+	Level       uint   // Position of the stage in the schedule
+	Name        string // Name of the stage
+	DaysFromDue int    // Days after the due date that the stage's reminder is sent (negative for days before the due date)
+	Subject     string // Subject line, formatted with the invoice number
+	Intro       string // First paragraph of the message
+}
+
+// Escalating reminders sent for unpaid invoices, in the order they are sent
+var dunningSchedule []dunningStage = []dunningStage{
+	{
+		Level:       1,
+		Name:        "Upcoming",
+		DaysFromDue: -3,
+		Subject:     "Invoice %s is due soon",
+		Intro:       "This is a friendly reminder that the invoice below is due soon.",
+	},
+	{
+		Level:       2,
+		Name:        "Overdue",
+		DaysFromDue: 1,
+		Subject:     "Invoice %s is overdue",
+		Intro:       "The invoice below was due and has not been paid in full. Please pay the amount due at your earliest convenience.",
+	},
+	{
+		Level:       3,
+		Name:        "Second Notice",
+		DaysFromDue: 7,
+		Subject:     "Second notice: invoice %s is overdue",
+		Intro:       "The invoice below is now a week overdue. Please pay the amount due as soon as possible.",
+	},
+	{
+		Level:       4,
+		Name:        "Final Notice",
+		DaysFromDue: 14,
+		Subject:     "Final notice: invoice %s is overdue",
+		Intro:       "This is the final reminder for the invoice below. Please pay the amount due immediately or contact us to arrange payment.",
+	},
+}
+
+// Kind of the messages sent for invoice reminders (see 'notifications.Message')
+const ReminderMessageKind string = "invoice.reminder"
+
+// Statuses of invoices that are still owed
+var owedInvoiceStatuses []string = []string{
+	InvoiceStatusUnpaid,
+	InvoiceStatusPartiallyPaid,
+	InvoiceStatusOverdue,
+}
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from InvoiceReminder object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the invoice reminder object
+*/
+func (reminder *InvoiceReminder) GetID() uint {
+	return reminder.ID
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves all InvoiceReminder records in the database with the specified secondary ID (e.g. "invoice_id"), oldest first.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key of the secondary ID.
+
+	secondaryID  <uint>
+
+		The secondary ID.
+
+*Returns*
+
+	_  <[]InvoiceReminder>
+
+		The matching InvoiceReminder records.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (reminder *InvoiceReminder) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]InvoiceReminder, error) {
+	var reminders []InvoiceReminder
+	err := db.Where(fmt.Sprintf("%s = ?", secondaryIDJsonKey), secondaryID).Order("level").Find(&reminders).Error
+	return reminders, err
+}
+
+/*
+*Description*
+
+func getDueStage
+
+Returns the latest stage of the dunning schedule whose reminder is due for an invoice as of the specified time.
+
+*Parameters*
+
+	dueAt  <time.Time>
+
+		The invoice's due date.
+
+	asOf  <time.Time>
+
+		The current time.
+
+*Returns*
+
+	_  <*dunningStage>
+
+		The stage (nil if no reminder is due yet).
+*/
+func getDueStage(dueAt time.Time, asOf time.Time) *dunningStage {
+	var dueStage *dunningStage
+	for i := range dunningSchedule {
+		if !dueAt.AddDate(0, 0, dunningSchedule[i].DaysFromDue).After(asOf) {
+			dueStage = &dunningSchedule[i]
+		}
+	}
+
+	return dueStage
+}
+
+/*
+*Description*
+
+func MarkOverdueInvoices
+
+Moves every issued Invoice that is still owed after its due date to the Overdue status. This is run periodically by the dunning job.
+Payments on an overdue invoice keep it Overdue until it is paid in full.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be updated.
+
+	asOf  <time.Time>
+
+		The time to compare due dates with (normally the current time).
+
+*Returns*
+
+	_  <[]Invoice>
+
+		The invoices that became overdue.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) MarkOverdueInvoices(db *gorm.DB, asOf time.Time) ([]Invoice, error) {
+	var overdueInvoices []Invoice
+	err := db.Session(&gorm.Session{SkipHooks: true}).Model(&overdueInvoices).Clauses(clause.Returning{}).
+		Where("state = ? AND status IN ? AND due_at < ? AND remaining_balance > 0", InvoiceStateIssued, []string{InvoiceStatusUnpaid, InvoiceStatusPartiallyPaid}, asOf).
+		Update("status", InvoiceStatusOverdue).Error
+
+	return overdueInvoices, err
+}
+
+/*
+*Description*
+
+func ApplyLateFees
+
+Charges a late fee for every Overdue Invoice whose Business charges late fees (see 'Business.GetLateFee') once its grace period has
+passed. This is run periodically by the dunning job.
+
+The late fee is billed on a new invoice that is issued straight away and refers to the overdue invoice (see 'LateFeeForID'). Each
+overdue invoice is charged one late fee at most, and late fees are never charged on other late fees. Each invoice is handled in its own
+transaction, so an invoice that fails is retried on the next run.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	asOf  <time.Time>
+
+		The time to compare due dates with (normally the current time).
+
+*Returns*
+
+	_  <[]Invoice>
+
+		The issued late fee invoices.
+
+	_  <error>
+
+		The first error encountered.
+*/
+func (invoice *Invoice) ApplyLateFees(db *gorm.DB, asOf time.Time) ([]Invoice, error) {
+	var feeInvoices []Invoice
+	var firstErr error
+
+	var overdueIDs []uint
+	err := db.Model(&Invoice{}).
+		Joins("JOIN businesses ON businesses.id = invoices.business_id AND businesses.deleted_at IS NULL").
+		Where("invoices.state = ? AND invoices.status = ? AND invoices.late_fee_for_id = 0 AND invoices.remaining_balance > 0", InvoiceStateIssued, InvoiceStatusOverdue).
+		Where("businesses.late_fees_enabled AND invoices.due_at + businesses.late_fee_grace_days * INTERVAL '1 day' <= ?", asOf).
+		Where("NOT EXISTS (SELECT 1 FROM invoices AS fees WHERE fees.late_fee_for_id = invoices.id AND fees.deleted_at IS NULL)").
+		Order("invoices.id").
+		Pluck("invoices.id", &overdueIDs).Error
+	if err != nil {
+		return feeInvoices, err
+	}
+
+	for _, overdueID := range overdueIDs {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			overdueInvoice := &Invoice{}
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(overdueInvoice, overdueID).Error
+			if err != nil {
+				return err
+			}
+
+			feeInvoice, err := overdueInvoice.chargeLateFee(tx, asOf)
+			if err != nil || feeInvoice == nil {
+				return err
+			}
+
+			feeInvoices = append(feeInvoices, *feeInvoice)
+			return nil
+		})
+
+		if err != nil {
+			log.Printf("ERROR:  Late fee for Invoice ID (%d) could not be charged.  [%s]", overdueID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return feeInvoices, firstErr
+}
+
+/*
+*Description*
+
+func chargeLateFee
+
+Charges the late fee for the calling overdue Invoice (see 'ApplyLateFees') on a new invoice, and issues it.
+
+The calling Invoice record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be created.
+
+	asOf  <time.Time>
+
+		The date/time when the late fee is charged.
+
+*Returns*
+
+	_  <*Invoice>
+
+		The issued late fee invoice (nil if no late fee is charged).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) chargeLateFee(db *gorm.DB, asOf time.Time) (*Invoice, error) {
+	if invoice.Status != InvoiceStatusOverdue || invoice.LateFeeForID != 0 {
+		return nil, nil
+	}
+
+	var existingFees int64
+	err := db.Model(&Invoice{}).Where("late_fee_for_id = ?", invoice.ID).Count(&existingFees).Error
+	if err != nil || existingFees > 0 {
+		return nil, err
+	}
+
+	business := &Business{}
+	err = db.First(business, invoice.BusinessID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	fee := business.GetLateFee(invoice.RemainingBalance)
+	if fee <= 0 {
+		return nil, nil
+	}
+
+	feeInvoice := &Invoice{UserID: invoice.UserID, BusinessID: invoice.BusinessID, Currency: invoice.Currency}
+	_, err = feeInvoice.CreateWithLineItems(db, []InvoiceLineItem{{
+		Description: fmt.Sprintf("Late fee for invoice %s", invoice.Number),
+		Quantity:    1,
+		UnitPrice:   fee,
+		Currency:    invoice.Currency,
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	feeInvoice.LateFeeForID = invoice.ID
+	err = db.Model(feeInvoice).Update("late_fee_for_id", feeInvoice.LateFeeForID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return feeInvoice, feeInvoice.issue(db, asOf)
+}
+
+/*
+*Description*
+
+func SendDueReminders
+
+Sends the payment reminders that are due as of the specified time through the specified notifier. This is run periodically by the
+dunning job.
+
+Reminders are sent for issued invoices that are still owed, following the dunning schedule (see 'dunningSchedule'). Only the latest
+stage that is due is sent, so an invoice that is already two weeks overdue when it is first reminded gets the final notice rather than
+every earlier reminder at once. Reminders are recorded in the same transaction they are sent in, so a reminder that can't be delivered
+is retried on the next run. Users without an email address are not reminded.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	notifier  <notifications.Notifier>
+
+		The notifier that delivers the reminders.
+
+	asOf  <time.Time>
+
+		The time to compare due dates with (normally the current time).
+
+*Returns*
+
+	_  <[]InvoiceReminder>
+
+		The reminders that were sent.
+
+	_  <error>
+
+		The first error encountered.
+*/
+func (reminder *InvoiceReminder) SendDueReminders(db *gorm.DB, notifier notifications.Notifier, asOf time.Time) ([]InvoiceReminder, error) {
+	var reminders []InvoiceReminder
+	var firstErr error
+
+	var invoiceIDs []uint
+	err := db.Model(&Invoice{}).
+		Where("state = ? AND status IN ? AND remaining_balance > 0 AND due_at <= ?", InvoiceStateIssued, owedInvoiceStatuses, asOf.AddDate(0, 0, -dunningSchedule[0].DaysFromDue)).
+		Order("id").
+		Pluck("id", &invoiceIDs).Error
+	if err != nil {
+		return reminders, err
+	}
+
+	for _, invoiceID := range invoiceIDs {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			invoice := &Invoice{}
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(invoice, invoiceID).Error
+			if err != nil {
+				return err
+			}
+
+			sentReminder, err := invoice.sendReminder(tx, notifier, asOf)
+			if err != nil || sentReminder == nil {
+				return err
+			}
+
+			reminders = append(reminders, *sentReminder)
+			return nil
+		})
+
+		if err != nil {
+			log.Printf("ERROR:  Reminder for Invoice ID (%d) could not be sent.  [%s]", invoiceID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return reminders, firstErr
+}
+
+/*
+*Description*
+
+func sendReminder
+
+Sends the reminder that is due for the calling Invoice (see 'SendDueReminders') and records it.
+
+The calling Invoice record should be locked by the calling transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the record will be created.
+
+	notifier  <notifications.Notifier>
+
+		The notifier that delivers the reminder.
+
+	asOf  <time.Time>
+
+		The time to compare the due date with.
+
+*Returns*
+
+	_  <*InvoiceReminder>
+
+		The reminder that was sent (nil if no reminder is due).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) sendReminder(db *gorm.DB, notifier notifications.Notifier, asOf time.Time) (*InvoiceReminder, error) {
+	if invoice.DueAt == nil || invoice.RemainingBalance <= 0 || !slices.Contains(owedInvoiceStatuses, invoice.Status) {
+		return nil, nil
+	}
+
+	stage := getDueStage(*invoice.DueAt, asOf)
+	if stage == nil {
+		return nil, nil
+	}
+
+	var lastLevel uint
+	err := db.Model(&InvoiceReminder{}).Select("COALESCE(MAX(level), 0)").Where("invoice_id = ?", invoice.ID).Scan(&lastLevel).Error
+	if err != nil || stage.Level <= lastLevel {
+		return nil, err
+	}
+
+	user := &User{}
+	err = db.Limit(1).Find(user, invoice.UserID).Error
+	if err != nil || user.ID == 0 || user.Email == "" {
+		return nil, err
+	}
+
+	business := &Business{}
+	err = db.Limit(1).Find(business, invoice.BusinessID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	message := notifications.Message{
+		To:      user.Email,
+		ToName:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Subject: fmt.Sprintf(stage.Subject, invoice.Number),
+		Body:    invoice.formatReminder(stage, user, business),
+		Kind:    ReminderMessageKind,
+	}
+
+	reminder := &InvoiceReminder{
+		InvoiceID: invoice.ID,
+		UserID:    invoice.UserID,
+		Level:     stage.Level,
+		Stage:     stage.Name,
+		Recipient: user.Email,
+		Notifier:  notifier.Name(),
+		SentAt:    asOf,
+	}
+
+	err = db.Create(reminder).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reminder, notifier.Send(message)
+}
+
+/*
+*Description*
+
+func formatReminder
+
+Formats the body of a payment reminder for the calling Invoice.
+
+*Parameters*
+
+	stage  <*dunningStage>
+
+		The stage of the dunning schedule that the reminder is for.
+
+	user  <*User>
+
+		The recipient.
+
+	business  <*Business>
+
+		The Business that issued the invoice (its ID is 0 if the invoice has no business).
+
+*Returns*
+
+	_  <string>
+
+		The body of the reminder.
+*/
+func (invoice *Invoice) formatReminder(stage *dunningStage, user *User, business *Business) string {
+	var body strings.Builder
+
+	greeting := "Hello"
+	if user.FirstName != "" {
+		greeting = fmt.Sprintf("Hello %s", user.FirstName)
+	}
+
+	fmt.Fprintf(&body, "%s,\n\n%s\n\n", greeting, stage.Intro)
+	fmt.Fprintf(&body, "Invoice: %s\n", invoice.Number)
+	fmt.Fprintf(&body, "Amount due: %s\n", formatAmount(invoice.RemainingBalance, invoice.Currency, money.DefaultLocale))
+	fmt.Fprintf(&body, "Due date: %s\n", invoice.DueAt.Format("January 2, 2006"))
+
+	if business.Name != "" {
+		fmt.Fprintf(&body, "\nThank you,\n%s\n", business.Name)
+	}
+
+	return body.String()
+}
@@ -0,0 +1,447 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Number of records in a page of a list when the request doesn't set a limit, and the most that a request can ask for
+const (
+	DefaultListLimit int = 50
+	MaxListLimit     int = 200
+)
+
+// Error returned when the limit, cursor, sort or filters of a list request are invalid
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+// Types of the fields that lists can be sorted and filtered by (determines how cursor and filter values are parsed)
+const (
+	listFieldInt    string = "int"
+	listFieldString string = "string"
+	listFieldBool   string = "bool"
+	listFieldTime   string = "time"
+)
+
+// Filter operators, as set in a filter's key (e.g. "price[gte]"). Filters without an operator match values that are equal
+var listFilterOperators map[string]string = map[string]string{
+	"eq":  "=",
+	"ne":  "<>",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+	"in":  "IN",
+}
+
+// A field that a list of records can be sorted and filtered by
+type listField struct {
+	column    string // Column of the field in the table
+	jsonKey   string // Key of the field in the records' JSON (read to build the cursor of the next page)
+	fieldType string // Type of the field's values (see 'listFieldInt')
+	sortable  bool   // True if the list can be sorted by the field (only set for columns that are never null)
+}
+
+// Paging, sorting and filtering of a list of records (see 'listRecords')
+type ListOptions struct {
+	Limit   int               // Number of records in the page (defaults to 'DefaultListLimit', at most 'MaxListLimit')
+	Cursor  string            // Cursor of the page, from the previous page's 'ListPage.NextCursor' (empty for the first page)
+	Sort    string            // Comma separated fields to sort by, each prefixed with '-' to sort in descending order (defaults to "id")
+	Filters map[string]string // Filters keyed by field and optional operator (e.g. "status" or "price[gte]"). 'in' filters take comma separated values
+}
+
+// A page of a list of records, with the total number of records that match the list's filters
+type ListPage struct {
+	Data       interface{} `json:"data"`        // Records in the page
+	TotalCount int64       `json:"total_count"` // Number of records that match the filters, on every page
+	Limit      int         `json:"limit"`       // Most records that a page holds
+	NextCursor string      `json:"next_cursor"` // Cursor of the next page (empty on the last page)
+}
+
+// Sort order of one field of a list
+type listSort struct {
+	field      listField
+	name       string
+	descending bool
+}
+
+// Contents of a list cursor: the sort that it was made for and the sorted field values of the last record of the previous page
+type listCursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+}
+
+/*
+*Description*
+
+func parseListValue
+
+Parses the value of a filter or cursor for the type of the field that it is compared with.
+
+*Parameters*
+
+	field  <listField>
+
+		The field that the value is compared with.
+
+	name  <string>
+
+		The name of the field (used in error messages).
+
+	value  <string>
+
+		The value. Times are dates (YYYY-MM-DD, the start of that day in UTC) or date/times (RFC 3339).
+
+*Returns*
+
+	_  <interface{}>
+
+		The parsed value.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func parseListValue(field listField, name string, value string) (interface{}, error) {
+	switch field.fieldType {
+	case listFieldInt:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s '%s' must be a whole number", ErrInvalidListOptions, name, value)
+		}
+
+		return parsed, nil
+	case listFieldBool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s '%s' must be true or false", ErrInvalidListOptions, name, value)
+		}
+
+		return parsed, nil
+	case listFieldTime:
+		if date, err := time.Parse("2006-01-02", value); err == nil {
+			return date, nil
+		}
+
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s '%s' must be a date (YYYY-MM-DD) or a date/time (RFC 3339)", ErrInvalidListOptions, name, value)
+		}
+
+		return parsed, nil
+	default:
+		return value, nil
+	}
+}
+
+/*
+*Description*
+
+func parseListSort
+
+Parses the sort of a list. The list's records are always sorted by ID last, so records that sort equally stay in a stable order across
+pages.
+
+*Parameters*
+
+	fields  <map[string]listField>
+
+		The fields that the list can be sorted by, keyed by name.
+
+	sort  <string>
+
+		Comma separated field names, each prefixed with '-' to sort in descending order (e.g. "-price,name").
+
+*Returns*
+
+	_  <[]listSort>
+
+		The sort order of each field.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func parseListSort(fields map[string]listField, sort string) ([]listSort, error) {
+	var sorts []listSort
+	sortedByID := false
+
+	for _, name := range strings.Split(sort, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		descending := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		field, found := fields[name]
+		if !found || !field.sortable {
+			return nil, fmt.Errorf("%w: can't sort by '%s'", ErrInvalidListOptions, name)
+		}
+
+		sorts = append(sorts, listSort{field: field, name: name, descending: descending})
+		sortedByID = sortedByID || name == "id"
+	}
+
+	if !sortedByID {
+		sorts = append(sorts, listSort{field: fields["id"], name: "id"})
+	}
+
+	return sorts, nil
+}
+
+/*
+*Description*
+
+func applyListFilters
+
+Adds the filters of a list to a query. Filters are keyed by field name and an optional operator in brackets (e.g. "price[gte]"), and
+their values are parsed for the field's type.
+
+*Parameters*
+
+	query  <*gorm.DB>
+
+		The query that the filters are added to.
+
+	fields  <map[string]listField>
+
+		The fields that the list can be filtered by, keyed by name.
+
+	filters  <map[string]string>
+
+		The filters' values, keyed by field name and operator.
+
+*Returns*
+
+	_  <*gorm.DB>
+
+		The filtered query.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func applyListFilters(query *gorm.DB, fields map[string]listField, filters map[string]string) (*gorm.DB, error) {
+	for key, value := range filters {
+		name, operator := key, "eq"
+		if open := strings.Index(key, "["); open > 0 && strings.HasSuffix(key, "]") {
+			name, operator = key[:open], key[open+1:len(key)-1]
+		}
+
+		field, found := fields[name]
+		if !found {
+			return query, fmt.Errorf("%w: can't filter by '%s'", ErrInvalidListOptions, name)
+		}
+
+		sqlOperator, found := listFilterOperators[operator]
+		if !found {
+			return query, fmt.Errorf("%w: '%s' is not a filter operator (eq, ne, gt, gte, lt, lte, in)", ErrInvalidListOptions, operator)
+		}
+
+		ordered := sqlOperator != "=" && sqlOperator != "<>" && sqlOperator != "IN"
+		if ordered && (field.fieldType == listFieldBool || field.fieldType == listFieldString) {
+			return query, fmt.Errorf("%w: %s can only be filtered by eq, ne or in", ErrInvalidListOptions, name)
+		}
+
+		if sqlOperator != "IN" {
+			parsed, err := parseListValue(field, name, value)
+			if err != nil {
+				return query, err
+			}
+
+			query = query.Where(fmt.Sprintf("%s %s ?", field.column, sqlOperator), parsed)
+			continue
+		}
+
+		var values []interface{}
+		for _, item := range strings.Split(value, ",") {
+			parsed, err := parseListValue(field, name, strings.TrimSpace(item))
+			if err != nil {
+				return query, err
+			}
+
+			values = append(values, parsed)
+		}
+
+		query = query.Where(fmt.Sprintf("%s IN ?", field.column), values)
+	}
+
+	return query, nil
+}
+
+/*
+*Description*
+
+func listRecords
+
+Retrieves a page of a list of records from the database, sorted and filtered as requested. Pages are found with a cursor (the sorted
+values of the last record of the previous page) instead of an offset, so pages stay fast to find however deep they are and records
+aren't skipped or repeated when records are added to earlier pages.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	records  <interface{}>
+
+		A pointer to an empty slice of the records' model (e.g. '&[]Service{}') that the page is retrieved into.
+
+	fields  <map[string]listField>
+
+		The fields that the list can be sorted and filtered by, keyed by name. Must include "id".
+
+	options  <ListOptions>
+
+		The limit, cursor, sort and filters of the page.
+
+*Returns*
+
+	_  <ListPage>
+
+		The page of records, with the total number of records that match the filters and the cursor of the next page.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func listRecords(db *gorm.DB, records interface{}, fields map[string]listField, options ListOptions) (ListPage, error) {
+	page := ListPage{Data: reflect.ValueOf(records).Elem().Interface(), Limit: options.Limit}
+
+	if page.Limit == 0 {
+		page.Limit = DefaultListLimit
+	}
+
+	if page.Limit < 0 || page.Limit > MaxListLimit {
+		return page, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListOptions, MaxListLimit)
+	}
+
+	sorts, err := parseListSort(fields, options.Sort)
+	if err != nil {
+		return page, err
+	}
+
+	query, err := applyListFilters(db.Model(records), fields, options.Filters)
+	if err != nil {
+		return page, err
+	}
+	query = query.Session(&gorm.Session{})
+
+	err = query.Count(&page.TotalCount).Error
+	if err != nil {
+		return page, err
+	}
+
+	if options.Cursor != "" {
+		cursor := listCursor{}
+		decoded, err := base64.RawURLEncoding.DecodeString(options.Cursor)
+		if err == nil {
+			err = json.Unmarshal(decoded, &cursor)
+		}
+
+		if err != nil || cursor.Sort != options.Sort || len(cursor.Values) != len(sorts) {
+			return page, fmt.Errorf("%w: the cursor is invalid or was made for a different sort", ErrInvalidListOptions)
+		}
+
+		var cursorValues []interface{}
+		for i, sort := range sorts {
+			var value interface{}
+			decoder := json.NewDecoder(bytes.NewReader(cursor.Values[i]))
+			decoder.UseNumber()
+			err := decoder.Decode(&value)
+			if err == nil && value != nil {
+				value, err = parseListValue(sort.field, sort.name, fmt.Sprint(value))
+			}
+
+			if err != nil || value == nil {
+				return page, fmt.Errorf("%w: the cursor is invalid", ErrInvalidListOptions)
+			}
+
+			cursorValues = append(cursorValues, value)
+		}
+
+		// Records after the cursor sort after it on the first field, or equal it on the fields before one that they sort after it on
+		var conditions []string
+		var values []interface{}
+		for i, sort := range sorts {
+			operator := ">"
+			if sort.descending {
+				operator = "<"
+			}
+
+			var terms []string
+			for j, previous := range sorts[:i] {
+				terms = append(terms, fmt.Sprintf("%s = ?", previous.field.column))
+				values = append(values, cursorValues[j])
+			}
+
+			terms = append(terms, fmt.Sprintf("%s %s ?", sort.field.column, operator))
+			values = append(values, cursorValues[i])
+			conditions = append(conditions, "("+strings.Join(terms, " AND ")+")")
+		}
+
+		query = query.Where(strings.Join(conditions, " OR "), values...)
+	}
+
+	for _, sort := range sorts {
+		order := sort.field.column
+		if sort.descending {
+			order += " DESC"
+		}
+
+		query = query.Order(order)
+	}
+
+	// One extra record is retrieved to tell whether there is a next page
+	err = query.Limit(page.Limit + 1).Find(records).Error
+	if err != nil {
+		return page, err
+	}
+
+	found := reflect.ValueOf(records).Elem()
+	if found.Len() > page.Limit {
+		found.Set(found.Slice(0, page.Limit))
+
+		encoded, err := json.Marshal(found.Index(page.Limit - 1).Interface())
+		if err != nil {
+			return page, err
+		}
+
+		var last map[string]json.RawMessage
+		err = json.Unmarshal(encoded, &last)
+		if err != nil {
+			return page, err
+		}
+
+		cursor := listCursor{Sort: options.Sort}
+		for _, sort := range sorts {
+			cursor.Values = append(cursor.Values, last[sort.field.jsonKey])
+		}
+
+		encoded, err = json.Marshal(cursor)
+		if err != nil {
+			return page, err
+		}
+
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(encoded)
+	}
+
+	if found.Len() == 0 {
+		found.Set(reflect.MakeSlice(found.Type(), 0, 0))
+	}
+
+	page.Data = found.Interface()
+
+	return page, nil
+}
@@ -0,0 +1,555 @@
+package models
+
+import "server/money"
+
+// Interface for records whose amounts are formatted for the requester's locale in API responses
+//
+// Amounts are always stored and returned in the smallest unit of the record's currency (e.g. cents). 'Localize' adds a 'display'
+// object with the same amounts written the way they are read in the locale (e.g. "1 234,56 $" in fr-CA), so clients don't need to
+// know each currency's decimal places or symbol.
+type Localizable interface {
+	Localize(locale string)
+}
+
+// Service amounts formatted for a locale (see 'Localizable')
+type ServiceDisplay struct {
+	Locale    string `json:"locale"`     // Locale that the amounts are formatted for (e.g. "en-CA")
+	Price     string `json:"price"`      // Price for the service
+	CancelFee string `json:"cancel_fee"` // Fee for cancelling after the minimum notice cutoff
+}
+
+// ClassPack or MembershipPlan price formatted for a locale (see 'Localizable')
+type PriceDisplay struct {
+	Locale string `json:"locale"` // Locale that the price is formatted for (e.g. "en-CA")
+	Price  string `json:"price"`  // Price of the class pack or membership plan
+}
+
+// Invoice amounts formatted for a locale (see 'Localizable')
+type InvoiceDisplay struct {
+	Locale           string `json:"locale"`            // Locale that the amounts are formatted for (e.g. "en-CA")
+	Subtotal         string `json:"subtotal"`          // Total of the line items after discounts, before tax
+	DiscountTotal    string `json:"discount_total"`    // Total discount taken off the line items
+	TaxTotal         string `json:"tax_total"`         // Total tax on the line items
+	OriginalBalance  string `json:"original_balance"`  // Total original balance
+	CreditTotal      string `json:"credit_total"`      // Total credited by the invoice's credit notes
+	RemainingBalance string `json:"remaining_balance"` // Remaining balance
+}
+
+// InvoiceLineItem amounts formatted for a locale (see 'Localizable')
+type InvoiceLineItemDisplay struct {
+	Locale    string `json:"locale"`     // Locale that the amounts are formatted for (e.g. "en-CA")
+	UnitPrice string `json:"unit_price"` // Price of each unit
+	Discount  string `json:"discount"`   // Amount taken off the line before tax
+	Subtotal  string `json:"subtotal"`   // Taxable amount
+	Tax       string `json:"tax"`        // Tax on the line
+	Total     string `json:"total"`      // Amount charged for the line
+}
+
+// Payment amounts formatted for a locale (see 'Localizable')
+type PaymentDisplay struct {
+	Locale       string `json:"locale"`        // Locale that the amounts are formatted for (e.g. "en-CA")
+	Amount       string `json:"amount"`        // Amount paid
+	BalanceAfter string `json:"balance_after"` // Remaining balance of the invoice right after the payment was applied
+}
+
+// CreditNote amounts formatted for a locale (see 'Localizable')
+type CreditNoteDisplay struct {
+	Locale string `json:"locale"` // Locale that the amounts are formatted for (e.g. "en-CA")
+	Amount string `json:"amount"` // Amount credited
+	Tax    string `json:"tax"`    // Part of the amount that credits tax
+}
+
+// OutstandingBalance amounts formatted for a locale (see 'Localizable')
+type OutstandingBalanceDisplay struct {
+	Locale        string `json:"locale"`         // Locale that the amounts are formatted for (e.g. "en-CA")
+	Amount        string `json:"amount"`         // Total still owed
+	OverdueAmount string `json:"overdue_amount"` // Part of the total that is overdue
+}
+
+// Refund amount formatted for a locale (see 'Localizable')
+type RefundDisplay struct {
+	Locale string `json:"locale"` // Locale that the amount is formatted for (e.g. "en-CA")
+	Amount string `json:"amount"` // Amount refunded
+}
+
+// CancellationRefund amounts formatted for a locale (see 'Localizable')
+type CancellationRefundDisplay struct {
+	Locale           string `json:"locale"`            // Locale that the amounts are formatted for (e.g. "en-CA")
+	RemainingBalance string `json:"remaining_balance"` // Remaining balance of the invoice after the refund
+	AmountPaid       string `json:"amount_paid"`       // Amount paid before the refund
+	CancelFee        string `json:"cancel_fee"`        // Cancellation fee charged
+	AmountRetained   string `json:"amount_retained"`   // Part of the amount paid that the Business keeps
+	AmountRefunded   string `json:"amount_refunded"`   // Amount refunded
+	AmountPending    string `json:"amount_pending"`    // Amount waiting for the payment provider to confirm the refund
+}
+
+// PromoCode fixed discount formatted for a locale (see 'Localizable')
+type PromoCodeDisplay struct {
+	Locale    string `json:"locale"`     // Locale that the amount is formatted for (e.g. "en-CA")
+	AmountOff string `json:"amount_off"` // Amount taken off each appointment (empty for percentage discounts)
+}
+
+// StoreCreditAccount balance formatted for a locale (see 'Localizable')
+type StoreCreditAccountDisplay struct {
+	Locale  string `json:"locale"`  // Locale that the amount is formatted for (e.g. "en-CA")
+	Balance string `json:"balance"` // Credit available to spend
+}
+
+// GiftCard amounts formatted for a locale (see 'Localizable')
+type GiftCardDisplay struct {
+	Locale        string `json:"locale"`         // Locale that the amounts are formatted for (e.g. "en-CA")
+	InitialAmount string `json:"initial_amount"` // Amount loaded onto the gift card when it was issued
+	Balance       string `json:"balance"`        // Amount that can still be spent
+}
+
+// StoreCreditTransaction or GiftCardTransaction amounts formatted for a locale (see 'Localizable')
+type CreditTransactionDisplay struct {
+	Locale       string `json:"locale"`        // Locale that the amounts are formatted for (e.g. "en-CA")
+	Amount       string `json:"amount"`        // Change in balance
+	BalanceAfter string `json:"balance_after"` // Balance right after the change
+}
+
+// Discount given with a PromoCode formatted for a locale (see 'Localizable')
+type PromoCodeRedemptionDisplay struct {
+	Locale   string `json:"locale"`   // Locale that the amount is formatted for (e.g. "en-CA")
+	Discount string `json:"discount"` // Discount taken off the invoice(s)
+}
+
+/*
+*Description*
+
+func formatAmount
+
+Formats an amount in the smallest unit of a currency for a locale (see 'money.Money.Format').
+
+*Parameters*
+
+	amount  <int>
+
+		The amount (e.g. in cents).
+
+	currency  <string>
+
+		The ISO 4217 currency code ("" for 'money.DefaultCurrency').
+
+	locale  <string>
+
+		The locale.
+
+*Returns*
+
+	_  <string>
+
+		The formatted amount.
+*/
+func formatAmount(amount int, currency string, locale string) string {
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	return money.Money{Amount: amount, Currency: currency}.Format(locale)
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling Service's price and cancellation fee for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (service *Service) Localize(locale string) {
+	service.Display = &ServiceDisplay{
+		Locale:    locale,
+		Price:     formatAmount(int(service.Price), service.Currency, locale),
+		CancelFee: formatAmount(int(service.CancelFee), service.Currency, locale),
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling ClassPack's price for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (pack *ClassPack) Localize(locale string) {
+	pack.Display = &PriceDisplay{Locale: locale, Price: formatAmount(int(pack.Price), pack.Currency, locale)}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling MembershipPlan's price for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (plan *MembershipPlan) Localize(locale string) {
+	plan.Display = &PriceDisplay{Locale: locale, Price: formatAmount(int(plan.Price), plan.Currency, locale)}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling Invoice's totals and balances for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (invoice *Invoice) Localize(locale string) {
+	invoice.Display = &InvoiceDisplay{
+		Locale:           locale,
+		Subtotal:         formatAmount(invoice.Subtotal, invoice.Currency, locale),
+		DiscountTotal:    formatAmount(invoice.DiscountTotal, invoice.Currency, locale),
+		TaxTotal:         formatAmount(invoice.TaxTotal, invoice.Currency, locale),
+		OriginalBalance:  formatAmount(invoice.OriginalBalance, invoice.Currency, locale),
+		CreditTotal:      formatAmount(invoice.CreditTotal, invoice.Currency, locale),
+		RemainingBalance: formatAmount(invoice.RemainingBalance, invoice.Currency, locale),
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling InvoiceLineItem's amounts for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (lineItem *InvoiceLineItem) Localize(locale string) {
+	lineItem.Display = &InvoiceLineItemDisplay{
+		Locale:    locale,
+		UnitPrice: formatAmount(lineItem.UnitPrice, lineItem.Currency, locale),
+		Discount:  formatAmount(lineItem.Discount, lineItem.Currency, locale),
+		Subtotal:  formatAmount(lineItem.Subtotal, lineItem.Currency, locale),
+		Tax:       formatAmount(lineItem.Tax, lineItem.Currency, locale),
+		Total:     formatAmount(lineItem.Total, lineItem.Currency, locale),
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling Payment's amount and the balance after it for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (payment *Payment) Localize(locale string) {
+	payment.Display = &PaymentDisplay{
+		Locale:       locale,
+		Amount:       formatAmount(payment.Amount, payment.Currency, locale),
+		BalanceAfter: formatAmount(payment.BalanceAfter, payment.Currency, locale),
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling Refund's amount for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (refund *Refund) Localize(locale string) {
+	refund.Display = &RefundDisplay{Locale: locale, Amount: formatAmount(refund.Amount, refund.Currency, locale)}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling CreditNote's amounts for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (creditNote *CreditNote) Localize(locale string) {
+	creditNote.Display = &CreditNoteDisplay{
+		Locale: locale,
+		Amount: formatAmount(creditNote.Amount, creditNote.Currency, locale),
+		Tax:    formatAmount(creditNote.Tax, creditNote.Currency, locale),
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling OutstandingBalance's amounts for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (balance *OutstandingBalance) Localize(locale string) {
+	balance.Display = &OutstandingBalanceDisplay{
+		Locale:        locale,
+		Amount:        formatAmount(balance.Amount, balance.Currency, locale),
+		OverdueAmount: formatAmount(balance.OverdueAmount, balance.Currency, locale),
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling CancellationRefund's amounts, and the amounts of its refunds, for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (breakdown *CancellationRefund) Localize(locale string) {
+	breakdown.Display = &CancellationRefundDisplay{
+		Locale:           locale,
+		RemainingBalance: formatAmount(breakdown.RemainingBalance, breakdown.Currency, locale),
+		AmountPaid:       formatAmount(breakdown.AmountPaid, breakdown.Currency, locale),
+		CancelFee:        formatAmount(breakdown.CancelFee, breakdown.Currency, locale),
+		AmountRetained:   formatAmount(breakdown.AmountRetained, breakdown.Currency, locale),
+		AmountRefunded:   formatAmount(breakdown.AmountRefunded, breakdown.Currency, locale),
+		AmountPending:    formatAmount(breakdown.AmountPending, breakdown.Currency, locale),
+	}
+
+	for i := range breakdown.Refunds {
+		breakdown.Refunds[i].Localize(locale)
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling PromoCode's fixed discount for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (promo *PromoCode) Localize(locale string) {
+	promo.Display = &PromoCodeDisplay{Locale: locale}
+	if promo.AmountOff > 0 {
+		promo.Display.AmountOff = formatAmount(int(promo.AmountOff), promo.Currency, locale)
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the discounts in the calling PromoCodeRedemptionReport (and its promo code's fixed discount) for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (report *PromoCodeRedemptionReport) Localize(locale string) {
+	if report.PromoCode != nil {
+		report.PromoCode.Localize(locale)
+	}
+
+	for i, total := range report.Totals {
+		report.Totals[i].Display = &PromoCodeRedemptionDisplay{Locale: locale, Discount: formatAmount(total.Discount, total.Currency, locale)}
+	}
+
+	for i, redemption := range report.Redemptions {
+		report.Redemptions[i].Display = &PromoCodeRedemptionDisplay{Locale: locale, Discount: formatAmount(redemption.Discount, redemption.Currency, locale)}
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling StoreCreditAccount's balance for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (account *StoreCreditAccount) Localize(locale string) {
+	account.Display = &StoreCreditAccountDisplay{Locale: locale, Balance: formatAmount(account.Balance, account.Currency, locale)}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling StoreCreditTransaction's amount and the balance after it for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (transaction *StoreCreditTransaction) Localize(locale string) {
+	transaction.Display = &CreditTransactionDisplay{
+		Locale:       locale,
+		Amount:       formatAmount(transaction.Amount, transaction.Currency, locale),
+		BalanceAfter: formatAmount(transaction.BalanceAfter, transaction.Currency, locale),
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling GiftCard's initial amount and balance for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (card *GiftCard) Localize(locale string) {
+	card.Display = &GiftCardDisplay{
+		Locale:        locale,
+		InitialAmount: formatAmount(card.InitialAmount, card.Currency, locale),
+		Balance:       formatAmount(card.Balance, card.Currency, locale),
+	}
+}
+
+/*
+*Description*
+
+func Localize
+
+Formats the calling GiftCardTransaction's amount and the balance after it for a locale (see 'Localizable').
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'money.ParseLocale').
+
+*Returns*
+
+	N/A (None)
+*/
+func (transaction *GiftCardTransaction) Localize(locale string) {
+	transaction.Display = &CreditTransactionDisplay{
+		Locale:       locale,
+		Amount:       formatAmount(transaction.Amount, transaction.Currency, locale),
+		BalanceAfter: formatAmount(transaction.BalanceAfter, transaction.Currency, locale),
+	}
+}
@@ -0,0 +1,438 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all MembershipPlan records in the database (recurring memberships that a Business sells)
+//
+// A MembershipPlan with no MembershipPlanService records includes every Service offered by the Business. A plan with a
+// VisitsPerPeriod of 0 allows unlimited visits to the included Services.
+type MembershipPlan struct {
+	gorm.Model
+	BusinessID      uint          `gorm:"column:business_id;not null;index" json:"business_id"`     // ID of Business that sells the membership plan
+	Name            string        `gorm:"column:name" json:"name"`                                  // Membership plan name (e.g. "Unlimited monthly")
+	Price           uint          `gorm:"column:price" json:"price"`                                // Price (in cents) charged every billing period
+	Currency        string        `gorm:"column:currency;not null;default:USD" json:"currency"`     // ISO 4217 currency of the price (defaults to the Business's currency)
+	BillingInterval string        `gorm:"column:billing_interval;not null" json:"billing_interval"` // Length of each billing period (Weekly, Monthly, Yearly)
+	VisitsPerPeriod uint          `gorm:"column:visits_per_period" json:"visits_per_period"`        // Number of included visits per billing period (0 for unlimited access)
+	Display         *PriceDisplay `gorm:"-" json:"display,omitempty"`                               // Price formatted for the requester's locale (only set in API responses)
+}
+
+// GORM model for all MembershipPlanService records in the database (one record per Service name that a MembershipPlan includes)
+//
+// Services are matched by name, since each scheduled session of a class is a separate Service record.
+type MembershipPlanService struct {
+	gorm.Model
+	MembershipPlanID uint   `gorm:"column:membership_plan_id;not null;index" json:"membership_plan_id"` // ID of MembershipPlan that includes the service
+	ServiceName      string `gorm:"column:service_name;not null" json:"service_name"`                   // Name of the included Service(s)
+}
+
+// Membership plan billing intervals
+const (
+	BillingIntervalWeekly  string = "Weekly"
+	BillingIntervalMonthly string = "Monthly"
+	BillingIntervalYearly  string = "Yearly"
+)
+
+// List of valid membership plan billing intervals
+var billingIntervals []string = []string{
+	BillingIntervalWeekly,
+	BillingIntervalMonthly,
+	BillingIntervalYearly,
+}
+
+// Error returned when a MembershipPlan definition is invalid
+var ErrInvalidMembershipPlan = errors.New("invalid membership plan")
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from MembershipPlan object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the membership plan object
+*/
+func (plan *MembershipPlan) GetID() uint {
+	return plan.ID
+}
+
+/*
+*Description*
+
+func GetPeriodEnd
+
+Returns the end of the billing period that starts at the specified time, based on the calling MembershipPlan's billing interval.
+
+*Parameters*
+
+	periodStart  <time.Time>
+
+		The start of the billing period.
+
+*Returns*
+
+	_  <time.Time>
+
+		The end of the billing period (the start of the next billing period).
+*/
+func (plan *MembershipPlan) GetPeriodEnd(periodStart time.Time) time.Time {
+	switch plan.BillingInterval {
+	case BillingIntervalWeekly:
+		return periodStart.AddDate(0, 0, 7)
+	case BillingIntervalYearly:
+		return periodStart.AddDate(1, 0, 0)
+	default:
+		return periodStart.AddDate(0, 1, 0)
+	}
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new MembershipPlan record in the database and returns the created record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created MembershipPlan object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (plan *MembershipPlan) Create(db *gorm.DB) (map[string]Model, error) {
+	if plan.BillingInterval == "" {
+		plan.BillingInterval = BillingIntervalMonthly
+	}
+
+	if !slices.Contains(billingIntervals, plan.BillingInterval) {
+		return map[string]Model{"membership_plan": plan}, fmt.Errorf("%w: billing interval '%s' must be one of %v", ErrInvalidMembershipPlan, plan.BillingInterval, billingIntervals)
+	}
+
+	currency, err := resolveCurrency(db, plan.BusinessID, plan.Currency)
+	if err != nil {
+		return map[string]Model{"membership_plan": plan}, err
+	}
+	plan.Currency = currency
+
+	err = db.Create(&plan).Error
+	returnRecords := map[string]Model{"membership_plan": plan}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a MembershipPlan record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	planID  <uint>
+
+		The ID of the membership plan record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved MembershipPlan object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (plan *MembershipPlan) Get(db *gorm.DB, planID uint) (map[string]Model, error) {
+	err := db.First(&plan, planID).Error
+	returnRecords := map[string]Model{"membership_plan": plan}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of MembershipPlan records from the database that are associated with the specified secondary key.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]MembershipPlan>
+
+		The list of MembershipPlan records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (plan *MembershipPlan) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]MembershipPlan, error) {
+	var plans []MembershipPlan
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&plans).Error
+	return plans, err
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the specified MembershipPlan record in the database with the specified changes if the record exists.
+
+Returns the updated record along with any errors that are thrown.
+
+Price changes apply to existing subscriptions from their next billing period. Subscribers are moved to a different plan with 'Subscription.ChangePlan'.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	planID  <uint>
+
+		The ID of the membership plan record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated MembershipPlan object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (plan *MembershipPlan) Update(db *gorm.DB, planID uint, updates map[string]interface{}) (map[string]Model, error) {
+	updatePlan := &MembershipPlan{}
+	returnRecords := map[string]Model{"membership_plan": updatePlan}
+
+	if billingInterval, intervalUpdated := updates["billing_interval"]; intervalUpdated && !slices.Contains(billingIntervals, fmt.Sprint(billingInterval)) {
+		return returnRecords, fmt.Errorf("%w: billing interval '%v' must be one of %v", ErrInvalidMembershipPlan, billingInterval, billingIntervals)
+	}
+
+	if err := normalizeCurrencyUpdate(updates); err != nil {
+		return returnRecords, err
+	}
+
+	err := db.First(updatePlan, planID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Model(updatePlan).Clauses(clause.Returning{}).Where("id = ?", planID).Updates(updates).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified MembershipPlan record from the database if it exists, so that no new subscriptions can be started for it.
+
+Existing subscriptions continue to be billed and keep their entitlements. Deleted records are returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted from.
+
+	planID  <uint>
+
+		The ID of the membership plan record being deleted.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the deleted MembershipPlan object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (plan *MembershipPlan) Delete(db *gorm.DB, planID uint) (map[string]Model, error) {
+	deletePlan := &MembershipPlan{}
+	returnRecords := map[string]Model{"membership_plan": deletePlan}
+
+	err := db.First(deletePlan, planID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Delete(deletePlan).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetIncludedServices
+
+Returns the names of the Services that the specified MembershipPlan includes.
+
+An empty list means that the plan includes every Service offered by the Business.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	planID  <uint>
+
+		The ID of the membership plan.
+
+*Returns*
+
+	_  <[]string>
+
+		The list of included Service names.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (plan *MembershipPlan) GetIncludedServices(db *gorm.DB, planID uint) ([]string, error) {
+	serviceNames := []string{}
+
+	err := db.Model(&MembershipPlanService{}).Where("membership_plan_id = ?", planID).Order("service_name").Pluck("service_name", &serviceNames).Error
+	return serviceNames, err
+}
+
+/*
+*Description*
+
+func SetIncludedServices
+
+Replaces the list of Services that the specified MembershipPlan includes.
+
+An empty list makes the plan include every Service offered by the Business.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be replaced.
+
+	planID  <uint>
+
+		The ID of the membership plan.
+
+	serviceNames  <[]string>
+
+		The names of the included Services.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (plan *MembershipPlan) SetIncludedServices(db *gorm.DB, planID uint, serviceNames []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("membership_plan_id = ?", planID).Delete(&MembershipPlanService{}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, serviceName := range serviceNames {
+			err = tx.Create(&MembershipPlanService{MembershipPlanID: planID, ServiceName: serviceName}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+/*
+*Description*
+
+func IncludesService
+
+Returns whether the specified MembershipPlan includes the specified Service.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	planID  <uint>
+
+		The ID of the membership plan.
+
+	service  <*Service>
+
+		The Service to check.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the plan includes the Service, else 'false'.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (plan *MembershipPlan) IncludesService(db *gorm.DB, planID uint, service *Service) (bool, error) {
+	includedServices, err := plan.GetIncludedServices(db, planID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(includedServices) == 0 || slices.Contains(includedServices, service.Name), nil
+}
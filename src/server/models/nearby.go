@@ -0,0 +1,290 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"server/geocoding"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Radius of a nearby business search when the request doesn't set one, and the largest radius that a request can ask for (in miles)
+const (
+	DefaultNearbyRadiusMiles float64 = 10
+	MaxNearbyRadiusMiles     float64 = 250
+)
+
+// Number of businesses that a nearby business search returns when the request doesn't set a limit, and the most that it can ask for
+const (
+	DefaultNearbyLimit int = 20
+	MaxNearbyLimit     int = 100
+)
+
+// Length of one degree of latitude (in miles), used to narrow nearby searches to a bounding box before distances are calculated
+const milesPerDegreeLatitude float64 = 69.0
+
+// A Business found by a nearby business search, with its address, its distance from the search's origin and its number of upcoming Services
+type NearbyBusiness struct {
+	Business
+	Address          *Address `gorm:"-" json:"address"`                                  // Address of the Business
+	DistanceMiles    float64  `gorm:"column:distance_miles" json:"distance_miles"`       // Distance from the search's origin to the Business (in miles)
+	UpcomingServices int64    `gorm:"column:upcoming_services" json:"upcoming_services"` // Number of the Business's Services that haven't started yet
+}
+
+/*
+*Description*
+
+func SetAddress
+
+Sets the address of a Business, creating its Address record or updating the one that it already has. Addresses without coordinates are
+geocoded, so a business can be found by nearby business searches as soon as its address is set. Coordinates that are given (e.g. picked
+on a map) are kept as they are.
+
+The Business's State and ZipCode, which determine its sales tax rate, are not changed.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the address will be saved.
+
+	geocoder  <geocoding.Geocoder>
+
+		The geocoder that finds the coordinates of addresses that don't have them.
+
+	businessID  <uint>
+
+		The ID of the Business whose address is set.
+
+	address  <Address>
+
+		The address (its ID and timestamps are ignored).
+
+*Returns*
+
+	_  <map[string]Model>
+
+		The Business ("business") and its Address ("address").
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (business *Business) SetAddress(db *gorm.DB, geocoder geocoding.Geocoder, businessID uint, address Address) (map[string]Model, error) {
+	address.Model = gorm.Model{}
+	returnRecords := map[string]Model{"business": business, "address": &address}
+
+	if err := address.validate(); err != nil {
+		return returnRecords, err
+	}
+
+	if address.Latitude == nil {
+		coordinates, err := geocoder.Geocode(address.Location())
+		if err != nil {
+			return returnRecords, err
+		}
+
+		address.Latitude, address.Longitude = &coordinates.Latitude, &coordinates.Longitude
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(business, businessID).Error
+		if err != nil {
+			return err
+		}
+
+		if business.AddressID != nil {
+			address.ID = *business.AddressID
+			return tx.Model(&address).Select("address1", "address2", "city", "state", "zip", "latitude", "longitude").Updates(&address).Error
+		}
+
+		err = tx.Create(&address).Error
+		if err != nil {
+			return err
+		}
+
+		business.AddressID = &address.ID
+		return tx.Model(business).Update("address_id", address.ID).Error
+	})
+
+	if err == nil && address.CreatedAt.IsZero() {
+		err = db.First(&address, address.ID).Error
+	}
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetAddress
+
+Retrieves the Address of a Business.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the address will be retrieved from.
+
+	businessID  <uint>
+
+		The ID of the Business whose address is retrieved.
+
+	address  <*Address>
+
+		The Address that the business's address is retrieved into.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error ('gorm.ErrRecordNotFound' if the Business doesn't exist or has no address, nil if no errors are encountered)
+*/
+func (business *Business) GetAddress(db *gorm.DB, businessID uint, address *Address) error {
+	err := db.First(business, businessID).Error
+	if err != nil {
+		return err
+	}
+
+	if business.AddressID == nil {
+		return fmt.Errorf("Business ID (%d) has no address.  [%w]", businessID, gorm.ErrRecordNotFound)
+	}
+
+	return db.First(address, *business.AddressID).Error
+}
+
+/*
+*Description*
+
+func NearbyBusinesses
+
+Finds the Businesses whose addresses are within a radius of a point, nearest first. Distances are great-circle distances between the
+point and the coordinates of each Business's Address, so Businesses without a geocoded address are never found.
+
+Addresses are first narrowed to a bounding box around the point (which the index on the addresses' coordinates can serve), and only
+those are checked against the exact radius.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be searched.
+
+	origin  <geocoding.Coordinates>
+
+		The point to search around.
+
+	radiusMiles  <float64>
+
+		The radius of the search (defaults to 'DefaultNearbyRadiusMiles' if 0, at most 'MaxNearbyRadiusMiles').
+
+	limit  <int>
+
+		The number of Businesses returned (defaults to 'DefaultNearbyLimit' if 0, at most 'MaxNearbyLimit').
+
+*Returns*
+
+	_  <[]NearbyBusiness>
+
+		The Businesses found, nearest first.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func NearbyBusinesses(db *gorm.DB, origin geocoding.Coordinates, radiusMiles float64, limit int) ([]NearbyBusiness, error) {
+	businesses := []NearbyBusiness{}
+
+	if err := origin.Validate(); err != nil {
+		return businesses, fmt.Errorf("%w: %s", ErrInvalidSearch, err)
+	}
+
+	if radiusMiles == 0 {
+		radiusMiles = DefaultNearbyRadiusMiles
+	}
+
+	if math.IsNaN(radiusMiles) || radiusMiles < 0 || radiusMiles > MaxNearbyRadiusMiles {
+		return businesses, fmt.Errorf("%w: radius must be between 0 and %g miles", ErrInvalidSearch, MaxNearbyRadiusMiles)
+	}
+
+	if limit == 0 {
+		limit = DefaultNearbyLimit
+	}
+
+	if limit < 0 || limit > MaxNearbyLimit {
+		return businesses, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidSearch, MaxNearbyLimit)
+	}
+
+	parameters := map[string]interface{}{
+		"latitude":     origin.Latitude,
+		"longitude":    origin.Longitude,
+		"radius":       radiusMiles,
+		"earth_radius": geocoding.EarthRadiusMiles,
+		"min_latitude": origin.Latitude - radiusMiles/milesPerDegreeLatitude,
+		"max_latitude": origin.Latitude + radiusMiles/milesPerDegreeLatitude,
+		"now":          time.Now(),
+		"limit":        limit,
+	}
+
+	// Degrees of longitude shrink towards the poles, so the box is only narrowed by longitude where it doesn't reach a pole or wrap around
+	// the antimeridian
+	longitudeFilter := ""
+	if maxLatitude := math.Abs(origin.Latitude) + radiusMiles/milesPerDegreeLatitude; maxLatitude < 89 {
+		longitudeDelta := radiusMiles / (milesPerDegreeLatitude * math.Cos(maxLatitude*math.Pi/180))
+		if origin.Longitude-longitudeDelta >= -180 && origin.Longitude+longitudeDelta <= 180 {
+			longitudeFilter = "AND addresses.longitude BETWEEN @min_longitude AND @max_longitude"
+			parameters["min_longitude"] = origin.Longitude - longitudeDelta
+			parameters["max_longitude"] = origin.Longitude + longitudeDelta
+		}
+	}
+
+	err := db.Raw(`SELECT * FROM (
+			SELECT
+				businesses.*,
+				2 * @earth_radius * ASIN(LEAST(1, SQRT(
+					POWER(SIN(RADIANS(addresses.latitude - @latitude) / 2), 2)
+					+ COS(RADIANS(@latitude)) * COS(RADIANS(addresses.latitude)) * POWER(SIN(RADIANS(addresses.longitude - @longitude) / 2), 2)
+				))) AS distance_miles,
+				(SELECT COUNT(*) FROM services
+					WHERE services.business_id = businesses.id
+						AND services.start_date_time >= @now
+						AND services.deleted_at IS NULL) AS upcoming_services
+			FROM businesses
+			JOIN addresses ON addresses.id = businesses.address_id AND addresses.deleted_at IS NULL
+			WHERE businesses.deleted_at IS NULL
+				AND addresses.latitude BETWEEN @min_latitude AND @max_latitude
+				`+longitudeFilter+`
+		) AS nearby
+		WHERE distance_miles <= @radius
+		ORDER BY distance_miles, id
+		LIMIT @limit`, parameters).Scan(&businesses).Error
+	if err != nil || len(businesses) == 0 {
+		return businesses, err
+	}
+
+	var addressIDs []uint
+	for _, business := range businesses {
+		addressIDs = append(addressIDs, *business.AddressID)
+	}
+
+	var addresses []Address
+	err = db.Find(&addresses, addressIDs).Error
+	if err != nil {
+		return businesses, err
+	}
+
+	addressesByID := map[uint]*Address{}
+	for i := range addresses {
+		addressesByID[addresses[i].ID] = &addresses[i]
+	}
+
+	for i := range businesses {
+		businesses[i].Address = addressesByID[*businesses[i].AddressID]
+	}
+
+	return businesses, nil
+}
@@ -0,0 +1,737 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"server/money"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all Payment records in the database (one record per payment applied to an Invoice)
+//
+// Payments are never modified once they are recorded. Money returned to the User is recorded as a Refund against the payment.
+type Payment struct {
+	gorm.Model
+	InvoiceID    uint            `gorm:"column:invoice_id;not null;index" json:"invoice_id"`   // ID of Invoice that the payment is applied to
+	Amount       int             `gorm:"column:amount;not null" json:"amount"`                 // Amount paid (in cents)
+	Method       string          `gorm:"column:method;not null" json:"method"`                 // Payment method (Cash, Card, Bank Transfer, Check, Store Credit, Gift Card, Other)
+	Reference    string          `gorm:"column:reference" json:"reference"`                    // External reference for the payment (e.g. receipt number or card transaction ID)
+	PaidAt       time.Time       `gorm:"column:paid_at;not null" json:"paid_at"`               // Date/time when the payment was made
+	BalanceAfter int             `gorm:"column:balance_after" json:"balance_after"`            // Remaining balance of the invoice (in cents) right after the payment was applied (shown on the payment's receipt)
+	Currency     string          `gorm:"column:currency;not null;default:USD" json:"currency"` // ISO 4217 currency of the payment (must match the Invoice's currency)
+	GiftCardID   uint            `gorm:"column:gift_card_id;index" json:"gift_card_id"`        // ID of GiftCard that the payment was made with (0 unless the method is Gift Card)
+	GiftCardCode string          `gorm:"-" json:"gift_card_code,omitempty"`                    // Code of the gift card to pay with (only read from requests to pay with a Gift Card)
+	Display      *PaymentDisplay `gorm:"-" json:"display,omitempty"`                           // Amount and balance after formatted for the requester's locale (only set in API responses)
+}
+
+// GORM model for all Refund records in the database (one record per amount returned to the User from a Payment)
+type Refund struct {
+	gorm.Model
+	PaymentID   uint           `gorm:"column:payment_id;not null;index" json:"payment_id"`   // ID of Payment that is refunded
+	InvoiceID   uint           `gorm:"column:invoice_id;not null;index" json:"invoice_id"`   // ID of Invoice that the refunded payment was applied to
+	Amount      int            `gorm:"column:amount;not null" json:"amount"`                 // Amount refunded (in cents)
+	Reason      string         `gorm:"column:reason" json:"reason"`                          // Reason for the refund
+	Reference   string         `gorm:"column:reference" json:"reference"`                    // External reference for the refund
+	RefundedAt  time.Time      `gorm:"column:refunded_at;not null" json:"refunded_at"`       // Date/time when the refund was made
+	Currency    string         `gorm:"column:currency;not null;default:USD" json:"currency"` // ISO 4217 currency of the refund (the refunded Payment's currency)
+	StoreCredit bool           `gorm:"column:store_credit" json:"store_credit"`              // True if the amount was given to the User as store credit instead of money (see 'StoreCreditAccount')
+	Display     *RefundDisplay `gorm:"-" json:"display,omitempty"`                           // Amount formatted for the requester's locale (only set in API responses)
+
+	storeCreditType string // Type of StoreCreditTransaction recorded for a store credit refund (defaults to 'StoreCreditRefunded')
+}
+
+// Payment methods
+const (
+	PaymentMethodCash         string = "Cash"
+	PaymentMethodCard         string = "Card"
+	PaymentMethodBankTransfer string = "Bank Transfer"
+	PaymentMethodCheck        string = "Check"
+	PaymentMethodStoreCredit  string = "Store Credit" // Paid from the User's StoreCreditAccount with the invoice's Business
+	PaymentMethodGiftCard     string = "Gift Card"    // Paid from the balance of a GiftCard issued by the invoice's Business
+	PaymentMethodOther        string = "Other"
+)
+
+// List of valid payment methods
+var paymentMethods []string = []string{
+	PaymentMethodCash,
+	PaymentMethodCard,
+	PaymentMethodBankTransfer,
+	PaymentMethodCheck,
+	PaymentMethodStoreCredit,
+	PaymentMethodGiftCard,
+	PaymentMethodOther,
+}
+
+// Errors returned when a Payment or Refund cannot be applied to an Invoice
+var (
+	ErrInvalidPayment = errors.New("invalid payment")
+	ErrInvalidRefund  = errors.New("invalid refund")
+)
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from Payment object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the payment object
+*/
+func (payment *Payment) GetID() uint {
+	return payment.ID
+}
+
+/*
+*Description*
+
+func Create
+
+Records the calling Payment against its Invoice and updates the invoice's remaining balance and status from its payments and refunds.
+
+The Invoice record is locked while the payment is recorded. Payments must be for a positive amount with a valid payment method, and
+can only be applied to issued invoices that are not void. If the payment time is not specified, it is set to the current time. The invoice's remaining balance
+after the payment is recorded with the payment for its receipt (see 'handlers.GetPaymentReceipt').
+
+Store Credit and Gift Card payments are taken from the User's store credit or the gift card in the same transaction (see 'redeem'), and
+can't be for more than the invoice's remaining balance.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the created Payment object and the updated Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (payment *Payment) Create(db *gorm.DB) (map[string]Model, error) {
+	invoice := &Invoice{}
+	returnRecords := map[string]Model{"payment": payment, "invoice": invoice}
+
+	if payment.Amount <= 0 {
+		return returnRecords, fmt.Errorf("%w: amount must be greater than 0", ErrInvalidPayment)
+	}
+
+	if !slices.Contains(paymentMethods, payment.Method) {
+		return returnRecords, fmt.Errorf("%w: payment method '%s' must be one of %v", ErrInvalidPayment, payment.Method, paymentMethods)
+	}
+
+	if payment.PaidAt.IsZero() {
+		payment.PaidAt = time.Now()
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(invoice, payment.InvoiceID).Error
+		if err != nil {
+			return err
+		}
+
+		if err = invoice.checkPayable(); err != nil {
+			return err
+		}
+
+		// Payments default to the invoice's currency, and can't be applied to an invoice in another currency
+		if payment.Currency == "" {
+			payment.Currency = invoice.Currency
+		}
+
+		paid, err := money.New(payment.Amount, payment.Currency)
+		if err != nil {
+			return err
+		}
+
+		if err = paid.SameCurrency(invoice.Currency); err != nil {
+			return fmt.Errorf("payment can't be applied to Invoice ID (%d): %w", invoice.ID, err)
+		}
+		payment.Currency = paid.Currency
+
+		if (payment.Method == PaymentMethodStoreCredit || payment.Method == PaymentMethodGiftCard) && payment.Amount > invoice.RemainingBalance {
+			return fmt.Errorf("%w: %d is owed on Invoice ID (%d), so no more than that can be paid with %s", ErrInvalidPayment, invoice.RemainingBalance, invoice.ID, strings.ToLower(payment.Method))
+		}
+
+		amountPaid, err := invoice.GetAmountPaid(tx)
+		if err != nil {
+			return err
+		}
+
+		// The balance is recorded with the payment so its receipt always shows the balance as of the payment
+		payment.BalanceAfter = invoice.GetAmountBilled() - amountPaid - payment.Amount
+
+		payment.GiftCardID = 0
+		err = tx.Create(payment).Error
+		if err != nil {
+			return err
+		}
+
+		err = payment.redeem(tx, invoice)
+		if err != nil {
+			return err
+		}
+
+		return invoice.applyLedger(tx)
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a Payment record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	paymentID  <uint>
+
+		The ID of the payment record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved Payment object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (payment *Payment) Get(db *gorm.DB, paymentID uint) (map[string]Model, error) {
+	err := db.First(&payment, paymentID).Error
+	returnRecords := map[string]Model{"payment": payment}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of Payment records from the database that are associated with the specified secondary key (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]Payment>
+
+		The list of Payment records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (payment *Payment) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]Payment, error) {
+	payments := []Payment{}
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&payments).Error
+	return payments, err
+}
+
+/*
+*Description*
+
+func Update
+
+Payments cannot be modified once they are recorded (see 'Refund.Create' to return money to the User).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	paymentID  <uint>
+
+		N/A
+
+	updates  <map[string]interface{}>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (payment *Payment) Update(db *gorm.DB, paymentID uint, updates map[string]interface{}) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: payments cannot be modified once they are recorded", ErrInvalidPayment)
+}
+
+/*
+*Description*
+
+func Delete
+
+Payments cannot be deleted once they are recorded (see 'Refund.Create' to return money to the User).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	paymentID  <uint>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (payment *Payment) Delete(db *gorm.DB, paymentID uint) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: payments cannot be deleted once they are recorded", ErrInvalidPayment)
+}
+
+/*
+*Description*
+
+func GetRefundedAmount
+
+Returns the total amount (in cents) that has been refunded from the specified Payment.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	paymentID  <uint>
+
+		The ID of the payment.
+
+*Returns*
+
+	_  <int>
+
+		The total amount refunded (in cents).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (payment *Payment) GetRefundedAmount(db *gorm.DB, paymentID uint) (int, error) {
+	var refundedAmount int
+
+	err := db.Model(&Refund{}).Select("COALESCE(SUM(amount), 0)").Where("payment_id = ?", paymentID).Scan(&refundedAmount).Error
+	return refundedAmount, err
+}
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from Refund object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the refund object
+*/
+func (refund *Refund) GetID() uint {
+	return refund.ID
+}
+
+/*
+*Description*
+
+func Create
+
+Records the calling Refund against its Payment and updates the remaining balance and status of the Invoice the payment was applied to.
+
+If the refund amount is not specified, everything that has not already been refunded from the payment is refunded. The total refunded
+from a payment cannot exceed the amount paid. The Invoice record is locked while the refund is recorded. If the refund time is not
+specified, it is set to the current time.
+
+Refunds of Store Credit and Gift Card payments go back to the store credit or gift card they were paid with. Refunds of other payments
+can be given as store credit instead of money (see 'StoreCredit'). Either way, the credit is recorded in the same transaction (see
+'returnCredit').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the created Refund object and the updated Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (refund *Refund) Create(db *gorm.DB) (map[string]Model, error) {
+	invoice := &Invoice{}
+	returnRecords := map[string]Model{"refund": refund, "invoice": invoice}
+
+	if refund.Amount < 0 {
+		return returnRecords, fmt.Errorf("%w: amount must be greater than 0", ErrInvalidRefund)
+	}
+
+	if refund.RefundedAt.IsZero() {
+		refund.RefundedAt = time.Now()
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		payment := &Payment{}
+		err := tx.First(payment, refund.PaymentID).Error
+		if err != nil {
+			return err
+		}
+
+		// Lock the Invoice record so that refunds of the same payment are applied one at a time
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(invoice, payment.InvoiceID).Error
+		if err != nil {
+			return err
+		}
+
+		refundedAmount, err := payment.GetRefundedAmount(tx, payment.ID)
+		if err != nil {
+			return err
+		}
+
+		var refundableAmount int = payment.Amount - refundedAmount
+		if refund.Amount == 0 {
+			refund.Amount = refundableAmount
+		}
+
+		if refund.Amount <= 0 || refund.Amount > refundableAmount {
+			return fmt.Errorf("%w: %d of Payment ID (%d) can be refunded, but %d was requested", ErrInvalidRefund, refundableAmount, payment.ID, refund.Amount)
+		}
+
+		refund.InvoiceID = payment.InvoiceID
+		refund.Currency = payment.Currency
+		err = tx.Create(refund).Error
+		if err != nil {
+			return err
+		}
+
+		err = refund.returnCredit(tx, payment, invoice)
+		if err != nil {
+			return err
+		}
+
+		return invoice.applyLedger(tx)
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a Refund record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	refundID  <uint>
+
+		The ID of the refund record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved Refund object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (refund *Refund) Get(db *gorm.DB, refundID uint) (map[string]Model, error) {
+	err := db.First(&refund, refundID).Error
+	returnRecords := map[string]Model{"refund": refund}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of Refund records from the database that are associated with the specified secondary key (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]Refund>
+
+		The list of Refund records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (refund *Refund) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]Refund, error) {
+	refunds := []Refund{}
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&refunds).Error
+	return refunds, err
+}
+
+/*
+*Description*
+
+func Update
+
+Refunds cannot be modified once they are recorded.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	refundID  <uint>
+
+		N/A
+
+	updates  <map[string]interface{}>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (refund *Refund) Update(db *gorm.DB, refundID uint, updates map[string]interface{}) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: refunds cannot be modified once they are recorded", ErrInvalidRefund)
+}
+
+/*
+*Description*
+
+func Delete
+
+Refunds cannot be deleted once they are recorded.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	refundID  <uint>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (refund *Refund) Delete(db *gorm.DB, refundID uint) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: refunds cannot be deleted once they are recorded", ErrInvalidRefund)
+}
+
+/*
+*Description*
+
+func redeem
+
+Takes the calling Store Credit or Gift Card Payment from the User's store credit with the invoice's Business, or from the gift card with
+the payment's GiftCardCode. Payments made with other methods are left as they are.
+
+Gift cards must have been issued by the invoice's Business in the invoice's currency, and must be redeemable when the payment is made
+(see 'GiftCard.checkRedeemable'). A gift card can't pay for the invoice it was sold on. The StoreCreditAccount or GiftCard record is
+locked until the calling transaction completes.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+	invoice  <*Invoice>
+
+		The Invoice that the payment is applied to.
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidStoreCredit' or 'ErrInvalidGiftCard' if the balance can't be spent, else any encountered error (nil if no errors are encountered)
+*/
+func (payment *Payment) redeem(db *gorm.DB, invoice *Invoice) error {
+	switch payment.Method {
+	case PaymentMethodStoreCredit:
+		account, err := lockStoreCreditAccount(db, invoice.UserID, invoice.BusinessID, invoice.Currency)
+		if err != nil {
+			return err
+		}
+
+		return account.changeBalance(db, &StoreCreditTransaction{Type: StoreCreditRedeemed, Amount: -payment.Amount, InvoiceID: invoice.ID, PaymentID: payment.ID})
+	case PaymentMethodGiftCard:
+		card := &GiftCard{}
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", normalizeGiftCardCode(payment.GiftCardCode)).First(card).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: no gift card has the code '%s'", ErrInvalidGiftCard, payment.GiftCardCode)
+		} else if err != nil {
+			return err
+		}
+
+		if card.BusinessID != invoice.BusinessID || card.Currency != invoice.Currency || card.InvoiceID == invoice.ID {
+			return fmt.Errorf("%w: gift card %s can't be used to pay Invoice ID (%d)", ErrInvalidGiftCard, card.Code, invoice.ID)
+		}
+
+		err = card.checkRedeemable(db, payment.PaidAt)
+		if err != nil {
+			return err
+		}
+
+		payment.GiftCardID = card.ID
+		err = db.Model(payment).Update("gift_card_id", payment.GiftCardID).Error
+		if err != nil {
+			return err
+		}
+
+		return card.changeBalance(db, &GiftCardTransaction{Type: GiftCardRedeemed, Amount: -payment.Amount, InvoiceID: invoice.ID, PaymentID: payment.ID})
+	default:
+		return nil
+	}
+}
+
+/*
+*Description*
+
+func returnCredit
+
+Gives the calling Refund's amount back to the store credit or gift card that the refunded Payment was made with, or to the User's store
+credit with the invoice's Business if the refund is given as store credit. Refunds of money are left as they are.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+	payment  <*Payment>
+
+		The refunded Payment.
+
+	invoice  <*Invoice>
+
+		The Invoice that the refunded payment was applied to.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (refund *Refund) returnCredit(db *gorm.DB, payment *Payment, invoice *Invoice) error {
+	switch {
+	case payment.Method == PaymentMethodGiftCard:
+		card := &GiftCard{}
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(card, payment.GiftCardID).Error
+		if err != nil {
+			return err
+		}
+
+		return card.changeBalance(db, &GiftCardTransaction{Type: GiftCardRestored, Amount: refund.Amount, InvoiceID: invoice.ID, PaymentID: payment.ID, RefundID: refund.ID})
+	case payment.Method == PaymentMethodStoreCredit || refund.StoreCredit:
+		transactionType := StoreCreditRestored
+		if payment.Method != PaymentMethodStoreCredit {
+			transactionType = StoreCreditRefunded
+			if refund.storeCreditType != "" {
+				transactionType = refund.storeCreditType
+			}
+		}
+
+		account, err := lockStoreCreditAccount(db, invoice.UserID, invoice.BusinessID, payment.Currency)
+		if err != nil {
+			return err
+		}
+
+		return account.changeBalance(db, &StoreCreditTransaction{Type: transactionType, Amount: refund.Amount, InvoiceID: invoice.ID, PaymentID: payment.ID, RefundID: refund.ID, Reason: refund.Reason})
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,782 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"server/payments"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all PaymentIntent records in the database (one record per online payment requested from a PaymentProvider)
+//
+// The intent's status is kept in sync with the provider through webhook events (see 'PaymentEvent.Process'). When the intent succeeds,
+// a Payment is recorded against its Invoice exactly once.
+type PaymentIntent struct {
+	gorm.Model
+	InvoiceID      uint   `gorm:"column:invoice_id;not null;index" json:"invoice_id"`                                         // ID of Invoice that the intent pays
+	Provider       string `gorm:"column:provider;not null;uniqueIndex:idx_payment_intents_provider_id" json:"provider"`       // Name of the PaymentProvider (e.g. stripe)
+	ProviderID     string `gorm:"column:provider_id;not null;uniqueIndex:idx_payment_intents_provider_id" json:"provider_id"` // Provider's ID of the intent
+	Amount         int    `gorm:"column:amount;not null" json:"amount"`                                                       // Amount requested (in cents)
+	AmountReceived int    `gorm:"column:amount_received" json:"amount_received"`                                              // Amount captured by the provider (in cents)
+	Status         string `gorm:"column:status;not null" json:"status"`                                                       // Status reported by the provider (Pending, Requires Capture, Succeeded, Cancelled)
+	PaymentID      *uint  `gorm:"column:payment_id;default:null" json:"payment_id"`                                           // ID of Payment recorded when the intent succeeded (null until then)
+	ClientSecret   string `gorm:"-" json:"client_secret,omitempty"`                                                           // Secret the customer uses to pay the intent (returned when the intent is created, never stored)
+}
+
+// GORM model for all PaymentEvent records in the database (one record per webhook event applied from a PaymentProvider)
+//
+// Providers can deliver the same event more than once. Each event is recorded in the same transaction that applies it, so a repeated
+// delivery is recognized and skipped.
+type PaymentEvent struct {
+	gorm.Model
+	Provider string `gorm:"column:provider;not null;uniqueIndex:idx_payment_events_provider_event" json:"provider"` // Name of the PaymentProvider that sent the event
+	EventID  string `gorm:"column:event_id;not null;uniqueIndex:idx_payment_events_provider_event" json:"event_id"` // Provider's ID of the event
+	Type     string `gorm:"column:type" json:"type"`                                                                // Provider's event type (e.g. payment_intent.succeeded)
+}
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from PaymentIntent object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the payment intent object
+*/
+func (intent *PaymentIntent) GetID() uint {
+	return intent.ID
+}
+
+/*
+*Description*
+
+func Create
+
+PaymentIntents are only created through 'Start', which asks the PaymentProvider for the intent first.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (intent *PaymentIntent) Create(db *gorm.DB) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: payment intents must be started through the payment provider", ErrInvalidPayment)
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a PaymentIntent record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	intentID  <uint>
+
+		The ID of the payment intent record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved PaymentIntent object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (intent *PaymentIntent) Get(db *gorm.DB, intentID uint) (map[string]Model, error) {
+	err := db.First(&intent, intentID).Error
+	returnRecords := map[string]Model{"payment_intent": intent}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Update
+
+PaymentIntents are only modified by the PaymentProvider's webhook events.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	intentID  <uint>
+
+		N/A
+
+	updates  <map[string]interface{}>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (intent *PaymentIntent) Update(db *gorm.DB, intentID uint, updates map[string]interface{}) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: payment intents are only updated by the payment provider", ErrInvalidPayment)
+}
+
+/*
+*Description*
+
+func Delete
+
+PaymentIntents cannot be deleted once they are started with the PaymentProvider.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	intentID  <uint>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (intent *PaymentIntent) Delete(db *gorm.DB, intentID uint) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: payment intents cannot be deleted once they are started", ErrInvalidPayment)
+}
+
+/*
+*Description*
+
+func Start
+
+Asks the PaymentProvider for a payment towards the specified Invoice and records the resulting intent. The customer completes the
+payment with the provider using the intent's client secret, and the payment is recorded against the invoice when the provider
+reports that the intent succeeded.
+
+If the amount is not specified, the invoice's remaining balance is requested. The amount can't be more than the remaining balance,
+and only issued invoices that are not void can be paid.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+	provider  <payments.PaymentProvider>
+
+		The provider that collects the payment.
+
+	invoiceID  <uint>
+
+		The ID of the invoice being paid.
+
+	amount  <int>
+
+		The amount to request (in cents). 0 requests the remaining balance.
+
+	captureManually  <bool>
+
+		If true, the payment is only authorized until it is captured (see 'Capture').
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the created PaymentIntent object and the Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (intent *PaymentIntent) Start(db *gorm.DB, provider payments.PaymentProvider, invoiceID uint, amount int, captureManually bool) (map[string]Model, error) {
+	invoice := &Invoice{}
+	returnRecords := map[string]Model{"payment_intent": intent, "invoice": invoice}
+
+	err := db.First(invoice, invoiceID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	if err = invoice.checkPayable(); err != nil {
+		return returnRecords, err
+	}
+
+	if amount == 0 {
+		amount = invoice.RemainingBalance
+	}
+
+	if amount <= 0 || amount > invoice.RemainingBalance {
+		return returnRecords, fmt.Errorf("%w: %d is owed on Invoice ID (%d), but %d was requested", ErrInvalidPayment, invoice.RemainingBalance, invoice.ID, amount)
+	}
+
+	providerIntent, err := provider.CreatePaymentIntent(payments.PaymentIntentParams{
+		Amount:          amount,
+		Currency:        strings.ToLower(invoice.Currency),
+		Description:     fmt.Sprintf("Invoice %s", invoice.Number),
+		CaptureManually: captureManually,
+		Metadata:        map[string]string{"invoice_id": strconv.FormatUint(uint64(invoice.ID), 10)},
+	})
+	if err != nil {
+		return returnRecords, err
+	}
+
+	*intent = PaymentIntent{
+		InvoiceID:    invoice.ID,
+		Provider:     provider.Name(),
+		ProviderID:   providerIntent.ID,
+		Amount:       providerIntent.Amount,
+		Status:       providerIntent.Status,
+		ClientSecret: providerIntent.ClientSecret,
+	}
+
+	err = db.Create(intent).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Capture
+
+Captures an authorized PaymentIntent with its PaymentProvider and records the payment against its Invoice.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be updated.
+
+	provider  <payments.PaymentProvider>
+
+		The provider that created the intent.
+
+	intentID  <uint>
+
+		The ID of the PaymentIntent record.
+
+	amount  <int>
+
+		The amount to capture (in cents). 0 captures everything that was authorized.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the updated PaymentIntent object and the updated Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (intent *PaymentIntent) Capture(db *gorm.DB, provider payments.PaymentProvider, intentID uint, amount int) (map[string]Model, error) {
+	invoice := &Invoice{}
+	returnRecords := map[string]Model{"payment_intent": intent, "invoice": invoice}
+
+	err := db.First(intent, intentID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	if intent.Provider != provider.Name() || intent.Status != payments.PaymentIntentStatusRequiresCapture {
+		return returnRecords, fmt.Errorf("%w: Payment Intent ID (%d) has a status of '%s' and can't be captured", ErrInvalidPayment, intent.ID, intent.Status)
+	}
+
+	providerIntent, err := provider.CapturePaymentIntent(intent.ProviderID, amount)
+	if err != nil {
+		return returnRecords, err
+	}
+
+	_, err = intent.sync(db, provider.Name(), providerIntent, time.Now())
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.First(invoice, intent.InvoiceID).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Refund
+
+Refunds all or part of a Payment that was collected through a PaymentProvider. The refund is sent with the provider and recorded
+against the payment once the provider reports that it succeeded (refunds that are still pending are recorded by the webhook event
+that reports them succeeding).
+
+If the amount is not specified, everything that has not already been refunded from the payment is refunded.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	provider  <payments.PaymentProvider>
+
+		The provider that collected the payment.
+
+	paymentID  <uint>
+
+		The ID of the payment being refunded.
+
+	amount  <int>
+
+		The amount to refund (in cents). 0 refunds everything that is refundable.
+
+	reason  <string>
+
+		The reason for the refund.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the Refund object (with an ID of 0 if the refund is still pending) and
+		the Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (intent *PaymentIntent) Refund(db *gorm.DB, provider payments.PaymentProvider, paymentID uint, amount int, reason string) (map[string]Model, error) {
+	return intent.refund(db, provider, paymentID, amount, reason, "")
+}
+
+/*
+*Description*
+
+func refund
+
+Refunds all or part of a Payment that was collected through a PaymentProvider (see 'Refund'), with an idempotency key that makes a
+retried refund be sent to the provider only once.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	provider  <payments.PaymentProvider>
+
+		The provider that collected the payment.
+
+	paymentID  <uint>
+
+		The ID of the payment being refunded.
+
+	amount  <int>
+
+		The amount to refund (in cents). 0 refunds everything that is refundable.
+
+	reason  <string>
+
+		The reason for the refund.
+
+	idempotencyKey  <string>
+
+		Key sent with the refund request ("" to send none).
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the Refund object (with an ID of 0 if the refund is still pending) and
+		the Invoice object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (intent *PaymentIntent) refund(db *gorm.DB, provider payments.PaymentProvider, paymentID uint, amount int, reason string, idempotencyKey string) (map[string]Model, error) {
+	refund := &Refund{}
+	invoice := &Invoice{}
+	returnRecords := map[string]Model{"refund": refund, "invoice": invoice}
+
+	err := db.Where("payment_id = ?", paymentID).First(intent).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	payment := &Payment{}
+	err = db.First(payment, paymentID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	refundedAmount, err := payment.GetRefundedAmount(db, paymentID)
+	if err != nil {
+		return returnRecords, err
+	}
+
+	var refundableAmount int = payment.Amount - refundedAmount
+	if amount == 0 {
+		amount = refundableAmount
+	}
+
+	if amount <= 0 || amount > refundableAmount {
+		return returnRecords, fmt.Errorf("%w: %d of Payment ID (%d) can be refunded, but %d was requested", ErrInvalidRefund, refundableAmount, payment.ID, amount)
+	}
+
+	providerRefund, err := provider.RefundPaymentIntent(payments.RefundParams{
+		PaymentIntentID: intent.ProviderID,
+		Amount:          amount,
+		Metadata:        map[string]string{"reason": reason, "payment_id": strconv.FormatUint(uint64(paymentID), 10)},
+		IdempotencyKey:  idempotencyKey,
+	})
+	if err != nil {
+		return returnRecords, err
+	}
+
+	recordedRefund, err := recordProviderRefund(db, provider.Name(), providerRefund, time.Now())
+	if err != nil {
+		return returnRecords, err
+	}
+
+	if recordedRefund != nil {
+		*refund = *recordedRefund
+	}
+
+	err = db.First(invoice, payment.InvoiceID).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetByPaymentID
+
+Retrieves the PaymentIntent that collected the specified Payment, if the payment was collected through a PaymentProvider.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	paymentID  <uint>
+
+		The ID of the payment.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the payment was collected through a provider, else 'false'.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (intent *PaymentIntent) GetByPaymentID(db *gorm.DB, paymentID uint) (bool, error) {
+	err := db.Where("payment_id = ?", paymentID).First(intent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+/*
+*Description*
+
+func sync
+
+Updates the PaymentIntent record for an intent reported by its provider, and records the payment against the Invoice the first time
+the intent is reported as succeeded. The record is locked while it is updated, so the payment is recorded only once no matter how
+many times (or in what order) the provider reports the intent.
+
+Intents that succeeded or were cancelled keep their status, since provider events can arrive out of order.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be updated.
+
+	providerName  <string>
+
+		Name of the provider that reported the intent.
+
+	providerIntent  <*payments.PaymentIntent>
+
+		The intent, as reported by the provider.
+
+	paidAt  <time.Time>
+
+		Date/time recorded as the payment time if the intent succeeded.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the intent belongs to a PaymentIntent record, else 'false' (the intent was not created by this application).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (intent *PaymentIntent) sync(db *gorm.DB, providerName string, providerIntent *payments.PaymentIntent, paidAt time.Time) (bool, error) {
+	var found bool
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("provider = ? AND provider_id = ?", providerName, providerIntent.ID).First(intent).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		found = true
+
+		if intent.Status == payments.PaymentIntentStatusSucceeded || intent.Status == payments.PaymentIntentStatusCancelled {
+			return nil
+		}
+
+		intent.Status = providerIntent.Status
+		intent.AmountReceived = providerIntent.AmountReceived
+
+		if intent.Status == payments.PaymentIntentStatusSucceeded && intent.PaymentID == nil && intent.AmountReceived > 0 {
+			payment := &Payment{
+				InvoiceID: intent.InvoiceID,
+				Amount:    intent.AmountReceived,
+				Method:    PaymentMethodCard,
+				Reference: intent.ProviderID,
+				PaidAt:    paidAt,
+			}
+
+			_, err = payment.Create(tx)
+			if err != nil {
+				return err
+			}
+
+			intent.PaymentID = &payment.ID
+		}
+
+		return tx.Model(intent).Updates(map[string]interface{}{
+			"status":          intent.Status,
+			"amount_received": intent.AmountReceived,
+			"payment_id":      intent.PaymentID,
+		}).Error
+	})
+
+	return found, err
+}
+
+/*
+*Description*
+
+func recordProviderRefund
+
+Records a refund reported by a PaymentProvider against the Payment collected by the refunded intent, unless it has already been
+recorded. Refunds are matched by the provider's refund ID (stored as the Refund's reference), and the Invoice record is locked while
+the refund is recorded, so each provider refund is recorded only once.
+
+Only refunds that succeeded are recorded, and refunds of intents that were not created by this application are ignored.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+	providerName  <string>
+
+		Name of the provider that reported the refund.
+
+	providerRefund  <*payments.Refund>
+
+		The refund, as reported by the provider.
+
+	refundedAt  <time.Time>
+
+		Date/time recorded as the refund time.
+
+*Returns*
+
+	_  <*Refund>
+
+		The recorded Refund (nil if nothing was recorded).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func recordProviderRefund(db *gorm.DB, providerName string, providerRefund *payments.Refund, refundedAt time.Time) (*Refund, error) {
+	if providerRefund.Status != payments.RefundStatusSucceeded {
+		return nil, nil
+	}
+
+	intent := &PaymentIntent{}
+	err := db.Where("provider = ? AND provider_id = ? AND payment_id IS NOT NULL", providerName, providerRefund.PaymentIntentID).First(intent).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	refund := &Refund{}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&Invoice{}, intent.InvoiceID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("payment_id = ? AND reference = ?", *intent.PaymentID, providerRefund.ID).First(refund).Error
+		if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var reason string = providerRefund.Metadata["reason"]
+		if reason == "" {
+			reason = fmt.Sprintf("Refunded through %s", providerName)
+		}
+
+		*refund = Refund{
+			PaymentID:  *intent.PaymentID,
+			Amount:     providerRefund.Amount,
+			Reason:     reason,
+			Reference:  providerRefund.ID,
+			RefundedAt: refundedAt,
+		}
+
+		_, err = refund.Create(tx)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from PaymentEvent object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the payment event object
+*/
+func (paymentEvent *PaymentEvent) GetID() uint {
+	return paymentEvent.ID
+}
+
+/*
+*Description*
+
+func Process
+
+Applies a verified webhook event from a PaymentProvider to the invoice ledger (see 'PaymentIntent.sync' and 'recordProviderRefund').
+
+The event is recorded in the same transaction that applies it. If the event was already applied, nothing is changed, so providers
+can safely deliver the same event more than once. If applying the event fails, the event is not recorded and the provider's retry
+applies it.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	providerName  <string>
+
+		Name of the provider that sent the event.
+
+	event  <*payments.Event>
+
+		The verified event.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the event was applied, 'false' if it had already been applied.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (paymentEvent *PaymentEvent) Process(db *gorm.DB, providerName string, event *payments.Event) (bool, error) {
+	var applied bool
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		*paymentEvent = PaymentEvent{Provider: providerName, EventID: event.ID, Type: event.Type}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(paymentEvent)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		applied = true
+
+		if event.PaymentIntent != nil {
+			intent := &PaymentIntent{}
+			_, err := intent.sync(tx, providerName, event.PaymentIntent, event.Created)
+			return err
+		}
+
+		if event.Refund != nil {
+			_, err := recordProviderRefund(tx, providerName, event.Refund, event.Created)
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,700 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all PromoCode records in the database (discount codes that customers of a Business can enter when booking)
+//
+// A promo code takes either a percentage (PercentOff) or a fixed amount (AmountOff) off the price of the appointment it is used for.
+// A PromoCode with no PromoCodeService records can be used for every Service offered by the Business.
+type PromoCode struct {
+	gorm.Model
+	BusinessID             uint              `gorm:"column:business_id;not null;index" json:"business_id"`              // ID of Business that offers the promo code
+	Code                   string            `gorm:"column:code;not null" json:"code"`                                  // Code that customers enter when booking (stored in upper case, unique per Business, e.g. "SPRING10")
+	Description            string            `gorm:"column:description" json:"description"`                             // Description of the promotion (e.g. "20% off your first class")
+	PercentOff             uint              `gorm:"column:percent_off" json:"percent_off"`                             // Percentage taken off in basis points (e.g. 2000 for 20%), or 0 for a fixed discount
+	AmountOff              uint              `gorm:"column:amount_off" json:"amount_off"`                               // Amount (in cents) taken off each appointment, or 0 for a percentage discount
+	Currency               string            `gorm:"column:currency;not null;default:USD" json:"currency"`              // ISO 4217 currency of the fixed discount (defaults to the Business's currency)
+	StartsAt               *time.Time        `gorm:"column:starts_at;default:null" json:"starts_at"`                    // Date/time that the code can first be used (null if it can be used straight away)
+	EndsAt                 *time.Time        `gorm:"column:ends_at;default:null" json:"ends_at"`                        // Date/time that the code stops being usable (null if it never expires)
+	MaxRedemptions         uint              `gorm:"column:max_redemptions" json:"max_redemptions"`                     // Max number of appointments the code can be used for in total (0 for no limit)
+	MaxRedemptionsPerUser  uint              `gorm:"column:max_redemptions_per_user" json:"max_redemptions_per_user"`   // Max number of appointments each User can use the code for (0 for no limit)
+	FirstTimeCustomersOnly bool              `gorm:"column:first_time_customers_only" json:"first_time_customers_only"` // True if the code can only be used by Users who have no other appointments with the Business
+	Display                *PromoCodeDisplay `gorm:"-" json:"display,omitempty"`                                        // Fixed discount formatted for the requester's locale (only set in API responses)
+}
+
+// GORM model for all PromoCodeService records in the database (one record per Service name that a PromoCode can be used for)
+//
+// Services are matched by name, since each scheduled session of a class is a separate Service record.
+type PromoCodeService struct {
+	gorm.Model
+	PromoCodeID uint   `gorm:"column:promo_code_id;not null;index" json:"promo_code_id"` // ID of PromoCode that the eligible service belongs to
+	ServiceName string `gorm:"column:service_name;not null" json:"service_name"`         // Name of the eligible Service(s)
+}
+
+// GORM model for all PromoCodeRedemption records in the database (one record per Appointment booked with a PromoCode)
+//
+// Redemptions of appointments that have been cancelled are kept, but don't count towards the code's redemption limits.
+type PromoCodeRedemption struct {
+	gorm.Model
+	PromoCodeID   uint   `gorm:"column:promo_code_id;not null;index" json:"promo_code_id"`   // ID of PromoCode that was redeemed
+	UserID        uint   `gorm:"column:user_id;not null;index" json:"user_id"`               // ID of User that redeemed the code
+	AppointmentID uint   `gorm:"column:appointment_id;not null;index" json:"appointment_id"` // ID of Appointment that the code was used for
+	InvoiceID     uint   `gorm:"column:invoice_id" json:"invoice_id"`                        // ID of Invoice that the discount was taken off (0 until the appointment is invoiced)
+	Discount      int    `gorm:"column:discount" json:"discount"`                            // Discount (in cents) taken off the invoice (0 until the appointment is invoiced)
+	Currency      string `gorm:"column:currency;not null;default:USD" json:"currency"`       // ISO 4217 currency of the discount
+}
+
+// Error returned when a PromoCode definition is invalid
+var ErrInvalidPromoCode = errors.New("invalid promo code")
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from PromoCode object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the promo code object
+*/
+func (promo *PromoCode) GetID() uint {
+	return promo.ID
+}
+
+/*
+*Description*
+
+func normalizePromoCode
+
+Returns the form that a promo code is stored and looked up in (trimmed and in upper case), so that codes are not case sensitive.
+
+*Parameters*
+
+	code  <string>
+
+		The code as entered.
+
+*Returns*
+
+	_  <string>
+
+		The normalized code.
+*/
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+/*
+*Description*
+
+func validate
+
+Confirms that the calling PromoCode is a valid definition: it has a code, takes either a percentage (of at most 100%) or a fixed amount
+off (but not both), and its validity window does not end before it starts.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) validate() error {
+	if promo.Code == "" {
+		return fmt.Errorf("%w: a promo code must have a code", ErrInvalidPromoCode)
+	}
+
+	if (promo.PercentOff == 0) == (promo.AmountOff == 0) {
+		return fmt.Errorf("%w: a promo code must take either a percentage (percent_off) or a fixed amount (amount_off) off", ErrInvalidPromoCode)
+	}
+
+	if int64(promo.PercentOff) > basisPointsPerUnit {
+		return fmt.Errorf("%w: percent_off (%d) can't be more than 100%% (%d basis points)", ErrInvalidPromoCode, promo.PercentOff, basisPointsPerUnit)
+	}
+
+	if promo.StartsAt != nil && promo.EndsAt != nil && !promo.EndsAt.After(*promo.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromoCode)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func IsActive
+
+Returns whether the calling PromoCode can be used at the specified time (within its validity window).
+
+*Parameters*
+
+	at  <time.Time>
+
+		The time the code is being used.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the code can be used at the specified time, else 'false'.
+*/
+func (promo *PromoCode) IsActive(at time.Time) bool {
+	if promo.StartsAt != nil && at.Before(*promo.StartsAt) {
+		return false
+	}
+
+	return promo.EndsAt == nil || at.Before(*promo.EndsAt)
+}
+
+/*
+*Description*
+
+func GetDiscount
+
+Returns the discount (in cents) that the calling PromoCode takes off the specified amount. Percentage discounts are rounded half up to
+the nearest cent, and fixed discounts are capped at the amount, so a discount is never more than what it is taken off.
+
+*Parameters*
+
+	amount  <int>
+
+		The amount (in cents) that the discount is taken off.
+
+*Returns*
+
+	_  <int>
+
+		The discount (in cents).
+*/
+func (promo *PromoCode) GetDiscount(amount int) int {
+	if amount <= 0 {
+		return 0
+	}
+
+	if promo.PercentOff > 0 {
+		return int(roundHalfUp(int64(amount)*int64(promo.PercentOff), basisPointsPerUnit))
+	}
+
+	if int(promo.AmountOff) > amount {
+		return amount
+	}
+
+	return int(promo.AmountOff)
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new PromoCode record in the database and returns the created record along with any errors that are thrown.
+
+The code is stored in upper case and must be unique among the Business's promo codes.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created PromoCode object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (promo *PromoCode) Create(db *gorm.DB) (map[string]Model, error) {
+	returnRecords := map[string]Model{"promo_code": promo}
+
+	promo.Code = normalizePromoCode(promo.Code)
+	err := promo.validate()
+	if err != nil {
+		return returnRecords, err
+	}
+
+	currency, err := resolveCurrency(db, promo.BusinessID, promo.Currency)
+	if err != nil {
+		return returnRecords, err
+	}
+	promo.Currency = currency
+
+	err = db.Create(&promo).Error
+	if isUniqueViolation(err) {
+		return returnRecords, fmt.Errorf("%w: Business ID (%d) already has a promo code '%s'", ErrInvalidPromoCode, promo.BusinessID, promo.Code)
+	}
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a PromoCode record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	promoID  <uint>
+
+		The ID of the promo code record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved PromoCode object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) Get(db *gorm.DB, promoID uint) (map[string]Model, error) {
+	err := db.First(&promo, promoID).Error
+	returnRecords := map[string]Model{"promo_code": promo}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of PromoCode records from the database that are associated with the specified secondary key.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]PromoCode>
+
+		The list of PromoCode records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]PromoCode, error) {
+	var promos []PromoCode
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&promos).Error
+	return promos, err
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the specified PromoCode record in the database with the specified changes if the record exists.
+
+Returns the updated record along with any errors that are thrown.
+
+The updated record must still be a valid promo code (see 'Create'). Discounts already taken off invoices are not changed, and
+redemptions that were made before a limit was lowered still count towards it.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	promoID  <uint>
+
+		The ID of the promo code record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated PromoCode object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) Update(db *gorm.DB, promoID uint, updates map[string]interface{}) (map[string]Model, error) {
+	updatePromo := &PromoCode{}
+	returnRecords := map[string]Model{"promo_code": updatePromo}
+
+	if code, codeUpdated := updates["code"]; codeUpdated {
+		updates["code"] = normalizePromoCode(fmt.Sprint(code))
+	}
+
+	if err := normalizeCurrencyUpdate(updates); err != nil {
+		return returnRecords, err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.First(updatePromo, promoID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(updatePromo).Clauses(clause.Returning{}).Where("id = ?", promoID).Updates(updates).Error
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: Business ID (%d) already has a promo code '%s'", ErrInvalidPromoCode, updatePromo.BusinessID, updates["code"])
+		} else if err != nil {
+			return err
+		}
+
+		// The combination of the existing and updated fields must still be valid, or the update is rolled back
+		return updatePromo.validate()
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified PromoCode record from the database if it exists, so that it can no longer be used.
+
+Discounts already taken off invoices are kept. Deleted records are returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted from.
+
+	promoID  <uint>
+
+		The ID of the promo code record being deleted.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the deleted PromoCode object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) Delete(db *gorm.DB, promoID uint) (map[string]Model, error) {
+	deletePromo := &PromoCode{}
+	returnRecords := map[string]Model{"promo_code": deletePromo}
+
+	err := db.First(deletePromo, promoID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Delete(deletePromo).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetEligibleServices
+
+Returns the names of the Services that the specified PromoCode can be used for.
+
+An empty list means that the code can be used for every Service offered by the Business.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	promoID  <uint>
+
+		The ID of the promo code.
+
+*Returns*
+
+	_  <[]string>
+
+		The list of eligible Service names.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) GetEligibleServices(db *gorm.DB, promoID uint) ([]string, error) {
+	serviceNames := []string{}
+
+	err := db.Model(&PromoCodeService{}).Where("promo_code_id = ?", promoID).Order("service_name").Pluck("service_name", &serviceNames).Error
+	return serviceNames, err
+}
+
+/*
+*Description*
+
+func SetEligibleServices
+
+Replaces the list of Services that the specified PromoCode can be used for.
+
+An empty list makes the code eligible for every Service offered by the Business.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be replaced.
+
+	promoID  <uint>
+
+		The ID of the promo code.
+
+	serviceNames  <[]string>
+
+		The names of the eligible Services.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) SetEligibleServices(db *gorm.DB, promoID uint, serviceNames []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Where("promo_code_id = ?", promoID).Delete(&PromoCodeService{}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, serviceName := range serviceNames {
+			err = tx.Create(&PromoCodeService{PromoCodeID: promoID, ServiceName: serviceName}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+/*
+*Description*
+
+func countRedemptions
+
+Counts the redemptions of the specified PromoCode that count towards its limits (redemptions of appointments that have not been
+cancelled), optionally only those of one User.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	promoID  <uint>
+
+		The ID of the promo code.
+
+	userID  <uint>
+
+		The ID of the User whose redemptions are counted (0 for every User).
+
+*Returns*
+
+	_  <int64>
+
+		The number of redemptions.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) countRedemptions(db *gorm.DB, promoID uint, userID uint) (int64, error) {
+	var count int64
+
+	query := db.Model(&PromoCodeRedemption{}).
+		Joins("JOIN appointments ON appointments.id = promo_code_redemptions.appointment_id AND appointments.deleted_at IS NULL").
+		Where("promo_code_redemptions.promo_code_id = ? AND appointments.status IN ?", promoID, seatHoldingAppointmentStatuses)
+
+	if userID != 0 {
+		query = query.Where("promo_code_redemptions.user_id = ?", userID)
+	}
+
+	err := query.Count(&count).Error
+	return count, err
+}
+
+/*
+*Description*
+
+func Redeem
+
+Applies the specified promo code to a newly booked Appointment, and records the redemption.
+
+The booking is rejected with a *BookingRejectedError (which carries a machine-readable reason code) if the Business of the
+appointment's Service has no such code, if the code is not valid at the time of booking, if the code can't be used for the Service (or
+is a fixed discount in a different currency), if the code or the User has reached its redemption limit, or if the code is for first-time
+customers and the User has other appointments with the Business.
+
+The discount itself is taken off the appointment's invoice when it is billed (see 'Appointment.bill'). The PromoCode record is
+locked until the transaction completes, so that concurrent bookings cannot go over the code's redemption limits.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the record will be created.
+
+	code  <string>
+
+		The promo code entered when booking (not case sensitive).
+
+	appt  <*Appointment>
+
+		The newly created Appointment.
+
+	service  <*Service>
+
+		The appointment's Service.
+
+	bookingTime  <time.Time>
+
+		The time the booking is being made.
+
+*Returns*
+
+	_  <*PromoCodeRedemption>
+
+		The created redemption.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) Redeem(db *gorm.DB, code string, appt *Appointment, service *Service, bookingTime time.Time) (*PromoCodeRedemption, error) {
+	code = normalizePromoCode(code)
+
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("business_id = ? AND code = ?", service.BusinessID, code).
+		First(promo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &BookingRejectedError{
+			Code:    BookingRejectedPromoCodeNotFound,
+			Message: fmt.Sprintf("Promo code '%s' does not exist for Business ID (%d).", code, service.BusinessID),
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !promo.IsActive(bookingTime) {
+		return nil, &BookingRejectedError{
+			Code:    BookingRejectedPromoCodeNotActive,
+			Message: fmt.Sprintf("Promo code '%s' is not valid at this time.", code),
+		}
+	}
+
+	eligibleServices, err := promo.GetEligibleServices(db, promo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if (len(eligibleServices) > 0 && !slices.Contains(eligibleServices, service.Name)) || (promo.AmountOff > 0 && promo.Currency != service.Currency) {
+		return nil, &BookingRejectedError{
+			Code:    BookingRejectedPromoCodeNotEligible,
+			Message: fmt.Sprintf("Promo code '%s' can't be used for Service ID (%d).", code, service.ID),
+		}
+	}
+
+	if promo.MaxRedemptions > 0 {
+		redemptions, err := promo.countRedemptions(db, promo.ID, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		if redemptions >= int64(promo.MaxRedemptions) {
+			return nil, &BookingRejectedError{
+				Code:    BookingRejectedPromoCodeLimit,
+				Message: fmt.Sprintf("Promo code '%s' has already been used the maximum number of times (%d).", code, promo.MaxRedemptions),
+			}
+		}
+	}
+
+	if promo.MaxRedemptionsPerUser > 0 {
+		redemptions, err := promo.countRedemptions(db, promo.ID, appt.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if redemptions >= int64(promo.MaxRedemptionsPerUser) {
+			return nil, &BookingRejectedError{
+				Code:    BookingRejectedPromoCodeLimit,
+				Message: fmt.Sprintf("User ID (%d) has already used promo code '%s' the maximum number of times (%d).", appt.UserID, code, promo.MaxRedemptionsPerUser),
+			}
+		}
+	}
+
+	if promo.FirstTimeCustomersOnly {
+		var otherAppointments int64
+		err = db.Model(&Appointment{}).
+			Joins("JOIN services ON services.id = appointments.service_id").
+			Where("appointments.user_id = ? AND appointments.id <> ? AND services.business_id = ? AND appointments.status IN ?",
+				appt.UserID, appt.ID, service.BusinessID, seatHoldingAppointmentStatuses).
+			Count(&otherAppointments).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if otherAppointments > 0 {
+			return nil, &BookingRejectedError{
+				Code:    BookingRejectedPromoCodeFirstTime,
+				Message: fmt.Sprintf("Promo code '%s' is only for first-time customers of Business ID (%d).", code, service.BusinessID),
+			}
+		}
+	}
+
+	redemption := &PromoCodeRedemption{
+		PromoCodeID:   promo.ID,
+		UserID:        appt.UserID,
+		AppointmentID: appt.ID,
+		Currency:      service.Currency,
+	}
+
+	err = db.Create(redemption).Error
+	return redemption, err
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// A redemption listed in a promo code's redemption report (see 'PromoCode.GetRedemptionReport')
+type PromoCodeRedemptionDetail struct {
+	RedemptionID      uint                        `gorm:"column:redemption_id" json:"redemption_id"`           // ID of the PromoCodeRedemption
+	RedeemedAt        time.Time                   `gorm:"column:redeemed_at" json:"redeemed_at"`               // Date/time when the appointment was booked with the code
+	UserID            uint                        `gorm:"column:user_id" json:"user_id"`                       // ID of User that redeemed the code
+	FirstName         string                      `gorm:"column:first_name" json:"first_name"`                 // User's first name
+	LastName          string                      `gorm:"column:last_name" json:"last_name"`                   // User's last name
+	Email             string                      `gorm:"column:email" json:"email"`                           // User's email address
+	AppointmentID     uint                        `gorm:"column:appointment_id" json:"appointment_id"`         // ID of Appointment that the code was used for
+	AppointmentStatus string                      `gorm:"column:appointment_status" json:"appointment_status"` // Current status of the appointment (empty if it was deleted)
+	ServiceID         uint                        `gorm:"column:service_id" json:"service_id"`                 // ID of the appointment's Service
+	ServiceName       string                      `gorm:"column:service_name" json:"service_name"`             // Name of the appointment's Service
+	InvoiceID         uint                        `gorm:"column:invoice_id" json:"invoice_id"`                 // ID of Invoice that the discount was taken off (0 if not invoiced yet)
+	InvoiceNumber     string                      `gorm:"column:invoice_number" json:"invoice_number"`         // Number of the invoice
+	Discount          int                         `gorm:"column:discount" json:"discount"`                     // Discount taken off the invoice
+	Currency          string                      `gorm:"column:currency" json:"currency"`                     // ISO 4217 currency of the discount
+	Cancelled         bool                        `gorm:"-" json:"cancelled"`                                  // True if the appointment was cancelled or deleted (the redemption doesn't count towards the code's limits)
+	Display           *PromoCodeRedemptionDisplay `gorm:"-" json:"display,omitempty"`                          // Discount formatted for the requester's locale (only set in API responses)
+}
+
+// Total discount given with a promo code in one currency (see 'PromoCode.GetRedemptionReport')
+type PromoCodeRedemptionTotal struct {
+	Currency        string                      `json:"currency"`          // ISO 4217 currency of the discount
+	RedemptionCount int                         `json:"redemption_count"`  // Number of redemptions of appointments that have not been cancelled
+	Discount        int                         `json:"discount"`          // Total discount taken off the invoices of those appointments
+	Display         *PromoCodeRedemptionDisplay `json:"display,omitempty"` // Discount formatted for the requester's locale (only set in API responses)
+}
+
+// Redemption report of a promo code (see 'PromoCode.GetRedemptionReport')
+type PromoCodeRedemptionReport struct {
+	PromoCode            *PromoCode                  `json:"promo_code"`            // The reported promo code
+	RedemptionCount      int                         `json:"redemption_count"`      // Number of redemptions that count towards the code's limits (appointments that have not been cancelled)
+	CancelledCount       int                         `json:"cancelled_count"`       // Number of redemptions of appointments that were cancelled
+	CustomerCount        int                         `json:"customer_count"`        // Number of Users with a redemption that counts towards the code's limits
+	RemainingRedemptions *int                        `json:"remaining_redemptions"` // Number of times the code can still be used (null if there is no limit)
+	Totals               []PromoCodeRedemptionTotal  `json:"totals"`                // Total discount in each currency, ordered by currency
+	Redemptions          []PromoCodeRedemptionDetail `json:"redemptions"`           // Every redemption of the code, most recent first
+}
+
+/*
+*Description*
+
+func GetRedemptionReport
+
+Returns the redemption report of the specified PromoCode: every appointment that was booked with the code (with the customer, the
+appointment's status, and the discount taken off its invoice), how many times the code has been used and by how many customers, how
+many more times it can be used, and the total discount given in each currency.
+
+Redemptions of appointments that were cancelled (or deleted) are listed, but are not included in the counts and totals, since they
+don't count towards the code's limits and their invoices were credited. Promo codes that have been deleted can still be reported.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	promoID  <uint>
+
+		The ID of the promo code.
+
+*Returns*
+
+	_  <*PromoCodeRedemptionReport>
+
+		The report.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (promo *PromoCode) GetRedemptionReport(db *gorm.DB, promoID uint) (*PromoCodeRedemptionReport, error) {
+	report := &PromoCodeRedemptionReport{
+		PromoCode:   promo,
+		Totals:      []PromoCodeRedemptionTotal{},
+		Redemptions: []PromoCodeRedemptionDetail{},
+	}
+
+	err := db.Unscoped().First(promo, promoID).Error
+	if err != nil {
+		return report, err
+	}
+
+	err = db.Raw(`SELECT
+			promo_code_redemptions.id AS redemption_id,
+			promo_code_redemptions.created_at AS redeemed_at,
+			promo_code_redemptions.user_id,
+			COALESCE(users.first_name, '') AS first_name,
+			COALESCE(users.last_name, '') AS last_name,
+			COALESCE(users.email, '') AS email,
+			promo_code_redemptions.appointment_id,
+			COALESCE(appointments.status, '') AS appointment_status,
+			COALESCE(appointments.service_id, 0) AS service_id,
+			COALESCE(services.name, '') AS service_name,
+			promo_code_redemptions.invoice_id,
+			COALESCE(invoices.number, '') AS invoice_number,
+			promo_code_redemptions.discount,
+			promo_code_redemptions.currency
+		FROM promo_code_redemptions
+		LEFT JOIN users ON users.id = promo_code_redemptions.user_id
+		LEFT JOIN appointments ON appointments.id = promo_code_redemptions.appointment_id AND appointments.deleted_at IS NULL
+		LEFT JOIN services ON services.id = appointments.service_id
+		LEFT JOIN invoices ON invoices.id = promo_code_redemptions.invoice_id
+		WHERE promo_code_redemptions.promo_code_id = @promo_code_id AND promo_code_redemptions.deleted_at IS NULL
+		ORDER BY promo_code_redemptions.created_at DESC, promo_code_redemptions.id DESC`,
+		map[string]interface{}{"promo_code_id": promoID}).Scan(&report.Redemptions).Error
+	if err != nil {
+		return report, err
+	}
+
+	customers := map[uint]bool{}
+	totals := map[string]*PromoCodeRedemptionTotal{}
+	for i := range report.Redemptions {
+		redemption := &report.Redemptions[i]
+		redemption.Cancelled = redemption.AppointmentStatus == "" || AppointmentStatusIsCancelled(redemption.AppointmentStatus)
+		if redemption.Cancelled {
+			report.CancelledCount++
+			continue
+		}
+
+		report.RedemptionCount++
+		customers[redemption.UserID] = true
+
+		total, found := totals[redemption.Currency]
+		if !found {
+			total = &PromoCodeRedemptionTotal{Currency: redemption.Currency}
+			totals[redemption.Currency] = total
+		}
+
+		total.RedemptionCount++
+		total.Discount += redemption.Discount
+	}
+	report.CustomerCount = len(customers)
+
+	for _, total := range totals {
+		report.Totals = append(report.Totals, *total)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool { return report.Totals[i].Currency < report.Totals[j].Currency })
+
+	if promo.MaxRedemptions > 0 {
+		var remaining int = int(promo.MaxRedemptions) - report.RedemptionCount
+		if remaining < 0 {
+			remaining = 0
+		}
+		report.RemainingRedemptions = &remaining
+	}
+
+	return report, nil
+}
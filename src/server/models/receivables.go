@@ -0,0 +1,403 @@
+package models
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"server/money"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Aging buckets of outstanding invoices, by how long they have been past due
+const (
+	AgingCurrent string = "Current" // Not due yet
+	Aging1To30   string = "1-30"    // 1-30 days past due
+	Aging31To60  string = "31-60"   // 31-60 days past due
+	Aging61To90  string = "61-90"   // 61-90 days past due
+	AgingOver90  string = "90+"     // More than 90 days past due
+)
+
+// Number of days in each past due aging bucket (except the last)
+const agingBucketDays int = 30
+
+// Outstanding balances split into aging buckets (in the smallest unit of their currency, e.g. cents)
+type AgingBuckets struct {
+	Current    int `gorm:"column:current_balance" json:"current"`   // Owed on invoices that aren't due yet
+	Days1To30  int `gorm:"column:days_1_30" json:"days_1_30"`       // Owed on invoices 1-30 days past due
+	Days31To60 int `gorm:"column:days_31_60" json:"days_31_60"`     // Owed on invoices 31-60 days past due
+	Days61To90 int `gorm:"column:days_61_90" json:"days_61_90"`     // Owed on invoices 61-90 days past due
+	Over90     int `gorm:"column:days_over_90" json:"days_over_90"` // Owed on invoices more than 90 days past due
+	Total      int `gorm:"column:total" json:"total"`               // Owed on all invoices
+}
+
+// Outstanding balance of one customer of a Business in one currency (see 'Business.GetReceivablesReport')
+type ReceivablesCustomer struct {
+	UserID       uint                `gorm:"column:user_id" json:"user_id"`             // ID of User that owes the balance
+	FirstName    string              `gorm:"column:first_name" json:"first_name"`       // User's first name
+	LastName     string              `gorm:"column:last_name" json:"last_name"`         // User's last name
+	Email        string              `gorm:"column:email" json:"email"`                 // User's email address
+	Currency     string              `gorm:"column:currency" json:"currency"`           // ISO 4217 currency of the amounts
+	InvoiceCount int                 `gorm:"column:invoice_count" json:"invoice_count"` // Number of invoices that are still owed
+	OldestDueAt  *time.Time          `gorm:"column:oldest_due_at" json:"oldest_due_at"` // Due date of the customer's oldest outstanding invoice
+	AgingBuckets                     // Balance owed, split into aging buckets
+	Invoices     []ReceivableInvoice `gorm:"-" json:"invoices,omitempty"` // Outstanding invoices, oldest due first (only included in detailed reports)
+}
+
+// An outstanding invoice listed in a receivables report (see 'Business.GetReceivablesReport')
+type ReceivableInvoice struct {
+	InvoiceID        uint       `gorm:"column:invoice_id" json:"invoice_id"`               // ID of the Invoice
+	Number           string     `gorm:"column:number" json:"number"`                       // Invoice number
+	UserID           uint       `gorm:"column:user_id" json:"user_id"`                     // ID of User billed by the invoice
+	Currency         string     `gorm:"column:currency" json:"currency"`                   // ISO 4217 currency of the invoice
+	IssuedAt         *time.Time `gorm:"column:issued_at" json:"issued_at"`                 // Date/time when the invoice was issued
+	DueAt            *time.Time `gorm:"column:due_at" json:"due_at"`                       // Date/time when the invoice is due
+	RemainingBalance int        `gorm:"column:remaining_balance" json:"remaining_balance"` // Balance still owed on the invoice
+	DaysPastDue      int        `gorm:"-" json:"days_past_due"`                            // Whole days the invoice is past due, rounded up (0 if it isn't due yet)
+	Bucket           string     `gorm:"-" json:"bucket"`                                   // Aging bucket of the invoice (see the 'Aging*' constants)
+}
+
+// Total outstanding balance of a Business's customers in one currency
+type ReceivablesTotal struct {
+	Currency      string `json:"currency"`       // ISO 4217 currency of the amounts
+	CustomerCount int    `json:"customer_count"` // Number of customers that owe a balance
+	InvoiceCount  int    `json:"invoice_count"`  // Number of invoices that are still owed
+	AgingBuckets         // Balance owed, split into aging buckets
+}
+
+// Accounts receivable aging report of a Business (see 'Business.GetReceivablesReport')
+type ReceivablesReport struct {
+	BusinessID uint                  `json:"business_id"` // ID of the Business that is owed the balances
+	AsOf       time.Time             `json:"as_of"`       // Date/time that the invoices are aged to
+	Customers  []ReceivablesCustomer `json:"customers"`   // Balance of each customer, largest first
+	Totals     []ReceivablesTotal    `json:"totals"`      // Total balance in each currency, ordered by currency
+	Detailed   bool                  `json:"detailed"`    // True if each customer's outstanding invoices are listed
+}
+
+// Filter shared by the receivables queries (issued invoices that are still owed, see 'idx_invoices_receivables')
+const receivablesFilter string = `invoices.business_id = @business_id
+	AND invoices.state = @state
+	AND invoices.status IN @statuses
+	AND invoices.remaining_balance > 0
+	AND invoices.deleted_at IS NULL`
+
+/*
+*Description*
+
+func agingBucket
+
+Returns the aging bucket of an invoice with the specified due date as of the specified time, and the number of whole days (rounded up)
+that it is past due. Invoices without a due date are current.
+
+The bucket boundaries match the ones used by the receivables query (see 'GetReceivablesReport').
+
+*Parameters*
+
+	dueAt  <*time.Time>
+
+		The invoice's due date.
+
+	asOf  <time.Time>
+
+		The time that the invoice is aged to.
+
+*Returns*
+
+	_  <string>
+
+		The aging bucket (see the 'Aging*' constants).
+
+	_  <int>
+
+		The number of days the invoice is past due (0 if it isn't due yet).
+*/
+func agingBucket(dueAt *time.Time, asOf time.Time) (string, int) {
+	if dueAt == nil || !dueAt.Before(asOf) {
+		return AgingCurrent, 0
+	}
+
+	daysPastDue := int(math.Ceil(asOf.Sub(*dueAt).Hours() / 24))
+	switch {
+	case !dueAt.Before(asOf.AddDate(0, 0, -agingBucketDays)):
+		return Aging1To30, daysPastDue
+	case !dueAt.Before(asOf.AddDate(0, 0, -2*agingBucketDays)):
+		return Aging31To60, daysPastDue
+	case !dueAt.Before(asOf.AddDate(0, 0, -3*agingBucketDays)):
+		return Aging61To90, daysPastDue
+	default:
+		return AgingOver90, daysPastDue
+	}
+}
+
+/*
+*Description*
+
+func add
+
+Adds the calling AgingBuckets' amounts to the specified buckets.
+
+*Parameters*
+
+	buckets  <*AgingBuckets>
+
+		The buckets that the amounts are added to.
+
+*Returns*
+
+	N/A (None)
+*/
+func (aging AgingBuckets) add(buckets *AgingBuckets) {
+	buckets.Current += aging.Current
+	buckets.Days1To30 += aging.Days1To30
+	buckets.Days31To60 += aging.Days31To60
+	buckets.Days61To90 += aging.Days61To90
+	buckets.Over90 += aging.Over90
+	buckets.Total += aging.Total
+}
+
+/*
+*Description*
+
+func GetReceivablesReport
+
+Builds the accounts receivable aging report of the specified Business: the balance that each customer still owes on the Business's
+issued invoices, split into aging buckets by how long the invoices have been past due (current, 1-30, 31-60, 61-90 and more than 90
+days), along with the totals of each currency.
+
+The balances are aggregated by the database in a single query, so only one row per customer is loaded however many invoices there are.
+A detailed report also lists each customer's outstanding invoices (the drill-down), which can be limited to a single customer.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	businessID  <uint>
+
+		The ID of the Business.
+
+	asOf  <time.Time>
+
+		The time that the invoices are aged to (normally the current time).
+
+	userID  <uint>
+
+		The ID of the only customer to include (0 for every customer).
+
+	detailed  <bool>
+
+		True to list each customer's outstanding invoices.
+
+*Returns*
+
+	_  <*ReceivablesReport>
+
+		The report.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (business *Business) GetReceivablesReport(db *gorm.DB, businessID uint, asOf time.Time, userID uint, detailed bool) (*ReceivablesReport, error) {
+	report := &ReceivablesReport{
+		BusinessID: businessID,
+		AsOf:       asOf,
+		Customers:  []ReceivablesCustomer{},
+		Totals:     []ReceivablesTotal{},
+		Detailed:   detailed,
+	}
+
+	parameters := map[string]interface{}{
+		"business_id": businessID,
+		"user_id":     userID,
+		"state":       InvoiceStateIssued,
+		"statuses":    owedInvoiceStatuses,
+		"as_of":       asOf,
+		"days_30":     asOf.AddDate(0, 0, -agingBucketDays),
+		"days_60":     asOf.AddDate(0, 0, -2*agingBucketDays),
+		"days_90":     asOf.AddDate(0, 0, -3*agingBucketDays),
+	}
+
+	filter := receivablesFilter
+	if userID != 0 {
+		filter += " AND invoices.user_id = @user_id"
+	}
+
+	err := db.Raw(`SELECT
+			invoices.user_id,
+			invoices.currency,
+			COALESCE(MAX(users.first_name), '') AS first_name,
+			COALESCE(MAX(users.last_name), '') AS last_name,
+			COALESCE(MAX(users.email), '') AS email,
+			COUNT(*) AS invoice_count,
+			MIN(invoices.due_at) AS oldest_due_at,
+			COALESCE(SUM(invoices.remaining_balance) FILTER (WHERE invoices.due_at IS NULL OR invoices.due_at >= @as_of), 0) AS current_balance,
+			COALESCE(SUM(invoices.remaining_balance) FILTER (WHERE invoices.due_at < @as_of AND invoices.due_at >= @days_30), 0) AS days_1_30,
+			COALESCE(SUM(invoices.remaining_balance) FILTER (WHERE invoices.due_at < @days_30 AND invoices.due_at >= @days_60), 0) AS days_31_60,
+			COALESCE(SUM(invoices.remaining_balance) FILTER (WHERE invoices.due_at < @days_60 AND invoices.due_at >= @days_90), 0) AS days_61_90,
+			COALESCE(SUM(invoices.remaining_balance) FILTER (WHERE invoices.due_at < @days_90), 0) AS days_over_90,
+			SUM(invoices.remaining_balance) AS total
+		FROM invoices
+		LEFT JOIN users ON users.id = invoices.user_id
+		WHERE `+filter+`
+		GROUP BY invoices.user_id, invoices.currency
+		ORDER BY total DESC, invoices.user_id, invoices.currency`, parameters).Scan(&report.Customers).Error
+	if err != nil {
+		return report, err
+	}
+
+	totals := map[string]*ReceivablesTotal{}
+	for _, customer := range report.Customers {
+		total, found := totals[customer.Currency]
+		if !found {
+			total = &ReceivablesTotal{Currency: customer.Currency}
+			totals[customer.Currency] = total
+		}
+
+		total.CustomerCount++
+		total.InvoiceCount += customer.InvoiceCount
+		customer.AgingBuckets.add(&total.AgingBuckets)
+	}
+
+	for _, total := range totals {
+		report.Totals = append(report.Totals, *total)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool { return report.Totals[i].Currency < report.Totals[j].Currency })
+
+	if !detailed {
+		return report, nil
+	}
+
+	var invoices []ReceivableInvoice
+	err = db.Raw(`SELECT
+			invoices.id AS invoice_id,
+			invoices.number,
+			invoices.user_id,
+			invoices.currency,
+			invoices.issued_at,
+			invoices.due_at,
+			invoices.remaining_balance
+		FROM invoices
+		WHERE `+filter+`
+		ORDER BY invoices.due_at, invoices.id`, parameters).Scan(&invoices).Error
+	if err != nil {
+		return report, err
+	}
+
+	customerIndexes := map[string]int{}
+	for i, customer := range report.Customers {
+		report.Customers[i].Invoices = []ReceivableInvoice{}
+		customerIndexes[customer.Currency+strconv.FormatUint(uint64(customer.UserID), 10)] = i
+	}
+
+	for _, invoice := range invoices {
+		invoice.Bucket, invoice.DaysPastDue = agingBucket(invoice.DueAt, asOf)
+
+		i, found := customerIndexes[invoice.Currency+strconv.FormatUint(uint64(invoice.UserID), 10)]
+		if found {
+			report.Customers[i].Invoices = append(report.Customers[i].Invoices, invoice)
+		}
+	}
+
+	return report, nil
+}
+
+/*
+*Description*
+
+func CSV
+
+Writes the calling ReceivablesReport as a CSV file that can be opened in a spreadsheet. Amounts are written as plain decimal numbers in
+each currency's major unit (see 'money.Money.Decimal').
+
+A summary report has one row per customer followed by a total row for each currency. A detailed report has one row per outstanding
+invoice instead.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <[]byte>
+
+		The contents of the CSV file.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (report *ReceivablesReport) CSV() ([]byte, error) {
+	var file bytes.Buffer
+	writer := csv.NewWriter(&file)
+
+	amount := func(amount int, currency string) string {
+		return money.Money{Amount: amount, Currency: currency}.Decimal()
+	}
+
+	date := func(date *time.Time) string {
+		if date == nil {
+			return ""
+		}
+
+		return date.Format("2006-01-02")
+	}
+
+	var rows [][]string
+	if report.Detailed {
+		rows = append(rows, []string{"Customer ID", "Customer", "Email", "Currency", "Invoice", "Issued", "Due", "Days Past Due", "Bucket", "Balance"})
+		for _, customer := range report.Customers {
+			name := strings.TrimSpace(customer.FirstName + " " + customer.LastName)
+			for _, invoice := range customer.Invoices {
+				rows = append(rows, []string{
+					strconv.FormatUint(uint64(customer.UserID), 10),
+					name,
+					customer.Email,
+					invoice.Currency,
+					invoice.Number,
+					date(invoice.IssuedAt),
+					date(invoice.DueAt),
+					strconv.Itoa(invoice.DaysPastDue),
+					invoice.Bucket,
+					amount(invoice.RemainingBalance, invoice.Currency),
+				})
+			}
+		}
+	} else {
+		bucketColumns := func(aging AgingBuckets, currency string) []string {
+			return []string{
+				amount(aging.Current, currency),
+				amount(aging.Days1To30, currency),
+				amount(aging.Days31To60, currency),
+				amount(aging.Days61To90, currency),
+				amount(aging.Over90, currency),
+				amount(aging.Total, currency),
+			}
+		}
+
+		rows = append(rows, []string{"Customer ID", "Customer", "Email", "Currency", "Invoices", AgingCurrent, Aging1To30, Aging31To60, Aging61To90, AgingOver90, "Total"})
+		for _, customer := range report.Customers {
+			row := []string{
+				strconv.FormatUint(uint64(customer.UserID), 10),
+				strings.TrimSpace(customer.FirstName + " " + customer.LastName),
+				customer.Email,
+				customer.Currency,
+				strconv.Itoa(customer.InvoiceCount),
+			}
+			rows = append(rows, append(row, bucketColumns(customer.AgingBuckets, customer.Currency)...))
+		}
+
+		for _, total := range report.Totals {
+			row := []string{"", "Total", "", total.Currency, strconv.Itoa(total.InvoiceCount)}
+			rows = append(rows, append(row, bucketColumns(total.AgingBuckets, total.Currency)...))
+		}
+	}
+
+	err := writer.WriteAll(rows)
+	return file.Bytes(), err
+}
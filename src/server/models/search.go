@@ -0,0 +1,243 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// Number of results of each kind that a search returns when the request doesn't set a limit, and the most that a request can ask for
+const (
+	DefaultSearchLimit int = 20
+	MaxSearchLimit     int = 100
+)
+
+// Most words of a search query that are matched (the rest are ignored)
+const maxSearchTerms int = 10
+
+// Text search configuration that records are indexed and searched with (see 'createSearchIndexes')
+const searchConfiguration string = "english"
+
+// Weight of a match on the name of a Service's Business, relative to a match on the Service's own name and description
+const businessMatchWeight float64 = 0.5
+
+// Error returned when a search's query or filters are invalid
+var ErrInvalidSearch = errors.New("invalid search")
+
+// Query and filters of a search (see 'Search')
+type SearchOptions struct {
+	Query         string    // Words to search for. Each word matches the words that start with it, so partly typed words match
+	From          time.Time // Earliest start of the Services found (defaults to now, so only upcoming Services are found)
+	To            time.Time // Latest start of the Services found (exclusive, zero for no limit)
+	MinPrice      *uint     // Lowest price of the Services found (in cents, nil for no limit)
+	MaxPrice      *uint     // Highest price of the Services found (in cents, nil for no limit)
+	AvailableOnly bool      // True to only find Services that aren't full
+	Limit         int       // Number of Services and of Businesses returned (defaults to 'DefaultSearchLimit', at most 'MaxSearchLimit')
+}
+
+// A Service found by a search, with the name of its Business and the relevance of the match
+type ServiceSearchResult struct {
+	Service
+	BusinessName string  `gorm:"column:business_name" json:"business_name"` // Name of the Business that offers the Service
+	Rank         float64 `gorm:"column:search_rank" json:"rank"`            // Relevance of the match (higher is more relevant)
+}
+
+// A Business found by a search, with the relevance of the match and its number of upcoming Services
+type BusinessSearchResult struct {
+	Business
+	UpcomingServices int64   `gorm:"column:upcoming_services" json:"upcoming_services"` // Number of the Business's Services that start after the search's 'From'
+	Rank             float64 `gorm:"column:search_rank" json:"rank"`                    // Relevance of the match (higher is more relevant)
+}
+
+// Services and Businesses found by a search, most relevant first (see 'Search')
+type SearchResults struct {
+	Query        string                 `json:"query"`         // Query that was searched for
+	Services     []ServiceSearchResult  `json:"services"`      // Services that match the query and filters (at most the search's limit)
+	ServiceCount int64                  `json:"service_count"` // Number of Services that match the query and filters
+	Businesses   []BusinessSearchResult `json:"businesses"`    // Businesses whose names match the query (at most the search's limit)
+}
+
+/*
+*Description*
+
+func searchQuery
+
+Converts the words of a search into a text search query (tsquery) that matches records containing every word, or a word that starts
+with it. Anything but letters and digits separates words, so the query can't contain text search operators.
+
+*Parameters*
+
+	query  <string>
+
+		The words to search for.
+
+*Returns*
+
+	_  <string>
+
+		The text search query (e.g. "yoga:* & class:*").
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func searchQuery(query string) (string, error) {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if len(words) == 0 {
+		return "", fmt.Errorf("%w: the query must contain a letter or digit", ErrInvalidSearch)
+	}
+
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+/*
+*Description*
+
+func Search
+
+Searches the names and descriptions of Services and the names of Businesses with Postgres full-text search. Words are matched after
+stemming (e.g. "classes" matches "class"), and each word also matches the words that start with it, so results can be shown as the
+query is typed.
+
+Services are found if their name or description, or their Business's name, match the query. They are ranked by relevance, with matches
+on the Service's name weighted above its description and matches on its Business's name weighted below both, and can be filtered by
+start time, price and availability. Businesses are found if their name matches the query.
+
+The search index is kept in generated columns of the services and businesses tables (see 'createSearchIndexes'), so it is updated as
+records are created and changed.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be searched.
+
+	options  <SearchOptions>
+
+		The query and filters of the search.
+
+*Returns*
+
+	_  <SearchResults>
+
+		The Services and Businesses found, most relevant first.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func Search(db *gorm.DB, options SearchOptions) (SearchResults, error) {
+	results := SearchResults{Query: options.Query, Services: []ServiceSearchResult{}, Businesses: []BusinessSearchResult{}}
+
+	query, err := searchQuery(options.Query)
+	if err != nil {
+		return results, err
+	}
+
+	limit := options.Limit
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	}
+
+	if limit < 0 || limit > MaxSearchLimit {
+		return results, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidSearch, MaxSearchLimit)
+	}
+
+	if options.MinPrice != nil && options.MaxPrice != nil && *options.MinPrice > *options.MaxPrice {
+		return results, fmt.Errorf("%w: the minimum price can't be above the maximum price", ErrInvalidSearch)
+	}
+
+	from := options.From
+	if from.IsZero() {
+		from = time.Now()
+	}
+
+	if !options.To.IsZero() && !from.Before(options.To) {
+		return results, fmt.Errorf("%w: the start of the date range must be before its end", ErrInvalidSearch)
+	}
+
+	parameters := map[string]interface{}{
+		"configuration":   searchConfiguration,
+		"query":           query,
+		"from":            from,
+		"business_weight": businessMatchWeight,
+		"limit":           limit,
+	}
+
+	filters := []string{
+		"services.deleted_at IS NULL",
+		"(services.search_vector @@ to_tsquery(@configuration::regconfig, @query) OR businesses.search_vector @@ to_tsquery(@configuration::regconfig, @query))",
+		"services.start_date_time >= @from",
+	}
+
+	if !options.To.IsZero() {
+		filters = append(filters, "services.start_date_time < @to")
+		parameters["to"] = options.To
+	}
+
+	if options.MinPrice != nil {
+		filters = append(filters, "services.price >= @min_price")
+		parameters["min_price"] = *options.MinPrice
+	}
+
+	if options.MaxPrice != nil {
+		filters = append(filters, "services.price <= @max_price")
+		parameters["max_price"] = *options.MaxPrice
+	}
+
+	if options.AvailableOnly {
+		filters = append(filters, "services.is_full = false")
+	}
+
+	matchingServices := `FROM services
+		LEFT JOIN businesses ON businesses.id = services.business_id AND businesses.deleted_at IS NULL
+		WHERE ` + strings.Join(filters, "\n\t\t\tAND ")
+
+	err = db.Raw(`SELECT COUNT(*) `+matchingServices, parameters).Scan(&results.ServiceCount).Error
+	if err != nil {
+		return results, err
+	}
+
+	err = db.Raw(`SELECT
+			services.*,
+			COALESCE(businesses.name, '') AS business_name,
+			ts_rank(services.search_vector, to_tsquery(@configuration::regconfig, @query))
+				+ @business_weight * COALESCE(ts_rank(businesses.search_vector, to_tsquery(@configuration::regconfig, @query)), 0) AS search_rank
+		`+matchingServices+`
+		ORDER BY search_rank DESC, services.start_date_time, services.id
+		LIMIT @limit`, parameters).Scan(&results.Services).Error
+	if err != nil {
+		return results, err
+	}
+
+	err = db.Raw(`SELECT
+			businesses.*,
+			ts_rank(businesses.search_vector, to_tsquery(@configuration::regconfig, @query)) AS search_rank,
+			(SELECT COUNT(*) FROM services
+				WHERE services.business_id = businesses.id
+					AND services.start_date_time >= @from
+					AND services.deleted_at IS NULL) AS upcoming_services
+		FROM businesses
+		WHERE businesses.deleted_at IS NULL
+			AND businesses.search_vector @@ to_tsquery(@configuration::regconfig, @query)
+		ORDER BY search_rank DESC, businesses.name, businesses.id
+		LIMIT @limit`, parameters).Scan(&results.Businesses).Error
+
+	return results, err
+}
@@ -14,16 +14,35 @@ import (
 // GORM model for all Service records in the database
 type Service struct {
 	gorm.Model
-	BusinessID    uint      `gorm:"column:business_id" json:"business_id"`         // ID of Business that Service is associated with
-	Name          string    `gorm:"column:name" json:"name"`                       // Service name
-	Description   string    `gorm:"column:desc" json:"desc"`                       // Service description
-	StartDateTime time.Time `gorm:"column:start_date_time" json:"start_date_time"` // Date/time that the service starts
-	Length        uint      `gorm:"column:length" json:"length"`                   // Length of time in minutes that the service will take
-	Capacity      uint      `gorm:"column:capacity" json:"capacity"`               // Number of users that can sign up for the service
-	CancelFee     uint      `gorm:"column:cancel_fee" json:"cancel_fee"`           // Fee (in cents) for cancelling appointment after minimum notice cutoff
-	Price         uint      `gorm:"column:price" json:"price"`                     // Price (in cents) for the service being offered
-	AppointmentCt int       `gorm:"column:appt_ct" json:"appt_ct" default:"0"`     // Number of active appointments scheduled for the Service
-	IsFull        bool      `gorm:"column:is_full" json:"is_full" default:"false"` // True if number of active appointments equals the capacity for the Service (False if not)
+	BusinessID    uint            `gorm:"column:business_id" json:"business_id"`                // ID of Business that Service is associated with
+	Name          string          `gorm:"column:name" json:"name"`                              // Service name
+	Description   string          `gorm:"column:desc" json:"desc"`                              // Service description
+	StartDateTime time.Time       `gorm:"column:start_date_time;index" json:"start_date_time"`  // Date/time that the service starts
+	Length        uint            `gorm:"column:length" json:"length"`                          // Length of time in minutes that the service will take
+	Capacity      uint            `gorm:"column:capacity" json:"capacity"`                      // Number of users that can sign up for the service
+	CancelFee     uint            `gorm:"column:cancel_fee" json:"cancel_fee"`                  // Fee (in cents) for cancelling appointment after minimum notice cutoff
+	Price         uint            `gorm:"column:price" json:"price"`                            // Price (in cents) for the service being offered
+	Currency      string          `gorm:"column:currency;not null;default:USD" json:"currency"` // ISO 4217 currency of the price and cancellation fee (defaults to the Business's currency)
+	AppointmentCt int             `gorm:"column:appt_ct" json:"appt_ct" default:"0"`            // Number of seats held by active appointments scheduled for the Service
+	IsFull        bool            `gorm:"column:is_full" json:"is_full" default:"false"`        // True if number of held seats has reached the capacity for the Service (False if not)
+	TaxExempt     bool            `gorm:"column:tax_exempt;default:false" json:"tax_exempt"`    // True if the Service is exempt from sales tax (see 'TaxRate')
+	Display       *ServiceDisplay `gorm:"-" json:"display,omitempty"`                           // Price and cancellation fee formatted for the requester's locale (only set in API responses)
+}
+
+// Fields that lists of Service records can be sorted and filtered by (see 'Service.List')
+var serviceListFields map[string]listField = map[string]listField{
+	"id":              {column: "id", jsonKey: "ID", fieldType: listFieldInt, sortable: true},
+	"created_at":      {column: "created_at", jsonKey: "CreatedAt", fieldType: listFieldTime, sortable: true},
+	"updated_at":      {column: "updated_at", jsonKey: "UpdatedAt", fieldType: listFieldTime, sortable: true},
+	"business_id":     {column: "business_id", jsonKey: "business_id", fieldType: listFieldInt, sortable: true},
+	"name":            {column: "name", jsonKey: "name", fieldType: listFieldString, sortable: true},
+	"start_date_time": {column: "start_date_time", jsonKey: "start_date_time", fieldType: listFieldTime, sortable: true},
+	"length":          {column: "length", jsonKey: "length", fieldType: listFieldInt, sortable: true},
+	"capacity":        {column: "capacity", jsonKey: "capacity", fieldType: listFieldInt, sortable: true},
+	"price":           {column: "price", jsonKey: "price", fieldType: listFieldInt, sortable: true},
+	"currency":        {column: "currency", jsonKey: "currency", fieldType: listFieldString},
+	"is_full":         {column: "is_full", jsonKey: "is_full", fieldType: listFieldBool},
+	"tax_exempt":      {column: "tax_exempt", jsonKey: "tax_exempt", fieldType: listFieldBool},
 }
 
 /*
@@ -136,7 +155,13 @@ Creates a new Service record in the database and returns the created record alon
 		Encountered error (nil if no errors are encountered).
 */
 func (service *Service) Create(db *gorm.DB) (map[string]Model, error) {
-	err := db.Create(&service).Error
+	currency, err := resolveCurrency(db, service.BusinessID, service.Currency)
+	if err != nil {
+		return map[string]Model{"service": service}, err
+	}
+	service.Currency = currency
+
+	err = db.Create(&service).Error
 	returnRecords := map[string]Model{"service": service}
 	return returnRecords, err
 }
@@ -207,6 +232,38 @@ func (service *Service) GetAll(db *gorm.DB) ([]Service, error) {
 /*
 *Description*
 
+func List
+
+Retrieves a page of Service records from the database, sorted and filtered as requested (see 'serviceListFields').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	options  <ListOptions>
+
+		The limit, cursor, sort and filters of the page.
+
+*Returns*
+
+	_  <ListPage>
+
+		The page of Service records ([]Service), with the total number of records that match the filters and the cursor of the next page.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (service *Service) List(db *gorm.DB, options ListOptions) (ListPage, error) {
+	var services []Service
+	return listRecords(db, &services, serviceListFields, options)
+}
+
+/*
+*Description*
+
 func GetRecordsByPrimaryIDs
 
 Retrieves a list of Service records from the database using their IDs (primary key).
@@ -361,7 +418,7 @@ func (service *Service) GetUsers(db *gorm.DB, serviceID uint, activeOnly bool) (
 
 	// Get list of UserIDs from appointments
 	for _, appt := range appts {
-		if !activeOnly || (activeOnly && appt.Active) {
+		if !activeOnly || (activeOnly && appt.IsActive()) {
 			apptsUserIDs = append(apptsUserIDs, appt.GetUserID())
 		}
 	}
@@ -421,6 +478,10 @@ If a specified field's value should be deleted from the record, the appropriate
 		Encountered error (nil if no errors are encountered)
 */
 func (service *Service) Update(db *gorm.DB, serviceID uint, updates map[string]interface{}) (map[string]Model, error) {
+	if err := normalizeCurrencyUpdate(updates); err != nil {
+		return map[string]Model{"service": &Service{}}, err
+	}
+
 	// Confirm serviceID exists in the database and get current object
 	returnRecords, err := service.Get(db, serviceID)
 	updateService := returnRecords["service"]
@@ -0,0 +1,582 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all StoreCreditAccount records in the database (one record per User, Business and currency that the User holds credit in)
+//
+// Store credit can only be spent with the Business that gave it, as a 'Store Credit' Payment. The balance is derived from the
+// account's StoreCreditTransaction records and is never changed directly.
+type StoreCreditAccount struct {
+	gorm.Model
+	UserID     uint                       `gorm:"column:user_id;not null;uniqueIndex:idx_store_credit_accounts_owner" json:"user_id"`               // ID of User that holds the credit
+	BusinessID uint                       `gorm:"column:business_id;not null;uniqueIndex:idx_store_credit_accounts_owner" json:"business_id"`       // ID of Business that the credit can be spent with
+	Currency   string                     `gorm:"column:currency;not null;default:USD;uniqueIndex:idx_store_credit_accounts_owner" json:"currency"` // ISO 4217 currency of the balance
+	Balance    int                        `gorm:"column:balance;not null;default:0" json:"balance"`                                                 // Credit available to spend (in cents)
+	Display    *StoreCreditAccountDisplay `gorm:"-" json:"display,omitempty"`                                                                       // Balance formatted for the requester's locale (only set in API responses)
+}
+
+// GORM model for all StoreCreditTransaction records in the database (one record per change to a StoreCreditAccount's balance)
+type StoreCreditTransaction struct {
+	gorm.Model
+	AccountID    uint                      `gorm:"column:account_id;not null;index" json:"account_id"`   // ID of StoreCreditAccount whose balance changed
+	UserID       uint                      `gorm:"column:user_id;not null;index" json:"user_id"`         // ID of User that holds the credit
+	BusinessID   uint                      `gorm:"column:business_id;not null;index" json:"business_id"` // ID of Business that the credit can be spent with
+	Type         string                    `gorm:"column:type;not null" json:"type"`                     // Type of balance change (Overpayment, Goodwill, Refunded, Redeemed, Restored)
+	Amount       int                       `gorm:"column:amount;not null" json:"amount"`                 // Change in balance (in cents, positive for credits and negative for redemptions)
+	BalanceAfter int                       `gorm:"column:balance_after" json:"balance_after"`            // Balance of the account (in cents) right after the change
+	InvoiceID    uint                      `gorm:"column:invoice_id" json:"invoice_id"`                  // ID of Invoice that the credit came from or was spent on (0 for goodwill credits)
+	PaymentID    uint                      `gorm:"column:payment_id" json:"payment_id"`                  // ID of Payment that spent the credit, or whose refund was credited (0 for goodwill credits)
+	RefundID     uint                      `gorm:"column:refund_id" json:"refund_id"`                    // ID of Refund that was credited to the account (0 if the change is not a refund)
+	Reason       string                    `gorm:"column:reason" json:"reason"`                          // Reason for the change (e.g. "Sorry for the late start")
+	Currency     string                    `gorm:"column:currency;not null;default:USD" json:"currency"` // ISO 4217 currency of the amount
+	Display      *CreditTransactionDisplay `gorm:"-" json:"display,omitempty"`                           // Amount and balance after formatted for the requester's locale (only set in API responses)
+}
+
+// Store credit transaction types
+const (
+	StoreCreditOverpayment string = "Overpayment" // Surplus paid on an Overpaid invoice kept as credit (see 'Invoice.CreditOverpayment')
+	StoreCreditGoodwill    string = "Goodwill"    // Credit given by the Business (see 'StoreCreditAccount.AddGoodwillCredit')
+	StoreCreditRefunded    string = "Refunded"    // A payment refunded as store credit instead of money
+	StoreCreditRedeemed    string = "Redeemed"    // Credit spent on an invoice with a 'Store Credit' payment
+	StoreCreditRestored    string = "Restored"    // Credit given back when a 'Store Credit' payment is refunded
+)
+
+// Error returned when store credit can't be given or spent
+var ErrInvalidStoreCredit = errors.New("invalid store credit")
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from StoreCreditAccount object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the store credit account object
+*/
+func (account *StoreCreditAccount) GetID() uint {
+	return account.ID
+}
+
+/*
+*Description*
+
+func Create
+
+Store credit accounts are opened the first time credit is given to a User (see 'AddGoodwillCredit' and 'Invoice.CreditOverpayment'),
+so this method always returns an error.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (account *StoreCreditAccount) Create(db *gorm.DB) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: store credit accounts are opened when credit is first given", ErrInvalidStoreCredit)
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a StoreCreditAccount record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	accountID  <uint>
+
+		The ID of the store credit account record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved StoreCreditAccount object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (account *StoreCreditAccount) Get(db *gorm.DB, accountID uint) (map[string]Model, error) {
+	err := db.First(&account, accountID).Error
+	returnRecords := map[string]Model{"store_credit_account": account}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of StoreCreditAccount records from the database that are associated with the specified secondary key (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute (e.g. "user_id" or "business_id").
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]StoreCreditAccount>
+
+		The list of StoreCreditAccount records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (account *StoreCreditAccount) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]StoreCreditAccount, error) {
+	accounts := []StoreCreditAccount{}
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&accounts).Error
+	return accounts, err
+}
+
+/*
+*Description*
+
+func Update
+
+Store credit balances can only change through credits and redemptions (which are recorded as StoreCreditTransaction records), so this
+method always returns an error.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	accountID  <uint>
+
+		N/A
+
+	updates  <map[string]interface{}>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (account *StoreCreditAccount) Update(db *gorm.DB, accountID uint, updates map[string]interface{}) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: store credit balances can only change through credits and redemptions", ErrInvalidStoreCredit)
+}
+
+/*
+*Description*
+
+func Delete
+
+Store credit accounts can't be deleted, since their history is part of the Business's ledger.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		N/A
+
+	accountID  <uint>
+
+		N/A
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Always returns an error.
+*/
+func (account *StoreCreditAccount) Delete(db *gorm.DB, accountID uint) (map[string]Model, error) {
+	return map[string]Model{}, fmt.Errorf("%w: store credit accounts cannot be deleted", ErrInvalidStoreCredit)
+}
+
+/*
+*Description*
+
+func GetTransactions
+
+Retrieves the history of the specified StoreCreditAccount's balance (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	accountID  <uint>
+
+		The ID of the store credit account.
+
+*Returns*
+
+	_  <[]StoreCreditTransaction>
+
+		The account's StoreCreditTransaction records.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (account *StoreCreditAccount) GetTransactions(db *gorm.DB, accountID uint) ([]StoreCreditTransaction, error) {
+	transactions := []StoreCreditTransaction{}
+
+	err := db.Where("account_id = ?", accountID).Order("id").Find(&transactions).Error
+	return transactions, err
+}
+
+/*
+*Description*
+
+func lockStoreCreditAccount
+
+Finds the specified User's StoreCreditAccount with the specified Business in the specified currency, opening it with a balance of 0 if
+the User has never held credit with the Business in that currency. The account record is locked until the calling transaction completes,
+so changes to its balance are applied one at a time.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the account will be found or created.
+
+	userID  <uint>
+
+		The ID of the User that holds the credit.
+
+	businessID  <uint>
+
+		The ID of the Business that the credit can be spent with.
+
+	currency  <string>
+
+		The ISO 4217 currency of the credit.
+
+*Returns*
+
+	_  <*StoreCreditAccount>
+
+		The locked StoreCreditAccount.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func lockStoreCreditAccount(db *gorm.DB, userID uint, businessID uint, currency string) (*StoreCreditAccount, error) {
+	account := &StoreCreditAccount{UserID: userID, BusinessID: businessID, Currency: currency}
+
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(account).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND business_id = ? AND currency = ?", userID, businessID, currency).
+		First(account).Error
+
+	return account, err
+}
+
+/*
+*Description*
+
+func changeBalance
+
+Applies the specified StoreCreditTransaction to the calling StoreCreditAccount and records it in the account's history. The balance
+can't go below 0.
+
+The StoreCreditAccount record should be locked by the calling transaction (see 'lockStoreCreditAccount').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be updated.
+
+	transaction  <*StoreCreditTransaction>
+
+		The balance change. Its account, owner, balance after and currency are set from the account.
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidStoreCredit' if the account doesn't have enough credit, else any encountered error (nil if no errors are encountered)
+*/
+func (account *StoreCreditAccount) changeBalance(db *gorm.DB, transaction *StoreCreditTransaction) error {
+	if account.Balance+transaction.Amount < 0 {
+		return fmt.Errorf("%w: %d of store credit is available, but %d was requested", ErrInvalidStoreCredit, account.Balance, -transaction.Amount)
+	}
+
+	account.Balance += transaction.Amount
+	err := db.Model(account).Update("balance", account.Balance).Error
+	if err != nil {
+		return err
+	}
+
+	transaction.AccountID = account.ID
+	transaction.UserID = account.UserID
+	transaction.BusinessID = account.BusinessID
+	transaction.BalanceAfter = account.Balance
+	transaction.Currency = account.Currency
+
+	return db.Create(transaction).Error
+}
+
+/*
+*Description*
+
+func AddGoodwillCredit
+
+Gives the specified User store credit with the specified Business (e.g. to make up for a poor experience).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be updated.
+
+	userID  <uint>
+
+		The ID of the User receiving the credit.
+
+	businessID  <uint>
+
+		The ID of the Business giving the credit.
+
+	amount  <int>
+
+		The amount of credit (in cents).
+
+	currency  <string>
+
+		The ISO 4217 currency of the credit ("" for the Business's currency).
+
+	reason  <string>
+
+		The reason the credit was given.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated StoreCreditAccount object.
+
+	_  <*StoreCreditTransaction>
+
+		The recorded credit.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (account *StoreCreditAccount) AddGoodwillCredit(db *gorm.DB, userID uint, businessID uint, amount int, currency string, reason string) (map[string]Model, *StoreCreditTransaction, error) {
+	transaction := &StoreCreditTransaction{Type: StoreCreditGoodwill, Amount: amount, Reason: reason}
+	returnRecords := map[string]Model{"store_credit_account": account}
+
+	if amount <= 0 {
+		return returnRecords, transaction, fmt.Errorf("%w: amount must be greater than 0", ErrInvalidStoreCredit)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.First(&User{}, userID).Error
+		if err != nil {
+			return fmt.Errorf("User ID (%d) does not exist in the database.  [%w]", userID, err)
+		}
+
+		err = tx.First(&Business{}, businessID).Error
+		if err != nil {
+			return fmt.Errorf("Business ID (%d) does not exist in the database.  [%w]", businessID, err)
+		}
+
+		currency, err := resolveCurrency(tx, businessID, currency)
+		if err != nil {
+			return err
+		}
+
+		lockedAccount, err := lockStoreCreditAccount(tx, userID, businessID, currency)
+		if err != nil {
+			return err
+		}
+
+		err = lockedAccount.changeBalance(tx, transaction)
+		*account = *lockedAccount
+		return err
+	})
+
+	return returnRecords, transaction, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of StoreCreditTransaction records from the database that are associated with the specified secondary key (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute (e.g. "user_id" or "business_id").
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]StoreCreditTransaction>
+
+		The list of StoreCreditTransaction records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (transaction *StoreCreditTransaction) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]StoreCreditTransaction, error) {
+	transactions := []StoreCreditTransaction{}
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&transactions).Error
+	return transactions, err
+}
+
+/*
+*Description*
+
+func CreditOverpayment
+
+Moves the surplus paid on the specified Overpaid Invoice to the User's store credit with the invoice's Business, instead of refunding it.
+
+The surplus is refunded from the invoice's payments (newest first) as store credit refunds (see 'Refund.StoreCredit'), so the invoice
+ends up Paid and the credit is recorded as an Overpayment. Surplus paid with a gift card goes back to the gift card.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be updated.
+
+	invoiceID  <uint>
+
+		The ID of the Overpaid invoice.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated Invoice object.
+
+	_  <[]Refund>
+
+		The refunds that moved the surplus.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (invoice *Invoice) CreditOverpayment(db *gorm.DB, invoiceID uint) (map[string]Model, []Refund, error) {
+	returnRecords := map[string]Model{"invoice": invoice}
+	refunds := []Refund{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(invoice, invoiceID).Error
+		if err != nil {
+			return err
+		}
+
+		if invoice.Status != InvoiceStatusOverpaid {
+			return fmt.Errorf("%w: Invoice ID (%d) is %s, not overpaid", ErrInvalidStoreCredit, invoice.ID, invoice.Status)
+		}
+
+		var payments []Payment
+		err = tx.Where("invoice_id = ?", invoice.ID).Order("id DESC").Find(&payments).Error
+		if err != nil {
+			return err
+		}
+
+		surplus := -invoice.RemainingBalance
+		for _, payment := range payments {
+			if surplus <= 0 {
+				break
+			}
+
+			refundedAmount, err := payment.GetRefundedAmount(tx, payment.ID)
+			if err != nil {
+				return err
+			}
+
+			var amount int = payment.Amount - refundedAmount
+			if amount > surplus {
+				amount = surplus
+			}
+
+			if amount <= 0 {
+				continue
+			}
+
+			refund := Refund{PaymentID: payment.ID, Amount: amount, Reason: "Overpayment kept as store credit", StoreCredit: true, storeCreditType: StoreCreditOverpayment}
+			_, err = refund.Create(tx)
+			if err != nil {
+				return err
+			}
+
+			refunds = append(refunds, refund)
+			surplus -= amount
+		}
+
+		return tx.First(invoice, invoice.ID).Error
+	})
+
+	return returnRecords, refunds, err
+}
@@ -0,0 +1,1311 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"server/money"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all Subscription records in the database (one record per MembershipPlan that a User subscribes to)
+//
+// Subscriptions are billed in advance: an Invoice is generated at the start of each billing period. The current billing period
+// is the period that has been invoiced most recently (CurrentPeriodStart == CurrentPeriodEnd until the first period is invoiced).
+type Subscription struct {
+	gorm.Model
+	UserID             uint       `gorm:"column:user_id;not null;index" json:"user_id"`                       // ID of User that subscribes to the membership plan
+	MembershipPlanID   uint       `gorm:"column:membership_plan_id;not null;index" json:"membership_plan_id"` // ID of MembershipPlan that the User currently subscribes to
+	BusinessID         uint       `gorm:"column:business_id;not null" json:"business_id"`                     // ID of Business that sells the membership plan
+	Status             string     `gorm:"column:status;not null;default:Active" json:"status"`                // Status of the subscription (Active, Paused, Cancelled)
+	StartDate          time.Time  `gorm:"column:start_date" json:"start_date"`                                // Date/time when the membership starts
+	CurrentPeriodStart time.Time  `gorm:"column:current_period_start" json:"current_period_start"`            // Start of the most recently invoiced billing period
+	CurrentPeriodEnd   time.Time  `gorm:"column:current_period_end" json:"current_period_end"`                // End of the most recently invoiced billing period (when the next period is invoiced)
+	PausedAt           *time.Time `gorm:"column:paused_at;default:null" json:"paused_at"`                     // Date/time when the subscription was paused (if paused, else null)
+	CancelledAt        *time.Time `gorm:"column:cancelled_at;default:null" json:"cancelled_at"`               // Date/time when the subscription was cancelled (if cancelled, else null)
+	ProrationCredit    uint       `gorm:"column:proration_credit" json:"proration_credit"`                    // Credit (in cents) from plan downgrades that is deducted from the next invoice(s)
+}
+
+// GORM model for all SubscriptionInvoice records in the database (one record per Invoice generated for a Subscription)
+type SubscriptionInvoice struct {
+	gorm.Model
+	SubscriptionID   uint      `gorm:"column:subscription_id;not null;uniqueIndex:idx_subscription_invoices_period" json:"subscription_id"` // ID of Subscription that was billed
+	InvoiceID        uint      `gorm:"column:invoice_id" json:"invoice_id"`                                                                 // ID of the generated Invoice
+	MembershipPlanID uint      `gorm:"column:membership_plan_id" json:"membership_plan_id"`                                                 // ID of MembershipPlan that was billed
+	Type             string    `gorm:"column:type;not null;uniqueIndex:idx_subscription_invoices_period" json:"type"`                       // Type of charge (Period, Proration)
+	PeriodStart      time.Time `gorm:"column:period_start;uniqueIndex:idx_subscription_invoices_period" json:"period_start"`                // Start of the billed period (time of the plan change for prorations)
+	PeriodEnd        time.Time `gorm:"column:period_end" json:"period_end"`                                                                 // End of the billed period
+	Amount           int       `gorm:"column:amount" json:"amount"`                                                                         // Amount (in cents) invoiced after any proration credit is applied
+}
+
+// GORM model for all SubscriptionVisit records in the database (one record per Appointment that was covered by a Subscription)
+type SubscriptionVisit struct {
+	gorm.Model
+	SubscriptionID uint `gorm:"column:subscription_id;not null;index" json:"subscription_id"` // ID of Subscription that covered the appointment
+	AppointmentID  uint `gorm:"column:appointment_id;not null;index" json:"appointment_id"`   // ID of the covered Appointment
+}
+
+// Subscription statuses
+const (
+	SubscriptionStatusActive    string = "Active"    // Billed every period, with access to the plan's Services
+	SubscriptionStatusPaused    string = "Paused"    // Not billed, without access to the plan's Services
+	SubscriptionStatusCancelled string = "Cancelled" // Not billed, with access to the plan's Services until the end of the paid period
+)
+
+// Subscription invoice types
+const (
+	SubscriptionInvoicePeriod    string = "Period"    // Charge for a billing period
+	SubscriptionInvoiceProration string = "Proration" // Prorated charge for changing plans part-way through a billing period
+)
+
+// Permitted status transitions for Subscription records (current status --> list of statuses it may move to)
+var subscriptionStatusTransitions map[string][]string = map[string][]string{
+	SubscriptionStatusActive: {
+		SubscriptionStatusPaused,
+		SubscriptionStatusCancelled,
+	},
+	SubscriptionStatusPaused: {
+		SubscriptionStatusActive,
+		SubscriptionStatusCancelled,
+	},
+	SubscriptionStatusCancelled: {},
+}
+
+// Error returned when a Subscription status change or plan change is rejected
+var ErrInvalidSubscriptionStatusTransition = errors.New("invalid subscription status transition")
+
+/*
+*Description*
+
+type SubscriptionProration
+
+SubscriptionProration is the breakdown of the prorated charge for moving a Subscription to a different MembershipPlan part-way through
+a billing period.
+
+The User is credited for the unused part of the current period on the old plan and charged for the rest of the period on the new plan.
+If the plans have different billing intervals, a new billing period on the new plan starts at the time of the change and the new plan's
+full price is charged instead. All amounts are in cents and are rounded to the nearest cent.
+*/
+type SubscriptionProration struct {
+	FromPlanID    uint      `json:"from_plan_id"`    // ID of MembershipPlan the Subscription is moving from
+	ToPlanID      uint      `json:"to_plan_id"`      // ID of MembershipPlan the Subscription is moving to
+	ChangeTime    time.Time `json:"change_time"`     // Time of the plan change
+	PeriodStart   time.Time `json:"period_start"`    // Start of the billing period after the change
+	PeriodEnd     time.Time `json:"period_end"`      // End of the billing period after the change
+	UnusedCredit  int       `json:"unused_credit"`   // Credit for the unused part of the current period on the old plan
+	NewPlanCharge int       `json:"new_plan_charge"` // Charge for the rest of the period on the new plan
+	Amount        int       `json:"amount"`          // Net amount (charge minus credit); negative amounts are credited to the next invoice(s)
+}
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from Subscription object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the subscription object
+*/
+func (sub *Subscription) GetID() uint {
+	return sub.ID
+}
+
+/*
+*Description*
+
+func IsBilled
+
+Returns whether the calling Subscription has been invoiced for at least one billing period.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <bool>
+
+		'true' if at least one billing period has been invoiced, else 'false'.
+*/
+func (sub *Subscription) IsBilled() bool {
+	return sub.CurrentPeriodEnd.After(sub.CurrentPeriodStart)
+}
+
+/*
+*Description*
+
+func IsEntitled
+
+Returns whether the calling Subscription gives access to Services that start at the specified time.
+
+Active subscriptions give access from their start date onwards. Cancelled subscriptions give access until the end of the last paid
+billing period. Paused subscriptions do not give access.
+
+*Parameters*
+
+	serviceStart  <time.Time>
+
+		The start time of the Service.
+
+*Returns*
+
+	_  <bool>
+
+		'true' if the subscription gives access at the specified time, else 'false'.
+*/
+func (sub *Subscription) IsEntitled(serviceStart time.Time) bool {
+	if serviceStart.Before(sub.StartDate) {
+		return false
+	}
+
+	switch sub.Status {
+	case SubscriptionStatusActive:
+		return true
+	case SubscriptionStatusCancelled:
+		return serviceStart.Before(sub.CurrentPeriodEnd)
+	default:
+		return false
+	}
+}
+
+/*
+*Description*
+
+func GetBillingPeriod
+
+Returns the billing period of the calling Subscription that contains the specified time, on the specified MembershipPlan.
+
+Periods after the current billing period are projected from the end of the current period.
+
+*Parameters*
+
+	plan  <*MembershipPlan>
+
+		The subscription's MembershipPlan.
+
+	t  <time.Time>
+
+		The time to find the billing period for.
+
+*Returns*
+
+	_  <time.Time>
+
+		The start of the billing period.
+
+	_  <time.Time>
+
+		The end of the billing period.
+*/
+func (sub *Subscription) GetBillingPeriod(plan *MembershipPlan, t time.Time) (time.Time, time.Time) {
+	periodStart := sub.CurrentPeriodStart
+	periodEnd := sub.CurrentPeriodEnd
+	if !sub.IsBilled() {
+		periodEnd = plan.GetPeriodEnd(periodStart)
+	}
+
+	for !t.Before(periodEnd) {
+		periodStart = periodEnd
+		periodEnd = plan.GetPeriodEnd(periodStart)
+	}
+
+	return periodStart, periodEnd
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new Subscription record in the database and returns the created record along with any errors that are thrown.
+
+Subscriptions made through the API should be started with the 'Start' method, which also invoices the first billing period.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created Subscription object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (sub *Subscription) Create(db *gorm.DB) (map[string]Model, error) {
+	err := db.Create(&sub).Error
+	returnRecords := map[string]Model{"subscription": sub}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a Subscription record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	subID  <uint>
+
+		The ID of the subscription record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved Subscription object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) Get(db *gorm.DB, subID uint) (map[string]Model, error) {
+	err := db.First(&sub, subID).Error
+	returnRecords := map[string]Model{"subscription": sub}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetRecordsBySecondaryID
+
+Retrieves a list of Subscription records from the database that are associated with the specified secondary key.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	secondaryIDJsonKey  <string>
+
+		The JSON key for the secondary ID attribute.
+
+	secondaryID  <uint>
+
+		The secondary ID value.
+
+*Returns*
+
+	_  <[]Subscription>
+
+		The list of Subscription records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) GetRecordsBySecondaryID(db *gorm.DB, secondaryIDJsonKey string, secondaryID uint) ([]Subscription, error) {
+	var subs []Subscription
+
+	err := db.Where(map[string]interface{}{secondaryIDJsonKey: secondaryID}).Order("id").Find(&subs).Error
+	return subs, err
+}
+
+/*
+*Description*
+
+func Update
+
+Subscriptions can only change by being paused, resumed, cancelled, billed or moved to a different plan, so this method always returns an error.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		Unused.
+
+	subID  <uint>
+
+		Unused.
+
+	updates  <map[string]interface{}>
+
+		Unused.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		An empty map.
+
+	_  <error>
+
+		Error stating that subscriptions cannot be modified directly.
+*/
+func (sub *Subscription) Update(db *gorm.DB, subID uint, updates map[string]interface{}) (map[string]Model, error) {
+	return map[string]Model{}, errors.New("subscriptions cannot be modified directly")
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified Subscription record from the database if it exists.
+
+Deleted records are returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted from.
+
+	subID  <uint>
+
+		The ID of the subscription record being deleted.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the deleted Subscription object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) Delete(db *gorm.DB, subID uint) (map[string]Model, error) {
+	deleteSub := &Subscription{}
+	returnRecords := map[string]Model{"subscription": deleteSub}
+
+	err := db.First(deleteSub, subID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Delete(deleteSub).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Start
+
+Subscribes the specified User to the specified MembershipPlan, starting at the specified time.
+
+If the membership starts immediately, the first billing period is invoiced in the same transaction. Memberships that start in the
+future are invoiced by the billing job ('BillDueSubscriptions') once their start date is reached.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	planID  <uint>
+
+		The ID of the MembershipPlan being subscribed to.
+
+	userID  <uint>
+
+		The ID of the User subscribing to the plan.
+
+	startDate  <time.Time>
+
+		The time the membership starts.
+
+	now  <time.Time>
+
+		The current time.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the created Subscription object and the first Invoice (if one was generated).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) Start(db *gorm.DB, planID uint, userID uint, startDate time.Time, now time.Time) (map[string]Model, error) {
+	returnRecords := map[string]Model{"subscription": sub}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		plan := &MembershipPlan{}
+		err := tx.First(plan, planID).Error
+		if err != nil {
+			return fmt.Errorf("Membership Plan ID (%d) does not exist in the database.  [%w]", planID, err)
+		}
+
+		*sub = Subscription{
+			UserID:             userID,
+			MembershipPlanID:   plan.ID,
+			BusinessID:         plan.BusinessID,
+			Status:             SubscriptionStatusActive,
+			StartDate:          startDate,
+			CurrentPeriodStart: startDate,
+			CurrentPeriodEnd:   startDate,
+		}
+
+		_, err = sub.Create(tx)
+		if err != nil {
+			return err
+		}
+
+		invoices, err := sub.bill(tx, now)
+		if len(invoices) > 0 {
+			returnRecords["invoice"] = &invoices[0]
+		}
+
+		return err
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Pause
+
+Pauses the specified active Subscription. Paused subscriptions are not billed and do not give access to the plan's Services.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be updated.
+
+	subID  <uint>
+
+		The ID of the subscription being paused.
+
+	pauseTime  <time.Time>
+
+		The time of the pause (normally the current time).
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated Subscription object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) Pause(db *gorm.DB, subID uint, pauseTime time.Time) (map[string]Model, error) {
+	return sub.changeStatus(db, subID, SubscriptionStatusPaused, func(tx *gorm.DB, pausedSub *Subscription) error {
+		pausedSub.PausedAt = &pauseTime
+		return nil
+	})
+}
+
+/*
+*Description*
+
+func Resume
+
+Resumes the specified paused Subscription.
+
+The time the subscription spent paused during a paid billing period is added to the end of that period, so the User keeps the access
+they paid for. If the billing period has ended, the next period is invoiced in the same transaction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be updated.
+
+	subID  <uint>
+
+		The ID of the subscription being resumed.
+
+	resumeTime  <time.Time>
+
+		The time the subscription is resumed (normally the current time).
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the updated Subscription object and the generated Invoice (if one was generated).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) Resume(db *gorm.DB, subID uint, resumeTime time.Time) (map[string]Model, error) {
+	var invoices []Invoice
+
+	returnRecords, err := sub.changeStatus(db, subID, SubscriptionStatusActive, func(tx *gorm.DB, resumedSub *Subscription) error {
+		if resumedSub.PausedAt != nil && resumedSub.PausedAt.Before(resumedSub.CurrentPeriodEnd) {
+			// Memberships paused before their first billing period keep an unbilled (empty) current period
+			var billed bool = resumedSub.IsBilled()
+			resumedSub.CurrentPeriodEnd = resumedSub.CurrentPeriodEnd.Add(resumeTime.Sub(*resumedSub.PausedAt))
+			if !billed {
+				resumedSub.CurrentPeriodStart = resumedSub.CurrentPeriodEnd
+			}
+		}
+		resumedSub.PausedAt = nil
+
+		var err error
+		invoices, err = resumedSub.bill(tx, resumeTime)
+		return err
+	})
+
+	if len(invoices) > 0 {
+		returnRecords["invoice"] = &invoices[0]
+	}
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Cancel
+
+Cancels the specified Subscription. Cancelled subscriptions are no longer billed.
+
+An active subscription keeps access to the plan's Services until the end of the billing period that has already been paid for.
+A paused subscription loses access immediately.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be updated.
+
+	subID  <uint>
+
+		The ID of the subscription being cancelled.
+
+	cancelTime  <time.Time>
+
+		The time of the cancellation (normally the current time).
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated Subscription object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) Cancel(db *gorm.DB, subID uint, cancelTime time.Time) (map[string]Model, error) {
+	return sub.changeStatus(db, subID, SubscriptionStatusCancelled, func(tx *gorm.DB, cancelledSub *Subscription) error {
+		if cancelledSub.Status == SubscriptionStatusPaused && cancelledSub.PausedAt.Before(cancelledSub.CurrentPeriodEnd) {
+			cancelledSub.CurrentPeriodEnd = *cancelledSub.PausedAt
+		}
+		cancelledSub.CancelledAt = &cancelTime
+		return nil
+	})
+}
+
+/*
+*Description*
+
+func changeStatus
+
+Moves the specified Subscription to a new status, applying the specified changes to the record in the same transaction.
+
+The subscription record is locked until the transaction completes.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be updated.
+
+	subID  <uint>
+
+		The ID of the subscription being updated.
+
+	status  <string>
+
+		The new status.
+
+	apply  <func(*gorm.DB, *Subscription) error>
+
+		Applies the changes for the status change to the subscription (called before the status is changed).
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated Subscription object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) changeStatus(db *gorm.DB, subID uint, status string, apply func(*gorm.DB, *Subscription) error) (map[string]Model, error) {
+	updateSub := &Subscription{}
+	returnRecords := map[string]Model{"subscription": updateSub}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(updateSub, subID).Error
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(subscriptionStatusTransitions[updateSub.Status], status) {
+			return fmt.Errorf("%w: Subscription ID (%d) cannot move from '%s' to '%s'", ErrInvalidSubscriptionStatusTransition, subID, updateSub.Status, status)
+		}
+
+		err = apply(tx, updateSub)
+		if err != nil {
+			return err
+		}
+
+		updateSub.Status = status
+		return updateSub.save(tx)
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func save
+
+Saves the calling Subscription's billing and status attributes to the database.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be updated.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) save(db *gorm.DB) error {
+	return db.Model(sub).Updates(map[string]interface{}{
+		"membership_plan_id":   sub.MembershipPlanID,
+		"status":               sub.Status,
+		"current_period_start": sub.CurrentPeriodStart,
+		"current_period_end":   sub.CurrentPeriodEnd,
+		"paused_at":            sub.PausedAt,
+		"cancelled_at":         sub.CancelledAt,
+		"proration_credit":     sub.ProrationCredit,
+	}).Error
+}
+
+/*
+*Description*
+
+func BillDueSubscriptions
+
+Invoices every active Subscription whose billing period has ended as of the specified time. This is run periodically by the billing job.
+
+Each subscription is billed in its own transaction and is invoiced once for every billing period that has started, so a missed run is
+caught up on the next run. A subscription cannot be invoiced twice for the same period.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be created.
+
+	asOf  <time.Time>
+
+		The time to bill up to (normally the current time).
+
+*Returns*
+
+	_  <[]Invoice>
+
+		The generated Invoices.
+
+	_  <error>
+
+		The first error encountered (subscriptions that could not be billed are retried on the next run).
+*/
+func (sub *Subscription) BillDueSubscriptions(db *gorm.DB, asOf time.Time) ([]Invoice, error) {
+	var invoices []Invoice
+	var firstErr error
+
+	var dueSubIDs []uint
+	err := db.Model(&Subscription{}).
+		Where("status = ? AND current_period_end <= ?", SubscriptionStatusActive, asOf).
+		Order("id").
+		Pluck("id", &dueSubIDs).Error
+	if err != nil {
+		return invoices, err
+	}
+
+	for _, subID := range dueSubIDs {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			dueSub := &Subscription{}
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(dueSub, subID).Error
+			if err != nil {
+				return err
+			}
+
+			subInvoices, err := dueSub.bill(tx, asOf)
+			if err != nil {
+				return err
+			}
+
+			invoices = append(invoices, subInvoices...)
+			return nil
+		})
+
+		if err != nil {
+			log.Printf("ERROR:  Subscription ID (%d) could not be billed.  [%s]", subID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return invoices, firstErr
+}
+
+/*
+*Description*
+
+func bill
+
+Invoices the calling Subscription for every billing period that has started as of the specified time and saves the new billing period.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be created.
+
+	asOf  <time.Time>
+
+		The time to bill up to.
+
+*Returns*
+
+	_  <[]Invoice>
+
+		The generated Invoices.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) bill(db *gorm.DB, asOf time.Time) ([]Invoice, error) {
+	var invoices []Invoice
+
+	if sub.Status != SubscriptionStatusActive || sub.CurrentPeriodEnd.After(asOf) {
+		return invoices, nil
+	}
+
+	plan := &MembershipPlan{}
+	err := db.Unscoped().First(plan, sub.MembershipPlanID).Error
+	if err != nil {
+		return invoices, err
+	}
+
+	for !sub.CurrentPeriodEnd.After(asOf) {
+		periodStart := sub.CurrentPeriodEnd
+		periodEnd := plan.GetPeriodEnd(periodStart)
+
+		invoice, err := sub.invoice(db, plan, SubscriptionInvoicePeriod, periodStart, periodEnd, int(plan.Price))
+		if err != nil {
+			return invoices, err
+		}
+
+		invoices = append(invoices, *invoice)
+		sub.CurrentPeriodStart = periodStart
+		sub.CurrentPeriodEnd = periodEnd
+	}
+
+	return invoices, sub.save(db)
+}
+
+/*
+*Description*
+
+func invoice
+
+Generates an Invoice for the calling Subscription and records it against the Subscription.
+
+The Invoice lists the charge for the period as a line item. Any proration credit that the Subscription has is deducted from the charge
+as a discount, and the Business's sales tax is charged on the rest (see 'applySalesTax'). The Subscription record itself is not saved.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be created.
+
+	plan  <*MembershipPlan>
+
+		The MembershipPlan being billed.
+
+	invoiceType  <string>
+
+		The type of charge (see the 'SubscriptionInvoice*' constants).
+
+	periodStart  <time.Time>
+
+		The start of the billed period.
+
+	periodEnd  <time.Time>
+
+		The end of the billed period.
+
+	amount  <int>
+
+		The amount (in cents) to bill before proration credit is applied.
+
+*Returns*
+
+	_  <*Invoice>
+
+		The generated Invoice.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) invoice(db *gorm.DB, plan *MembershipPlan, invoiceType string, periodStart time.Time, periodEnd time.Time, amount int) (*Invoice, error) {
+	var appliedCredit int = int(sub.ProrationCredit)
+	if appliedCredit > amount {
+		appliedCredit = amount
+	}
+
+	sub.ProrationCredit -= uint(appliedCredit)
+
+	var description string = fmt.Sprintf("%s membership (%s to %s)", plan.Name, periodStart.Format("Jan 2, 2006"), periodEnd.Format("Jan 2, 2006"))
+	if invoiceType == SubscriptionInvoiceProration {
+		description = fmt.Sprintf("Change to %s membership (prorated from %s to %s)", plan.Name, periodStart.Format("Jan 2, 2006"), periodEnd.Format("Jan 2, 2006"))
+	}
+
+	invoice := &Invoice{UserID: sub.UserID, BusinessID: sub.BusinessID, Currency: plan.Currency}
+	lineItems := []InvoiceLineItem{{Description: description, Quantity: 1, UnitPrice: amount, Discount: appliedCredit, Currency: plan.Currency}}
+
+	err := applySalesTax(db, sub.BusinessID, false, lineItems)
+	if err != nil {
+		return invoice, err
+	}
+
+	err = invoice.createIssued(db, lineItems)
+	if err != nil {
+		return invoice, err
+	}
+
+	return invoice, db.Create(&SubscriptionInvoice{
+		SubscriptionID:   sub.ID,
+		InvoiceID:        invoice.ID,
+		MembershipPlanID: plan.ID,
+		Type:             invoiceType,
+		PeriodStart:      periodStart,
+		PeriodEnd:        periodEnd,
+		Amount:           invoice.OriginalBalance,
+	}).Error
+}
+
+/*
+*Description*
+
+func ChangePlan
+
+Moves the specified active Subscription to a different MembershipPlan offered by the same Business and calculates the proration.
+
+If the proration amount is positive, it is invoiced immediately. If it is negative (a downgrade), it is kept as proration credit and
+deducted from the Subscription's next invoice(s). Subscriptions that have not been billed yet simply switch plans. If 'preview' is
+true, the proration is calculated without changing anything.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the records will be updated.
+
+	subID  <uint>
+
+		The ID of the subscription changing plans.
+
+	planID  <uint>
+
+		The ID of the MembershipPlan the subscription is moving to.
+
+	changeTime  <time.Time>
+
+		The time of the plan change (normally the current time).
+
+	preview  <bool>
+
+		'true' to only calculate the proration.
+
+*Returns*
+
+	_  <SubscriptionProration>
+
+		The proration breakdown.
+
+	_  <map[string]Model>
+
+		A JSON style map object with key-value pairs that contain the Subscription object and the generated Invoice (if one was generated).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) ChangePlan(db *gorm.DB, subID uint, planID uint, changeTime time.Time, preview bool) (SubscriptionProration, map[string]Model, error) {
+	var proration SubscriptionProration
+	changeSub := &Subscription{}
+	returnRecords := map[string]Model{"subscription": changeSub}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(changeSub, subID).Error
+		if err != nil {
+			return err
+		}
+
+		if changeSub.Status != SubscriptionStatusActive {
+			return fmt.Errorf("%w: Subscription ID (%d) has status '%s' and cannot change plans", ErrInvalidSubscriptionStatusTransition, subID, changeSub.Status)
+		}
+
+		oldPlan := &MembershipPlan{}
+		err = tx.Unscoped().First(oldPlan, changeSub.MembershipPlanID).Error
+		if err != nil {
+			return err
+		}
+
+		newPlan := &MembershipPlan{}
+		err = tx.First(newPlan, planID).Error
+		if err != nil {
+			return fmt.Errorf("Membership Plan ID (%d) does not exist in the database.  [%w]", planID, err)
+		}
+
+		if newPlan.BusinessID != changeSub.BusinessID || newPlan.ID == oldPlan.ID {
+			return fmt.Errorf("%w: Subscription ID (%d) cannot move from Membership Plan ID (%d) to Membership Plan ID (%d)", ErrInvalidMembershipPlan, subID, oldPlan.ID, newPlan.ID)
+		}
+
+		// Proration credits the old plan's price against the new plan's price, so both must be in the same currency
+		if !strings.EqualFold(newPlan.Currency, oldPlan.Currency) {
+			return fmt.Errorf("%w: Membership Plan ID (%d) is priced in %s, but Subscription ID (%d) is billed in %s", money.ErrCurrencyMismatch, newPlan.ID, newPlan.Currency, subID, oldPlan.Currency)
+		}
+
+		proration = CalculateProration(changeSub, oldPlan, newPlan, changeTime)
+		if preview {
+			return nil
+		}
+
+		changeSub.MembershipPlanID = newPlan.ID
+		if changeSub.IsBilled() {
+			changeSub.CurrentPeriodStart = proration.PeriodStart
+			changeSub.CurrentPeriodEnd = proration.PeriodEnd
+		}
+
+		if proration.Amount > 0 {
+			invoice, err := changeSub.invoice(tx, newPlan, SubscriptionInvoiceProration, changeTime, proration.PeriodEnd, proration.Amount)
+			if err != nil {
+				return err
+			}
+			returnRecords["invoice"] = invoice
+		} else {
+			changeSub.ProrationCredit += uint(-proration.Amount)
+		}
+
+		return changeSub.save(tx)
+	})
+
+	return proration, returnRecords, err
+}
+
+/*
+*Description*
+
+func CalculateProration
+
+Calculates the proration for moving the specified Subscription from one MembershipPlan to another at the specified time (see 'SubscriptionProration').
+
+Subscriptions that have not been billed yet have nothing to prorate.
+
+*Parameters*
+
+	sub  <*Subscription>
+
+		The Subscription changing plans.
+
+	oldPlan  <*MembershipPlan>
+
+		The Subscription's current MembershipPlan.
+
+	newPlan  <*MembershipPlan>
+
+		The MembershipPlan the Subscription is moving to.
+
+	changeTime  <time.Time>
+
+		The time of the plan change.
+
+*Returns*
+
+	_  <SubscriptionProration>
+
+		The proration breakdown.
+*/
+func CalculateProration(sub *Subscription, oldPlan *MembershipPlan, newPlan *MembershipPlan, changeTime time.Time) SubscriptionProration {
+	proration := SubscriptionProration{
+		FromPlanID:  oldPlan.ID,
+		ToPlanID:    newPlan.ID,
+		ChangeTime:  changeTime,
+		PeriodStart: sub.CurrentPeriodStart,
+		PeriodEnd:   sub.CurrentPeriodEnd,
+	}
+
+	if !sub.IsBilled() {
+		return proration
+	}
+
+	periodLength := sub.CurrentPeriodEnd.Sub(sub.CurrentPeriodStart)
+	remaining := sub.CurrentPeriodEnd.Sub(changeTime)
+	if remaining < 0 {
+		remaining = 0
+	} else if remaining > periodLength {
+		remaining = periodLength
+	}
+
+	proration.UnusedCredit = prorateAmount(oldPlan.Price, remaining, periodLength)
+
+	if newPlan.BillingInterval == oldPlan.BillingInterval {
+		proration.NewPlanCharge = prorateAmount(newPlan.Price, remaining, periodLength)
+	} else {
+		// A different billing interval starts a new billing period on the new plan
+		proration.PeriodStart = changeTime
+		proration.PeriodEnd = newPlan.GetPeriodEnd(changeTime)
+		proration.NewPlanCharge = int(newPlan.Price)
+	}
+
+	proration.Amount = proration.NewPlanCharge - proration.UnusedCredit
+	return proration
+}
+
+/*
+*Description*
+
+func prorateAmount
+
+Returns the share of the specified price that corresponds to the specified part of a period, rounded to the nearest cent.
+
+*Parameters*
+
+	price  <uint>
+
+		The price (in cents) for the full period.
+
+	part  <time.Duration>
+
+		The part of the period being charged for.
+
+	period  <time.Duration>
+
+		The length of the full period.
+
+*Returns*
+
+	_  <int>
+
+		The prorated amount (in cents).
+*/
+func prorateAmount(price uint, part time.Duration, period time.Duration) int {
+	if period <= 0 {
+		return 0
+	}
+
+	partSeconds := int64(part / time.Second)
+	periodSeconds := int64(period / time.Second)
+	return int((int64(price)*partSeconds + periodSeconds/2) / periodSeconds)
+}
+
+/*
+*Description*
+
+func UseEntitlement
+
+Covers the booking user's own seat on the specified Appointment with one of the User's Subscriptions, if the User has one that is entitled
+to the Service.
+
+A Subscription is entitled if it was sold by the Service's Business, gives access at the time the Service starts (see 'IsEntitled'), its
+MembershipPlan includes the Service, and the plan's visit limit for the billing period that the Service falls in has not been reached.
+Guest seats are not covered by memberships.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) where the records will be created.
+
+	appt  <*Appointment>
+
+		The Appointment being booked.
+
+	service  <*Service>
+
+		The Service that the Appointment is for.
+
+*Returns*
+
+	_  <*Subscription>
+
+		The Subscription that covered the appointment (nil if the User has no entitled subscription).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) UseEntitlement(db *gorm.DB, appt *Appointment, service *Service) (*Subscription, error) {
+	var subs []Subscription
+
+	err := db.Where("user_id = ? AND business_id = ? AND status IN ?", appt.UserID, service.BusinessID, []string{SubscriptionStatusActive, SubscriptionStatusCancelled}).
+		Order("id").
+		Find(&subs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subs {
+		if !subs[i].IsEntitled(service.StartDateTime) {
+			continue
+		}
+
+		plan := &MembershipPlan{}
+		err = db.Unscoped().First(plan, subs[i].MembershipPlanID).Error
+		if err != nil {
+			return nil, err
+		}
+
+		included, err := plan.IncludesService(db, plan.ID, service)
+		if err != nil {
+			return nil, err
+		}
+
+		if !included {
+			continue
+		}
+
+		if plan.VisitsPerPeriod > 0 {
+			periodStart, periodEnd := subs[i].GetBillingPeriod(plan, service.StartDateTime)
+			visits, err := subs[i].CountVisits(db, periodStart, periodEnd)
+			if err != nil {
+				return nil, err
+			}
+
+			if visits >= int64(plan.VisitsPerPeriod) {
+				continue
+			}
+		}
+
+		err = db.Create(&SubscriptionVisit{SubscriptionID: subs[i].ID, AppointmentID: appt.ID}).Error
+		return &subs[i], err
+	}
+
+	return nil, nil
+}
+
+/*
+*Description*
+
+func CountVisits
+
+Returns the number of appointments covered by the calling Subscription for Services that start within the specified period.
+
+Cancelled appointments are not counted.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	periodStart  <time.Time>
+
+		The start of the period.
+
+	periodEnd  <time.Time>
+
+		The end of the period.
+
+*Returns*
+
+	_  <int64>
+
+		The number of covered appointments.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) CountVisits(db *gorm.DB, periodStart time.Time, periodEnd time.Time) (int64, error) {
+	var visits int64
+
+	err := db.Model(&SubscriptionVisit{}).
+		Joins("JOIN appointments ON appointments.id = subscription_visits.appointment_id AND appointments.deleted_at IS NULL").
+		Joins("JOIN services ON services.id = appointments.service_id").
+		Where("subscription_visits.subscription_id = ? AND appointments.status IN ?", sub.ID, seatHoldingAppointmentStatuses).
+		Where("services.start_date_time >= ? AND services.start_date_time < ?", periodStart, periodEnd).
+		Count(&visits).Error
+
+	return visits, err
+}
+
+/*
+*Description*
+
+func GetInvoices
+
+Returns the Invoices generated for the specified Subscription (oldest to newest).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	subID  <uint>
+
+		The ID of the subscription.
+
+*Returns*
+
+	_  <[]SubscriptionInvoice>
+
+		The subscription's invoice records.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (sub *Subscription) GetInvoices(db *gorm.DB, subID uint) ([]SubscriptionInvoice, error) {
+	subInvoices := []SubscriptionInvoice{}
+
+	err := db.Where("subscription_id = ?", subID).Order("period_start, id").Find(&subInvoices).Error
+	return subInvoices, err
+}
@@ -0,0 +1,585 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GORM model for all TaxRate records in the database (one record per sales tax jurisdiction)
+//
+// A Business is taxed at the rate of its explicit tax jurisdiction if it has one, or else at the rate for its zip code, or else at the
+// rate for its state (see 'Business.GetTaxRate'). The rate is the combined rate charged in the jurisdiction (e.g. state plus local tax).
+type TaxRate struct {
+	gorm.Model
+	Jurisdiction string `gorm:"column:jurisdiction;not null" json:"jurisdiction"` // Code of the jurisdiction (stored in upper case and unique, e.g. "TX" or "TX-AUSTIN")
+	Name         string `gorm:"column:name" json:"name"`                          // Name of the tax (e.g. "Austin sales tax")
+	State        string `gorm:"column:state" json:"state"`                        // State (2 letter abbreviation) that the rate applies to (empty if it only applies to businesses that choose the jurisdiction)
+	ZipCode      string `gorm:"column:zip" json:"zip"`                            // Zip code within the state that the rate applies to (empty for the whole state)
+	Rate         uint   `gorm:"column:rate;not null" json:"rate"`                 // Tax rate in basis points (hundredths of a percent, e.g. 825 for 8.25%)
+}
+
+// Error returned when a TaxRate definition is invalid
+var ErrInvalidTaxRate = errors.New("invalid tax rate")
+
+/*
+*Description*
+
+func GetID
+
+# Returns ID field from TaxRate object
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <uint>
+
+		The ID of the tax rate object
+*/
+func (rate *TaxRate) GetID() uint {
+	return rate.ID
+}
+
+/*
+*Description*
+
+func normalizeTaxLocation
+
+Returns the form that a jurisdiction code or state is stored and looked up in (trimmed and in upper case).
+
+*Parameters*
+
+	value  <string>
+
+		The jurisdiction code or state as entered.
+
+*Returns*
+
+	_  <string>
+
+		The normalized value.
+*/
+func normalizeTaxLocation(value string) string {
+	return strings.ToUpper(strings.TrimSpace(value))
+}
+
+/*
+*Description*
+
+func normalizeTaxLocationUpdate
+
+Normalizes the jurisdiction, state and zip code in a map of updates (if they are being updated, see 'normalizeTaxLocation').
+
+*Parameters*
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	N/A (None)
+*/
+func normalizeTaxLocationUpdate(updates map[string]interface{}) {
+	for _, attribute := range []string{"jurisdiction", "tax_jurisdiction", "state"} {
+		if value, attributeUpdated := updates[attribute]; attributeUpdated && value != nil {
+			updates[attribute] = normalizeTaxLocation(fmt.Sprint(value))
+		}
+	}
+
+	if zipCode, zipCodeUpdated := updates["zip"]; zipCodeUpdated && zipCode != nil {
+		updates["zip"] = strings.TrimSpace(fmt.Sprint(zipCode))
+	}
+}
+
+/*
+*Description*
+
+func validate
+
+Confirms that the calling TaxRate is a valid definition: it has a jurisdiction code, its rate is at most 100% (10000 basis points), and
+it only applies to a zip code within a state.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (rate *TaxRate) validate() error {
+	if rate.Jurisdiction == "" {
+		return fmt.Errorf("%w: a tax rate must have a jurisdiction", ErrInvalidTaxRate)
+	}
+
+	if int64(rate.Rate) > basisPointsPerUnit {
+		return fmt.Errorf("%w: rate (%d) can't be more than 100%% (%d basis points)", ErrInvalidTaxRate, rate.Rate, basisPointsPerUnit)
+	}
+
+	if rate.ZipCode != "" && rate.State == "" {
+		return fmt.Errorf("%w: a tax rate for a zip code must also have a state", ErrInvalidTaxRate)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func Create
+
+Creates a new TaxRate record in the database and returns the created record along with any errors that are thrown.
+
+The jurisdiction code must be unique, and there can only be one rate for each state and zip code.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be created.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the created TaxRate object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func (rate *TaxRate) Create(db *gorm.DB) (map[string]Model, error) {
+	returnRecords := map[string]Model{"tax_rate": rate}
+
+	rate.Jurisdiction = normalizeTaxLocation(rate.Jurisdiction)
+	rate.State = normalizeTaxLocation(rate.State)
+	rate.ZipCode = strings.TrimSpace(rate.ZipCode)
+	err := rate.validate()
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Create(&rate).Error
+	if isUniqueViolation(err) {
+		return returnRecords, fmt.Errorf("%w: there is already a tax rate for jurisdiction '%s' or for its state and zip code", ErrInvalidTaxRate, rate.Jurisdiction)
+	}
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Get
+
+Retrieves a TaxRate record in the database by ID if it exists and returns that record along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve the specified record.
+
+	rateID  <uint>
+
+		The ID of the tax rate record being requested.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the retrieved TaxRate object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (rate *TaxRate) Get(db *gorm.DB, rateID uint) (map[string]Model, error) {
+	err := db.First(&rate, rateID).Error
+	returnRecords := map[string]Model{"tax_rate": rate}
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func GetAll
+
+Retrieves all TaxRate records from the database, ordered by state, zip code and jurisdiction.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+*Returns*
+
+	_  <[]TaxRate>
+
+		The list of TaxRate records that are retrieved from the database.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (rate *TaxRate) GetAll(db *gorm.DB) ([]TaxRate, error) {
+	var rates []TaxRate
+
+	err := db.Order("state, zip, jurisdiction").Find(&rates).Error
+	return rates, err
+}
+
+/*
+*Description*
+
+func Update
+
+Updates the specified TaxRate record in the database with the specified changes if the record exists.
+
+Returns the updated record along with any errors that are thrown.
+
+A new rate is charged on invoices created after the update. Tax already charged on invoices is not changed. The jurisdiction code can't
+be changed while Businesses have chosen the jurisdiction explicitly.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be used to retrieve and update the specified record.
+
+	rateID  <uint>
+
+		The ID of the tax rate record being updated.
+
+	updates  <map[string]interface{}>
+
+		JSON with the fields that will be updated as keys and the updated values as values.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the updated TaxRate object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (rate *TaxRate) Update(db *gorm.DB, rateID uint, updates map[string]interface{}) (map[string]Model, error) {
+	updateRate := &TaxRate{}
+	returnRecords := map[string]Model{"tax_rate": updateRate}
+
+	normalizeTaxLocationUpdate(updates)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.First(updateRate, rateID).Error
+		if err != nil {
+			return err
+		}
+
+		// Businesses refer to the jurisdiction by its code, so it can't be renamed while they use it
+		if jurisdiction, jurisdictionUpdated := updates["jurisdiction"]; jurisdictionUpdated && jurisdiction != updateRate.Jurisdiction {
+			err = checkTaxJurisdictionUnused(tx, updateRate.Jurisdiction)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(updateRate).Clauses(clause.Returning{}).Where("id = ?", rateID).Updates(updates).Error
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: there is already a tax rate for jurisdiction '%s' or for its state and zip code", ErrInvalidTaxRate, updateRate.Jurisdiction)
+		} else if err != nil {
+			return err
+		}
+
+		// The combination of the existing and updated fields must still be valid, or the update is rolled back
+		return updateRate.validate()
+	})
+
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func Delete
+
+Deletes the specified TaxRate record from the database if it exists. Businesses that chose its jurisdiction explicitly must choose
+another jurisdiction first.
+
+Deleted record is returned along with any errors that are thrown.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance where the record will be deleted from.
+
+	rateID  <uint>
+
+		The ID of the tax rate record being deleted.
+
+*Returns*
+
+	_  <map[string]Model>
+
+		A JSON style map object with a key-value pair that contains the deleted TaxRate object.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (rate *TaxRate) Delete(db *gorm.DB, rateID uint) (map[string]Model, error) {
+	deleteRate := &TaxRate{}
+	returnRecords := map[string]Model{"tax_rate": deleteRate}
+
+	err := db.First(deleteRate, rateID).Error
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = checkTaxJurisdictionUnused(db, deleteRate.Jurisdiction)
+	if err != nil {
+		return returnRecords, err
+	}
+
+	err = db.Delete(deleteRate).Error
+	return returnRecords, err
+}
+
+/*
+*Description*
+
+func checkTaxJurisdictionUnused
+
+Confirms that no Business has chosen the specified jurisdiction explicitly (see 'Business.TaxJurisdiction'), so that its TaxRate can be
+deleted or its code changed.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	jurisdiction  <string>
+
+		The jurisdiction code.
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidTaxRate' if a Business has chosen the jurisdiction, any other encountered error, or nil.
+*/
+func checkTaxJurisdictionUnused(db *gorm.DB, jurisdiction string) error {
+	var businessCount int64
+	err := db.Model(&Business{}).Where("tax_jurisdiction = ?", jurisdiction).Count(&businessCount).Error
+	if err != nil {
+		return err
+	}
+
+	if businessCount > 0 {
+		return fmt.Errorf("%w: %d business(es) are taxed in jurisdiction '%s'", ErrInvalidTaxRate, businessCount, jurisdiction)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func GetByJurisdiction
+
+Retrieves the TaxRate record for the specified jurisdiction code (not case sensitive).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	jurisdiction  <string>
+
+		The jurisdiction code.
+
+*Returns*
+
+	_  <*TaxRate>
+
+		The tax rate (nil if there is no rate for the jurisdiction).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (rate *TaxRate) GetByJurisdiction(db *gorm.DB, jurisdiction string) (*TaxRate, error) {
+	var rates []TaxRate
+
+	err := db.Where("jurisdiction = ?", normalizeTaxLocation(jurisdiction)).Limit(1).Find(&rates).Error
+	if err != nil || len(rates) == 0 {
+		return nil, err
+	}
+
+	return &rates[0], nil
+}
+
+/*
+*Description*
+
+func GetTaxRate
+
+Returns the TaxRate that the calling Business charges on its Services.
+
+	Explicit tax jurisdiction  -->  The rate for that jurisdiction
+	Otherwise  -->  The rate for the business's state and zip code, or else the rate for its whole state
+
+Businesses without a tax jurisdiction or state, or whose state has no tax rate, don't charge tax.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+*Returns*
+
+	_  <*TaxRate>
+
+		The tax rate (nil if the business doesn't charge tax).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (business *Business) GetTaxRate(db *gorm.DB) (*TaxRate, error) {
+	rate := &TaxRate{}
+	if business.TaxJurisdiction != "" {
+		return rate.GetByJurisdiction(db, business.TaxJurisdiction)
+	}
+
+	if business.State == "" {
+		return nil, nil
+	}
+
+	// A rate for the business's zip code takes precedence over the rate for its whole state
+	var rates []TaxRate
+	err := db.Where("state = ? AND (zip = ? OR zip = '')", normalizeTaxLocation(business.State), strings.TrimSpace(business.ZipCode)).
+		Order("zip DESC").
+		Limit(1).
+		Find(&rates).Error
+	if err != nil || len(rates) == 0 {
+		return nil, err
+	}
+
+	return &rates[0], nil
+}
+
+/*
+*Description*
+
+func validateTaxJurisdiction
+
+Confirms that the specified explicit tax jurisdiction of a Business (if it is set) has a TaxRate.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that will be queried.
+
+	jurisdiction  <string>
+
+		The normalized jurisdiction code ("" if the Business is taxed by its state and zip code).
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidTaxRate' if the jurisdiction has no tax rate, any other encountered error, or nil.
+*/
+func validateTaxJurisdiction(db *gorm.DB, jurisdiction string) error {
+	if jurisdiction == "" {
+		return nil
+	}
+
+	rate := &TaxRate{}
+	jurisdictionRate, err := rate.GetByJurisdiction(db, jurisdiction)
+	if err != nil {
+		return err
+	}
+
+	if jurisdictionRate == nil {
+		return fmt.Errorf("%w: there is no tax rate for jurisdiction '%s'", ErrInvalidTaxRate, jurisdiction)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func applySalesTax
+
+Charges the sales tax of the specified Business on the specified line items (see 'Business.GetTaxRate'), and records the jurisdiction
+they were taxed in. Tax exempt lines are recorded in the jurisdiction, but aren't charged tax. Line items of Businesses that don't charge
+tax are left unchanged.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance (transaction) that will be queried.
+
+	businessID  <uint>
+
+		The ID of the Business that is selling the line items.
+
+	taxExempt  <bool>
+
+		True if the line items are exempt from sales tax (see 'Service.TaxExempt').
+
+	lineItems  <[]InvoiceLineItem>
+
+		The line items, which are updated in place before the invoice is created.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered).
+*/
+func applySalesTax(db *gorm.DB, businessID uint, taxExempt bool, lineItems []InvoiceLineItem) error {
+	if len(lineItems) == 0 {
+		return nil
+	}
+
+	var businesses []Business
+	err := db.Limit(1).Find(&businesses, businessID).Error
+	if err != nil || len(businesses) == 0 {
+		return err
+	}
+
+	rate, err := businesses[0].GetTaxRate(db)
+	if err != nil || rate == nil {
+		return err
+	}
+
+	for i := range lineItems {
+		lineItems[i].TaxJurisdiction = rate.Jurisdiction
+		if !taxExempt {
+			lineItems[i].TaxRate = rate.Rate
+		}
+	}
+
+	return nil
+}
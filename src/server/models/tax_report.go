@@ -0,0 +1,365 @@
+package models
+
+import (
+	"bytes"
+	"encoding/csv"
+	"server/money"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sales and sales tax of a Business (in the smallest unit of their currency, e.g. cents)
+type TaxAmounts struct {
+	TaxableSales int `gorm:"column:taxable_sales" json:"taxable_sales"` // Sales that were charged tax (after discounts, before tax)
+	ExemptSales  int `gorm:"column:exempt_sales" json:"exempt_sales"`   // Sales that weren't charged tax (tax exempt Services, or sales outside any tax jurisdiction)
+	Tax          int `gorm:"column:tax" json:"tax"`                     // Tax charged on the taxable sales
+}
+
+// Sales and tax of a Business in one jurisdiction and currency over a period (see 'Business.GetTaxReport')
+type TaxReportJurisdiction struct {
+	Jurisdiction    string     `json:"jurisdiction"`      // Code of the jurisdiction that the sales were taxed in (empty for sales outside any tax jurisdiction)
+	Name            string     `json:"name"`              // Name of the jurisdiction's tax (see 'TaxRate')
+	Currency        string     `json:"currency"`          // ISO 4217 currency of the amounts
+	InvoiceCount    int        `json:"invoice_count"`     // Number of invoices issued in the period
+	CreditNoteCount int        `json:"credit_note_count"` // Number of credit notes issued in the period
+	Invoiced        TaxAmounts `json:"invoiced"`          // Sales and tax on the invoices issued in the period
+	Credited        TaxAmounts `json:"credited"`          // Sales and tax taken back by the credit notes issued in the period
+	Net             TaxAmounts `json:"net"`               // Invoiced less credited (the taxable sales and tax collected that are reported to the jurisdiction)
+}
+
+// Total sales and tax of a Business in one currency over a period (see 'Business.GetTaxReport')
+type TaxReportTotal struct {
+	Currency        string     `json:"currency"`          // ISO 4217 currency of the amounts
+	InvoiceCount    int        `json:"invoice_count"`     // Number of invoices issued in the period
+	CreditNoteCount int        `json:"credit_note_count"` // Number of credit notes issued in the period
+	Invoiced        TaxAmounts `json:"invoiced"`          // Sales and tax on the invoices issued in the period
+	Credited        TaxAmounts `json:"credited"`          // Sales and tax taken back by the credit notes issued in the period
+	Net             TaxAmounts `json:"net"`               // Invoiced less credited
+}
+
+// Sales tax report of a Business for a period (see 'Business.GetTaxReport')
+type TaxReport struct {
+	BusinessID    uint                    `json:"business_id"`   // ID of the Business that charged the tax
+	From          time.Time               `json:"from"`          // Start of the period (inclusive)
+	To            time.Time               `json:"to"`            // End of the period (exclusive)
+	Jurisdictions []TaxReportJurisdiction `json:"jurisdictions"` // Sales and tax in each jurisdiction, ordered by jurisdiction and currency
+	Totals        []TaxReportTotal        `json:"totals"`        // Total sales and tax in each currency, ordered by currency
+}
+
+// Invoiced sales of one jurisdiction and currency, as aggregated by the tax report query
+type taxReportInvoicedRow struct {
+	Jurisdiction string `gorm:"column:jurisdiction"`
+	Currency     string `gorm:"column:currency"`
+	InvoiceCount int    `gorm:"column:invoice_count"`
+	TaxAmounts
+}
+
+// A credit note issued in the period, with the totals of the invoice it credits
+type taxReportCreditRow struct {
+	Jurisdiction    string `gorm:"column:jurisdiction"`
+	Currency        string `gorm:"column:currency"`
+	Amount          int    `gorm:"column:amount"`
+	Tax             int    `gorm:"column:tax"`
+	InvoiceSubtotal int    `gorm:"column:invoice_subtotal"`
+	TaxableSubtotal int    `gorm:"column:taxable_subtotal"`
+}
+
+/*
+*Description*
+
+func add
+
+Adds the calling TaxAmounts to the specified amounts.
+
+*Parameters*
+
+	amounts  <*TaxAmounts>
+
+		The amounts being added to.
+
+*Returns*
+
+	N/A (None)
+*/
+func (tax TaxAmounts) add(amounts *TaxAmounts) {
+	amounts.TaxableSales += tax.TaxableSales
+	amounts.ExemptSales += tax.ExemptSales
+	amounts.Tax += tax.Tax
+}
+
+/*
+*Description*
+
+func subtract
+
+Returns the calling TaxAmounts less the specified amounts.
+
+*Parameters*
+
+	amounts  <TaxAmounts>
+
+		The amounts being subtracted.
+
+*Returns*
+
+	_  <TaxAmounts>
+
+		The difference.
+*/
+func (tax TaxAmounts) subtract(amounts TaxAmounts) TaxAmounts {
+	return TaxAmounts{
+		TaxableSales: tax.TaxableSales - amounts.TaxableSales,
+		ExemptSales:  tax.ExemptSales - amounts.ExemptSales,
+		Tax:          tax.Tax - amounts.Tax,
+	}
+}
+
+/*
+*Description*
+
+func GetTaxReport
+
+Builds the sales tax report of the specified Business for a period: the taxable sales, tax exempt sales and tax collected in each
+jurisdiction that the Business's invoices were taxed in, along with the totals of each currency.
+
+Sales are reported when their invoice is issued, from the invoice's line items (invoices without line items are tax exempt sales).
+Credit notes issued in the period take sales and tax back in the jurisdiction of the invoice they credit. A credit note's tax is recorded
+on the credit note itself (see 'CreditNote.Tax'), and the rest of the credit is split between taxable and exempt sales in proportion to
+the invoice's subtotals (rounded half up to the nearest cent).
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	businessID  <uint>
+
+		The ID of the Business.
+
+	from  <time.Time>
+
+		The start of the period (inclusive).
+
+	to  <time.Time>
+
+		The end of the period (exclusive).
+
+*Returns*
+
+	_  <*TaxReport>
+
+		The report.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (business *Business) GetTaxReport(db *gorm.DB, businessID uint, from time.Time, to time.Time) (*TaxReport, error) {
+	report := &TaxReport{
+		BusinessID:    businessID,
+		From:          from,
+		To:            to,
+		Jurisdictions: []TaxReportJurisdiction{},
+		Totals:        []TaxReportTotal{},
+	}
+
+	parameters := map[string]interface{}{
+		"business_id": businessID,
+		"state":       InvoiceStateIssued,
+		"from":        from,
+		"to":          to,
+	}
+
+	var invoicedRows []taxReportInvoicedRow
+	err := db.Raw(`SELECT
+			COALESCE(invoice_line_items.tax_jurisdiction, '') AS jurisdiction,
+			invoices.currency,
+			COUNT(DISTINCT invoices.id) AS invoice_count,
+			COALESCE(SUM(invoice_line_items.subtotal) FILTER (WHERE invoice_line_items.tax_rate > 0), 0) AS taxable_sales,
+			COALESCE(SUM(COALESCE(invoice_line_items.subtotal, invoices.subtotal)) FILTER (WHERE COALESCE(invoice_line_items.tax_rate, 0) = 0), 0) AS exempt_sales,
+			COALESCE(SUM(invoice_line_items.tax), 0) AS tax
+		FROM invoices
+		LEFT JOIN invoice_line_items ON invoice_line_items.invoice_id = invoices.id AND invoice_line_items.deleted_at IS NULL
+		WHERE invoices.business_id = @business_id
+			AND invoices.state = @state
+			AND invoices.issued_at >= @from
+			AND invoices.issued_at < @to
+			AND invoices.deleted_at IS NULL
+		GROUP BY 1, invoices.currency`, parameters).Scan(&invoicedRows).Error
+	if err != nil {
+		return report, err
+	}
+
+	var creditRows []taxReportCreditRow
+	err = db.Raw(`SELECT
+			COALESCE(line_items.jurisdiction, '') AS jurisdiction,
+			credit_notes.currency,
+			credit_notes.amount,
+			credit_notes.tax,
+			invoices.subtotal AS invoice_subtotal,
+			COALESCE(line_items.taxable_subtotal, 0) AS taxable_subtotal
+		FROM credit_notes
+		JOIN invoices ON invoices.id = credit_notes.invoice_id
+		LEFT JOIN (
+			SELECT
+				invoice_id,
+				MAX(tax_jurisdiction) AS jurisdiction,
+				SUM(subtotal) FILTER (WHERE tax_rate > 0) AS taxable_subtotal
+			FROM invoice_line_items
+			WHERE deleted_at IS NULL
+			GROUP BY invoice_id
+		) AS line_items ON line_items.invoice_id = invoices.id
+		WHERE credit_notes.business_id = @business_id
+			AND credit_notes.issued_at >= @from
+			AND credit_notes.issued_at < @to
+			AND credit_notes.deleted_at IS NULL`, parameters).Scan(&creditRows).Error
+	if err != nil {
+		return report, err
+	}
+
+	jurisdictions := map[[2]string]*TaxReportJurisdiction{}
+	getJurisdiction := func(jurisdiction string, currency string) *TaxReportJurisdiction {
+		key := [2]string{jurisdiction, currency}
+		row, found := jurisdictions[key]
+		if !found {
+			row = &TaxReportJurisdiction{Jurisdiction: jurisdiction, Currency: currency}
+			jurisdictions[key] = row
+		}
+
+		return row
+	}
+
+	for _, invoiced := range invoicedRows {
+		row := getJurisdiction(invoiced.Jurisdiction, invoiced.Currency)
+		row.InvoiceCount += invoiced.InvoiceCount
+		invoiced.TaxAmounts.add(&row.Invoiced)
+	}
+
+	for _, credit := range creditRows {
+		row := getJurisdiction(credit.Jurisdiction, credit.Currency)
+		row.CreditNoteCount++
+
+		var credited TaxAmounts = TaxAmounts{Tax: credit.Tax}
+		var sales int = credit.Amount - credit.Tax
+		if credit.InvoiceSubtotal > 0 && credit.TaxableSubtotal > 0 && sales > 0 {
+			credited.TaxableSales = int(roundHalfUp(int64(sales)*int64(credit.TaxableSubtotal), int64(credit.InvoiceSubtotal)))
+			if credited.TaxableSales > sales {
+				credited.TaxableSales = sales
+			}
+		}
+		credited.ExemptSales = sales - credited.TaxableSales
+
+		credited.add(&row.Credited)
+	}
+
+	// The names of the jurisdictions are those of their current tax rates (including rates that have since been deleted)
+	var rates []TaxRate
+	err = db.Unscoped().Order("deleted_at DESC NULLS FIRST").Find(&rates).Error
+	if err != nil {
+		return report, err
+	}
+
+	names := map[string]string{}
+	for _, rate := range rates {
+		if _, found := names[rate.Jurisdiction]; !found {
+			names[rate.Jurisdiction] = rate.Name
+		}
+	}
+
+	totals := map[string]*TaxReportTotal{}
+	for _, row := range jurisdictions {
+		row.Name = names[row.Jurisdiction]
+		row.Net = row.Invoiced.subtract(row.Credited)
+		report.Jurisdictions = append(report.Jurisdictions, *row)
+
+		total, found := totals[row.Currency]
+		if !found {
+			total = &TaxReportTotal{Currency: row.Currency}
+			totals[row.Currency] = total
+		}
+
+		total.InvoiceCount += row.InvoiceCount
+		total.CreditNoteCount += row.CreditNoteCount
+		row.Invoiced.add(&total.Invoiced)
+		row.Credited.add(&total.Credited)
+		row.Net.add(&total.Net)
+	}
+
+	sort.Slice(report.Jurisdictions, func(i, j int) bool {
+		if report.Jurisdictions[i].Jurisdiction != report.Jurisdictions[j].Jurisdiction {
+			return report.Jurisdictions[i].Jurisdiction < report.Jurisdictions[j].Jurisdiction
+		}
+
+		return report.Jurisdictions[i].Currency < report.Jurisdictions[j].Currency
+	})
+
+	for _, total := range totals {
+		report.Totals = append(report.Totals, *total)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool { return report.Totals[i].Currency < report.Totals[j].Currency })
+
+	return report, nil
+}
+
+/*
+*Description*
+
+func CSV
+
+Writes the calling TaxReport as a CSV file that can be opened in a spreadsheet, with one row per jurisdiction followed by a total row for
+each currency. Amounts are written as plain decimal numbers in each currency's major unit (see 'money.Money.Decimal').
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <[]byte>
+
+		The contents of the CSV file.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (report *TaxReport) CSV() ([]byte, error) {
+	var file bytes.Buffer
+	writer := csv.NewWriter(&file)
+
+	amountColumns := func(currency string, amounts ...TaxAmounts) []string {
+		var columns []string
+		for _, tax := range amounts {
+			columns = append(columns,
+				money.Money{Amount: tax.TaxableSales, Currency: currency}.Decimal(),
+				money.Money{Amount: tax.ExemptSales, Currency: currency}.Decimal(),
+				money.Money{Amount: tax.Tax, Currency: currency}.Decimal())
+		}
+
+		return columns
+	}
+
+	rows := [][]string{{
+		"Jurisdiction", "Name", "Currency", "Invoices", "Credit Notes",
+		"Taxable Sales", "Exempt Sales", "Tax",
+		"Credited Taxable Sales", "Credited Exempt Sales", "Credited Tax",
+		"Net Taxable Sales", "Net Exempt Sales", "Net Tax",
+	}}
+
+	for _, row := range report.Jurisdictions {
+		columns := []string{row.Jurisdiction, row.Name, row.Currency, strconv.Itoa(row.InvoiceCount), strconv.Itoa(row.CreditNoteCount)}
+		rows = append(rows, append(columns, amountColumns(row.Currency, row.Invoiced, row.Credited, row.Net)...))
+	}
+
+	for _, total := range report.Totals {
+		columns := []string{"", "Total", total.Currency, strconv.Itoa(total.InvoiceCount), strconv.Itoa(total.CreditNoteCount)}
+		rows = append(rows, append(columns, amountColumns(total.Currency, total.Invoiced, total.Credited, total.Net)...))
+	}
+
+	err := writer.WriteAll(rows)
+	return file.Bytes(), err
+}
@@ -10,6 +10,15 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// Total that a User still owes in one currency (see 'User.GetOutstandingBalances')
+type OutstandingBalance struct {
+	Currency      string                     `json:"currency"`                   // ISO 4217 currency of the amounts
+	Amount        int                        `json:"amount"`                     // Total still owed on the User's issued invoices (in cents)
+	OverdueAmount int                        `json:"overdue_amount"`             // Part of the amount owed on Overdue invoices (in cents)
+	InvoiceCount  int                        `json:"invoice_count"`              // Number of invoices that are still owed
+	Display       *OutstandingBalanceDisplay `gorm:"-" json:"display,omitempty"` // Amounts formatted for the requester's locale (only set in API responses)
+}
+
 // GORM model for all User records in the database
 type User struct {
 	gorm.Model
@@ -21,6 +30,18 @@ type User struct {
 	BusinessID  *uint  `gorm:"column:business_id;default:null" json:"business_id"` // ID of the Business record associated with the User record
 }
 
+// Fields that lists of User records can be sorted and filtered by (see 'User.List')
+var userListFields map[string]listField = map[string]listField{
+	"id":           {column: "id", jsonKey: "ID", fieldType: listFieldInt, sortable: true},
+	"created_at":   {column: "created_at", jsonKey: "CreatedAt", fieldType: listFieldTime, sortable: true},
+	"updated_at":   {column: "updated_at", jsonKey: "UpdatedAt", fieldType: listFieldTime, sortable: true},
+	"email":        {column: "email", jsonKey: "email", fieldType: listFieldString, sortable: true},
+	"first_name":   {column: "first_name", jsonKey: "first_name", fieldType: listFieldString, sortable: true},
+	"last_name":    {column: "last_name", jsonKey: "last_name", fieldType: listFieldString, sortable: true},
+	"account_type": {column: "account_type", jsonKey: "account_type", fieldType: listFieldString},
+	"business_id":  {column: "business_id", jsonKey: "business_id", fieldType: listFieldInt},
+}
+
 /*
 *Description*
 
@@ -313,6 +334,38 @@ func (user *User) GetAll(db *gorm.DB) ([]User, error) {
 /*
 *Description*
 
+func List
+
+Retrieves a page of User records from the database, sorted and filtered as requested (see 'userListFields').
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	options  <ListOptions>
+
+		The limit, cursor, sort and filters of the page.
+
+*Returns*
+
+	_  <ListPage>
+
+		The page of User records ([]User), with the total number of records that match the filters and the cursor of the next page.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (user *User) List(db *gorm.DB, options ListOptions) (ListPage, error) {
+	var users []User
+	return listRecords(db, &users, userListFields, options)
+}
+
+/*
+*Description*
+
 func GetRecordsByPrimaryIDs
 
 Retrieves a list of User records from the database using their IDs (primary key).
@@ -382,6 +435,50 @@ func (user *User) GetAppointments(db *gorm.DB, userID uint) ([]Appointment, erro
 /*
 *Description*
 
+func GetOutstandingBalances
+
+Retrieves the total that the specified User still owes on their issued invoices, with one balance for each currency they are billed in.
+Drafts aren't owed yet and are left out.
+
+*Parameters*
+
+	db  <*gorm.DB>
+
+		A pointer to the database instance that the records will be retrieved from.
+
+	userID  <uint>
+
+		The ID of the User.
+
+*Returns*
+
+	_  <[]OutstandingBalance>
+
+		The User's outstanding balances, ordered by currency (empty if nothing is owed).
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (user *User) GetOutstandingBalances(db *gorm.DB, userID uint) ([]OutstandingBalance, error) {
+	balances := []OutstandingBalance{}
+
+	err := db.Model(&Invoice{}).
+		Select(`currency,
+			SUM(remaining_balance) AS amount,
+			COALESCE(SUM(remaining_balance) FILTER (WHERE status = ?), 0) AS overdue_amount,
+			COUNT(*) AS invoice_count`, InvoiceStatusOverdue).
+		Where("user_id = ? AND state = ? AND status IN ? AND remaining_balance > 0", userID, InvoiceStateIssued, owedInvoiceStatuses).
+		Group("currency").
+		Order("currency").
+		Scan(&balances).Error
+
+	return balances, err
+}
+
+/*
+*Description*
+
 func GetServiceAppointments
 
 Retrieves the list of all Appointments (and the Service each Appointment is for) that are associated with the specified User.
@@ -413,7 +510,8 @@ Retrieves the list of all Appointments (and the Service each Appointment is for)
 						"service_id":22,
 						"user_id":33,
 						"cancel_date_time":null,
-						"active":true
+						"status":"Confirmed",
+						"seats":1
 					},
 					"service": {
 						"ID": 22,
@@ -441,7 +539,8 @@ Retrieves the list of all Appointments (and the Service each Appointment is for)
 						"service_id":55,
 						"user_id":66,
 						"cancel_date_time":null,
-						"active":true
+						"status":"Confirmed",
+						"seats":1
 					},
 					"service": {
 						"ID": 55,
@@ -481,7 +580,7 @@ func (user *User) GetServiceAppointments(db *gorm.DB, userID uint, activeOnly bo
 
 	// Get list of ServiceIDs from user's appointments
 	for _, appt := range appts {
-		if !activeOnly || (activeOnly && appt.Active) {
+		if !activeOnly || (activeOnly && appt.IsActive()) {
 			// Get Service associated with each of the user's appointments
 			apptService := Service{}
 			apptServiceID = appt.GetServiceID()
@@ -505,7 +604,7 @@ func (user *User) GetServiceAppointments(db *gorm.DB, userID uint, activeOnly bo
 
 func HasServiceAppointment
 
-Returns whether the specified User has an Appointment scheduled for the specified Service.
+Returns whether the specified User has an Appointment for the specified Service that has not been cancelled.
 
 *Parameters*
 
@@ -532,25 +631,13 @@ Returns whether the specified User has an Appointment scheduled for the specifie
 		Encountered error (nil if no errors are encountered)
 */
 func (user *User) HasServiceAppointment(db *gorm.DB, userID uint, serviceID uint) (bool, error) {
-	var appts []Appointment
-	var apptServiceID uint
+	var apptCt int64
 
-	// Get list of appointments for specified UserID
-	appts, apptErr := user.GetAppointments(db, userID)
-	if apptErr != nil {
-		var errorMessage string = fmt.Sprintf("User ID (%d) does not have any appointment records in the database.  [%s]", userID, apptErr)
-		return false, errors.New(errorMessage)
-	}
-
-	for _, appt := range appts {
-		// Get Service associated with each of the user's appointments
-		apptServiceID = appt.GetServiceID()
-		if serviceID == apptServiceID {
-			return true, nil
-		}
-	}
+	err := db.Model(&Appointment{}).
+		Where("user_id = ? AND service_id = ? AND status IN ?", userID, serviceID, seatHoldingAppointmentStatuses).
+		Count(&apptCt).Error
 
-	return false, nil
+	return apptCt > 0, err
 }
 
 /*
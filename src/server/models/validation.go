@@ -111,6 +111,32 @@ func StandardizeUserAccountType(userAcctType string) string {
 	return titleCaser.String(strings.ToLower(TrimWhitespace(userAcctType)))
 }
 
+/*  APPOINTMENT FIELDS  */
+
+/*
+*Description*
+
+func StandardizeAppointmentStatus
+
+Standardizes the string formatting for an Appointment's status by converting it to title case, replacing underscores/hyphens with spaces, and removing trailing/leading whitespace.
+
+*Parameters*
+
+	    apptStatus <string>
+
+			The appointment status string to be standardized.
+
+*Returns*
+
+	    _ <string>
+
+			The updated string value that has been standardized.
+*/
+func StandardizeAppointmentStatus(apptStatus string) string {
+	var spacedStatus string = strings.NewReplacer("_", " ", "-", " ").Replace(apptStatus)
+	return titleCaser.String(strings.ToLower(TrimWhitespace(spacedStatus)))
+}
+
 /*  --  OBJECT-SPECIFIC VALIDATION FUNCTIONS  --  */
 
 /*
@@ -142,3 +168,91 @@ func UserAccountTypeIsValid(userAcctType string) bool {
 	stdUserAcctType := StandardizeUserAccountType(userAcctType)
 	return slices.Contains(validAccountTypes, stdUserAcctType)
 }
+
+/*
+*Description*
+
+func AppointmentStatusIsValid
+
+Checks if an Appointment's status is a valid value.
+
+Valid statuses:
+  - Pending
+  - Confirmed
+  - Cancelled By Customer
+  - Cancelled By Business
+  - Completed
+  - No Show
+
+*Parameters*
+
+	    apptStatus <string>
+
+			The appointment status to be validated.
+
+*Returns*
+
+	    _ <bool>
+
+			'true' if the status is a valid value, else 'false'.
+*/
+func AppointmentStatusIsValid(apptStatus string) bool {
+	_, isValid := appointmentStatusTransitions[apptStatus]
+	return isValid
+}
+
+/*
+*Description*
+
+func AppointmentStatusTransitionIsValid
+
+Checks if an Appointment is permitted to move from its current status to the specified new status.
+
+Permitted transitions:
+  - Pending  -->  Confirmed, Cancelled By Customer, Cancelled By Business
+  - Confirmed  -->  Completed, No Show, Cancelled By Customer, Cancelled By Business
+
+All other statuses are final and cannot be changed.
+
+*Parameters*
+
+	    currentStatus <string>
+
+			The appointment's current status.
+
+	    newStatus <string>
+
+			The status the appointment is moving to.
+
+*Returns*
+
+	    _ <bool>
+
+			'true' if the transition is permitted, else 'false'.
+*/
+func AppointmentStatusTransitionIsValid(currentStatus string, newStatus string) bool {
+	return slices.Contains(appointmentStatusTransitions[currentStatus], newStatus)
+}
+
+/*
+*Description*
+
+func AppointmentStatusIsCancelled
+
+Checks if an Appointment's status is one of the cancelled statuses ('Cancelled By Customer' or 'Cancelled By Business').
+
+*Parameters*
+
+	    apptStatus <string>
+
+			The appointment status to be checked.
+
+*Returns*
+
+	    _ <bool>
+
+			'true' if the status is a cancelled status, else 'false'.
+*/
+func AppointmentStatusIsCancelled(apptStatus string) bool {
+	return apptStatus == AppointmentStatusCancelledByCustomer || apptStatus == AppointmentStatusCancelledByBusiness
+}
@@ -0,0 +1,243 @@
+package money
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// How amounts are written in a locale
+type localeFormat struct {
+	Decimal     string // Decimal separator
+	Group       string // Thousands separator
+	SymbolAfter bool   // True if the currency symbol follows the amount (e.g. "12,50 €")
+	SymbolSpace string // Space between the amount and the currency symbol (if any)
+}
+
+// Locale used when a request doesn't ask for a supported one
+const DefaultLocale string = "en-US"
+
+// Non-breaking space (keeps amounts from being split across lines)
+const nbsp string = "\u00a0"
+
+// Supported locales by BCP 47 language tag
+var locales = map[string]localeFormat{
+	"da-DK": {Decimal: ",", Group: ".", SymbolAfter: true, SymbolSpace: nbsp},
+	"de-CH": {Decimal: ".", Group: "'", SymbolSpace: nbsp},
+	"de-DE": {Decimal: ",", Group: ".", SymbolAfter: true, SymbolSpace: nbsp},
+	"en-AU": {Decimal: ".", Group: ","},
+	"en-CA": {Decimal: ".", Group: ","},
+	"en-GB": {Decimal: ".", Group: ","},
+	"en-IE": {Decimal: ".", Group: ","},
+	"en-NZ": {Decimal: ".", Group: ","},
+	"en-US": {Decimal: ".", Group: ","},
+	"es-ES": {Decimal: ",", Group: ".", SymbolAfter: true, SymbolSpace: nbsp},
+	"es-MX": {Decimal: ".", Group: ","},
+	"fi-FI": {Decimal: ",", Group: nbsp, SymbolAfter: true, SymbolSpace: nbsp},
+	"fr-BE": {Decimal: ",", Group: nbsp, SymbolAfter: true, SymbolSpace: nbsp},
+	"fr-CA": {Decimal: ",", Group: nbsp, SymbolAfter: true, SymbolSpace: nbsp},
+	"fr-CH": {Decimal: ".", Group: nbsp, SymbolAfter: true, SymbolSpace: nbsp},
+	"fr-FR": {Decimal: ",", Group: nbsp, SymbolAfter: true, SymbolSpace: nbsp},
+	"it-IT": {Decimal: ",", Group: ".", SymbolAfter: true, SymbolSpace: nbsp},
+	"ja-JP": {Decimal: ".", Group: ","},
+	"nb-NO": {Decimal: ",", Group: nbsp, SymbolAfter: true, SymbolSpace: nbsp},
+	"nl-BE": {Decimal: ",", Group: ".", SymbolSpace: nbsp},
+	"nl-NL": {Decimal: ",", Group: ".", SymbolSpace: nbsp},
+	"pt-PT": {Decimal: ",", Group: nbsp, SymbolAfter: true, SymbolSpace: nbsp},
+	"sv-SE": {Decimal: ",", Group: nbsp, SymbolAfter: true, SymbolSpace: nbsp},
+}
+
+// Locales used for requests that only name a language (e.g. "fr")
+var languageDefaults = map[string]string{
+	"da": "da-DK",
+	"de": "de-DE",
+	"en": "en-US",
+	"es": "es-ES",
+	"fi": "fi-FI",
+	"fr": "fr-FR",
+	"it": "it-IT",
+	"ja": "ja-JP",
+	"nb": "nb-NO",
+	"nl": "nl-NL",
+	"no": "nb-NO",
+	"pt": "pt-PT",
+	"sv": "sv-SE",
+}
+
+/*
+*Description*
+
+func ParseLocale
+
+Picks the supported locale that best matches an HTTP 'Accept-Language' header (e.g. "fr-CA,fr;q=0.9,en;q=0.8" as "fr-CA").
+
+Languages are tried in order of preference. A language with an unsupported region (e.g. "fr-LU") falls back to the language's
+default locale (e.g. "fr-FR"). 'DefaultLocale' is returned if no language is supported.
+
+*Parameters*
+
+	acceptLanguage  <string>
+
+		The header value (a single language tag also works).
+
+*Returns*
+
+	_  <string>
+
+		The supported locale.
+*/
+func ParseLocale(acceptLanguage string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	preferences := []preference{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err == nil {
+				quality = parsed
+			}
+		}
+
+		if tag != "" && tag != "*" && quality > 0 {
+			preferences = append(preferences, preference{tag: tag, quality: quality})
+		}
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].quality > preferences[j].quality })
+
+	for _, preferred := range preferences {
+		language, region, _ := strings.Cut(strings.ReplaceAll(preferred.tag, "_", "-"), "-")
+		language = strings.ToLower(language)
+		region = strings.ToUpper(region)
+
+		if _, ok := locales[language+"-"+region]; ok {
+			return language + "-" + region
+		}
+
+		if locale, ok := languageDefaults[language]; ok {
+			return locale
+		}
+	}
+
+	return DefaultLocale
+}
+
+/*
+*Description*
+
+func Format
+
+Formats an amount of money the way it is written in a locale (e.g. 123456 USD as "$1,234.56" in "en-US", 123456 CAD as
+"1 234,56 $" in "fr-CA" and 123456 EUR as "1.234,56 €" in "de-DE").
+
+The currency's local symbol is used in its home regions. Elsewhere, currencies that share a symbol are told apart (e.g. USD is
+written as "US$" in "en-CA").
+
+*Parameters*
+
+	locale  <string>
+
+		The locale (see 'ParseLocale'). Unsupported locales are formatted as 'DefaultLocale'.
+
+*Returns*
+
+	_  <string>
+
+		The formatted amount.
+*/
+func (money Money) Format(locale string) string {
+	if _, ok := locales[locale]; !ok {
+		locale = ParseLocale(locale)
+	}
+	format := locales[locale]
+	_, region, _ := strings.Cut(locale, "-")
+
+	symbol := strings.ToUpper(money.Currency)
+	symbolSpace := format.SymbolSpace
+	if details, ok := currencies[symbol]; ok {
+		symbol = details.IntlSymbol
+		if slices.Contains(details.HomeRegions, region) {
+			symbol = details.Symbol
+		}
+	} else if symbolSpace == "" {
+		symbolSpace = nbsp
+	}
+
+	amount := money.Amount
+	var sign string
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	minorUnits := MinorUnits(money.Currency)
+	digits := strconv.Itoa(amount)
+	for len(digits) <= minorUnits {
+		digits = "0" + digits
+	}
+
+	whole, fraction := digits[:len(digits)-minorUnits], digits[len(digits)-minorUnits:]
+	var groups []string
+	for len(whole) > 3 {
+		groups = append([]string{whole[len(whole)-3:]}, groups...)
+		whole = whole[:len(whole)-3]
+	}
+	groups = append([]string{whole}, groups...)
+
+	number := strings.Join(groups, format.Group)
+	if minorUnits > 0 {
+		number += format.Decimal + fraction
+	}
+
+	if format.SymbolAfter {
+		return sign + number + symbolSpace + symbol
+	}
+
+	return sign + symbol + symbolSpace + number
+}
+
+/*
+*Description*
+
+func Decimal
+
+Writes an amount of money as a plain decimal number in the currency's major unit, without a symbol or thousands separators (e.g. 123456
+USD as "1234.56" and 1234 JPY as "1234"). Used in exported files that are read by spreadsheets and accounting software.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The amount as a decimal number.
+*/
+func (money Money) Decimal() string {
+	amount := money.Amount
+	var sign string
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	minorUnits := MinorUnits(money.Currency)
+	digits := strconv.Itoa(amount)
+	for len(digits) <= minorUnits {
+		digits = "0" + digits
+	}
+
+	if minorUnits == 0 {
+		return sign + digits
+	}
+
+	return sign + digits[:len(digits)-minorUnits] + "." + digits[len(digits)-minorUnits:]
+}
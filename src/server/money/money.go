@@ -0,0 +1,233 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// An amount of money in the smallest unit of its currency (e.g. cents for USD, EUR and CAD, yen for JPY)
+//
+// Amounts in different currencies can't be added, subtracted or compared with each other (see 'ErrCurrencyMismatch').
+type Money struct {
+	Amount   int    `json:"amount"`   // Amount in the currency's smallest unit
+	Currency string `json:"currency"` // ISO 4217 currency code (e.g. "USD")
+}
+
+// Details of a supported ISO 4217 currency
+type currency struct {
+	MinorUnits  int      // Number of digits after the decimal separator (e.g. 2 for cents)
+	Symbol      string   // Symbol used in the currency's home regions (e.g. "$")
+	IntlSymbol  string   // Symbol used everywhere else, where the local symbol would be ambiguous (e.g. "US$")
+	HomeRegions []string // ISO 3166 regions where the currency is the local currency
+}
+
+// Currency used when a business has not chosen one
+const DefaultCurrency string = "USD"
+
+// Supported currencies by ISO 4217 code
+var currencies = map[string]currency{
+	"AUD": {MinorUnits: 2, Symbol: "$", IntlSymbol: "A$", HomeRegions: []string{"AU"}},
+	"CAD": {MinorUnits: 2, Symbol: "$", IntlSymbol: "CA$", HomeRegions: []string{"CA"}},
+	"CHF": {MinorUnits: 2, Symbol: "CHF", IntlSymbol: "CHF", HomeRegions: []string{"CH"}},
+	"DKK": {MinorUnits: 2, Symbol: "kr.", IntlSymbol: "DKK", HomeRegions: []string{"DK"}},
+	"EUR": {MinorUnits: 2, Symbol: "€", IntlSymbol: "€", HomeRegions: []string{"AT", "BE", "DE", "ES", "FI", "FR", "IE", "IT", "NL", "PT"}},
+	"GBP": {MinorUnits: 2, Symbol: "£", IntlSymbol: "£", HomeRegions: []string{"GB"}},
+	"JPY": {MinorUnits: 0, Symbol: "¥", IntlSymbol: "¥", HomeRegions: []string{"JP"}},
+	"MXN": {MinorUnits: 2, Symbol: "$", IntlSymbol: "MX$", HomeRegions: []string{"MX"}},
+	"NOK": {MinorUnits: 2, Symbol: "kr", IntlSymbol: "NOK", HomeRegions: []string{"NO"}},
+	"NZD": {MinorUnits: 2, Symbol: "$", IntlSymbol: "NZ$", HomeRegions: []string{"NZ"}},
+	"SEK": {MinorUnits: 2, Symbol: "kr", IntlSymbol: "SEK", HomeRegions: []string{"SE"}},
+	"USD": {MinorUnits: 2, Symbol: "$", IntlSymbol: "US$", HomeRegions: []string{"US"}},
+}
+
+// Errors returned by money operations
+var (
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+)
+
+/*
+*Description*
+
+func NormalizeCurrency
+
+Validates an ISO 4217 currency code and returns it in upper case (e.g. "cad" as "CAD").
+
+*Parameters*
+
+	code  <string>
+
+		The currency code.
+
+*Returns*
+
+	_  <string>
+
+		The upper case currency code.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func NormalizeCurrency(code string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := currencies[normalized]; !ok {
+		return "", fmt.Errorf("%w: '%s' is not a supported ISO 4217 currency code", ErrInvalidCurrency, code)
+	}
+
+	return normalized, nil
+}
+
+/*
+*Description*
+
+func New
+
+Creates an amount of money in the specified currency.
+
+*Parameters*
+
+	amount  <int>
+
+		The amount in the currency's smallest unit (e.g. cents).
+
+	currencyCode  <string>
+
+		The ISO 4217 currency code.
+
+*Returns*
+
+	_  <Money>
+
+		The amount of money.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func New(amount int, currencyCode string) (Money, error) {
+	normalized, err := NormalizeCurrency(currencyCode)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: amount, Currency: normalized}, nil
+}
+
+/*
+*Description*
+
+func SameCurrency
+
+Confirms that an amount is in the specified currency.
+
+*Parameters*
+
+	currencyCode  <string>
+
+		The ISO 4217 currency code that the amount should be in.
+
+*Returns*
+
+	_  <error>
+
+		'ErrCurrencyMismatch' if the amount is in another currency (nil otherwise)
+*/
+func (money Money) SameCurrency(currencyCode string) error {
+	if !strings.EqualFold(money.Currency, currencyCode) {
+		return fmt.Errorf("%w: %s can't be combined with %s", ErrCurrencyMismatch, money.Currency, strings.ToUpper(currencyCode))
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func Add
+
+Adds two amounts of money in the same currency.
+
+*Parameters*
+
+	other  <Money>
+
+		The amount being added.
+
+*Returns*
+
+	_  <Money>
+
+		The sum.
+
+	_  <error>
+
+		'ErrCurrencyMismatch' if the amounts are in different currencies (nil otherwise)
+*/
+func (money Money) Add(other Money) (Money, error) {
+	if err := other.SameCurrency(money.Currency); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: money.Amount + other.Amount, Currency: money.Currency}, nil
+}
+
+/*
+*Description*
+
+func Sub
+
+Subtracts an amount of money in the same currency.
+
+*Parameters*
+
+	other  <Money>
+
+		The amount being subtracted.
+
+*Returns*
+
+	_  <Money>
+
+		The difference.
+
+	_  <error>
+
+		'ErrCurrencyMismatch' if the amounts are in different currencies (nil otherwise)
+*/
+func (money Money) Sub(other Money) (Money, error) {
+	if err := other.SameCurrency(money.Currency); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: money.Amount - other.Amount, Currency: money.Currency}, nil
+}
+
+/*
+*Description*
+
+func MinorUnits
+
+Returns the number of digits after the decimal separator for a currency (e.g. 2 for USD and 0 for JPY). Unsupported currencies
+are treated as having 2.
+
+*Parameters*
+
+	currencyCode  <string>
+
+		The ISO 4217 currency code.
+
+*Returns*
+
+	_  <int>
+
+		The number of minor unit digits.
+*/
+func MinorUnits(currencyCode string) int {
+	if details, ok := currencies[strings.ToUpper(currencyCode)]; ok {
+		return details.MinorUnits
+	}
+
+	return 2
+}
@@ -0,0 +1,139 @@
+package notifications
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Interface for services that deliver messages to users (e.g. email)
+//
+// Notifiers only deliver messages. Deciding who is notified and when (and recording what was sent so that nothing is sent twice) is
+// left to the caller.
+type Notifier interface {
+	Name() string               // Name of the notifier, recorded with the messages that were sent through it
+	Send(message Message) error // Delivers a message, returning an error if it could not be delivered
+}
+
+// A message to a single recipient
+type Message struct {
+	To      string // Address of the recipient (e.g. an email address)
+	ToName  string // Name of the recipient (optional)
+	Subject string // Subject line
+	Body    string // Plain text body
+	Kind    string // Kind of message (e.g. "invoice.reminder"), for logging and filtering
+}
+
+// Errors returned by notifiers
+var (
+	ErrInvalidMessage     = errors.New("invalid notification message")
+	ErrNotificationFailed = errors.New("notification could not be delivered")
+)
+
+/*
+*Description*
+
+func Validate
+
+Confirms that the calling Message has a recipient and a subject, and that neither contains line breaks (which could be used to add
+headers to an email).
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidMessage' if the message can't be sent (nil otherwise)
+*/
+func (message Message) Validate() error {
+	if strings.TrimSpace(message.To) == "" {
+		return fmt.Errorf("%w: the message has no recipient", ErrInvalidMessage)
+	}
+
+	if strings.TrimSpace(message.Subject) == "" {
+		return fmt.Errorf("%w: the message has no subject", ErrInvalidMessage)
+	}
+
+	if strings.ContainsAny(message.To+message.ToName+message.Subject, "\r\n") {
+		return fmt.Errorf("%w: the recipient and subject can't contain line breaks", ErrInvalidMessage)
+	}
+
+	return nil
+}
+
+// Notifier that writes messages to the application log instead of delivering them (used when no delivery service is configured)
+type LogNotifier struct{}
+
+/*
+*Description*
+
+func NewLogNotifier
+
+Creates a LogNotifier.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <*LogNotifier>
+
+		The notifier.
+*/
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+/*
+*Description*
+
+func Name
+
+Returns the name of the notifier ("log").
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The name of the notifier.
+*/
+func (notifier *LogNotifier) Name() string {
+	return "log"
+}
+
+/*
+*Description*
+
+func Send
+
+Writes the message to the application log.
+
+*Parameters*
+
+	message  <Message>
+
+		The message.
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidMessage' if the message can't be sent (nil otherwise)
+*/
+func (notifier *LogNotifier) Send(message Message) error {
+	if err := message.Validate(); err != nil {
+		return err
+	}
+
+	log.Printf("NOTIFICATION (%s) to %s: %s\n\n%s", message.Kind, message.To, message.Subject, message.Body)
+	return nil
+}
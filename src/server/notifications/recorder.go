@@ -0,0 +1,115 @@
+package notifications
+
+import (
+	"sync"
+)
+
+// Notifier that keeps the messages sent through it in memory instead of delivering them, for tests
+//
+// Setting 'Err' makes every delivery fail with that error, so tests can check what happens when messages can't be delivered.
+type Recorder struct {
+	mutex    sync.Mutex
+	messages []Message
+	Err      error // Error returned by 'Send' (nil to accept every message)
+}
+
+/*
+*Description*
+
+func NewRecorder
+
+Creates a Recorder with no messages.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <*Recorder>
+
+		The notifier.
+*/
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+/*
+*Description*
+
+func Name
+
+Returns the name of the notifier ("recorder").
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The name of the notifier.
+*/
+func (recorder *Recorder) Name() string {
+	return "recorder"
+}
+
+/*
+*Description*
+
+func Send
+
+Keeps the message (unless 'Err' is set, in which case the message is dropped and 'Err' is returned).
+
+*Parameters*
+
+	message  <Message>
+
+		The message.
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidMessage' if the message can't be sent, or 'Err' (nil otherwise)
+*/
+func (recorder *Recorder) Send(message Message) error {
+	if err := message.Validate(); err != nil {
+		return err
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if recorder.Err != nil {
+		return recorder.Err
+	}
+
+	recorder.messages = append(recorder.messages, message)
+	return nil
+}
+
+/*
+*Description*
+
+func Messages
+
+Returns the messages that were sent, oldest first.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <[]Message>
+
+		The messages that were sent.
+*/
+func (recorder *Recorder) Messages() []Message {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return append([]Message{}, recorder.messages...)
+}
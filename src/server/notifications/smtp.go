@@ -0,0 +1,196 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notifier that delivers messages as plain text email through an SMTP server
+type SMTPNotifier struct {
+	address string           // Network address ("host:port") of the SMTP server
+	auth    smtp.Auth        // Credentials for the SMTP server (nil if the server doesn't require them)
+	from    mail.Address     // Sender of every email
+	Now     func() time.Time // Returns the current time (used for the Date header)
+}
+
+/*
+*Description*
+
+func NewSMTPNotifier
+
+Creates an SMTPNotifier that sends email through the specified SMTP server. Credentials are only sent if a username is specified.
+
+*Parameters*
+
+	host  <string>
+
+		Host name of the SMTP server.
+
+	port  <int>
+
+		Port of the SMTP server (e.g. 587).
+
+	username  <string>
+
+		Username for the SMTP server ("" if the server doesn't require credentials).
+
+	password  <string>
+
+		Password for the SMTP server.
+
+	from  <string>
+
+		Sender of every email, with an optional name (e.g. "BizZen <billing@example.com>").
+
+*Returns*
+
+	_  <*SMTPNotifier>
+
+		The notifier.
+
+	_  <error>
+
+		'ErrInvalidMessage' if the sender is not a valid email address (nil otherwise)
+*/
+func NewSMTPNotifier(host string, port int, username string, password string, from string) (*SMTPNotifier, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sender '%s' is not a valid email address", ErrInvalidMessage, from)
+	}
+
+	notifier := &SMTPNotifier{
+		address: net.JoinHostPort(host, strconv.Itoa(port)),
+		from:    *sender,
+		Now:     time.Now,
+	}
+
+	if username != "" {
+		notifier.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return notifier, nil
+}
+
+/*
+*Description*
+
+func Name
+
+Returns the name of the notifier ("smtp").
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The name of the notifier.
+*/
+func (notifier *SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+/*
+*Description*
+
+func Send
+
+Sends the message as a plain text email (see 'FormatEmail').
+
+*Parameters*
+
+	message  <Message>
+
+		The message.
+
+*Returns*
+
+	_  <error>
+
+		'ErrInvalidMessage' if the message can't be sent, or 'ErrNotificationFailed' if the SMTP server didn't accept it (nil otherwise)
+*/
+func (notifier *SMTPNotifier) Send(message Message) error {
+	email, err := FormatEmail(notifier.from, message, notifier.Now())
+	if err != nil {
+		return err
+	}
+
+	err = smtp.SendMail(notifier.address, notifier.auth, notifier.from.Address, []string{message.To}, email)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotificationFailed, err)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func FormatEmail
+
+Formats a message as a plain text email (RFC 5322) from the specified sender. Names and subjects that aren't plain ASCII are encoded,
+and the body is sent as UTF-8 with CRLF line endings.
+
+*Parameters*
+
+	from  <mail.Address>
+
+		The sender.
+
+	message  <Message>
+
+		The message.
+
+	date  <time.Time>
+
+		The date of the email.
+
+*Returns*
+
+	_  <[]byte>
+
+		The email, headers included.
+
+	_  <error>
+
+		'ErrInvalidMessage' if the message can't be sent (nil otherwise)
+*/
+func FormatEmail(from mail.Address, message Message, date time.Time) ([]byte, error) {
+	if err := message.Validate(); err != nil {
+		return nil, err
+	}
+
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: recipient '%s' is not a valid email address", ErrInvalidMessage, message.To)
+	}
+
+	if message.ToName != "" {
+		to.Name = message.ToName
+	}
+
+	var email bytes.Buffer
+	fmt.Fprintf(&email, "From: %s\r\n", from.String())
+	fmt.Fprintf(&email, "To: %s\r\n", to.String())
+	fmt.Fprintf(&email, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&email, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	email.WriteString("MIME-Version: 1.0\r\n")
+	email.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	email.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	email.WriteString("\r\n")
+
+	body := strings.ReplaceAll(message.Body, "\r\n", "\n")
+	email.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	email.WriteString("\r\n")
+
+	return email.Bytes(), nil
+}
@@ -0,0 +1,683 @@
+package payments
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keys that a FakeServer accepts and signs webhooks with
+const (
+	FakeSecretKey     string = "sk_test_fake"
+	FakeWebhookSecret string = "whsec_fake"
+)
+
+// Local, in-memory server that implements the parts of the Stripe API used by StripeProvider, for tests
+//
+// Customers "pay" intents with 'Pay'. Every change to an intent or refund records an event, and 'Webhooks' returns the signed webhook
+// deliveries for those events so tests can deliver them (as many times as they like) to the webhook endpoint.
+type FakeServer struct {
+	server    *httptest.Server
+	mutex     sync.Mutex
+	nextID    int
+	intents   map[string]*stripePaymentIntent
+	refunds   map[string]*stripeRefund
+	responses map[string]fakeResponse // Responses to requests with an idempotency key
+	events    [][]byte                // Bodies of the webhook deliveries for every recorded event
+	Now       func() time.Time        // Returns the current time (used for event and signature timestamps)
+}
+
+// Signed webhook delivery from a FakeServer
+type Webhook struct {
+	Payload []byte      // Body of the delivery
+	Header  http.Header // Headers of the delivery (including the signature)
+}
+
+// Response recorded for a request with an idempotency key
+type fakeResponse struct {
+	code int
+	body []byte
+}
+
+/*
+*Description*
+
+func NewFakeServer
+
+Starts a FakeServer on a local port. The server must be stopped with 'Close'.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <*FakeServer>
+
+		The running server.
+*/
+func NewFakeServer() *FakeServer {
+	fake := &FakeServer{
+		intents:   map[string]*stripePaymentIntent{},
+		refunds:   map[string]*stripeRefund{},
+		responses: map[string]fakeResponse{},
+		Now:       time.Now,
+	}
+
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serveHTTP))
+	return fake
+}
+
+/*
+*Description*
+
+func URL
+
+Returns the base URL of the server.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The base URL.
+*/
+func (fake *FakeServer) URL() string {
+	return fake.server.URL
+}
+
+/*
+*Description*
+
+func Close
+
+Stops the server.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	N/A (None)
+*/
+func (fake *FakeServer) Close() {
+	fake.server.Close()
+}
+
+/*
+*Description*
+
+func Provider
+
+Returns a StripeProvider that sends its requests to the server and accepts the server's webhooks.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <*StripeProvider>
+
+		The provider.
+*/
+func (fake *FakeServer) Provider() *StripeProvider {
+	provider := NewStripeProvider(fake.URL(), FakeSecretKey, FakeWebhookSecret)
+	provider.now = func() time.Time { return fake.Now() }
+	return provider
+}
+
+/*
+*Description*
+
+func Pay
+
+Simulates the customer paying an intent: intents with automatic capture succeed and intents with manual capture become capturable.
+
+*Parameters*
+
+	intentID  <string>
+
+		ID of the intent being paid.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (fake *FakeServer) Pay(intentID string) error {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	intent, ok := fake.intents[intentID]
+	if !ok || intent.Status != "requires_payment_method" {
+		return fmt.Errorf("%w: intent '%s' can't be paid", ErrProviderRequest, intentID)
+	}
+
+	fake.pay(intent)
+	return nil
+}
+
+/*
+*Description*
+
+func Webhooks
+
+Returns the signed webhook deliveries for every event recorded so far (oldest first).
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <[]Webhook>
+
+		The webhook deliveries.
+*/
+func (fake *FakeServer) Webhooks() []Webhook {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	webhooks := []Webhook{}
+	for _, payload := range fake.events {
+		webhooks = append(webhooks, Webhook{Payload: payload, Header: fake.SignWebhook(payload, fake.Now())})
+	}
+
+	return webhooks
+}
+
+/*
+*Description*
+
+func SignWebhook
+
+Returns the headers of a webhook delivery for the specified payload, signed with 'FakeWebhookSecret' at the specified time.
+
+*Parameters*
+
+	payload  <[]byte>
+
+		The body of the delivery.
+
+	signedAt  <time.Time>
+
+		The signed timestamp.
+
+*Returns*
+
+	_  <http.Header>
+
+		The headers of the delivery.
+*/
+func (fake *FakeServer) SignWebhook(payload []byte, signedAt time.Time) http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(stripeSignatureHeader, fmt.Sprintf("t=%d,v1=%s", signedAt.Unix(), signStripePayload(FakeWebhookSecret, signedAt.Unix(), payload)))
+	return header
+}
+
+/*
+*Description*
+
+func serveHTTP
+
+Handles requests to the server's API endpoints:
+
+	POST /v1/payment_intents
+	GET  /v1/payment_intents/{id}
+	POST /v1/payment_intents/{id}/confirm
+	POST /v1/payment_intents/{id}/capture
+	POST /v1/refunds
+
+Requests must be authorized with 'FakeSecretKey'. Requests with an 'Idempotency-Key' header that was already used get the original
+response without taking effect again.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	request  <*http.Request>
+
+		The HTTP request
+
+*Returns*
+
+	None
+*/
+func (fake *FakeServer) serveHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Header.Get("Authorization") != "Bearer "+FakeSecretKey {
+		writeFakeResponse(writer, fakeError(http.StatusUnauthorized, "Invalid API key provided."))
+		return
+	}
+
+	if err := request.ParseForm(); err != nil {
+		writeFakeResponse(writer, fakeError(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	idempotencyKey := request.Header.Get("Idempotency-Key")
+	if idempotencyKey != "" {
+		if response, ok := fake.responses[request.URL.Path+" "+idempotencyKey]; ok {
+			writeFakeResponse(writer, response)
+			return
+		}
+	}
+
+	response := fake.route(request)
+	if idempotencyKey != "" {
+		fake.responses[request.URL.Path+" "+idempotencyKey] = response
+	}
+
+	writeFakeResponse(writer, response)
+}
+
+/*
+*Description*
+
+func route
+
+Performs the API request and returns its response (see 'serveHTTP'). The server's mutex must be held.
+
+*Parameters*
+
+	request  <*http.Request>
+
+		The HTTP request (with its form parsed).
+
+*Returns*
+
+	_  <fakeResponse>
+
+		The response.
+*/
+func (fake *FakeServer) route(request *http.Request) fakeResponse {
+	path := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+
+	switch {
+	case request.Method == http.MethodPost && len(path) == 2 && path[1] == "payment_intents":
+		return fake.createPaymentIntent(request)
+	case request.Method == http.MethodPost && len(path) == 2 && path[1] == "refunds":
+		return fake.createRefund(request)
+	case len(path) >= 3 && path[1] == "payment_intents":
+		intent, ok := fake.intents[path[2]]
+		if !ok {
+			return fakeError(http.StatusNotFound, fmt.Sprintf("No such payment_intent: '%s'", path[2]))
+		}
+
+		if request.Method == http.MethodGet && len(path) == 3 {
+			return fakeJSON(intent)
+		} else if request.Method == http.MethodPost && len(path) == 4 && path[3] == "confirm" {
+			if intent.Status != "requires_payment_method" {
+				return fakeError(http.StatusBadRequest, "This PaymentIntent can't be confirmed.")
+			}
+
+			fake.pay(intent)
+			return fakeJSON(intent)
+		} else if request.Method == http.MethodPost && len(path) == 4 && path[3] == "capture" {
+			return fake.capture(intent, request)
+		}
+	}
+
+	return fakeError(http.StatusNotFound, fmt.Sprintf("Unrecognized request URL (%s: %s)", request.Method, request.URL.Path))
+}
+
+/*
+*Description*
+
+func createPaymentIntent
+
+Creates an intent from the request parameters (amount, currency, capture_method, description, metadata[...]).
+
+*Parameters*
+
+	request  <*http.Request>
+
+		The HTTP request (with its form parsed).
+
+*Returns*
+
+	_  <fakeResponse>
+
+		The response.
+*/
+func (fake *FakeServer) createPaymentIntent(request *http.Request) fakeResponse {
+	amount, err := strconv.Atoi(request.PostForm.Get("amount"))
+	if err != nil || amount <= 0 {
+		return fakeError(http.StatusBadRequest, "Amount must be a positive integer.")
+	}
+
+	id := fake.newID("pi")
+	intent := &stripePaymentIntent{
+		ID:            id,
+		Object:        "payment_intent",
+		Amount:        amount,
+		Currency:      request.PostForm.Get("currency"),
+		Status:        "requires_payment_method",
+		CaptureMethod: request.PostForm.Get("capture_method"),
+		ClientSecret:  id + "_secret_fake",
+		Description:   request.PostForm.Get("description"),
+		Metadata:      getMetadata(request),
+	}
+
+	fake.intents[id] = intent
+	fake.recordEvent("payment_intent.created", intent)
+	return fakeJSON(intent)
+}
+
+/*
+*Description*
+
+func capture
+
+Captures an authorized intent (all of it, or 'amount_to_capture').
+
+*Parameters*
+
+	intent  <*stripePaymentIntent>
+
+		The intent being captured.
+
+	request  <*http.Request>
+
+		The HTTP request (with its form parsed).
+
+*Returns*
+
+	_  <fakeResponse>
+
+		The response.
+*/
+func (fake *FakeServer) capture(intent *stripePaymentIntent, request *http.Request) fakeResponse {
+	if intent.Status != "requires_capture" {
+		return fakeError(http.StatusBadRequest, "This PaymentIntent could not be captured because it has a status of "+intent.Status+".")
+	}
+
+	amount := intent.AmountCapturable
+	if request.PostForm.Get("amount_to_capture") != "" {
+		var err error
+		amount, err = strconv.Atoi(request.PostForm.Get("amount_to_capture"))
+		if err != nil || amount <= 0 || amount > intent.AmountCapturable {
+			return fakeError(http.StatusBadRequest, "The amount to capture must be positive and can't exceed the amount capturable.")
+		}
+	}
+
+	intent.AmountCapturable = 0
+	intent.AmountReceived = amount
+	intent.Status = "succeeded"
+	fake.recordEvent("payment_intent.succeeded", intent)
+	return fakeJSON(intent)
+}
+
+/*
+*Description*
+
+func createRefund
+
+Refunds a succeeded intent from the request parameters (payment_intent, amount, metadata[...]). The refund succeeds immediately.
+
+*Parameters*
+
+	request  <*http.Request>
+
+		The HTTP request (with its form parsed).
+
+*Returns*
+
+	_  <fakeResponse>
+
+		The response.
+*/
+func (fake *FakeServer) createRefund(request *http.Request) fakeResponse {
+	intent, ok := fake.intents[request.PostForm.Get("payment_intent")]
+	if !ok || intent.Status != "succeeded" {
+		return fakeError(http.StatusBadRequest, "Only succeeded payment intents can be refunded.")
+	}
+
+	var refunded int
+	for _, refund := range fake.refunds {
+		if refund.PaymentIntent == intent.ID {
+			refunded += refund.Amount
+		}
+	}
+
+	amount := intent.AmountReceived - refunded
+	if request.PostForm.Get("amount") != "" {
+		var err error
+		amount, err = strconv.Atoi(request.PostForm.Get("amount"))
+		if err != nil || amount <= 0 {
+			return fakeError(http.StatusBadRequest, "Amount must be a positive integer.")
+		}
+	}
+
+	if amount <= 0 || amount > intent.AmountReceived-refunded {
+		return fakeError(http.StatusBadRequest, "Refund amount is greater than the unrefunded amount on the payment.")
+	}
+
+	refund := &stripeRefund{
+		ID:            fake.newID("re"),
+		Object:        "refund",
+		Amount:        amount,
+		Currency:      intent.Currency,
+		PaymentIntent: intent.ID,
+		Status:        "succeeded",
+		Metadata:      getMetadata(request),
+	}
+
+	fake.refunds[refund.ID] = refund
+	fake.recordEvent("refund.created", refund)
+	return fakeJSON(refund)
+}
+
+/*
+*Description*
+
+func pay
+
+Marks an intent as paid by the customer (see 'Pay'). The server's mutex must be held.
+
+*Parameters*
+
+	intent  <*stripePaymentIntent>
+
+		The intent being paid.
+
+*Returns*
+
+	N/A (None)
+*/
+func (fake *FakeServer) pay(intent *stripePaymentIntent) {
+	if intent.CaptureMethod == "manual" {
+		intent.Status = "requires_capture"
+		intent.AmountCapturable = intent.Amount
+		fake.recordEvent("payment_intent.amount_capturable_updated", intent)
+		return
+	}
+
+	intent.Status = "succeeded"
+	intent.AmountReceived = intent.Amount
+	fake.recordEvent("payment_intent.succeeded", intent)
+}
+
+/*
+*Description*
+
+func recordEvent
+
+Records an event with a copy of the current state of the object it is about. The server's mutex must be held.
+
+*Parameters*
+
+	eventType  <string>
+
+		The event type (e.g. "payment_intent.succeeded").
+
+	object  <interface{}>
+
+		The intent or refund that the event is about.
+
+*Returns*
+
+	N/A (None)
+*/
+func (fake *FakeServer) recordEvent(eventType string, object interface{}) {
+	objectJSON, _ := json.Marshal(object)
+
+	event := stripeEvent{ID: fake.newID("evt"), Object: "event", Type: eventType, Created: fake.Now().Unix()}
+	event.Data.Object = objectJSON
+
+	payload, _ := json.Marshal(event)
+	fake.events = append(fake.events, payload)
+}
+
+/*
+*Description*
+
+func newID
+
+Returns a new object ID with the specified prefix (e.g. "pi_fake_3"). The server's mutex must be held.
+
+*Parameters*
+
+	prefix  <string>
+
+		The ID prefix.
+
+*Returns*
+
+	_  <string>
+
+		The new ID.
+*/
+func (fake *FakeServer) newID(prefix string) string {
+	fake.nextID++
+	return fmt.Sprintf("%s_fake_%d", prefix, fake.nextID)
+}
+
+/*
+*Description*
+
+func getMetadata
+
+Returns the metadata parameters ("metadata[key]=value") of a request.
+
+*Parameters*
+
+	request  <*http.Request>
+
+		The HTTP request (with its form parsed).
+
+*Returns*
+
+	_  <map[string]string>
+
+		The metadata.
+*/
+func getMetadata(request *http.Request) map[string]string {
+	metadata := map[string]string{}
+	for key, values := range request.PostForm {
+		if strings.HasPrefix(key, "metadata[") && strings.HasSuffix(key, "]") && len(values) > 0 {
+			metadata[strings.TrimSuffix(strings.TrimPrefix(key, "metadata["), "]")] = values[0]
+		}
+	}
+
+	return metadata
+}
+
+/*
+*Description*
+
+func fakeJSON
+
+Returns a successful response with the JSON encoding of an object.
+
+*Parameters*
+
+	object  <interface{}>
+
+		The object.
+
+*Returns*
+
+	_  <fakeResponse>
+
+		The response.
+*/
+func fakeJSON(object interface{}) fakeResponse {
+	body, _ := json.Marshal(object)
+	return fakeResponse{code: http.StatusOK, body: body}
+}
+
+/*
+*Description*
+
+func fakeError
+
+Returns an error response in the format used by the Stripe API.
+
+*Parameters*
+
+	code  <int>
+
+		The HTTP status code.
+
+	message  <string>
+
+		The error message.
+
+*Returns*
+
+	_  <fakeResponse>
+
+		The response.
+*/
+func fakeError(code int, message string) fakeResponse {
+	apiError := stripeError{}
+	apiError.Error.Type = "invalid_request_error"
+	apiError.Error.Message = message
+
+	body, _ := json.Marshal(apiError)
+	return fakeResponse{code: code, body: body}
+}
+
+/*
+*Description*
+
+func writeFakeResponse
+
+Writes a response.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	response  <fakeResponse>
+
+		The response.
+
+*Returns*
+
+	None
+*/
+func writeFakeResponse(writer http.ResponseWriter, response fakeResponse) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(response.code)
+	writer.Write(response.body)
+}
@@ -0,0 +1,89 @@
+package payments
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Interface for online payment providers (e.g. Stripe)
+//
+// Customers pay a PaymentIntent created for an Invoice. The provider reports what happened to the intent (and to any refunds) through
+// signed webhook events, which are verified with 'VerifyWebhook' before they are applied to the invoice ledger.
+type PaymentProvider interface {
+	Name() string                                                             // Name of the provider, recorded with provider payments and events
+	CreatePaymentIntent(params PaymentIntentParams) (*PaymentIntent, error)   // Creates an intent for the customer to pay
+	CapturePaymentIntent(intentID string, amount int) (*PaymentIntent, error) // Captures an authorized intent (amount 0 captures everything authorized)
+	RefundPaymentIntent(params RefundParams) (*Refund, error)                 // Refunds all or part of a captured intent
+	VerifyWebhook(payload []byte, header http.Header) (*Event, error)         // Verifies a webhook delivery's signature and parses its event
+}
+
+// Options for creating a PaymentIntent. Amounts are in cents.
+type PaymentIntentParams struct {
+	Amount          int               // Amount the customer is asked to pay
+	Currency        string            // ISO 4217 currency code (defaults to "usd")
+	Description     string            // Description shown to the customer
+	CaptureManually bool              // If true, the payment is only authorized until it is captured with 'CapturePaymentIntent'
+	Metadata        map[string]string // Extra data returned with the intent (e.g. the invoice ID)
+	IdempotencyKey  string            // Key that makes retried requests create the intent only once (optional)
+}
+
+// Options for refunding a PaymentIntent. Amounts are in cents.
+type RefundParams struct {
+	PaymentIntentID string            // Provider's ID of the intent being refunded
+	Amount          int               // Amount to refund (0 refunds everything that was captured)
+	Metadata        map[string]string // Extra data returned with the refund (e.g. the reason)
+	IdempotencyKey  string            // Key that makes retried requests refund only once (optional)
+}
+
+// A payment the customer is asked to make, as reported by the provider. Amounts are in cents.
+type PaymentIntent struct {
+	ID             string            // Provider's ID of the intent
+	Amount         int               // Amount the customer is asked to pay
+	AmountReceived int               // Amount that has been captured
+	Currency       string            // ISO 4217 currency code
+	Status         string            // Provider-neutral status (see 'PaymentIntentStatusPending', etc.)
+	ClientSecret   string            // Secret the customer's browser uses to complete the payment with the provider
+	Metadata       map[string]string // Extra data set when the intent was created
+}
+
+// A refund of a PaymentIntent, as reported by the provider. Amounts are in cents.
+type Refund struct {
+	ID              string            // Provider's ID of the refund
+	PaymentIntentID string            // Provider's ID of the refunded intent
+	Amount          int               // Amount refunded
+	Status          string            // Provider-neutral status (see 'RefundStatusPending', etc.)
+	Metadata        map[string]string // Extra data set when the refund was created
+}
+
+// A verified webhook event. Exactly one of PaymentIntent and Refund is set for events about payments and refunds, and neither is set
+// for events that don't affect the invoice ledger.
+type Event struct {
+	ID            string         // Provider's ID of the event (the same event can be delivered more than once)
+	Type          string         // Provider's event type (e.g. "payment_intent.succeeded")
+	Created       time.Time      // Date/time when the event happened
+	PaymentIntent *PaymentIntent // State of the intent that the event is about
+	Refund        *Refund        // State of the refund that the event is about
+}
+
+// Provider-neutral PaymentIntent statuses
+const (
+	PaymentIntentStatusPending         string = "Pending"          // Waiting for the customer to pay
+	PaymentIntentStatusRequiresCapture string = "Requires Capture" // Authorized, waiting to be captured
+	PaymentIntentStatusSucceeded       string = "Succeeded"        // Paid
+	PaymentIntentStatusCancelled       string = "Cancelled"        // Cancelled before it was paid
+)
+
+// Provider-neutral Refund statuses
+const (
+	RefundStatusPending   string = "Pending"   // Waiting to be sent to the customer
+	RefundStatusSucceeded string = "Succeeded" // Sent to the customer
+	RefundStatusFailed    string = "Failed"    // Could not be sent to the customer
+)
+
+// Errors returned by payment providers
+var (
+	ErrProviderRequest         = errors.New("payment provider request failed")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+	ErrProviderNotConfigured   = errors.New("payment provider is not configured")
+)
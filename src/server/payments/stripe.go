@@ -0,0 +1,618 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Base URL of the Stripe API (used when no other URL is configured)
+const StripeAPIURL string = "https://api.stripe.com"
+
+// Header that carries the signature of Stripe webhook deliveries
+const stripeSignatureHeader string = "Stripe-Signature"
+
+// How far a webhook delivery's signed timestamp may be from the current time (rejects replayed deliveries)
+const webhookTolerance time.Duration = 5 * time.Minute
+
+// Largest response body read from the provider
+const maxResponseSize int64 = 1 << 20
+
+// PaymentProvider for the Stripe API, or any server that implements the same endpoints (see 'FakeServer')
+type StripeProvider struct {
+	apiURL        string           // Base URL of the API
+	secretKey     string           // API secret key
+	webhookSecret string           // Secret that webhook deliveries are signed with
+	client        *http.Client     // HTTP client used for API requests
+	now           func() time.Time // Returns the current time (checked against webhook timestamps)
+}
+
+// PaymentIntent object in the Stripe API
+type stripePaymentIntent struct {
+	ID               string            `json:"id"`
+	Object           string            `json:"object"`
+	Amount           int               `json:"amount"`
+	AmountCapturable int               `json:"amount_capturable"`
+	AmountReceived   int               `json:"amount_received"`
+	Currency         string            `json:"currency"`
+	Status           string            `json:"status"`
+	CaptureMethod    string            `json:"capture_method"`
+	ClientSecret     string            `json:"client_secret"`
+	Description      string            `json:"description"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+// Refund object in the Stripe API
+type stripeRefund struct {
+	ID            string            `json:"id"`
+	Object        string            `json:"object"`
+	Amount        int               `json:"amount"`
+	Currency      string            `json:"currency"`
+	PaymentIntent string            `json:"payment_intent"`
+	Status        string            `json:"status"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+// Event object in the Stripe API (the body of a webhook delivery)
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+// Error response in the Stripe API
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+/*
+*Description*
+
+func NewStripeProvider
+
+Creates a PaymentProvider for the Stripe API.
+
+*Parameters*
+
+	apiURL  <string>
+
+		Base URL of the API (defaults to 'StripeAPIURL'). Tests point this at a 'FakeServer'.
+
+	secretKey  <string>
+
+		API secret key.
+
+	webhookSecret  <string>
+
+		Secret that webhook deliveries are signed with.
+
+*Returns*
+
+	_  <*StripeProvider>
+
+		The new provider.
+*/
+func NewStripeProvider(apiURL string, secretKey string, webhookSecret string) *StripeProvider {
+	if apiURL == "" {
+		apiURL = StripeAPIURL
+	}
+
+	return &StripeProvider{
+		apiURL:        strings.TrimRight(apiURL, "/"),
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		client:        &http.Client{Timeout: 30 * time.Second},
+		now:           time.Now,
+	}
+}
+
+/*
+*Description*
+
+func Name
+
+Returns the name of the provider ("stripe").
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <string>
+
+		The name of the provider.
+*/
+func (provider *StripeProvider) Name() string {
+	return "stripe"
+}
+
+/*
+*Description*
+
+func CreatePaymentIntent
+
+Creates a Stripe PaymentIntent for the customer to pay.
+
+*Parameters*
+
+	params  <PaymentIntentParams>
+
+		The amount, currency, capture method and metadata of the intent.
+
+*Returns*
+
+	_  <*PaymentIntent>
+
+		The created intent.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (provider *StripeProvider) CreatePaymentIntent(params PaymentIntentParams) (*PaymentIntent, error) {
+	if params.Currency == "" {
+		params.Currency = "usd"
+	}
+
+	form := url.Values{}
+	form.Set("amount", strconv.Itoa(params.Amount))
+	form.Set("currency", strings.ToLower(params.Currency))
+	form.Set("capture_method", "automatic")
+	if params.CaptureManually {
+		form.Set("capture_method", "manual")
+	}
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	setMetadata(form, params.Metadata)
+
+	intent := &stripePaymentIntent{}
+	err := provider.request(http.MethodPost, "/v1/payment_intents", form, params.IdempotencyKey, intent)
+	if err != nil {
+		return nil, err
+	}
+
+	return intent.toPaymentIntent(), nil
+}
+
+/*
+*Description*
+
+func CapturePaymentIntent
+
+Captures a Stripe PaymentIntent that was created with manual capture and has been authorized by the customer.
+
+*Parameters*
+
+	intentID  <string>
+
+		Stripe's ID of the intent.
+
+	amount  <int>
+
+		Amount to capture (in cents). 0 captures everything that was authorized.
+
+*Returns*
+
+	_  <*PaymentIntent>
+
+		The captured intent.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (provider *StripeProvider) CapturePaymentIntent(intentID string, amount int) (*PaymentIntent, error) {
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.Itoa(amount))
+	}
+
+	intent := &stripePaymentIntent{}
+	err := provider.request(http.MethodPost, fmt.Sprintf("/v1/payment_intents/%s/capture", url.PathEscape(intentID)), form, "", intent)
+	if err != nil {
+		return nil, err
+	}
+
+	return intent.toPaymentIntent(), nil
+}
+
+/*
+*Description*
+
+func RefundPaymentIntent
+
+Refunds all or part of a captured Stripe PaymentIntent.
+
+*Parameters*
+
+	params  <RefundParams>
+
+		The intent, amount and metadata of the refund.
+
+*Returns*
+
+	_  <*Refund>
+
+		The created refund.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (provider *StripeProvider) RefundPaymentIntent(params RefundParams) (*Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", params.PaymentIntentID)
+	if params.Amount > 0 {
+		form.Set("amount", strconv.Itoa(params.Amount))
+	}
+	setMetadata(form, params.Metadata)
+
+	refund := &stripeRefund{}
+	err := provider.request(http.MethodPost, "/v1/refunds", form, params.IdempotencyKey, refund)
+	if err != nil {
+		return nil, err
+	}
+
+	return refund.toRefund(), nil
+}
+
+/*
+*Description*
+
+func VerifyWebhook
+
+Verifies the 'Stripe-Signature' header of a webhook delivery and parses its event.
+
+The header holds a timestamp ('t') and one or more signatures ('v1'), each an HMAC-SHA256 of "<timestamp>.<payload>" keyed with the
+webhook secret. The delivery is rejected unless one of the signatures matches and the timestamp is within 5 minutes of the current
+time, so altered and replayed deliveries are refused.
+
+*Parameters*
+
+	payload  <[]byte>
+
+		The unmodified body of the webhook delivery.
+
+	header  <http.Header>
+
+		The headers of the webhook delivery.
+
+*Returns*
+
+	_  <*Event>
+
+		The verified event.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (provider *StripeProvider) VerifyWebhook(payload []byte, header http.Header) (*Event, error) {
+	if provider.webhookSecret == "" {
+		return nil, fmt.Errorf("%w: no webhook secret", ErrProviderNotConfigured)
+	}
+
+	var timestamp int64
+	var signatures []string
+	for _, part := range strings.Split(header.Get(stripeSignatureHeader), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	if timestamp == 0 || len(signatures) == 0 {
+		return nil, fmt.Errorf("%w: missing timestamp or signature", ErrInvalidWebhookSignature)
+	}
+
+	expected, _ := hex.DecodeString(signStripePayload(provider.webhookSecret, timestamp, payload))
+	var signatureMatches bool
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			signatureMatches = true
+		}
+	}
+
+	if !signatureMatches {
+		return nil, fmt.Errorf("%w: signature does not match", ErrInvalidWebhookSignature)
+	}
+
+	age := provider.now().Sub(time.Unix(timestamp, 0))
+	if age > webhookTolerance || age < -webhookTolerance {
+		return nil, fmt.Errorf("%w: timestamp is outside the tolerance", ErrInvalidWebhookSignature)
+	}
+
+	return parseStripeEvent(payload)
+}
+
+/*
+*Description*
+
+func request
+
+Sends a form-encoded request to the API and decodes the JSON response.
+
+*Parameters*
+
+	method  <string>
+
+		The HTTP method.
+
+	path  <string>
+
+		The path of the endpoint (e.g. "/v1/refunds").
+
+	form  <url.Values>
+
+		The request parameters.
+
+	idempotencyKey  <string>
+
+		Key that makes retried requests take effect only once (optional).
+
+	response  <interface{}>
+
+		Object the response body is decoded into.
+
+*Returns*
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func (provider *StripeProvider) request(method string, path string, form url.Values, idempotencyKey string, response interface{}) error {
+	if provider.secretKey == "" {
+		return fmt.Errorf("%w: no secret key", ErrProviderNotConfigured)
+	}
+
+	request, err := http.NewRequest(method, provider.apiURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrProviderRequest, err)
+	}
+
+	request.Header.Set("Authorization", "Bearer "+provider.secretKey)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		request.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	httpResponse, err := provider.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrProviderRequest, err)
+	}
+
+	defer httpResponse.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(httpResponse.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrProviderRequest, err)
+	}
+
+	if httpResponse.StatusCode >= http.StatusMultipleChoices {
+		apiError := stripeError{}
+		json.Unmarshal(body, &apiError)
+		return fmt.Errorf("%w: %s (HTTP %d)", ErrProviderRequest, apiError.Error.Message, httpResponse.StatusCode)
+	}
+
+	err = json.Unmarshal(body, response)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrProviderRequest, err)
+	}
+
+	return nil
+}
+
+/*
+*Description*
+
+func parseStripeEvent
+
+Parses the body of a Stripe webhook delivery. PaymentIntent events ("payment_intent.*") carry the intent and refund events
+("refund.*" and "charge.refund.*") carry the refund. Other events are returned without either.
+
+*Parameters*
+
+	payload  <[]byte>
+
+		The body of the webhook delivery.
+
+*Returns*
+
+	_  <*Event>
+
+		The parsed event.
+
+	_  <error>
+
+		Encountered error (nil if no errors are encountered)
+*/
+func parseStripeEvent(payload []byte) (*Event, error) {
+	apiEvent := stripeEvent{}
+	err := json.Unmarshal(payload, &apiEvent)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProviderRequest, err)
+	}
+
+	if apiEvent.ID == "" {
+		return nil, fmt.Errorf("%w: event has no ID", ErrProviderRequest)
+	}
+
+	event := &Event{ID: apiEvent.ID, Type: apiEvent.Type, Created: time.Unix(apiEvent.Created, 0)}
+
+	if strings.HasPrefix(apiEvent.Type, "payment_intent.") {
+		intent := &stripePaymentIntent{}
+		err = json.Unmarshal(apiEvent.Data.Object, intent)
+		event.PaymentIntent = intent.toPaymentIntent()
+	} else if strings.HasPrefix(apiEvent.Type, "refund.") || strings.HasPrefix(apiEvent.Type, "charge.refund.") {
+		refund := &stripeRefund{}
+		err = json.Unmarshal(apiEvent.Data.Object, refund)
+		event.Refund = refund.toRefund()
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrProviderRequest, err)
+	}
+
+	return event, nil
+}
+
+/*
+*Description*
+
+func signStripePayload
+
+Returns the hex encoded HMAC-SHA256 signature of a webhook payload, as sent in the 'v1' part of the 'Stripe-Signature' header.
+
+*Parameters*
+
+	secret  <string>
+
+		The webhook secret.
+
+	timestamp  <int64>
+
+		The signed timestamp (Unix seconds).
+
+	payload  <[]byte>
+
+		The body of the webhook delivery.
+
+*Returns*
+
+	_  <string>
+
+		The signature.
+*/
+func signStripePayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+*Description*
+
+func setMetadata
+
+Adds metadata to the parameters of a request ("metadata[key]=value").
+
+*Parameters*
+
+	form  <url.Values>
+
+		The request parameters.
+
+	metadata  <map[string]string>
+
+		The metadata.
+
+*Returns*
+
+	N/A (None)
+*/
+func setMetadata(form url.Values, metadata map[string]string) {
+	for key, value := range metadata {
+		form.Set(fmt.Sprintf("metadata[%s]", key), value)
+	}
+}
+
+/*
+*Description*
+
+func toPaymentIntent
+
+Converts a Stripe PaymentIntent to a provider-neutral PaymentIntent.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <*PaymentIntent>
+
+		The provider-neutral intent.
+*/
+func (intent *stripePaymentIntent) toPaymentIntent() *PaymentIntent {
+	var status string
+	switch intent.Status {
+	case "requires_capture":
+		status = PaymentIntentStatusRequiresCapture
+	case "succeeded":
+		status = PaymentIntentStatusSucceeded
+	case "canceled":
+		status = PaymentIntentStatusCancelled
+	default:
+		status = PaymentIntentStatusPending
+	}
+
+	return &PaymentIntent{
+		ID:             intent.ID,
+		Amount:         intent.Amount,
+		AmountReceived: intent.AmountReceived,
+		Currency:       intent.Currency,
+		Status:         status,
+		ClientSecret:   intent.ClientSecret,
+		Metadata:       intent.Metadata,
+	}
+}
+
+/*
+*Description*
+
+func toRefund
+
+Converts a Stripe Refund to a provider-neutral Refund.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <*Refund>
+
+		The provider-neutral refund.
+*/
+func (refund *stripeRefund) toRefund() *Refund {
+	var status string
+	switch refund.Status {
+	case "succeeded":
+		status = RefundStatusSucceeded
+	case "failed", "canceled":
+		status = RefundStatusFailed
+	default:
+		status = RefundStatusPending
+	}
+
+	return &Refund{
+		ID:              refund.ID,
+		PaymentIntentID: refund.PaymentIntent,
+		Amount:          refund.Amount,
+		Status:          status,
+		Metadata:        refund.Metadata,
+	}
+}
@@ -0,0 +1,389 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page size (in points, 72 points per inch) of every page in a Document (US Letter)
+const (
+	PageWidth  float64 = 612
+	PageHeight float64 = 792
+)
+
+// Fonts available in a Document. Both are PDF standard fonts, so readers supply them and nothing is embedded.
+type Font string
+
+const (
+	FontRegular Font = "F1" // Helvetica
+	FontBold    Font = "F2" // Helvetica-Bold
+)
+
+// Minimal PDF document writer (text, lines and filled rectangles on US Letter pages)
+//
+// The output only depends on what is drawn: no creation dates or document IDs are written, so drawing the same content always
+// produces the same bytes.
+type Document struct {
+	pages []*bytes.Buffer // Content stream of each page
+}
+
+/*
+*Description*
+
+func NewDocument
+
+Creates an empty Document. Drawing starts on a new page once 'AddPage' is called.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <*Document>
+
+		The new Document.
+*/
+func NewDocument() *Document {
+	return &Document{}
+}
+
+/*
+*Description*
+
+func AddPage
+
+Adds a blank page to the end of the calling Document. Everything drawn afterwards is drawn on the new page.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	N/A (None)
+*/
+func (doc *Document) AddPage() {
+	doc.pages = append(doc.pages, &bytes.Buffer{})
+}
+
+/*
+*Description*
+
+func PageCount
+
+Returns the number of pages in the calling Document.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <int>
+
+		The number of pages.
+*/
+func (doc *Document) PageCount() int {
+	return len(doc.pages)
+}
+
+/*
+*Description*
+
+func page
+
+Returns the content stream of the current (last) page, adding the first page if there are no pages yet.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <*bytes.Buffer>
+
+		The content stream of the current page.
+*/
+func (doc *Document) page() *bytes.Buffer {
+	if len(doc.pages) == 0 {
+		doc.AddPage()
+	}
+
+	return doc.pages[len(doc.pages)-1]
+}
+
+/*
+*Description*
+
+func SetGray
+
+Sets the color used for text and filled rectangles drawn afterwards on the current page.
+
+*Parameters*
+
+	level  <float64>
+
+		The gray level, from 0 (black) to 1 (white).
+
+*Returns*
+
+	N/A (None)
+*/
+func (doc *Document) SetGray(level float64) {
+	fmt.Fprintf(doc.page(), "%s g\n", formatNumber(level))
+}
+
+/*
+*Description*
+
+func Text
+
+Draws a single line of text on the current page. Characters that can't be shown with the standard fonts are drawn as '?'.
+
+*Parameters*
+
+	x  <float64>
+
+		Distance (in points) of the start of the text from the left edge of the page.
+
+	y  <float64>
+
+		Distance (in points) of the text's baseline from the bottom of the page.
+
+	font  <Font>
+
+		The font to draw the text with.
+
+	size  <float64>
+
+		The font size (in points).
+
+	text  <string>
+
+		The text to draw.
+
+*Returns*
+
+	N/A (None)
+*/
+func (doc *Document) Text(x float64, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(doc.page(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, formatNumber(size), formatNumber(x), formatNumber(y), escapeText(encodeText(text)))
+}
+
+/*
+*Description*
+
+func TextRight
+
+Draws a single line of text on the current page that ends at the specified position (right aligned).
+
+*Parameters*
+
+	right  <float64>
+
+		Distance (in points) of the end of the text from the left edge of the page.
+
+	y  <float64>
+
+		Distance (in points) of the text's baseline from the bottom of the page.
+
+	font  <Font>
+
+		The font to draw the text with.
+
+	size  <float64>
+
+		The font size (in points).
+
+	text  <string>
+
+		The text to draw.
+
+*Returns*
+
+	N/A (None)
+*/
+func (doc *Document) TextRight(right float64, y float64, font Font, size float64, text string) {
+	doc.Text(right-TextWidth(font, size, text), y, font, size, text)
+}
+
+/*
+*Description*
+
+func Line
+
+Draws a straight line on the current page.
+
+*Parameters*
+
+	x1, y1  <float64>
+
+		Start of the line (in points from the bottom left corner of the page).
+
+	x2, y2  <float64>
+
+		End of the line (in points from the bottom left corner of the page).
+
+	width  <float64>
+
+		Width of the line (in points).
+
+*Returns*
+
+	N/A (None)
+*/
+func (doc *Document) Line(x1 float64, y1 float64, x2 float64, y2 float64, width float64) {
+	fmt.Fprintf(doc.page(), "%s w %s %s m %s %s l S\n", formatNumber(width), formatNumber(x1), formatNumber(y1), formatNumber(x2), formatNumber(y2))
+}
+
+/*
+*Description*
+
+func FillRect
+
+Draws a filled rectangle on the current page in the current color (see 'SetGray').
+
+*Parameters*
+
+	x, y  <float64>
+
+		Bottom left corner of the rectangle (in points from the bottom left corner of the page).
+
+	width, height  <float64>
+
+		Size of the rectangle (in points).
+
+*Returns*
+
+	N/A (None)
+*/
+func (doc *Document) FillRect(x float64, y float64, width float64, height float64) {
+	fmt.Fprintf(doc.page(), "%s %s %s %s re f\n", formatNumber(x), formatNumber(y), formatNumber(width), formatNumber(height))
+}
+
+/*
+*Description*
+
+func Bytes
+
+Returns the calling Document as a PDF file.
+
+Objects are written in a fixed order (catalog, page tree, fonts, then each page followed by its content stream), so the same
+content always produces the same file.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	_  <[]byte>
+
+		The contents of the PDF file.
+*/
+func (doc *Document) Bytes() []byte {
+	doc.page()
+
+	var objects []string
+	var pageRefs []string
+	const firstPageObject int = 5
+
+	for i := range doc.pages {
+		pageRefs = append(pageRefs, fmt.Sprintf("%d 0 R", firstPageObject+2*i))
+	}
+
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(doc.pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	)
+
+	for i, content := range doc.pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+				formatNumber(PageWidth), formatNumber(PageHeight), FontRegular, FontBold, firstPageObject+2*i+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+
+	output := &bytes.Buffer{}
+	output.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = output.Len()
+		fmt.Fprintf(output, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xrefOffset := output.Len()
+	fmt.Fprintf(output, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(output, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(output, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%EOF\n", len(objects)+1, xrefOffset)
+
+	return output.Bytes()
+}
+
+/*
+*Description*
+
+func formatNumber
+
+Formats a number for a PDF content stream with at most 2 decimal places (trailing zeros are removed).
+
+*Parameters*
+
+	value  <float64>
+
+		The number to format.
+
+*Returns*
+
+	_  <string>
+
+		The formatted number.
+*/
+func formatNumber(value float64) string {
+	formatted := strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+	if formatted == "-0" {
+		return "0"
+	}
+
+	return formatted
+}
+
+/*
+*Description*
+
+func escapeText
+
+Escapes the characters that have a special meaning inside a PDF string literal.
+
+*Parameters*
+
+	text  <[]byte>
+
+		The encoded text (see 'encodeText').
+
+*Returns*
+
+	_  <string>
+
+		The escaped text.
+*/
+func escapeText(text []byte) string {
+	var escaped strings.Builder
+	for _, char := range text {
+		if char == '(' || char == ')' || char == '\\' {
+			escaped.WriteByte('\\')
+		}
+		escaped.WriteByte(char)
+	}
+
+	return escaped.String()
+}
@@ -0,0 +1,117 @@
+package pdf
+
+// Widths (in thousandths of the font size) of the printable ASCII characters (' ' to '~') in each standard font, from the
+// Adobe font metrics for Helvetica and Helvetica-Bold
+var characterWidths = map[Font][95]int{
+	FontRegular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // ' ' to '/'
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // '0' to '9'
+		278, 278, 584, 584, 584, 556, 1015, // ':' to '@'
+		667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // 'A' to 'M'
+		722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // 'N' to 'Z'
+		278, 278, 278, 469, 556, 333, // '[' to '`'
+		556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // 'a' to 'm'
+		556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // 'n' to 'z'
+		334, 260, 334, 584, // '{' to '~'
+	},
+	FontBold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // ' ' to '/'
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // '0' to '9'
+		333, 333, 584, 584, 584, 611, 975, // ':' to '@'
+		722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, // 'A' to 'M'
+		722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // 'N' to 'Z'
+		333, 278, 333, 584, 556, 333, // '[' to '`'
+		556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, // 'a' to 'm'
+		611, 611, 611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, // 'n' to 'z'
+		389, 280, 389, 584, // '{' to '~'
+	},
+}
+
+// Width (in thousandths of the font size) used for characters outside printable ASCII
+const defaultCharacterWidth int = 556
+
+// Characters outside Latin-1 that the WinAnsi encoding used by the standard fonts can show
+var winAnsiCharacters = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B,
+	'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99,
+	'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+/*
+*Description*
+
+func encodeText
+
+Encodes text in the WinAnsi encoding used by the standard fonts. Control characters are replaced with spaces and characters that
+the encoding can't show are replaced with '?'.
+
+*Parameters*
+
+	text  <string>
+
+		The text to encode.
+
+*Returns*
+
+	_  <[]byte>
+
+		The encoded text.
+*/
+func encodeText(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, char := range text {
+		if winAnsiChar, ok := winAnsiCharacters[char]; ok {
+			encoded = append(encoded, winAnsiChar)
+		} else if char < ' ' || char == 0x7F {
+			encoded = append(encoded, ' ')
+		} else if char < 0x80 || (char >= 0xA0 && char <= 0xFF) {
+			encoded = append(encoded, byte(char))
+		} else {
+			encoded = append(encoded, '?')
+		}
+	}
+
+	return encoded
+}
+
+/*
+*Description*
+
+func TextWidth
+
+Returns the width (in points) of a line of text drawn with the specified font and size (see 'Document.Text').
+
+*Parameters*
+
+	font  <Font>
+
+		The font the text is drawn with.
+
+	size  <float64>
+
+		The font size (in points).
+
+	text  <string>
+
+		The text being measured.
+
+*Returns*
+
+	_  <float64>
+
+		The width of the text (in points).
+*/
+func TextWidth(font Font, size float64, text string) float64 {
+	widths := characterWidths[font]
+
+	var width int
+	for _, char := range encodeText(text) {
+		if char >= ' ' && char <= '~' {
+			width += widths[char-' ']
+		} else {
+			width += defaultCharacterWidth
+		}
+	}
+
+	return float64(width) * size / 1000
+}
@@ -0,0 +1,639 @@
+package pdf
+
+import (
+	"fmt"
+	"server/money"
+	"strings"
+	"time"
+)
+
+// Contents of an invoice document (see 'RenderInvoice'). All amounts are in the smallest unit of the invoice's currency (e.g. cents).
+type Invoice struct {
+	Number        string        // Invoice number shown in the header
+	Draft         bool          // Whether the invoice is a draft that hasn't been issued yet (titled "DRAFT INVOICE")
+	BusinessName  string        // Name of the Business that issued the invoice
+	CustomerName  string        // Name of the User billed by the invoice
+	CustomerEmail string        // Email address of the User billed by the invoice
+	IssuedAt      time.Time     // Date the invoice was issued
+	DueAt         *time.Time    // Date the invoice is due (nil to leave the due date out)
+	Status        string        // Invoice status (Unpaid, Partially Paid, Overdue, Paid, Overpaid, Void)
+	LineItems     []LineItem    // Charges listed on the invoice
+	Subtotal      int           // Total of the line items before discounts and tax
+	DiscountTotal int           // Total discount taken off the line items
+	TaxTotal      int           // Total tax on the line items
+	Total         int           // Amount billed (after discounts and tax)
+	Credits       []LedgerEntry // Credit notes issued against the invoice (oldest to newest)
+	CreditTotal   int           // Total amount credited by the credit notes
+	Transactions  []LedgerEntry // Payments and refunds recorded against the invoice (oldest to newest)
+	AmountPaid    int           // Net amount paid (payments minus refunds)
+	BalanceDue    int           // Remaining balance of the invoice
+	Currency      string        // ISO 4217 currency of every amount (defaults to 'money.DefaultCurrency')
+	Locale        string        // Locale that amounts are formatted for (defaults to 'money.DefaultLocale')
+}
+
+// A charge listed on an invoice document. All amounts are in the smallest unit of the invoice's currency.
+type LineItem struct {
+	Description string // Description of the charge
+	Quantity    uint   // Number of units charged
+	UnitPrice   int    // Price of each unit
+	Discount    int    // Amount taken off the line before tax
+	Tax         int    // Tax on the line
+	Total       int    // Amount charged for the line (after discount and tax)
+}
+
+// A payment, refund or credit note listed on an invoice document
+type LedgerEntry struct {
+	Date        time.Time // Date of the payment, refund or credit note
+	Description string    // Description of the payment, refund or credit note (e.g. "Card payment")
+	Amount      int       // Amount paid (negative for refunds) or credited
+}
+
+// Contents of a payment receipt document (see 'RenderReceipt'). All amounts are in the smallest unit of the payment's currency.
+type Receipt struct {
+	Number        string    // Receipt number shown in the header
+	BusinessName  string    // Name of the Business that issued the invoice
+	CustomerName  string    // Name of the User billed by the invoice
+	CustomerEmail string    // Email address of the User billed by the invoice
+	InvoiceNumber string    // Number of the invoice that the payment was applied to
+	PaidAt        time.Time // Date the payment was made
+	Method        string    // Payment method
+	Reference     string    // External reference for the payment
+	Amount        int       // Amount paid
+	BalanceAfter  int       // Remaining balance of the invoice right after the payment was applied
+	Currency      string    // ISO 4217 currency of the payment (defaults to 'money.DefaultCurrency')
+	Locale        string    // Locale that amounts are formatted for (defaults to 'money.DefaultLocale')
+}
+
+// Page layout (in points)
+const (
+	marginLeft    float64 = 54
+	marginRight   float64 = PageWidth - 54
+	marginBottom  float64 = 72
+	headerHeight  float64 = 72
+	lineHeight    float64 = 16
+	bodyFontSize  float64 = 10
+	titleFontSize float64 = 20
+	dateFormat    string  = "Jan 2, 2006"
+)
+
+// Right edges of the numeric columns of the line item table (the description fills the space to the left)
+const (
+	columnQuantity  float64 = 318
+	columnUnitPrice float64 = 390
+	columnDiscount  float64 = 450
+	columnTax       float64 = 504
+	columnTotal     float64 = marginRight
+)
+
+// Writes a document from top to bottom, starting new pages as they fill up
+type layout struct {
+	doc      *Document
+	title    string  // Title shown in the header of every page
+	brand    string  // Business name shown in the header of every page
+	currency string  // ISO 4217 currency of the document's amounts
+	locale   string  // Locale that the document's amounts are formatted for
+	y        float64 // Baseline of the next line of text
+}
+
+/*
+*Description*
+
+func RenderInvoice
+
+Renders an invoice as a PDF file. The header of each page shows the issuing Business, followed by the invoice details, the
+customer, the line items with their totals, any credit notes, the payments and refunds, and the balance due. Drafts are titled
+"DRAFT INVOICE" so they can't be mistaken for an issued invoice.
+
+The same invoice always renders to the same bytes.
+
+*Parameters*
+
+	invoice  <*Invoice>
+
+		The contents of the invoice.
+
+*Returns*
+
+	_  <[]byte>
+
+		The contents of the PDF file.
+*/
+func RenderInvoice(invoice *Invoice) []byte {
+	var title string = "INVOICE"
+	if invoice.Draft {
+		title = "DRAFT INVOICE"
+	}
+
+	page := newLayout(title, invoice.BusinessName, invoice.Currency, invoice.Locale)
+
+	page.field("Invoice", invoice.Number)
+	page.field("Date", invoice.IssuedAt.Format(dateFormat))
+	if invoice.DueAt != nil {
+		page.field("Due", invoice.DueAt.Format(dateFormat))
+	}
+	page.field("Status", invoice.Status)
+	page.space()
+
+	page.heading("Bill To")
+	page.text(invoice.CustomerName)
+	page.text(invoice.CustomerEmail)
+	page.space()
+
+	page.lineItemHeader()
+	for _, lineItem := range invoice.LineItems {
+		if page.ensureSpace(lineHeight) {
+			page.lineItemHeader()
+		}
+
+		page.doc.Text(marginLeft, page.y, FontRegular, bodyFontSize, truncate(lineItem.Description, FontRegular, bodyFontSize, columnQuantity-marginLeft-36))
+		page.doc.TextRight(columnQuantity, page.y, FontRegular, bodyFontSize, fmt.Sprintf("%d", lineItem.Quantity))
+		page.doc.TextRight(columnUnitPrice, page.y, FontRegular, bodyFontSize, page.money(lineItem.UnitPrice))
+		page.doc.TextRight(columnDiscount, page.y, FontRegular, bodyFontSize, page.money(-lineItem.Discount))
+		page.doc.TextRight(columnTax, page.y, FontRegular, bodyFontSize, page.money(lineItem.Tax))
+		page.doc.TextRight(columnTotal, page.y, FontRegular, bodyFontSize, page.money(lineItem.Total))
+		page.y -= lineHeight
+	}
+
+	page.rule()
+	page.ensureSpace(5 * lineHeight)
+	page.total("Subtotal", invoice.Subtotal, FontRegular)
+	page.total("Discounts", -invoice.DiscountTotal, FontRegular)
+	page.total("Tax", invoice.TaxTotal, FontRegular)
+	page.total("Total", invoice.Total, FontBold)
+	page.space()
+
+	if len(invoice.Credits) > 0 {
+		page.ensureSpace(2 * lineHeight)
+		page.heading("Credit Notes")
+		for _, entry := range invoice.Credits {
+			page.ledgerEntry(entry)
+		}
+
+		page.rule()
+		page.ensureSpace(2 * lineHeight)
+		page.total("Credited", -invoice.CreditTotal, FontRegular)
+		page.total("Amount billed", invoice.Total-invoice.CreditTotal, FontBold)
+		page.space()
+	}
+
+	page.ensureSpace(2 * lineHeight)
+	page.heading("Payments")
+	if len(invoice.Transactions) == 0 {
+		page.text("No payments have been recorded.")
+	}
+
+	for _, entry := range invoice.Transactions {
+		page.ledgerEntry(entry)
+	}
+
+	page.rule()
+	page.ensureSpace(2 * lineHeight)
+	page.total("Amount paid", invoice.AmountPaid, FontRegular)
+	page.total("Balance due", invoice.BalanceDue, FontBold)
+
+	return page.doc.Bytes()
+}
+
+/*
+*Description*
+
+func RenderReceipt
+
+Renders a payment receipt as a PDF file, showing the issuing Business, the customer, the payment and the invoice's remaining
+balance after the payment.
+
+The same receipt always renders to the same bytes.
+
+*Parameters*
+
+	receipt  <*Receipt>
+
+		The contents of the receipt.
+
+*Returns*
+
+	_  <[]byte>
+
+		The contents of the PDF file.
+*/
+func RenderReceipt(receipt *Receipt) []byte {
+	page := newLayout("RECEIPT", receipt.BusinessName, receipt.Currency, receipt.Locale)
+
+	page.field("Receipt", receipt.Number)
+	page.field("Date", receipt.PaidAt.Format(dateFormat))
+	page.field("Invoice", receipt.InvoiceNumber)
+	page.space()
+
+	page.heading("Received From")
+	page.text(receipt.CustomerName)
+	page.text(receipt.CustomerEmail)
+	page.space()
+
+	page.heading("Payment")
+	page.field("Method", receipt.Method)
+	if receipt.Reference != "" {
+		page.field("Reference", receipt.Reference)
+	}
+
+	page.rule()
+	page.total("Amount paid", receipt.Amount, FontBold)
+	page.total("Remaining balance", receipt.BalanceAfter, FontRegular)
+	page.space()
+
+	page.text("Thank you for your payment.")
+
+	return page.doc.Bytes()
+}
+
+/*
+*Description*
+
+func ledgerEntry
+
+Writes a line with the date, description and amount of a payment, refund or credit note, starting a new page if needed.
+
+*Parameters*
+
+	entry  <LedgerEntry>
+
+		The entry being written.
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) ledgerEntry(entry LedgerEntry) {
+	page.ensureSpace(lineHeight)
+	page.doc.Text(marginLeft, page.y, FontRegular, bodyFontSize, entry.Date.Format(dateFormat))
+	page.doc.Text(marginLeft+90, page.y, FontRegular, bodyFontSize, truncate(entry.Description, FontRegular, bodyFontSize, columnTotal-marginLeft-180))
+	page.doc.TextRight(columnTotal, page.y, FontRegular, bodyFontSize, page.money(entry.Amount))
+	page.y -= lineHeight
+}
+
+/*
+*Description*
+
+func FormatMoney
+
+Formats an amount in cents as US dollars with thousands separators (e.g. 123456 as "$1,234.56" and -500 as "-$5.00"). Documents
+format their amounts in their own currency and locale (see 'money.Money.Format').
+
+*Parameters*
+
+	cents  <int>
+
+		The amount (in cents).
+
+*Returns*
+
+	_  <string>
+
+		The formatted amount.
+*/
+func FormatMoney(cents int) string {
+	return money.Money{Amount: cents, Currency: money.DefaultCurrency}.Format(money.DefaultLocale)
+}
+
+/*
+*Description*
+
+func money
+
+Formats an amount in the document's currency for the document's locale.
+
+*Parameters*
+
+	amount  <int>
+
+		The amount (in the smallest unit of the document's currency).
+
+*Returns*
+
+	_  <string>
+
+		The formatted amount.
+*/
+func (page *layout) money(amount int) string {
+	return money.Money{Amount: amount, Currency: page.currency}.Format(page.locale)
+}
+
+/*
+*Description*
+
+func truncate
+
+Shortens text that is wider than the specified width, ending it with "...".
+
+*Parameters*
+
+	text  <string>
+
+		The text to shorten.
+
+	font  <Font>
+
+		The font the text is drawn with.
+
+	size  <float64>
+
+		The font size (in points).
+
+	maxWidth  <float64>
+
+		The widest the text may be (in points).
+
+*Returns*
+
+	_  <string>
+
+		The text, shortened if needed.
+*/
+func truncate(text string, font Font, size float64, maxWidth float64) string {
+	if TextWidth(font, size, text) <= maxWidth {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && TextWidth(font, size, string(runes)+"...") > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+
+	return strings.TrimRight(string(runes), " ") + "..."
+}
+
+/*
+*Description*
+
+func newLayout
+
+Creates a document with its first page started (see 'newPage').
+
+*Parameters*
+
+	title  <string>
+
+		Title shown in the header of every page (e.g. "INVOICE").
+
+	brand  <string>
+
+		Business name shown in the header of every page.
+
+	currency  <string>
+
+		ISO 4217 currency of the document's amounts ("" for 'money.DefaultCurrency').
+
+	locale  <string>
+
+		Locale that the document's amounts are formatted for ("" for 'money.DefaultLocale').
+
+*Returns*
+
+	_  <*layout>
+
+		The layout of the new document.
+*/
+func newLayout(title string, brand string, currency string, locale string) *layout {
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	if locale == "" {
+		locale = money.DefaultLocale
+	}
+
+	page := &layout{doc: NewDocument(), title: title, brand: brand, currency: currency, locale: locale}
+	page.newPage()
+	return page
+}
+
+/*
+*Description*
+
+func newPage
+
+Starts a new page with the branded header: a dark bar with the business name on the left and the document title on the right.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) newPage() {
+	page.doc.AddPage()
+
+	page.doc.SetGray(0.2)
+	page.doc.FillRect(0, PageHeight-headerHeight, PageWidth, headerHeight)
+	page.doc.SetGray(1)
+	page.doc.Text(marginLeft, PageHeight-headerHeight/2-titleFontSize/3, FontBold, titleFontSize, truncate(page.brand, FontBold, titleFontSize, 360))
+	page.doc.TextRight(marginRight, PageHeight-headerHeight/2-titleFontSize/3, FontBold, titleFontSize, page.title)
+	page.doc.SetGray(0)
+
+	page.y = PageHeight - headerHeight - 36
+}
+
+/*
+*Description*
+
+func ensureSpace
+
+Starts a new page if there isn't enough room left on the current page.
+
+*Parameters*
+
+	height  <float64>
+
+		The room needed (in points).
+
+*Returns*
+
+	_  <bool>
+
+		'true' if a new page was started, else 'false'.
+*/
+func (page *layout) ensureSpace(height float64) bool {
+	if page.y-height >= marginBottom {
+		return false
+	}
+
+	page.newPage()
+	return true
+}
+
+/*
+*Description*
+
+func text
+
+Writes a line of body text (blank text is skipped).
+
+*Parameters*
+
+	text  <string>
+
+		The text to write.
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) text(text string) {
+	if text == "" {
+		return
+	}
+
+	page.ensureSpace(lineHeight)
+	page.doc.Text(marginLeft, page.y, FontRegular, bodyFontSize, text)
+	page.y -= lineHeight
+}
+
+/*
+*Description*
+
+func heading
+
+Writes a section heading.
+
+*Parameters*
+
+	text  <string>
+
+		The heading text.
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) heading(text string) {
+	page.ensureSpace(2 * lineHeight)
+	page.doc.Text(marginLeft, page.y, FontBold, bodyFontSize+2, text)
+	page.y -= lineHeight + 2
+}
+
+/*
+*Description*
+
+func field
+
+Writes a labelled value (e.g. "Invoice:  #123").
+
+*Parameters*
+
+	label  <string>
+
+		The label.
+
+	value  <string>
+
+		The value.
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) field(label string, value string) {
+	page.ensureSpace(lineHeight)
+	page.doc.Text(marginLeft, page.y, FontBold, bodyFontSize, label+":")
+	page.doc.Text(marginLeft+90, page.y, FontRegular, bodyFontSize, value)
+	page.y -= lineHeight
+}
+
+/*
+*Description*
+
+func total
+
+Writes a labelled amount aligned with the right edge of the page.
+
+*Parameters*
+
+	label  <string>
+
+		The label.
+
+	amount  <int>
+
+		The amount (in the smallest unit of the document's currency).
+
+	font  <Font>
+
+		The font to write the label and amount with.
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) total(label string, amount int, font Font) {
+	page.ensureSpace(lineHeight)
+	page.doc.TextRight(columnTax, page.y, font, bodyFontSize, label)
+	page.doc.TextRight(columnTotal, page.y, font, bodyFontSize, page.money(amount))
+	page.y -= lineHeight
+}
+
+/*
+*Description*
+
+func lineItemHeader
+
+Writes the column headings of the line item table.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) lineItemHeader() {
+	page.ensureSpace(3 * lineHeight)
+	page.doc.Text(marginLeft, page.y, FontBold, bodyFontSize, "Description")
+	page.doc.TextRight(columnQuantity, page.y, FontBold, bodyFontSize, "Qty")
+	page.doc.TextRight(columnUnitPrice, page.y, FontBold, bodyFontSize, "Unit Price")
+	page.doc.TextRight(columnDiscount, page.y, FontBold, bodyFontSize, "Discount")
+	page.doc.TextRight(columnTax, page.y, FontBold, bodyFontSize, "Tax")
+	page.doc.TextRight(columnTotal, page.y, FontBold, bodyFontSize, "Total")
+	page.y -= lineHeight
+	page.rule()
+}
+
+/*
+*Description*
+
+func rule
+
+Draws a horizontal line across the page below the last line written.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) rule() {
+	page.doc.Line(marginLeft, page.y+lineHeight-4, marginRight, page.y+lineHeight-4, 0.5)
+	page.y -= 4
+}
+
+/*
+*Description*
+
+func space
+
+Leaves a blank line.
+
+*Parameters*
+
+	N/A (None)
+
+*Returns*
+
+	N/A (None)
+*/
+func (page *layout) space() {
+	page.y -= lineHeight / 2
+}
@@ -48,10 +48,40 @@ func TestFormatAllTables(t *testing.T) {
 	expectedRowCount := 0
 	tableList := []string{
 		"users",
+		"addresses",
 		"businesses",
 		"services",
 		"appointments",
+		"appointment_status_histories",
+		"appointment_guests",
+		"booking_rules",
+		"class_packs",
+		"class_pack_services",
+		"class_pack_purchases",
+		"class_pack_credit_transactions",
+		"membership_plans",
+		"membership_plan_services",
+		"subscriptions",
+		"subscription_invoices",
+		"subscription_visits",
 		"invoices",
+		"invoice_line_items",
+		"credit_notes",
+		"document_sequences",
+		"invoice_reminders",
+		"payments",
+		"refunds",
+		"payment_intents",
+		"payment_events",
+		"promo_codes",
+		"promo_code_services",
+		"promo_code_redemptions",
+		"tax_rates",
+		"store_credit_accounts",
+		"store_credit_transactions",
+		"gift_cards",
+		"gift_card_transactions",
+		"accounting_exports",
 	}
 
 	models.FormatAllTables(testAppDB)
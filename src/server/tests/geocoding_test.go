@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"server/geocoding"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestZipCentroidGeocode
+
+Tests the Geocode method for the ZipCentroidGeocoder of the geocoding package. Confirms that the bundled centroids load, that addresses
+are placed at their zip code's centroid (including ZIP+4 codes), that unknown and malformed zip codes are rejected, and that comma or
+tab separated datasets are loaded by their column names.
+*/
+func TestZipCentroidGeocode(t *testing.T) {
+	geocoder, err := geocoding.NewZipCentroidGeocoder("")
+	if err != nil {
+		t.Fatalf("Could not load the bundled zip centroids.  --  %s", err)
+	}
+	assert.Greater(t, geocoder.Count(), 0)
+
+	coordinates, err := geocoder.Geocode(geocoding.Location{Address1: "1 Gator Way", City: "Gainesville", State: "FL", ZipCode: "32601"})
+	assert.NoError(t, err)
+	assert.InDelta(t, 29.6479, coordinates.Latitude, 0.0001)
+	assert.InDelta(t, -82.3249, coordinates.Longitude, 0.0001)
+
+	plusFour, err := geocoder.Geocode(geocoding.Location{ZipCode: "32601-1234"})
+	assert.NoError(t, err)
+	assert.Equal(t, coordinates, plusFour, "ZIP+4 codes should be looked up by their first 5 digits.")
+
+	_, err = geocoder.Geocode(geocoding.Location{ZipCode: "00000"})
+	assert.ErrorIs(t, err, geocoding.ErrLocationNotFound)
+
+	_, err = geocoder.Geocode(geocoding.Location{ZipCode: "3260"})
+	assert.ErrorIs(t, err, geocoding.ErrInvalidLocation)
+
+	_, err = geocoder.Geocode(geocoding.Location{ZipCode: "gator"})
+	assert.ErrorIs(t, err, geocoding.ErrInvalidLocation)
+
+	// Datasets are read by column name, in either the simple or the Census Gazetteer layout
+	gazetteer := "GEOID\tALAND\tAWATER\tINTPTLAT\tINTPTLONG   \n02108\t392797\t0\t42.357603\t-71.063757\n"
+	geocoder, err = geocoding.LoadZipCentroids(strings.NewReader(gazetteer))
+	if assert.NoError(t, err) {
+		coordinates, err = geocoder.Geocode(geocoding.Location{ZipCode: "02108"})
+		assert.NoError(t, err)
+		assert.Equal(t, geocoding.Coordinates{Latitude: 42.357603, Longitude: -71.063757}, coordinates)
+	}
+
+	_, err = geocoding.LoadZipCentroids(strings.NewReader("zip,city\n32601,Gainesville\n"))
+	assert.Error(t, err, "Datasets without coordinate columns should be rejected.")
+
+	_, err = geocoding.LoadZipCentroids(strings.NewReader("zip,lat,lng\n32601,129.6,-82.3\n"))
+	assert.ErrorIs(t, err, geocoding.ErrInvalidLocation, "Out of range coordinates should be rejected.")
+
+	// Distances are great-circle distances
+	gainesville := geocoding.Coordinates{Latitude: 29.6516, Longitude: -82.3248}
+	orlando := geocoding.Coordinates{Latitude: 28.5384, Longitude: -81.3789}
+	assert.InDelta(t, 97, gainesville.DistanceMiles(orlando), 2)
+	assert.Zero(t, gainesville.DistanceMiles(gainesville))
+}
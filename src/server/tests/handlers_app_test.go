@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"server/handlers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestAPIRouteVersions
+
+Tests the routes defined by Application.InitializeRouter. Confirms that API routes are served under the version prefix without
+deprecation headers, that legacy (unversioned) routes are still served with 'Deprecation' and 'Link' headers pointing to their
+versioned route, and that unknown API routes respond with JSON errors instead of being passed on to the frontend.
+*/
+func TestAPIRouteVersions(t *testing.T) {
+	app := &handlers.Application{NGHandler: handlers.NewAngularHandler("localhost", "http://localhost:4200")}
+	app.InitializeRouter()
+
+	serve := func(method string, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		app.Router.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		return recorder
+	}
+
+	// Versioned route
+	recorder := serve("GET", handlers.APIV1Prefix+"/openapi.json")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Deprecation"))
+
+	// Legacy route
+	recorder = serve("GET", "/openapi.json")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get("Deprecation"))
+	assert.Equal(t, `<`+handlers.APIV1Prefix+`/openapi.json>; rel="successor-version"`, recorder.Header().Get("Link"))
+
+	// Unknown API routes and methods
+	recorder = serve("GET", handlers.APIV1Prefix+"/no-such-route")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	recorder = serve("GET", "/api/v2/openapi.json")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	recorder = serve("DELETE", handlers.APIV1Prefix+"/openapi.json")
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"server/handlers"
+	"server/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestCreateAppointmentInitialStatus
+
+Tests the CreateAppointment handler. Confirms that a booking is always created in its initial status, ignoring a cancelled status and
+a cancellation time sent in the request body, and that the initial status is recorded in the appointment's status history.
+*/
+func TestCreateAppointmentInitialStatus(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	app := &handlers.Application{AppDB: testAppDB, NGHandler: handlers.NewAngularHandler("localhost", "http://localhost:4200")}
+	app.InitializeRouter()
+
+	testService := &models.Service{
+		BusinessID:    128,
+		Name:          "Spin Class",
+		StartDateTime: time.Now().Add(48 * time.Hour),
+		Length:        45,
+		Capacity:      10,
+	}
+
+	_, err := testService.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Service.  --  %s", err)
+	}
+
+	var body string = fmt.Sprintf(`{"service_id":%d,"user_id":69,"status":"Cancelled By Customer","cancel_date_time":"%s"}`,
+		testService.ID, time.Now().Add(-72*time.Hour).Format(time.RFC3339))
+
+	recorder := httptest.NewRecorder()
+	app.Router.ServeHTTP(recorder, httptest.NewRequest("POST", handlers.APIV1Prefix+"/appointment", strings.NewReader(body)))
+	if !assert.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String()) {
+		return
+	}
+
+	createdAppointment := models.Appointment{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &createdAppointment)
+	if err != nil {
+		t.Fatalf("Could not decode created Appointment.  --  %s", err)
+	}
+
+	storedAppointment := models.Appointment{}
+	_, err = storedAppointment.Get(testAppDB, createdAppointment.ID)
+	if err != nil {
+		t.Fatalf("Could not retrieve created Appointment.  --  %s", err)
+	}
+
+	assert.Equal(t, models.AppointmentStatusConfirmed, storedAppointment.Status, "Bookings shouldn't be created in a later status.")
+	assert.Nil(t, storedAppointment.CancelDateTime, "Bookings shouldn't be created with a cancellation time.")
+
+	history, err := storedAppointment.GetStatusHistory(testAppDB, createdAppointment.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, models.AppointmentStatusConfirmed, history[0].ToStatus)
+	}
+}
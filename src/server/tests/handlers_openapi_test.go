@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"server/handlers"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestOpenAPISpec
+
+Tests the GetOpenAPISpec handler. Confirms that every versioned API route registered with the router is documented in the OpenAPI
+document, that every legacy route is an alias of a versioned route, that the document has no operations for routes that don't exist, and
+that every schema reference resolves to a component.
+*/
+func TestOpenAPISpec(t *testing.T) {
+	app := &handlers.Application{NGHandler: handlers.NewAngularHandler("localhost", "http://localhost:4200")}
+	app.InitializeRouter()
+
+	recorder := httptest.NewRecorder()
+	app.Router.ServeHTTP(recorder, httptest.NewRequest("GET", handlers.APIV1Prefix+"/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var document struct {
+		OpenAPI string `json:"openapi"`
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	body := recorder.Body.String()
+	assert.NoError(t, json.Unmarshal([]byte(body), &document))
+	assert.True(t, strings.HasPrefix(document.OpenAPI, "3."))
+	if assert.Len(t, document.Servers, 1) {
+		assert.Equal(t, handlers.APIV1Prefix, document.Servers[0].URL)
+	}
+
+	// Every versioned API route must be in the document (paths in the document are relative to the server URL)
+	registered := map[string]bool{}
+	legacy := map[string]bool{}
+	err := app.Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || route.GetName() == handlers.FrontendRouteName {
+			return nil
+		}
+
+		// Routes without methods serve static pages (e.g. the table of contents), not the API
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			if !strings.HasPrefix(path, handlers.APIV1Prefix+"/") {
+				legacy[method+" "+path] = true
+				continue
+			}
+
+			path := strings.TrimPrefix(path, handlers.APIV1Prefix)
+			registered[method+" "+path] = true
+			_, documented := document.Paths[path][strings.ToLower(method)]
+			assert.True(t, documented, "route %s %s is missing from the OpenAPI document", method, path)
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, registered)
+	assert.Equal(t, registered, legacy, "legacy routes must be aliases of the versioned routes")
+
+	// Every operation in the document must be a registered route
+	for path, operations := range document.Paths {
+		for method := range operations {
+			assert.True(t, registered[strings.ToUpper(method)+" "+path], "OpenAPI operation %s %s is not a registered route", method, path)
+		}
+	}
+
+	// Every schema reference must resolve
+	for _, reference := range strings.Split(body, `"$ref":"#/components/schemas/`)[1:] {
+		name, _, _ := strings.Cut(reference, `"`)
+		assert.Contains(t, document.Components.Schemas, name)
+	}
+
+	assert.Contains(t, document.Components.Schemas, "User")
+	assert.Contains(t, document.Components.Schemas, "CreateAppointmentRequest")
+}
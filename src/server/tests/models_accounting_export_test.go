@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"server/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestAccountingExport
+
+Tests the ExportAccounting method for the Business db object. Confirms that invalid account mappings are rejected, that each invoice,
+credit note, payment and refund in the period is exported as one balanced journal entry posted to the Business's accounts, that store
+credit payments are posted to the customer credit account, that the CSV and IIF files have a row per journal line, and that incremental
+exports continue from the end of the previous incremental export and leave the most recent documents to the next one.
+*/
+func TestAccountingExport(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	user := &models.User{Email: "ledger.lee@gmail.com", Password: "pw123", AccountType: "User", FirstName: "Lee", LastName: "Ledger"}
+	_, err := user.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test User.  --  %s", err)
+	}
+
+	// Account names are required and can't contain tabs or line breaks
+	invalidBusiness := &models.Business{OwnerID: 1, Name: "Tabby Tapir LLC", RevenueAccount: "Service\tIncome"}
+	_, err = invalidBusiness.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidAccountMapping)
+
+	business := &models.Business{OwnerID: 1, Name: "Balanced Bison LLC", RevenueAccount: "Service Income"}
+	_, err = business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+	assert.Equal(t, models.DefaultReceivablesAccount, business.ReceivablesAccount, "Accounts that aren't set should use their defaults.")
+
+	_, err = business.Update(testAppDB, business.ID, map[string]interface{}{"deposit_account": " "})
+	assert.ErrorIs(t, err, models.ErrInvalidAccountMapping)
+
+	invoice := &models.Invoice{UserID: user.ID, BusinessID: business.ID, OriginalBalance: 10000}
+	_, err = invoice.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Invoice.  --  %s", err)
+	}
+
+	_, err = invoice.Issue(testAppDB, invoice.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Could not issue test Invoice.  --  %s", err)
+	}
+
+	creditNote := &models.CreditNote{InvoiceID: invoice.ID, Amount: 2000, Reason: "Session ended early"}
+	_, err = creditNote.Create(testAppDB)
+	assert.NoError(t, err)
+
+	cardPayment := &models.Payment{InvoiceID: invoice.ID, Amount: 5000, Method: models.PaymentMethodCard, Reference: "ch_123"}
+	_, err = cardPayment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	refund := &models.Refund{PaymentID: cardPayment.ID, Amount: 1000, Reason: "Goodwill"}
+	_, err = refund.Create(testAppDB)
+	assert.NoError(t, err)
+
+	account := &models.StoreCreditAccount{}
+	_, _, err = account.AddGoodwillCredit(testAppDB, user.ID, business.ID, 3000, "", "Loyalty")
+	assert.NoError(t, err)
+
+	creditPayment := &models.Payment{InvoiceID: invoice.ID, Amount: 3000, Method: models.PaymentMethodStoreCredit}
+	_, err = creditPayment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	// Only the supported formats can be exported
+	now := time.Now()
+	_, _, err = business.ExportAccounting(testAppDB, business.ID, "xlsx", now.Add(-time.Hour), now.Add(time.Hour), false)
+	assert.ErrorIs(t, err, models.ErrInvalidAccountingExport)
+
+	journal, export, err := business.ExportAccounting(testAppDB, business.ID, models.AccountingFormatJSON, now.Add(-time.Hour), now.Add(time.Hour), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, export.EntryCount)
+	if assert.Len(t, journal.Entries, 5) {
+		entryTypes := map[string]models.JournalEntry{}
+		for _, entry := range journal.Entries {
+			entryTypes[entry.Type+" "+entry.Reference] = entry
+
+			debits, credits := 0, 0
+			for _, line := range entry.Lines {
+				debits += line.Debit
+				credits += line.Credit
+			}
+			assert.Equal(t, debits, credits, "Every journal entry should balance.")
+			assert.Equal(t, "Lee Ledger", entry.Customer)
+		}
+
+		issued := entryTypes[models.JournalEntryInvoice+" "+invoice.Number]
+		assert.Equal(t, []models.JournalLine{
+			{Account: models.DefaultReceivablesAccount, Debit: 10000},
+			{Account: "Service Income", Credit: 10000},
+		}, issued.Lines, "Untaxed invoices should have no sales tax line.")
+
+		credited := entryTypes[models.JournalEntryCreditNote+" "+creditNote.Number]
+		assert.Equal(t, models.JournalLine{Account: models.DefaultReceivablesAccount, Credit: 2000}, credited.Lines[0])
+
+		paid := entryTypes[models.JournalEntryPayment+" ch_123"]
+		assert.Equal(t, models.JournalLine{Account: models.DefaultDepositAccount, Debit: 5000}, paid.Lines[0])
+
+		refunded := entryTypes[models.JournalEntryRefund+" "]
+		assert.Equal(t, models.JournalLine{Account: models.DefaultDepositAccount, Credit: 1000}, refunded.Lines[0])
+
+		paidWithCredit := entryTypes[models.JournalEntryPayment+" "]
+		assert.Equal(t, models.JournalLine{Account: models.DefaultCustomerCreditAccount, Debit: 3000}, paidWithCredit.Lines[0], "Store credit payments should be posted to the customer credit account.")
+
+		assert.Equal(t, models.JournalEntryInvoice, journal.Entries[0].Type, "Entries should be ordered by date.")
+	}
+
+	file, err := journal.CSV()
+	assert.NoError(t, err)
+	assert.Equal(t, 1+10, strings.Count(string(file), "\n"), "The CSV file should have a header and a row per journal line.")
+	assert.Contains(t, string(file), "Service Income,,100.00,USD")
+
+	iif := string(journal.IIF())
+	assert.Equal(t, 5, strings.Count(iif, "\nTRNS\t"), "The IIF file should have a transaction per journal entry.")
+	assert.Equal(t, 5, strings.Count(iif, "\nSPL\t"))
+	assert.Contains(t, iif, "TRNS\tINVOICE\t")
+	assert.Contains(t, iif, "\t-100.00\t")
+
+	// Incremental exports end some time before they are made, so documents that may not have committed yet aren't skipped
+	journal, export, err = business.ExportAccounting(testAppDB, business.ID, models.AccountingFormatIIF, time.Time{}, time.Now(), true)
+	assert.NoError(t, err)
+	assert.Len(t, journal.Entries, 0, "Documents recorded less than the lag ago shouldn't be exported yet.")
+	assert.Zero(t, export.ID, "Exports with nothing to export shouldn't be recorded.")
+
+	// Incremental exports are made as if the lag had passed since the documents were recorded
+	exportTime := func() time.Time {
+		return time.Now().Add(models.AccountingExportLag)
+	}
+
+	// The first incremental export starts when the Business was created, and isn't moved by exports of a date range
+	journal, export, err = business.ExportAccounting(testAppDB, business.ID, models.AccountingFormatIIF, time.Time{}, exportTime(), true)
+	assert.NoError(t, err)
+	assert.Len(t, journal.Entries, 5)
+	assert.True(t, export.From.Equal(business.CreatedAt))
+
+	journal, secondExport, err := business.ExportAccounting(testAppDB, business.ID, models.AccountingFormatIIF, time.Time{}, exportTime(), true)
+	assert.NoError(t, err)
+	assert.Len(t, journal.Entries, 0, "Documents should only be exported once by incremental exports.")
+	assert.WithinDuration(t, export.To, secondExport.From, time.Millisecond, "Incremental exports should continue from the end of the previous one.")
+
+	lastPayment := &models.Payment{InvoiceID: invoice.ID, Amount: 1000, Method: models.PaymentMethodCash}
+	_, err = lastPayment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	journal, _, err = business.ExportAccounting(testAppDB, business.ID, models.AccountingFormatCSV, time.Time{}, exportTime(), true)
+	assert.NoError(t, err)
+	if assert.Len(t, journal.Entries, 1) {
+		assert.Equal(t, lastPayment.ID, journal.Entries[0].DocumentID)
+	}
+
+	exports, err := export.GetRecordsBySecondaryID(testAppDB, "business_id", business.ID)
+	assert.NoError(t, err)
+	assert.Len(t, exports, 4, "Every export should be recorded.")
+}
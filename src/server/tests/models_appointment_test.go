@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"errors"
 	"server/models"
 	"testing"
 	"time"
@@ -49,7 +50,7 @@ func TestCreateGetAppointment(t *testing.T) {
 
 func TestUpdateAppointment
 
-Tests the Update method for the Appointment db object. Confirmed that the updated Appointment object is returned and that the record was updated in the datbas. Throws the appropriate error if the record doesn't exist in the database
+Tests the Update method for the Appointment db object. Confirmed that the updated Appointment object is returned and that the record was updated in the datbas. Throws the appropriate error if the record doesn't exist in the database. Confirms that the cancellation time is set by the server and that the cancellation time, service and user can't be updated directly. Confirms that the cancellation time is set by the server and that the cancellation time, service and user can't be updated directly
 */
 func TestUpdateAppointment(t *testing.T) {
 	// Refresh database to control testing environment
@@ -73,8 +74,7 @@ func TestUpdateAppointment(t *testing.T) {
 	testUpdateAppointment := models.Appointment{}
 
 	updates := map[string]interface{}{
-		"active":           "false",
-		"cancel_date_time": time.Now(),
+		"status": models.AppointmentStatusCancelledByCustomer,
 	}
 
 	returnRecords, err = testUpdateAppointment.Update(testAppDB, apptID, updates)
@@ -94,6 +94,18 @@ func TestUpdateAppointment(t *testing.T) {
 
 	unequalFields, equal := models.Equal(updatedAppointment, returnedAppointment)
 	assert.Truef(t, equal, "The following fields did not match between the updated and returned object  --  %s", unequalFields)
+	assert.NotNil(t, returnedAppointment.(*models.Appointment).CancelDateTime, "Cancelled appointment should have a cancellation time.")
+
+	// The cancellation time is set by the server and cannot be backdated
+	_, err = testUpdateAppointment.Update(testAppDB, apptID, map[string]interface{}{"cancel_date_time": time.Now().Add(-72 * time.Hour)})
+	assert.ErrorIs(t, err, models.ErrRestrictedAppointmentField)
+
+	// Appointments can't be moved to another service or user without booking
+	_, err = testUpdateAppointment.Update(testAppDB, apptID, map[string]interface{}{"service_id": 421})
+	assert.ErrorIs(t, err, models.ErrRestrictedAppointmentField)
+
+	_, err = testUpdateAppointment.Update(testAppDB, apptID, map[string]interface{}{"user_id": 70})
+	assert.ErrorIs(t, err, models.ErrRestrictedAppointmentField)
 }
 
 /*
@@ -138,3 +150,365 @@ func TestDeleteAppointment(t *testing.T) {
 	}
 	assert.False(t, deletedIDExists, "Appointment record's ID still exists in the database after Delete method was executed.")
 }
+
+/*
+*Description*
+
+func TestAppointmentStatusTransitions
+
+Tests the UpdateStatus and GetStatusHistory methods for the Appointment db object. Confirms that permitted status transitions are applied and recorded in the status history, that illegal transitions are rejected, and that only seat-holding appointments count towards the Service record's appointment count.
+*/
+func TestAppointmentStatusTransitions(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	// Create Service and Appointment records
+	testService := &models.Service{
+		BusinessID:    128,
+		Name:          "Planks & Pilates",
+		StartDateTime: time.Now().Add(48 * time.Hour),
+		Length:        30,
+		Capacity:      1,
+		Price:         2000,
+	}
+
+	returnRecords, err := testService.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Service.  --  %s", err)
+	}
+	serviceID := returnRecords["service"].GetID()
+
+	testAppointment := &models.Appointment{
+		UserID:    69,
+		ServiceID: serviceID,
+		Status:    "pending",
+	}
+
+	returnRecords, err = testAppointment.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Appointment.  --  %s", err)
+	}
+	apptID := returnRecords["appointment"].GetID()
+
+	checkService := models.Service{}
+	returnRecords, _ = checkService.Get(testAppDB, serviceID)
+	assert.True(t, returnRecords["service"].(*models.Service).IsFull, "Pending appointment should hold a seat for the Service.")
+
+	// Permitted transitions
+	appt := models.Appointment{}
+	_, err = appt.UpdateStatus(testAppDB, apptID, models.AppointmentStatusConfirmed, "")
+	assert.NoError(t, err, "Pending --> Confirmed should be a permitted transition.")
+
+	returnRecords, err = appt.UpdateStatus(testAppDB, apptID, models.AppointmentStatusCancelledByBusiness, "Instructor is sick")
+	assert.NoError(t, err, "Confirmed --> Cancelled By Business should be a permitted transition.")
+	assert.NotNil(t, returnRecords["appointment"].(*models.Appointment).CancelDateTime, "Cancelled appointment should have a cancellation time.")
+
+	// Illegal transition
+	_, err = appt.UpdateStatus(testAppDB, apptID, models.AppointmentStatusCompleted, "")
+	assert.ErrorIs(t, err, models.ErrInvalidAppointmentStatusTransition, "Cancelled By Business --> Completed should be rejected.")
+
+	// Cancelled appointments release their seat
+	checkService = models.Service{}
+	returnRecords, _ = checkService.Get(testAppDB, serviceID)
+	assert.Equal(t, 0, returnRecords["service"].(*models.Service).AppointmentCt, "Cancelled appointment should not count towards the Service's appointment count.")
+
+	// Status history records the initial status and each permitted transition
+	history, err := appt.GetStatusHistory(testAppDB, apptID)
+	if err != nil {
+		t.Fatalf("Could not retrieve Appointment status history.  --  %s", err)
+	}
+
+	expectedStatuses := []string{
+		models.AppointmentStatusPending,
+		models.AppointmentStatusConfirmed,
+		models.AppointmentStatusCancelledByBusiness,
+	}
+
+	var actualStatuses []string
+	for _, statusChange := range history {
+		actualStatuses = append(actualStatuses, statusChange.ToStatus)
+	}
+	assert.Equal(t, expectedStatuses, actualStatuses, "Status history does not match the status changes that were made.")
+}
+
+/*
+*Description*
+
+func TestAppointmentStatusTransitionIsValid
+
+Tests the AppointmentStatusTransitionIsValid method to confirm that only the permitted Appointment status transitions are allowed.
+*/
+func TestAppointmentStatusTransitionIsValid(t *testing.T) {
+	testCases := []struct {
+		currentStatus string
+		newStatus     string
+		expected      bool
+	}{
+		{models.AppointmentStatusPending, models.AppointmentStatusConfirmed, true},
+		{models.AppointmentStatusPending, models.AppointmentStatusCancelledByCustomer, true},
+		{models.AppointmentStatusPending, models.AppointmentStatusCompleted, false},
+		{models.AppointmentStatusConfirmed, models.AppointmentStatusNoShow, true},
+		{models.AppointmentStatusConfirmed, models.AppointmentStatusPending, false},
+		{models.AppointmentStatusCancelledByCustomer, models.AppointmentStatusConfirmed, false},
+		{models.AppointmentStatusCompleted, models.AppointmentStatusCancelledByBusiness, false},
+		{"Active", models.AppointmentStatusConfirmed, false},
+	}
+
+	for _, testCase := range testCases {
+		actual := models.AppointmentStatusTransitionIsValid(testCase.currentStatus, testCase.newStatus)
+		assert.Equalf(t, testCase.expected, actual, "Unexpected result for transition '%s' --> '%s'", testCase.currentStatus, testCase.newStatus)
+	}
+}
+
+/*
+*Description*
+
+func TestAppointmentBookGroup
+
+Tests the Book method for group bookings and the Cancel method for the AppointmentGuest db object. Confirms that every seat beyond the booking user's own seat gets a guest record, that bookings over the Service's remaining capacity are rejected, and that cancelling a guest releases only that guest's seat.
+*/
+func TestAppointmentBookGroup(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	testService := &models.Service{
+		BusinessID:    128,
+		Name:          "Planks & Pilates",
+		StartDateTime: time.Now().Add(48 * time.Hour),
+		Length:        30,
+		Capacity:      4,
+		Price:         2000,
+	}
+
+	returnRecords, err := testService.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Service.  --  %s", err)
+	}
+	serviceID := returnRecords["service"].GetID()
+
+	// Book three seats with one named guest (the remaining guest is unnamed)
+	testAppointment := &models.Appointment{
+		UserID:    69,
+		ServiceID: serviceID,
+		Seats:     3,
+	}
+
+	guests := []models.AppointmentGuest{{Name: "Jane Doe", Email: "jane.doe@example.com"}}
+	returnRecords, err = testAppointment.Book(testAppDB, time.Now(), guests)
+	if err != nil {
+		t.Fatalf("Could not book test Appointment.  --  %s", err)
+	}
+	apptID := returnRecords["appointment"].GetID()
+
+	guest := models.AppointmentGuest{}
+	createdGuests, err := guest.GetRecordsBySecondaryID(testAppDB, "appointment_id", apptID)
+	assert.NoError(t, err)
+	if assert.Len(t, createdGuests, 2, "Expected one guest record per additional seat.") {
+		assert.Equal(t, "Jane Doe", createdGuests[0].Name)
+		assert.Equal(t, "", createdGuests[1].Name)
+	}
+
+	checkService := models.Service{}
+	returnRecords, _ = checkService.Get(testAppDB, serviceID)
+	assert.Equal(t, 3, returnRecords["service"].(*models.Service).AppointmentCt, "Each seat should count towards the Service's appointment count.")
+
+	price, err := testAppointment.GetPrice(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, 6000, price, "Appointment should be priced per seat.")
+
+	// Only one seat is left
+	overbookedAppointment := &models.Appointment{
+		UserID:    70,
+		ServiceID: serviceID,
+		Seats:     2,
+	}
+
+	_, err = overbookedAppointment.Book(testAppDB, time.Now(), nil)
+	var bookingErr *models.BookingRejectedError
+	if assert.True(t, errors.As(err, &bookingErr), "Expected a BookingRejectedError, got:  %v", err) {
+		assert.Equal(t, models.BookingRejectedInsufficientCapacity, bookingErr.Code)
+	}
+
+	// More guests than seats
+	invalidAppointment := &models.Appointment{
+		UserID:    70,
+		ServiceID: serviceID,
+		Seats:     1,
+	}
+
+	_, err = invalidAppointment.Book(testAppDB, time.Now(), guests)
+	assert.ErrorIs(t, err, models.ErrInvalidSeatCount)
+
+	// Seats cannot be changed directly
+	appt := models.Appointment{}
+	_, err = appt.Update(testAppDB, apptID, map[string]interface{}{"seats": 10})
+	assert.ErrorIs(t, err, models.ErrInvalidSeatCount)
+
+	// Cancel a single guest
+	returnRecords, err = guest.Cancel(testAppDB, apptID, createdGuests[0].ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(2), returnRecords["appointment"].(*models.Appointment).Seats)
+		assert.True(t, returnRecords["appointment_guest"].(*models.AppointmentGuest).IsCancelled())
+	}
+
+	_, err = guest.Cancel(testAppDB, apptID, createdGuests[0].ID)
+	assert.ErrorIs(t, err, models.ErrGuestAlreadyCancelled)
+
+	checkService = models.Service{}
+	returnRecords, _ = checkService.Get(testAppDB, serviceID)
+	assert.Equal(t, 2, returnRecords["service"].(*models.Service).AppointmentCt, "Cancelled guest should release their seat.")
+
+	returnRecords, _ = appt.Get(testAppDB, apptID)
+	assert.Equal(t, models.AppointmentStatusConfirmed, returnRecords["appointment"].(*models.Appointment).Status, "Cancelling a guest should not cancel the booking.")
+}
+
+/*
+*Description*
+
+func TestAppointmentBookConflicts
+
+Tests the Book method for the Appointment db object against the User's other appointments. Confirms that booking the same Service twice or a Service whose time slot overlaps an active appointment is rejected, that touching time slots and cancelled appointments do not conflict, and that a Business can allow overlapping bookings.
+*/
+func TestAppointmentBookConflicts(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	testBusiness := &models.Business{OwnerID: 1, Name: "Later Gator LLC"}
+	returnRecords, err := testBusiness.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+	businessID := returnRecords["business"].GetID()
+
+	// 09:00-10:00, 09:30-10:30 (overlaps the first), 10:00-11:00 (touches the first)
+	serviceStart := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	var serviceIDs []uint
+	for _, startOffset := range []time.Duration{0, 30 * time.Minute, 60 * time.Minute} {
+		testService := &models.Service{
+			BusinessID:    businessID,
+			Name:          "Planks & Pilates",
+			StartDateTime: serviceStart.Add(startOffset),
+			Length:        60,
+			Capacity:      20,
+		}
+
+		returnRecords, err := testService.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+		serviceIDs = append(serviceIDs, returnRecords["service"].GetID())
+	}
+	var userID uint = 69
+
+	testAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[0]}
+	returnRecords, err = testAppointment.Book(testAppDB, time.Now(), nil)
+	if err != nil {
+		t.Fatalf("Could not book test Appointment.  --  %s", err)
+	}
+	apptID := returnRecords["appointment"].GetID()
+
+	testCases := []struct {
+		description  string
+		serviceID    uint
+		expectedCode string
+	}{
+		{"Duplicate booking", serviceIDs[0], models.BookingRejectedDuplicateBooking},
+		{"Overlapping booking", serviceIDs[1], models.BookingRejectedOverlappingBooking},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			appt := &models.Appointment{UserID: userID, ServiceID: testCase.serviceID}
+			_, err := appt.Book(testAppDB, time.Now(), nil)
+
+			var bookingErr *models.BookingRejectedError
+			if assert.True(t, errors.As(err, &bookingErr), "Expected a BookingRejectedError, got:  %v", err) {
+				assert.Equal(t, testCase.expectedCode, bookingErr.Code)
+			}
+		})
+	}
+
+	touchingAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[2]}
+	_, err = touchingAppointment.Book(testAppDB, time.Now(), nil)
+	assert.NoError(t, err, "Services whose time slots only touch should not overlap.")
+
+	// Duplicates are also rejected by the database when booking rules are bypassed
+	duplicateAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[0]}
+	_, err = duplicateAppointment.Create(testAppDB)
+	assert.Error(t, err, "Expected the database to reject a duplicate appointment.")
+
+	// Business allows overlapping bookings
+	_, err = testBusiness.Update(testAppDB, businessID, map[string]interface{}{"allow_overlapping_bookings": true})
+	if err != nil {
+		t.Fatalf("Could not update test Business.  --  %s", err)
+	}
+
+	overlappingAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[1]}
+	_, err = overlappingAppointment.Book(testAppDB, time.Now(), nil)
+	assert.NoError(t, err, "Overlapping bookings should be permitted when the Business allows them.")
+
+	// Cancelled appointments can be re-booked
+	appt := models.Appointment{}
+	_, err = appt.Cancel(testAppDB, apptID)
+	if err != nil {
+		t.Fatalf("Could not cancel test Appointment.  --  %s", err)
+	}
+
+	rebookedAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[0]}
+	_, err = rebookedAppointment.Book(testAppDB, time.Now(), nil)
+	assert.NoError(t, err, "A cancelled appointment should not block re-booking the Service.")
+}
+
+/*
+*Description*
+
+func TestGetOverlappingAppointments
+
+Tests the GetOverlappingAppointments method for the Appointment db object. Confirms that only active appointments whose Service time slot overlaps are returned, and that time slots that only touch do not overlap.
+*/
+func TestGetOverlappingAppointments(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	// 09:00-10:00, 09:30-10:30, 10:00-11:00
+	serviceStart := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	var services []*models.Service
+	for _, startOffset := range []time.Duration{0, 30 * time.Minute, 60 * time.Minute} {
+		testService := &models.Service{
+			BusinessID:    128,
+			Name:          "Planks & Pilates",
+			StartDateTime: serviceStart.Add(startOffset),
+			Length:        60,
+			Capacity:      20,
+		}
+
+		_, err := testService.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+		services = append(services, testService)
+	}
+
+	var userID uint = 69
+	testAppointment := &models.Appointment{UserID: userID, ServiceID: services[0].ID}
+	_, err := testAppointment.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Appointment.  --  %s", err)
+	}
+
+	appt := models.Appointment{}
+
+	overlappingAppts, err := appt.GetOverlappingAppointments(testAppDB, userID, services[1])
+	assert.NoError(t, err)
+	if assert.Len(t, overlappingAppts, 1) {
+		assert.Equal(t, testAppointment.ID, overlappingAppts[0].ID)
+	}
+
+	overlappingAppts, err = appt.GetOverlappingAppointments(testAppDB, userID, services[2])
+	assert.NoError(t, err)
+	assert.Empty(t, overlappingAppts, "Time slots that only touch should not overlap.")
+
+	overlappingAppts, err = appt.GetOverlappingAppointments(testAppDB, userID+1, services[1])
+	assert.NoError(t, err)
+	assert.Empty(t, overlappingAppts, "Other users' appointments should not overlap.")
+}
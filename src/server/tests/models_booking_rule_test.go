@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"errors"
+	"server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestBookingRuleCheckBooking
+
+Tests the CheckBooking method for the BookingRule db object. Confirms that bookings outside of the booking window, after the minimum lead time, or over the weekly booking limit are rejected with the appropriate reason code.
+*/
+func TestBookingRuleCheckBooking(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	var businessID uint = 128
+	serviceStart := time.Date(2030, 04, 17, 17, 30, 00, 00, time.UTC) // Wednesday
+
+	// Create two Services in the same week and one Appointment for the first Service
+	var serviceIDs []uint
+	for _, startDateTime := range []time.Time{serviceStart, serviceStart.AddDate(0, 0, 1)} {
+		testService := &models.Service{
+			BusinessID:    businessID,
+			Name:          "Planks & Pilates",
+			StartDateTime: startDateTime,
+			Length:        30,
+			Capacity:      20,
+			Price:         2000,
+		}
+
+		returnRecords, err := testService.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+		serviceIDs = append(serviceIDs, returnRecords["service"].GetID())
+	}
+
+	testAppointment := &models.Appointment{
+		UserID:    69,
+		ServiceID: serviceIDs[0],
+	}
+
+	_, err := testAppointment.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Appointment.  --  %s", err)
+	}
+
+	rule := &models.BookingRule{
+		BusinessID:         businessID,
+		BookingWindowDays:  14,
+		MinLeadTimeMinutes: 120,
+		MaxBookingsPerWeek: 1,
+	}
+
+	service := &models.Service{}
+	_, err = service.Get(testAppDB, serviceIDs[1])
+	if err != nil {
+		t.Fatalf("Could not retrieve test Service.  --  %s", err)
+	}
+
+	testCases := []struct {
+		description  string
+		userID       uint
+		bookingTime  time.Time
+		expectedCode string
+	}{
+		{"Booking before window opens", 70, service.StartDateTime.AddDate(0, 0, -15), models.BookingRejectedWindowNotOpen},
+		{"Booking after lead time cutoff", 70, service.StartDateTime.Add(-time.Hour), models.BookingRejectedWindowClosed},
+		{"Booking after service started", 70, service.StartDateTime.Add(time.Minute), models.BookingRejectedServiceStarted},
+		{"Booking over weekly limit", 69, service.StartDateTime.AddDate(0, 0, -2), models.BookingRejectedWeeklyLimitReached},
+		{"Permitted booking", 70, service.StartDateTime.AddDate(0, 0, -2), ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			err := rule.CheckBooking(testAppDB, service, testCase.userID, testCase.bookingTime)
+
+			if testCase.expectedCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var bookingErr *models.BookingRejectedError
+			if assert.True(t, errors.As(err, &bookingErr), "Expected a BookingRejectedError, got:  %v", err) {
+				assert.Equal(t, testCase.expectedCode, bookingErr.Code)
+			}
+		})
+	}
+}
+
+/*
+*Description*
+
+func TestBookingRuleGetEffectiveRule
+
+Tests the GetEffectiveRule and Upsert methods for the BookingRule db object. Confirms that a Service's own rule takes precedence over the Business default rule and that upserting a rule replaces the existing rule instead of creating a duplicate.
+*/
+func TestBookingRuleGetEffectiveRule(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	var businessID uint = 128
+	service := &models.Service{
+		BusinessID:    businessID,
+		Name:          "Planks & Pilates",
+		StartDateTime: time.Now().AddDate(0, 0, 7),
+		Length:        30,
+		Capacity:      20,
+	}
+
+	_, err := service.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Service.  --  %s", err)
+	}
+
+	rule := models.BookingRule{}
+
+	// No rules defined
+	effectiveRule, err := rule.GetEffectiveRule(testAppDB, service)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), effectiveRule.ID, "Expected an unsaved rule with no limits when no rules are defined.")
+
+	// Business default rule (upserted twice)
+	for _, windowDays := range []uint{7, 14} {
+		businessRule := &models.BookingRule{BusinessID: businessID, BookingWindowDays: windowDays}
+		_, err = businessRule.Upsert(testAppDB)
+		assert.NoError(t, err)
+	}
+
+	effectiveRule, err = rule.GetEffectiveRule(testAppDB, service)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(14), effectiveRule.BookingWindowDays, "Expected the Business default rule to apply.")
+
+	var ruleCt int64
+	testAppDB.Model(&models.BookingRule{}).Where("business_id = ?", businessID).Count(&ruleCt)
+	assert.Equal(t, int64(1), ruleCt, "Upsert should replace the existing Business rule.")
+
+	// Service rule takes precedence
+	serviceID := service.ID
+	serviceRule := &models.BookingRule{BusinessID: businessID, ServiceID: &serviceID, BookingWindowDays: 3}
+	_, err = serviceRule.Upsert(testAppDB)
+	assert.NoError(t, err)
+
+	effectiveRule, err = rule.GetEffectiveRule(testAppDB, service)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), effectiveRule.BookingWindowDays, "Expected the Service rule to apply.")
+}
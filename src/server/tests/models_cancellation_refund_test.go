@@ -0,0 +1,276 @@
+package tests
+
+import (
+	"server/models"
+	"server/payments"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestCancellationRefunds
+
+Tests the RefundCancellation and RetryCancellationRefunds methods for the Appointment db object. Confirms that a timely cancellation refunds everything that was paid and leaves the invoice Refunded, that a late cancellation refunds everything paid beyond the cancellation fee (most recent payment first, through the payment provider for online payments) and leaves the invoice Paid, that nothing is refunded when the fee is at least what was paid, that cancellations by the business are refunded in full, that refunded invoices can't be paid again, that concurrent refunds of the same cancellation only refund what was paid once, that appointments that haven't been cancelled aren't refunded, and that refunds that failed are retried until they succeed (while overpaid invoices that no refund was started for are left alone).
+*/
+func TestCancellationRefunds(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	fake := payments.NewFakeServer()
+	defer fake.Close()
+	provider := fake.Provider()
+
+	var userID uint = 69
+	now := time.Now()
+
+	createService := func(name string, minCancelNoticeMinutes uint) *models.Service {
+		business := &models.Business{OwnerID: 1, Name: name, BillingPolicy: models.BillingPolicyAtBooking}
+		_, err := business.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Business.  --  %s", err)
+		}
+
+		if minCancelNoticeMinutes > 0 {
+			rule := &models.BookingRule{BusinessID: business.ID, MinCancelNoticeMinutes: minCancelNoticeMinutes}
+			_, err = rule.Upsert(testAppDB)
+			if err != nil {
+				t.Fatalf("Could not create test BookingRule.  --  %s", err)
+			}
+		}
+
+		service := &models.Service{
+			BusinessID:    business.ID,
+			Name:          "Pilates",
+			StartDateTime: now.Add(48 * time.Hour),
+			Length:        60,
+			Capacity:      20,
+			Price:         2500,
+			CancelFee:     1000,
+		}
+
+		_, err = service.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+
+		return service
+	}
+
+	book := func(service *models.Service) (*models.Appointment, *models.Invoice) {
+		appt := &models.Appointment{UserID: userID, ServiceID: service.ID, Seats: 1}
+		_, err := appt.Book(testAppDB, now, nil)
+		if err != nil {
+			t.Fatalf("Could not book test Appointment.  --  %s", err)
+		}
+
+		invoice, err := appt.GetInvoice(testAppDB)
+		if err != nil || invoice == nil {
+			t.Fatalf("Could not get test Invoice.  --  %v", err)
+		}
+
+		return appt, invoice
+	}
+
+	payCash := func(invoice *models.Invoice, amount int) {
+		payment := &models.Payment{InvoiceID: invoice.ID, Amount: amount, Method: models.PaymentMethodCash}
+		_, err := payment.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Payment.  --  %s", err)
+		}
+	}
+
+	flexibleService := createService("Flexible Gator LLC", 0)
+	strictService := createService("Strict Gator LLC", 7*24*60)
+
+	// A timely cancellation refunds everything that was paid
+	appt, invoice := book(flexibleService)
+	payCash(invoice, 2500)
+
+	_, err := appt.RefundCancellation(testAppDB, provider, appt.ID)
+	assert.ErrorIs(t, err, models.ErrInvalidRefund, "Appointments that haven't been cancelled shouldn't be refunded.")
+
+	_, err = appt.Cancel(testAppDB, appt.ID)
+	assert.NoError(t, err)
+
+	breakdown, err := appt.RefundCancellation(testAppDB, provider, appt.ID)
+	assert.NoError(t, err)
+	assert.True(t, breakdown.Timely)
+	assert.Equal(t, models.RefundPolicyFull, breakdown.Policy)
+	assert.Equal(t, 2500, breakdown.AmountPaid)
+	assert.Equal(t, 0, breakdown.CancelFee)
+	assert.Equal(t, 2500, breakdown.AmountRefunded)
+	assert.Equal(t, 0, breakdown.AmountRetained)
+	assert.Equal(t, models.InvoiceStatusRefunded, breakdown.InvoiceStatus)
+	assert.Equal(t, 0, breakdown.RemainingBalance)
+	if assert.Len(t, breakdown.Refunds, 1) {
+		assert.Equal(t, 2500, breakdown.Refunds[0].Amount)
+		assert.Equal(t, "Cancellation: Pilates", breakdown.Refunds[0].Reason)
+	}
+
+	// Refunded invoices can't be paid again
+	payment := &models.Payment{InvoiceID: invoice.ID, Amount: 100, Method: models.PaymentMethodCash}
+	_, err = payment.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPayment)
+
+	// A late cancellation refunds everything beyond the cancellation fee, most recent payment (online) first
+	appt, invoice = book(strictService)
+	payCash(invoice, 1000)
+
+	intent := &models.PaymentIntent{}
+	_, err = intent.Start(testAppDB, provider, invoice.ID, 0, false)
+	if err != nil {
+		t.Fatalf("Could not start test PaymentIntent.  --  %s", err)
+	}
+
+	err = fake.Pay(intent.ProviderID)
+	if err != nil {
+		t.Fatalf("Could not pay test PaymentIntent.  --  %s", err)
+	}
+	deliverWebhooks(t, fake, provider, 0)
+
+	_, err = intent.Get(testAppDB, intent.ID)
+	if err != nil || intent.PaymentID == nil {
+		t.Fatalf("Test PaymentIntent was not paid.  --  %v", err)
+	}
+
+	_, err = appt.Cancel(testAppDB, appt.ID)
+	assert.NoError(t, err)
+
+	delivered := len(fake.Webhooks())
+	breakdown, err = appt.RefundCancellation(testAppDB, provider, appt.ID)
+	assert.NoError(t, err)
+	assert.False(t, breakdown.Timely)
+	assert.Equal(t, models.RefundPolicyPartial, breakdown.Policy)
+	assert.Equal(t, 2500, breakdown.AmountPaid)
+	assert.Equal(t, 1000, breakdown.CancelFee)
+	assert.Equal(t, 1000, breakdown.AmountRetained)
+	assert.Equal(t, 1500, breakdown.AmountRefunded)
+	assert.Equal(t, models.InvoiceStatusPaid, breakdown.InvoiceStatus)
+	if assert.Len(t, breakdown.Refunds, 1) {
+		assert.Equal(t, *intent.PaymentID, breakdown.Refunds[0].PaymentID, "The online payment should be refunded through the provider.")
+		assert.NotEmpty(t, breakdown.Refunds[0].Reference)
+		assert.Equal(t, "Late cancellation: Pilates (cancellation fee retained)", breakdown.Refunds[0].Reason)
+	}
+
+	deliverWebhooks(t, fake, provider, delivered)
+	refunds, err := (&models.Refund{}).GetRecordsBySecondaryID(testAppDB, "invoice_id", invoice.ID)
+	assert.NoError(t, err)
+	assert.Len(t, refunds, 1, "The refund's webhook should not record a second refund.")
+
+	// Nothing is refunded when the cancellation fee is at least what was paid
+	appt, invoice = book(strictService)
+	payCash(invoice, 800)
+	_, err = appt.Cancel(testAppDB, appt.ID)
+	assert.NoError(t, err)
+
+	breakdown, err = appt.RefundCancellation(testAppDB, provider, appt.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.RefundPolicyNone, breakdown.Policy)
+	assert.Equal(t, 800, breakdown.AmountRetained)
+	assert.Equal(t, 0, breakdown.AmountRefunded)
+	assert.Empty(t, breakdown.Refunds)
+	assert.Equal(t, 200, breakdown.RemainingBalance, "The rest of the cancellation fee should still be owed.")
+
+	// Cancellations by the business are refunded in full, however late they are
+	appt, invoice = book(strictService)
+	payCash(invoice, 2500)
+	_, err = appt.UpdateStatus(testAppDB, appt.ID, models.AppointmentStatusCancelledByBusiness, "Instructor is sick")
+	assert.NoError(t, err)
+
+	breakdown, err = appt.RefundCancellation(testAppDB, provider, appt.ID)
+	assert.NoError(t, err)
+	assert.True(t, breakdown.Timely)
+	assert.Equal(t, models.RefundPolicyFull, breakdown.Policy)
+	assert.Equal(t, 2500, breakdown.AmountRefunded)
+	assert.Equal(t, models.InvoiceStatusRefunded, breakdown.InvoiceStatus)
+
+	// Concurrent refunds of the same cancellation only refund what was paid once
+	appt, invoice = book(flexibleService)
+	payCash(invoice, 2500)
+	_, err = appt.Cancel(testAppDB, appt.ID)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	concurrentBreakdowns := make([]*models.CancellationRefund, 2)
+	concurrentErrs := make([]error, 2)
+	for i := range concurrentBreakdowns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			concurrentBreakdowns[i], concurrentErrs[i] = (&models.Appointment{}).RefundCancellation(testAppDB, provider, appt.ID)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.NoError(t, concurrentErrs[0])
+	assert.NoError(t, concurrentErrs[1])
+	assert.Equal(t, 2500, concurrentBreakdowns[0].AmountRefunded+concurrentBreakdowns[1].AmountRefunded, "What was paid should only be refunded once.")
+
+	refunds, err = (&models.Refund{}).GetRecordsBySecondaryID(testAppDB, "invoice_id", invoice.ID)
+	assert.NoError(t, err)
+	var totalRefunded int
+	for _, refund := range refunds {
+		totalRefunded += refund.Amount
+	}
+	assert.Equal(t, 2500, totalRefunded)
+
+	// Refunds that fail are retried until they succeed
+	appt, invoice = book(flexibleService)
+	delivered = len(fake.Webhooks())
+	intent = &models.PaymentIntent{}
+	_, err = intent.Start(testAppDB, provider, invoice.ID, 0, false)
+	if err != nil {
+		t.Fatalf("Could not start test PaymentIntent.  --  %s", err)
+	}
+
+	err = fake.Pay(intent.ProviderID)
+	if err != nil {
+		t.Fatalf("Could not pay test PaymentIntent.  --  %s", err)
+	}
+	deliverWebhooks(t, fake, provider, delivered)
+
+	_, err = appt.Cancel(testAppDB, appt.ID)
+	assert.NoError(t, err)
+
+	_, err = appt.RefundCancellation(testAppDB, nil, appt.ID)
+	assert.ErrorIs(t, err, models.ErrInvalidRefund, "Online payments can't be refunded without the payment provider.")
+
+	// Overpaid invoices that no cancellation refund was started for are left alone
+	legacyAppointment := &models.Appointment{UserID: userID, ServiceID: flexibleService.ID, Status: models.AppointmentStatusCancelledByCustomer}
+	_, err = legacyAppointment.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Appointment.  --  %s", err)
+	}
+
+	legacyInvoice := &models.Invoice{UserID: userID, BusinessID: flexibleService.BusinessID, AppointmentID: legacyAppointment.ID, OriginalBalance: 1000}
+	_, err = legacyInvoice.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Invoice.  --  %s", err)
+	}
+
+	_, err = legacyInvoice.Issue(testAppDB, legacyInvoice.ID, now)
+	if err != nil {
+		t.Fatalf("Could not issue test Invoice.  --  %s", err)
+	}
+	payCash(legacyInvoice, 1500)
+
+	delivered = len(fake.Webhooks())
+	breakdowns, err := appt.RetryCancellationRefunds(testAppDB, provider)
+	assert.NoError(t, err)
+	if assert.Len(t, breakdowns, 1) {
+		assert.Equal(t, appt.ID, breakdowns[0].AppointmentID)
+		assert.Empty(t, breakdowns[0].Error)
+		assert.Equal(t, 2500, breakdowns[0].AmountRefunded+breakdowns[0].AmountPending)
+	}
+
+	deliverWebhooks(t, fake, provider, delivered)
+
+	breakdowns, err = appt.RetryCancellationRefunds(testAppDB, provider)
+	assert.NoError(t, err)
+	assert.Empty(t, breakdowns, "Refunded cancellations shouldn't be retried.")
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestClassPackCredits
+
+Tests the Purchase, UseCredits, RefundCredits, and GetCreditBalance methods for the ClassPackPurchase db object. Confirms that purchasing a class pack creates an Invoice, that booking an eligible Service uses a credit, that a timely cancellation refunds the credit while a late cancellation does not, and that ineligible Services are not paid for with credits.
+*/
+func TestClassPackCredits(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	var businessID uint = 128
+	var userID uint = 69
+	now := time.Now()
+
+	var serviceIDs []uint
+	for _, serviceName := range []string{"Tai Chi", "Tai Chi", "Pottery"} {
+		testService := &models.Service{
+			BusinessID:    businessID,
+			Name:          serviceName,
+			StartDateTime: now.Add(time.Duration(len(serviceIDs)+2) * 24 * time.Hour),
+			Length:        60,
+			Capacity:      20,
+			Price:         2000,
+		}
+
+		returnRecords, err := testService.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+		serviceIDs = append(serviceIDs, returnRecords["service"].GetID())
+	}
+
+	pack := &models.ClassPack{
+		BusinessID: businessID,
+		Name:       "10-class pack",
+		Credits:    10,
+		ExpiryDays: 90,
+		Price:      15000,
+	}
+
+	_, err := pack.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test ClassPack.  --  %s", err)
+	}
+
+	err = pack.SetEligibleServices(testAppDB, pack.ID, []string{"Tai Chi"})
+	if err != nil {
+		t.Fatalf("Could not set eligible services for test ClassPack.  --  %s", err)
+	}
+
+	// Purchase
+	purchase := models.ClassPackPurchase{}
+	returnRecords, err := purchase.Purchase(testAppDB, pack.ID, userID, now)
+	if err != nil {
+		t.Fatalf("Could not purchase test ClassPack.  --  %s", err)
+	}
+
+	invoice := returnRecords["invoice"].(*models.Invoice)
+	assert.Equal(t, 15000, invoice.OriginalBalance, "Class pack Invoice should bill the class pack price.")
+	assert.Equal(t, userID, invoice.UserID)
+	assert.Equal(t, invoice.ID, purchase.InvoiceID)
+	assert.Equal(t, uint(10), purchase.CreditsRemaining)
+
+	// Booking an eligible Service uses a credit
+	testAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[0]}
+	returnRecords, err = testAppointment.Book(testAppDB, now, nil)
+	if err != nil {
+		t.Fatalf("Could not book test Appointment.  --  %s", err)
+	}
+	apptID := returnRecords["appointment"].GetID()
+
+	creditsAvailable, _, _, err := purchase.GetCreditBalance(testAppDB, userID, now)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), creditsAvailable, "Booking an eligible Service should use a credit.")
+
+	// Booking an ineligible Service does not use a credit
+	ineligibleAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[2]}
+	_, err = ineligibleAppointment.Book(testAppDB, now, nil)
+	assert.NoError(t, err)
+
+	creditsAvailable, _, _, _ = purchase.GetCreditBalance(testAppDB, userID, now)
+	assert.Equal(t, uint(9), creditsAvailable, "Booking an ineligible Service should not use a credit.")
+
+	// Timely cancellation refunds the credit
+	appt := models.Appointment{}
+	_, err = appt.Cancel(testAppDB, apptID)
+	assert.NoError(t, err)
+
+	creditsAvailable, _, _, _ = purchase.GetCreditBalance(testAppDB, userID, now)
+	assert.Equal(t, uint(10), creditsAvailable, "Timely cancellation should refund the credit.")
+
+	// Late cancellation (inside the cancellation notice window) forfeits the credit
+	rule := &models.BookingRule{BusinessID: businessID, MinCancelNoticeMinutes: 7 * 24 * 60}
+	_, err = rule.Upsert(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test BookingRule.  --  %s", err)
+	}
+
+	lateAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[1]}
+	returnRecords, err = lateAppointment.Book(testAppDB, now, nil)
+	if err != nil {
+		t.Fatalf("Could not book test Appointment.  --  %s", err)
+	}
+
+	_, err = appt.Cancel(testAppDB, returnRecords["appointment"].GetID())
+	assert.NoError(t, err)
+
+	creditsAvailable, purchases, history, err := purchase.GetCreditBalance(testAppDB, userID, now)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), creditsAvailable, "Late cancellation should not refund the credit.")
+	assert.Len(t, purchases, 1)
+
+	var historyTypes []string
+	for _, transaction := range history {
+		historyTypes = append(historyTypes, transaction.Type)
+	}
+	expectedTypes := []string{
+		models.ClassPackCreditPurchased,
+		models.ClassPackCreditUsed,
+		models.ClassPackCreditRefunded,
+		models.ClassPackCreditUsed,
+	}
+	assert.Equal(t, expectedTypes, historyTypes)
+
+	// Expired credits are not available
+	creditsAvailable, _, _, _ = purchase.GetCreditBalance(testAppDB, userID, now.AddDate(0, 0, 91))
+	assert.Equal(t, uint(0), creditsAvailable, "Expired credits should not be available.")
+}
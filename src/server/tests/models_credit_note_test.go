@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestInvoiceNumbering
+
+Tests the Issue method for the Invoice db object. Confirms that invoices are numbered in a gapless sequence for each Business using the Business's prefix, that drafts aren't numbered, that an invoice can only be issued once, that a failed issue doesn't use up a number, and that invalid prefixes are rejected.
+*/
+func TestInvoiceNumbering(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	business := &models.Business{OwnerID: 1, Name: "Numbered Gator LLC", InvoicePrefix: "NG-"}
+	_, err := business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	otherBusiness := &models.Business{OwnerID: 1, Name: "Other Gator LLC"}
+	_, err = otherBusiness.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+	assert.Equal(t, models.DefaultInvoicePrefix, otherBusiness.InvoicePrefix, "Invoice prefixes should default to INV-.")
+
+	invalidBusiness := &models.Business{OwnerID: 1, Name: "Invalid Gator LLC", InvoicePrefix: "NO SPACES"}
+	_, err = invalidBusiness.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidNumberPrefix)
+
+	issue := func(businessID uint) *models.Invoice {
+		invoice := &models.Invoice{UserID: 69, BusinessID: businessID, OriginalBalance: 1000}
+		_, err := invoice.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Invoice.  --  %s", err)
+		}
+
+		assert.Equal(t, models.InvoiceStateDraft, invoice.State)
+		assert.Empty(t, invoice.Number, "Drafts should not be numbered.")
+
+		returnRecords, err := invoice.Issue(testAppDB, invoice.ID, time.Now())
+		assert.NoError(t, err)
+		return returnRecords["invoice"].(*models.Invoice)
+	}
+
+	first := issue(business.ID)
+	assert.Equal(t, "NG-000001", first.Number)
+	assert.Equal(t, models.InvoiceStateIssued, first.State)
+	assert.NotNil(t, first.IssuedAt)
+
+	// Each Business has its own sequence
+	assert.Equal(t, "INV-000001", issue(otherBusiness.ID).Number)
+
+	// Invoices can only be issued once
+	_, err = first.Issue(testAppDB, first.ID, time.Now())
+	assert.ErrorIs(t, err, models.ErrInvoiceIssued)
+
+	// Void invoices can't be issued, and don't use up a number
+	voidInvoice := &models.Invoice{UserID: 69, BusinessID: business.ID, OriginalBalance: 1000}
+	_, err = voidInvoice.Create(testAppDB)
+	assert.NoError(t, err)
+	_, err = voidInvoice.Update(testAppDB, voidInvoice.ID, map[string]interface{}{"original_balance": 0})
+	assert.NoError(t, err)
+	_, err = voidInvoice.Issue(testAppDB, voidInvoice.ID, time.Now())
+	assert.ErrorIs(t, err, models.ErrInvalidInvoice)
+
+	assert.Equal(t, "NG-000002", issue(business.ID).Number, "Invoice numbers should have no gaps.")
+
+	// Changing the prefix applies to invoices issued from then on
+	_, err = business.Update(testAppDB, business.ID, map[string]interface{}{"invoice_prefix": "NG/2021/"})
+	assert.NoError(t, err)
+	assert.Equal(t, "NG/2021/000003", issue(business.ID).Number)
+}
+
+/*
+*Description*
+
+func TestCreditNotes
+
+Tests the Create method for the CreditNote db object. Confirms that credit notes are numbered in their own sequence, that they reduce the amount billed by an issued Invoice without changing it, that they can't credit more than is billed, that drafts can't be credited, that crediting an unpaid invoice in full voids it, and that credit notes can't be modified or deleted.
+*/
+func TestCreditNotes(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	business := &models.Business{OwnerID: 1, Name: "Credited Gator LLC"}
+	_, err := business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	invoice := &models.Invoice{UserID: 69, BusinessID: business.ID}
+	_, err = invoice.CreateWithLineItems(testAppDB, []models.InvoiceLineItem{
+		{Description: "Yoga", Quantity: 2, UnitPrice: 2500, TaxRate: 1000},
+	})
+	if err != nil {
+		t.Fatalf("Could not create test Invoice.  --  %s", err)
+	}
+
+	// Drafts are changed directly instead of being credited
+	draftCredit := &models.CreditNote{InvoiceID: invoice.ID, Amount: 1000, Reason: "Too early"}
+	_, err = draftCredit.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidCreditNote)
+
+	_, err = invoice.Issue(testAppDB, invoice.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Could not issue test Invoice.  --  %s", err)
+	}
+
+	payment := &models.Payment{InvoiceID: invoice.ID, Amount: 2000, Method: models.PaymentMethodCash}
+	_, err = payment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	// Credit notes need a reason
+	noReason := &models.CreditNote{InvoiceID: invoice.ID, Amount: 1000}
+	_, err = noReason.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidCreditNote)
+
+	creditNote := &models.CreditNote{InvoiceID: invoice.ID, Amount: 1100, Reason: "One class was missed"}
+	returnRecords, err := creditNote.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test CreditNote.  --  %s", err)
+	}
+
+	creditedInvoice := returnRecords["invoice"].(*models.Invoice)
+	assert.Equal(t, "CN-000001", creditNote.Number)
+	assert.Equal(t, 100, creditNote.Tax, "Tax should be credited in proportion to the amount credited.")
+	assert.Equal(t, 5500, creditedInvoice.OriginalBalance, "Issued invoices should not be changed.")
+	assert.Equal(t, 1100, creditedInvoice.CreditTotal)
+	assert.Equal(t, 2400, creditedInvoice.RemainingBalance)
+	assert.Equal(t, models.InvoiceStatusPartiallyPaid, creditedInvoice.Status)
+
+	// Credit notes can't credit more than is billed
+	tooMuch := &models.CreditNote{InvoiceID: invoice.ID, Amount: 4401, Reason: "Too much"}
+	_, err = tooMuch.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidCreditNote)
+
+	// The amount defaults to everything that is still billed, and a paid invoice is left overpaid
+	rest := &models.CreditNote{InvoiceID: invoice.ID, Reason: "Course cancelled"}
+	returnRecords, err = rest.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, 4400, rest.Amount)
+	assert.Equal(t, "CN-000002", rest.Number)
+	assert.Equal(t, -2000, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+	assert.Equal(t, models.InvoiceStatusOverpaid, returnRecords["invoice"].(*models.Invoice).Status)
+
+	creditNotes, err := creditNote.GetRecordsBySecondaryID(testAppDB, "invoice_id", invoice.ID)
+	assert.NoError(t, err)
+	assert.Len(t, creditNotes, 2)
+
+	// Crediting an unpaid invoice in full voids it
+	unpaidInvoice := &models.Invoice{UserID: 69, BusinessID: business.ID, OriginalBalance: 3000}
+	_, err = unpaidInvoice.Create(testAppDB)
+	assert.NoError(t, err)
+	_, err = unpaidInvoice.Issue(testAppDB, unpaidInvoice.ID, time.Now())
+	assert.NoError(t, err)
+
+	fullCredit := &models.CreditNote{InvoiceID: unpaidInvoice.ID, Reason: "Billed in error"}
+	returnRecords, err = fullCredit.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InvoiceStatusVoid, returnRecords["invoice"].(*models.Invoice).Status)
+	assert.Equal(t, 0, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+
+	// Credit notes are immutable
+	_, err = creditNote.Update(testAppDB, creditNote.ID, map[string]interface{}{"amount": 1})
+	assert.ErrorIs(t, err, models.ErrInvalidCreditNote)
+
+	_, err = creditNote.Delete(testAppDB, creditNote.ID)
+	assert.ErrorIs(t, err, models.ErrInvalidCreditNote)
+}
@@ -0,0 +1,325 @@
+package tests
+
+import (
+	"errors"
+	"server/models"
+	"server/notifications"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestInvoiceDueDates
+
+Tests the due dates of Invoice db objects. Confirms that an issued invoice is due according to its Business's payment terms unless a due date was set on the draft, that an invoice can't be due before it is issued, that the payment terms can't be updated directly, that the dunning job moves invoices that are still owed after their due date to Overdue, and that an overdue invoice stays Overdue until it is paid in full.
+*/
+func TestInvoiceDueDates(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	business := &models.Business{OwnerID: 1, Name: "Punctual Gator LLC", PaymentTermsDays: 14}
+	_, err := business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	// Payment terms are limited to a year
+	_, err = business.Update(testAppDB, business.ID, map[string]interface{}{"payment_terms_days": 400})
+	assert.ErrorIs(t, err, models.ErrInvalidPaymentTerms)
+
+	// Invoices are due according to the Business's payment terms
+	issuedAt := time.Now()
+	invoice := &models.Invoice{UserID: 69, BusinessID: business.ID, OriginalBalance: 5000}
+	_, err = invoice.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Invoice.  --  %s", err)
+	}
+
+	returnRecords, err := invoice.Issue(testAppDB, invoice.ID, issuedAt)
+	assert.NoError(t, err)
+	issuedInvoice := returnRecords["invoice"].(*models.Invoice)
+	assert.Equal(t, uint(14), issuedInvoice.PaymentTermsDays)
+	if assert.NotNil(t, issuedInvoice.DueAt) {
+		assert.WithinDuration(t, issuedAt.AddDate(0, 0, 14), *issuedInvoice.DueAt, time.Second)
+	}
+	assert.Equal(t, models.InvoiceStatusUnpaid, issuedInvoice.Status)
+
+	// Payment terms are set when the invoice is issued
+	_, err = invoice.Update(testAppDB, invoice.ID, map[string]interface{}{"payment_terms_days": 60})
+	assert.ErrorIs(t, err, models.ErrInvalidInvoice)
+
+	// Drafts can set their own due date, but not one before they are issued
+	earlyDueAt := issuedAt.AddDate(0, 0, -1)
+	earlyInvoice := &models.Invoice{UserID: 69, BusinessID: business.ID, OriginalBalance: 5000, DueAt: &earlyDueAt}
+	_, err = earlyInvoice.Create(testAppDB)
+	assert.NoError(t, err)
+	_, err = earlyInvoice.Issue(testAppDB, earlyInvoice.ID, issuedAt)
+	assert.ErrorIs(t, err, models.ErrInvalidInvoice)
+
+	lateDueAt := issuedAt.AddDate(0, 0, 45)
+	_, err = earlyInvoice.Update(testAppDB, earlyInvoice.ID, map[string]interface{}{"due_at": lateDueAt})
+	assert.NoError(t, err)
+	returnRecords, err = earlyInvoice.Issue(testAppDB, earlyInvoice.ID, issuedAt)
+	assert.NoError(t, err)
+	if assert.NotNil(t, returnRecords["invoice"].(*models.Invoice).DueAt) {
+		assert.WithinDuration(t, lateDueAt, *returnRecords["invoice"].(*models.Invoice).DueAt, time.Second)
+	}
+	assert.Equal(t, uint(45), returnRecords["invoice"].(*models.Invoice).PaymentTermsDays)
+
+	// Invoices issued 20 days ago on 14 day terms are overdue
+	overdueInvoice := &models.Invoice{UserID: 69, BusinessID: business.ID, OriginalBalance: 3000}
+	_, err = overdueInvoice.Create(testAppDB)
+	assert.NoError(t, err)
+	_, err = overdueInvoice.Issue(testAppDB, overdueInvoice.ID, issuedAt.AddDate(0, 0, -20))
+	assert.NoError(t, err)
+
+	overdueInvoices, err := invoice.MarkOverdueInvoices(testAppDB, time.Now())
+	assert.NoError(t, err)
+	if assert.Len(t, overdueInvoices, 1) {
+		assert.Equal(t, overdueInvoice.ID, overdueInvoices[0].ID)
+		assert.Equal(t, models.InvoiceStatusOverdue, overdueInvoices[0].Status)
+	}
+
+	overdueInvoices, err = invoice.MarkOverdueInvoices(testAppDB, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, overdueInvoices, "Invoices should only be marked overdue once.")
+
+	// Overdue invoices stay overdue until they are paid in full
+	payment := &models.Payment{InvoiceID: overdueInvoice.ID, Amount: 1000, Method: models.PaymentMethodCash}
+	returnRecords, err = payment.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InvoiceStatusOverdue, returnRecords["invoice"].(*models.Invoice).Status)
+
+	payment = &models.Payment{InvoiceID: overdueInvoice.ID, Amount: 2000, Method: models.PaymentMethodCash}
+	returnRecords, err = payment.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InvoiceStatusPaid, returnRecords["invoice"].(*models.Invoice).Status)
+}
+
+/*
+*Description*
+
+func TestLateFees
+
+Tests the ApplyLateFees method for the Invoice db object. Confirms that late fee settings are validated, that an overdue invoice is charged the Business's flat fee plus its rate on the overdue balance once the grace period has passed, that the fee is billed on a new issued invoice that refers to the overdue invoice, that each invoice is charged once, that businesses without late fees charge nothing, and that the user's outstanding balance includes the late fees.
+*/
+func TestLateFees(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	// Late fees need an amount or a rate
+	invalidBusiness := &models.Business{OwnerID: 1, Name: "Vague Gator LLC", LateFeesEnabled: true}
+	_, err := invalidBusiness.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPaymentTerms)
+
+	business := &models.Business{
+		OwnerID:          1,
+		Name:             "Strict Gator LLC",
+		LateFeesEnabled:  true,
+		LateFeeAmount:    500,
+		LateFeeRate:      150,
+		LateFeeGraceDays: 5,
+	}
+	_, err = business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	lenientBusiness := &models.Business{OwnerID: 1, Name: "Lenient Gator LLC"}
+	_, err = lenientBusiness.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+	assert.Equal(t, 0, lenientBusiness.GetLateFee(10000))
+
+	issue := func(businessID uint, balance int, daysAgo int) *models.Invoice {
+		invoice := &models.Invoice{UserID: 69, BusinessID: businessID, OriginalBalance: balance}
+		_, err := invoice.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Invoice.  --  %s", err)
+		}
+
+		_, err = invoice.Issue(testAppDB, invoice.ID, time.Now().AddDate(0, 0, -daysAgo))
+		if err != nil {
+			t.Fatalf("Could not issue test Invoice.  --  %s", err)
+		}
+
+		return invoice
+	}
+
+	// 10 days past due (past the grace period), 3 days past due (within the grace period), and a business without late fees
+	longOverdue := issue(business.ID, 10000, 40)
+	recentlyOverdue := issue(business.ID, 2000, 33)
+	issue(lenientBusiness.ID, 10000, 40)
+
+	invoice := models.Invoice{}
+	overdueInvoices, err := invoice.MarkOverdueInvoices(testAppDB, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, overdueInvoices, 3)
+
+	feeInvoices, err := invoice.ApplyLateFees(testAppDB, time.Now())
+	assert.NoError(t, err)
+	if assert.Len(t, feeInvoices, 1) {
+		assert.Equal(t, longOverdue.ID, feeInvoices[0].LateFeeForID)
+		assert.Equal(t, 650, feeInvoices[0].OriginalBalance, "The late fee should be the flat fee plus 1.5% of the overdue balance.")
+		assert.Equal(t, models.InvoiceStateIssued, feeInvoices[0].State)
+		assert.Equal(t, "INV-000003", feeInvoices[0].Number)
+		assert.Equal(t, models.InvoiceStatusUnpaid, feeInvoices[0].Status)
+
+		lineItems, err := invoice.GetLineItems(testAppDB, feeInvoices[0].ID)
+		assert.NoError(t, err)
+		if assert.Len(t, lineItems, 1) {
+			assert.Equal(t, "Late fee for invoice INV-000001", lineItems[0].Description)
+		}
+	}
+
+	// Each invoice is charged one late fee at most
+	feeInvoices, err = invoice.ApplyLateFees(testAppDB, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, feeInvoices)
+
+	// Once the grace period has passed, the other overdue invoice is charged too
+	feeInvoices, err = invoice.ApplyLateFees(testAppDB, time.Now().AddDate(0, 0, 3))
+	assert.NoError(t, err)
+	if assert.Len(t, feeInvoices, 1) {
+		assert.Equal(t, recentlyOverdue.ID, feeInvoices[0].LateFeeForID)
+		assert.Equal(t, 530, feeInvoices[0].OriginalBalance)
+	}
+
+	// Late fees can't be linked to other invoices by hand
+	_, err = invoice.Update(testAppDB, recentlyOverdue.ID, map[string]interface{}{"late_fee_for_id": longOverdue.ID})
+	assert.ErrorIs(t, err, models.ErrInvalidInvoice)
+
+	// The outstanding balance includes every invoice still owed, late fees included
+	user := models.User{}
+	balances, err := user.GetOutstandingBalances(testAppDB, 69)
+	assert.NoError(t, err)
+	if assert.Len(t, balances, 1) {
+		assert.Equal(t, "USD", balances[0].Currency)
+		assert.Equal(t, 10000+2000+10000+650+530, balances[0].Amount)
+		assert.Equal(t, 10000+2000+10000, balances[0].OverdueAmount)
+		assert.Equal(t, 5, balances[0].InvoiceCount)
+	}
+
+	balances, err = user.GetOutstandingBalances(testAppDB, 70)
+	assert.NoError(t, err)
+	assert.Empty(t, balances)
+}
+
+/*
+*Description*
+
+func TestInvoiceReminders
+
+Tests the SendDueReminders method for the InvoiceReminder db object. Confirms that reminders escalate from a reminder before the due date to a final notice, that each stage is sent once and only the latest stage that is due is sent, that a reminder that can't be delivered is retried on the next run, and that paid invoices and users that can't be reached aren't reminded.
+*/
+func TestInvoiceReminders(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	user := &models.User{Email: "late.payer@gmail.com", Password: "pw123", AccountType: "User", FirstName: "Lee", LastName: "Payer"}
+	_, err := user.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test User.  --  %s", err)
+	}
+
+	business := &models.Business{OwnerID: 1, Name: "Reminding Gator LLC"}
+	_, err = business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	issuedAt := time.Now()
+	issue := func(userID uint) *models.Invoice {
+		invoice := &models.Invoice{UserID: userID, BusinessID: business.ID, OriginalBalance: 5000}
+		_, err := invoice.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Invoice.  --  %s", err)
+		}
+
+		returnRecords, err := invoice.Issue(testAppDB, invoice.ID, issuedAt)
+		if err != nil {
+			t.Fatalf("Could not issue test Invoice.  --  %s", err)
+		}
+
+		return returnRecords["invoice"].(*models.Invoice)
+	}
+
+	invoice := issue(user.ID)
+	dueAt := *invoice.DueAt
+
+	// Paid invoices and users that don't exist aren't reminded
+	paidInvoice := issue(user.ID)
+	payment := &models.Payment{InvoiceID: paidInvoice.ID, Amount: 5000, Method: models.PaymentMethodCash}
+	_, err = payment.Create(testAppDB)
+	assert.NoError(t, err)
+	issue(user.ID + 1000)
+
+	recorder := notifications.NewRecorder()
+	reminder := models.InvoiceReminder{}
+
+	reminders, err := reminder.SendDueReminders(testAppDB, recorder, dueAt.AddDate(0, 0, -5))
+	assert.NoError(t, err)
+	assert.Empty(t, reminders, "No reminder should be sent more than 3 days before the due date.")
+
+	// The first reminder is sent 3 days before the due date
+	reminders, err = reminder.SendDueReminders(testAppDB, recorder, dueAt.AddDate(0, 0, -2))
+	assert.NoError(t, err)
+	if assert.Len(t, reminders, 1) {
+		assert.Equal(t, invoice.ID, reminders[0].InvoiceID)
+		assert.Equal(t, uint(1), reminders[0].Level)
+		assert.Equal(t, "Upcoming", reminders[0].Stage)
+		assert.Equal(t, user.Email, reminders[0].Recipient)
+		assert.Equal(t, "recorder", reminders[0].Notifier)
+	}
+
+	messages := recorder.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, user.Email, messages[0].To)
+		assert.Equal(t, "Lee Payer", messages[0].ToName)
+		assert.Equal(t, "Invoice INV-000001 is due soon", messages[0].Subject)
+		assert.Equal(t, models.ReminderMessageKind, messages[0].Kind)
+		assert.Contains(t, messages[0].Body, "Amount due: $50.00")
+		assert.Contains(t, messages[0].Body, "Reminding Gator LLC")
+	}
+
+	// Each stage is only sent once
+	reminders, err = reminder.SendDueReminders(testAppDB, recorder, dueAt.AddDate(0, 0, -1))
+	assert.NoError(t, err)
+	assert.Empty(t, reminders)
+
+	// Only the latest stage that is due is sent
+	reminders, err = reminder.SendDueReminders(testAppDB, recorder, dueAt.AddDate(0, 0, 10))
+	assert.NoError(t, err)
+	if assert.Len(t, reminders, 1) {
+		assert.Equal(t, "Second Notice", reminders[0].Stage)
+	}
+
+	// Reminders that can't be delivered aren't recorded, and are sent on the next run
+	recorder.Err = errors.New("mailbox unavailable")
+	reminders, err = reminder.SendDueReminders(testAppDB, recorder, dueAt.AddDate(0, 0, 15))
+	assert.Error(t, err)
+	assert.Empty(t, reminders)
+
+	recorder.Err = nil
+	reminders, err = reminder.SendDueReminders(testAppDB, recorder, dueAt.AddDate(0, 0, 15))
+	assert.NoError(t, err)
+	if assert.Len(t, reminders, 1) {
+		assert.Equal(t, "Final Notice", reminders[0].Stage)
+		assert.True(t, strings.HasPrefix(recorder.Messages()[2].Subject, "Final notice"))
+	}
+
+	reminders, err = reminder.SendDueReminders(testAppDB, recorder, dueAt.AddDate(0, 0, 30))
+	assert.NoError(t, err)
+	assert.Empty(t, reminders, "Nothing should be sent after the final notice.")
+
+	sentReminders, err := reminder.GetRecordsBySecondaryID(testAppDB, "invoice_id", invoice.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sentReminders, 3)
+	assert.Len(t, recorder.Messages(), 3)
+}
@@ -3,6 +3,7 @@ package tests
 import (
 	"server/models"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,7 +13,7 @@ import (
 
 func TestCreateGetInvoice
 
-Tests the Create and Get methods for the Invoice db object. Confirms that the created Invoice object is returned when the method is called and that the record is created in the application database.
+Tests the Create and Get methods for the Invoice db object. Confirms that the created Invoice object is returned when the method is called, that the record is created in the application database, that an appointment can't be invoiced twice, and that a new invoice's status follows from its balance rather than the status it is created with.
 */
 func TestCreateGetInvoice(t *testing.T) {
 	// Refresh database to control testing environment
@@ -42,6 +43,20 @@ func TestCreateGetInvoice(t *testing.T) {
 
 	unequalFields, equal := models.Equal(createdInvoice, returnedInvoice)
 	assert.Truef(t, equal, "The following fields did not match between the created and returned object  --  %s", unequalFields)
+
+	// An appointment can't be invoiced twice while its invoice is still live
+	duplicateInvoice := models.Invoice{AppointmentID: 8675, OriginalBalance: 2000}
+	_, err = duplicateInvoice.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrDuplicateInvoice)
+
+	// The status of a new invoice follows from its balance, whatever status it is created with
+	for _, status := range []string{models.InvoiceStatusVoid, models.InvoiceStatusRefunded, models.InvoiceStatusPaid} {
+		spoofedInvoice := models.Invoice{OriginalBalance: 2000, Status: status}
+		_, err = spoofedInvoice.Create(testAppDB)
+		if assert.NoError(t, err) {
+			assert.Equalf(t, models.InvoiceStatusUnpaid, spoofedInvoice.Status, "An invoice created as '%s' should be Unpaid.", status)
+		}
+	}
 }
 
 /*
@@ -49,7 +64,7 @@ func TestCreateGetInvoice(t *testing.T) {
 
 func TestUpdateInvoice
 
-Tests the Update method for the Invoice db object. Confirmed that the updated Invoice object is returned and that the record was updated in the datbas. Throws the appropriate error if the record doesn't exist in the database
+Tests the Update method for the Invoice db object. Confirmed that the updated Invoice object is returned and that the record was updated in the datbas. Throws the appropriate error if the record doesn't exist in the database, or if the update tries to change the remaining balance directly
 */
 func TestUpdateInvoice(t *testing.T) {
 	// Refresh database to control testing environment
@@ -74,7 +89,7 @@ func TestUpdateInvoice(t *testing.T) {
 	testUpdateInvoice := models.Invoice{}
 
 	updates := map[string]interface{}{
-		"remaining_balance": 0,
+		"original_balance": 3000,
 	}
 
 	returnRecords, err = testUpdateInvoice.Update(testAppDB, invoiceID, updates)
@@ -83,6 +98,12 @@ func TestUpdateInvoice(t *testing.T) {
 		t.Errorf("Could not update test Invoice.  --  %s", err)
 	}
 
+	assert.Equal(t, 3000, updatedInvoice.(*models.Invoice).RemainingBalance, "Remaining balance should be derived from the invoice's payments.")
+
+	// Balances derived from the payment ledger can't be updated directly
+	_, err = testUpdateInvoice.Update(testAppDB, invoiceID, map[string]interface{}{"remaining_balance": 0})
+	assert.ErrorIs(t, err, models.ErrInvoiceBalanceReadOnly)
+
 	// Attempt to retrieve created/updated invoice record from database
 	testGetInvoice := models.Invoice{}
 
@@ -139,3 +160,253 @@ func TestDeleteInvoice(t *testing.T) {
 	}
 	assert.False(t, deletedIDExists, "Invoice record's ID still exists in the database after Delete method was executed.")
 }
+
+/*
+*Description*
+
+func TestAppointmentInvoicing
+
+Tests automatic invoicing of appointments by Appointment.Book and Appointment.UpdateStatus. Confirms that Businesses that invoice at booking issue an invoice that bills every seat at the Service price, that a timely guest cancellation credits the guest's seat, that a timely cancellation voids the invoice while a late cancellation credits everything but the cancellation fee, that Businesses that invoice at completion bill the appointment when it is completed, and that Businesses with no billing policy are not invoiced.
+*/
+func TestAppointmentInvoicing(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	var userID uint = 69
+	now := time.Now()
+
+	var serviceCount int
+	createService := func(billingPolicy string) *models.Service {
+		serviceCount++
+
+		business := &models.Business{OwnerID: 1, Name: "Gator Aider LLC " + billingPolicy, BillingPolicy: billingPolicy}
+		_, err := business.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Business.  --  %s", err)
+		}
+
+		service := &models.Service{
+			BusinessID:    business.ID,
+			Name:          "Pilates",
+			StartDateTime: now.Add(time.Duration(serviceCount+1) * 24 * time.Hour),
+			Length:        60,
+			Capacity:      20,
+			Price:         2500,
+			CancelFee:     1000,
+		}
+
+		_, err = service.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+
+		return service
+	}
+
+	book := func(service *models.Service, seats uint) *models.Appointment {
+		appt := &models.Appointment{UserID: userID, ServiceID: service.ID, Seats: seats}
+		_, err := appt.Book(testAppDB, now, nil)
+		if err != nil {
+			t.Fatalf("Could not book test Appointment.  --  %s", err)
+		}
+
+		return appt
+	}
+
+	getInvoices := func(apptID uint) []models.Invoice {
+		invoice := models.Invoice{}
+		invoices, err := invoice.GetRecordsBySecondaryID(testAppDB, "appointment_id", apptID)
+		assert.NoError(t, err)
+		return invoices
+	}
+
+	invalidBusiness := &models.Business{OwnerID: 1, Name: "Invalid", BillingPolicy: "Monthly"}
+	_, err := invalidBusiness.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidBillingPolicy)
+
+	// Invoice at booking
+	atBookingService := createService(models.BillingPolicyAtBooking)
+	appt := book(atBookingService, 3)
+
+	invoices := getInvoices(appt.ID)
+	if assert.Len(t, invoices, 1, "Booking should invoice the appointment.") {
+		assert.Equal(t, 7500, invoices[0].OriginalBalance, "Every seat should be invoiced at the Service price.")
+		assert.Equal(t, models.InvoiceStatusUnpaid, invoices[0].Status)
+		assert.Equal(t, models.InvoiceStateIssued, invoices[0].State, "Automatic invoices should be issued straight away.")
+	}
+
+	// A timely guest cancellation credits the guest's seat
+	guest := models.AppointmentGuest{}
+	guests, err := guest.GetRecordsBySecondaryID(testAppDB, "appointment_id", appt.ID)
+	assert.NoError(t, err)
+	_, err = guest.Cancel(testAppDB, appt.ID, guests[0].ID)
+	assert.NoError(t, err)
+
+	invoices = getInvoices(appt.ID)
+	assert.Equal(t, 7500, invoices[0].OriginalBalance, "Issued invoices should not be changed.")
+	assert.Equal(t, 5000, invoices[0].RemainingBalance)
+
+	creditNote := models.CreditNote{}
+	creditNotes, err := creditNote.GetRecordsBySecondaryID(testAppDB, "invoice_id", invoices[0].ID)
+	assert.NoError(t, err)
+	if assert.Len(t, creditNotes, 1, "The guest's seat should be credited.") {
+		assert.Equal(t, 2500, creditNotes[0].Amount)
+	}
+
+	// A timely cancellation voids the invoice
+	_, err = appt.Cancel(testAppDB, appt.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InvoiceStatusVoid, getInvoices(appt.ID)[0].Status, "Timely cancellation should void the invoice.")
+
+	// A late cancellation reduces the invoice to the cancellation fee
+	rule := &models.BookingRule{BusinessID: atBookingService.BusinessID, MinCancelNoticeMinutes: 7 * 24 * 60}
+	_, err = rule.Upsert(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test BookingRule.  --  %s", err)
+	}
+
+	lateAppointment := book(atBookingService, 1)
+	_, err = lateAppointment.Cancel(testAppDB, lateAppointment.ID)
+	assert.NoError(t, err)
+
+	invoices = getInvoices(lateAppointment.ID)
+	if assert.Len(t, invoices, 1) {
+		assert.Equal(t, 1000, invoices[0].RemainingBalance, "Late cancellation should credit everything but the cancellation fee.")
+		assert.Equal(t, models.InvoiceStatusUnpaid, invoices[0].Status)
+	}
+
+	// Invoice at completion
+	atCompletionService := createService(models.BillingPolicyAtCompletion)
+	completedAppointment := book(atCompletionService, 1)
+	assert.Empty(t, getInvoices(completedAppointment.ID), "Businesses that invoice at completion should not invoice at booking.")
+
+	_, err = completedAppointment.UpdateStatus(testAppDB, completedAppointment.ID, models.AppointmentStatusCompleted, "")
+	assert.NoError(t, err)
+
+	invoices = getInvoices(completedAppointment.ID)
+	if assert.Len(t, invoices, 1, "Completing the appointment should invoice it.") {
+		assert.Equal(t, 2500, invoices[0].OriginalBalance)
+	}
+
+	// No automatic invoicing
+	noneService := createService(models.BillingPolicyNone)
+	uninvoicedAppointment := book(noneService, 1)
+	_, err = uninvoicedAppointment.UpdateStatus(testAppDB, uninvoicedAppointment.ID, models.AppointmentStatusCompleted, "")
+	assert.NoError(t, err)
+	assert.Empty(t, getInvoices(uninvoicedAppointment.ID), "Businesses with no billing policy should not be invoiced automatically.")
+
+	invoice := models.Invoice{}
+	userInvoices, err := invoice.GetRecordsBySecondaryID(testAppDB, "user_id", userID)
+	assert.NoError(t, err)
+	assert.Len(t, userInvoices, 3)
+}
+
+/*
+*Description*
+
+func TestInvoiceLineItemCalculate
+
+Tests the Calculate method for the InvoiceLineItem db object. Confirms that a line's subtotal is its quantity times its unit price less its discount, that tax is rounded half up to the nearest cent, and that invalid discounts and tax rates are rejected.
+*/
+func TestInvoiceLineItemCalculate(t *testing.T) {
+	lineItem := &models.InvoiceLineItem{Description: "Yoga", Quantity: 2, UnitPrice: 2500, TaxRate: 825}
+	assert.NoError(t, lineItem.Calculate())
+	assert.Equal(t, 5000, lineItem.Subtotal)
+	assert.Equal(t, 413, lineItem.Tax, "412.5 cents of tax should round up to 413.")
+	assert.Equal(t, 5413, lineItem.Total)
+
+	lineItem = &models.InvoiceLineItem{Description: "Mat rental", UnitPrice: 1999, Discount: 199, TaxRate: 700}
+	assert.NoError(t, lineItem.Calculate())
+	assert.Equal(t, uint(1), lineItem.Quantity, "Quantity should default to 1.")
+	assert.Equal(t, 1800, lineItem.Subtotal)
+	assert.Equal(t, 126, lineItem.Tax)
+
+	lineItem = &models.InvoiceLineItem{Description: "Towel", UnitPrice: 333, TaxRate: 1000}
+	assert.NoError(t, lineItem.Calculate())
+	assert.Equal(t, 33, lineItem.Tax, "33.3 cents of tax should round down to 33.")
+
+	lineItem = &models.InvoiceLineItem{Description: "Too generous", UnitPrice: 500, Discount: 501}
+	assert.ErrorIs(t, lineItem.Calculate(), models.ErrInvalidInvoice, "Discounts can't be larger than the line's price.")
+
+	lineItem = &models.InvoiceLineItem{Description: "Too taxing", UnitPrice: 500, TaxRate: 10001}
+	assert.ErrorIs(t, lineItem.Calculate(), models.ErrInvalidInvoice, "Tax rates can't be more than 100%.")
+}
+
+/*
+*Description*
+
+func TestInvoiceLineItems
+
+Tests the CreateWithLineItems and SetLineItems methods for the Invoice db object. Confirms that a draft Invoice's subtotal, discount, tax and balances are recalculated from its line items whenever they change, that the balance of an invoice with line items can't be updated directly, that removing every line item from an unpaid invoice voids it, and that issued invoices can't be changed or deleted.
+*/
+func TestInvoiceLineItems(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	invoice := &models.Invoice{UserID: 69}
+	_, err := invoice.CreateWithLineItems(testAppDB, []models.InvoiceLineItem{
+		{Description: "Yoga", Quantity: 2, UnitPrice: 2500, TaxRate: 825},
+		{Description: "Mat rental", Quantity: 1, UnitPrice: 500, Discount: 500},
+	})
+	if err != nil {
+		t.Fatalf("Could not create test Invoice.  --  %s", err)
+	}
+
+	assert.Equal(t, 5000, invoice.Subtotal)
+	assert.Equal(t, 500, invoice.DiscountTotal)
+	assert.Equal(t, 413, invoice.TaxTotal)
+	assert.Equal(t, 5413, invoice.OriginalBalance)
+	assert.Equal(t, 5413, invoice.RemainingBalance)
+
+	lineItems, err := invoice.GetLineItems(testAppDB, invoice.ID)
+	assert.NoError(t, err)
+	assert.Len(t, lineItems, 2)
+
+	// Changing the line items of a draft recalculates its totals
+	returnRecords, err := invoice.SetLineItems(testAppDB, invoice.ID, []models.InvoiceLineItem{
+		{Description: "Yoga", Quantity: 1, UnitPrice: 2500, TaxRate: 825},
+	})
+	assert.NoError(t, err)
+
+	updatedInvoice := returnRecords["invoice"].(*models.Invoice)
+	assert.Equal(t, 2706, updatedInvoice.OriginalBalance)
+	assert.Equal(t, 2706, updatedInvoice.RemainingBalance)
+	assert.Equal(t, models.InvoiceStatusUnpaid, updatedInvoice.Status)
+
+	lineItems, _ = invoice.GetLineItems(testAppDB, invoice.ID)
+	assert.Len(t, lineItems, 1, "Line items should be replaced.")
+
+	_, err = invoice.SetLineItems(testAppDB, invoice.ID, []models.InvoiceLineItem{{Description: "Bad", UnitPrice: -1}})
+	assert.ErrorIs(t, err, models.ErrInvalidInvoice)
+
+	// The balance of an invoice with line items is derived from them
+	_, err = invoice.Update(testAppDB, invoice.ID, map[string]interface{}{"original_balance": 100})
+	assert.ErrorIs(t, err, models.ErrInvoiceBalanceReadOnly)
+
+	// Issued invoices can't be changed or deleted
+	_, err = invoice.Issue(testAppDB, invoice.ID, time.Now())
+	assert.NoError(t, err)
+
+	payment := &models.Payment{InvoiceID: invoice.ID, Amount: 2000, Method: models.PaymentMethodCash}
+	returnRecords, err = payment.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, 706, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+
+	_, err = invoice.SetLineItems(testAppDB, invoice.ID, nil)
+	assert.ErrorIs(t, err, models.ErrInvoiceIssued)
+
+	_, err = invoice.Update(testAppDB, invoice.ID, map[string]interface{}{"user_id": 70})
+	assert.ErrorIs(t, err, models.ErrInvoiceIssued)
+
+	_, err = invoice.Delete(testAppDB, invoice.ID)
+	assert.ErrorIs(t, err, models.ErrInvoiceIssued)
+
+	// Removing every line item from an unpaid invoice voids it
+	unpaidInvoice := &models.Invoice{UserID: 69}
+	_, err = unpaidInvoice.CreateWithLineItems(testAppDB, []models.InvoiceLineItem{{Description: "Pottery", UnitPrice: 3000}})
+	assert.NoError(t, err)
+
+	returnRecords, err = unpaidInvoice.SetLineItems(testAppDB, unpaidInvoice.ID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InvoiceStatusVoid, returnRecords["invoice"].(*models.Invoice).Status)
+}
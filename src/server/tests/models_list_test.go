@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestListServices
+
+Tests the List method for the Service db object. Confirms that pages hold at most the limit, that following the next cursor visits every
+matching record once in the requested multi-field sort order, that the total count covers every page, that typed filters narrow the list
+by business, date range, price range and availability, and that invalid limits, sorts, filters and cursors are rejected.
+*/
+func TestListServices(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	start := time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+	prices := []uint{3000, 1000, 3000, 2000, 3000, 1000, 2000}
+	for i, price := range prices {
+		service := models.Service{
+			BusinessID:    uint(1 + i%2),
+			Name:          "Spin class",
+			StartDateTime: start.AddDate(0, 0, i),
+			Length:        45,
+			Capacity:      10,
+			Price:         price,
+			IsFull:        i == 4,
+		}
+
+		_, err := service.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+	}
+
+	// Following the cursors visits every service once, sorted by price (highest first) and then by start time
+	service := models.Service{}
+	var listed []models.Service
+	options := models.ListOptions{Limit: 3, Sort: "-price,start_date_time"}
+	for pages := 0; pages < 5; pages++ {
+		page, err := service.List(testAppDB, options)
+		if !assert.NoError(t, err) {
+			break
+		}
+
+		assert.Equal(t, int64(len(prices)), page.TotalCount, "The total count should cover every page.")
+		assert.LessOrEqual(t, len(page.Data.([]models.Service)), 3)
+		listed = append(listed, page.Data.([]models.Service)...)
+
+		if page.NextCursor == "" {
+			break
+		}
+		options.Cursor = page.NextCursor
+	}
+
+	if assert.Len(t, listed, len(prices)) {
+		for i := 1; i < len(listed); i++ {
+			previous, current := listed[i-1], listed[i]
+			assert.True(t, previous.Price > current.Price || (previous.Price == current.Price && previous.StartDateTime.Before(current.StartDateTime)),
+				"Services should be sorted by price, then by start time.")
+		}
+	}
+
+	// Filters are parsed for their field's type and combined
+	page, err := service.List(testAppDB, models.ListOptions{Filters: map[string]string{
+		"business_id":          "1",
+		"start_date_time[gte]": "2023-06-02",
+		"price[lte]":           "3000",
+		"is_full":              "false",
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), page.TotalCount, "Only services of business 1 starting from June 2nd that aren't full should be listed.")
+	assert.Empty(t, page.NextCursor)
+
+	page, err = service.List(testAppDB, models.ListOptions{Filters: map[string]string{"price[in]": "1000,2000"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), page.TotalCount)
+
+	page, err = service.List(testAppDB, models.ListOptions{Filters: map[string]string{"price[gt]": "5000"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Service{}, page.Data, "Empty pages should list no services instead of null.")
+
+	// Invalid options are rejected
+	_, err = service.List(testAppDB, models.ListOptions{Limit: models.MaxListLimit + 1})
+	assert.ErrorIs(t, err, models.ErrInvalidListOptions)
+
+	_, err = service.List(testAppDB, models.ListOptions{Sort: "desc"})
+	assert.ErrorIs(t, err, models.ErrInvalidListOptions, "Only listed fields can be sorted by.")
+
+	_, err = service.List(testAppDB, models.ListOptions{Filters: map[string]string{"price": "cheap"}})
+	assert.ErrorIs(t, err, models.ErrInvalidListOptions, "Filter values should be parsed for the field's type.")
+
+	_, err = service.List(testAppDB, models.ListOptions{Filters: map[string]string{"is_full[gt]": "true"}})
+	assert.ErrorIs(t, err, models.ErrInvalidListOptions)
+
+	page, err = service.List(testAppDB, models.ListOptions{Limit: 2, Sort: "price"})
+	assert.NoError(t, err)
+	_, err = service.List(testAppDB, models.ListOptions{Limit: 2, Sort: "name", Cursor: page.NextCursor})
+	assert.ErrorIs(t, err, models.ErrInvalidListOptions, "Cursors should only be used with the sort they were made for.")
+
+	_, err = service.List(testAppDB, models.ListOptions{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, models.ErrInvalidListOptions)
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"server/geocoding"
+	"server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestNearbyBusinesses
+
+Tests the SetAddress method for the Business db object and the NearbyBusinesses method. Confirms that addresses without coordinates are
+geocoded and that given coordinates are kept, that setting an address again updates the business's existing address, that invalid
+addresses are rejected, and that nearby searches only find businesses within the radius, nearest first, with their addresses and their
+number of upcoming services.
+*/
+func TestNearbyBusinesses(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	geocoder, err := geocoding.NewZipCentroidGeocoder("")
+	if err != nil {
+		t.Fatalf("Could not load the bundled zip centroids.  --  %s", err)
+	}
+
+	createBusiness := func(name string) *models.Business {
+		business := &models.Business{OwnerID: 1, Name: name}
+		_, err := business.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Business.  --  %s", err)
+		}
+
+		return business
+	}
+
+	downtown := createBusiness("Downtown Gator Yoga")
+	campus := createBusiness("Campus Gator Spin")
+	orlando := createBusiness("Orlando Gator Pilates")
+	unlisted := createBusiness("Unlisted Gator Dance")
+
+	// Addresses without coordinates are placed at their zip code's centroid
+	business := models.Business{}
+	returnRecords, err := business.SetAddress(testAppDB, geocoder, downtown.ID, models.Address{Address1: "1 Main St", City: "Gainesville", State: "fl", ZipCode: "32601"})
+	if assert.NoError(t, err) {
+		address := returnRecords["address"].(*models.Address)
+		assert.NotZero(t, address.ID)
+		assert.Equal(t, "FL", address.State)
+		assert.InDelta(t, 29.6479, *address.Latitude, 0.0001)
+		assert.Equal(t, address.ID, *returnRecords["business"].(*models.Business).AddressID)
+	}
+
+	// Given coordinates are kept
+	latitude, longitude := 29.6436, -82.3549
+	business = models.Business{}
+	_, err = business.SetAddress(testAppDB, geocoder, campus.ID, models.Address{Address1: "2 Stadium Rd", City: "Gainesville", State: "FL", ZipCode: "32611", Latitude: &latitude, Longitude: &longitude})
+	assert.NoError(t, err)
+
+	business = models.Business{}
+	_, err = business.SetAddress(testAppDB, geocoder, orlando.ID, models.Address{Address1: "3 Orange Ave", City: "Somewhere", State: "FL", ZipCode: "32609"})
+	assert.NoError(t, err)
+
+	// Setting the address again moves the business instead of creating another address
+	business = models.Business{}
+	returnRecords, err = business.SetAddress(testAppDB, geocoder, orlando.ID, models.Address{Address1: "3 Orange Ave", City: "Orlando", State: "FL", ZipCode: "32801"})
+	if assert.NoError(t, err) {
+		address := returnRecords["address"].(*models.Address)
+		assert.Equal(t, "Orlando", address.City)
+		assert.InDelta(t, 28.5399, *address.Latitude, 0.0001)
+	}
+
+	var addressCount int64
+	testAppDB.Model(&models.Address{}).Count(&addressCount)
+	assert.Equal(t, int64(3), addressCount, "Each business should keep a single address.")
+
+	// Invalid addresses are rejected
+	business = models.Business{}
+	_, err = business.SetAddress(testAppDB, geocoder, unlisted.ID, models.Address{Address1: "4 Nowhere Ln", City: "Nowhere", State: "FL"})
+	assert.ErrorIs(t, err, models.ErrInvalidAddress)
+
+	_, err = business.SetAddress(testAppDB, geocoder, unlisted.ID, models.Address{Address1: "4 Nowhere Ln", City: "Nowhere", State: "FL", ZipCode: "00000"})
+	assert.ErrorIs(t, err, geocoding.ErrLocationNotFound)
+
+	_, err = business.SetAddress(testAppDB, geocoder, unlisted.ID, models.Address{Address1: "4 Nowhere Ln", City: "Nowhere", State: "FL", ZipCode: "32601", Latitude: &latitude})
+	assert.ErrorIs(t, err, models.ErrInvalidAddress, "Latitude and longitude should be set together.")
+
+	_, err = business.Update(testAppDB, unlisted.ID, map[string]interface{}{"address_id": 1})
+	assert.ErrorIs(t, err, models.ErrInvalidAddress, "Addresses shouldn't be linked by a business update.")
+
+	// Upcoming services are counted
+	for i, start := range []time.Time{time.Now().Add(24 * time.Hour), time.Now().Add(48 * time.Hour), time.Now().Add(-24 * time.Hour)} {
+		service := models.Service{BusinessID: downtown.ID, Name: "Yoga", StartDateTime: start, Length: 60, Capacity: 10, Price: uint(1000 * (i + 1))}
+		_, err := service.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+	}
+
+	// Only businesses within the radius are found, nearest first
+	origin := geocoding.Coordinates{Latitude: 29.6516, Longitude: -82.3248}
+	businesses, err := models.NearbyBusinesses(testAppDB, origin, 5, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, businesses, 2, "Only the Gainesville businesses should be within 5 miles.") {
+		assert.Equal(t, downtown.ID, businesses[0].ID)
+		assert.Equal(t, campus.ID, businesses[1].ID)
+		assert.Less(t, businesses[0].DistanceMiles, businesses[1].DistanceMiles)
+		assert.InDelta(t, origin.DistanceMiles(geocoding.Coordinates{Latitude: latitude, Longitude: longitude}), businesses[1].DistanceMiles, 0.01)
+		assert.Equal(t, int64(2), businesses[0].UpcomingServices, "Past services shouldn't be counted.")
+		assert.Equal(t, int64(0), businesses[1].UpcomingServices)
+
+		if assert.NotNil(t, businesses[0].Address) {
+			assert.Equal(t, "1 Main St", businesses[0].Address.Address1)
+		}
+	}
+
+	businesses, err = models.NearbyBusinesses(testAppDB, origin, 150, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, businesses, 3) {
+		assert.Equal(t, orlando.ID, businesses[2].ID)
+	}
+
+	businesses, err = models.NearbyBusinesses(testAppDB, origin, 150, 1)
+	assert.NoError(t, err)
+	assert.Len(t, businesses, 1)
+
+	businesses, err = models.NearbyBusinesses(testAppDB, geocoding.Coordinates{Latitude: 47.6114, Longitude: -122.3339}, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.NearbyBusiness{}, businesses, "Searches without results should find no businesses instead of null.")
+
+	// Invalid searches are rejected
+	_, err = models.NearbyBusinesses(testAppDB, geocoding.Coordinates{Latitude: 91, Longitude: 0}, 0, 0)
+	assert.ErrorIs(t, err, models.ErrInvalidSearch)
+
+	_, err = models.NearbyBusinesses(testAppDB, origin, models.MaxNearbyRadiusMiles+1, 0)
+	assert.ErrorIs(t, err, models.ErrInvalidSearch)
+
+	_, err = models.NearbyBusinesses(testAppDB, origin, 5, models.MaxNearbyLimit+1)
+	assert.ErrorIs(t, err, models.ErrInvalidSearch)
+}
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"server/models"
+	"server/payments"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func deliverWebhooks
+
+Verifies and processes every webhook delivery recorded by a FakeServer (starting at the specified index) and returns how many of them
+were applied.
+*/
+func deliverWebhooks(t *testing.T, fake *payments.FakeServer, provider payments.PaymentProvider, start int) int {
+	t.Helper()
+
+	var applied int
+	for _, webhook := range fake.Webhooks()[start:] {
+		event, err := provider.VerifyWebhook(webhook.Payload, webhook.Header)
+		if err != nil {
+			t.Fatalf("Could not verify webhook.  --  %s", err)
+		}
+
+		paymentEvent := &models.PaymentEvent{}
+		wasApplied, err := paymentEvent.Process(testAppDB, provider.Name(), event)
+		if err != nil {
+			t.Fatalf("Could not process webhook.  --  %s", err)
+		}
+
+		if wasApplied {
+			applied++
+		}
+	}
+
+	return applied
+}
+
+/*
+*Description*
+
+func TestPaymentProviderWebhooks
+
+Tests the PaymentIntent and PaymentEvent db objects against a fake payment provider. Confirms that a paid intent records exactly one payment against its invoice no matter how many times its webhooks are delivered, that manually captured intents are only paid once they are captured, that payments collected through the provider are refunded through the provider and recorded exactly once, and that intents can't ask for more than the remaining balance.
+*/
+func TestPaymentProviderWebhooks(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	fake := payments.NewFakeServer()
+	defer fake.Close()
+	provider := fake.Provider()
+
+	invoice := &models.Invoice{UserID: 69, OriginalBalance: 5000}
+	_, err := invoice.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Invoice.  --  %s", err)
+	}
+
+	_, err = invoice.Issue(testAppDB, invoice.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Could not issue test Invoice.  --  %s", err)
+	}
+
+	// Intents can't ask for more than the remaining balance
+	tooMuch := &models.PaymentIntent{}
+	_, err = tooMuch.Start(testAppDB, provider, invoice.ID, 5001, false)
+	assert.ErrorIs(t, err, models.ErrInvalidPayment)
+
+	// A paid intent records one payment, however many times its webhooks are delivered
+	intent := &models.PaymentIntent{}
+	_, err = intent.Start(testAppDB, provider, invoice.ID, 3000, false)
+	if err != nil {
+		t.Fatalf("Could not start test PaymentIntent.  --  %s", err)
+	}
+
+	assert.Equal(t, payments.PaymentIntentStatusPending, intent.Status)
+	assert.NotEmpty(t, intent.ClientSecret, "The client secret should be returned when the intent is started.")
+
+	err = fake.Pay(intent.ProviderID)
+	if err != nil {
+		t.Fatalf("Could not pay test PaymentIntent.  --  %s", err)
+	}
+
+	assert.Equal(t, 2, deliverWebhooks(t, fake, provider, 0))
+	assert.Equal(t, 0, deliverWebhooks(t, fake, provider, 0), "Repeated deliveries should not be applied again.")
+
+	invoicePayments, err := (&models.Payment{}).GetRecordsBySecondaryID(testAppDB, "invoice_id", invoice.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, invoicePayments, 1, "A paid intent should record exactly one payment.") {
+		assert.Equal(t, 3000, invoicePayments[0].Amount)
+		assert.Equal(t, models.PaymentMethodCard, invoicePayments[0].Method)
+		assert.Equal(t, intent.ProviderID, invoicePayments[0].Reference)
+	}
+
+	_, err = intent.Get(testAppDB, intent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, payments.PaymentIntentStatusSucceeded, intent.Status)
+	if assert.NotNil(t, intent.PaymentID) {
+		assert.Equal(t, invoicePayments[0].ID, *intent.PaymentID)
+	}
+
+	_, err = invoice.Get(testAppDB, invoice.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2000, invoice.RemainingBalance)
+	assert.Equal(t, models.InvoiceStatusPartiallyPaid, invoice.Status)
+
+	// Manually captured intents are only paid once they are captured
+	authorized := &models.PaymentIntent{}
+	_, err = authorized.Start(testAppDB, provider, invoice.ID, 0, true)
+	if err != nil {
+		t.Fatalf("Could not start test PaymentIntent.  --  %s", err)
+	}
+
+	assert.Equal(t, 2000, authorized.Amount, "Intents should default to the remaining balance.")
+
+	delivered := len(fake.Webhooks())
+	err = fake.Pay(authorized.ProviderID)
+	if err != nil {
+		t.Fatalf("Could not pay test PaymentIntent.  --  %s", err)
+	}
+
+	deliverWebhooks(t, fake, provider, delivered)
+	_, err = authorized.Get(testAppDB, authorized.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, payments.PaymentIntentStatusRequiresCapture, authorized.Status)
+	assert.Nil(t, authorized.PaymentID, "Authorized intents should not record a payment until they are captured.")
+
+	returnRecords, err := authorized.Capture(testAppDB, provider, authorized.ID, 0)
+	if err != nil {
+		t.Fatalf("Could not capture test PaymentIntent.  --  %s", err)
+	}
+
+	assert.Equal(t, payments.PaymentIntentStatusSucceeded, authorized.Status)
+	assert.Equal(t, 0, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+	assert.Equal(t, models.InvoiceStatusPaid, returnRecords["invoice"].(*models.Invoice).Status)
+
+	_, err = authorized.Capture(testAppDB, provider, authorized.ID, 0)
+	assert.ErrorIs(t, err, models.ErrInvalidPayment, "Intents can only be captured once.")
+
+	assert.Equal(t, 1, deliverWebhooks(t, fake, provider, delivered+1), "The capture's webhook should not record a second payment.")
+	invoicePayments, err = (&models.Payment{}).GetRecordsBySecondaryID(testAppDB, "invoice_id", invoice.ID)
+	assert.NoError(t, err)
+	assert.Len(t, invoicePayments, 2)
+
+	// Payments collected through the provider are refunded through the provider and recorded once
+	refundIntent := &models.PaymentIntent{}
+	collectedOnline, err := refundIntent.GetByPaymentID(testAppDB, invoicePayments[0].ID)
+	assert.NoError(t, err)
+	assert.True(t, collectedOnline)
+
+	delivered = len(fake.Webhooks())
+	returnRecords, err = refundIntent.Refund(testAppDB, provider, invoicePayments[0].ID, 1000, "Guest cancelled")
+	if err != nil {
+		t.Fatalf("Could not refund test Payment.  --  %s", err)
+	}
+
+	refund := returnRecords["refund"].(*models.Refund)
+	assert.NotZero(t, refund.ID)
+	assert.Equal(t, 1000, refund.Amount)
+	assert.Equal(t, "Guest cancelled", refund.Reason)
+	assert.Equal(t, 1000, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+
+	deliverWebhooks(t, fake, provider, delivered)
+	deliverWebhooks(t, fake, provider, delivered)
+	refunds, err := (&models.Refund{}).GetRecordsBySecondaryID(testAppDB, "payment_id", invoicePayments[0].ID)
+	assert.NoError(t, err)
+	assert.Len(t, refunds, 1, "The refund's webhook should not record a second refund.")
+
+	_, err = refundIntent.Refund(testAppDB, provider, invoicePayments[0].ID, 2001, "")
+	assert.ErrorIs(t, err, models.ErrInvalidRefund, "Refunds can't exceed the amount paid.")
+
+	// Payments recorded by hand aren't linked to an intent
+	cashPayment := &models.Payment{InvoiceID: invoice.ID, Amount: 500, Method: models.PaymentMethodCash}
+	_, err = cashPayment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	collectedOnline, err = (&models.PaymentIntent{}).GetByPaymentID(testAppDB, cashPayment.ID)
+	assert.NoError(t, err)
+	assert.False(t, collectedOnline)
+}
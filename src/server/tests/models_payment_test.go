@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"server/models"
+	"server/money"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestPaymentLedger
+
+Tests the Create methods for the Payment and Refund db objects. Confirms that an Invoice's remaining balance and status are derived from the payments and refunds recorded against it, that invalid payments and payments against draft or void invoices are rejected, that a payment can't be refunded for more than was paid, and that payments can't be modified once they are recorded.
+*/
+func TestPaymentLedger(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	invoice := &models.Invoice{UserID: 69, OriginalBalance: 5000}
+	_, err := invoice.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Invoice.  --  %s", err)
+	}
+
+	// Drafts can't be paid until they are issued
+	draftPayment := &models.Payment{InvoiceID: invoice.ID, Amount: 1000, Method: models.PaymentMethodCash}
+	_, err = draftPayment.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPayment, "Draft invoices can't be paid.")
+
+	_, err = invoice.Issue(testAppDB, invoice.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Could not issue test Invoice.  --  %s", err)
+	}
+
+	// Invalid payments are rejected
+	invalidPayment := &models.Payment{InvoiceID: invoice.ID, Amount: 0, Method: models.PaymentMethodCash}
+	_, err = invalidPayment.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPayment, "Payments must be for a positive amount.")
+
+	invalidPayment = &models.Payment{InvoiceID: invoice.ID, Amount: 1000, Method: "Seashells"}
+	_, err = invalidPayment.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPayment, "Payments must use a valid payment method.")
+
+	// Payments reduce the remaining balance
+	payment := &models.Payment{InvoiceID: invoice.ID, Amount: 3000, Method: models.PaymentMethodCard, Reference: "ch_123"}
+	returnRecords, err := payment.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Payment.  --  %s", err)
+	}
+
+	paidInvoice := returnRecords["invoice"].(*models.Invoice)
+	assert.Equal(t, 2000, paidInvoice.RemainingBalance)
+	assert.Equal(t, models.InvoiceStatusPartiallyPaid, paidInvoice.Status)
+	assert.False(t, payment.PaidAt.IsZero(), "Payment time should default to the current time.")
+	assert.Equal(t, 2000, payment.BalanceAfter)
+
+	secondPayment := &models.Payment{InvoiceID: invoice.ID, Amount: 2500, Method: models.PaymentMethodCash}
+	returnRecords, err = secondPayment.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, -500, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+	assert.Equal(t, -500, secondPayment.BalanceAfter, "Payments should record the invoice balance right after they were applied.")
+	assert.Equal(t, models.InvoiceStatusOverpaid, returnRecords["invoice"].(*models.Invoice).Status)
+
+	// Refunds increase the remaining balance, up to the amount paid
+	refund := &models.Refund{PaymentID: secondPayment.ID, Amount: 500, Reason: "Overpaid"}
+	returnRecords, err = refund.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, invoice.ID, refund.InvoiceID)
+	assert.Equal(t, 0, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+	assert.Equal(t, models.InvoiceStatusPaid, returnRecords["invoice"].(*models.Invoice).Status)
+
+	overRefund := &models.Refund{PaymentID: secondPayment.ID, Amount: 2001}
+	_, err = overRefund.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidRefund, "Refunds can't exceed the amount paid.")
+
+	fullRefund := &models.Refund{PaymentID: secondPayment.ID}
+	returnRecords, err = fullRefund.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, 2000, fullRefund.Amount, "Refunds should default to everything that is refundable.")
+	assert.Equal(t, 2000, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+
+	amountPaid, err := invoice.GetAmountPaid(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, 3000, amountPaid)
+
+	// Ledger records are immutable
+	_, err = payment.Update(testAppDB, payment.ID, map[string]interface{}{"amount": 1})
+	assert.ErrorIs(t, err, models.ErrInvalidPayment)
+
+	// Void invoices can't be paid
+	voidInvoice := &models.Invoice{UserID: 69, OriginalBalance: 1000}
+	_, err = voidInvoice.Create(testAppDB)
+	assert.NoError(t, err)
+	_, err = voidInvoice.Issue(testAppDB, voidInvoice.ID, time.Now())
+	assert.NoError(t, err)
+
+	creditNote := &models.CreditNote{InvoiceID: voidInvoice.ID, Reason: "Billed in error"}
+	returnRecords, err = creditNote.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InvoiceStatusVoid, returnRecords["invoice"].(*models.Invoice).Status)
+
+	voidPayment := &models.Payment{InvoiceID: voidInvoice.ID, Amount: 1000, Method: models.PaymentMethodCash}
+	_, err = voidPayment.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPayment, "Void invoices can't be paid.")
+}
+
+/*
+*Description*
+
+func TestCurrencies
+
+Tests the currencies of prices, invoices and payments. Confirms that a Business's Services and Invoices default to the Business's currency, that unsupported currencies are rejected, that an Invoice can't list line items or take payments in another currency, and that the currency of an Invoice can't be changed.
+*/
+func TestCurrencies(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	business := &models.Business{OwnerID: 1, Name: "Maple Gator Ltd", Currency: "cad"}
+	_, err := business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+	assert.Equal(t, "CAD", business.Currency, "Currencies should be stored as upper case ISO 4217 codes.")
+
+	invalidBusiness := &models.Business{OwnerID: 1, Name: "Doubloon Gator Co", Currency: "XYZ"}
+	_, err = invalidBusiness.Create(testAppDB)
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+
+	service := &models.Service{BusinessID: business.ID, Name: "Hot Yoga", StartDateTime: time.Now().Add(24 * time.Hour), Length: 60, Capacity: 10, Price: 2500}
+	_, err = service.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Service.  --  %s", err)
+	}
+	assert.Equal(t, "CAD", service.Currency, "Services should default to the Business's currency.")
+
+	invoice := &models.Invoice{UserID: 69, BusinessID: business.ID}
+	_, err = invoice.CreateWithLineItems(testAppDB, []models.InvoiceLineItem{{Description: "Hot Yoga", Quantity: 1, UnitPrice: 2500}})
+	if err != nil {
+		t.Fatalf("Could not create test Invoice.  --  %s", err)
+	}
+	assert.Equal(t, "CAD", invoice.Currency, "Invoices should default to the Business's currency.")
+	assert.Equal(t, 2500, invoice.RemainingBalance)
+
+	// Amounts in different currencies can't be mixed on an invoice
+	_, err = invoice.SetLineItems(testAppDB, invoice.ID, []models.InvoiceLineItem{
+		{Description: "Hot Yoga", Quantity: 1, UnitPrice: 2500},
+		{Description: "Mat rental", Quantity: 1, UnitPrice: 300, Currency: "USD"},
+	})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	_, err = invoice.Update(testAppDB, invoice.ID, map[string]interface{}{"currency": "USD"})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	_, err = invoice.Issue(testAppDB, invoice.ID, time.Now())
+	if err != nil {
+		t.Fatalf("Could not issue test Invoice.  --  %s", err)
+	}
+
+	usdPayment := &models.Payment{InvoiceID: invoice.ID, Amount: 2500, Method: models.PaymentMethodCash, Currency: "USD"}
+	_, err = usdPayment.Create(testAppDB)
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	payment := &models.Payment{InvoiceID: invoice.ID, Amount: 2500, Method: models.PaymentMethodCash}
+	returnRecords, err := payment.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, "CAD", payment.Currency, "Payments should default to the Invoice's currency.")
+	assert.Equal(t, models.InvoiceStatusPaid, returnRecords["invoice"].(*models.Invoice).Status)
+
+	refund := &models.Refund{PaymentID: payment.ID, Amount: 500}
+	_, err = refund.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, "CAD", refund.Currency, "Refunds should be in the currency of the refunded Payment.")
+
+	// Amounts are formatted in the record's currency for the requested locale
+	invoice.Localize("fr-CA")
+	assert.Equal(t, "25,00\u00a0$", invoice.Display.OriginalBalance)
+	payment.Localize("en-US")
+	assert.Equal(t, "CA$25.00", payment.Display.Amount)
+}
@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"errors"
+	"server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestPromoCodes
+
+Tests the PromoCode db object and booking with promo codes. Confirms that invalid and duplicate codes are rejected, that percentage and
+fixed discounts are taken off the appointment's invoice, that codes are rejected for ineligible Services, outside their validity window,
+for customers who aren't first-time customers and once their global or per-user redemption limits are reached, that redemptions of
+cancelled appointments don't count towards the limits, and that the redemption report lists and totals each code's redemptions.
+*/
+func TestPromoCodes(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	now := time.Now()
+
+	business := &models.Business{OwnerID: 1, Name: "Thrifty Gator LLC", BillingPolicy: models.BillingPolicyAtBooking}
+	_, err := business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	createService := func(name string, price uint, hoursAhead int) *models.Service {
+		service := &models.Service{
+			BusinessID:    business.ID,
+			Name:          name,
+			StartDateTime: now.Add(time.Duration(hoursAhead) * time.Hour),
+			Length:        60,
+			Capacity:      10,
+			Price:         price,
+		}
+
+		_, err := service.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+
+		return service
+	}
+
+	yoga := createService("Yoga", 2000, 48)
+	laterYoga := createService("Yoga", 2000, 72)
+	spin := createService("Spin", 1500, 50)
+	laterSpin := createService("Spin", 1500, 74)
+
+	book := func(userID uint, service *models.Service, code string) (*models.Appointment, error) {
+		appt := &models.Appointment{UserID: userID, ServiceID: service.ID, Seats: 1, PromoCode: code}
+		_, err := appt.Book(testAppDB, now, nil)
+		return appt, err
+	}
+
+	rejectionCode := func(err error) string {
+		var bookingErr *models.BookingRejectedError
+		if errors.As(err, &bookingErr) {
+			return bookingErr.Code
+		}
+
+		return ""
+	}
+
+	// Codes must take either a percentage or a fixed amount off, and are unique per Business
+	invalid := &models.PromoCode{BusinessID: business.ID, Code: "BOTH", PercentOff: 1000, AmountOff: 500}
+	_, err = invalid.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPromoCode)
+
+	invalid = &models.PromoCode{BusinessID: business.ID, Code: "TOOMUCH", PercentOff: 15000}
+	_, err = invalid.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPromoCode)
+
+	firstClass := &models.PromoCode{BusinessID: business.ID, Code: " first20 ", PercentOff: 2000, FirstTimeCustomersOnly: true}
+	_, err = firstClass.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test PromoCode.  --  %s", err)
+	}
+	assert.Equal(t, "FIRST20", firstClass.Code)
+	assert.Equal(t, "USD", firstClass.Currency)
+
+	err = firstClass.SetEligibleServices(testAppDB, firstClass.ID, []string{"Yoga"})
+	assert.NoError(t, err)
+
+	duplicate := &models.PromoCode{BusinessID: business.ID, Code: "First20", AmountOff: 500}
+	_, err = duplicate.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPromoCode)
+
+	spring := &models.PromoCode{BusinessID: business.ID, Code: "SPRING10", AmountOff: 1000, MaxRedemptions: 2, MaxRedemptionsPerUser: 1}
+	_, err = spring.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test PromoCode.  --  %s", err)
+	}
+
+	startsAt, endsAt := now.Add(-48*time.Hour), now.Add(-time.Hour)
+	expired := &models.PromoCode{BusinessID: business.ID, Code: "WINTER", PercentOff: 5000, StartsAt: &startsAt, EndsAt: &endsAt}
+	_, err = expired.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test PromoCode.  --  %s", err)
+	}
+
+	// Updates must leave a valid promo code
+	_, err = spring.Update(testAppDB, spring.ID, map[string]interface{}{"percent_off": 500})
+	assert.ErrorIs(t, err, models.ErrInvalidPromoCode)
+	_, err = spring.Get(testAppDB, spring.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), spring.PercentOff, "An invalid update should be rolled back.")
+
+	// Percentage discounts are taken off the invoice
+	var firstUserID, secondUserID, thirdUserID, fourthUserID uint = 69, 70, 71, 72
+	appt, err := book(firstUserID, yoga, "first20")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	invoice, err := appt.GetInvoice(testAppDB)
+	if assert.NoError(t, err) && assert.NotNil(t, invoice) {
+		assert.Equal(t, 400, invoice.DiscountTotal)
+		assert.Equal(t, 1600, invoice.OriginalBalance)
+
+		lineItems, err := invoice.GetLineItems(testAppDB, invoice.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, lineItems, 1) {
+			assert.Equal(t, 400, lineItems[0].Discount)
+			assert.Equal(t, "Yoga (promo code FIRST20)", lineItems[0].Description)
+		}
+	}
+
+	// Codes can only be used for eligible Services, within their validity window, and by first-time customers
+	_, err = book(firstUserID, spin, "FIRST20")
+	assert.Equal(t, models.BookingRejectedPromoCodeNotEligible, rejectionCode(err))
+
+	_, err = book(firstUserID, laterYoga, "FIRST20")
+	assert.Equal(t, models.BookingRejectedPromoCodeFirstTime, rejectionCode(err))
+
+	_, err = book(firstUserID, spin, "WINTER")
+	assert.Equal(t, models.BookingRejectedPromoCodeNotActive, rejectionCode(err))
+
+	_, err = book(firstUserID, spin, "NOSUCHCODE")
+	assert.Equal(t, models.BookingRejectedPromoCodeNotFound, rejectionCode(err))
+
+	// Fixed discounts are taken off once, and each User can only use the code once
+	appt, err = book(firstUserID, spin, "spring10")
+	if assert.NoError(t, err) {
+		invoice, err = appt.GetInvoice(testAppDB)
+		if assert.NoError(t, err) && assert.NotNil(t, invoice) {
+			assert.Equal(t, 1000, invoice.DiscountTotal)
+			assert.Equal(t, 500, invoice.OriginalBalance)
+		}
+	}
+
+	_, err = book(firstUserID, laterYoga, "SPRING10")
+	assert.Equal(t, models.BookingRejectedPromoCodeLimit, rejectionCode(err))
+
+	// Redemptions of cancelled appointments don't count towards the code's limit
+	cancelled, err := book(secondUserID, yoga, "SPRING10")
+	assert.NoError(t, err)
+	_, err = cancelled.Cancel(testAppDB, cancelled.ID)
+	assert.NoError(t, err)
+
+	_, err = book(thirdUserID, laterSpin, "SPRING10")
+	assert.NoError(t, err)
+
+	_, err = book(fourthUserID, laterSpin, "SPRING10")
+	assert.Equal(t, models.BookingRejectedPromoCodeLimit, rejectionCode(err))
+
+	// The redemption report lists every redemption, but only counts those of appointments that weren't cancelled
+	report, err := spring.GetRedemptionReport(testAppDB, spring.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "SPRING10", report.PromoCode.Code)
+	assert.Equal(t, 2, report.RedemptionCount)
+	assert.Equal(t, 1, report.CancelledCount)
+	assert.Equal(t, 2, report.CustomerCount)
+	if assert.NotNil(t, report.RemainingRedemptions) {
+		assert.Equal(t, 0, *report.RemainingRedemptions)
+	}
+	if assert.Len(t, report.Totals, 1) {
+		assert.Equal(t, models.PromoCodeRedemptionTotal{Currency: "USD", RedemptionCount: 2, Discount: 2000}, report.Totals[0])
+	}
+	if assert.Len(t, report.Redemptions, 3) {
+		// Most recent first
+		assert.Equal(t, thirdUserID, report.Redemptions[0].UserID)
+		assert.Equal(t, "Spin", report.Redemptions[0].ServiceName)
+		assert.Equal(t, 1000, report.Redemptions[0].Discount)
+		assert.NotZero(t, report.Redemptions[0].InvoiceID)
+		assert.False(t, report.Redemptions[0].Cancelled)
+
+		assert.Equal(t, cancelled.ID, report.Redemptions[1].AppointmentID)
+		assert.Equal(t, models.AppointmentStatusCancelledByCustomer, report.Redemptions[1].AppointmentStatus)
+		assert.True(t, report.Redemptions[1].Cancelled)
+	}
+
+	// Deleted codes can't be used, but can still be reported
+	_, err = firstClass.Delete(testAppDB, firstClass.ID)
+	assert.NoError(t, err)
+
+	_, err = book(fourthUserID, laterYoga, "FIRST20")
+	assert.Equal(t, models.BookingRejectedPromoCodeNotFound, rejectionCode(err))
+
+	report, err = firstClass.GetRedemptionReport(testAppDB, firstClass.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.RedemptionCount)
+	assert.Nil(t, report.RemainingRedemptions)
+}
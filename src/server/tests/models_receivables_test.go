@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"server/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestReceivablesReport
+
+Tests the GetReceivablesReport method for the Business db object. Confirms that only issued invoices that are still owed are included, that each customer's balance is split into aging buckets by how long their invoices are past due, that balances in different currencies are reported and totaled separately, that a detailed report lists the invoices behind each balance, that the report can be limited to one customer, and that the report is exported as CSV.
+*/
+func TestReceivablesReport(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	payer := &models.User{Email: "late.payer@gmail.com", Password: "pw123", AccountType: "User", FirstName: "Lee", LastName: "Payer"}
+	_, err := payer.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test User.  --  %s", err)
+	}
+
+	otherPayer := &models.User{Email: "sam.owes@gmail.com", Password: "pw123", AccountType: "User", FirstName: "Sam", LastName: "Owes"}
+	_, err = otherPayer.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test User.  --  %s", err)
+	}
+
+	business := &models.Business{OwnerID: 1, Name: "Patient Gator LLC"}
+	_, err = business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	otherBusiness := &models.Business{OwnerID: 1, Name: "Other Gator LLC"}
+	_, err = otherBusiness.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	// Whole seconds in UTC, so due dates are exact days apart after a round trip through the database
+	asOf := time.Now().UTC().Truncate(time.Second)
+	issue := func(businessID uint, userID uint, currency string, balance int, daysAgo int) *models.Invoice {
+		invoice := &models.Invoice{UserID: userID, BusinessID: businessID, Currency: currency, OriginalBalance: balance}
+		_, err := invoice.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Invoice.  --  %s", err)
+		}
+
+		returnRecords, err := invoice.Issue(testAppDB, invoice.ID, asOf.AddDate(0, 0, -daysAgo))
+		if err != nil {
+			t.Fatalf("Could not issue test Invoice.  --  %s", err)
+		}
+
+		return returnRecords["invoice"].(*models.Invoice)
+	}
+
+	// Invoices are due 30 days after they are issued (the default payment terms)
+	issue(business.ID, payer.ID, "USD", 1000, 10)                    // Current
+	partlyPaid := issue(business.ID, payer.ID, "USD", 2000, 45)      // 15 days past due
+	issue(business.ID, payer.ID, "USD", 3000, 100)                   // 70 days past due
+	oldest := issue(business.ID, otherPayer.ID, "USD", 4000, 150)    // 120 days past due
+	euroInvoice := issue(business.ID, otherPayer.ID, "EUR", 500, 70) // 40 days past due
+
+	payment := &models.Payment{InvoiceID: partlyPaid.ID, Amount: 500, Method: models.PaymentMethodCash}
+	_, err = payment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	// Paid invoices, drafts and other businesses' invoices aren't owed
+	paid := issue(business.ID, payer.ID, "USD", 700, 50)
+	payment = &models.Payment{InvoiceID: paid.ID, Amount: 700, Method: models.PaymentMethodCash}
+	_, err = payment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	draft := &models.Invoice{UserID: payer.ID, BusinessID: business.ID, OriginalBalance: 9000}
+	_, err = draft.Create(testAppDB)
+	assert.NoError(t, err)
+
+	issue(otherBusiness.ID, payer.ID, "USD", 9000, 100)
+
+	report, err := business.GetReceivablesReport(testAppDB, business.ID, asOf, 0, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, business.ID, report.BusinessID)
+	assert.False(t, report.Detailed)
+	if assert.Len(t, report.Customers, 3) {
+		// Largest balance first
+		assert.Equal(t, payer.ID, report.Customers[0].UserID)
+		assert.Equal(t, "Lee", report.Customers[0].FirstName)
+		assert.Equal(t, "late.payer@gmail.com", report.Customers[0].Email)
+		assert.Equal(t, "USD", report.Customers[0].Currency)
+		assert.Equal(t, 3, report.Customers[0].InvoiceCount)
+		assert.Equal(t, models.AgingBuckets{Current: 1000, Days1To30: 1500, Days61To90: 3000, Total: 5500}, report.Customers[0].AgingBuckets)
+		assert.Nil(t, report.Customers[0].Invoices)
+
+		assert.Equal(t, otherPayer.ID, report.Customers[1].UserID)
+		assert.Equal(t, "USD", report.Customers[1].Currency)
+		assert.Equal(t, models.AgingBuckets{Over90: 4000, Total: 4000}, report.Customers[1].AgingBuckets)
+		if assert.NotNil(t, report.Customers[1].OldestDueAt) {
+			assert.WithinDuration(t, asOf.AddDate(0, 0, -120), *report.Customers[1].OldestDueAt, time.Second)
+		}
+
+		assert.Equal(t, otherPayer.ID, report.Customers[2].UserID)
+		assert.Equal(t, "EUR", report.Customers[2].Currency)
+		assert.Equal(t, models.AgingBuckets{Days31To60: 500, Total: 500}, report.Customers[2].AgingBuckets)
+	}
+
+	// Currencies are totaled separately
+	if assert.Len(t, report.Totals, 2) {
+		assert.Equal(t, "EUR", report.Totals[0].Currency)
+		assert.Equal(t, 1, report.Totals[0].CustomerCount)
+		assert.Equal(t, models.AgingBuckets{Days31To60: 500, Total: 500}, report.Totals[0].AgingBuckets)
+
+		assert.Equal(t, "USD", report.Totals[1].Currency)
+		assert.Equal(t, 2, report.Totals[1].CustomerCount)
+		assert.Equal(t, 4, report.Totals[1].InvoiceCount)
+		assert.Equal(t, models.AgingBuckets{Current: 1000, Days1To30: 1500, Days61To90: 3000, Over90: 4000, Total: 9500}, report.Totals[1].AgingBuckets)
+	}
+
+	file, err := report.CSV()
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(file)), "\n")
+	if assert.Len(t, lines, 6) {
+		assert.Equal(t, "Customer ID,Customer,Email,Currency,Invoices,Current,1-30,31-60,61-90,90+,Total", lines[0])
+		assert.Contains(t, lines[1], ",Lee Payer,late.payer@gmail.com,USD,3,10.00,15.00,0.00,30.00,0.00,55.00")
+		assert.Equal(t, ",Total,,EUR,1,0.00,0.00,5.00,0.00,0.00,5.00", lines[4])
+		assert.Equal(t, ",Total,,USD,4,10.00,15.00,0.00,30.00,40.00,95.00", lines[5])
+	}
+
+	// Detailed reports list the invoices behind each balance, oldest due first
+	report, err = business.GetReceivablesReport(testAppDB, business.ID, asOf, 0, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, report.Detailed)
+	if assert.Len(t, report.Customers, 3) {
+		invoices := report.Customers[0].Invoices
+		if assert.Len(t, invoices, 3) {
+			assert.Equal(t, models.Aging61To90, invoices[0].Bucket)
+			assert.Equal(t, 70, invoices[0].DaysPastDue)
+			assert.Equal(t, partlyPaid.ID, invoices[1].InvoiceID)
+			assert.Equal(t, models.Aging1To30, invoices[1].Bucket)
+			assert.Equal(t, 15, invoices[1].DaysPastDue)
+			assert.Equal(t, 1500, invoices[1].RemainingBalance)
+			assert.Equal(t, models.AgingCurrent, invoices[2].Bucket)
+			assert.Equal(t, 0, invoices[2].DaysPastDue)
+		}
+
+		if assert.Len(t, report.Customers[1].Invoices, 1) {
+			assert.Equal(t, oldest.ID, report.Customers[1].Invoices[0].InvoiceID)
+			assert.Equal(t, models.AgingOver90, report.Customers[1].Invoices[0].Bucket)
+		}
+
+		if assert.Len(t, report.Customers[2].Invoices, 1) {
+			assert.Equal(t, euroInvoice.ID, report.Customers[2].Invoices[0].InvoiceID)
+			assert.Equal(t, models.Aging31To60, report.Customers[2].Invoices[0].Bucket)
+		}
+	}
+
+	file, err = report.CSV()
+	assert.NoError(t, err)
+	lines = strings.Split(strings.TrimSpace(string(file)), "\n")
+	if assert.Len(t, lines, 6) {
+		assert.Equal(t, "Customer ID,Customer,Email,Currency,Invoice,Issued,Due,Days Past Due,Bucket,Balance", lines[0])
+		assert.Contains(t, lines[2], ",Lee Payer,late.payer@gmail.com,USD,"+partlyPaid.Number+",")
+		assert.True(t, strings.HasSuffix(lines[2], ",15,1-30,15.00"))
+	}
+
+	// Reports can be limited to one customer
+	report, err = business.GetReceivablesReport(testAppDB, business.ID, asOf, otherPayer.ID, true)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Customers, 2) {
+		assert.Equal(t, otherPayer.ID, report.Customers[0].UserID)
+		assert.Equal(t, otherPayer.ID, report.Customers[1].UserID)
+	}
+
+	// Aging moves forward with the report date
+	report, err = business.GetReceivablesReport(testAppDB, business.ID, asOf.AddDate(0, 0, 30), payer.ID, false)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Customers, 1) {
+		assert.Equal(t, models.AgingBuckets{Days1To30: 1000, Days31To60: 1500, Over90: 3000, Total: 5500}, report.Customers[0].AgingBuckets)
+	}
+}
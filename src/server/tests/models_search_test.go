@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestSearch
+
+Tests the Search method. Confirms that partly typed and stemmed words match service names and descriptions, that services are also
+found by their business's name but ranked below matches on their own name, that past services are excluded by default, that the date,
+price and availability filters narrow the results, that businesses are found by name with their number of upcoming services, and that
+empty queries and invalid filters are rejected.
+*/
+func TestSearch(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	createBusiness := func(name string) *models.Business {
+		business := &models.Business{OwnerID: 1, Name: name}
+		_, err := business.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Business.  --  %s", err)
+		}
+
+		return business
+	}
+
+	studio := createBusiness("Downward Dog Studio")
+	collective := createBusiness("Yoga Collective")
+
+	now := time.Now()
+	createService := func(business *models.Business, name string, description string, start time.Time, price uint, isFull bool) *models.Service {
+		service := &models.Service{
+			BusinessID:    business.ID,
+			Name:          name,
+			Description:   description,
+			StartDateTime: start,
+			Length:        60,
+			Capacity:      10,
+			Price:         price,
+			IsFull:        isFull,
+		}
+
+		_, err := service.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+
+		return service
+	}
+
+	yoga := createService(studio, "Yoga Class", "Beginner flow", now.Add(48*time.Hour), 2000, false)
+	createService(studio, "Power Yoga", "Advanced classes", now.Add(96*time.Hour), 3500, true)
+	createService(studio, "Yoga Class", "Last week's class", now.Add(-168*time.Hour), 2000, false)
+	createService(studio, "Spin Class", "High intensity", now.Add(72*time.Hour), 1500, false)
+	meditation := createService(collective, "Meditation", "Guided breathing", now.Add(24*time.Hour), 1000, false)
+
+	// Partly typed words match, and only upcoming services are found by default
+	results, err := models.Search(testAppDB, models.SearchOptions{Query: "yog"})
+	assert.NoError(t, err)
+	assert.Equal(t, "yog", results.Query)
+	assert.Equal(t, int64(3), results.ServiceCount, "Both upcoming yoga services and the Yoga Collective's service should be found.")
+	if assert.Len(t, results.Services, 3) {
+		assert.Equal(t, meditation.ID, results.Services[2].ID, "Matches on the business's name should rank below matches on the service's name.")
+		assert.Equal(t, "Yoga Collective", results.Services[2].BusinessName)
+	}
+
+	if assert.Len(t, results.Businesses, 1) {
+		assert.Equal(t, collective.ID, results.Businesses[0].ID)
+		assert.Equal(t, int64(1), results.Businesses[0].UpcomingServices)
+	}
+
+	// Words are stemmed and every word must match
+	results, err = models.Search(testAppDB, models.SearchOptions{Query: "yoga classes"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), results.ServiceCount, "\"classes\" should match both \"Class\" and \"classes\".")
+
+	results, err = models.Search(testAppDB, models.SearchOptions{Query: "beginner yoga"})
+	assert.NoError(t, err)
+	if assert.Len(t, results.Services, 1) {
+		assert.Equal(t, yoga.ID, results.Services[0].ID)
+	}
+
+	// Filters narrow the results
+	maxPrice := uint(2500)
+	results, err = models.Search(testAppDB, models.SearchOptions{Query: "yoga", MaxPrice: &maxPrice})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), results.ServiceCount)
+
+	results, err = models.Search(testAppDB, models.SearchOptions{Query: "yoga", AvailableOnly: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), results.ServiceCount, "Full services should be excluded.")
+
+	results, err = models.Search(testAppDB, models.SearchOptions{Query: "yoga", From: now.Add(-30 * 24 * time.Hour), To: now.Add(36 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), results.ServiceCount, "Last week's class and the Yoga Collective's service should be in the date range.")
+
+	results, err = models.Search(testAppDB, models.SearchOptions{Query: "pilates"})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ServiceSearchResult{}, results.Services, "Searches without matches should find no services instead of null.")
+	assert.Equal(t, []models.BusinessSearchResult{}, results.Businesses)
+
+	// Queries without words and invalid filters are rejected
+	_, err = models.Search(testAppDB, models.SearchOptions{Query: " & | ! "})
+	assert.ErrorIs(t, err, models.ErrInvalidSearch, "Text search operators shouldn't be treated as words.")
+
+	_, err = models.Search(testAppDB, models.SearchOptions{Query: "yoga", Limit: models.MaxSearchLimit + 1})
+	assert.ErrorIs(t, err, models.ErrInvalidSearch)
+
+	minPrice := uint(3000)
+	_, err = models.Search(testAppDB, models.SearchOptions{Query: "yoga", MinPrice: &minPrice, MaxPrice: &maxPrice})
+	assert.ErrorIs(t, err, models.ErrInvalidSearch)
+}
@@ -0,0 +1,306 @@
+package tests
+
+import (
+	"server/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestStoreCredit
+
+Tests the StoreCreditAccount db object and Store Credit payments. Confirms that goodwill credit and the surplus on an overpaid invoice are
+added to the user's balance with the invoice's Business, that store credit can pay an invoice but not for more than the user holds or
+more than the invoice's remaining balance, that refunds of store credit payments restore the credit, that other payments can be refunded
+as store credit, and that every change is recorded in the account's history.
+*/
+func TestStoreCredit(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	user := &models.User{Email: "credit.holder@gmail.com", Password: "pw123", AccountType: "User", FirstName: "Cal", LastName: "Holder"}
+	_, err := user.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test User.  --  %s", err)
+	}
+
+	business := &models.Business{OwnerID: 1, Name: "Generous Gator LLC"}
+	_, err = business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	createInvoice := func(amount int) *models.Invoice {
+		invoice := &models.Invoice{UserID: user.ID, BusinessID: business.ID, OriginalBalance: amount}
+		_, err := invoice.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Invoice.  --  %s", err)
+		}
+
+		_, err = invoice.Issue(testAppDB, invoice.ID, time.Now())
+		if err != nil {
+			t.Fatalf("Could not issue test Invoice.  --  %s", err)
+		}
+
+		return invoice
+	}
+
+	// Goodwill credit must be for a positive amount
+	account := &models.StoreCreditAccount{}
+	_, _, err = account.AddGoodwillCredit(testAppDB, user.ID, business.ID, 0, "", "Nothing")
+	assert.ErrorIs(t, err, models.ErrInvalidStoreCredit)
+
+	_, transaction, err := account.AddGoodwillCredit(testAppDB, user.ID, business.ID, 1500, "", "Sorry for the late start")
+	assert.NoError(t, err)
+	assert.Equal(t, 1500, account.Balance)
+	assert.Equal(t, "USD", account.Currency, "Store credit should default to the Business's currency.")
+	assert.Equal(t, models.StoreCreditGoodwill, transaction.Type)
+	assert.Equal(t, 1500, transaction.BalanceAfter)
+
+	// The surplus on an overpaid invoice is kept as store credit instead of refunded
+	overpaid := createInvoice(4000)
+	cashPayment := &models.Payment{InvoiceID: overpaid.ID, Amount: 5000, Method: models.PaymentMethodCash}
+	_, err = cashPayment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	notOverpaid := createInvoice(1000)
+	_, _, err = notOverpaid.CreditOverpayment(testAppDB, notOverpaid.ID)
+	assert.ErrorIs(t, err, models.ErrInvalidStoreCredit, "Only overpaid invoices have a surplus to keep.")
+
+	returnRecords, refunds, err := overpaid.CreditOverpayment(testAppDB, overpaid.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.InvoiceStatusPaid, returnRecords["invoice"].(*models.Invoice).Status)
+	if assert.Len(t, refunds, 1) {
+		assert.Equal(t, 1000, refunds[0].Amount)
+		assert.True(t, refunds[0].StoreCredit)
+	}
+
+	_, err = account.Get(testAppDB, account.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2500, account.Balance)
+
+	// Store credit pays invoices, up to the balance held and the amount owed
+	owed := createInvoice(3000)
+	tooMuch := &models.Payment{InvoiceID: owed.ID, Amount: 3000, Method: models.PaymentMethodStoreCredit}
+	_, err = tooMuch.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidStoreCredit, "Payments can't spend more store credit than the User holds.")
+
+	small := createInvoice(500)
+	overpay := &models.Payment{InvoiceID: small.ID, Amount: 600, Method: models.PaymentMethodStoreCredit}
+	_, err = overpay.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidPayment, "Store credit can't overpay an invoice.")
+
+	creditPayment := &models.Payment{InvoiceID: owed.ID, Amount: 2000, Method: models.PaymentMethodStoreCredit}
+	returnRecords, err = creditPayment.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, returnRecords["invoice"].(*models.Invoice).RemainingBalance)
+
+	_, err = account.Get(testAppDB, account.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, account.Balance)
+
+	// Refunds of store credit payments go back to the store credit
+	creditRefund := &models.Refund{PaymentID: creditPayment.ID, Amount: 800}
+	_, err = creditRefund.Create(testAppDB)
+	assert.NoError(t, err)
+
+	_, err = account.Get(testAppDB, account.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1300, account.Balance)
+
+	// Other payments can be refunded as store credit instead of money
+	cardPayment := &models.Payment{InvoiceID: small.ID, Amount: 500, Method: models.PaymentMethodCard}
+	_, err = cardPayment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	cardRefund := &models.Refund{PaymentID: cardPayment.ID, StoreCredit: true, Reason: "Changed plans"}
+	_, err = cardRefund.Create(testAppDB)
+	assert.NoError(t, err)
+
+	_, err = account.Get(testAppDB, account.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1800, account.Balance)
+
+	// Every change is recorded in the account's history
+	transactions, err := account.GetTransactions(testAppDB, account.ID)
+	assert.NoError(t, err)
+
+	var types []string
+	var total int
+	for _, transaction := range transactions {
+		types = append(types, transaction.Type)
+		total += transaction.Amount
+	}
+	assert.Equal(t, []string{
+		models.StoreCreditGoodwill,
+		models.StoreCreditOverpayment,
+		models.StoreCreditRedeemed,
+		models.StoreCreditRestored,
+		models.StoreCreditRefunded,
+	}, types)
+	assert.Equal(t, account.Balance, total, "The balance should match the account's history.")
+
+	// Balances can't be changed directly
+	_, err = account.Update(testAppDB, account.ID, map[string]interface{}{"balance": 100000})
+	assert.ErrorIs(t, err, models.ErrInvalidStoreCredit)
+}
+
+/*
+*Description*
+
+func TestGiftCards
+
+Tests the GiftCard db object and Gift Card payments. Confirms that gift cards are issued with a generated code and their initial balance,
+that sold gift cards are billed to the purchaser without tax and can't be spent until their invoice is paid, that codes are matched in
+any case and with or without dashes, that gift cards can only pay their own Business's invoices, can't be spent for more than their
+balance or after they expire, that refunds of gift card payments restore the balance, and that every change is recorded in the card's
+history.
+*/
+func TestGiftCards(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	purchaser := &models.User{Email: "gift.giver@gmail.com", Password: "pw123", AccountType: "User", FirstName: "Gil", LastName: "Giver"}
+	_, err := purchaser.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test User.  --  %s", err)
+	}
+
+	business := &models.Business{OwnerID: 1, Name: "Gifted Gator LLC"}
+	_, err = business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	otherBusiness := &models.Business{OwnerID: 1, Name: "Other Gator LLC"}
+	_, err = otherBusiness.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	createInvoice := func(businessID uint, amount int) *models.Invoice {
+		invoice := &models.Invoice{UserID: purchaser.ID, BusinessID: businessID, OriginalBalance: amount}
+		_, err := invoice.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Invoice.  --  %s", err)
+		}
+
+		_, err = invoice.Issue(testAppDB, invoice.ID, time.Now())
+		if err != nil {
+			t.Fatalf("Could not issue test Invoice.  --  %s", err)
+		}
+
+		return invoice
+	}
+
+	// Gift cards must be for a positive amount
+	invalid := &models.GiftCard{BusinessID: business.ID}
+	_, err = invalid.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidGiftCard)
+
+	// Sold gift cards are billed to the purchaser without tax
+	card := &models.GiftCard{BusinessID: business.ID, InitialAmount: 5000, PurchaserID: purchaser.ID, RecipientName: "Sam Lee"}
+	returnRecords, err := card.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test GiftCard.  --  %s", err)
+	}
+
+	assert.Regexp(t, `^[A-Z2-9]{4}-[A-Z2-9]{4}-[A-Z2-9]{4}-[A-Z2-9]{4}$`, card.Code)
+	assert.Equal(t, 5000, card.Balance)
+	saleInvoice := returnRecords["invoice"].(*models.Invoice)
+	assert.Equal(t, card.InvoiceID, saleInvoice.ID)
+	assert.Equal(t, 5000, saleInvoice.OriginalBalance)
+	assert.Equal(t, 0, saleInvoice.TaxTotal)
+
+	// Gift cards can't be spent until they are paid for, or pay for themselves
+	owed := createInvoice(business.ID, 3000)
+	unpaid := &models.Payment{InvoiceID: owed.ID, Amount: 1000, Method: models.PaymentMethodGiftCard, GiftCardCode: card.Code}
+	_, err = unpaid.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidGiftCard, "Gift cards can't be spent until they are paid for.")
+
+	selfPayment := &models.Payment{InvoiceID: saleInvoice.ID, Amount: 1000, Method: models.PaymentMethodGiftCard, GiftCardCode: card.Code}
+	_, err = selfPayment.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidGiftCard, "Gift cards can't pay for themselves.")
+
+	salePayment := &models.Payment{InvoiceID: saleInvoice.ID, Amount: 5000, Method: models.PaymentMethodCard}
+	_, err = salePayment.Create(testAppDB)
+	assert.NoError(t, err)
+
+	// Codes are matched in any case, with or without dashes
+	lookup := &models.GiftCard{}
+	_, err = lookup.GetByCode(testAppDB, " "+strings.ToLower(strings.ReplaceAll(card.Code, "-", ""))+" ")
+	assert.NoError(t, err)
+	assert.Equal(t, card.ID, lookup.ID)
+
+	// Gift cards only pay their own Business's invoices, up to their balance
+	otherInvoice := createInvoice(otherBusiness.ID, 1000)
+	otherPayment := &models.Payment{InvoiceID: otherInvoice.ID, Amount: 1000, Method: models.PaymentMethodGiftCard, GiftCardCode: card.Code}
+	_, err = otherPayment.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidGiftCard, "Gift cards can only be spent with the Business that issued them.")
+
+	unknown := &models.Payment{InvoiceID: owed.ID, Amount: 1000, Method: models.PaymentMethodGiftCard, GiftCardCode: "NOPE-NOPE-NOPE-NOPE"}
+	_, err = unknown.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidGiftCard)
+
+	cardPayment := &models.Payment{InvoiceID: owed.ID, Amount: 3000, Method: models.PaymentMethodGiftCard, GiftCardCode: strings.ToLower(card.Code)}
+	returnRecords, err = cardPayment.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.Equal(t, card.ID, cardPayment.GiftCardID)
+	assert.Equal(t, models.InvoiceStatusPaid, returnRecords["invoice"].(*models.Invoice).Status)
+
+	bigInvoice := createInvoice(business.ID, 10000)
+	overspend := &models.Payment{InvoiceID: bigInvoice.ID, Amount: 2500, Method: models.PaymentMethodGiftCard, GiftCardCode: card.Code}
+	_, err = overspend.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidGiftCard, "Gift cards can't be spent for more than their balance.")
+
+	// Refunds of gift card payments restore the balance
+	refund := &models.Refund{PaymentID: cardPayment.ID, Amount: 1000}
+	_, err = refund.Create(testAppDB)
+	assert.NoError(t, err)
+
+	_, err = card.Get(testAppDB, card.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3000, card.Balance)
+
+	// Expired gift cards can't be spent
+	expired := time.Now().Add(-time.Hour)
+	_, err = card.Update(testAppDB, card.ID, map[string]interface{}{"expires_at": expired})
+	assert.NoError(t, err)
+
+	lateSpend := &models.Payment{InvoiceID: bigInvoice.ID, Amount: 1000, Method: models.PaymentMethodGiftCard, GiftCardCode: card.Code}
+	_, err = lateSpend.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidGiftCard, "Expired gift cards can't be spent.")
+
+	// The code and amounts can't be changed
+	_, err = card.Update(testAppDB, card.ID, map[string]interface{}{"balance": 100000})
+	assert.ErrorIs(t, err, models.ErrInvalidGiftCard)
+
+	// Every change is recorded in the card's history
+	transactions, err := card.GetTransactions(testAppDB, card.ID)
+	assert.NoError(t, err)
+
+	var types []string
+	for _, transaction := range transactions {
+		types = append(types, transaction.Type)
+	}
+	assert.Equal(t, []string{models.GiftCardIssued, models.GiftCardRedeemed, models.GiftCardRestored}, types)
+	if assert.Len(t, transactions, 3) {
+		assert.Equal(t, 3000, transactions[2].BalanceAfter)
+	}
+
+	// Gift cards given away by the Business can be spent straight away
+	giveaway := &models.GiftCard{BusinessID: business.ID, InitialAmount: 1000}
+	returnRecords, err = giveaway.Create(testAppDB)
+	assert.NoError(t, err)
+	assert.NotContains(t, returnRecords, "invoice")
+	assert.NotEqual(t, card.Code, giveaway.Code)
+
+	giveawayPayment := &models.Payment{InvoiceID: bigInvoice.ID, Amount: 1000, Method: models.PaymentMethodGiftCard, GiftCardCode: giveaway.Code}
+	_, err = giveawayPayment.Create(testAppDB)
+	assert.NoError(t, err)
+}
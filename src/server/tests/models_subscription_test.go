@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"errors"
+	"server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+/*
+*Description*
+
+func TestSubscriptionBilling
+
+Tests the Start, BillDueSubscriptions, Pause, Resume, and Cancel methods for the Subscription db object. Confirms that starting a membership invoices the first billing period, that the billing job invoices each period once (catching up on missed periods), that paused and cancelled subscriptions are not billed, and that resuming extends the paid period by the time spent paused.
+*/
+func TestSubscriptionBilling(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	var userID uint = 69
+	start := time.Date(2023, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	plan := &models.MembershipPlan{BusinessID: 128, Name: "Monthly", Price: 9900, BillingInterval: models.BillingIntervalMonthly}
+	_, err := plan.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test MembershipPlan.  --  %s", err)
+	}
+
+	invalidPlan := &models.MembershipPlan{BusinessID: 128, BillingInterval: "Fortnightly"}
+	_, err = invalidPlan.Create(testAppDB)
+	assert.True(t, errors.Is(err, models.ErrInvalidMembershipPlan), "Invalid billing intervals should be rejected.")
+
+	// Starting a membership invoices the first period
+	sub := models.Subscription{}
+	returnRecords, err := sub.Start(testAppDB, plan.ID, userID, start, start)
+	if err != nil {
+		t.Fatalf("Could not start test Subscription.  --  %s", err)
+	}
+
+	invoice := returnRecords["invoice"].(*models.Invoice)
+	assert.Equal(t, 9900, invoice.OriginalBalance)
+	assert.Equal(t, userID, invoice.UserID)
+	assert.True(t, sub.CurrentPeriodEnd.Equal(start.AddDate(0, 1, 0)), "First billing period should last one month.")
+
+	// Billing job does nothing until the period ends, then catches up on every missed period
+	invoices, err := sub.BillDueSubscriptions(testAppDB, start.AddDate(0, 0, 15))
+	assert.NoError(t, err)
+	assert.Empty(t, invoices)
+
+	invoices, err = sub.BillDueSubscriptions(testAppDB, start.AddDate(0, 2, 1))
+	assert.NoError(t, err)
+	assert.Len(t, invoices, 2, "Billing job should invoice both missed periods.")
+
+	invoices, err = sub.BillDueSubscriptions(testAppDB, start.AddDate(0, 2, 1))
+	assert.NoError(t, err)
+	assert.Empty(t, invoices, "Billing job should not invoice the same period twice.")
+
+	subInvoices, err := sub.GetInvoices(testAppDB, sub.ID)
+	assert.NoError(t, err)
+	assert.Len(t, subInvoices, 3)
+
+	// Paused subscriptions are not billed, and resuming extends the paid period
+	pauseTime := start.AddDate(0, 2, 10)
+	returnRecords, err = sub.Pause(testAppDB, sub.ID, pauseTime)
+	assert.NoError(t, err)
+	assert.Equal(t, models.SubscriptionStatusPaused, returnRecords["subscription"].(*models.Subscription).Status)
+
+	invoices, err = sub.BillDueSubscriptions(testAppDB, start.AddDate(0, 4, 0))
+	assert.NoError(t, err)
+	assert.Empty(t, invoices, "Paused subscriptions should not be billed.")
+
+	_, err = sub.Pause(testAppDB, sub.ID, pauseTime)
+	assert.True(t, errors.Is(err, models.ErrInvalidSubscriptionStatusTransition), "Paused subscriptions cannot be paused again.")
+
+	resumeTime := pauseTime.AddDate(0, 0, 5)
+	returnRecords, err = sub.Resume(testAppDB, sub.ID, resumeTime)
+	assert.NoError(t, err)
+
+	resumedSub := returnRecords["subscription"].(*models.Subscription)
+	assert.Equal(t, models.SubscriptionStatusActive, resumedSub.Status)
+	assert.True(t, resumedSub.CurrentPeriodEnd.Equal(start.AddDate(0, 3, 0).AddDate(0, 0, 5)), "Resuming should extend the paid period by the time spent paused.")
+	assert.Nil(t, returnRecords["invoice"], "Resuming inside the paid period should not generate an invoice.")
+
+	// Cancelled subscriptions are no longer billed
+	_, err = sub.Cancel(testAppDB, sub.ID, resumeTime)
+	assert.NoError(t, err)
+
+	invoices, err = sub.BillDueSubscriptions(testAppDB, start.AddDate(1, 0, 0))
+	assert.NoError(t, err)
+	assert.Empty(t, invoices, "Cancelled subscriptions should not be billed.")
+
+	_, err = sub.Resume(testAppDB, sub.ID, resumeTime)
+	assert.True(t, errors.Is(err, models.ErrInvalidSubscriptionStatusTransition), "Cancelled subscriptions cannot be resumed.")
+}
+
+/*
+*Description*
+
+func TestSubscriptionEntitlement
+
+Tests the UseEntitlement method for the Subscription db object through Appointment.Book. Confirms that a membership covers bookings for included Services until the plan's visit limit for the billing period is reached, that Services that are not included are not covered, that cancelled appointments free up a visit, and that paused memberships do not cover bookings.
+*/
+func TestSubscriptionEntitlement(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	var businessID uint = 128
+	var userID uint = 69
+	now := time.Now()
+
+	var serviceIDs []uint
+	for i, serviceName := range []string{"Yoga", "Yoga", "Yoga", "Pottery"} {
+		testService := &models.Service{
+			BusinessID:    businessID,
+			Name:          serviceName,
+			StartDateTime: now.Add(time.Duration(i+1) * time.Hour),
+			Length:        30,
+			Capacity:      20,
+			Price:         2000,
+		}
+
+		returnRecords, err := testService.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+		serviceIDs = append(serviceIDs, returnRecords["service"].GetID())
+	}
+
+	plan := &models.MembershipPlan{BusinessID: businessID, Name: "2 yoga classes a week", Price: 3000, BillingInterval: models.BillingIntervalWeekly, VisitsPerPeriod: 2}
+	_, err := plan.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test MembershipPlan.  --  %s", err)
+	}
+
+	err = plan.SetIncludedServices(testAppDB, plan.ID, []string{"Yoga"})
+	if err != nil {
+		t.Fatalf("Could not set included services for test MembershipPlan.  --  %s", err)
+	}
+
+	sub := models.Subscription{}
+	_, err = sub.Start(testAppDB, plan.ID, userID, now.Add(-time.Minute), now)
+	if err != nil {
+		t.Fatalf("Could not start test Subscription.  --  %s", err)
+	}
+
+	countVisits := func() int64 {
+		visits, err := sub.CountVisits(testAppDB, sub.CurrentPeriodStart, sub.CurrentPeriodEnd)
+		assert.NoError(t, err)
+		return visits
+	}
+
+	var apptIDs []uint
+	for _, serviceID := range serviceIDs {
+		testAppointment := &models.Appointment{UserID: userID, ServiceID: serviceID}
+		returnRecords, err := testAppointment.Book(testAppDB, now, nil)
+		if err != nil {
+			t.Fatalf("Could not book test Appointment.  --  %s", err)
+		}
+		apptIDs = append(apptIDs, returnRecords["appointment"].GetID())
+	}
+
+	assert.Equal(t, int64(2), countVisits(), "Only the plan's included visits for the period should be covered, and only for included Services.")
+
+	// Cancelling a covered appointment frees up a visit
+	appt := models.Appointment{}
+	_, err = appt.Cancel(testAppDB, apptIDs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), countVisits())
+
+	_, err = appt.Cancel(testAppDB, apptIDs[2])
+	assert.NoError(t, err)
+
+	// Paused memberships do not cover bookings
+	_, err = sub.Pause(testAppDB, sub.ID, now)
+	assert.NoError(t, err)
+
+	rebookedAppointment := &models.Appointment{UserID: userID, ServiceID: serviceIDs[0]}
+	_, err = rebookedAppointment.Book(testAppDB, now, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), countVisits(), "Paused memberships should not cover bookings.")
+}
+
+/*
+*Description*
+
+func TestCalculateProration
+
+Tests the CalculateProration method. Confirms that changing plans part-way through a billing period credits the unused part of the old plan and charges the rest of the period on the new plan (rounded to the nearest cent), and that changing to a plan with a different billing interval starts a new billing period.
+*/
+func TestCalculateProration(t *testing.T) {
+	periodStart := time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)
+	sub := &models.Subscription{
+		CurrentPeriodStart: periodStart,
+		CurrentPeriodEnd:   periodStart.AddDate(0, 1, 0),
+	}
+
+	monthly := &models.MembershipPlan{Model: gorm.Model{ID: 1}, Price: 9900, BillingInterval: models.BillingIntervalMonthly}
+	premium := &models.MembershipPlan{Model: gorm.Model{ID: 2}, Price: 14900, BillingInterval: models.BillingIntervalMonthly}
+	yearly := &models.MembershipPlan{Model: gorm.Model{ID: 3}, Price: 99900, BillingInterval: models.BillingIntervalYearly}
+
+	// One third of the 30 day period is left
+	changeTime := periodStart.AddDate(0, 0, 20)
+	proration := models.CalculateProration(sub, monthly, premium, changeTime)
+	assert.Equal(t, 3300, proration.UnusedCredit)
+	assert.Equal(t, 4967, proration.NewPlanCharge, "Prorated charge should be rounded to the nearest cent.")
+	assert.Equal(t, 1667, proration.Amount)
+	assert.True(t, proration.PeriodEnd.Equal(sub.CurrentPeriodEnd), "Plans with the same interval should keep the billing period.")
+
+	// Downgrades produce a credit
+	proration = models.CalculateProration(sub, premium, monthly, changeTime)
+	assert.Equal(t, -1667, proration.Amount)
+
+	// A different billing interval starts a new period at the full price
+	proration = models.CalculateProration(sub, monthly, yearly, changeTime)
+	assert.Equal(t, 99900-3300, proration.Amount)
+	assert.True(t, proration.PeriodStart.Equal(changeTime))
+	assert.True(t, proration.PeriodEnd.Equal(changeTime.AddDate(1, 0, 0)))
+
+	// Subscriptions that have not been billed yet have nothing to prorate
+	unbilledSub := &models.Subscription{CurrentPeriodStart: periodStart, CurrentPeriodEnd: periodStart}
+	proration = models.CalculateProration(unbilledSub, monthly, premium, changeTime)
+	assert.Equal(t, 0, proration.Amount)
+}
@@ -0,0 +1,339 @@
+package tests
+
+import (
+	"server/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestTaxRates
+
+Tests the TaxRate db object and the sales tax charged on invoices. Confirms that invalid and duplicate rates are rejected, that a
+Business is taxed at the rate of its explicit jurisdiction, or else at the rate for its zip code, or else at the rate for its state, that
+jurisdictions in use can't be deleted, and that appointment invoices are charged the Business's rate except for tax exempt Services.
+*/
+func TestTaxRates(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	createRate := func(rate *models.TaxRate) *models.TaxRate {
+		_, err := rate.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test TaxRate.  --  %s", err)
+		}
+
+		return rate
+	}
+
+	stateRate := createRate(&models.TaxRate{Jurisdiction: "tx", Name: "Texas sales tax", State: "tx", Rate: 625})
+	assert.Equal(t, "TX", stateRate.Jurisdiction)
+	assert.Equal(t, "TX", stateRate.State)
+
+	cityRate := createRate(&models.TaxRate{Jurisdiction: "TX-AUSTIN", Name: "Austin sales tax", State: "TX", ZipCode: " 78701 ", Rate: 825})
+	assert.Equal(t, "78701", cityRate.ZipCode)
+
+	specialRate := createRate(&models.TaxRate{Jurisdiction: "SPECIAL", Name: "Special district tax", Rate: 500})
+
+	// Rates need a jurisdiction, can't be more than 100%, and zip codes need a state
+	invalid := &models.TaxRate{Rate: 500}
+	_, err := invalid.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+
+	invalid = &models.TaxRate{Jurisdiction: "TOOMUCH", Rate: 10001}
+	_, err = invalid.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+
+	invalid = &models.TaxRate{Jurisdiction: "NOSTATE", ZipCode: "78701", Rate: 500}
+	_, err = invalid.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+
+	// Jurisdictions are unique, and a state and zip code can only have one rate
+	duplicate := &models.TaxRate{Jurisdiction: "Tx", Rate: 600}
+	_, err = duplicate.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+
+	duplicate = &models.TaxRate{Jurisdiction: "TX-AUSTIN-2", State: "TX", ZipCode: "78701", Rate: 600}
+	_, err = duplicate.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+
+	// Updates must leave a valid rate
+	_, err = cityRate.Update(testAppDB, cityRate.ID, map[string]interface{}{"state": ""})
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+	_, err = cityRate.Get(testAppDB, cityRate.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "TX", cityRate.State, "An invalid update should be rolled back.")
+
+	createBusiness := func(business *models.Business) *models.Business {
+		business.OwnerID = 1
+		_, err := business.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Business.  --  %s", err)
+		}
+
+		return business
+	}
+
+	austin := createBusiness(&models.Business{Name: "Austin Gator LLC", State: "tx", ZipCode: "78701"})
+	dallas := createBusiness(&models.Business{Name: "Dallas Gator LLC", State: "TX", ZipCode: "75001"})
+	portland := createBusiness(&models.Business{Name: "Portland Gator LLC", State: "OR", ZipCode: "97201"})
+	special := createBusiness(&models.Business{Name: "Special Gator LLC", State: "TX", ZipCode: "78701", TaxJurisdiction: "special"})
+
+	// An explicit jurisdiction must have a tax rate
+	unknown := &models.Business{OwnerID: 1, Name: "Unknown Gator LLC", TaxJurisdiction: "NOWHERE"}
+	_, err = unknown.Create(testAppDB)
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+
+	// Explicit jurisdiction, then zip code, then state
+	for _, test := range []struct {
+		business     *models.Business
+		jurisdiction string
+	}{
+		{austin, "TX-AUSTIN"},
+		{dallas, "TX"},
+		{portland, ""},
+		{special, "SPECIAL"},
+	} {
+		rate, err := test.business.GetTaxRate(testAppDB)
+		assert.NoError(t, err)
+		if test.jurisdiction == "" {
+			assert.Nil(t, rate, "%s should not charge tax.", test.business.Name)
+		} else if assert.NotNil(t, rate, "%s should charge tax.", test.business.Name) {
+			assert.Equal(t, test.jurisdiction, rate.Jurisdiction, test.business.Name)
+		}
+	}
+
+	// Jurisdictions chosen by a Business can't be deleted or renamed
+	_, err = specialRate.Delete(testAppDB, specialRate.ID)
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+
+	_, err = specialRate.Update(testAppDB, specialRate.ID, map[string]interface{}{"jurisdiction": "SPECIAL-2"})
+	assert.ErrorIs(t, err, models.ErrInvalidTaxRate)
+
+	// Appointment invoices are charged the Business's rate, except for tax exempt Services
+	now := time.Now()
+	createService := func(name string, price uint, taxExempt bool) *models.Service {
+		service := &models.Service{
+			BusinessID:    austin.ID,
+			Name:          name,
+			StartDateTime: now.Add(48 * time.Hour),
+			Length:        60,
+			Capacity:      10,
+			Price:         price,
+			TaxExempt:     taxExempt,
+		}
+
+		_, err := service.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+
+		return service
+	}
+
+	yoga := createService("Yoga", 2000, false)
+	therapy := createService("Physical therapy", 3000, true)
+
+	appt := &models.Appointment{UserID: 69, ServiceID: yoga.ID, Seats: 1}
+	_, err = appt.Book(testAppDB, now, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	invoice, err := appt.GetInvoice(testAppDB)
+	if assert.NoError(t, err) && assert.NotNil(t, invoice) {
+		assert.Equal(t, 165, invoice.TaxTotal)
+		assert.Equal(t, 2165, invoice.OriginalBalance)
+
+		lineItems, err := invoice.GetLineItems(testAppDB, invoice.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, lineItems, 1) {
+			assert.Equal(t, uint(825), lineItems[0].TaxRate)
+			assert.Equal(t, "TX-AUSTIN", lineItems[0].TaxJurisdiction)
+		}
+	}
+
+	appt = &models.Appointment{UserID: 69, ServiceID: therapy.ID, Seats: 1}
+	_, err = appt.Book(testAppDB, now, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	invoice, err = appt.GetInvoice(testAppDB)
+	if assert.NoError(t, err) && assert.NotNil(t, invoice) {
+		assert.Equal(t, 0, invoice.TaxTotal)
+		assert.Equal(t, 3000, invoice.OriginalBalance)
+
+		lineItems, err := invoice.GetLineItems(testAppDB, invoice.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, lineItems, 1) {
+			assert.Equal(t, uint(0), lineItems[0].TaxRate)
+			assert.Equal(t, "TX-AUSTIN", lineItems[0].TaxJurisdiction, "Exempt sales should still be recorded in the jurisdiction.")
+		}
+	}
+
+	// A new rate only applies to invoices created afterwards
+	_, err = cityRate.Update(testAppDB, cityRate.ID, map[string]interface{}{"rate": 850})
+	assert.NoError(t, err)
+
+	appt = &models.Appointment{UserID: 70, ServiceID: yoga.ID, Seats: 1}
+	_, err = appt.Book(testAppDB, now, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	invoice, err = appt.GetInvoice(testAppDB)
+	if assert.NoError(t, err) && assert.NotNil(t, invoice) {
+		assert.Equal(t, 170, invoice.TaxTotal)
+	}
+
+	var taxTotals []int
+	err = testAppDB.Model(&models.Invoice{}).Where("business_id = ?", austin.ID).Order("id").Pluck("tax_total", &taxTotals).Error
+	assert.NoError(t, err)
+	assert.Equal(t, []int{165, 0, 170}, taxTotals, "Tax already charged should not change.")
+}
+
+/*
+*Description*
+
+func TestTaxReport
+
+Tests the GetTaxReport method for the Business db object. Confirms that the taxable sales, exempt sales and tax on the invoices issued in
+the period are reported by jurisdiction, that credit notes issued in the period take sales and tax back, that invoices without line items
+are reported as exempt sales outside any jurisdiction, that invoices issued outside the period and other businesses' invoices aren't
+included, and that the report is exported as CSV.
+*/
+func TestTaxReport(t *testing.T) {
+	// Refresh database to control testing environment
+	models.FormatAllTables(testAppDB)
+
+	rate := &models.TaxRate{Jurisdiction: "TX-AUSTIN", Name: "Austin sales tax", State: "TX", ZipCode: "78701", Rate: 825}
+	_, err := rate.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test TaxRate.  --  %s", err)
+	}
+
+	business := &models.Business{OwnerID: 1, Name: "Taxed Gator LLC", State: "TX", ZipCode: "78701"}
+	_, err = business.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	otherBusiness := &models.Business{OwnerID: 1, Name: "Other Gator LLC", State: "TX", ZipCode: "78701"}
+	_, err = otherBusiness.Create(testAppDB)
+	if err != nil {
+		t.Fatalf("Could not create test Business.  --  %s", err)
+	}
+
+	now := time.Now()
+	createService := func(businessID uint, name string, price uint, taxExempt bool) *models.Service {
+		service := &models.Service{
+			BusinessID:    businessID,
+			Name:          name,
+			StartDateTime: now.Add(48 * time.Hour),
+			Length:        60,
+			Capacity:      10,
+			Price:         price,
+			TaxExempt:     taxExempt,
+		}
+
+		_, err := service.Create(testAppDB)
+		if err != nil {
+			t.Fatalf("Could not create test Service.  --  %s", err)
+		}
+
+		return service
+	}
+
+	book := func(userID uint, service *models.Service) *models.Appointment {
+		appt := &models.Appointment{UserID: userID, ServiceID: service.ID, Seats: 1}
+		_, err := appt.Book(testAppDB, now, nil)
+		if err != nil {
+			t.Fatalf("Could not book test Appointment.  --  %s", err)
+		}
+
+		return appt
+	}
+
+	yoga := createService(business.ID, "Yoga", 2000, false)
+	therapy := createService(business.ID, "Physical therapy", 3000, true)
+	otherYoga := createService(otherBusiness.ID, "Yoga", 9000, false)
+
+	// Two taxable sales (2000 + 165 tax each) and one exempt sale, then one taxable sale is cancelled and credited in full
+	book(69, yoga)
+	cancelled := book(70, yoga)
+	book(69, therapy)
+	book(69, otherYoga)
+
+	_, err = cancelled.Cancel(testAppDB, cancelled.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Invoices without line items are exempt sales outside any jurisdiction
+	flatInvoice := &models.Invoice{UserID: 69, BusinessID: business.ID, OriginalBalance: 1000}
+	_, err = flatInvoice.Create(testAppDB)
+	assert.NoError(t, err)
+	_, err = flatInvoice.Issue(testAppDB, flatInvoice.ID, now)
+	assert.NoError(t, err)
+
+	// Invoices issued before the period aren't included
+	oldInvoice := &models.Invoice{UserID: 69, BusinessID: business.ID, OriginalBalance: 5000}
+	_, err = oldInvoice.Create(testAppDB)
+	assert.NoError(t, err)
+	_, err = oldInvoice.Issue(testAppDB, oldInvoice.ID, now.AddDate(0, -2, 0))
+	assert.NoError(t, err)
+
+	report, err := business.GetTaxReport(testAppDB, business.ID, now.Add(-time.Hour), now.Add(time.Hour))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, business.ID, report.BusinessID)
+	if assert.Len(t, report.Jurisdictions, 2) {
+		assert.Equal(t, "", report.Jurisdictions[0].Jurisdiction)
+		assert.Equal(t, 1, report.Jurisdictions[0].InvoiceCount)
+		assert.Equal(t, models.TaxAmounts{ExemptSales: 1000}, report.Jurisdictions[0].Net)
+
+		austin := report.Jurisdictions[1]
+		assert.Equal(t, "TX-AUSTIN", austin.Jurisdiction)
+		assert.Equal(t, "Austin sales tax", austin.Name)
+		assert.Equal(t, "USD", austin.Currency)
+		assert.Equal(t, 3, austin.InvoiceCount)
+		assert.Equal(t, 1, austin.CreditNoteCount)
+		assert.Equal(t, models.TaxAmounts{TaxableSales: 4000, ExemptSales: 3000, Tax: 330}, austin.Invoiced)
+		assert.Equal(t, models.TaxAmounts{TaxableSales: 2000, Tax: 165}, austin.Credited)
+		assert.Equal(t, models.TaxAmounts{TaxableSales: 2000, ExemptSales: 3000, Tax: 165}, austin.Net)
+	}
+
+	if assert.Len(t, report.Totals, 1) {
+		assert.Equal(t, "USD", report.Totals[0].Currency)
+		assert.Equal(t, 4, report.Totals[0].InvoiceCount)
+		assert.Equal(t, models.TaxAmounts{TaxableSales: 2000, ExemptSales: 4000, Tax: 165}, report.Totals[0].Net)
+	}
+
+	// Nothing was sold in an earlier period but the old invoice
+	report, err = business.GetTaxReport(testAppDB, business.ID, now.AddDate(0, -3, 0), now.AddDate(0, -1, 0))
+	if assert.NoError(t, err) && assert.Len(t, report.Jurisdictions, 1) {
+		assert.Equal(t, models.TaxAmounts{ExemptSales: 5000}, report.Jurisdictions[0].Net)
+	}
+
+	// CSV export has one row per jurisdiction and a total row per currency
+	report, err = business.GetTaxReport(testAppDB, business.ID, now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	file, err := report.CSV()
+	if assert.NoError(t, err) {
+		rows := strings.Split(strings.TrimSpace(string(file)), "\n")
+		if assert.Len(t, rows, 4) {
+			assert.True(t, strings.HasPrefix(rows[0], "Jurisdiction,Name,Currency,Invoices,Credit Notes,Taxable Sales"))
+			assert.Equal(t, "TX-AUSTIN,Austin sales tax,USD,3,1,40.00,30.00,3.30,20.00,0.00,1.65,20.00,30.00,1.65", rows[2])
+			assert.Equal(t, ",Total,USD,4,1,40.00,40.00,3.30,20.00,0.00,1.65,20.00,40.00,1.65", rows[3])
+		}
+	}
+}
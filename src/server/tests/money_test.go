@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"server/money"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestMoneyFormat
+
+Tests the Format method of the money package. Confirms that amounts are written with the locale's separators and symbol placement,
+that currencies without minor units are written without decimals, and that a currency's local symbol is only used in its home
+regions.
+*/
+func TestMoneyFormat(t *testing.T) {
+	const nbsp = "\u00a0"
+
+	assert.Equal(t, "$1,234.56", money.Money{Amount: 123456, Currency: "USD"}.Format("en-US"))
+	assert.Equal(t, "-$5.00", money.Money{Amount: -500, Currency: "USD"}.Format("en-US"))
+	assert.Equal(t, "$0.05", money.Money{Amount: 5, Currency: "USD"}.Format("en-US"))
+	assert.Equal(t, "1"+nbsp+"234,56"+nbsp+"$", money.Money{Amount: 123456, Currency: "CAD"}.Format("fr-CA"))
+	assert.Equal(t, "$1,234.56", money.Money{Amount: 123456, Currency: "CAD"}.Format("en-CA"))
+	assert.Equal(t, "US$1,234.56", money.Money{Amount: 123456, Currency: "USD"}.Format("en-CA"))
+	assert.Equal(t, "1.234,56"+nbsp+"€", money.Money{Amount: 123456, Currency: "EUR"}.Format("de-DE"))
+	assert.Equal(t, "€1,234.56", money.Money{Amount: 123456, Currency: "EUR"}.Format("en-IE"))
+	assert.Equal(t, "£12.50", money.Money{Amount: 1250, Currency: "GBP"}.Format("en-GB"))
+	assert.Equal(t, "¥1,234", money.Money{Amount: 1234, Currency: "JPY"}.Format("ja-JP"))
+
+	// Unsupported locales are formatted as the closest supported locale
+	assert.Equal(t, "$1,234.56", money.Money{Amount: 123456, Currency: "USD"}.Format("xx-YY"))
+	assert.Equal(t, "1"+nbsp+"234,56"+nbsp+"€", money.Money{Amount: 123456, Currency: "EUR"}.Format("fr-LU"))
+}
+
+/*
+*Description*
+
+func TestMoneyDecimal
+
+Tests the Decimal method of the money package. Confirms that amounts are written as plain decimal numbers in the currency's major
+unit, with the currency's number of decimals and no symbol or separators.
+*/
+func TestMoneyDecimal(t *testing.T) {
+	assert.Equal(t, "1234.56", money.Money{Amount: 123456, Currency: "USD"}.Decimal())
+	assert.Equal(t, "-5.00", money.Money{Amount: -500, Currency: "USD"}.Decimal())
+	assert.Equal(t, "0.05", money.Money{Amount: 5, Currency: "CAD"}.Decimal())
+	assert.Equal(t, "0.00", money.Money{Amount: 0, Currency: "EUR"}.Decimal())
+	assert.Equal(t, "1234", money.Money{Amount: 1234, Currency: "JPY"}.Decimal())
+}
+
+/*
+*Description*
+
+func TestParseLocale
+
+Tests the ParseLocale method of the money package. Confirms that the most preferred supported language in an 'Accept-Language'
+header is chosen, that unsupported regions fall back to the language's default locale, and that unsupported or missing languages
+fall back to the default locale.
+*/
+func TestParseLocale(t *testing.T) {
+	assert.Equal(t, "fr-CA", money.ParseLocale("fr-CA,fr;q=0.9,en;q=0.8"))
+	assert.Equal(t, "de-DE", money.ParseLocale("xx-YY,de;q=0.5"))
+	assert.Equal(t, "en-GB", money.ParseLocale("en;q=0.3, en-gb;q=0.8"))
+	assert.Equal(t, "fr-FR", money.ParseLocale("fr-LU"))
+	assert.Equal(t, "pt-PT", money.ParseLocale("pt_BR"))
+	assert.Equal(t, money.DefaultLocale, money.ParseLocale("fr;q=0, *"))
+	assert.Equal(t, money.DefaultLocale, money.ParseLocale(""))
+}
+
+/*
+*Description*
+
+func TestMoneyArithmetic
+
+Tests the New, Add and Sub methods of the money package. Confirms that currency codes are validated and normalized, and that
+amounts in different currencies can't be combined.
+*/
+func TestMoneyArithmetic(t *testing.T) {
+	price, err := money.New(5000, "cad")
+	assert.NoError(t, err)
+	assert.Equal(t, money.Money{Amount: 5000, Currency: "CAD"}, price)
+
+	_, err = money.New(5000, "XYZ")
+	assert.ErrorIs(t, err, money.ErrInvalidCurrency)
+
+	total, err := price.Add(money.Money{Amount: 1250, Currency: "CAD"})
+	assert.NoError(t, err)
+	assert.Equal(t, 6250, total.Amount)
+
+	change, err := total.Sub(money.Money{Amount: 250, Currency: "CAD"})
+	assert.NoError(t, err)
+	assert.Equal(t, 6000, change.Amount)
+
+	_, err = price.Add(money.Money{Amount: 1250, Currency: "USD"})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	_, err = price.Sub(money.Money{Amount: 1250, Currency: "EUR"})
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+
+	assert.Equal(t, 0, money.MinorUnits("JPY"))
+	assert.Equal(t, 2, money.MinorUnits("EUR"))
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"net/mail"
+	"server/notifications"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestFormatEmail
+
+Tests the FormatEmail method of the notifications package. Confirms that a message is formatted as a plain text email with the sender, recipient, encoded subject and date headers, and that the body is sent with CRLF line endings.
+*/
+func TestFormatEmail(t *testing.T) {
+	from := mail.Address{Name: "BizZen Billing", Address: "billing@example.com"}
+	message := notifications.Message{
+		To:      "late.payer@example.com",
+		ToName:  "Lee Payer",
+		Subject: "Invoice INV-000001 is due soon – thank you",
+		Body:    "Hello Lee,\n\nAmount due: $50.00\n",
+	}
+	date := time.Date(2023, time.March, 14, 9, 30, 0, 0, time.UTC)
+
+	email, err := notifications.FormatEmail(from, message, date)
+	if err != nil {
+		t.Fatalf("Could not format test email.  --  %s", err)
+	}
+
+	expected := "From: \"BizZen Billing\" <billing@example.com>\r\n" +
+		"To: \"Lee Payer\" <late.payer@example.com>\r\n" +
+		"Subject: =?utf-8?q?Invoice_INV-000001_is_due_soon_=E2=80=93_thank_you?=\r\n" +
+		"Date: Tue, 14 Mar 2023 09:30:00 +0000\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: 8bit\r\n" +
+		"\r\n" +
+		"Hello Lee,\r\n\r\nAmount due: $50.00\r\n\r\n"
+	assert.Equal(t, expected, string(email))
+
+	// Recipients must be valid email addresses
+	message.To = "not an address"
+	_, err = notifications.FormatEmail(from, message, date)
+	assert.ErrorIs(t, err, notifications.ErrInvalidMessage)
+}
+
+/*
+*Description*
+
+func TestMessageValidate
+
+Tests the Validate method for the Message object of the notifications package. Confirms that messages need a recipient and a subject, and that line breaks can't be used to add email headers.
+*/
+func TestMessageValidate(t *testing.T) {
+	valid := notifications.Message{To: "late.payer@example.com", Subject: "Invoice INV-000001 is overdue"}
+	assert.NoError(t, valid.Validate())
+
+	invalidMessages := map[string]notifications.Message{
+		"No recipient":            {Subject: "Invoice INV-000001 is overdue"},
+		"No subject":              {To: "late.payer@example.com"},
+		"Line break in recipient": {To: "late.payer@example.com\r\nBcc: everyone@example.com", Subject: "Overdue"},
+		"Line break in name":      {To: "late.payer@example.com", ToName: "Lee\nBcc: everyone@example.com", Subject: "Overdue"},
+		"Line break in subject":   {To: "late.payer@example.com", Subject: "Overdue\r\nBcc: everyone@example.com"},
+		"Blank recipient":         {To: "   ", Subject: "Overdue"},
+	}
+
+	for name, message := range invalidMessages {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, message.Validate(), notifications.ErrInvalidMessage)
+		})
+	}
+
+	// Messages that can't be sent aren't recorded
+	recorder := notifications.NewRecorder()
+	assert.ErrorIs(t, recorder.Send(invalidMessages["No subject"]), notifications.ErrInvalidMessage)
+	assert.NoError(t, recorder.Send(valid))
+	assert.Equal(t, []notifications.Message{valid}, recorder.Messages())
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"server/payments"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+*Description*
+
+func TestStripeVerifyWebhook
+
+Tests the VerifyWebhook method for the StripeProvider. Confirms that a signed delivery is verified and parsed into an Event, and that
+deliveries with a tampered payload, a stale timestamp, the wrong secret or no signature are rejected.
+*/
+func TestStripeVerifyWebhook(t *testing.T) {
+	fake := payments.NewFakeServer()
+	defer fake.Close()
+
+	signedAt := time.Date(2020, time.January, 1, 6, 0, 0, 0, time.UTC)
+	fake.Now = func() time.Time { return signedAt }
+	provider := fake.Provider()
+
+	payload := []byte(`{"id":"evt_1","object":"event","type":"payment_intent.succeeded","created":1577858400,"data":{"object":{"id":"pi_1","object":"payment_intent","amount":3000,"amount_received":3000,"currency":"usd","status":"succeeded","metadata":{"invoice_id":"123"}}}}`)
+
+	// Signed deliveries are verified and parsed
+	event, err := provider.VerifyWebhook(payload, fake.SignWebhook(payload, signedAt))
+	if err != nil {
+		t.Fatalf("Could not verify signed webhook.  --  %s", err)
+	}
+
+	assert.Equal(t, "evt_1", event.ID)
+	assert.Equal(t, "payment_intent.succeeded", event.Type)
+	assert.True(t, event.Created.Equal(signedAt))
+	assert.Nil(t, event.Refund)
+	if assert.NotNil(t, event.PaymentIntent) {
+		assert.Equal(t, "pi_1", event.PaymentIntent.ID)
+		assert.Equal(t, 3000, event.PaymentIntent.AmountReceived)
+		assert.Equal(t, payments.PaymentIntentStatusSucceeded, event.PaymentIntent.Status)
+		assert.Equal(t, "123", event.PaymentIntent.Metadata["invoice_id"])
+	}
+
+	// Tampered payloads are rejected
+	tampered := []byte(string(payload[:len(payload)-2]) + `,"amount_received":9999}}`)
+	_, err = provider.VerifyWebhook(tampered, fake.SignWebhook(payload, signedAt))
+	assert.ErrorIs(t, err, payments.ErrInvalidWebhookSignature, "Payloads that don't match their signature should be rejected.")
+
+	// Stale deliveries are rejected, so captured deliveries can't be replayed later
+	_, err = provider.VerifyWebhook(payload, fake.SignWebhook(payload, signedAt.Add(-10*time.Minute)))
+	assert.ErrorIs(t, err, payments.ErrInvalidWebhookSignature, "Deliveries signed long ago should be rejected.")
+
+	// Deliveries signed with another secret, or not signed at all, are rejected
+	otherProvider := payments.NewStripeProvider(fake.URL(), payments.FakeSecretKey, "whsec_other")
+	_, err = otherProvider.VerifyWebhook(payload, fake.SignWebhook(payload, time.Now()))
+	assert.ErrorIs(t, err, payments.ErrInvalidWebhookSignature, "Deliveries signed with another secret should be rejected.")
+
+	_, err = provider.VerifyWebhook(payload, nil)
+	assert.ErrorIs(t, err, payments.ErrInvalidWebhookSignature, "Unsigned deliveries should be rejected.")
+
+	// Providers without a webhook secret can't verify anything
+	unconfigured := payments.NewStripeProvider(fake.URL(), payments.FakeSecretKey, "")
+	_, err = unconfigured.VerifyWebhook(payload, fake.SignWebhook(payload, signedAt))
+	assert.ErrorIs(t, err, payments.ErrProviderNotConfigured)
+}
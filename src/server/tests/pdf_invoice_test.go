@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"server/pdf"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files in 'testdata' with the current output")
+
+/*
+*Description*
+
+func compareGolden
+
+Compares generated output against a golden file in the 'testdata' directory. The golden file is rewritten instead when the tests are
+run with the '-update' flag.
+*/
+func compareGolden(t *testing.T, fileName string, output []byte) {
+	t.Helper()
+
+	goldenPath := filepath.Join("testdata", fileName)
+	if *updateGolden {
+		err := os.WriteFile(goldenPath, output, 0644)
+		if err != nil {
+			t.Fatalf("Could not update golden file '%s'.  --  %s", goldenPath, err)
+		}
+	}
+
+	golden, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("Could not read golden file '%s'.  --  %s", goldenPath, err)
+	}
+
+	assert.True(t, bytes.Equal(golden, output), "Output should match golden file '%s' (run 'go test ./tests -run %s -update' after intended changes).", goldenPath, t.Name())
+}
+
+/*
+*Description*
+
+func TestRenderInvoice
+
+Tests the RenderInvoice method of the pdf package. Confirms that an invoice with line items, discounts, tax, payments and a refund
+renders to the same document every time and matches the golden file.
+*/
+func TestRenderInvoice(t *testing.T) {
+	invoice := &pdf.Invoice{
+		Number:        "1042",
+		BusinessName:  "Swamp Yoga (Gainesville)",
+		CustomerName:  "Albert Gator",
+		CustomerEmail: "albert@ufl.edu",
+		IssuedAt:      time.Date(2023, time.March, 1, 9, 30, 0, 0, time.UTC),
+		Status:        "Partially Paid",
+		LineItems: []pdf.LineItem{
+			{Description: "Yoga", Quantity: 2, UnitPrice: 2500, Tax: 413, Total: 5413},
+			{Description: "Mat rental", Quantity: 1, UnitPrice: 500, Discount: 500},
+			{Description: "Private lesson with a description that is much too long to fit in the description column", Quantity: 1, UnitPrice: 123456, Total: 123456},
+		},
+		Subtotal:      128956,
+		DiscountTotal: 500,
+		TaxTotal:      413,
+		Total:         128869,
+		Transactions: []pdf.LedgerEntry{
+			{Date: time.Date(2023, time.March, 2, 0, 0, 0, 0, time.UTC), Description: "Card payment (ch_123)", Amount: 5000},
+			{Date: time.Date(2023, time.March, 5, 0, 0, 0, 0, time.UTC), Description: "Refund of payment 7: Café closed", Amount: -1000},
+		},
+		AmountPaid: 4000,
+		BalanceDue: 124869,
+	}
+
+	document := pdf.RenderInvoice(invoice)
+	assert.True(t, bytes.Equal(document, pdf.RenderInvoice(invoice)), "Rendering the same invoice should produce the same document.")
+	assert.True(t, bytes.HasPrefix(document, []byte("%PDF-1.4")))
+	compareGolden(t, "invoice.pdf", document)
+
+	// Long invoices continue on more pages
+	for i := 0; i < 60; i++ {
+		invoice.LineItems = append(invoice.LineItems, pdf.LineItem{Description: "Drop-in class", Quantity: 1, UnitPrice: 1500, Total: 1500})
+	}
+	assert.True(t, bytes.Contains(pdf.RenderInvoice(invoice), []byte("/Count 3")), "Line items that don't fit should continue on new pages.")
+}
+
+/*
+*Description*
+
+func TestRenderReceipt
+
+Tests the RenderReceipt method of the pdf package. Confirms that a payment receipt renders to the same document every time and
+matches the golden file.
+*/
+func TestRenderReceipt(t *testing.T) {
+	receipt := &pdf.Receipt{
+		Number:        "7",
+		BusinessName:  "Swamp Yoga (Gainesville)",
+		CustomerName:  "Albert Gator",
+		CustomerEmail: "albert@ufl.edu",
+		InvoiceNumber: "1042",
+		PaidAt:        time.Date(2023, time.March, 2, 0, 0, 0, 0, time.UTC),
+		Method:        "Card",
+		Reference:     "ch_123",
+		Amount:        5000,
+		BalanceAfter:  123869,
+	}
+
+	document := pdf.RenderReceipt(receipt)
+	assert.True(t, bytes.Equal(document, pdf.RenderReceipt(receipt)), "Rendering the same receipt should produce the same document.")
+	compareGolden(t, "receipt.pdf", document)
+}
+
+/*
+*Description*
+
+func TestFormatMoney
+
+Tests the FormatMoney method of the pdf package. Confirms that amounts in cents are formatted as dollars with thousands separators.
+*/
+func TestFormatMoney(t *testing.T) {
+	assert.Equal(t, "$0.00", pdf.FormatMoney(0))
+	assert.Equal(t, "$0.05", pdf.FormatMoney(5))
+	assert.Equal(t, "$1,234.56", pdf.FormatMoney(123456))
+	assert.Equal(t, "$1,000,000.00", pdf.FormatMoney(100000000))
+	assert.Equal(t, "-$5.00", pdf.FormatMoney(-500))
+}
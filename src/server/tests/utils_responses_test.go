@@ -90,6 +90,26 @@ func TestRespondWithError(t *testing.T) {
 /*
 *Description*
 
+func TestRespondWithFile
+
+Tests the RespondWithFile method and ensures that the file is returned as-is with the expected content type and file name
+*/
+func TestRespondWithFile(t *testing.T) {
+	w := httptest.NewRecorder()
+	content := []byte("%PDF-1.4 test")
+
+	utils.RespondWithFile(w, http.StatusOK, "application/pdf", "invoice-123.pdf", content)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, `inline; filename="invoice-123.pdf"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, strconv.Itoa(len(content)), w.Header().Get("Content-Length"))
+	assert.Equal(t, content, w.Body.Bytes())
+}
+
+/*
+*Description*
+
 func TestParseRequestID
 
 Tests the ParseRequestID method to confirm that the ID field from the request URL is parsed into uint format and that the appropriate error is returned if the ID is missing or formatted incorrectly.
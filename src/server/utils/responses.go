@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -52,6 +53,48 @@ func RespondWithJSON(writer http.ResponseWriter, code int, payload interface{})
 /*
 *Description*
 
+func RespondWithFile
+
+Writes the contents of a file (e.g. a generated PDF document) as the HTTP response. The file is sent inline with the specified
+content type and file name, so browsers display it when they can and offer to save it otherwise.
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	code  <int>
+
+		The HTTP status code of the response.
+
+	contentType  <string>
+
+		The media type of the file (e.g. 'application/pdf').
+
+	fileName  <string>
+
+		The name the file is saved with.
+
+	content  <[]byte>
+
+		The contents of the file.
+
+*Returns*
+
+	None
+*/
+func RespondWithFile(writer http.ResponseWriter, code int, contentType string, fileName string, content []byte) {
+	writer.Header().Set("Content-Type", contentType)
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", fileName))
+	writer.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	writer.WriteHeader(code)
+	writer.Write(content)
+}
+
+/*
+*Description*
+
 func RespondWithJSON
 
 Takes a http.ResponseWriter, a HTTP status code and a message as input parameters. It formats the error message as a JSON object with a "error" field containing the message, and writes it to the ResponseWriter with the given status code.
@@ -85,6 +128,46 @@ func RespondWithError(writer http.ResponseWriter, code int, message string) {
 /*
 *Description*
 
+func RespondWithErrorCode
+
+Formats the error message as a JSON object with an "error" field containing the message and a "code" field containing a
+machine-readable reason code, and writes it to the ResponseWriter with the given HTTP status code.
+
+Used when clients need to distinguish between different failure reasons programmatically (e.g. rejected bookings).
+
+*Parameters*
+
+	writer  <http.ResponseWriter>
+
+		The HTTP response writer
+
+	code  <int>
+
+		The HTTP status code to be used in the error response.
+
+	reasonCode  <string>
+
+		Machine-readable reason code for the error.
+
+	message  <string>
+
+		Error message to be sent in the response.
+
+*Returns*
+
+	None
+*/
+func RespondWithErrorCode(writer http.ResponseWriter, code int, reasonCode string, message string) {
+	RespondWithJSON(
+		writer,
+		code,
+		map[string]string{"error": message, "code": reasonCode},
+	)
+}
+
+/*
+*Description*
+
 func ParseRequestID
 
 Helper method to parse the "id" variable present in the request and convert it to an unsigned integer.